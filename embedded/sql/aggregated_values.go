@@ -104,6 +104,47 @@ func (v *CountValue) selectorRanges(table *Table, asTable string, params map[str
 	return nil
 }
 
+// DistinctValue aggregates only the distinct non-null values of a column i.e. COUNT, SUM or AVG(DISTINCT col),
+// at most limit distinct values are kept per group, ErrTooManyRows is returned when exceeded
+type DistinctValue struct {
+	AggregatedValue
+	seen  map[string]struct{}
+	limit int
+}
+
+func (v *DistinctValue) ColBounded() bool {
+	return true
+}
+
+func (v *DistinctValue) updateWith(val TypedValue) error {
+	_, isNull := val.(*NullValue)
+	if isNull {
+		return nil
+	}
+
+	encVal, err := EncodeValue(val.Value(), val.Type(), 0)
+	if err != nil {
+		return err
+	}
+
+	_, seen := v.seen[string(encVal)]
+	if seen {
+		return nil
+	}
+
+	if v.seen == nil {
+		v.seen = make(map[string]struct{})
+	}
+
+	if v.limit > 0 && len(v.seen) == v.limit {
+		return ErrTooManyRows
+	}
+
+	v.seen[string(encVal)] = struct{}{}
+
+	return v.AggregatedValue.updateWith(val)
+}
+
 type SumValue struct {
 	s   int64
 	sel string
@@ -387,6 +428,15 @@ func (v *AVGValue) Type() SQLValueType {
 }
 
 func (v *AVGValue) Value() interface{} {
+	return v.avg()
+}
+
+func (v *AVGValue) avg() int64 {
+	// no value was aggregated, as it's the case when DISTINCT values are all NULL
+	if v.c == 0 {
+		return 0
+	}
+
 	return v.s / v.c
 }
 
@@ -395,7 +445,7 @@ func (v *AVGValue) Compare(val TypedValue) (int, error) {
 		return 0, ErrNotComparableValues
 	}
 
-	avg := v.s / v.c
+	avg := v.avg()
 	nv := val.Value().(int64)
 
 	if avg == nv {
@@ -457,3 +507,157 @@ func (v *AVGValue) isConstant() bool {
 func (v *AVGValue) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
 	return nil
 }
+
+type BoolAndValue struct {
+	b   bool
+	sel string
+}
+
+func (v *BoolAndValue) Selector() string {
+	return v.sel
+}
+
+func (v *BoolAndValue) ColBounded() bool {
+	return true
+}
+
+func (v *BoolAndValue) Type() SQLValueType {
+	return BooleanType
+}
+
+func (v *BoolAndValue) Value() interface{} {
+	return v.b
+}
+
+func (v *BoolAndValue) Compare(val TypedValue) (int, error) {
+	return (&Bool{val: v.b}).Compare(val)
+}
+
+func (v *BoolAndValue) updateWith(val TypedValue) error {
+	if _, isNull := val.(*NullValue); isNull {
+		return nil
+	}
+
+	if val.Type() != BooleanType {
+		return ErrNotComparableValues
+	}
+
+	v.b = v.b && val.Value().(bool)
+
+	return nil
+}
+
+// ValueExp
+
+func (v *BoolAndValue) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return BooleanType, nil
+}
+
+func (v *BoolAndValue) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != BooleanType {
+		return ErrNotComparableValues
+	}
+
+	return nil
+}
+
+func (v *BoolAndValue) jointColumnTo(col *Column, tableAlias string) (*ColSelector, error) {
+	return nil, ErrUnexpected
+}
+
+func (v *BoolAndValue) substitute(params map[string]interface{}) (ValueExp, error) {
+	return nil, ErrUnexpected
+}
+
+func (v *BoolAndValue) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return nil, ErrUnexpected
+}
+
+func (v *BoolAndValue) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return nil
+}
+
+func (v *BoolAndValue) isConstant() bool {
+	return false
+}
+
+func (v *BoolAndValue) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+type BoolOrValue struct {
+	b   bool
+	sel string
+}
+
+func (v *BoolOrValue) Selector() string {
+	return v.sel
+}
+
+func (v *BoolOrValue) ColBounded() bool {
+	return true
+}
+
+func (v *BoolOrValue) Type() SQLValueType {
+	return BooleanType
+}
+
+func (v *BoolOrValue) Value() interface{} {
+	return v.b
+}
+
+func (v *BoolOrValue) Compare(val TypedValue) (int, error) {
+	return (&Bool{val: v.b}).Compare(val)
+}
+
+func (v *BoolOrValue) updateWith(val TypedValue) error {
+	if _, isNull := val.(*NullValue); isNull {
+		return nil
+	}
+
+	if val.Type() != BooleanType {
+		return ErrNotComparableValues
+	}
+
+	v.b = v.b || val.Value().(bool)
+
+	return nil
+}
+
+// ValueExp
+
+func (v *BoolOrValue) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return BooleanType, nil
+}
+
+func (v *BoolOrValue) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != BooleanType {
+		return ErrNotComparableValues
+	}
+
+	return nil
+}
+
+func (v *BoolOrValue) jointColumnTo(col *Column, tableAlias string) (*ColSelector, error) {
+	return nil, ErrUnexpected
+}
+
+func (v *BoolOrValue) substitute(params map[string]interface{}) (ValueExp, error) {
+	return nil, ErrUnexpected
+}
+
+func (v *BoolOrValue) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return nil, ErrUnexpected
+}
+
+func (v *BoolOrValue) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return nil
+}
+
+func (v *BoolOrValue) isConstant() bool {
+	return false
+}
+
+func (v *BoolOrValue) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
@@ -78,6 +78,49 @@ func TestCountValue(t *testing.T) {
 	require.Nil(t, cval.selectorRanges(nil, "", nil, nil))
 }
 
+func TestDistinctValue(t *testing.T) {
+	values := []TypedValue{
+		&Number{val: 1},
+		&Number{val: 2},
+		&Number{val: 1},
+		&NullValue{t: IntegerType},
+		&Number{val: 6},
+	}
+
+	cval := &DistinctValue{AggregatedValue: &CountValue{sel: "(db1.table1.col1)"}}
+	require.Equal(t, "(db1.table1.col1)", cval.Selector())
+	require.True(t, cval.ColBounded())
+	require.Equal(t, IntegerType, cval.Type())
+
+	sval := &DistinctValue{AggregatedValue: &SumValue{sel: "(db1.table1.col1)"}}
+	aval := &DistinctValue{AggregatedValue: &AVGValue{sel: "(db1.table1.col1)"}}
+
+	require.Equal(t, int64(0), aval.Value())
+
+	for _, v := range values {
+		for _, agg := range []*DistinctValue{cval, sval, aval} {
+			err := agg.updateWith(v)
+			require.NoError(t, err)
+		}
+	}
+
+	require.Equal(t, int64(3), cval.Value())
+	require.Equal(t, int64(9), sval.Value())
+	require.Equal(t, int64(3), aval.Value())
+
+	t.Run("distinct values should be limited", func(t *testing.T) {
+		lval := &DistinctValue{AggregatedValue: &SumValue{sel: "(db1.table1.col1)"}, limit: 2}
+
+		for _, v := range values[:4] {
+			err := lval.updateWith(v)
+			require.NoError(t, err)
+		}
+
+		err := lval.updateWith(values[4])
+		require.ErrorIs(t, err, ErrTooManyRows)
+	})
+}
+
 func TestSumValue(t *testing.T) {
 	cval := &SumValue{sel: "db1.table1.amount"}
 	require.Equal(t, "db1.table1.amount", cval.Selector())
@@ -325,3 +368,123 @@ func TestAVGValue(t *testing.T) {
 
 	require.Nil(t, cval.selectorRanges(nil, "", nil, nil))
 }
+
+func TestBoolAndValue(t *testing.T) {
+	cval := &BoolAndValue{b: true, sel: "db1.table1.active"}
+	require.Equal(t, "db1.table1.active", cval.Selector())
+	require.True(t, cval.ColBounded())
+	require.Equal(t, BooleanType, cval.Type())
+
+	err := cval.updateWith(&Bool{val: true})
+	require.NoError(t, err)
+	require.Equal(t, true, cval.Value())
+
+	err = cval.updateWith(&NullValue{t: BooleanType})
+	require.NoError(t, err)
+	require.Equal(t, true, cval.Value())
+
+	err = cval.updateWith(&Number{val: 1})
+	require.ErrorIs(t, err, ErrNotComparableValues)
+
+	err = cval.updateWith(&Bool{val: false})
+	require.NoError(t, err)
+	require.Equal(t, false, cval.Value())
+
+	err = cval.updateWith(&Bool{val: true})
+	require.NoError(t, err)
+	require.Equal(t, false, cval.Value())
+
+	cmp, err := cval.Compare(&Bool{val: false})
+	require.NoError(t, err)
+	require.Equal(t, 0, cmp)
+
+	_, err = cval.Compare(&Number{val: 1})
+	require.ErrorIs(t, err, ErrNotComparableValues)
+
+	// ValueExp
+
+	sqlt, err := cval.inferType(nil, nil, "db1", "table1")
+	require.NoError(t, err)
+	require.Equal(t, BooleanType, sqlt)
+
+	err = cval.requiresType(BooleanType, nil, nil, "db1", "table1")
+	require.NoError(t, err)
+
+	err = cval.requiresType(IntegerType, nil, nil, "db1", "table1")
+	require.ErrorIs(t, err, ErrNotComparableValues)
+
+	_, err = cval.jointColumnTo(nil, "table1")
+	require.ErrorIs(t, err, ErrUnexpected)
+
+	_, err = cval.substitute(nil)
+	require.ErrorIs(t, err, ErrUnexpected)
+
+	_, err = cval.reduce(nil, nil, "db1", "table1")
+	require.ErrorIs(t, err, ErrUnexpected)
+
+	require.Nil(t, cval.reduceSelectors(nil, "db1", "table1"))
+
+	require.False(t, cval.isConstant())
+
+	require.Nil(t, cval.selectorRanges(nil, "", nil, nil))
+}
+
+func TestBoolOrValue(t *testing.T) {
+	cval := &BoolOrValue{sel: "db1.table1.active"}
+	require.Equal(t, "db1.table1.active", cval.Selector())
+	require.True(t, cval.ColBounded())
+	require.Equal(t, BooleanType, cval.Type())
+
+	err := cval.updateWith(&Bool{val: false})
+	require.NoError(t, err)
+	require.Equal(t, false, cval.Value())
+
+	err = cval.updateWith(&NullValue{t: BooleanType})
+	require.NoError(t, err)
+	require.Equal(t, false, cval.Value())
+
+	err = cval.updateWith(&Number{val: 1})
+	require.ErrorIs(t, err, ErrNotComparableValues)
+
+	err = cval.updateWith(&Bool{val: true})
+	require.NoError(t, err)
+	require.Equal(t, true, cval.Value())
+
+	err = cval.updateWith(&Bool{val: false})
+	require.NoError(t, err)
+	require.Equal(t, true, cval.Value())
+
+	cmp, err := cval.Compare(&Bool{val: false})
+	require.NoError(t, err)
+	require.Equal(t, 1, cmp)
+
+	_, err = cval.Compare(&Number{val: 1})
+	require.ErrorIs(t, err, ErrNotComparableValues)
+
+	// ValueExp
+
+	sqlt, err := cval.inferType(nil, nil, "db1", "table1")
+	require.NoError(t, err)
+	require.Equal(t, BooleanType, sqlt)
+
+	err = cval.requiresType(BooleanType, nil, nil, "db1", "table1")
+	require.NoError(t, err)
+
+	err = cval.requiresType(IntegerType, nil, nil, "db1", "table1")
+	require.ErrorIs(t, err, ErrNotComparableValues)
+
+	_, err = cval.jointColumnTo(nil, "table1")
+	require.ErrorIs(t, err, ErrUnexpected)
+
+	_, err = cval.substitute(nil)
+	require.ErrorIs(t, err, ErrUnexpected)
+
+	_, err = cval.reduce(nil, nil, "db1", "table1")
+	require.ErrorIs(t, err, ErrUnexpected)
+
+	require.Nil(t, cval.reduceSelectors(nil, "db1", "table1"))
+
+	require.False(t, cval.isConstant())
+
+	require.Nil(t, cval.selectorRanges(nil, "", nil, nil))
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+// AuditEntry describes a statement given as text to the engine or to a session,
+// parameter values are not included so they can not leak into audit logs
+type AuditEntry struct {
+	SQL    string
+	Params []string // names of the given parameters, sorted
+
+	UpdatedRows  int // rows changed by the executed statements
+	ReturnedRows int // rows read from the query before its reader was closed
+
+	Err error // error the statement failed with, if any
+}
+
+// AuditHook is called once per audited statement, after it's executed or, for queries, after the reader is closed
+type AuditHook func(entry *AuditEntry)
+
+func newAuditEntry(sql string, params map[string]interface{}) *AuditEntry {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return &AuditEntry{SQL: sql, Params: names}
+}
+
+func (e *Engine) execStmt(s *Session, sql string, params map[string]interface{}, waitForIndexing bool) (*ExecSummary, error) {
+	summary, err := e.exec(s, strings.NewReader(sql), params, waitForIndexing)
+
+	if e.auditHook != nil {
+		entry := newAuditEntry(sql, params)
+		entry.Err = err
+
+		if summary != nil {
+			entry.UpdatedRows = summary.UpdatedRows
+		}
+
+		e.auditHook(entry)
+	}
+
+	return summary, err
+}
+
+func (e *Engine) queryStmt(s *Session, sql string, params map[string]interface{}, renewSnapshot bool) (RowReader, error) {
+	r, err := e.query(s, strings.NewReader(sql), params, renewSnapshot)
+
+	if e.auditHook == nil {
+		return r, err
+	}
+
+	entry := newAuditEntry(sql, params)
+
+	if err != nil {
+		entry.Err = err
+		e.auditHook(entry)
+		return nil, err
+	}
+
+	return &auditedRowReader{rowReader: r, hook: e.auditHook, entry: entry}, nil
+}
+
+// auditedRowReader counts the rows read so to report them when it's closed
+type auditedRowReader struct {
+	rowReader RowReader
+
+	hook  AuditHook
+	entry *AuditEntry
+}
+
+func (ar *auditedRowReader) ImplicitDB() string {
+	return ar.rowReader.ImplicitDB()
+}
+
+func (ar *auditedRowReader) ImplicitTable() string {
+	return ar.rowReader.ImplicitTable()
+}
+
+func (ar *auditedRowReader) SetParameters(params map[string]interface{}) error {
+	return ar.rowReader.SetParameters(params)
+}
+
+func (ar *auditedRowReader) OrderBy() []ColDescriptor {
+	return ar.rowReader.OrderBy()
+}
+
+func (ar *auditedRowReader) ScanSpecs() *ScanSpecs {
+	return ar.rowReader.ScanSpecs()
+}
+
+func (ar *auditedRowReader) TxHeader() (*store.TxHeader, error) {
+	return ReaderTxHeader(ar.rowReader)
+}
+
+func (ar *auditedRowReader) Cursor() ([]byte, error) {
+	return ar.rowReader.Cursor()
+}
+
+func (ar *auditedRowReader) Columns() ([]ColDescriptor, error) {
+	return ar.rowReader.Columns()
+}
+
+func (ar *auditedRowReader) colsBySelector() (map[string]ColDescriptor, error) {
+	return ar.rowReader.colsBySelector()
+}
+
+func (ar *auditedRowReader) InferParameters(params map[string]SQLValueType) error {
+	return ar.rowReader.InferParameters(params)
+}
+
+func (ar *auditedRowReader) Read() (*Row, error) {
+	row, err := ar.rowReader.Read()
+	if err == nil {
+		ar.entry.ReturnedRows++
+	}
+	if err != nil && err != ErrNoMoreRows && ar.entry.Err == nil {
+		ar.entry.Err = err
+	}
+
+	return row, err
+}
+
+func (ar *auditedRowReader) Close() error {
+	err := ar.rowReader.Close()
+
+	if ar.entry != nil {
+		if ar.entry.Err == nil {
+			ar.entry.Err = err
+		}
+
+		ar.hook(ar.entry)
+
+		// closing the reader again must not duplicate the entry
+		ar.entry = nil
+	}
+
+	return err
+}
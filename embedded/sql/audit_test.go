@@ -0,0 +1,146 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"os"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditHook(t *testing.T) {
+	catalogStore, err := store.Open("catalog_audit", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_audit")
+
+	dataStore, err := store.Open("sqldata_audit", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_audit")
+
+	var entries []*AuditEntry
+
+	opts := DefaultOptions().
+		WithPrefix(sqlPrefix).
+		WithAuditHook(func(entry *AuditEntry) {
+			entries = append(entries, entry)
+		})
+
+	engine, err := NewEngine(catalogStore, dataStore, opts)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	entries = nil
+
+	t.Run("executed statements should be audited", func(t *testing.T) {
+		_, err = engine.ExecStmt(
+			"INSERT INTO table1 (id, title) VALUES (@id1, @title), (@id2, @title)",
+			map[string]interface{}{"title": "secret", "id2": 2, "id1": 1},
+			true,
+		)
+		require.NoError(t, err)
+
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (1, 'title1')", nil, true)
+		require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+
+		_, err = engine.ExecStmt("INSERT INTO", nil, true)
+		require.Error(t, err)
+
+		require.Len(t, entries, 3)
+
+		require.Equal(t, &AuditEntry{
+			SQL:         "INSERT INTO table1 (id, title) VALUES (@id1, @title), (@id2, @title)",
+			Params:      []string{"id1", "id2", "title"},
+			UpdatedRows: 2,
+		}, entries[0])
+
+		require.Equal(t, "INSERT INTO table1 (id, title) VALUES (1, 'title1')", entries[1].SQL)
+		require.ErrorIs(t, entries[1].Err, store.ErrKeyAlreadyExists)
+		require.Zero(t, entries[1].UpdatedRows)
+
+		require.Equal(t, "INSERT INTO", entries[2].SQL)
+		require.Error(t, entries[2].Err)
+
+		entries = nil
+	})
+
+	t.Run("queries should be audited when their reader is closed", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT id FROM table1 WHERE id > @id", map[string]interface{}{"id": 0}, true)
+		require.NoError(t, err)
+
+		_, err = r.Read()
+		require.NoError(t, err)
+
+		require.Empty(t, entries)
+
+		err = r.Close()
+		require.NoError(t, err)
+
+		err = r.Close()
+		require.Error(t, err)
+
+		require.Len(t, entries, 1)
+		require.Equal(t, &AuditEntry{
+			SQL:          "SELECT id FROM table1 WHERE id > @id",
+			Params:       []string{"id"},
+			ReturnedRows: 1,
+		}, entries[0])
+
+		_, _, err = engine.QueryAll("SELECT id FROM table1", nil)
+		require.NoError(t, err)
+
+		require.Len(t, entries, 2)
+		require.Equal(t, 2, entries[1].ReturnedRows)
+		require.NoError(t, entries[1].Err)
+
+		_, err = engine.QueryStmt("SELECT id FROM table2", nil, true)
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+		require.Len(t, entries, 3)
+		require.Equal(t, "SELECT id FROM table2", entries[2].SQL)
+		require.ErrorIs(t, entries[2].Err, ErrTableDoesNotExist)
+
+		entries = nil
+	})
+
+	t.Run("statements run in sessions should be audited", func(t *testing.T) {
+		session, err := engine.SessionWithDatabase("db1")
+		require.NoError(t, err)
+		defer session.Close()
+
+		_, err = session.ExecStmt("UPSERT INTO table1 (id, title) VALUES (3, 'title3')", nil, true)
+		require.NoError(t, err)
+
+		_, _, err = session.QueryAll("SELECT id FROM table1", nil)
+		require.NoError(t, err)
+
+		require.Len(t, entries, 2)
+		require.Equal(t, 1, entries[0].UpdatedRows)
+		require.Equal(t, 3, entries[1].ReturnedRows)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
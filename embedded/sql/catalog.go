@@ -15,12 +15,19 @@ limitations under the License.
 */
 package sql
 
-import "fmt"
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
 
 type Catalog struct {
 	dbsByID   map[uint32]*Database
 	dbsByName map[string]*Database
 
+	version uint64 // id of the last transaction where the catalog was changed
+
 	mutated bool
 }
 
@@ -30,6 +37,7 @@ type Database struct {
 	name         string
 	tablesByID   map[uint32]*Table
 	tablesByName map[string]*Table
+	maxTableID   uint32 // ids of dropped tables are not assigned again
 }
 
 type Table struct {
@@ -44,12 +52,15 @@ type Table struct {
 	primaryIndex    *Index
 	autoIncrementPK bool
 	maxPK           int64
+	nextIndexID     uint32 // ids of dropped indexes are not assigned again
+	comment         string
 }
 
 type Index struct {
 	table    *Table
 	id       uint32
 	unique   bool
+	nullable bool // NULL values are indexed, being distinct from each other
 	cols     []*Column
 	colsByID map[uint32]*Column
 }
@@ -62,6 +73,18 @@ type Column struct {
 	maxLen        int
 	autoIncrement bool
 	notNull       bool
+	defaultValue  ValueExp   // either a constant value or a function evaluated when the default is used, nil when not set
+	generatedAs   ValueExp   // the expression the value is computed from whenever the row is written, nil when not generated
+	missingValue  TypedValue // the value of the rows written before the column was added, nil when they hold NULL
+	missingTx     uint64     // the last transaction written before the column was added
+	prevTypes     []*prevColType
+	comment       string
+}
+
+// prevColType is a type a column had before ALTER COLUMN ... TYPE, rows written up to untilTx were encoded with it
+type prevColType struct {
+	colType SQLValueType
+	untilTx uint64
 }
 
 func newCatalog() *Catalog {
@@ -193,6 +216,11 @@ func (t *Table) Name() string {
 	return t.name
 }
 
+// Comment returns the comment set with COMMENT ON TABLE, empty when not set
+func (t *Table) Comment() string {
+	return t.comment
+}
+
 func (t *Table) PrimaryIndex() *Index {
 	return t.primaryIndex
 }
@@ -236,6 +264,11 @@ func (i *Index) IsUnique() bool {
 	return i.unique
 }
 
+// IsNullable returns true when the index accepts NULL values, which are distinct from each other
+func (i *Index) IsNullable() bool {
+	return i.nullable
+}
+
 func (i *Index) Cols() []*Column {
 	return i.cols
 }
@@ -245,21 +278,164 @@ func (i *Index) IncludesCol(colID uint32) bool {
 	return ok
 }
 
-func (i *Index) sortableUsing(colID uint32, rangesByColID map[uint32]*typedValueRange) bool {
-	// all columns before colID must be fixedValues otherwise the index can not be used
-	for _, col := range i.cols {
-		if col.id == colID {
-			return true
-		}
+// sortableUsing tells if rows read using the index are sorted by the columns, in the given order
+func (i *Index) sortableUsing(colIDs []uint32, rangesByColID map[uint32]*typedValueRange) bool {
+	pos := i.colsPos(colIDs)
+	if pos < 0 {
+		return false
+	}
 
+	// all columns before the first one must be fixedValues otherwise the index can not be used
+	for _, col := range i.cols[:pos] {
 		colRange, ok := rangesByColID[col.id]
-		if ok && colRange.unitary() {
+		if !ok || !colRange.unitary() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// colsPos returns the position of the first of the columns when they are consecutive columns of the index, -1 otherwise
+func (i *Index) colsPos(colIDs []uint32) int {
+	for pos, col := range i.cols {
+		if col.id != colIDs[0] {
 			continue
 		}
 
-		return false
+		if pos+len(colIDs) > len(i.cols) {
+			return -1
+		}
+
+		for k, colID := range colIDs {
+			if i.cols[pos+k].id != colID {
+				return -1
+			}
+		}
+
+		return pos
+	}
+
+	return -1
+}
+
+// rangeScore tells how much the scan over the index is narrowed by the ranges of the columns:
+// leading columns restricted to a single value add two points, the first column restricted to a range adds one
+// and an additional point is given to unique indexes with all their columns restricted to a single value
+func (i *Index) rangeScore(rangesByColID map[uint32]*typedValueRange) int {
+	score := 0
+
+	for _, col := range i.cols {
+		colRange, ok := rangesByColID[col.id]
+		if !ok {
+			return score
+		}
+
+		if !colRange.unitary() {
+			return score + 1
+		}
+
+		score += 2
+	}
+
+	if i.unique {
+		score++
+	}
+
+	return score
+}
+
+// mostSelectiveIndex returns the index whose scan is the most narrowed by the ranges of the columns,
+// the primary index is kept unless another one does better, unique indexes are preferred on ties
+func (t *Table) mostSelectiveIndex(rangesByColID map[uint32]*typedValueRange) *Index {
+	selected := t.primaryIndex
+	selectedScore := selected.rangeScore(rangesByColID)
+
+	indexes := make([]*Index, 0, len(t.indexes))
+	for _, index := range t.indexes {
+		indexes = append(indexes, index)
+	}
+
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i].id < indexes[j].id })
+
+	for _, index := range indexes {
+		score := index.rangeScore(rangesByColID)
+
+		if score > selectedScore || (score == selectedScore && score > 0 && index.unique && !selected.unique) {
+			selected = index
+			selectedScore = score
+		}
+	}
+
+	return selected
+}
+
+// schemaDigest returns a hash of the definition of the tables of the database,
+// every table is hashed in creation order together with its columns and indexes
+func (db *Database) schemaDigest() (d [sha256.Size]byte, err error) {
+	h := sha256.New()
+
+	// every field is prefixed by its length so to make the encoding unambiguous
+	writeField := func(b []byte) {
+		var bl [EncLenLen]byte
+		binary.BigEndian.PutUint32(bl[:], uint32(len(b)))
+		h.Write(bl[:])
+		h.Write(b)
 	}
-	return false
+
+	tables := db.GetTables()
+	sort.Slice(tables, func(i, j int) bool { return tables[i].id < tables[j].id })
+
+	for _, t := range tables {
+		writeField([]byte(t.name))
+		writeField(EncodeID(uint32(len(t.cols))))
+
+		for _, col := range t.cols {
+			writeField([]byte(col.colName))
+			writeField([]byte(col.colType))
+			writeField(EncodeID(uint32(col.MaxLen())))
+			writeField([]byte{boolFlag(col.notNull), boolFlag(col.autoIncrement)})
+
+			var encDefault []byte
+
+			if col.defaultValue != nil {
+				encDefault, err = encodeDefaultValue(col)
+				if err != nil {
+					return d, err
+				}
+			}
+
+			writeField(encDefault)
+		}
+
+		indexes := make([]*Index, 0, len(t.indexes))
+		for _, idx := range t.indexes {
+			indexes = append(indexes, idx)
+		}
+		sort.Slice(indexes, func(i, j int) bool { return indexes[i].id < indexes[j].id })
+
+		writeField(EncodeID(uint32(len(indexes))))
+
+		for _, idx := range indexes {
+			writeField([]byte{boolFlag(idx.unique)})
+			writeField(EncodeID(uint32(len(idx.cols))))
+
+			for _, col := range idx.cols {
+				writeField([]byte(col.colName))
+			}
+		}
+	}
+
+	copy(d[:], h.Sum(nil))
+
+	return
+}
+
+func boolFlag(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
 }
 
 func (i *Index) prefix() string {
@@ -274,6 +450,82 @@ func (i *Index) prefix() string {
 	return SIndexPrefix
 }
 
+// markers prefixing the values of nullable indexes, NULLs are encoded as zeroes thus sorted before any other value
+const (
+	nullKeyMarker    = byte(0)
+	notNullKeyMarker = byte(1)
+)
+
+// keyValLen returns the length of the encoded values of col as they are part of the keys of the index
+func (i *Index) keyValLen(col *Column) int {
+	n := col.MaxLen()
+
+	if variableSized(col.colType) {
+		n += EncLenLen
+	}
+
+	if i.nullable {
+		n++
+	}
+
+	return n
+}
+
+// encodeKeyValue encodes a non-null value of an indexed column as it's part of the keys of the index
+func (i *Index) encodeKeyValue(col *Column, val TypedValue) ([]byte, error) {
+	encVal, err := EncodeAsKey(val.Value(), col.colType, col.MaxLen())
+	if err != nil {
+		return nil, err
+	}
+
+	if !i.nullable {
+		return encVal, nil
+	}
+
+	return append([]byte{notNullKeyMarker}, encVal...), nil
+}
+
+// maxKeyValOf returns a value greater than any encoded value of the indexed column
+func (i *Index) maxKeyValOf(col *Column) []byte {
+	if !i.nullable {
+		return maxKeyValOf(col.colType)
+	}
+
+	return append([]byte{0xFF}, maxKeyValOf(col.colType)...)
+}
+
+// encodeKeyValues encodes the values of the indexed columns as they are part of the key of an index entry,
+// hasNulls is true when any of them is NULL, which is only accepted by nullable indexes
+func (i *Index) encodeKeyValues(valuesByColID map[uint32]TypedValue) (encVals [][]byte, hasNulls bool, err error) {
+	encVals = make([][]byte, len(i.cols))
+
+	for j, col := range i.cols {
+		val, notNull := valuesByColID[col.id]
+		if notNull {
+			_, isNull := val.(*NullValue)
+			notNull = !isNull
+		}
+
+		if !notNull {
+			if !i.nullable {
+				return nil, false, ErrIndexedColumnCanNotBeNull
+			}
+
+			encVals[j] = make([]byte, i.keyValLen(col))
+			encVals[j][0] = nullKeyMarker
+			hasNulls = true
+			continue
+		}
+
+		encVals[j], err = i.encodeKeyValue(col, val)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	return encVals, hasNulls, nil
+}
+
 func (db *Database) newTable(name string, colsSpec []*ColSpec) (table *Table, err error) {
 	if len(name) == 0 || len(colsSpec) == 0 {
 		return nil, ErrIllegalArguments
@@ -284,66 +536,102 @@ func (db *Database) newTable(name string, colsSpec []*ColSpec) (table *Table, er
 		return nil, ErrTableAlreadyExists
 	}
 
-	id := len(db.tablesByID) + 1
+	id := db.maxTableID + 1
 
 	table = &Table{
 		id:             uint32(id),
 		db:             db,
 		name:           name,
-		cols:           make([]*Column, len(colsSpec)),
+		cols:           make([]*Column, 0, len(colsSpec)),
 		colsByID:       make(map[uint32]*Column),
 		colsByName:     make(map[string]*Column),
 		indexes:        make(map[string]*Index),
 		indexesByColID: make(map[uint32][]*Index),
 	}
 
-	for i, cs := range colsSpec {
-		_, colExists := table.colsByName[cs.colName]
-		if colExists {
-			return nil, ErrDuplicatedColumn
-		}
-
-		if cs.autoIncrement && cs.colType != IntegerType {
-			return nil, ErrLimitedAutoIncrement
-		}
-
-		if cs.colType == TimestampType {
-			return nil, fmt.Errorf("%w (%v)", ErrNoSupported, TimestampType)
+	for _, cs := range colsSpec {
+		_, err := table.newColumn(cs)
+		if err != nil {
+			return nil, err
 		}
+	}
 
-		if !validMaxLenForType(cs.maxLen, cs.colType) {
-			return nil, ErrLimitedMaxLen
+	// generated columns may reference any other column, thus they are validated once all of them are known
+	for _, col := range table.cols {
+		if col.generatedAs == nil {
+			continue
 		}
 
-		id := len(table.colsByID) + 1
-
-		col := &Column{
-			id:            uint32(id),
-			table:         table,
-			colName:       cs.colName,
-			colType:       cs.colType,
-			maxLen:        cs.maxLen,
-			autoIncrement: cs.autoIncrement,
-			notNull:       cs.notNull,
+		err := validateGeneratedExp(col)
+		if err != nil {
+			return nil, err
 		}
-
-		table.cols[i] = col
-		table.colsByID[col.id] = col
-		table.colsByName[col.colName] = col
 	}
 
 	db.tablesByID[table.id] = table
 	db.tablesByName[table.name] = table
+	db.maxTableID = table.id
 	db.catalog.mutated = true
 
 	return table, nil
 }
 
-func (t *Table) newIndex(unique bool, colIDs []uint32) (index *Index, err error) {
+func (db *Database) dropTable(table *Table) {
+	delete(db.tablesByID, table.id)
+	delete(db.tablesByName, table.name)
+	db.catalog.mutated = true
+}
+
+// newColumn appends a column to the table, columns are identified by their position in the table
+func (t *Table) newColumn(cs *ColSpec) (*Column, error) {
+	_, colExists := t.colsByName[cs.colName]
+	if colExists {
+		return nil, ErrDuplicatedColumn
+	}
+
+	if cs.autoIncrement && cs.colType != IntegerType {
+		return nil, ErrLimitedAutoIncrement
+	}
+
+	if !validMaxLenForType(cs.maxLen, cs.colType) {
+		return nil, ErrLimitedMaxLen
+	}
+
+	defaultValue, err := validDefaultValue(cs)
+	if err != nil {
+		return nil, err
+	}
+
+	id := len(t.colsByID) + 1
+
+	col := &Column{
+		id:            uint32(id),
+		table:         t,
+		colName:       cs.colName,
+		colType:       cs.colType,
+		maxLen:        cs.maxLen,
+		autoIncrement: cs.autoIncrement,
+		notNull:       cs.notNull,
+		defaultValue:  defaultValue,
+		generatedAs:   cs.generatedAs,
+	}
+
+	t.cols = append(t.cols, col)
+	t.colsByID[col.id] = col
+	t.colsByName[col.colName] = col
+
+	return col, nil
+}
+
+func (t *Table) newIndex(unique, nullable bool, colIDs []uint32) (index *Index, err error) {
 	if len(colIDs) < 1 {
 		return nil, ErrIllegalArguments
 	}
 
+	if nullable && t.nextIndexID == PKIndexID {
+		return nil, ErrPKCanNotBeNull
+	}
+
 	// validate column ids
 	cols := make([]*Column, len(colIDs))
 	colsByID := make(map[uint32]*Column, len(colIDs))
@@ -359,6 +647,11 @@ func (t *Table) newIndex(unique bool, colIDs []uint32) (index *Index, err error)
 			return nil, ErrDuplicatedColumn
 		}
 
+		if col.generatedAs != nil && t.nextIndexID == PKIndexID {
+			// primary keys can not be updated while generated values follow the updates of other columns
+			return nil, fmt.Errorf("%w (%s can not be part of the primary key)", ErrIllegalGeneratedColumn, col.colName)
+		}
+
 		cols[i] = col
 		colsByID[colID] = col
 	}
@@ -371,14 +664,16 @@ func (t *Table) newIndex(unique bool, colIDs []uint32) (index *Index, err error)
 	}
 
 	index = &Index{
-		id:       uint32(len(t.indexes)),
+		id:       t.nextIndexID,
 		table:    t,
 		unique:   unique,
+		nullable: nullable,
 		cols:     cols,
 		colsByID: colsByID,
 	}
 
 	t.indexes[indexKey] = index
+	t.nextIndexID++
 
 	// having a direct way to get the indexes by colID
 	for _, col := range index.cols {
@@ -395,6 +690,29 @@ func (t *Table) newIndex(unique bool, colIDs []uint32) (index *Index, err error)
 	return index, nil
 }
 
+func (t *Table) dropIndex(index *Index) {
+	delete(t.indexes, indexKeyFrom(index.cols))
+
+	for _, col := range index.cols {
+		indexes := t.indexesByColID[col.id]
+
+		for i, idx := range indexes {
+			if idx == index {
+				indexes = append(indexes[:i], indexes[i+1:]...)
+				break
+			}
+		}
+
+		if len(indexes) == 0 {
+			delete(t.indexesByColID, col.id)
+		} else {
+			t.indexesByColID[col.id] = indexes
+		}
+	}
+
+	t.db.catalog.mutated = true
+}
+
 func (c *Column) ID() uint32 {
 	return c.id
 }
@@ -427,6 +745,70 @@ func (c *Column) IsAutoIncremental() bool {
 	return c.autoIncrement
 }
 
+// TypeAt returns the type values of the column were encoded with in the rows written at the given transaction,
+// it differs from the current type for rows written before the type of the column was altered
+func (c *Column) TypeAt(txID uint64) SQLValueType {
+	for _, prevType := range c.prevTypes {
+		if txID <= prevType.untilTx {
+			return prevType.colType
+		}
+	}
+
+	return c.colType
+}
+
+// Comment returns the comment set with COMMENT ON COLUMN, empty when not set
+func (c *Column) Comment() string {
+	return c.comment
+}
+
+// validDefaultValue returns the default value of the column, constant expressions are evaluated
+// while functions without arguments e.g. NOW() are kept to be evaluated every time the default is used
+func validDefaultValue(cs *ColSpec) (ValueExp, error) {
+	if cs.defaultValue == nil {
+		return nil, nil
+	}
+
+	if cs.autoIncrement {
+		return nil, fmt.Errorf("%w (auto-incremental columns can not have a default value)", ErrIllegalDefaultValue)
+	}
+
+	err := cs.defaultValue.requiresType(cs.colType, map[string]ColDescriptor{}, map[string]SQLValueType{}, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("%w (%v)", ErrIllegalDefaultValue, err)
+	}
+
+	fn, isFn := cs.defaultValue.(*SysFn)
+	if isFn && len(fn.params) == 0 {
+		return fn, nil
+	}
+
+	if !cs.defaultValue.isConstant() {
+		return nil, fmt.Errorf("%w (expecting a constant or a function without arguments)", ErrIllegalDefaultValue)
+	}
+
+	val, err := cs.defaultValue.reduce(nil, nil, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("%w (%v)", ErrIllegalDefaultValue, err)
+	}
+
+	_, isNull := val.(*NullValue)
+	if isNull {
+		if cs.notNull {
+			return nil, fmt.Errorf("%w (NOT NULL column with NULL default)", ErrIllegalDefaultValue)
+		}
+
+		return nil, nil
+	}
+
+	_, err = EncodeValue(val.Value(), cs.colType, cs.maxLen)
+	if err != nil {
+		return nil, fmt.Errorf("%w (%v)", ErrIllegalDefaultValue, err)
+	}
+
+	return val, nil
+}
+
 func validMaxLenForType(maxLen int, sqlType SQLValueType) bool {
 	switch sqlType {
 	case BooleanType:
@@ -0,0 +1,260 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"bytes"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+// Columns added to the rows returned by ChangesSince
+const (
+	ChangeTxCol = "_tx"
+	ChangeOpCol = "_op"
+)
+
+// Operation types reported in the ChangeOpCol column
+const (
+	ChangeInsert = "INSERT"
+	ChangeUpdate = "UPDATE"
+	ChangeDelete = "DELETE"
+)
+
+const changesHistoryPageSize = 100
+
+// changesRowReader reads the primary index entries of a table written within a range of transactions,
+// deleted rows are reported with the content they had before being deleted
+type changesRowReader struct {
+	e     *Engine
+	table *Table
+
+	txReader *store.TxReader
+	prevTx   *store.Tx
+	lastTxID uint64
+
+	prefix []byte
+
+	colsByPos []ColDescriptor
+	colsBySel map[string]ColDescriptor
+
+	pending []*Row
+}
+
+func (e *Engine) newChangesRowReader(table *Table, sinceTx, lastTxID uint64) (*changesRowReader, error) {
+	txReader, err := e.dataStore.NewTxReader(sinceTx+1, false, e.dataStore.NewTx())
+	if err != nil {
+		return nil, err
+	}
+
+	colsByPos := make([]ColDescriptor, len(table.Cols()), len(table.Cols())+2)
+
+	for i, c := range table.Cols() {
+		colsByPos[i] = ColDescriptor{
+			Database: table.db.name,
+			Table:    table.name,
+			Column:   c.colName,
+			Type:     c.colType,
+		}
+	}
+
+	colsByPos = append(colsByPos,
+		ColDescriptor{Database: table.db.name, Table: table.name, Column: ChangeTxCol, Type: IntegerType},
+		ColDescriptor{Database: table.db.name, Table: table.name, Column: ChangeOpCol, Type: VarcharType},
+	)
+
+	colsBySel := make(map[string]ColDescriptor, len(colsByPos))
+
+	for _, col := range colsByPos {
+		colsBySel[col.Selector()] = col
+	}
+
+	return &changesRowReader{
+		e:         e,
+		table:     table,
+		txReader:  txReader,
+		prevTx:    e.dataStore.NewTx(),
+		lastTxID:  lastTxID,
+		prefix:    e.mapKey(PIndexPrefix, EncodeID(table.db.id), EncodeID(table.id), EncodeID(PKIndexID)),
+		colsByPos: colsByPos,
+		colsBySel: colsBySel,
+	}, nil
+}
+
+func (r *changesRowReader) ImplicitDB() string {
+	return r.table.db.name
+}
+
+func (r *changesRowReader) ImplicitTable() string {
+	return r.table.name
+}
+
+// OrderBy returns no columns as rows are read in the order they were written
+func (r *changesRowReader) OrderBy() []ColDescriptor {
+	return nil
+}
+
+func (r *changesRowReader) ScanSpecs() *ScanSpecs {
+	return nil
+}
+
+func (r *changesRowReader) TxHeader() (*store.TxHeader, error) {
+	return r.e.txHeader(r.lastTxID)
+}
+
+// Cursor is not supported, changes are resumed from the transaction of the last change read
+func (r *changesRowReader) Cursor() ([]byte, error) {
+	return nil, ErrNoSupported
+}
+
+func (r *changesRowReader) Columns() ([]ColDescriptor, error) {
+	ret := make([]ColDescriptor, len(r.colsByPos))
+	copy(ret, r.colsByPos)
+	return ret, nil
+}
+
+func (r *changesRowReader) colsBySelector() (map[string]ColDescriptor, error) {
+	ret := make(map[string]ColDescriptor, len(r.colsBySel))
+	for sel := range r.colsBySel {
+		ret[sel] = r.colsBySel[sel]
+	}
+	return ret, nil
+}
+
+func (r *changesRowReader) InferParameters(params map[string]SQLValueType) error {
+	return nil
+}
+
+func (r *changesRowReader) SetParameters(params map[string]interface{}) error {
+	return nil
+}
+
+func (r *changesRowReader) Read() (*Row, error) {
+	for len(r.pending) == 0 {
+		if r.txReader.CurrTxID > r.lastTxID {
+			return nil, ErrNoMoreRows
+		}
+
+		tx, err := r.txReader.Read()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range tx.Entries() {
+			if !bytes.HasPrefix(entry.Key(), r.prefix) {
+				continue
+			}
+
+			row, err := r.changeOf(tx.Header().ID, entry)
+			if err != nil {
+				return nil, err
+			}
+
+			r.pending = append(r.pending, row)
+		}
+	}
+
+	row := r.pending[0]
+	r.pending = r.pending[1:]
+
+	return row, nil
+}
+
+func (r *changesRowReader) changeOf(txID uint64, entry *store.TxEntry) (*Row, error) {
+	key := entry.Key()
+
+	prevMD, prevVal, prevTxID, err := r.previousEntry(key, txID)
+	if err != nil && err != store.ErrKeyNotFound {
+		return nil, err
+	}
+
+	prevRowExists := err == nil && (prevMD == nil || !prevMD.Deleted())
+
+	var op string
+	var v []byte
+
+	// the row is decoded as it was written in the transaction holding it
+	vTxID := txID
+
+	if entry.Metadata() != nil && entry.Metadata().Deleted() {
+		if !prevRowExists {
+			return nil, ErrCorruptedData
+		}
+
+		op = ChangeDelete
+		v = prevVal
+		vTxID = prevTxID
+	} else {
+		op = ChangeInsert
+		if prevRowExists {
+			op = ChangeUpdate
+		}
+
+		v = make([]byte, entry.VLen())
+
+		_, err = r.e.dataStore.ReadValueAt(v, entry.VOff(), entry.HVal())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	row, err := decodeRow(r.table, r.table.name, v, vTxID)
+	if err != nil {
+		return nil, err
+	}
+
+	row.Values[EncodeSelector("", r.table.db.name, r.table.name, ChangeTxCol)] = &Number{val: int64(txID)}
+	row.Values[EncodeSelector("", r.table.db.name, r.table.name, ChangeOpCol)] = &Varchar{val: op}
+
+	return row, nil
+}
+
+// previousEntry returns the metadata and value the key had in the latest transaction preceding txID,
+// along with the id of that transaction
+func (r *changesRowReader) previousEntry(key []byte, txID uint64) (*store.KVMetadata, []byte, uint64, error) {
+	var offset uint64
+
+	for {
+		txs, err := r.e.dataStore.History(key, offset, true, changesHistoryPageSize)
+		if err == store.ErrNoMoreEntries {
+			return nil, nil, 0, store.ErrKeyNotFound
+		}
+		if err != nil {
+			return nil, nil, 0, err
+		}
+
+		for _, hTx := range txs {
+			if hTx >= txID {
+				continue
+			}
+
+			err = r.e.dataStore.ReadTx(hTx, r.prevTx)
+			if err != nil {
+				return nil, nil, 0, err
+			}
+
+			md, v, err := r.e.dataStore.ReadValue(r.prevTx, key)
+
+			return md, v, hTx, err
+		}
+
+		offset += uint64(len(txs))
+	}
+}
+
+func (r *changesRowReader) Close() error {
+	return nil
+}
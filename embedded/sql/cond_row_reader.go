@@ -15,6 +15,8 @@ limitations under the License.
 */
 package sql
 
+import "github.com/codenotary/immudb/embedded/store"
+
 type conditionalRowReader struct {
 	e *Engine
 
@@ -23,6 +25,9 @@ type conditionalRowReader struct {
 	condition ValueExp
 
 	params map[string]interface{}
+
+	// condition with params substituted and constants folded, set on the first read
+	cond ValueExp
 }
 
 func (e *Engine) newConditionalRowReader(rowReader RowReader, condition ValueExp, params map[string]interface{}) (*conditionalRowReader, error) {
@@ -49,6 +54,7 @@ func (cr *conditionalRowReader) SetParameters(params map[string]interface{}) err
 	}
 
 	cr.params, err = normalizeParams(params)
+	cr.cond = nil
 
 	return err
 }
@@ -61,6 +67,14 @@ func (cr *conditionalRowReader) ScanSpecs() *ScanSpecs {
 	return cr.rowReader.ScanSpecs()
 }
 
+func (cr *conditionalRowReader) TxHeader() (*store.TxHeader, error) {
+	return ReaderTxHeader(cr.rowReader)
+}
+
+func (cr *conditionalRowReader) Cursor() ([]byte, error) {
+	return cr.rowReader.Cursor()
+}
+
 func (cr *conditionalRowReader) Columns() ([]ColDescriptor, error) {
 	return cr.rowReader.Columns()
 }
@@ -92,12 +106,16 @@ func (cr *conditionalRowReader) Read() (*Row, error) {
 			return nil, err
 		}
 
-		cond, err := cr.condition.substitute(cr.params)
-		if err != nil {
-			return nil, err
+		if cr.cond == nil {
+			cond, err := cr.condition.substitute(cr.params)
+			if err != nil {
+				return nil, err
+			}
+
+			cr.cond = foldConstants(cond)
 		}
 
-		r, err := cond.reduce(cr.e.catalog, row, cr.rowReader.ImplicitDB(), cr.rowReader.ImplicitTable())
+		r, err := cr.cond.reduce(cr.e.catalog, row, cr.rowReader.ImplicitDB(), cr.rowReader.ImplicitTable())
 		if err != nil {
 			return nil, err
 		}
@@ -107,12 +125,12 @@ func (cr *conditionalRowReader) Read() (*Row, error) {
 			continue
 		}
 
-		satisfies, boolExp := r.(*Bool)
-		if !boolExp {
+		// aggregated values e.g. BOOL_OR(col) are reduced to themselves
+		if r.Type() != BooleanType {
 			return nil, ErrInvalidCondition
 		}
 
-		if satisfies.val {
+		if r.Value().(bool) {
 			return row, err
 		}
 	}
@@ -15,7 +15,11 @@ limitations under the License.
 */
 package sql
 
-import "crypto/sha256"
+import (
+	"crypto/sha256"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
 
 type distinctRowReader struct {
 	e *Engine
@@ -60,6 +64,15 @@ func (dr *distinctRowReader) ScanSpecs() *ScanSpecs {
 	return dr.rowReader.ScanSpecs()
 }
 
+func (dr *distinctRowReader) TxHeader() (*store.TxHeader, error) {
+	return ReaderTxHeader(dr.rowReader)
+}
+
+// Cursor is not supported, rows skipped as duplicates would depend on the rows of previous pages
+func (dr *distinctRowReader) Cursor() ([]byte, error) {
+	return nil, ErrNoSupported
+}
+
 func (dr *distinctRowReader) Columns() ([]ColDescriptor, error) {
 	return dr.rowReader.Columns()
 }
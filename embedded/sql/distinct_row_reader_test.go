@@ -45,6 +45,9 @@ func TestDistinctRowReader(t *testing.T) {
 	require.Equal(t, dummyr.OrderBy(), rowReader.OrderBy())
 	require.Equal(t, dummyr.ScanSpecs(), rowReader.ScanSpecs())
 
+	_, err = rowReader.TxHeader()
+	require.ErrorIs(t, err, errDummy)
+
 	dummyr.failReturningColumns = true
 	_, err = rowReader.Columns()
 	require.Equal(t, errDummy, err)
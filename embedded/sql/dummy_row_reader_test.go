@@ -17,6 +17,8 @@ package sql
 
 import (
 	"errors"
+
+	"github.com/codenotary/immudb/embedded/store"
 )
 
 var errDummy = errors.New("dummy error")
@@ -50,6 +52,14 @@ func (r *dummyRowReader) ScanSpecs() *ScanSpecs {
 	return nil
 }
 
+func (r *dummyRowReader) TxHeader() (*store.TxHeader, error) {
+	return nil, errDummy
+}
+
+func (r *dummyRowReader) Cursor() ([]byte, error) {
+	return nil, errDummy
+}
+
 func (r *dummyRowReader) Columns() ([]ColDescriptor, error) {
 	if r.failReturningColumns {
 		return nil, errDummy
@@ -17,6 +17,7 @@ package sql
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/binary"
 	"errors"
 	"io"
@@ -24,7 +25,11 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 
+	"github.com/codenotary/immudb/embedded/cache"
+	"github.com/codenotary/immudb/embedded/htree"
 	"github.com/codenotary/immudb/embedded/store"
 	"github.com/codenotary/immudb/embedded/tbtree"
 )
@@ -38,6 +43,7 @@ var ErrNoDatabaseSelected = errors.New("no database selected")
 var ErrTableAlreadyExists = errors.New("table already exists")
 var ErrTableDoesNotExist = errors.New("table does not exist")
 var ErrColumnDoesNotExist = errors.New("column does not exist")
+var ErrColumnAlreadyExists = errors.New("column already exists")
 var ErrColumnNotIndexed = errors.New("column is not indexed")
 var ErrLimitedKeyType = errors.New("indexed key of invalid type. Supported types are: INTEGER, VARCHAR[256] OR BLOB[256]")
 var ErrLimitedAutoIncrement = errors.New("only INTEGER single-column primary keys can be set as auto incremental")
@@ -47,17 +53,24 @@ var ErrDuplicatedColumn = errors.New("duplicated column")
 var ErrInvalidColumn = errors.New("invalid column")
 var ErrPKCanNotBeNull = errors.New("primary key can not be null")
 var ErrPKCanNotBeUpdated = errors.New("primary key can not be updated")
+var ErrPKCanNotBeDropped = errors.New("primary key can not be dropped")
 var ErrNotNullableColumnCannotBeNull = errors.New("not nullable column can not be null")
 var ErrIndexedColumnCanNotBeNull = errors.New("indexed column can not be null")
 var ErrIndexAlreadyExists = errors.New("index already exists")
+var ErrIndexDoesNotExist = errors.New("index does not exist")
 var ErrMaxNumberOfColumnsInIndexExceeded = errors.New("number of columns in multi-column index exceeded")
 var ErrNoAvailableIndex = errors.New("no available index")
 var ErrInvalidNumberOfValues = errors.New("invalid number of values provided")
+var ErrMoreThanOneRow = errors.New("subquery returned more than one row")
+var ErrNoUniqueIndex = errors.New("no unique index on the conflict target")
 var ErrInvalidValue = errors.New("invalid value provided")
 var ErrInferredMultipleTypes = errors.New("inferred multiple types")
 var ErrExpectingDQLStmt = errors.New("illegal statement. DQL statement expected")
 var ErrLimitedOrderBy = errors.New("order is limit to one indexed column")
 var ErrLimitedGroupBy = errors.New("group by requires ordering by the grouping column")
+var ErrColumnNotInGroupBy = errors.New("column is neither grouped nor used in an aggregation")
+var ErrLimitedAggregation = errors.New("aggregations can not be used within expressions")
+var ErrLimitedWindowFunctions = errors.New("window functions are limited to a common ordering by one column, which is also the one of the query")
 var ErrIllegalMappedKey = errors.New("error illegal mapped key")
 var ErrCorruptedData = store.ErrCorruptedData
 var ErrCatalogNotReady = errors.New("catalog not ready")
@@ -74,13 +87,21 @@ var ErrColumnIsNotAnAggregation = errors.New("column is not an aggregation")
 var ErrLimitedCount = errors.New("only unbounded counting is supported i.e. COUNT()")
 var ErrTxDoesNotExist = errors.New("tx does not exist")
 var ErrDivisionByZero = errors.New("division by zero")
+var ErrArithmeticOverflow = errors.New("arithmetic overflow")
 var ErrMissingParameter = errors.New("missing parameter")
 var ErrUnsupportedParameter = errors.New("unsupported parameter")
 var ErrDuplicatedParameters = errors.New("duplicated parameters")
 var ErrLimitedIndexCreation = errors.New("index creation is only supported on empty tables")
 var ErrTooManyRows = errors.New("too many rows")
+var ErrInvalidConversion = errors.New("value can not be converted")
+var ErrScanLimitExceeded = errors.New("scan limit exceeded")
 var ErrAlreadyClosed = errors.New("sql engine already closed")
 var ErrAmbiguousSelector = errors.New("ambiguous selector")
+var ErrIllegalDefaultValue = errors.New("illegal default value")
+var ErrIllegalGeneratedColumn = errors.New("illegal generated column")
+var ErrGeneratedColumnCanNotBeAssigned = errors.New("generated columns can not be assigned")
+var ErrDuplicatedTableExpression = errors.New("duplicated common table expression")
+var ErrJoinDepthExceeded = errors.New("join depth exceeded")
 
 var maxKeyLen = 256
 var maxKeyVal []byte = greatestKeyOfSize(maxKeyLen)
@@ -94,8 +115,19 @@ type Engine struct {
 	catalogStore *store.ImmuStore
 	dataStore    *store.ImmuStore
 
-	prefix        []byte
-	distinctLimit int
+	prefix             []byte
+	distinctLimit      int
+	maxResultSize      int
+	maxScanRows        int
+	maxIndexesPerTable int
+	maxVarcharValueLen int
+	maxJoinDepth       int
+	truncateValues     bool
+	nullAggregations   bool
+	exportNulls        NullRendering
+	auditHook          AuditHook
+
+	indexCache *cache.LRUCache // rows resolved through secondary indexes, nil when disabled
 
 	catalog *Catalog // in-mem current catalog (used for INSERT, DDL statements and SELECT statements without UseSnapshotStmt)
 
@@ -104,6 +136,13 @@ type Engine struct {
 	snapshot       *store.Snapshot
 	snapAsBeforeTx uint64
 
+	// concurrent queries share the snapshot, thus renewing it and resolving readers over it must be serialized
+	snapshotMutex sync.Mutex
+
+	tx           *store.Tx       // reused when reading transaction headers back from the data store
+	lastTxHeader *store.TxHeader // header of the last data transaction committed or read by the engine
+	txMutex      sync.Mutex
+
 	closed bool
 
 	mutex sync.RWMutex
@@ -115,14 +154,32 @@ func NewEngine(catalogStore, dataStore *store.ImmuStore, opts *Options) (*Engine
 	}
 
 	e := &Engine{
-		catalogStore:  catalogStore,
-		dataStore:     dataStore,
-		prefix:        make([]byte, len(opts.prefix)),
-		distinctLimit: opts.distinctLimit,
+		catalogStore:       catalogStore,
+		dataStore:          dataStore,
+		prefix:             make([]byte, len(opts.prefix)),
+		distinctLimit:      opts.distinctLimit,
+		maxResultSize:      opts.maxResultSize,
+		maxScanRows:        opts.maxScanRows,
+		maxIndexesPerTable: opts.maxIndexesPerTable,
+		maxVarcharValueLen: opts.maxVarcharValueLen,
+		maxJoinDepth:       opts.maxJoinDepth,
+		truncateValues:     opts.truncateValues,
+		nullAggregations:   opts.nullAggregations,
+		exportNulls:        opts.exportNulls,
+		auditHook:          opts.auditHook,
 	}
 
 	copy(e.prefix, opts.prefix)
 
+	if opts.indexCacheSize > 0 {
+		indexCache, err := cache.NewLRUCache(opts.indexCacheSize)
+		if err != nil {
+			return nil, err
+		}
+
+		e.indexCache = indexCache
+	}
+
 	return e, nil
 }
 
@@ -134,6 +191,9 @@ func greatestKeyOfSize(size int) []byte {
 	return k
 }
 
+// EnsureCatalogReady loads the catalog unless it's already loaded. Loads are done while holding the engine mutex,
+// thus concurrent calls to EnsureCatalogReady and ReloadCatalog are serialized and no statement observes
+// a partially loaded catalog. A catalog is always built from scratch, entries are never applied onto the current one.
 // TODO (jeroiraz); this operation won't be needed with a transactional in-memory catalog
 func (e *Engine) EnsureCatalogReady(cancellation <-chan struct{}) error {
 	e.mutex.Lock()
@@ -150,6 +210,8 @@ func (e *Engine) EnsureCatalogReady(cancellation <-chan struct{}) error {
 	return e.loadCatalog(cancellation)
 }
 
+// ReloadCatalog replaces the catalog with the one stored at the latest committed transaction,
+// readers already resolved keep using the catalog they were resolved with
 func (e *Engine) ReloadCatalog(cancellation <-chan struct{}) error {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
@@ -244,6 +306,52 @@ func (e *Engine) UseDatabase(dbName string) error {
 	return nil
 }
 
+// ClearDatabaseSelection resets the database selected with UseDatabase or USE DATABASE,
+// thus statements run afterwards require a database to be selected again e.g. when the engine is reused by pooled connections
+func (e *Engine) ClearDatabaseSelection() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.closed {
+		return ErrAlreadyClosed
+	}
+
+	e.clearDatabaseSelection()
+
+	return nil
+}
+
+func (e *Engine) clearDatabaseSelection() {
+	e.implicitDB = ""
+}
+
+// ResetSession returns the engine to the state it had before a database was selected, so it can be reused
+// by another client without closing the underlying stores. Besides clearing the database selection as
+// ClearDatabaseSelection does, the snapshot settings are cleared and the snapshot is released,
+// it fails when readers over the snapshot are still open
+func (e *Engine) ResetSession() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.closed {
+		return ErrAlreadyClosed
+	}
+
+	if e.snapshot != nil {
+		err := e.snapshot.Close()
+		if err != nil {
+			return err
+		}
+
+		e.snapshot = nil
+	}
+
+	e.snapAsBeforeTx = 0
+	e.clearDatabaseSelection()
+
+	return nil
+}
+
 func (e *Engine) DatabaseInUse() (*Database, error) {
 	e.mutex.RLock()
 	defer e.mutex.RUnlock()
@@ -257,15 +365,25 @@ func (e *Engine) DatabaseInUse() (*Database, error) {
 		return nil, ErrCatalogNotReady
 	}
 
-	return e.databaseInUse()
+	return e.databaseInUse(nil)
 }
 
-func (e *Engine) databaseInUse() (*Database, error) {
-	if e.implicitDB == "" {
+// databaseInUse returns the database selected by the session or by the engine when no session is provided
+func (e *Engine) databaseInUse(s *Session) (*Database, error) {
+	dbName := e.implicitDB
+	if s != nil {
+		if s.closed {
+			return nil, ErrAlreadyClosed
+		}
+
+		dbName = s.db
+	}
+
+	if dbName == "" {
 		return nil, ErrNoDatabaseSelected
 	}
 
-	return e.catalog.GetDatabaseByName(e.implicitDB)
+	return e.catalog.GetDatabaseByName(dbName)
 }
 
 func (e *Engine) UseSnapshot(sinceTx uint64, asBeforeTx uint64) error {
@@ -298,18 +416,9 @@ func (e *Engine) useSnapshot(sinceTx uint64, asBeforeTx uint64) error {
 		sinceTx = math.MaxUint64
 	}
 
-	if e.snapshot == nil || e.snapshot.Ts() < sinceTx {
-		if e.snapshot != nil {
-			err = e.snapshot.Close()
-			if err != nil {
-				return err
-			}
-		}
-
-		e.snapshot, err = e.dataStore.SnapshotSince(sinceTx)
-		if err != nil {
-			return err
-		}
+	e.snapshot, err = e.snapshotSince(e.snapshot, sinceTx)
+	if err != nil {
+		return err
 	}
 
 	e.snapAsBeforeTx = asBeforeTx
@@ -317,6 +426,23 @@ func (e *Engine) useSnapshot(sinceTx uint64, asBeforeTx uint64) error {
 	return nil
 }
 
+// snapshotSince returns snap if it already reflects sinceTx, otherwise snap is closed and replaced by a new one.
+// snap is returned together with the error when it can not be closed
+func (e *Engine) snapshotSince(snap *store.Snapshot, sinceTx uint64) (*store.Snapshot, error) {
+	if snap != nil && snap.Ts() >= sinceTx {
+		return snap, nil
+	}
+
+	if snap != nil {
+		err := snap.Close()
+		if err != nil {
+			return snap, err
+		}
+	}
+
+	return e.dataStore.SnapshotSince(sinceTx)
+}
+
 func (e *Engine) getSnapshot() (*store.Snapshot, error) {
 	if e.snapshot == nil {
 		err := e.useSnapshot(0, 0)
@@ -347,6 +473,58 @@ func (e *Engine) renewSnapshot() error {
 	return e.useSnapshot(0, e.snapAsBeforeTx)
 }
 
+// LastCommittedTx returns the id of the last transaction committed into the data store
+func (e *Engine) LastCommittedTx() (uint64, error) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	if e.closed {
+		return 0, ErrAlreadyClosed
+	}
+
+	txID, _ := e.dataStore.Alh()
+
+	return txID, nil
+}
+
+// txHeader returns the header of the given transaction. Headers of transactions committed through the engine
+// are kept so they don't need to be read back from the data store.
+func (e *Engine) txHeader(txID uint64) (*store.TxHeader, error) {
+	if txID == 0 {
+		// no data has been committed yet
+		return &store.TxHeader{}, nil
+	}
+
+	e.txMutex.Lock()
+	defer e.txMutex.Unlock()
+
+	if e.lastTxHeader == nil || e.lastTxHeader.ID != txID {
+		if e.tx == nil {
+			e.tx = e.dataStore.NewTx()
+		}
+
+		err := e.dataStore.ReadTx(txID, e.tx)
+		if err != nil {
+			return nil, err
+		}
+
+		e.lastTxHeader = e.tx.Header()
+	}
+
+	hdr := *e.lastTxHeader
+
+	return &hdr, nil
+}
+
+func (e *Engine) setLastTxHeader(hdr *store.TxHeader) {
+	e.txMutex.Lock()
+	defer e.txMutex.Unlock()
+
+	if e.lastTxHeader == nil || e.lastTxHeader.ID < hdr.ID {
+		e.lastTxHeader = hdr
+	}
+}
+
 func (e *Engine) CloseSnapshot() error {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
@@ -497,13 +675,21 @@ func (e *Engine) catalogFrom(catalogSnap, dataSnap *store.Snapshot) (*Catalog, e
 		}
 	}
 
+	vref, err := catalogSnap.Get(e.mapKey(catalogVersionKey))
+	if err != nil && err != store.ErrKeyNotFound {
+		return nil, err
+	}
+	if err == nil {
+		catalog.version = vref.Tx()
+	}
+
 	return catalog, nil
 }
 
 func (e *Engine) loadTables(db *Database, catalogSnap, dataSnap *store.Snapshot) error {
+	// dropped tables are read as well, so their ids are not assigned again
 	dbReaderSpec := &store.KeyReaderSpec{
 		Prefix: e.mapKey(catalogTablePrefix, EncodeID(db.id)),
-		Filter: store.IgnoreDeleted,
 	}
 
 	tableReader, err := catalogSnap.NewKeyReader(dbReaderSpec)
@@ -530,6 +716,11 @@ func (e *Engine) loadTables(db *Database, catalogSnap, dataSnap *store.Snapshot)
 			return ErrCorruptedData
 		}
 
+		if md := vref.KVMetadata(); md != nil && md.Deleted() {
+			db.maxTableID = tableID
+			continue
+		}
+
 		colSpecs, err := e.loadColSpecs(db.id, tableID, catalogSnap)
 		if err != nil {
 			return err
@@ -554,7 +745,27 @@ func (e *Engine) loadTables(db *Database, catalogSnap, dataSnap *store.Snapshot)
 			return err
 		}
 
+		err = e.loadComments(table, catalogSnap)
+		if err != nil {
+			return err
+		}
+
+		err = e.loadMissingValues(table, catalogSnap)
+		if err != nil {
+			return err
+		}
+
+		err = e.loadPrevTypes(table, catalogSnap)
+		if err != nil {
+			return err
+		}
+
 		if table.autoIncrementPK {
+			err = e.loadNextAutoIncrementValue(table, catalogSnap)
+			if err != nil {
+				return err
+			}
+
 			encMaxPK, err := e.loadMaxPK(dataSnap, table)
 			if err == store.ErrNoMoreEntries {
 				continue
@@ -570,7 +781,10 @@ func (e *Engine) loadTables(db *Database, catalogSnap, dataSnap *store.Snapshot)
 			// map to signed integer space
 			encMaxPK[0] ^= 0x80
 
-			table.maxPK = int64(binary.BigEndian.Uint64(encMaxPK))
+			maxPK := int64(binary.BigEndian.Uint64(encMaxPK))
+			if maxPK > table.maxPK {
+				table.maxPK = maxPK
+			}
 		}
 	}
 
@@ -587,6 +801,201 @@ func indexKeyFrom(cols []*Column) string {
 	return buf.String()
 }
 
+func (e *Engine) loadComments(table *Table, snap *store.Snapshot) error {
+	commentReader, err := snap.NewKeyReader(&store.KeyReaderSpec{
+		Prefix: e.mapKey(catalogCommentPrefix, EncodeID(table.db.id), EncodeID(table.id)),
+		Filter: store.IgnoreDeleted,
+	})
+	if err != nil {
+		return err
+	}
+	defer commentReader.Close()
+
+	for {
+		mkey, vref, err := commentReader.Read()
+		if err == store.ErrNoMoreEntries {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		encID, err := e.trimPrefix(mkey, []byte(catalogCommentPrefix))
+		if err != nil {
+			return err
+		}
+
+		if len(encID) != EncIDLen*3 {
+			return ErrCorruptedData
+		}
+
+		v, err := vref.Resolve()
+		if err != nil {
+			return err
+		}
+
+		colID := binary.BigEndian.Uint32(encID[2*EncIDLen:])
+
+		if colID == 0 {
+			table.comment = string(v)
+			continue
+		}
+
+		col, err := table.GetColumnByID(colID)
+		if err != nil {
+			return ErrCorruptedData
+		}
+
+		col.comment = string(v)
+	}
+}
+
+// loadMissingValues loads the values of the rows written before the columns were added, if any
+func (e *Engine) loadMissingValues(table *Table, snap *store.Snapshot) error {
+	missingReader, err := snap.NewKeyReader(&store.KeyReaderSpec{
+		Prefix: e.mapKey(catalogMissingPrefix, EncodeID(table.db.id), EncodeID(table.id)),
+		Filter: store.IgnoreDeleted,
+	})
+	if err != nil {
+		return err
+	}
+	defer missingReader.Close()
+
+	for {
+		mkey, vref, err := missingReader.Read()
+		if err == store.ErrNoMoreEntries {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		encID, err := e.trimPrefix(mkey, []byte(catalogMissingPrefix))
+		if err != nil {
+			return err
+		}
+
+		if len(encID) != EncIDLen*3 {
+			return ErrCorruptedData
+		}
+
+		col, err := table.GetColumnByID(binary.BigEndian.Uint32(encID[2*EncIDLen:]))
+		if err != nil {
+			return ErrCorruptedData
+		}
+
+		v, err := vref.Resolve()
+		if err != nil {
+			return err
+		}
+
+		if len(v) < 8 {
+			return ErrCorruptedData
+		}
+
+		val, n, err := DecodeValue(v[8:], col.colType)
+		if err != nil {
+			return err
+		}
+
+		if n != len(v)-8 {
+			return ErrCorruptedData
+		}
+
+		col.missingValue = val
+		col.missingTx = binary.BigEndian.Uint64(v)
+	}
+}
+
+// loadPrevTypes loads the types the columns had before they were altered, if any
+func (e *Engine) loadPrevTypes(table *Table, snap *store.Snapshot) error {
+	prevTypeReader, err := snap.NewKeyReader(&store.KeyReaderSpec{
+		Prefix: e.mapKey(catalogPrevTypePrefix, EncodeID(table.db.id), EncodeID(table.id)),
+		Filter: store.IgnoreDeleted,
+	})
+	if err != nil {
+		return err
+	}
+	defer prevTypeReader.Close()
+
+	for {
+		mkey, vref, err := prevTypeReader.Read()
+		if err == store.ErrNoMoreEntries {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		encID, err := e.trimPrefix(mkey, []byte(catalogPrevTypePrefix))
+		if err != nil {
+			return err
+		}
+
+		if len(encID) != EncIDLen*3+8 {
+			return ErrCorruptedData
+		}
+
+		col, err := table.GetColumnByID(binary.BigEndian.Uint32(encID[2*EncIDLen:]))
+		if err != nil {
+			return ErrCorruptedData
+		}
+
+		v, err := vref.Resolve()
+		if err != nil {
+			return err
+		}
+
+		// keys are sorted by untilTx thus types are loaded in the order the column was altered
+		col.prevTypes = append(col.prevTypes, &prevColType{
+			colType: SQLValueType(v),
+			untilTx: binary.BigEndian.Uint64(encID[3*EncIDLen:]),
+		})
+	}
+}
+
+// isEmptyTable returns true when no row has ever been written into the table
+func (e *Engine) isEmptyTable(table *Table) (bool, error) {
+	lastTxID, _ := e.dataStore.Alh()
+	err := e.dataStore.WaitForIndexingUpto(lastTxID, nil)
+	if err != nil {
+		return false, err
+	}
+
+	pkPrefix := e.mapKey(PIndexPrefix, EncodeID(table.db.id), EncodeID(table.id), EncodeID(PKIndexID))
+
+	existKey, err := e.dataStore.ExistKeyWith(pkPrefix, pkPrefix, false)
+	if err != nil {
+		return false, err
+	}
+
+	return !existKey, nil
+}
+
+// loadNextAutoIncrementValue loads the next auto-incremental value explicitly set with ALTER TABLE, if any
+func (e *Engine) loadNextAutoIncrementValue(table *Table, snap *store.Snapshot) error {
+	vref, err := snap.Get(e.mapKey(catalogSequencePrefix, EncodeID(table.db.id), EncodeID(table.id)))
+	if err == store.ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	v, err := vref.Resolve()
+	if err != nil {
+		return err
+	}
+
+	if len(v) != 8 {
+		return ErrCorruptedData
+	}
+
+	table.maxPK = int64(binary.BigEndian.Uint64(v)) - 1
+
+	return nil
+}
+
 func (e *Engine) loadMaxPK(dataSnap *store.Snapshot, table *Table) ([]byte, error) {
 	pkReaderSpec := &store.KeyReaderSpec{
 		Prefix:    e.mapKey(PIndexPrefix, EncodeID(table.db.id), EncodeID(table.id), EncodeID(PKIndexID)),
@@ -657,6 +1066,17 @@ func (e *Engine) loadColSpecs(dbID, tableID uint32, snap *store.Snapshot) (specs
 			notNull:       v[0]&nullableFlag != 0,
 		}
 
+		exp, generated, err := e.loadDefaultValue(dbID, tableID, colID, colType, snap)
+		if err != nil {
+			return nil, err
+		}
+
+		if generated {
+			spec.generatedAs = exp
+		} else {
+			spec.defaultValue = exp
+		}
+
 		specs = append(specs, spec)
 
 		if int(colID) != len(specs) {
@@ -667,12 +1087,101 @@ func (e *Engine) loadColSpecs(dbID, tableID uint32, snap *store.Snapshot) (specs
 	return
 }
 
-func (e *Engine) loadIndexes(table *Table, snap *store.Snapshot) error {
-	initialKey := e.mapKey(catalogIndexPrefix, EncodeID(table.db.id), EncodeID(table.id))
-
+// loadDefaultValue returns either the default value of the column or, when generated is set, its generation expression
+func (e *Engine) loadDefaultValue(dbID, tableID, colID uint32, colType SQLValueType, snap *store.Snapshot) (exp ValueExp, generated bool, err error) {
+	vref, err := snap.Get(e.mapKey(catalogDefaultPrefix, EncodeID(dbID), EncodeID(tableID), EncodeID(colID)), store.IgnoreDeleted)
+	if err == store.ErrKeyNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	v, err := vref.Resolve()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(v) > 0 && v[0] == generatedExpFlag {
+		exp, err := parseGeneratedExp(string(v[1:]))
+		return exp, true, err
+	}
+
+	exp, err = decodeDefaultValue(v, colType)
+
+	return exp, false, err
+}
+
+// encodeDefaultValue encodes the default value of a column as {(value | function | generated){encVAL | fnNAME | expSQL}},
+// the expression of generated columns is stored in place of the default value
+func encodeDefaultValue(col *Column) ([]byte, error) {
+	if col.generatedAs != nil {
+		sql, err := generatedExpSQL(col.generatedAs)
+		if err != nil {
+			return nil, err
+		}
+
+		return append([]byte{generatedExpFlag}, []byte(sql)...), nil
+	}
+
+	switch v := col.defaultValue.(type) {
+	case *SysFn:
+		return append([]byte{defaultFnFlag}, []byte(strings.ToUpper(v.fn))...), nil
+	case TypedValue:
+		encVal, err := EncodeValue(v.Value(), col.colType, col.maxLen)
+		if err != nil {
+			return nil, err
+		}
+
+		return append([]byte{defaultValueFlag}, encVal...), nil
+	}
+
+	return nil, ErrUnexpected
+}
+
+// encodeMissingValue encodes the value of the rows written before a column was added as {missingTx}{encVAL}
+func encodeMissingValue(col *Column) ([]byte, error) {
+	encVal, err := EncodeValue(col.missingValue.Value(), col.colType, col.maxLen)
+	if err != nil {
+		return nil, err
+	}
+
+	var encMissingTx [8]byte
+	binary.BigEndian.PutUint64(encMissingTx[:], col.missingTx)
+
+	return append(encMissingTx[:], encVal...), nil
+}
+
+func decodeDefaultValue(b []byte, colType SQLValueType) (ValueExp, error) {
+	if len(b) < 2 {
+		return nil, ErrCorruptedData
+	}
+
+	switch b[0] {
+	case defaultFnFlag:
+		return &SysFn{fn: string(b[1:])}, nil
+	case defaultValueFlag:
+		val, n, err := DecodeValue(b[1:], colType)
+		if err != nil {
+			return nil, err
+		}
+
+		if n != len(b)-1 {
+			return nil, ErrCorruptedData
+		}
+
+		return val, nil
+	}
+
+	return nil, ErrCorruptedData
+}
+
+func (e *Engine) loadIndexes(table *Table, snap *store.Snapshot) error {
+	initialKey := e.mapKey(catalogIndexPrefix, EncodeID(table.db.id), EncodeID(table.id))
+
+	// dropped indexes are read as well, so their ids are not assigned again
 	idxReaderSpec := &store.KeyReaderSpec{
 		Prefix: initialKey,
-		Filter: store.IgnoreDeleted,
 	}
 
 	idxSpecReader, err := snap.NewKeyReader(idxReaderSpec)
@@ -699,12 +1208,17 @@ func (e *Engine) loadIndexes(table *Table, snap *store.Snapshot) error {
 			return ErrCorruptedData
 		}
 
+		if md := vref.KVMetadata(); md != nil && md.Deleted() {
+			table.nextIndexID = indexID + 1
+			continue
+		}
+
 		v, err := vref.Resolve()
 		if err != nil {
 			return err
 		}
 
-		// v={unique {colID1}(ASC|DESC)...{colIDN}(ASC|DESC)}
+		// v={flags {colID1}(ASC|DESC)...{colIDN}(ASC|DESC)}
 		colSpecLen := EncIDLen + 1
 
 		if len(v) < 1+colSpecLen || len(v)%colSpecLen != 1 {
@@ -724,7 +1238,7 @@ func (e *Engine) loadIndexes(table *Table, snap *store.Snapshot) error {
 			colIDs = append(colIDs, colID)
 		}
 
-		index, err := table.newIndex(v[0] > 0, colIDs)
+		index, err := table.newIndex(v[0]&uniqueIndexFlag != 0, v[0]&nullableIndexFlag != 0, colIDs)
 		if err != nil {
 			return err
 		}
@@ -859,10 +1373,7 @@ func (e *Engine) unmapIndexEntry(index *Index, mkey []byte) (encPKVals []byte, e
 	if !index.IsPrimary() {
 		//read index values
 		for _, col := range index.cols {
-			maxLen := col.MaxLen()
-			if variableSized(col.colType) {
-				maxLen += EncLenLen
-			}
+			maxLen := index.keyValLen(col)
 			if len(enc)-off < maxLen {
 				return nil, ErrCorruptedData
 			}
@@ -924,7 +1435,7 @@ func maxKeyValOf(colType SQLValueType) []byte {
 		{
 			return maxKeyVal[:1]
 		}
-	case IntegerType:
+	case IntegerType, TimestampType:
 		{
 			return maxKeyVal[:8]
 		}
@@ -932,6 +1443,67 @@ func maxKeyValOf(colType SQLValueType) []byte {
 	return maxKeyVal[:]
 }
 
+// truncatedValue cuts VARCHAR and BLOB values longer than the max length of the column when the engine
+// is set to truncate them, otherwise values are kept as they are and rejected when encoded
+func (e *Engine) truncatedValue(col *Column, val TypedValue) TypedValue {
+	if !e.truncateValues || col.maxLen == 0 {
+		return val
+	}
+
+	switch v := val.(type) {
+	case *Varchar:
+		{
+			if len(v.val) <= col.maxLen {
+				return v
+			}
+
+			// multi-byte characters are not split
+			n := col.maxLen
+			for n > 0 && !utf8.RuneStart(v.val[n]) {
+				n--
+			}
+
+			return &Varchar{val: v.val[:n]}
+		}
+	case *Blob:
+		{
+			if len(v.val) <= col.maxLen {
+				return v
+			}
+
+			return &Blob{val: v.val[:col.maxLen]}
+		}
+	}
+
+	return val
+}
+
+// valueMaxLen returns the maximum length of the values stored into the column,
+// values of VARCHAR columns declared without a length are capped by the engine settings
+func (e *Engine) valueMaxLen(col *Column) int {
+	if col.colType == VarcharType && col.maxLen == 0 {
+		return e.maxVarcharValueLen
+	}
+
+	return col.MaxLen()
+}
+
+// timestamps are stored as nanoseconds since the unix epoch, thus only those between the years 1678 and 2262 can be stored
+var (
+	minTimestamp = time.Unix(0, math.MinInt64).UTC()
+	maxTimestamp = time.Unix(0, math.MaxInt64).UTC()
+)
+
+// timestampNanos returns the nanoseconds elapsed since the unix epoch,
+// it fails instead of overflowing when they can not be represented as an int64
+func timestampNanos(t time.Time) (int64, error) {
+	if t.Before(minTimestamp) || t.After(maxTimestamp) {
+		return 0, ErrIllegalArguments
+	}
+
+	return t.UnixNano(), nil
+}
+
 func EncodeValue(val interface{}, colType SQLValueType, maxLen int) ([]byte, error) {
 	switch colType {
 	case VarcharType:
@@ -965,6 +1537,25 @@ func EncodeValue(val interface{}, colType SQLValueType, maxLen int) ([]byte, err
 			binary.BigEndian.PutUint32(encv[:], uint32(8))
 			binary.BigEndian.PutUint64(encv[EncLenLen:], uint64(intVal))
 
+			return encv[:], nil
+		}
+	case TimestampType:
+		{
+			timeVal, ok := val.(time.Time)
+			if !ok {
+				return nil, ErrInvalidValue
+			}
+
+			nanos, err := timestampNanos(timeVal)
+			if err != nil {
+				return nil, err
+			}
+
+			// len(v) + v, v being nanoseconds since the unix epoch
+			var encv [EncLenLen + 8]byte
+			binary.BigEndian.PutUint32(encv[:], uint32(8))
+			binary.BigEndian.PutUint64(encv[EncLenLen:], uint64(nanos))
+
 			return encv[:], nil
 		}
 	case BooleanType:
@@ -1008,10 +1599,6 @@ func EncodeValue(val interface{}, colType SQLValueType, maxLen int) ([]byte, err
 		}
 	}
 
-	/*
-		time
-	*/
-
 	return nil, ErrInvalidValue
 }
 
@@ -1059,6 +1646,30 @@ func EncodeAsKey(val interface{}, colType SQLValueType, maxLen int) ([]byte, err
 			binary.BigEndian.PutUint64(encv[:], uint64(intVal))
 			encv[0] ^= 0x80
 
+			return encv[:], nil
+		}
+	case TimestampType:
+		{
+			if maxLen != 8 {
+				return nil, ErrCorruptedData
+			}
+
+			timeVal, ok := val.(time.Time)
+			if !ok {
+				return nil, ErrInvalidValue
+			}
+
+			nanos, err := timestampNanos(timeVal)
+			if err != nil {
+				return nil, err
+			}
+
+			// v
+			// nanoseconds since the unix epoch, mapped to unsigned integer space as integers are
+			var encv [8]byte
+			binary.BigEndian.PutUint64(encv[:], uint64(nanos))
+			encv[0] ^= 0x80
+
 			return encv[:], nil
 		}
 	case BooleanType:
@@ -1100,13 +1711,63 @@ func EncodeAsKey(val interface{}, colType SQLValueType, maxLen int) ([]byte, err
 		}
 	}
 
-	/*
-		time
-	*/
-
 	return nil, ErrInvalidValue
 }
 
+// decodeKeyValue decodes a value encoded with EncodeAsKey
+func decodeKeyValue(b []byte, colType SQLValueType, maxLen int) (TypedValue, int, error) {
+	switch colType {
+	case VarcharType, BLOBType:
+		{
+			if len(b) < maxLen+EncLenLen {
+				return nil, 0, ErrCorruptedData
+			}
+
+			vlen := int(binary.BigEndian.Uint32(b[maxLen:]))
+			if vlen > maxLen {
+				return nil, 0, ErrCorruptedData
+			}
+
+			if colType == VarcharType {
+				return &Varchar{val: string(b[:vlen])}, maxLen + EncLenLen, nil
+			}
+
+			v := make([]byte, vlen)
+			copy(v, b)
+
+			return &Blob{val: v}, maxLen + EncLenLen, nil
+		}
+	case IntegerType, TimestampType:
+		{
+			if len(b) < 8 {
+				return nil, 0, ErrCorruptedData
+			}
+
+			var encv [8]byte
+			copy(encv[:], b)
+			encv[0] ^= 0x80
+
+			v := int64(binary.BigEndian.Uint64(encv[:]))
+
+			if colType == TimestampType {
+				return &Timestamp{val: time.Unix(0, v).UTC()}, 8, nil
+			}
+
+			return &Number{val: v}, 8, nil
+		}
+	case BooleanType:
+		{
+			if len(b) < 1 {
+				return nil, 0, ErrCorruptedData
+			}
+
+			return &Bool{val: b[0] == 1}, 1, nil
+		}
+	}
+
+	return nil, 0, ErrCorruptedData
+}
+
 func DecodeValue(b []byte, colType SQLValueType) (TypedValue, int, error) {
 	if len(b) < EncLenLen {
 		return nil, 0, ErrCorruptedData
@@ -1138,6 +1799,17 @@ func DecodeValue(b []byte, colType SQLValueType) (TypedValue, int, error) {
 
 			return &Number{val: int64(v)}, voff, nil
 		}
+	case TimestampType:
+		{
+			if vlen != 8 {
+				return nil, 0, ErrCorruptedData
+			}
+
+			v := binary.BigEndian.Uint64(b[voff:])
+			voff += vlen
+
+			return &Timestamp{val: time.Unix(0, int64(v)).UTC()}, voff, nil
+		}
 	case BooleanType:
 		{
 			if vlen != 1 {
@@ -1206,6 +1878,393 @@ func (e *Engine) GetTableByName(dbName, tableName string) (*Table, error) {
 	return e.catalog.GetTableByName(dbName, tableName)
 }
 
+// CatalogVersion returns the id of the last transaction where the catalog was changed,
+// thus it increases with every committed DDL statement
+func (e *Engine) CatalogVersion() (uint64, error) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	if e.closed {
+		return 0, ErrAlreadyClosed
+	}
+
+	if e.catalog == nil {
+		return 0, ErrCatalogNotReady
+	}
+
+	return e.catalog.version, nil
+}
+
+// SchemaHash returns a hash of the definition of the tables, columns and indexes of a database.
+// It doesn't depend on the data nor on the name of the database, so it can be used to detect schema drifts
+func (e *Engine) SchemaHash(dbName string) ([sha256.Size]byte, error) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	if e.closed {
+		return [sha256.Size]byte{}, ErrAlreadyClosed
+	}
+
+	if e.catalog == nil {
+		return [sha256.Size]byte{}, ErrCatalogNotReady
+	}
+
+	db, err := e.catalog.GetDatabaseByName(dbName)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+
+	return db.schemaDigest()
+}
+
+// ChangesSince returns the rows of the table inserted, updated or deleted by the transactions committed after sinceTx,
+// in the order they were written. Besides the columns of the table, each row holds the id of the transaction
+// it was written in (ChangeTxCol) and the kind of change (ChangeOpCol). Deleted rows hold their last content.
+func (e *Engine) ChangesSince(dbName, tableName string, sinceTx uint64) (RowReader, error) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	if e.closed {
+		return nil, ErrAlreadyClosed
+	}
+
+	if e.catalog == nil {
+		return nil, ErrCatalogNotReady
+	}
+
+	table, err := e.catalog.GetTableByName(dbName, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	lastTxID, _ := e.dataStore.Alh()
+	if sinceTx >= lastTxID {
+		sinceTx = lastTxID
+	}
+
+	// history of the keys is used to tell inserted rows from updated ones
+	err = e.dataStore.WaitForIndexingUpto(lastTxID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.newChangesRowReader(table, sinceTx, lastTxID)
+}
+
+// IndexCardinality returns the number of distinct values held by the index of the table defined over indexCols.
+// The count is exact and is computed by scanning the index, thus it takes time proportional to the size of the table.
+func (e *Engine) IndexCardinality(dbName, tableName string, indexCols []string) (uint64, error) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	if e.closed {
+		return 0, ErrAlreadyClosed
+	}
+
+	if e.catalog == nil {
+		return 0, ErrCatalogNotReady
+	}
+
+	table, err := e.catalog.GetTableByName(dbName, tableName)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(indexCols) == 0 {
+		return 0, ErrIllegalArguments
+	}
+
+	cols := make([]*Column, len(indexCols))
+
+	for i, colName := range indexCols {
+		col, err := table.GetColumnByName(colName)
+		if err != nil {
+			return 0, err
+		}
+
+		cols[i] = col
+	}
+
+	index, ok := table.indexes[indexKeyFrom(cols)]
+	if !ok {
+		return 0, ErrNoAvailableIndex
+	}
+
+	lastTxID, _ := e.dataStore.Alh()
+	err = e.dataStore.WaitForIndexingUpto(lastTxID, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	snap, err := e.dataStore.SnapshotSince(math.MaxUint64)
+	if err != nil {
+		return 0, err
+	}
+	defer snap.Close()
+
+	prefix := e.mapKey(index.prefix(), EncodeID(table.db.id), EncodeID(table.id), EncodeID(index.id))
+
+	// entries are sorted by the encoded values of the index, thus distinct values
+	// are counted as the number of changes between consecutive entries
+	valsLen := 0
+	for _, col := range index.cols {
+		valsLen += index.keyValLen(col)
+	}
+
+	r, err := snap.NewKeyReader(&store.KeyReaderSpec{
+		Prefix: prefix,
+		Filter: store.IgnoreDeleted,
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	var cardinality uint64
+	var prevVals []byte
+
+	for {
+		mkey, _, err := r.Read()
+		if err == store.ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if len(mkey) < len(prefix)+valsLen {
+			return 0, ErrCorruptedData
+		}
+
+		vals := mkey[len(prefix) : len(prefix)+valsLen]
+
+		if prevVals == nil || !bytes.Equal(vals, prevVals) {
+			cardinality++
+			prevVals = append(prevVals[:0], vals...)
+		}
+	}
+
+	return cardinality, nil
+}
+
+// TableStorageStats returns the number of rows of the table together with the size of the keys and values it's stored as,
+// entries of secondary indexes included. Sizes are computed by scanning every index of the table, thus it takes time
+// proportional to the size of the table. Only current entries are accounted, not the history kept by the store.
+func (e *Engine) TableStorageStats(dbName, tableName string) (rows uint64, keyBytes uint64, valueBytes uint64, err error) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	if e.closed {
+		return 0, 0, 0, ErrAlreadyClosed
+	}
+
+	if e.catalog == nil {
+		return 0, 0, 0, ErrCatalogNotReady
+	}
+
+	table, err := e.catalog.GetTableByName(dbName, tableName)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	lastTxID, _ := e.dataStore.Alh()
+	err = e.dataStore.WaitForIndexingUpto(lastTxID, nil)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	snap, err := e.dataStore.SnapshotSince(math.MaxUint64)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	defer snap.Close()
+
+	for _, index := range table.indexes {
+		r, err := snap.NewKeyReader(&store.KeyReaderSpec{
+			Prefix: e.mapKey(index.prefix(), EncodeID(table.db.id), EncodeID(table.id), EncodeID(index.id)),
+			Filter: store.IgnoreDeleted,
+		})
+		if err != nil {
+			return 0, 0, 0, err
+		}
+
+		for {
+			mkey, vref, err := r.Read()
+			if err == store.ErrNoMoreEntries {
+				break
+			}
+			if err != nil {
+				r.Close()
+				return 0, 0, 0, err
+			}
+
+			if index.IsPrimary() {
+				rows++
+			}
+
+			keyBytes += uint64(len(mkey))
+			valueBytes += uint64(vref.Len())
+		}
+
+		err = r.Close()
+		if err != nil {
+			return 0, 0, 0, err
+		}
+	}
+
+	return rows, keyBytes, valueBytes, nil
+}
+
+// GetRow returns the current content of the row of the table identified by the values of its primary key,
+// given in the order of the columns of the primary key. Values are accepted as query parameters are.
+// store.ErrKeyNotFound is returned when there is no such row.
+func (e *Engine) GetRow(dbName, tableName string, pkValues ...interface{}) (*Row, error) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	if e.closed {
+		return nil, ErrAlreadyClosed
+	}
+
+	if e.catalog == nil {
+		return nil, ErrCatalogNotReady
+	}
+
+	table, err := e.catalog.GetTableByName(dbName, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pkValues) != len(table.primaryIndex.cols) {
+		return nil, ErrIllegalArguments
+	}
+
+	valuesByColID := make(map[uint32]TypedValue, len(pkValues))
+
+	for i, col := range table.primaryIndex.cols {
+		if pkValues[i] == nil {
+			return nil, ErrPKCanNotBeNull
+		}
+
+		param := &Param{id: col.colName}
+
+		exp, err := param.substitute(map[string]interface{}{param.id: pkValues[i]})
+		if err != nil {
+			return nil, err
+		}
+
+		val, err := exp.reduce(e.catalog, nil, dbName, tableName)
+		if err != nil {
+			return nil, err
+		}
+
+		valuesByColID[col.id] = val
+	}
+
+	pk, err := encodedPK(table, valuesByColID)
+	if err != nil {
+		return nil, err
+	}
+
+	lastTxID, _ := e.dataStore.Alh()
+	err = e.dataStore.WaitForIndexingUpto(lastTxID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	pkKey := e.mapKey(PIndexPrefix, EncodeID(table.db.id), EncodeID(table.id), EncodeID(PKIndexID), pk)
+
+	vref, err := e.dataStore.Get(pkKey, store.IgnoreDeleted)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := vref.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeRow(table, table.name, v, vref.Tx())
+}
+
+// RowProof holds the entry of the primary index where a row is stored together with
+// the proof of its inclusion into the transaction it was last written in
+type RowProof struct {
+	Tx             *store.TxHeader
+	Key            []byte
+	Value          []byte
+	InclusionProof *htree.InclusionProof
+}
+
+// VerifiableRow returns the current content of a row identified by its encoded primary key values,
+// the returned proof can be verified with store.VerifyInclusion against the Eh of the transaction,
+// which is then linked to any known state through the Alh of the transaction header
+func (e *Engine) VerifiableRow(dbName, tableName string, pk []byte) (*Row, *store.KVMetadata, *RowProof, error) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	if e.closed {
+		return nil, nil, nil, ErrAlreadyClosed
+	}
+
+	if e.catalog == nil {
+		return nil, nil, nil, ErrCatalogNotReady
+	}
+
+	table, err := e.catalog.GetTableByName(dbName, tableName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if len(pk) == 0 {
+		return nil, nil, nil, ErrIllegalArguments
+	}
+
+	lastTxID, _ := e.dataStore.Alh()
+	err = e.dataStore.WaitForIndexingUpto(lastTxID, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	pkKey := e.mapKey(PIndexPrefix, EncodeID(table.db.id), EncodeID(table.id), EncodeID(PKIndexID), pk)
+
+	vref, err := e.dataStore.Get(pkKey, store.IgnoreDeleted)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	v, err := vref.Resolve()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	row, err := decodeRow(table, table.name, v, vref.Tx())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	tx := e.dataStore.NewTx()
+
+	err = e.dataStore.ReadTx(vref.Tx(), tx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	inclusionProof, err := tx.Proof(pkKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	proof := &RowProof{
+		Tx:             tx.Header(),
+		Key:            pkKey,
+		Value:          v,
+		InclusionProof: inclusionProof,
+	}
+
+	return row, vref.KVMetadata(), proof, nil
+}
+
 func (e *Engine) InferParameters(sql string) (map[string]SQLValueType, error) {
 	e.mutex.RLock()
 	defer e.mutex.RUnlock()
@@ -1214,10 +2273,10 @@ func (e *Engine) InferParameters(sql string) (map[string]SQLValueType, error) {
 		return nil, ErrAlreadyClosed
 	}
 
-	return e.inferParametersFrom(strings.NewReader(sql))
+	return e.inferParametersFrom(nil, strings.NewReader(sql))
 }
 
-func (e *Engine) inferParametersFrom(r io.ByteReader) (map[string]SQLValueType, error) {
+func (e *Engine) inferParametersFrom(s *Session, r io.ByteReader) (map[string]SQLValueType, error) {
 	stmts, err := Parse(r)
 	if err != nil {
 		return nil, err
@@ -1228,11 +2287,14 @@ func (e *Engine) inferParametersFrom(r io.ByteReader) (map[string]SQLValueType,
 		return nil, ErrCatalogNotReady
 	}
 
-	implicitDB, err := e.databaseInUse()
+	implicitDB, err := e.databaseInUse(s)
 	if err != nil {
 		return nil, err
 	}
 
+	e.snapshotMutex.Lock()
+	defer e.snapshotMutex.Unlock()
+
 	params := make(map[string]SQLValueType)
 
 	for _, stmt := range stmts {
@@ -1246,6 +2308,10 @@ func (e *Engine) inferParametersFrom(r io.ByteReader) (map[string]SQLValueType,
 }
 
 func (e *Engine) InferParametersPreparedStmt(stmt SQLStmt) (map[string]SQLValueType, error) {
+	return e.inferParametersPreparedStmt(nil, stmt)
+}
+
+func (e *Engine) inferParametersPreparedStmt(s *Session, stmt SQLStmt) (map[string]SQLValueType, error) {
 	if stmt == nil {
 		return nil, ErrIllegalArguments
 	}
@@ -1262,11 +2328,14 @@ func (e *Engine) InferParametersPreparedStmt(stmt SQLStmt) (map[string]SQLValueT
 		return nil, ErrCatalogNotReady
 	}
 
-	implicitDB, err := e.databaseInUse()
+	implicitDB, err := e.databaseInUse(s)
 	if err != nil {
 		return nil, err
 	}
 
+	e.snapshotMutex.Lock()
+	defer e.snapshotMutex.Unlock()
+
 	params := make(map[string]SQLValueType)
 
 	err = stmt.inferParameters(e, implicitDB, params)
@@ -1276,10 +2345,67 @@ func (e *Engine) InferParametersPreparedStmt(stmt SQLStmt) (map[string]SQLValueT
 
 // exist database directly on catalogStore: // existKey(e.mapKey(catalogDatabase, db), e.catalogStore)
 func (e *Engine) QueryStmt(sql string, params map[string]interface{}, renewSnapshot bool) (RowReader, error) {
-	return e.Query(strings.NewReader(sql), params, renewSnapshot)
+	return e.queryStmt(nil, sql, params, renewSnapshot)
+}
+
+// QueryAll reads the full result of the query and closes the reader,
+// ErrTooManyRows is returned if the result has more rows than the configured max result size
+func (e *Engine) QueryAll(sql string, params map[string]interface{}) ([]*Row, []ColDescriptor, error) {
+	return e.queryAll(nil, sql, params)
+}
+
+func (e *Engine) queryAll(s *Session, sql string, params map[string]interface{}) ([]*Row, []ColDescriptor, error) {
+	r, err := e.queryStmt(s, sql, params, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, cols, err := e.readAll(r)
+	if err != nil {
+		r.Close()
+		return nil, nil, err
+	}
+
+	err = r.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return rows, cols, nil
+}
+
+func (e *Engine) readAll(r RowReader) ([]*Row, []ColDescriptor, error) {
+	cols, err := r.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rows []*Row
+
+	for {
+		row, err := r.Read()
+		if err == ErrNoMoreRows {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(rows) == e.maxResultSize {
+			return nil, nil, ErrTooManyRows
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, cols, nil
 }
 
 func (e *Engine) Query(sql io.ByteReader, params map[string]interface{}, renewSnapshot bool) (RowReader, error) {
+	return e.query(nil, sql, params, renewSnapshot)
+}
+
+func (e *Engine) query(s *Session, sql io.ByteReader, params map[string]interface{}, renewSnapshot bool) (RowReader, error) {
 	stmts, err := Parse(sql)
 	if err != nil {
 		return nil, err
@@ -1293,10 +2419,39 @@ func (e *Engine) Query(sql io.ByteReader, params map[string]interface{}, renewSn
 		return nil, ErrExpectingDQLStmt
 	}
 
-	return e.QueryPreparedStmt(stmt, params, renewSnapshot)
+	return e.queryPreparedStmt(s, stmt, params, renewSnapshot)
+}
+
+// QueryStmtFromCursor resolves the query to read the rows following the position of a cursor returned by
+// RowReader.Cursor for the same query, thus pages of rows neither overlap nor leave gaps as rows are written.
+// An empty cursor reads rows from the beginning. Cursors are not supported by queries returning rows in an order
+// other than the one they are scanned, e.g. joins, aggregations or sorting by non-indexed columns
+func (e *Engine) QueryStmtFromCursor(sql string, params map[string]interface{}, cursor []byte) (RowReader, error) {
+	stmts, err := Parse(strings.NewReader(sql))
+	if err != nil {
+		return nil, err
+	}
+	if len(stmts) != 1 {
+		return nil, ErrExpectingDQLStmt
+	}
+
+	stmt, ok := stmts[0].(*SelectStmt)
+	if !ok {
+		return nil, ErrExpectingDQLStmt
+	}
+
+	if len(cursor) > 0 {
+		stmt.cursor = cursor
+	}
+
+	return e.queryPreparedStmt(nil, stmt, params, true)
 }
 
 func (e *Engine) QueryPreparedStmt(stmt *SelectStmt, params map[string]interface{}, renewSnapshot bool) (RowReader, error) {
+	return e.queryPreparedStmt(nil, stmt, params, renewSnapshot)
+}
+
+func (e *Engine) queryPreparedStmt(s *Session, stmt *SelectStmt, params map[string]interface{}, renewSnapshot bool) (RowReader, error) {
 	if stmt == nil {
 		return nil, ErrIllegalArguments
 	}
@@ -1313,19 +2468,28 @@ func (e *Engine) QueryPreparedStmt(stmt *SelectStmt, params map[string]interface
 		return nil, ErrCatalogNotReady
 	}
 
-	if renewSnapshot {
-		err := e.renewSnapshot()
-		if err != nil && err != tbtree.ErrReadersNotClosed {
-			return nil, err
-		}
-	}
+	e.snapshotMutex.Lock()
+	defer e.snapshotMutex.Unlock()
 
-	snapshot, err := e.getSnapshot()
+	implicitDB, err := e.databaseInUse(s)
 	if err != nil {
 		return nil, err
 	}
 
-	implicitDB, err := e.databaseInUse()
+	var snapshot *store.Snapshot
+
+	if s == nil {
+		if renewSnapshot {
+			err := e.renewSnapshot()
+			if err != nil && err != tbtree.ErrReadersNotClosed {
+				return nil, err
+			}
+		}
+
+		snapshot, err = e.getSnapshot()
+	} else {
+		snapshot, err = s.getSnapshot(renewSnapshot)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -1345,16 +2509,20 @@ func (e *Engine) QueryPreparedStmt(stmt *SelectStmt, params map[string]interface
 }
 
 func (e *Engine) ExecStmt(sql string, params map[string]interface{}, waitForIndexing bool) (summary *ExecSummary, err error) {
-	return e.Exec(strings.NewReader(sql), params, waitForIndexing)
+	return e.execStmt(nil, sql, params, waitForIndexing)
 }
 
 func (e *Engine) Exec(sql io.ByteReader, params map[string]interface{}, waitForIndexing bool) (summary *ExecSummary, err error) {
+	return e.exec(nil, sql, params, waitForIndexing)
+}
+
+func (e *Engine) exec(s *Session, sql io.ByteReader, params map[string]interface{}, waitForIndexing bool) (summary *ExecSummary, err error) {
 	stmts, err := Parse(sql)
 	if err != nil {
 		return nil, err
 	}
 
-	return e.ExecPreparedStmts(stmts, params, waitForIndexing)
+	return e.execPreparedStmts(s, stmts, params, waitForIndexing)
 }
 
 type ExecSummary struct {
@@ -1363,9 +2531,16 @@ type ExecSummary struct {
 
 	UpdatedRows     int
 	LastInsertedPKs map[string]int64
+
+	// ReturnedRows holds the rows written by statements with a RETURNING clause, in order
+	ReturnedRows []*ReturnedRow
 }
 
 func (e *Engine) ExecPreparedStmts(stmts []SQLStmt, params map[string]interface{}, waitForIndexing bool) (summary *ExecSummary, err error) {
+	return e.execPreparedStmts(nil, stmts, params, waitForIndexing)
+}
+
+func (e *Engine) execPreparedStmts(s *Session, stmts []SQLStmt, params map[string]interface{}, waitForIndexing bool) (summary *ExecSummary, err error) {
 	if len(stmts) == 0 {
 		return nil, ErrIllegalArguments
 	}
@@ -1384,7 +2559,7 @@ func (e *Engine) ExecPreparedStmts(stmts []SQLStmt, params map[string]interface{
 		}
 	}
 
-	implicitDB, err := e.databaseInUse()
+	implicitDB, err := e.databaseInUse(s)
 	if err != nil && err != ErrNoDatabaseSelected {
 		return nil, err
 	}
@@ -1408,14 +2583,27 @@ func (e *Engine) ExecPreparedStmts(stmts []SQLStmt, params map[string]interface{
 
 		implicitDB = txSummary.db
 
-		if len(txSummary.ces) > 0 && len(txSummary.des) > 0 {
+		mixed := len(txSummary.ces) > 0 && len(txSummary.des) > 0
+
+		if mixed && !txSummary.rewritesRows && !txSummary.dropsRows {
 			e.resetCatalog() // in-memory catalog changes needs to be reverted
 			return summary, ErrDDLorDMLTxOnly
 		}
 
+		// rows rewritten by a DDL statement are committed along with the catalog entries describing them
+		// when both are kept in the same store, otherwise rows written before the catalog change are still
+		// decoded as they were written, until they're rewritten
+		atOnce := mixed && txSummary.rewritesRows && e.catalogStore == e.dataStore
+
 		if len(txSummary.ces) > 0 {
+			entries := append(txSummary.ces, &store.EntrySpec{Key: e.mapKey(catalogVersionKey), Value: []byte{}})
+
+			if atOnce {
+				entries = append(entries, txSummary.des...)
+			}
+
 			txmd, err := e.catalogStore.Commit(&store.TxSpec{
-				Entries:         txSummary.ces,
+				Entries:         entries,
 				WaitForIndexing: waitForIndexing,
 			})
 			// TODO (jeroiraz): implement transactional in-memory catalog
@@ -1425,19 +2613,43 @@ func (e *Engine) ExecPreparedStmts(stmts []SQLStmt, params map[string]interface{
 			}
 
 			summary.DDTxs = append(summary.DDTxs, txmd)
+
+			e.catalog.version = txmd.ID
+
+			if atOnce {
+				summary.DMTxs = append(summary.DMTxs, txmd)
+
+				e.setLastTxHeader(txmd)
+			}
 		}
 
-		if len(txSummary.des) > 0 {
-			txmd, err := e.dataStore.Commit(&store.TxSpec{
-				Entries:         txSummary.des,
-				WaitForIndexing: waitForIndexing,
-			})
-			if err != nil {
-				e.resetCatalog() // in-memory catalog changes needs to be reverted
-				return summary, err
+		if len(txSummary.des) > 0 && !atOnce {
+			batchLen := len(txSummary.des)
+
+			// entries of dropped tables or indexes may be too many to be deleted in a single transaction
+			if txSummary.dropsRows && batchLen > e.dataStore.MaxTxEntries() {
+				batchLen = e.dataStore.MaxTxEntries()
 			}
 
-			summary.DMTxs = append(summary.DMTxs, txmd)
+			for i := 0; i < len(txSummary.des); i += batchLen {
+				j := i + batchLen
+				if j > len(txSummary.des) {
+					j = len(txSummary.des)
+				}
+
+				txmd, err := e.dataStore.Commit(&store.TxSpec{
+					Entries:         txSummary.des[i:j],
+					WaitForIndexing: waitForIndexing,
+				})
+				if err != nil {
+					e.resetCatalog() // in-memory catalog changes needs to be reverted
+					return summary, err
+				}
+
+				summary.DMTxs = append(summary.DMTxs, txmd)
+
+				e.setLastTxHeader(txmd)
+			}
 		}
 
 		summary.UpdatedRows += txSummary.updatedRows
@@ -1445,6 +2657,8 @@ func (e *Engine) ExecPreparedStmts(stmts []SQLStmt, params map[string]interface{
 		for t, pk := range txSummary.lastInsertedPKs {
 			summary.LastInsertedPKs[t] = pk
 		}
+
+		summary.ReturnedRows = append(summary.ReturnedRows, txSummary.returnedRows...)
 	}
 
 	e.catalog.mutated = false
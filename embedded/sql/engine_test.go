@@ -21,11 +21,14 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/embedded/tbtree"
 	"github.com/stretchr/testify/require"
 )
 
@@ -111,6 +114,106 @@ func TestUseDatabase(t *testing.T) {
 
 	_, err = engine.ExecStmt("USE DATABASE db2", nil, true)
 	require.Equal(t, ErrDatabaseDoesNotExist, err)
+
+	err = engine.ClearDatabaseSelection()
+	require.NoError(t, err)
+
+	_, err = engine.DatabaseInUse()
+	require.Equal(t, ErrNoDatabaseSelected, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, PRIMARY KEY id)", nil, true)
+	require.Equal(t, ErrNoDatabaseSelected, err)
+
+	_, err = engine.ExecStmt("USE DATABASE db1; CREATE TABLE table1 (id INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, _, err = engine.QueryAll("SELECT id FROM table1", nil)
+	require.NoError(t, err)
+
+	err = engine.ClearDatabaseSelection()
+	require.NoError(t, err)
+
+	_, _, err = engine.QueryAll("SELECT id FROM table1", nil)
+	require.Equal(t, ErrNoDatabaseSelected, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	err = engine.ClearDatabaseSelection()
+	require.Equal(t, ErrAlreadyClosed, err)
+}
+
+func TestResetSession(t *testing.T) {
+	catalogStore, err := store.Open("catalog_reset_session", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_reset_session")
+
+	dataStore, err := store.Open("sqldata_reset_session", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_reset_session")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	summary, err := engine.ExecStmt("INSERT INTO table1 (id) VALUES (1)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id) VALUES (2)", nil, true)
+	require.NoError(t, err)
+
+	t.Run("first session", func(t *testing.T) {
+		err = engine.UseSnapshot(0, summary.DMTxs[0].ID+1)
+		require.NoError(t, err)
+
+		rows, _, err := engine.QueryAll("SELECT id FROM table1", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+
+		r, err := engine.QueryStmt("SELECT id FROM table1", nil, false)
+		require.NoError(t, err)
+
+		err = engine.ResetSession()
+		require.ErrorIs(t, err, tbtree.ErrReadersNotClosed)
+
+		err = r.Close()
+		require.NoError(t, err)
+
+		err = engine.ResetSession()
+		require.NoError(t, err)
+	})
+
+	t.Run("second session", func(t *testing.T) {
+		_, err = engine.DatabaseInUse()
+		require.ErrorIs(t, err, ErrNoDatabaseSelected)
+
+		_, _, err = engine.QueryAll("SELECT id FROM table1", nil)
+		require.ErrorIs(t, err, ErrNoDatabaseSelected)
+
+		err = engine.UseDatabase("db1")
+		require.NoError(t, err)
+
+		rows, _, err := engine.QueryAll("SELECT id FROM table1", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	err = engine.ResetSession()
+	require.ErrorIs(t, err, ErrAlreadyClosed)
 }
 
 func TestCreateTable(t *testing.T) {
@@ -134,8 +237,8 @@ func TestCreateTable(t *testing.T) {
 	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, ts TIMESTAMP, PRIMARY KEY id)", nil, true)
-	require.ErrorIs(t, err, ErrNoSupported)
+	_, err = engine.ExecStmt("CREATE TABLE events (id INTEGER, ts TIMESTAMP, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
 
 	_, err = engine.ExecStmt("CREATE TABLE table1 (name VARCHAR, PRIMARY KEY id)", nil, true)
 	require.Equal(t, ErrColumnDoesNotExist, err)
@@ -162,6 +265,156 @@ func TestCreateTable(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestCreateTableLike(t *testing.T) {
+	catalogStore, err := store.Open("catalog_create_table_like", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_create_table_like")
+
+	dataStore, err := store.Open("sqldata_create_table_like", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_create_table_like")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table2 (LIKE table1)", nil, true)
+	require.ErrorIs(t, err, ErrNoDatabaseSelected)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table2 (LIKE table1)", nil, true)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	_, err = engine.ExecStmt(`CREATE TABLE table1 (
+		id INTEGER AUTO_INCREMENT,
+		title VARCHAR[64] NOT NULL,
+		active BOOLEAN,
+		payload BLOB,
+		PRIMARY KEY id
+	)`, nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(active)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table1(title, active)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (title, active) VALUES ('title1', true)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table2 (LIKE table1)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table2 (LIKE table1)", nil, true)
+	require.ErrorIs(t, err, ErrTableAlreadyExists)
+
+	_, err = engine.ExecStmt("CREATE TABLE IF NOT EXISTS table2 (LIKE table1 INCLUDING INDEXES)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table3 (LIKE table1 INCLUDING INDEXES)", nil, true)
+	require.NoError(t, err)
+
+	requireSameCols := func(source, target *Table) {
+		require.Len(t, target.Cols(), len(source.Cols()))
+
+		for i, col := range source.Cols() {
+			targetCol := target.Cols()[i]
+
+			require.Equal(t, col.Name(), targetCol.Name())
+			require.Equal(t, col.Type(), targetCol.Type())
+			require.Equal(t, col.MaxLen(), targetCol.MaxLen())
+			require.Equal(t, col.IsAutoIncremental(), targetCol.IsAutoIncremental())
+			require.Equal(t, col.IsNullable(), targetCol.IsNullable())
+		}
+
+		require.Equal(t, colNames(source.PrimaryIndex().Cols()), colNames(target.PrimaryIndex().Cols()))
+	}
+
+	checkSchemas := func() {
+		table1, err := engine.GetTableByName("db1", "table1")
+		require.NoError(t, err)
+
+		table2, err := engine.GetTableByName("db1", "table2")
+		require.NoError(t, err)
+
+		table3, err := engine.GetTableByName("db1", "table3")
+		require.NoError(t, err)
+
+		requireSameCols(table1, table2)
+		requireSameCols(table1, table3)
+
+		for _, col := range table1.Cols() {
+			// table2 was created without secondary indexes
+			table2Indexes := table2.IndexesByColID(col.ID())
+
+			if table1.PrimaryIndex().IncludesCol(col.ID()) {
+				require.Len(t, table2Indexes, 1)
+				require.True(t, table2Indexes[0].IsPrimary())
+			} else {
+				require.Empty(t, table2Indexes)
+			}
+
+			sourceIndexes := table1.IndexesByColID(col.ID())
+			table3Indexes := table3.IndexesByColID(col.ID())
+			require.Len(t, table3Indexes, len(sourceIndexes))
+
+			for i, index := range sourceIndexes {
+				require.Equal(t, index.IsPrimary(), table3Indexes[i].IsPrimary())
+				require.Equal(t, index.IsUnique(), table3Indexes[i].IsUnique())
+				require.Equal(t, colNames(index.Cols()), colNames(table3Indexes[i].Cols()))
+			}
+		}
+	}
+
+	checkSchemas()
+
+	// the new tables are empty and behave like the source one
+	r, err := engine.QueryStmt("SELECT COUNT() AS c FROM table3", nil, true)
+	require.NoError(t, err)
+
+	row, err := r.Read()
+	require.NoError(t, err)
+	require.Equal(t, int64(0), row.Values[EncodeSelector("", "db1", "table3", "c")].Value())
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table3 (title, active) VALUES ('title1', true)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table3 (title, active) VALUES ('title1', true)", nil, true)
+	require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+
+	_, err = engine.ExecStmt("INSERT INTO table2 (title, active) VALUES ('title1', true)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table2 (title, active) VALUES ('title1', true)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table2 (active) VALUES (true)", nil, true)
+	require.ErrorIs(t, err, ErrNotNullableColumnCannotBeNull)
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	// the copied schema is persisted in the catalog
+	engine, err = NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	err = engine.EnsureCatalogReady(nil)
+	require.NoError(t, err)
+
+	checkSchemas()
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
 func TestDumpCatalogTo(t *testing.T) {
 	catalogStore, err := store.Open("dump_catalog_catalog", store.DefaultOptions())
 	require.NoError(t, err)
@@ -245,244 +498,276 @@ func TestAddColumn(t *testing.T) {
 	require.Equal(t, ErrColumnDoesNotExist, err)
 
 	_, err = engine.ExecStmt("ALTER TABLE table1 ADD COLUMN surname VARCHAR", nil, true)
-	require.Equal(t, ErrNoSupported, err)
-}
+	require.Equal(t, ErrTableDoesNotExist, err)
 
-func TestCreateIndex(t *testing.T) {
-	catalogStore, err := store.Open("catalog_create_index", store.DefaultOptions())
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, name VARCHAR, PRIMARY KEY id)", nil, true)
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_create_index")
 
-	dataStore, err := store.Open("sqldata_create_index", store.DefaultOptions())
+	_, err = engine.ExecStmt("INSERT INTO table1 (name) VALUES ('john')", nil, true)
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_create_index")
 
-	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
-	require.NoError(t, err)
+	assertRow := func(t *testing.T, id int64, surname interface{}, active bool, level interface{}) {
+		rows, _, err := engine.QueryAll("SELECT surname, active, level FROM table1 WHERE id = @id", map[string]interface{}{"id": id})
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, surname, rows[0].Values[EncodeSelector("", "db1", "table1", "surname")].Value())
+		require.Equal(t, active, rows[0].Values[EncodeSelector("", "db1", "table1", "active")].Value())
+		require.Equal(t, level, rows[0].Values[EncodeSelector("", "db1", "table1", "level")].Value())
+	}
 
-	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	_, err = engine.ExecStmt("ALTER TABLE table1 ADD COLUMN surname VARCHAR", nil, true)
 	require.NoError(t, err)
 
-	err = engine.UseDatabase("db1")
+	_, err = engine.ExecStmt("ALTER TABLE table1 ADD COLUMN active BOOLEAN NOT NULL DEFAULT true", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, name VARCHAR[256], age INTEGER, active BOOLEAN, PRIMARY KEY id)", nil, true)
+	_, err = engine.ExecStmt("ALTER TABLE table1 ADD COLUMN level INTEGER DEFAULT 1", nil, true)
 	require.NoError(t, err)
 
-	db, err := engine.GetDatabaseByName("db1")
+	_, err = engine.ExecStmt("ALTER TABLE table1 ADD COLUMN note VARCHAR DEFAULT NULL", nil, true)
 	require.NoError(t, err)
-	require.NotNil(t, db)
 
-	table, err := engine.GetTableByName("db1", "table1")
-	require.NoError(t, err)
-	require.Len(t, table.indexes, 1)
+	t.Run("invalid columns can not be added", func(t *testing.T) {
+		_, err = engine.ExecStmt("ALTER TABLE table1 ADD COLUMN name VARCHAR", nil, true)
+		require.ErrorIs(t, err, ErrColumnAlreadyExists)
 
-	_, err = engine.ExecStmt("CREATE INDEX ON table1(name)", nil, true)
+		_, err = engine.ExecStmt("ALTER TABLE table1 ADD COLUMN age INTEGER NOT NULL", nil, true)
+		require.ErrorIs(t, err, ErrNotNullableColumnCannotBeNull)
+
+		_, err = engine.ExecStmt("ALTER TABLE table1 ADD COLUMN seq INTEGER AUTO_INCREMENT", nil, true)
+		require.ErrorIs(t, err, ErrLimitedAutoIncrement)
+
+		_, err = engine.ExecStmt("ALTER TABLE table1 ADD COLUMN code VARCHAR AS (name) STORED", nil, true)
+		require.ErrorIs(t, err, ErrIllegalGeneratedColumn)
+
+		_, err = engine.ExecStmt("ALTER TABLE table1 ADD COLUMN age INTEGER[2]", nil, true)
+		require.ErrorIs(t, err, ErrLimitedMaxLen)
+	})
+
+	t.Run("existing rows read NULL or the default value", func(t *testing.T) {
+		assertRow(t, 1, nil, true, int64(1))
+	})
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (name, surname, active) VALUES ('jane', 'doe', false)", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE INDEX IF NOT EXISTS ON table1(name)", nil, true)
+	_, err = engine.ExecStmt("INSERT INTO table1 (name) VALUES ('jack')", nil, true)
 	require.NoError(t, err)
 
-	col, err := table.GetColumnByName("name")
+	_, err = engine.ExecStmt("UPDATE table1 SET surname = 'smith' WHERE id = 1", nil, true)
 	require.NoError(t, err)
 
-	indexed, err := table.IsIndexed(col.colName)
+	// rows written after the column was added hold NULL when set so, instead of the default value
+	_, err = engine.ExecStmt("UPDATE table1 SET level = NULL WHERE id = 2", nil, true)
 	require.NoError(t, err)
-	require.True(t, indexed)
 
-	_, err = engine.ExecStmt("CREATE INDEX ON table1(id)", nil, true)
-	require.Equal(t, ErrIndexAlreadyExists, err)
+	t.Run("new rows hold the values of the added columns", func(t *testing.T) {
+		assertRow(t, 1, "smith", true, int64(1))
+		assertRow(t, 2, "doe", false, nil)
+		assertRow(t, 3, nil, true, int64(1))
 
-	_, err = engine.ExecStmt("CREATE UNIQUE INDEX IF NOT EXISTS ON table1(id)", nil, true)
-	require.NoError(t, err)
+		rows, _, err := engine.QueryAll("SELECT id FROM table1 WHERE active", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+	})
 
-	_, err = engine.ExecStmt("CREATE INDEX ON table1(age)", nil, true)
-	require.NoError(t, err)
+	t.Run("columns can be added to empty tables without a default value", func(t *testing.T) {
+		_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER, PRIMARY KEY id)", nil, true)
+		require.NoError(t, err)
 
-	col, err = table.GetColumnByName("age")
+		_, err = engine.ExecStmt("ALTER TABLE table2 ADD COLUMN title VARCHAR NOT NULL", nil, true)
+		require.NoError(t, err)
+
+		_, err = engine.ExecStmt("INSERT INTO table2 (id) VALUES (1)", nil, true)
+		require.ErrorIs(t, err, ErrNotNullableColumnCannotBeNull)
+
+		_, err = engine.ExecStmt("INSERT INTO table2 (id, title) VALUES (1, 'title1')", nil, true)
+		require.NoError(t, err)
+	})
+
+	err = engine.Close()
 	require.NoError(t, err)
 
-	indexed, err = table.IsIndexed(col.colName)
+	// added columns are kept in the catalog
+	engine, err = NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
-	require.True(t, indexed)
 
-	_, err = engine.ExecStmt("CREATE INDEX ON table1(name)", nil, true)
-	require.Equal(t, ErrIndexAlreadyExists, err)
+	err = engine.EnsureCatalogReady(nil)
+	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE INDEX ON table2(name)", nil, true)
-	require.Equal(t, ErrTableDoesNotExist, err)
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE INDEX ON table1(title)", nil, true)
-	require.Equal(t, ErrColumnDoesNotExist, err)
+	table, err := engine.GetTableByName("db1", "table1")
+	require.NoError(t, err)
+	require.Len(t, table.Cols(), 6)
 
-	_, err = engine.ExecStmt("INSERT INTO table1(id, name, age) VALUES (1, 'name1', 50)", nil, true)
+	col, err := table.GetColumnByName("active")
 	require.NoError(t, err)
+	require.Equal(t, BooleanType, col.Type())
+	require.False(t, col.IsNullable())
 
-	_, err = engine.ExecStmt("INSERT INTO table1(name, age) VALUES ('name2', 10)", nil, true)
-	require.ErrorIs(t, err, ErrPKCanNotBeNull)
+	assertRow(t, 1, "smith", true, int64(1))
+	assertRow(t, 2, "doe", false, nil)
+	assertRow(t, 3, nil, true, int64(1))
 
-	_, err = engine.ExecStmt("CREATE INDEX ON table1(active)", nil, true)
-	require.Equal(t, ErrLimitedIndexCreation, err)
+	err = engine.Close()
+	require.NoError(t, err)
 }
 
-func TestUpsertInto(t *testing.T) {
-	catalogStore, err := store.Open("catalog_upsert", store.DefaultOptions())
+func TestDropTable(t *testing.T) {
+	catalogStore, err := store.Open("catalog_drop_table", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_upsert")
+	defer os.RemoveAll("catalog_drop_table")
 
-	dataStore, err := store.Open("sqldata_upsert", store.DefaultOptions())
+	// rows of dropped tables are deleted in as many transactions as needed
+	dataStore, err := store.Open("sqldata_drop_table", store.DefaultOptions().WithMaxTxEntries(10))
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_upsert")
+	defer os.RemoveAll("sqldata_drop_table")
 
 	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
 
+	_, err = engine.ExecStmt("DROP TABLE table1", nil, true)
+	require.ErrorIs(t, err, ErrNoDatabaseSelected)
+
 	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title) VALUES (1, 'title1')", nil, true)
-	require.ErrorIs(t, err, ErrNoDatabaseSelected)
-
 	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title) VALUES (1, 'title1')", nil, true)
-	require.Equal(t, ErrTableDoesNotExist, err)
+	_, err = engine.ExecStmt("DROP TABLE table1", nil, true)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
 
-	_, err = engine.ExecStmt(`CREATE TABLE table1 (
-								id INTEGER, 
-								title VARCHAR,
-								amount INTEGER,
-								active BOOLEAN NOT NULL,
-								PRIMARY KEY id)`, nil, true)
+	_, err = engine.ExecStmt("DROP TABLE IF EXISTS table1", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE INDEX ON table1(active)", nil, true)
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table1 (
+			id INTEGER AUTO_INCREMENT,
+			title VARCHAR[10],
+			active BOOLEAN DEFAULT true,
+			PRIMARY KEY id
+		)`, nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table1(amount, active)", nil, true)
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(title)", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title) VALUES (1, 'title1')", nil, true)
-	require.Equal(t, ErrNotNullableColumnCannotBeNull, err)
-
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, age) VALUES (1, 50)", nil, true)
-	require.Equal(t, ErrColumnDoesNotExist, err)
+	_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table1(title, active)", nil, true)
+	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (@id, 'title1', true)", nil, true)
-	require.Equal(t, ErrMissingParameter, err)
+	_, err = engine.ExecStmt("COMMENT ON TABLE table1 IS 'to be dropped'", nil, true)
+	require.NoError(t, err)
 
-	params := make(map[string]interface{}, 1)
-	params["id"] = [4]byte{1, 2, 3, 4}
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (@id, 'title1', true)", params, true)
-	require.Equal(t, ErrUnsupportedParameter, err)
+	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
 
-	params = make(map[string]interface{}, 1)
-	params["id"] = []byte{1, 2, 3}
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (@id, 'title1', true)", params, true)
-	require.Equal(t, ErrInvalidValue, err)
+	for i := 0; i < 10; i++ {
+		_, err = engine.ExecStmt("INSERT INTO table1 (title) VALUES (@title)", map[string]interface{}{"title": fmt.Sprintf("title%d", i)}, true)
+		require.NoError(t, err)
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (1, @title, false)", nil, true)
-	require.Equal(t, ErrMissingParameter, err)
+		_, err = engine.ExecStmt("INSERT INTO table2 (id) VALUES (@id)", map[string]interface{}{"id": i}, true)
+		require.NoError(t, err)
+	}
 
-	params = make(map[string]interface{}, 1)
-	params["title"] = uint64(1)
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (1, @title, true)", params, true)
-	require.Equal(t, ErrInvalidValue, err)
+	table, err := engine.GetTableByName("db1", "table1")
+	require.NoError(t, err)
 
-	params = make(map[string]interface{}, 1)
-	params["title"] = uint64(1)
-	params["Title"] = uint64(2)
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (1, @title, true)", params, true)
-	require.Equal(t, ErrDuplicatedParameters, err)
+	droppedID := table.id
 
-	summary, err := engine.ExecStmt("UPSERT INTO table1 (id, amount, active) VALUES (1, 10, true)", nil, true)
+	_, err = engine.ExecStmt("DROP TABLE table1", nil, true)
 	require.NoError(t, err)
-	require.NotNil(t, summary)
-	require.Equal(t, summary.UpdatedRows, 1)
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, amount, active) VALUES (2, 10, true)", nil, true)
-	require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+	assertDropped := func(t *testing.T) {
+		_, err = engine.GetTableByName("db1", "table1")
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
 
-	t.Run("row with pk 1 should have active in false", func(t *testing.T) {
-		summary, err = engine.ExecStmt("UPSERT INTO table1 (id, amount, active) VALUES (1, 20, false)", nil, true)
-		require.NoError(t, err)
-		require.NotNil(t, summary)
-		require.Equal(t, summary.UpdatedRows, 1)
+		_, _, err = engine.QueryAll("SELECT id FROM table1", nil)
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
 
-		r, err := engine.QueryStmt("SELECT amount, active FROM table1 WHERE id = 1", nil, true)
+		rows, _, err := engine.QueryAll("SELECT id FROM table2", nil)
 		require.NoError(t, err)
+		require.Len(t, rows, 10)
+	}
 
-		row, err := r.Read()
-		require.NoError(t, err)
-		require.NotNil(t, row)
-		require.Len(t, row.Values, 2)
-		require.Equal(t, int64(20), row.Values[EncodeSelector("", "db1", "table1", "amount")].Value())
-		require.False(t, row.Values[EncodeSelector("", "db1", "table1", "active")].Value().(bool))
+	t.Run("dropped tables can not be used", assertDropped)
 
-		err = r.Close()
+	t.Run("rows and index entries of dropped tables are deleted", func(t *testing.T) {
+		snap, err := dataStore.Snapshot()
 		require.NoError(t, err)
+		defer snap.Close()
+
+		for _, prefix := range []string{PIndexPrefix, SIndexPrefix, UIndexPrefix} {
+			reader, err := snap.NewKeyReader(&store.KeyReaderSpec{
+				Prefix: engine.mapKey(prefix, EncodeID(1), EncodeID(droppedID)),
+				Filter: store.IgnoreDeleted,
+			})
+			require.NoError(t, err)
+
+			_, _, err = reader.Read()
+			require.ErrorIs(t, err, store.ErrNoMoreEntries)
+
+			err = reader.Close()
+			require.NoError(t, err)
+		}
 	})
 
-	t.Run("row with pk 1 should have active in true", func(t *testing.T) {
-		summary, err = engine.ExecStmt("UPSERT INTO table1 (id, amount, active) VALUES (1, 10, true)", nil, true)
-		require.NoError(t, err)
-		require.NotNil(t, summary)
-		require.Equal(t, summary.UpdatedRows, 1)
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[10], PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
 
-		r, err := engine.QueryStmt("SELECT amount, active FROM table1 WHERE id = 1", nil, true)
-		require.NoError(t, err)
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(title)", nil, true)
+	require.NoError(t, err)
 
-		row, err := r.Read()
+	t.Run("tables created afterwards get a new id", func(t *testing.T) {
+		table, err := engine.GetTableByName("db1", "table1")
 		require.NoError(t, err)
-		require.NotNil(t, row)
-		require.Len(t, row.Values, 2)
-		require.Equal(t, int64(10), row.Values[EncodeSelector("", "db1", "table1", "amount")].Value())
-		require.True(t, row.Values[EncodeSelector("", "db1", "table1", "active")].Value().(bool))
+		require.Greater(t, table.id, droppedID)
+		require.Len(t, table.Cols(), 2)
 
-		err = r.Close()
+		rows, _, err := engine.QueryAll("SELECT id FROM table1 WHERE title = 'title1'", nil)
 		require.NoError(t, err)
+		require.Empty(t, rows)
 	})
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (Id, Title, Active) VALUES (1, 'some title', false)", nil, true)
-	require.ErrorIs(t, err, ErrIndexedColumnCanNotBeNull)
-
-	_, err = engine.ExecStmt("UPSERT INTO table1 (Id, Title, Amount, Active) VALUES (1, 'some title', 100, false)", nil, true)
+	_, err = engine.ExecStmt("DROP TABLE table1", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, amount, active) VALUES (2, 'another title', 200, true)", nil, true)
+	err = engine.Close()
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id) VALUES (1, 'yat')", nil, true)
-	require.Equal(t, ErrInvalidNumberOfValues, err)
+	// dropped tables are not loaded again
+	engine, err = NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, id) VALUES (1, 2)", nil, true)
-	require.Equal(t, ErrDuplicatedColumn, err)
+	err = engine.EnsureCatalogReady(nil)
+	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, active) VALUES ('1', true)", nil, true)
-	require.Equal(t, ErrInvalidValue, err)
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, active) VALUES (NULL, false)", nil, true)
-	require.Equal(t, ErrPKCanNotBeNull, err)
+	t.Run("dropped tables are not loaded", assertDropped)
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (2, NULL, true)", nil, true)
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, PRIMARY KEY id)", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (title, active) VALUES ('interesting title', true)", nil, true)
-	require.Equal(t, ErrPKCanNotBeNull, err)
+	table, err = engine.GetTableByName("db1", "table1")
+	require.NoError(t, err)
+	require.Equal(t, droppedID+3, table.id)
 
-	_, err = engine.ExecStmt("CREATE TABLE IF NOT EXISTS blob_table (id BLOB[2], PRIMARY KEY id)", nil, true)
+	err = engine.Close()
 	require.NoError(t, err)
 }
 
-func TestInsertIntoEdgeCases(t *testing.T) {
-	catalogStore, err := store.Open("catalog_insert", store.DefaultOptions())
+func TestCreateIndex(t *testing.T) {
+	catalogStore, err := store.Open("catalog_create_index", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_insert")
+	defer os.RemoveAll("catalog_create_index")
 
-	dataStore, err := store.Open("sqldata_insert", store.DefaultOptions())
+	dataStore, err := store.Open("sqldata_create_index", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_insert")
+	defer os.RemoveAll("sqldata_create_index")
 
 	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
@@ -493,383 +778,208 @@ func TestInsertIntoEdgeCases(t *testing.T) {
 	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[10], active BOOLEAN, payload BLOB[2], PRIMARY KEY id)", nil, true)
-	require.NoError(t, err)
-
-	_, err = engine.ExecStmt("CREATE INDEX ON table1 (title)", nil, true)
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, name VARCHAR[256], age INTEGER, active BOOLEAN, PRIMARY KEY id)", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE INDEX ON table1 (active)", nil, true)
+	db, err := engine.GetDatabaseByName("db1")
 	require.NoError(t, err)
+	require.NotNil(t, db)
 
-	_, err = engine.ExecStmt("CREATE INDEX ON table1 (payload)", nil, true)
+	table, err := engine.GetTableByName("db1", "table1")
 	require.NoError(t, err)
+	require.Len(t, table.indexes, 1)
 
-	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (1, 'title1', true, x'00A1')", nil, true)
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(name)", nil, true)
 	require.NoError(t, err)
 
-	t.Run("varchar key cases", func(t *testing.T) {
-		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (1, 'title123456789', true, x'00A1')", nil, true)
-		require.ErrorIs(t, err, ErrMaxLengthExceeded)
-
-		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (1, 10, true, '00A1')", nil, true)
-		require.ErrorIs(t, err, ErrInvalidValue)
-	})
-
-	t.Run("boolean key cases", func(t *testing.T) {
-		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (1, 'title1', 'true', x'00A1')", nil, true)
-		require.ErrorIs(t, err, ErrInvalidValue)
-	})
-
-	t.Run("blob key cases", func(t *testing.T) {
-		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (1, 'title1', true, x'00A100A2')", nil, true)
-		require.ErrorIs(t, err, ErrMaxLengthExceeded)
-
-		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (1, 'title1', true, '00A100A2')", nil, true)
-		require.ErrorIs(t, err, ErrInvalidValue)
-	})
-
-	err = engine.Close()
+	_, err = engine.ExecStmt("CREATE INDEX IF NOT EXISTS ON table1(name)", nil, true)
 	require.NoError(t, err)
-}
 
-func TestAutoIncrementPK(t *testing.T) {
-	catalogStore, err := store.Open("catalog_auto_inc", store.DefaultOptions())
+	col, err := table.GetColumnByName("name")
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_auto_inc")
 
-	dataStore, err := store.Open("sqldata_auto_inc", store.DefaultOptions())
+	indexed, err := table.IsIndexed(col.colName)
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_auto_inc")
+	require.True(t, indexed)
 
-	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
-	require.NoError(t, err)
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(id)", nil, true)
+	require.Equal(t, ErrIndexAlreadyExists, err)
 
-	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	_, err = engine.ExecStmt("CREATE UNIQUE INDEX IF NOT EXISTS ON table1(id)", nil, true)
 	require.NoError(t, err)
 
-	err = engine.UseDatabase("db1")
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(age)", nil, true)
 	require.NoError(t, err)
 
-	t.Run("invalid use of auto-increment", func(t *testing.T) {
-		_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR AUTO_INCREMENT, PRIMARY KEY id)", nil, true)
-		require.ErrorIs(t, err, ErrLimitedAutoIncrement)
-
-		_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, age INTEGER AUTO_INCREMENT, PRIMARY KEY id)", nil, true)
-		require.ErrorIs(t, err, ErrLimitedAutoIncrement)
-
-		_, err = engine.ExecStmt("CREATE TABLE table1 (id VARCHAR AUTO_INCREMENT, title VARCHAR, PRIMARY KEY id)", nil, true)
-		require.ErrorIs(t, err, ErrLimitedAutoIncrement)
-	})
-
-	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR, PRIMARY KEY id)", nil, true)
+	col, err = table.GetColumnByName("age")
 	require.NoError(t, err)
 
-	summary, err := engine.ExecStmt("INSERT INTO table1(title) VALUES ('name1')", nil, true)
+	indexed, err = table.IsIndexed(col.colName)
 	require.NoError(t, err)
-	require.Empty(t, summary.DDTxs)
-	require.Len(t, summary.DMTxs, 1)
-	require.Equal(t, uint64(1), summary.DMTxs[0].ID)
-	require.Len(t, summary.LastInsertedPKs, 1)
-	require.Equal(t, int64(1), summary.LastInsertedPKs["table1"])
-	require.Equal(t, 1, summary.UpdatedRows)
+	require.True(t, indexed)
 
-	_, err = engine.ExecStmt("INSERT INTO table1(id, title) VALUES (2, 'name2')", nil, true)
-	require.ErrorIs(t, err, ErrNoValueForAutoIncrementalColumn)
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(name)", nil, true)
+	require.Equal(t, ErrIndexAlreadyExists, err)
 
-	_, err = engine.ExecStmt("UPSERT INTO table1(id, title) VALUES (2, 'name2')", nil, true)
-	require.ErrorIs(t, err, store.ErrKeyNotFound)
+	_, err = engine.ExecStmt("CREATE INDEX ON table2(name)", nil, true)
+	require.Equal(t, ErrTableDoesNotExist, err)
 
-	_, err = engine.ExecStmt("UPSERT INTO table1(id, title) VALUES (1, 'name11')", nil, true)
-	require.NoError(t, err)
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(title)", nil, true)
+	require.Equal(t, ErrColumnDoesNotExist, err)
 
-	err = engine.ReloadCatalog(nil)
+	_, err = engine.ExecStmt("INSERT INTO table1(id, name, age) VALUES (1, 'name1', 50)", nil, true)
 	require.NoError(t, err)
 
-	summary, err = engine.ExecStmt("INSERT INTO table1(title) VALUES ('name2')", nil, true)
-	require.NoError(t, err)
-	require.Empty(t, summary.DDTxs)
-	require.Len(t, summary.DMTxs, 1)
-	require.Equal(t, uint64(3), summary.DMTxs[0].ID)
-	require.Len(t, summary.LastInsertedPKs, 1)
-	require.Equal(t, int64(2), summary.LastInsertedPKs["table1"])
-	require.Equal(t, 1, summary.UpdatedRows)
+	_, err = engine.ExecStmt("INSERT INTO table1(name, age) VALUES ('name2', 10)", nil, true)
+	require.ErrorIs(t, err, ErrPKCanNotBeNull)
 
-	summary, err = engine.ExecStmt(`
-		BEGIN TRANSACTION
-			INSERT INTO table1(title) VALUES ('name3');
-			INSERT INTO table1(title) VALUES ('name4');
-		COMMIT
-	`, nil, true)
-	require.NoError(t, err)
-	require.Empty(t, summary.DDTxs)
-	require.Len(t, summary.DMTxs, 1)
-	require.Equal(t, uint64(4), summary.DMTxs[0].ID)
-	require.Len(t, summary.LastInsertedPKs, 1)
-	require.Equal(t, int64(4), summary.LastInsertedPKs["table1"])
-	require.Equal(t, 2, summary.UpdatedRows)
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(active)", nil, true)
+	require.Equal(t, ErrLimitedIndexCreation, err)
 }
 
-func TestDelete(t *testing.T) {
-	catalogStore, err := store.Open("catalog_delete", store.DefaultOptions())
+func TestDropIndex(t *testing.T) {
+	catalogStore, err := store.Open("catalog_drop_index", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_delete")
+	defer os.RemoveAll("catalog_drop_index")
 
-	dataStore, err := store.Open("sqldata_delete", store.DefaultOptions())
+	dataStore, err := store.Open("sqldata_drop_index", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_delete")
+	defer os.RemoveAll("sqldata_drop_index")
 
 	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
 
+	_, err = engine.ExecStmt("DROP INDEX ON table1(title)", nil, true)
+	require.ErrorIs(t, err, ErrNoDatabaseSelected)
+
 	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("DELETE FROM table1", nil, true)
-	require.ErrorIs(t, err, ErrNoDatabaseSelected)
-
 	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt(`CREATE TABLE table1 (
-		id INTEGER,
-		title VARCHAR[50],
-		active BOOLEAN,
-		PRIMARY KEY id
-	)`, nil, true)
+	_, err = engine.ExecStmt("DROP INDEX ON table1(title)", nil, true)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR[10], amount INTEGER, PRIMARY KEY id)", nil, true)
 	require.NoError(t, err)
 
 	_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table1(title)", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE INDEX ON table1(active)", nil, true)
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(amount)", nil, true)
 	require.NoError(t, err)
 
-	params, err := engine.InferParameters("DELETE FROM table1 WHERE active = @active")
-	require.NoError(t, err)
-	require.NotNil(t, params)
-	require.Len(t, params, 1)
-	require.Equal(t, params["active"], BooleanType)
+	_, err = engine.ExecStmt("DROP INDEX ON table1(name)", nil, true)
+	require.ErrorIs(t, err, ErrColumnDoesNotExist)
 
-	_, err = engine.ExecStmt("DELETE FROM table2", nil, true)
-	require.ErrorIs(t, err, ErrTableDoesNotExist)
-
-	_, err = engine.ExecStmt("DELETE FROM table1 WHERE name = 'name1'", nil, true)
-	require.ErrorIs(t, err, ErrColumnDoesNotExist)
+	_, err = engine.ExecStmt("DROP INDEX ON table1(id)", nil, true)
+	require.ErrorIs(t, err, ErrPKCanNotBeDropped)
 
-	t.Run("delete on empty table should complete without issues", func(t *testing.T) {
-		summary, err := engine.ExecStmt("DELETE FROM table1", nil, true)
-		require.NoError(t, err)
-		require.NotNil(t, summary)
-		require.Zero(t, summary.UpdatedRows)
-	})
+	_, err = engine.ExecStmt("DROP INDEX ON table1(amount, title)", nil, true)
+	require.ErrorIs(t, err, ErrIndexDoesNotExist)
 
-	rowCount := 10
+	_, err = engine.ExecStmt("DROP INDEX IF EXISTS ON table1(amount, title)", nil, true)
+	require.NoError(t, err)
 
-	for i := 0; i < rowCount; i++ {
-		_, err = engine.ExecStmt(fmt.Sprintf(`
-			INSERT INTO table1 (id, title, active) VALUES (%d, 'title%d', %v)`, i, i, i%2 == 0), nil, true)
+	for i := 0; i < 10; i++ {
+		_, err = engine.ExecStmt("INSERT INTO table1 (title, amount) VALUES (@title, @amount)", map[string]interface{}{"title": fmt.Sprintf("title%d", i), "amount": i}, true)
 		require.NoError(t, err)
 	}
 
-	t.Run("deleting with contradiction should not produce any change", func(t *testing.T) {
-		summary, err := engine.ExecStmt("DELETE FROM table1 WHERE false", nil, true)
-		require.NoError(t, err)
-		require.NotNil(t, summary)
-		require.Zero(t, summary.UpdatedRows)
-	})
-
-	t.Run("deleting active rows should remove half of the rows", func(t *testing.T) {
-		summary, err := engine.ExecStmt("DELETE FROM table1 WHERE active = @active", map[string]interface{}{"active": true}, true)
-		require.NoError(t, err)
-		require.NotNil(t, summary)
-		require.Equal(t, rowCount/2, summary.UpdatedRows)
-
-		r, err := engine.QueryStmt("SELECT COUNT() FROM table1", nil, true)
-		require.NoError(t, err)
-
-		row, err := r.Read()
-		require.NoError(t, err)
-		require.Equal(t, int64(rowCount/2), row.Values[EncodeSelector("", "db1", "table1", "col0")].Value())
-
-		err = r.Close()
-		require.NoError(t, err)
-
-		r, err = engine.QueryStmt("SELECT COUNT() FROM table1 WHERE active", nil, true)
-		require.NoError(t, err)
-
-		row, err = r.Read()
-		require.NoError(t, err)
-		require.Equal(t, int64(0), row.Values[EncodeSelector("", "db1", "table1", "col0")].Value())
-
-		err = r.Close()
-		require.NoError(t, err)
-	})
-}
-
-func TestUpdate(t *testing.T) {
-	catalogStore, err := store.Open("catalog_update", store.DefaultOptions())
-	require.NoError(t, err)
-	defer os.RemoveAll("catalog_update")
-
-	dataStore, err := store.Open("sqldata_update", store.DefaultOptions())
-	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_update")
-
-	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
-	require.NoError(t, err)
-
-	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
-	require.NoError(t, err)
-
-	_, err = engine.ExecStmt("UPDATE table1 SET title = 'title11' WHERE title = 'title", nil, true)
-	require.ErrorIs(t, err, ErrNoDatabaseSelected)
-
-	err = engine.UseDatabase("db1")
-	require.NoError(t, err)
+	_, err = engine.ExecStmt("INSERT INTO table1 (title, amount) VALUES ('title1', 100)", nil, true)
+	require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
 
-	_, err = engine.ExecStmt(`CREATE TABLE table1 (
-		id INTEGER,
-		title VARCHAR[50],
-		active BOOLEAN,
-		PRIMARY KEY id
-	)`, nil, true)
+	err = engine.EnsureCatalogReady(nil)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table1(title)", nil, true)
+	table, err := engine.GetTableByName("db1", "table1")
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE INDEX ON table1(active)", nil, true)
-	require.NoError(t, err)
+	droppedIndex := table.indexes[indexKeyFrom([]*Column{table.colsByName["title"]})]
+	require.NotNil(t, droppedIndex)
 
-	params, err := engine.InferParameters("UPDATE table1 SET active = @active")
+	_, err = engine.ExecStmt("DROP INDEX ON table1(title)", nil, true)
 	require.NoError(t, err)
-	require.NotNil(t, params)
-	require.Len(t, params, 1)
-	require.Equal(t, params["active"], BooleanType)
 
-	_, err = engine.ExecStmt("UPDATE table2 SET active = false", nil, true)
-	require.ErrorIs(t, err, ErrTableDoesNotExist)
-
-	_, err = engine.ExecStmt("UPDATE table1 SET name = 'name1'", nil, true)
-	require.ErrorIs(t, err, ErrColumnDoesNotExist)
-
-	t.Run("update on empty table should complete without issues", func(t *testing.T) {
-		summary, err := engine.ExecStmt("UPDATE table1 SET active = false", nil, true)
+	assertDropped := func(t *testing.T) {
+		table, err := engine.GetTableByName("db1", "table1")
 		require.NoError(t, err)
-		require.NotNil(t, summary)
-		require.Zero(t, summary.UpdatedRows)
-	})
-
-	rowCount := 10
+		require.Len(t, table.indexes, 2)
+		require.Equal(t, droppedIndex.id+2, table.nextIndexID)
 
-	for i := 0; i < rowCount; i++ {
-		_, err = engine.ExecStmt(fmt.Sprintf(`
-			INSERT INTO table1 (id, title, active) VALUES (%d, 'title%d', %v)`, i, i, i%2 == 0), nil, true)
+		indexed, err := table.IsIndexed("title")
 		require.NoError(t, err)
-	}
+		require.False(t, indexed)
 
-	t.Run("updating with contradiction should not produce any change", func(t *testing.T) {
-		summary, err := engine.ExecStmt("UPDATE table1 SET active = false WHERE false", nil, true)
+		indexed, err = table.IsIndexed("amount")
 		require.NoError(t, err)
-		require.NotNil(t, summary)
-		require.Zero(t, summary.UpdatedRows)
-	})
+		require.True(t, indexed)
 
-	t.Run("updating specific row should update only one row", func(t *testing.T) {
-		summary, err := engine.ExecStmt("UPDATE table1 SET active = true WHERE title = @title", map[string]interface{}{"title": "title1"}, true)
-		require.NoError(t, err)
-		require.NotNil(t, summary)
-		require.Equal(t, 1, summary.UpdatedRows)
+		_, err = engine.QueryStmt("SELECT id FROM table1 ORDER BY title", nil, true)
+		require.ErrorIs(t, err, ErrLimitedOrderBy)
 
-		r, err := engine.QueryStmt("SELECT COUNT() FROM table1", nil, true)
-		require.NoError(t, err)
+		_, err = engine.QueryStmt("SELECT id FROM table1 USE INDEX ON title", nil, true)
+		require.ErrorIs(t, err, ErrNoAvailableIndex)
 
-		row, err := r.Read()
+		rows, _, err := engine.QueryAll("SELECT id FROM table1 ORDER BY amount", nil)
 		require.NoError(t, err)
-		require.Equal(t, int64(rowCount), row.Values[EncodeSelector("", "db1", "table1", "col0")].Value())
+		require.NotEmpty(t, rows)
+	}
 
-		err = r.Close()
+	t.Run("dropped indexes can not be used", assertDropped)
+
+	t.Run("entries of dropped indexes are deleted", func(t *testing.T) {
+		snap, err := dataStore.Snapshot()
 		require.NoError(t, err)
+		defer snap.Close()
 
-		r, err = engine.QueryStmt("SELECT COUNT() FROM table1 WHERE active", nil, true)
+		reader, err := snap.NewKeyReader(&store.KeyReaderSpec{
+			Prefix: engine.mapKey(UIndexPrefix, EncodeID(1), EncodeID(table.id), EncodeID(droppedIndex.id)),
+			Filter: store.IgnoreDeleted,
+		})
 		require.NoError(t, err)
+		defer reader.Close()
 
-		row, err = r.Read()
+		_, _, err = reader.Read()
+		require.ErrorIs(t, err, store.ErrNoMoreEntries)
+	})
+
+	t.Run("values are no longer unique", func(t *testing.T) {
+		_, err = engine.ExecStmt("INSERT INTO table1 (title, amount) VALUES ('title1', 100)", nil, true)
 		require.NoError(t, err)
-		require.Equal(t, int64(rowCount/2+1), row.Values[EncodeSelector("", "db1", "table1", "col0")].Value())
 
-		err = r.Close()
+		rows, _, err := engine.QueryAll("SELECT id FROM table1 WHERE title = 'title1'", nil)
 		require.NoError(t, err)
+		require.Len(t, rows, 2)
 	})
-}
-func TestTransactions(t *testing.T) {
-	catalogStore, err := store.Open("catalog_tx", store.DefaultOptions())
-	require.NoError(t, err)
-	defer os.RemoveAll("catalog_tx")
 
-	dataStore, err := store.Open("sqldata_tx", store.DefaultOptions())
+	err = engine.Close()
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_tx")
 
-	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	// dropped indexes are not loaded again, nor their ids assigned again
+	engine, err = NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	err = engine.EnsureCatalogReady(nil)
 	require.NoError(t, err)
 
 	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt(`CREATE TABLE table1 (
-									id INTEGER, 
-									title VARCHAR, 
-									PRIMARY KEY id
-								)`, nil, true)
-	require.NoError(t, err)
-
-	_, err = engine.ExecStmt(`
-		BEGIN TRANSACTION
-			CREATE INDEX ON table2(title)
-		COMMIT
-		`, nil, true)
-	require.Equal(t, ErrTableDoesNotExist, err)
-
-	_, err = engine.ExecStmt(`
-		BEGIN TRANSACTION
-			UPSERT INTO table1 (id, title) VALUES (1, 'title1');
-			UPSERT INTO table1 (id, title) VALUES (2, 'title2');
-		COMMIT
-		`, nil, true)
-	require.NoError(t, err)
-
-	_, err = engine.ExecStmt(`
-		BEGIN TRANSACTION
-			CREATE TABLE table2 (id INTEGER, title VARCHAR[100], age INTEGER, PRIMARY KEY id);
-			CREATE INDEX ON table2(title);
-		COMMIT
-		`, nil, true)
-	require.NoError(t, err)
-
-	_, err = engine.ExecStmt(`
-		BEGIN TRANSACTION
-			CREATE INDEX ON table2(age);
-			INSERT INTO table2 (id, title, age) VALUES (1, 'title1', 40);
-		COMMIT
-		`, nil, true)
-	require.Equal(t, ErrDDLorDMLTxOnly, err)
+	t.Run("dropped indexes are not loaded", assertDropped)
 }
 
-func TestUseSnapshot(t *testing.T) {
-	catalogStore, err := store.Open("catalog_snap", store.DefaultOptions())
+func TestMaxIndexesPerTable(t *testing.T) {
+	catalogStore, err := store.Open("catalog_max_indexes", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_snap")
+	defer os.RemoveAll("catalog_max_indexes")
 
-	dataStore, err := store.Open("sqldata_snap", store.DefaultOptions())
+	dataStore, err := store.Open("sqldata_max_indexes", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_snap")
+	defer os.RemoveAll("sqldata_max_indexes")
 
-	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix).WithMaxIndexesPerTable(2))
 	require.NoError(t, err)
 
 	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
@@ -878,307 +988,256 @@ func TestUseSnapshot(t *testing.T) {
 	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, PRIMARY KEY id)", nil, true)
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, name VARCHAR[50], age INTEGER, active BOOLEAN, PRIMARY KEY id)", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("USE SNAPSHOT SINCE TX 1", nil, true)
-	require.Equal(t, ErrNoSupported, err)
-
-	err = engine.UseSnapshot(1, 1)
-	require.Equal(t, ErrTxDoesNotExist, err)
+	_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table1(name)", nil, true)
+	require.NoError(t, err)
 
-	err = engine.UseSnapshot(0, 1)
-	require.Equal(t, ErrTxDoesNotExist, err)
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(age)", nil, true)
+	require.NoError(t, err)
 
-	err = engine.UseSnapshot(1, 1)
-	require.Equal(t, ErrTxDoesNotExist, err)
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(active)", nil, true)
+	require.ErrorIs(t, err, ErrLimitedIndexCreation)
 
-	err = engine.UseSnapshot(1, 2)
-	require.Equal(t, ErrIllegalArguments, err)
+	_, err = engine.ExecStmt("CREATE INDEX IF NOT EXISTS ON table1(age)", nil, true)
+	require.NoError(t, err)
 
-	_, err = engine.ExecStmt(`
-		BEGIN TRANSACTION
-			UPSERT INTO table1 (id, title) VALUES (1, 'title1');
-			UPSERT INTO table1 (id, title) VALUES (2, 'title2');
-		COMMIT
-		`, nil, true)
+	table, err := engine.catalog.dbsByName["db1"].GetTableByName("table1")
 	require.NoError(t, err)
+	require.Len(t, table.indexes, 3)
 
-	err = engine.UseSnapshot(1, 0)
+	// the limit applies to every table on its own
+	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER, active BOOLEAN, PRIMARY KEY id)", nil, true)
 	require.NoError(t, err)
 
-	err = engine.RenewSnapshot()
+	_, err = engine.ExecStmt("CREATE INDEX ON table2(active)", nil, true)
 	require.NoError(t, err)
+}
 
-	err = engine.CloseSnapshot()
+func TestUpsertInto(t *testing.T) {
+	catalogStore, err := store.Open("catalog_upsert", store.DefaultOptions())
 	require.NoError(t, err)
+	defer os.RemoveAll("catalog_upsert")
 
-	err = engine.UseSnapshot(0, 1)
+	dataStore, err := store.Open("sqldata_upsert", store.DefaultOptions())
 	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_upsert")
 
-	err = engine.UseSnapshot(1, 1)
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
-}
 
-func TestEncodeRawValue(t *testing.T) {
-	b, err := EncodeValue(int64(1), IntegerType, 0)
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
 	require.NoError(t, err)
-	require.EqualValues(t, []byte{0, 0, 0, 8, 0, 0, 0, 0, 0, 0, 0, 1}, b)
 
-	b, err = EncodeValue(true, IntegerType, 0)
-	require.ErrorIs(t, err, ErrInvalidValue)
-	require.Nil(t, b)
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title) VALUES (1, 'title1')", nil, true)
+	require.ErrorIs(t, err, ErrNoDatabaseSelected)
 
-	b, err = EncodeValue(true, BooleanType, 0)
+	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
-	require.EqualValues(t, []byte{0, 0, 0, 1, 1}, b)
 
-	b, err = EncodeValue(uint64(1), BooleanType, 0)
-	require.ErrorIs(t, err, ErrInvalidValue)
-	require.Nil(t, b)
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title) VALUES (1, 'title1')", nil, true)
+	require.Equal(t, ErrTableDoesNotExist, err)
 
-	b, err = EncodeValue("title", VarcharType, 0)
+	_, err = engine.ExecStmt(`CREATE TABLE table1 (
+								id INTEGER, 
+								title VARCHAR,
+								amount INTEGER,
+								active BOOLEAN NOT NULL,
+								PRIMARY KEY id)`, nil, true)
 	require.NoError(t, err)
-	require.EqualValues(t, []byte{0, 0, 0, 5, 't', 'i', 't', 'l', 'e'}, b)
-
-	b, err = EncodeValue(uint64(1), VarcharType, 0)
-	require.ErrorIs(t, err, ErrInvalidValue)
-	require.Nil(t, b)
 
-	b, err = EncodeValue([]byte{}, BLOBType, 0)
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(active)", nil, true)
 	require.NoError(t, err)
-	require.EqualValues(t, []byte{0, 0, 0, 0}, b)
 
-	b, err = EncodeValue(nil, BLOBType, 0)
+	_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table1(amount, active)", nil, true)
 	require.NoError(t, err)
-	require.EqualValues(t, []byte{0, 0, 0, 0}, b)
 
-	b, err = EncodeValue(uint64(1), BLOBType, 0)
-	require.ErrorIs(t, err, ErrInvalidValue)
-	require.Nil(t, b)
-
-	b, err = EncodeValue(uint64(1), "invalid type", 0)
-	require.ErrorIs(t, err, ErrInvalidValue)
-	require.Nil(t, b)
-
-	// Max allowed key size is 32 bytes
-	b, err = EncodeValue("012345678901234567890123456789012", VarcharType, 32)
-	require.ErrorIs(t, err, ErrMaxLengthExceeded)
-	require.Nil(t, b)
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title) VALUES (1, 'title1')", nil, true)
+	require.Equal(t, ErrNotNullableColumnCannotBeNull, err)
 
-	_, err = EncodeValue("01234567890123456789012345678902", VarcharType, 0)
-	require.NoError(t, err)
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, age) VALUES (1, 50)", nil, true)
+	require.Equal(t, ErrColumnDoesNotExist, err)
 
-	_, err = EncodeValue("012345678901234567890123456789012", VarcharType, 0)
-	require.NoError(t, err)
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (@id, 'title1', true)", nil, true)
+	require.Equal(t, ErrMissingParameter, err)
 
-	b, err = EncodeValue([]byte{
-		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9,
-		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1, 2,
-	}, BLOBType, 32)
-	require.ErrorIs(t, err, ErrMaxLengthExceeded)
-	require.Nil(t, b)
+	params := make(map[string]interface{}, 1)
+	params["id"] = [4]byte{1, 2, 3, 4}
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (@id, 'title1', true)", params, true)
+	require.Equal(t, ErrUnsupportedParameter, err)
 
-	_, err = EncodeValue([]byte{
-		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9,
-		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1,
-	}, BLOBType, 0)
-	require.NoError(t, err)
+	params = make(map[string]interface{}, 1)
+	params["id"] = []byte{1, 2, 3}
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (@id, 'title1', true)", params, true)
+	require.Equal(t, ErrInvalidValue, err)
 
-	_, err = EncodeValue([]byte{
-		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9,
-		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1, 2,
-	}, BLOBType, 0)
-	require.NoError(t, err)
-}
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (1, @title, false)", nil, true)
+	require.Equal(t, ErrMissingParameter, err)
 
-func TestEncodeValue(t *testing.T) {
-	b, err := EncodeValue((&Number{val: 1}).Value(), IntegerType, 0)
-	require.NoError(t, err)
-	require.EqualValues(t, []byte{0, 0, 0, 8, 0, 0, 0, 0, 0, 0, 0, 1}, b)
+	params = make(map[string]interface{}, 1)
+	params["title"] = uint64(1)
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (1, @title, true)", params, true)
+	require.Equal(t, ErrInvalidValue, err)
 
-	b, err = EncodeValue((&Bool{val: true}).Value(), IntegerType, 0)
-	require.ErrorIs(t, err, ErrInvalidValue)
-	require.Nil(t, b)
+	params = make(map[string]interface{}, 1)
+	params["title"] = uint64(1)
+	params["Title"] = uint64(2)
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (1, @title, true)", params, true)
+	require.Equal(t, ErrDuplicatedParameters, err)
 
-	b, err = EncodeValue((&Bool{val: true}).Value(), BooleanType, 0)
+	summary, err := engine.ExecStmt("UPSERT INTO table1 (id, amount, active) VALUES (1, 10, true)", nil, true)
 	require.NoError(t, err)
-	require.EqualValues(t, []byte{0, 0, 0, 1, 1}, b)
-
-	b, err = EncodeValue((&Number{val: 1}).Value(), BooleanType, 0)
-	require.ErrorIs(t, err, ErrInvalidValue)
-	require.Nil(t, b)
+	require.NotNil(t, summary)
+	require.Equal(t, summary.UpdatedRows, 1)
 
-	b, err = EncodeValue((&Varchar{val: "title"}).Value(), VarcharType, 0)
-	require.NoError(t, err)
-	require.EqualValues(t, []byte{0, 0, 0, 5, 't', 'i', 't', 'l', 'e'}, b)
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, amount, active) VALUES (2, 10, true)", nil, true)
+	require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
 
-	b, err = EncodeValue((&Number{val: 1}).Value(), VarcharType, 0)
-	require.ErrorIs(t, err, ErrInvalidValue)
-	require.Nil(t, b)
+	t.Run("row with pk 1 should have active in false", func(t *testing.T) {
+		summary, err = engine.ExecStmt("UPSERT INTO table1 (id, amount, active) VALUES (1, 20, false)", nil, true)
+		require.NoError(t, err)
+		require.NotNil(t, summary)
+		require.Equal(t, summary.UpdatedRows, 1)
 
-	b, err = EncodeValue((&Blob{val: []byte{}}).Value(), BLOBType, 50)
-	require.NoError(t, err)
-	require.EqualValues(t, []byte{0, 0, 0, 0}, b)
+		r, err := engine.QueryStmt("SELECT amount, active FROM table1 WHERE id = 1", nil, true)
+		require.NoError(t, err)
 
-	b, err = EncodeValue((&Blob{val: nil}).Value(), BLOBType, 50)
-	require.NoError(t, err)
-	require.EqualValues(t, []byte{0, 0, 0, 0}, b)
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.NotNil(t, row)
+		require.Len(t, row.Values, 2)
+		require.Equal(t, int64(20), row.Values[EncodeSelector("", "db1", "table1", "amount")].Value())
+		require.False(t, row.Values[EncodeSelector("", "db1", "table1", "active")].Value().(bool))
 
-	b, err = EncodeValue((&Number{val: 1}).Value(), BLOBType, 50)
-	require.ErrorIs(t, err, ErrInvalidValue)
-	require.Nil(t, b)
+		err = r.Close()
+		require.NoError(t, err)
+	})
 
-	b, err = EncodeValue((&Number{val: 1}).Value(), "invalid type", 50)
-	require.ErrorIs(t, err, ErrInvalidValue)
-	require.Nil(t, b)
+	t.Run("row with pk 1 should have active in true", func(t *testing.T) {
+		summary, err = engine.ExecStmt("UPSERT INTO table1 (id, amount, active) VALUES (1, 10, true)", nil, true)
+		require.NoError(t, err)
+		require.NotNil(t, summary)
+		require.Equal(t, summary.UpdatedRows, 1)
 
-	// Max allowed key size is 32 bytes
-	b, err = EncodeValue((&Varchar{val: "012345678901234567890123456789012"}).Value(), VarcharType, 32)
-	require.ErrorIs(t, err, ErrMaxLengthExceeded)
-	require.Nil(t, b)
+		r, err := engine.QueryStmt("SELECT amount, active FROM table1 WHERE id = 1", nil, true)
+		require.NoError(t, err)
 
-	_, err = EncodeValue((&Varchar{val: "01234567890123456789012345678902"}).Value(), VarcharType, 256)
-	require.NoError(t, err)
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.NotNil(t, row)
+		require.Len(t, row.Values, 2)
+		require.Equal(t, int64(10), row.Values[EncodeSelector("", "db1", "table1", "amount")].Value())
+		require.True(t, row.Values[EncodeSelector("", "db1", "table1", "active")].Value().(bool))
 
-	_, err = EncodeValue((&Varchar{val: "012345678901234567890123456789012"}).Value(), VarcharType, 256)
-	require.NoError(t, err)
+		err = r.Close()
+		require.NoError(t, err)
+	})
 
-	b, err = EncodeValue((&Blob{val: []byte{
-		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9,
-		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1, 2,
-	}}).Value(), BLOBType, 32)
-	require.ErrorIs(t, err, ErrMaxLengthExceeded)
-	require.Nil(t, b)
+	_, err = engine.ExecStmt("UPSERT INTO table1 (Id, Title, Active) VALUES (1, 'some title', false)", nil, true)
+	require.ErrorIs(t, err, ErrIndexedColumnCanNotBeNull)
 
-	_, err = EncodeValue((&Blob{val: []byte{
-		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9,
-		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1,
-	}}).Value(), BLOBType, 256)
+	_, err = engine.ExecStmt("UPSERT INTO table1 (Id, Title, Amount, Active) VALUES (1, 'some title', 100, false)", nil, true)
 	require.NoError(t, err)
 
-	_, err = EncodeValue((&Blob{val: []byte{
-		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9,
-		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1, 2,
-	}}).Value(), BLOBType, 256)
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, amount, active) VALUES (2, 'another title', 200, true)", nil, true)
 	require.NoError(t, err)
-}
 
-func TestClosing(t *testing.T) {
-	catalogStore, err := store.Open("catalog_closing", store.DefaultOptions())
-	require.NoError(t, err)
-	defer os.RemoveAll("catalog_closing")
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id) VALUES (1, 'yat')", nil, true)
+	require.Equal(t, ErrInvalidNumberOfValues, err)
 
-	dataStore, err := store.Open("sqldata_closing", store.DefaultOptions())
-	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_closing")
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, id) VALUES (1, 2)", nil, true)
+	require.Equal(t, ErrDuplicatedColumn, err)
 
-	_, err = NewEngine(nil, nil, nil)
-	require.Equal(t, ErrIllegalArguments, err)
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, active) VALUES ('1', true)", nil, true)
+	require.Equal(t, ErrInvalidValue, err)
 
-	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
-	require.NoError(t, err)
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, active) VALUES (NULL, false)", nil, true)
+	require.Equal(t, ErrPKCanNotBeNull, err)
 
-	err = engine.Close()
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (2, NULL, true)", nil, true)
 	require.NoError(t, err)
 
-	err = engine.Close()
-	require.Equal(t, ErrAlreadyClosed, err)
-
-	_, err = engine.ExistDatabase("db1")
-	require.Equal(t, ErrAlreadyClosed, err)
+	_, err = engine.ExecStmt("UPSERT INTO table1 (title, active) VALUES ('interesting title', true)", nil, true)
+	require.Equal(t, ErrPKCanNotBeNull, err)
 
-	err = engine.UseDatabase("db1")
-	require.Equal(t, ErrAlreadyClosed, err)
+	t.Run("rows in a batch may rely on defaults for listed columns", func(t *testing.T) {
+		_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, amount, active) VALUES (3, 'title3', 30, DEFAULT)", nil, true)
+		require.ErrorIs(t, err, ErrNotNullableColumnCannotBeNull)
 
-	_, err = engine.GetDatabaseByName("db1")
-	require.Equal(t, ErrAlreadyClosed, err)
+		_, err = engine.ExecStmt("UPDATE table1 SET title = DEFAULT WHERE id = 1", nil, true)
+		require.ErrorIs(t, err, ErrIllegalArguments)
 
-	_, err = engine.GetTableByName("db1", "table1")
-	require.Equal(t, ErrAlreadyClosed, err)
+		_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, amount, active) VALUES (3, 'title3', 30, true), (4, DEFAULT, 40, false), (5, 'title5', DEFAULT)", nil, true)
+		require.Equal(t, ErrInvalidNumberOfValues, err)
 
-	_, err = engine.DatabaseInUse()
-	require.Equal(t, ErrAlreadyClosed, err)
+		_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, amount, active) VALUES (3, 'title3', 30, true), (4, 'title4', DEFAULT, false)", nil, true)
+		require.ErrorIs(t, err, ErrIndexedColumnCanNotBeNull)
 
-	err = engine.UseSnapshot(0, 0)
-	require.Equal(t, ErrAlreadyClosed, err)
+		summary, err := engine.ExecStmt("UPSERT INTO table1 (id, title, amount, active) VALUES (3, 'title3', 30, true), (4, DEFAULT, 40, false), (5, NULL, 50, true)", nil, true)
+		require.NoError(t, err)
+		require.Equal(t, 3, summary.UpdatedRows)
 
-	err = engine.RenewSnapshot()
-	require.Equal(t, ErrAlreadyClosed, err)
+		r, err := engine.QueryStmt("SELECT id, title, amount FROM table1 WHERE id >= 3", nil, true)
+		require.NoError(t, err)
 
-	err = engine.CloseSnapshot()
-	require.Equal(t, ErrAlreadyClosed, err)
+		expectedTitles := []interface{}{"title3", nil, nil}
 
-	_, err = engine.InferParameters("CREATE DATABASE db1")
-	require.Equal(t, ErrAlreadyClosed, err)
+		for i, title := range expectedTitles {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Equal(t, int64(i+3), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+			require.Equal(t, title, row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+			require.Equal(t, int64((i+3)*10), row.Values[EncodeSelector("", "db1", "table1", "amount")].Value())
+		}
 
-	_, err = engine.InferParametersPreparedStmt(&TxStmt{})
-	require.Equal(t, ErrAlreadyClosed, err)
+		_, err = r.Read()
+		require.Equal(t, ErrNoMoreRows, err)
 
-	err = engine.EnsureCatalogReady(nil)
-	require.Equal(t, ErrAlreadyClosed, err)
+		err = r.Close()
+		require.NoError(t, err)
+	})
 
-	err = engine.ReloadCatalog(nil)
-	require.Equal(t, ErrAlreadyClosed, err)
+	_, err = engine.ExecStmt("CREATE TABLE IF NOT EXISTS blob_table (id BLOB[2], PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
 }
 
-func TestQuery(t *testing.T) {
-	catalogStore, err := store.Open("catalog_q", store.DefaultOptions())
+func TestTimeParameters(t *testing.T) {
+	catalogStore, err := store.Open("catalog_time_params", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_q")
+	defer os.RemoveAll("catalog_time_params")
 
-	dataStore, err := store.Open("sqldata_q", store.DefaultOptions())
+	dataStore, err := store.Open("sqldata_time_params", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_q")
+	defer os.RemoveAll("sqldata_time_params")
 
 	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
 
-	_, err = engine.QueryStmt("SELECT id FROM table1", nil, true)
-	require.Equal(t, ErrCatalogNotReady, err)
-
 	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.QueryStmt("SELECT id FROM table1", nil, true)
-	require.Equal(t, ErrNoDatabaseSelected, err)
-
-	_, err = engine.QueryStmt("SELECT * FROM table1", nil, true)
-	require.Equal(t, ErrNoDatabaseSelected, err)
-
-	_, err = engine.ExecStmt("SELECT id FROM table1", nil, true)
-	require.Equal(t, ErrNoDatabaseSelected, err)
-
 	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	_, err = engine.QueryStmt("SELECT id FROM db2.table1", nil, true)
-	require.Equal(t, ErrDatabaseDoesNotExist, err)
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, ts TIMESTAMP, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
 
-	_, err = engine.QueryStmt("SELECT id FROM table1", nil, true)
-	require.Equal(t, ErrTableDoesNotExist, err)
+	ts := time.Date(2021, 11, 3, 10, 30, 0, 0, time.UTC)
 
-	_, err = engine.ExecStmt(`CREATE TABLE table1 (
-								id INTEGER,
-								ts INTEGER,
-								title VARCHAR,
-								active BOOLEAN,
-								payload BLOB,
-								PRIMARY KEY id)`, nil, true)
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, ts) VALUES (1, @ts)", map[string]interface{}{"ts": ts}, true)
 	require.NoError(t, err)
 
-	params := make(map[string]interface{})
-	params["id"] = 0
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, ts) VALUES (2, @ts)", map[string]interface{}{"ts": ts.Add(time.Hour)}, true)
+	require.NoError(t, err)
 
-	r, err := engine.QueryStmt("SELECT id FROM db1.table1 WHERE id >= @id", nil, true)
+	r, err := engine.QueryStmt("SELECT id, ts FROM table1 WHERE ts < @ts", map[string]interface{}{"ts": ts.Add(time.Minute)}, true)
 	require.NoError(t, err)
 
-	orderBy := r.OrderBy()
-	require.NotNil(t, orderBy)
-	require.Len(t, orderBy, 1)
-	require.Equal(t, "id", orderBy[0].Column)
-	require.Equal(t, "table1", orderBy[0].Table)
-	require.Equal(t, "db1", orderBy[0].Database)
+	row, err := r.Read()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+	require.Equal(t, ts, row.Values[EncodeSelector("", "db1", "table1", "ts")].Value())
 
 	_, err = r.Read()
 	require.Equal(t, ErrNoMoreRows, err)
@@ -1186,320 +1245,278 @@ func TestQuery(t *testing.T) {
 	err = r.Close()
 	require.NoError(t, err)
 
-	r, err = engine.QueryStmt("SELECT * FROM db1.table1", nil, true)
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, ts) VALUES (3, @ts)", map[string]interface{}{"ts": time.Hour}, true)
+	require.Equal(t, ErrUnsupportedParameter, err)
+}
+
+func TestTimestampType(t *testing.T) {
+	// rows are rewritten when altering column types, thus catalog and data are kept in the same store
+	st, err := store.Open("sqldata_timestamp", store.DefaultOptions())
 	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_timestamp")
 
-	_, err = r.Read()
-	require.Equal(t, ErrNoMoreRows, err)
+	engine, err := NewEngine(st, st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
 
-	err = r.Close()
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
 	require.NoError(t, err)
 
-	rowCount := 10
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
 
-	start := time.Now().UnixNano()
+	_, err = engine.ExecStmt("CREATE TABLE events (id INTEGER AUTO_INCREMENT, ts TIMESTAMP NOT NULL, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
 
-	for i := 0; i < rowCount; i++ {
-		encPayload := hex.EncodeToString([]byte(fmt.Sprintf("blob%d", i)))
-		_, err = engine.ExecStmt(fmt.Sprintf(`
-			UPSERT INTO table1 (id, ts, title, active, payload)
-			VALUES (%d, NOW(), 'title%d', %v, x'%s')
-		`, i, i, i%2 == 0, encPayload), nil, true)
-		require.NoError(t, err)
-	}
+	_, err = engine.ExecStmt("CREATE INDEX ON events(ts)", nil, true)
+	require.NoError(t, err)
 
-	t.Run("should resolve every row", func(t *testing.T) {
-		r, err = engine.QueryStmt("SELECT * FROM table1", nil, true)
+	t1 := time.Date(1969, 12, 31, 23, 0, 0, 0, time.UTC)
+	t2 := time.Date(2021, 11, 3, 10, 30, 0, 0, time.UTC)
+	t3 := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, ts := range []time.Time{t1, t2, t3} {
+		_, err = engine.ExecStmt("INSERT INTO events (ts) VALUES (@ts)", map[string]interface{}{"ts": ts}, true)
 		require.NoError(t, err)
+	}
 
-		colsBySel, err := r.colsBySelector()
+	eventIDs := func(t *testing.T, q string, params map[string]interface{}) []int64 {
+		rows, _, err := engine.QueryAll(q, params)
 		require.NoError(t, err)
-		require.Len(t, colsBySel, 5)
 
-		require.Equal(t, "db1", r.ImplicitDB())
-		require.Equal(t, "table1", r.ImplicitTable())
+		ids := make([]int64, len(rows))
+		for i, row := range rows {
+			ids[i] = row.Values[EncodeSelector("", "db1", "events", "id")].Value().(int64)
+		}
 
-		cols, err := r.Columns()
-		require.NoError(t, err)
-		require.Len(t, cols, 5)
+		return ids
+	}
 
-		for i := 0; i < rowCount; i++ {
-			row, err := r.Read()
-			require.NoError(t, err)
-			require.NotNil(t, row)
-			require.Len(t, row.Values, 5)
-			require.Less(t, int64(start), row.Values[EncodeSelector("", "db1", "table1", "ts")].Value())
-			require.Equal(t, int64(i), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
-			require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
-			require.Equal(t, i%2 == 0, row.Values[EncodeSelector("", "db1", "table1", "active")].Value())
+	t.Run("timestamps not representable as nanoseconds since the unix epoch are rejected", func(t *testing.T) {
+		for _, ts := range []time.Time{time.Date(1677, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2263, 1, 1, 0, 0, 0, 0, time.UTC)} {
+			_, err = engine.ExecStmt("INSERT INTO events (ts) VALUES (@ts)", map[string]interface{}{"ts": ts}, true)
+			require.ErrorIs(t, err, ErrIllegalArguments)
 
-			encPayload := []byte(fmt.Sprintf("blob%d", i))
-			require.Equal(t, []byte(encPayload), row.Values[EncodeSelector("", "db1", "table1", "payload")].Value())
+			_, err = EncodeAsKey(ts, TimestampType, 8)
+			require.ErrorIs(t, err, ErrIllegalArguments)
 		}
 
-		_, err = r.Read()
-		require.Equal(t, ErrNoMoreRows, err)
-
-		err = r.Close()
+		err = engine.EnsureCatalogReady(nil)
 		require.NoError(t, err)
 	})
 
-	t.Run("should fail reading due to non-existent column", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT id1 FROM table1", nil, true)
-		require.NoError(t, err)
+	t.Run("timestamps are sorted by the index", func(t *testing.T) {
+		require.Equal(t, []int64{1, 3, 2}, eventIDs(t, "SELECT id FROM events ORDER BY ts", nil))
+		require.Equal(t, []int64{2, 3, 1}, eventIDs(t, "SELECT id FROM events ORDER BY ts DESC", nil))
+	})
 
-		row, err := r.Read()
-		require.Equal(t, ErrColumnDoesNotExist, err)
-		require.Nil(t, row)
+	t.Run("timestamps are compared with timestamps", func(t *testing.T) {
+		require.Equal(t, []int64{3, 2}, eventIDs(t, "SELECT id FROM events WHERE ts >= @lower ORDER BY ts", map[string]interface{}{"lower": t3}))
+		require.Equal(t, []int64{1}, eventIDs(t, "SELECT id FROM events WHERE ts = @ts", map[string]interface{}{"ts": t1}))
+		require.Equal(t, []int64{1, 2, 3}, eventIDs(t, "SELECT id FROM events WHERE ts < NOW()", nil))
 
-		err = r.Close()
+		rows, _, err := engine.QueryAll("SELECT ts FROM events WHERE id = 2", nil)
 		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, t2, rows[0].Values[EncodeSelector("", "db1", "events", "ts")].Value())
 	})
 
-	t.Run("should resolve every row with two-time table aliasing", func(t *testing.T) {
-		r, err = engine.QueryStmt(fmt.Sprintf(`
-			SELECT * FROM table1 AS mytable1 WHERE mytable1.id >= 0 LIMIT %d
-		`, rowCount), nil, true)
-		require.NoError(t, err)
-
-		colsBySel, err := r.colsBySelector()
-		require.NoError(t, err)
-		require.Len(t, colsBySel, 5)
+	t.Run("timestamps are not compared with integers", func(t *testing.T) {
+		_, _, err := engine.QueryAll("SELECT id FROM events WHERE ts > 0", nil)
+		require.ErrorIs(t, err, ErrNotComparableValues)
 
-		require.Equal(t, "db1", r.ImplicitDB())
-		require.Equal(t, "mytable1", r.ImplicitTable())
+		_, _, err = engine.QueryAll("SELECT id FROM events WHERE ts > @ts", map[string]interface{}{"ts": t2.UnixNano()})
+		require.ErrorIs(t, err, ErrNotComparableValues)
+	})
 
-		cols, err := r.Columns()
+	t.Run("parameters compared with timestamps are inferred as timestamps", func(t *testing.T) {
+		params, err := engine.InferParameters("SELECT id FROM events WHERE ts > @p")
 		require.NoError(t, err)
-		require.Len(t, cols, 5)
-
-		for i := 0; i < rowCount; i++ {
-			row, err := r.Read()
-			require.NoError(t, err)
-			require.NotNil(t, row)
-			require.Len(t, row.Values, 5)
-			require.Less(t, int64(start), row.Values[EncodeSelector("", "db1", "mytable1", "ts")].Value())
-			require.Equal(t, int64(i), row.Values[EncodeSelector("", "db1", "mytable1", "id")].Value())
-			require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "mytable1", "title")].Value())
-			require.Equal(t, i%2 == 0, row.Values[EncodeSelector("", "db1", "mytable1", "active")].Value())
-
-			encPayload := []byte(fmt.Sprintf("blob%d", i))
-			require.Equal(t, []byte(encPayload), row.Values[EncodeSelector("", "db1", "mytable1", "payload")].Value())
-		}
-
-		_, err = r.Read()
-		require.Equal(t, ErrNoMoreRows, err)
+		require.Equal(t, map[string]SQLValueType{"p": TimestampType}, params)
 
-		err = r.Close()
+		params, err = engine.InferParameters("INSERT INTO events (ts) VALUES (@p)")
 		require.NoError(t, err)
+		require.Equal(t, map[string]SQLValueType{"p": TimestampType}, params)
 	})
 
-	t.Run("should resolve every row with column and two-time table aliasing", func(t *testing.T) {
-		r, err = engine.QueryStmt(fmt.Sprintf(`
-			SELECT mytable1.id AS D, ts, Title, payload, Active FROM table1 mytable1 WHERE mytable1.id >= 0 LIMIT %d
-		`, rowCount), nil, true)
+	t.Run("unix nanos are converted to timestamps", func(t *testing.T) {
+		_, err = engine.ExecStmt("CREATE TABLE logs (id INTEGER, ts INTEGER, PRIMARY KEY id)", nil, true)
 		require.NoError(t, err)
 
-		colsBySel, err := r.colsBySelector()
+		_, err = engine.ExecStmt("INSERT INTO logs (id, ts) VALUES (1, @ts)", map[string]interface{}{"ts": t2.UnixNano()}, true)
 		require.NoError(t, err)
-		require.Len(t, colsBySel, 5)
 
-		require.Equal(t, "db1", r.ImplicitDB())
-		require.Equal(t, "mytable1", r.ImplicitTable())
-
-		cols, err := r.Columns()
+		_, err = engine.ExecStmt("ALTER TABLE logs ALTER COLUMN ts TYPE TIMESTAMP", nil, true)
 		require.NoError(t, err)
-		require.Len(t, cols, 5)
-
-		for i := 0; i < rowCount; i++ {
-			row, err := r.Read()
-			require.NoError(t, err)
-			require.NotNil(t, row)
-			require.Len(t, row.Values, 5)
-			require.Less(t, int64(start), row.Values[EncodeSelector("", "db1", "mytable1", "ts")].Value())
-			require.Equal(t, int64(i), row.Values[EncodeSelector("", "db1", "mytable1", "d")].Value())
-			require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "mytable1", "title")].Value())
-			require.Equal(t, i%2 == 0, row.Values[EncodeSelector("", "db1", "mytable1", "active")].Value())
-
-			encPayload := []byte(fmt.Sprintf("blob%d", i))
-			require.Equal(t, []byte(encPayload), row.Values[EncodeSelector("", "db1", "mytable1", "payload")].Value())
-		}
-
-		_, err = r.Read()
-		require.Equal(t, ErrNoMoreRows, err)
 
-		err = r.Close()
+		rows, _, err := engine.QueryAll("SELECT ts FROM logs WHERE ts = @ts", map[string]interface{}{"ts": t2})
 		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, t2, rows[0].Values[EncodeSelector("", "db1", "logs", "ts")].Value())
 	})
 
-	r, err = engine.QueryStmt("SELECT id, title, active, payload FROM table1 ORDER BY title", nil, true)
-	require.Equal(t, ErrLimitedOrderBy, err)
-	require.Nil(t, r)
-
-	r, err = engine.QueryStmt("SELECT Id, Title, Active, payload FROM Table1 ORDER BY Id DESC", nil, true)
+	err = engine.Close()
 	require.NoError(t, err)
+}
 
-	for i := 0; i < rowCount; i++ {
-		row, err := r.Read()
-		require.NoError(t, err)
-		require.NotNil(t, row)
-		require.Len(t, row.Values, 4)
+func TestInsertIntoEdgeCases(t *testing.T) {
+	catalogStore, err := store.Open("catalog_insert", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_insert")
 
-		require.Equal(t, int64(rowCount-1-i), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
-		require.Equal(t, fmt.Sprintf("title%d", rowCount-1-i), row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
-		require.Equal(t, (rowCount-1-i)%2 == 0, row.Values[EncodeSelector("", "db1", "table1", "active")].Value())
+	dataStore, err := store.Open("sqldata_insert", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_insert")
 
-		encPayload := []byte(fmt.Sprintf("blob%d", rowCount-1-i))
-		require.Equal(t, []byte(encPayload), row.Values[EncodeSelector("", "db1", "table1", "payload")].Value())
-	}
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
 
-	err = r.Close()
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
 	require.NoError(t, err)
 
-	r, err = engine.QueryStmt("SELECT id FROM table1 WHERE id", nil, true)
+	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	_, err = r.Read()
-	require.Equal(t, ErrInvalidCondition, err)
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[10], active BOOLEAN, payload BLOB[2], PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
 
-	err = r.Close()
+	_, err = engine.ExecStmt("CREATE INDEX ON table1 (title)", nil, true)
 	require.NoError(t, err)
 
-	params = make(map[string]interface{})
-	params["some_param1"] = true
+	_, err = engine.ExecStmt("CREATE INDEX ON table1 (active)", nil, true)
+	require.NoError(t, err)
 
-	r, err = engine.QueryStmt("SELECT id FROM table1 WHERE active = @some_param1", nil, true)
+	_, err = engine.ExecStmt("CREATE INDEX ON table1 (payload)", nil, true)
 	require.NoError(t, err)
 
-	_, err = r.Read()
-	require.Equal(t, ErrMissingParameter, err)
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (1, 'title1', true, x'00A1')", nil, true)
+	require.NoError(t, err)
 
-	r.SetParameters(params)
+	t.Run("varchar key cases", func(t *testing.T) {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (1, 'title123456789', true, x'00A1')", nil, true)
+		require.ErrorIs(t, err, ErrMaxLengthExceeded)
 
-	row, err := r.Read()
-	require.NoError(t, err)
-	require.Equal(t, int64(2), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (1, 10, true, '00A1')", nil, true)
+		require.ErrorIs(t, err, ErrInvalidValue)
+	})
 
-	err = r.Close()
-	require.NoError(t, err)
+	t.Run("boolean key cases", func(t *testing.T) {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (1, 'title1', 'true', x'00A1')", nil, true)
+		require.ErrorIs(t, err, ErrInvalidValue)
+	})
 
-	params = make(map[string]interface{})
-	params["some_param"] = true
+	t.Run("blob key cases", func(t *testing.T) {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (1, 'title1', true, x'00A100A2')", nil, true)
+		require.ErrorIs(t, err, ErrMaxLengthExceeded)
 
-	encPayloadPrefix := hex.EncodeToString([]byte("blob"))
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (1, 'title1', true, '00A100A2')", nil, true)
+		require.ErrorIs(t, err, ErrInvalidValue)
+	})
 
-	r, err = engine.QueryStmt(fmt.Sprintf(`
-		SELECT id, title, active
-		FROM table1
-		WHERE active = @some_param AND title > 'title' AND payload >= x'%s' AND title LIKE 't`, encPayloadPrefix), params, true)
-	require.NoError(t, err)
+	t.Run("base64 blob literals", func(t *testing.T) {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (2, 'title2', false, b64'AKE=')", nil, true)
+		require.NoError(t, err)
 
-	for i := 0; i < rowCount/2; i += 2 {
-		row, err := r.Read()
+		rows, _, err := engine.QueryAll("SELECT id, payload FROM table1 WHERE payload = b64'AKE='", nil)
 		require.NoError(t, err)
-		require.NotNil(t, row)
-		require.Len(t, row.Values, 3)
+		require.Len(t, rows, 2)
+		require.Equal(t, []byte{0x00, 0xA1}, rows[1].Values[EncodeSelector("", "db1", "table1", "payload")].Value())
 
-		require.Equal(t, int64(i), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
-		require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
-		require.Equal(t, params["some_param"], row.Values[EncodeSelector("", "db1", "table1", "active")].Value())
-	}
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (3, 'title3', false, b64'AKE')", nil, true)
+		require.ErrorIs(t, err, ErrInvalidValue)
 
-	err = r.Close()
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (3, 'title3', false, b64'AK*=')", nil, true)
+		require.ErrorIs(t, err, ErrInvalidValue)
+	})
+
+	err = engine.Close()
 	require.NoError(t, err)
+}
 
-	r, err = engine.QueryStmt("SELECT * FROM table1 WHERE id = 0", nil, true)
+func TestInsertWithValueTruncation(t *testing.T) {
+	catalogStore, err := store.Open("catalog_truncate", store.DefaultOptions())
 	require.NoError(t, err)
+	defer os.RemoveAll("catalog_truncate")
 
-	cols, err := r.Columns()
+	dataStore, err := store.Open("sqldata_truncate", store.DefaultOptions())
 	require.NoError(t, err)
-	require.Len(t, cols, 5)
+	defer os.RemoveAll("sqldata_truncate")
 
-	row, err = r.Read()
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix).WithTruncateValues(true))
 	require.NoError(t, err)
-	require.Len(t, row.Values, 5)
 
-	err = r.Close()
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
 	require.NoError(t, err)
 
-	r, err = engine.QueryStmt("SELECT id, title, active FROM table1 WHERE id / 0", nil, true)
+	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	_, err = r.Read()
-	require.Equal(t, ErrDivisionByZero, err)
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[10], payload BLOB[2], note VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
 
-	err = r.Close()
+	_, err = engine.ExecStmt("CREATE INDEX ON table1 (title)", nil, true)
 	require.NoError(t, err)
 
-	r, err = engine.QueryStmt("SELECT id, title, active FROM table1 WHERE id + 1/1 > 1 * (1 - 0)", nil, true)
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, payload, note) VALUES (1, 'title123456789', x'00A100A2', 'unbounded note')", nil, true)
 	require.NoError(t, err)
 
-	_, err = r.Read()
+	// multi-byte characters are not split
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, payload) VALUES (2, @title, x'00')", map[string]interface{}{"title": "ttítulotítulo"}, true)
 	require.NoError(t, err)
 
-	err = r.Close()
+	rows, _, err := engine.QueryAll("SELECT id, title, payload, note FROM table1 WHERE title = 'title12345'", nil)
 	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, int64(1), rows[0].Values[EncodeSelector("", "db1", "table1", "id")].Value())
+	require.Equal(t, []byte{0x00, 0xA1}, rows[0].Values[EncodeSelector("", "db1", "table1", "payload")].Value())
+	require.Equal(t, "unbounded note", rows[0].Values[EncodeSelector("", "db1", "table1", "note")].Value())
 
-	r, err = engine.QueryStmt("SELECT id, title, active FROM table1 WHERE id = 0 AND NOT active OR active", nil, true)
+	rows, _, err = engine.QueryAll("SELECT title FROM table1 WHERE id = 2", nil)
 	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, "ttítulot", rows[0].Values[EncodeSelector("", "db1", "table1", "title")].Value())
 
-	_, err = r.Read()
+	_, err = engine.ExecStmt("UPDATE table1 SET title = 'updated title' WHERE id = 1", nil, true)
 	require.NoError(t, err)
 
-	err = r.Close()
+	rows, _, err = engine.QueryAll("SELECT title FROM table1 WHERE id = 1", nil)
 	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, "updated ti", rows[0].Values[EncodeSelector("", "db1", "table1", "title")].Value())
 
-	r, err = engine.QueryStmt("INVALID QUERY", nil, false)
-	require.EqualError(t, err, "syntax error: unexpected IDENTIFIER")
-	require.Nil(t, r)
+	err = engine.Close()
+	require.NoError(t, err)
 
-	r, err = engine.QueryStmt("UPSERT INTO table1 (id) VALUES(1)", nil, false)
-	require.ErrorIs(t, err, ErrExpectingDQLStmt)
-	require.Nil(t, r)
+	t.Run("over-length values should be rejected by default", func(t *testing.T) {
+		engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+		require.NoError(t, err)
 
-	r, err = engine.QueryStmt("UPSERT INTO table1 (id) VALUES(1); UPSERT INTO table1 (id) VALUES(1)", nil, false)
-	require.ErrorIs(t, err, ErrExpectingDQLStmt)
-	require.Nil(t, r)
+		err = engine.EnsureCatalogReady(nil)
+		require.NoError(t, err)
 
-	r, err = engine.QueryPreparedStmt(nil, nil, false)
-	require.ErrorIs(t, err, ErrIllegalArguments)
-	require.Nil(t, r)
+		err = engine.UseDatabase("db1")
+		require.NoError(t, err)
 
-	params = make(map[string]interface{})
-	params["null_param"] = nil
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (3, 'title123456789')", nil, true)
+		require.ErrorIs(t, err, ErrMaxLengthExceeded)
 
-	r, err = engine.QueryStmt("SELECT id FROM table1 WHERE active = @null_param", params, true)
-	require.NoError(t, err)
+		_, err = engine.ExecStmt("UPDATE table1 SET payload = x'00A100A2' WHERE id = 1", nil, true)
+		require.ErrorIs(t, err, ErrMaxLengthExceeded)
 
-	_, err = r.Read()
-	require.ErrorIs(t, err, ErrNoMoreRows)
-
-	err = r.Close()
-	require.NoError(t, err)
-
-	err = engine.Close()
-	require.NoError(t, err)
-
-	r, err = engine.QueryStmt("SELECT * FROM table1", nil, true)
-	require.ErrorIs(t, err, ErrAlreadyClosed)
-	require.Nil(t, r)
-
-	r, err = engine.QueryStmt("SELECT * FROM table1", nil, false)
-	require.ErrorIs(t, err, ErrAlreadyClosed)
-	require.Nil(t, r)
+		err = engine.Close()
+		require.NoError(t, err)
+	})
 }
 
-func TestQueryDistinct(t *testing.T) {
-	catalogStore, err := store.Open("catalog_qd", store.DefaultOptions())
+func TestMaxVarcharValueLen(t *testing.T) {
+	catalogStore, err := store.Open("catalog_max_varchar", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_qd")
+	defer os.RemoveAll("catalog_max_varchar")
 
-	dataStore, err := store.Open("sqldata_qd", store.DefaultOptions())
+	dataStore, err := store.Open("sqldata_max_varchar", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_qd")
+	defer os.RemoveAll("sqldata_max_varchar")
 
-	opts := DefaultOptions().WithPrefix(sqlPrefix).WithDistinctLimit(4)
-	engine, err := NewEngine(catalogStore, dataStore, opts)
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix).WithMaxVarcharValueLen(10))
 	require.NoError(t, err)
 
 	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
@@ -1508,207 +1525,157 @@ func TestQueryDistinct(t *testing.T) {
 	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt(`CREATE TABLE table1 (
-								id INTEGER AUTO_INCREMENT,
-								title VARCHAR,
-								amount INTEGER,
-								active BOOLEAN,
-								PRIMARY KEY id)`, nil, true)
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, note VARCHAR, title VARCHAR[20], PRIMARY KEY id)", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt(`INSERT INTO table1 (title, amount, active) VALUES 
-								('title1', 100, NULL),
-								('title2', 200, false),
-								('title3', 200, true),
-								('title4', 300, NULL)`, nil, true)
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, note, title) VALUES (1, 'short', 'longer than the cap')", nil, true)
 	require.NoError(t, err)
 
-	t.Run("should return all titles", func(t *testing.T) {
-		params := make(map[string]interface{})
-		params["id"] = 3
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, note) VALUES (2, 'longer than the cap')", nil, true)
+	require.ErrorIs(t, err, ErrMaxLengthExceeded)
 
-		r, err := engine.QueryStmt("SELECT DISTINCT title FROM table1 WHERE id <= @id", nil, true)
-		require.NoError(t, err)
+	_, err = engine.ExecStmt("UPDATE table1 SET note = @note WHERE id = 1", map[string]interface{}{"note": "longer than the cap"}, true)
+	require.ErrorIs(t, err, ErrMaxLengthExceeded)
 
-		r.SetParameters(params)
+	rows, _, err := engine.QueryAll("SELECT note FROM table1", nil)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, "short", rows[0].Values[EncodeSelector("", "db1", "table1", "note")].Value())
 
-		cols, err := r.Columns()
-		require.NoError(t, err)
-		require.Len(t, cols, 1)
-		require.Equal(t, "(db1.table1.title)", cols[0].Selector())
+	err = engine.Close()
+	require.NoError(t, err)
+}
 
-		for i := 1; i <= 3; i++ {
-			row, err := r.Read()
-			require.NoError(t, err)
-			require.Len(t, row.Values, 1)
-			require.Equal(t, fmt.Sprintf("title%d", i), row.Values["(db1.table1.title)"].Value())
-		}
+func TestGeneratedColumns(t *testing.T) {
+	catalogStore, err := store.Open("catalog_generated", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_generated")
 
-		_, err = r.Read()
-		require.ErrorIs(t, err, ErrNoMoreRows)
+	dataStore, err := store.Open("sqldata_generated", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_generated")
 
-		err = r.Close()
-		require.NoError(t, err)
-	})
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
 
-	t.Run("should return two titles", func(t *testing.T) {
-		params := make(map[string]interface{})
-		params["id"] = 3
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
 
-		r, err := engine.QueryStmt("SELECT DISTINCT title FROM table1 WHERE id <= @id LIMIT 2", nil, true)
-		require.NoError(t, err)
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
 
-		r.SetParameters(params)
+	t.Run("invalid generated columns", func(t *testing.T) {
+		_, err = engine.ExecStmt("CREATE TABLE gtable (id INTEGER, ts TIMESTAMP AS (NOW()) STORED, PRIMARY KEY id)", nil, true)
+		require.ErrorIs(t, err, ErrIllegalGeneratedColumn)
 
-		cols, err := r.Columns()
-		require.NoError(t, err)
-		require.Len(t, cols, 1)
-		require.Equal(t, "(db1.table1.title)", cols[0].Selector())
+		_, err = engine.ExecStmt("CREATE TABLE gtable (id INTEGER, total INTEGER AS (amount * 2) STORED, PRIMARY KEY id)", nil, true)
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
 
-		for i := 1; i <= 2; i++ {
-			row, err := r.Read()
-			require.NoError(t, err)
-			require.Len(t, row.Values, 1)
-			require.Equal(t, fmt.Sprintf("title%d", i), row.Values["(db1.table1.title)"].Value())
-		}
+		_, err = engine.ExecStmt("CREATE TABLE gtable (id INTEGER, flag VARCHAR AS (id * 2) STORED, PRIMARY KEY id)", nil, true)
+		require.ErrorIs(t, err, ErrInvalidTypes)
 
-		_, err = r.Read()
-		require.ErrorIs(t, err, ErrNoMoreRows)
+		_, err = engine.ExecStmt("CREATE TABLE gtable (id INTEGER, a INTEGER AS (b + 1) STORED, b INTEGER AS (id + 1) STORED, PRIMARY KEY id)", nil, true)
+		require.ErrorIs(t, err, ErrIllegalGeneratedColumn)
 
-		err = r.Close()
-		require.NoError(t, err)
+		_, err = engine.ExecStmt("CREATE TABLE gtable (id INTEGER, code INTEGER AS (id + 1) STORED, PRIMARY KEY code)", nil, true)
+		require.ErrorIs(t, err, ErrIllegalGeneratedColumn)
 	})
 
-	t.Run("should return two distinct amounts", func(t *testing.T) {
-		params := make(map[string]interface{})
-		params["id"] = 3
-
-		r, err := engine.QueryStmt("SELECT DISTINCT amount FROM table1 WHERE id <= @id", params, true)
-		require.NoError(t, err)
+	_, err = engine.ExecStmt(`
+		CREATE TABLE orders (
+			id INTEGER,
+			price INTEGER NOT NULL,
+			qty INTEGER NOT NULL DEFAULT 1,
+			total INTEGER AS (price * qty) STORED,
+			big BOOLEAN GENERATED ALWAYS AS (price * qty > 100) STORED NOT NULL,
+			PRIMARY KEY id
+		)`, nil, true)
+	require.NoError(t, err)
 
-		cols, err := r.Columns()
-		require.NoError(t, err)
-		require.Len(t, cols, 1)
-		require.Equal(t, "(db1.table1.amount)", cols[0].Selector())
+	_, err = engine.ExecStmt("CREATE INDEX ON orders(total)", nil, true)
+	require.NoError(t, err)
 
-		for i := 1; i <= 2; i++ {
-			row, err := r.Read()
-			require.NoError(t, err)
-			require.Len(t, row.Values, 1)
-			require.Equal(t, int64(i*100), row.Values["(db1.table1.amount)"].Value())
-		}
+	_, err = engine.ExecStmt("INSERT INTO orders (id, price, qty) VALUES (1, 10, 3), (2, 25, 5)", nil, true)
+	require.NoError(t, err)
 
-		_, err = r.Read()
-		require.ErrorIs(t, err, ErrNoMoreRows)
+	_, err = engine.ExecStmt("INSERT INTO orders (id, price) VALUES (3, 40)", nil, true)
+	require.NoError(t, err)
 
-		err = r.Close()
-		require.NoError(t, err)
-	})
+	_, err = engine.ExecStmt("INSERT INTO orders (id, price, total) VALUES (4, 10, 10)", nil, true)
+	require.ErrorIs(t, err, ErrGeneratedColumnCanNotBeAssigned)
 
-	t.Run("should return rows with null, false and true", func(t *testing.T) {
-		params := make(map[string]interface{})
-		params["id"] = 3
+	_, err = engine.ExecStmt("UPDATE orders SET total = 0 WHERE id = 1", nil, true)
+	require.ErrorIs(t, err, ErrGeneratedColumnCanNotBeAssigned)
 
-		r, err := engine.QueryStmt("SELECT DISTINCT active FROM table1 WHERE id <= @id", params, true)
-		require.NoError(t, err)
+	_, err = engine.ExecStmt("UPDATE orders SET qty = 20 WHERE id = 1", nil, true)
+	require.NoError(t, err)
 
-		cols, err := r.Columns()
+	checkOrders := func() {
+		rows, _, err := engine.QueryAll("SELECT id, total, big FROM orders USE INDEX ON total ORDER BY total", nil)
 		require.NoError(t, err)
-		require.Len(t, cols, 1)
-		require.Equal(t, "(db1.table1.active)", cols[0].Selector())
-
-		for i := 0; i <= 2; i++ {
-			row, err := r.Read()
-			require.NoError(t, err)
-			require.Len(t, row.Values, 1)
-
-			if i == 0 {
-				require.Nil(t, row.Values["(db1.table1.active)"].Value())
-				continue
-			}
+		require.Len(t, rows, 3)
 
-			require.Equal(t, i == 2, row.Values["(db1.table1.active)"].Value())
+		expected := []struct {
+			id    int64
+			total int64
+			big   bool
+		}{
+			{id: 3, total: 40, big: false},
+			{id: 2, total: 125, big: true},
+			{id: 1, total: 200, big: true},
 		}
 
-		_, err = r.Read()
-		require.ErrorIs(t, err, ErrNoMoreRows)
-
-		err = r.Close()
-		require.NoError(t, err)
-	})
-
-	t.Run("should return three rows", func(t *testing.T) {
-		params := make(map[string]interface{})
-		params["id"] = 3
-
-		r, err := engine.QueryStmt("SELECT DISTINCT amount, active FROM table1 WHERE id <= @id", params, true)
-		require.NoError(t, err)
+		for i, e := range expected {
+			require.Equal(t, e.id, rows[i].Values[EncodeSelector("", "db1", "orders", "id")].Value())
+			require.Equal(t, e.total, rows[i].Values[EncodeSelector("", "db1", "orders", "total")].Value())
+			require.Equal(t, e.big, rows[i].Values[EncodeSelector("", "db1", "orders", "big")].Value())
+		}
 
-		cols, err := r.Columns()
+		rows, _, err = engine.QueryAll("SELECT id FROM orders WHERE total = 125", nil)
 		require.NoError(t, err)
-		require.Len(t, cols, 2)
-		require.Equal(t, "(db1.table1.amount)", cols[0].Selector())
-		require.Equal(t, "(db1.table1.active)", cols[1].Selector())
-
-		for i := 0; i <= 2; i++ {
-			row, err := r.Read()
-			require.NoError(t, err)
-			require.Len(t, row.Values, 2)
-
-			if i == 0 {
-				require.Equal(t, int64(100), row.Values["(db1.table1.amount)"].Value())
-				require.Nil(t, row.Values["(db1.table1.active)"].Value())
-				continue
-			}
-
-			require.Equal(t, int64(200), row.Values["(db1.table1.amount)"].Value())
-			require.Equal(t, i == 2, row.Values["(db1.table1.active)"].Value())
-		}
+		require.Len(t, rows, 1)
+		require.Equal(t, int64(2), rows[0].Values[EncodeSelector("", "db1", "orders", "id")].Value())
+	}
 
-		_, err = r.Read()
-		require.ErrorIs(t, err, ErrNoMoreRows)
+	checkOrders()
 
-		err = r.Close()
-		require.NoError(t, err)
-	})
+	err = engine.Close()
+	require.NoError(t, err)
 
-	t.Run("should return too many rows error", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT DISTINCT id FROM table1", nil, true)
-		require.NoError(t, err)
+	// generation expressions are persisted in the catalog
+	engine, err = NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
 
-		cols, err := r.Columns()
-		require.NoError(t, err)
-		require.Len(t, cols, 1)
-		require.Equal(t, "(db1.table1.id)", cols[0].Selector())
+	err = engine.EnsureCatalogReady(nil)
+	require.NoError(t, err)
 
-		for i := 0; i < engine.distinctLimit; i++ {
-			row, err := r.Read()
-			require.NoError(t, err)
-			require.Len(t, row.Values, 1)
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
 
-			require.Equal(t, int64(i+1), row.Values["(db1.table1.id)"].Value())
-		}
+	_, err = engine.ExecStmt("UPSERT INTO orders (id, price, qty) VALUES (3, 5, 2)", nil, true)
+	require.NoError(t, err)
 
-		_, err = r.Read()
-		require.ErrorIs(t, err, ErrTooManyRows)
+	rows, _, err := engine.QueryAll("SELECT total, big FROM orders WHERE id = 3", nil)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, int64(10), rows[0].Values[EncodeSelector("", "db1", "orders", "total")].Value())
+	require.Equal(t, false, rows[0].Values[EncodeSelector("", "db1", "orders", "big")].Value())
 
-		err = r.Close()
-		require.NoError(t, err)
-	})
+	_, err = engine.ExecStmt("ALTER TABLE orders ALTER COLUMN total DROP DEFAULT", nil, true)
+	require.ErrorIs(t, err, ErrIllegalArguments)
 
 	err = engine.Close()
 	require.NoError(t, err)
-
 }
 
-func TestIndexing(t *testing.T) {
-	catalogStore, err := store.Open("catalog_indexing", store.DefaultOptions())
+func TestAutoIncrementPK(t *testing.T) {
+	catalogStore, err := store.Open("catalog_auto_inc", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_indexing")
+	defer os.RemoveAll("catalog_auto_inc")
 
-	dataStore, err := store.Open("sqldata_indexing", store.DefaultOptions())
+	dataStore, err := store.Open("sqldata_auto_inc", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_indexing")
+	defer os.RemoveAll("sqldata_auto_inc")
 
 	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
@@ -1719,564 +1686,5621 @@ func TestIndexing(t *testing.T) {
 	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt(`CREATE TABLE table1 (
-								id INTEGER AUTO_INCREMENT, 
-								ts INTEGER, 
-								title VARCHAR[20], 
-								active BOOLEAN,
-								amount INTEGER,
-								payload BLOB, 
-								PRIMARY KEY id
-							)`, nil, true)
-	require.NoError(t, err)
+	t.Run("invalid use of auto-increment", func(t *testing.T) {
+		_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR AUTO_INCREMENT, PRIMARY KEY id)", nil, true)
+		require.ErrorIs(t, err, ErrLimitedAutoIncrement)
 
-	_, err = engine.ExecStmt("CREATE INDEX ON table1 (ts)", nil, true)
+		_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, age INTEGER AUTO_INCREMENT, PRIMARY KEY id)", nil, true)
+		require.ErrorIs(t, err, ErrLimitedAutoIncrement)
+
+		_, err = engine.ExecStmt("CREATE TABLE table1 (id VARCHAR AUTO_INCREMENT, title VARCHAR, PRIMARY KEY id)", nil, true)
+		require.ErrorIs(t, err, ErrLimitedAutoIncrement)
+	})
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR, PRIMARY KEY id)", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table1 (title, amount)", nil, true)
+	summary, err := engine.ExecStmt("INSERT INTO table1(title) VALUES ('name1')", nil, true)
 	require.NoError(t, err)
+	require.Empty(t, summary.DDTxs)
+	require.Len(t, summary.DMTxs, 1)
+	require.Equal(t, uint64(1), summary.DMTxs[0].ID)
+	require.Len(t, summary.LastInsertedPKs, 1)
+	require.Equal(t, int64(1), summary.LastInsertedPKs["table1"])
+	require.Equal(t, 1, summary.UpdatedRows)
 
-	_, err = engine.ExecStmt("CREATE INDEX ON table1 (active, title)", nil, true)
+	_, err = engine.ExecStmt("INSERT INTO table1(id, title) VALUES (2, 'name2')", nil, true)
+	require.ErrorIs(t, err, ErrNoValueForAutoIncrementalColumn)
+
+	_, err = engine.ExecStmt("UPSERT INTO table1(id, title) VALUES (2, 'name2')", nil, true)
+	require.ErrorIs(t, err, store.ErrKeyNotFound)
+
+	_, err = engine.ExecStmt("UPSERT INTO table1(id, title) VALUES (1, 'name11')", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table1 (title)", nil, true)
+	err = engine.ReloadCatalog(nil)
 	require.NoError(t, err)
 
-	t.Run("should fail due to unique index", func(t *testing.T) {
-		_, err = engine.ExecStmt("INSERT INTO table1 (ts, title, amount, active) VALUES (1, 'title1', 10, true), (2, 'title1', 10, false)", nil, true)
-		require.ErrorIs(t, err, store.ErrDuplicatedKey)
-	})
+	summary, err = engine.ExecStmt("INSERT INTO table1(title) VALUES ('name2')", nil, true)
+	require.NoError(t, err)
+	require.Empty(t, summary.DDTxs)
+	require.Len(t, summary.DMTxs, 1)
+	require.Equal(t, uint64(3), summary.DMTxs[0].ID)
+	require.Len(t, summary.LastInsertedPKs, 1)
+	require.Equal(t, int64(2), summary.LastInsertedPKs["table1"])
+	require.Equal(t, 1, summary.UpdatedRows)
 
-	require.NoError(t, engine.EnsureCatalogReady(nil))
+	summary, err = engine.ExecStmt(`
+		BEGIN TRANSACTION
+			INSERT INTO table1(title) VALUES ('name3');
+			INSERT INTO table1(title) VALUES ('name4');
+		COMMIT
+	`, nil, true)
+	require.NoError(t, err)
+	require.Empty(t, summary.DDTxs)
+	require.Len(t, summary.DMTxs, 1)
+	require.Equal(t, uint64(4), summary.DMTxs[0].ID)
+	require.Len(t, summary.LastInsertedPKs, 1)
+	require.Equal(t, int64(4), summary.LastInsertedPKs["table1"])
+	require.Equal(t, 2, summary.UpdatedRows)
 
-	t.Run("should fail due non-available index", func(t *testing.T) {
-		_, err = engine.QueryStmt("SELECT * FROM table1 ORDER BY amount DESC", nil, true)
-		require.ErrorIs(t, err, ErrNoAvailableIndex)
-	})
+	summary, err = engine.ExecStmt("INSERT INTO table1(id, title) VALUES (DEFAULT, 'name5'), (DEFAULT, 'name6')", nil, true)
+	require.NoError(t, err)
+	require.Equal(t, int64(6), summary.LastInsertedPKs["table1"])
+	require.Equal(t, 2, summary.UpdatedRows)
 
-	require.NoError(t, engine.EnsureCatalogReady(nil))
+	t.Run("explicit auto-increment value", func(t *testing.T) {
+		_, err = engine.ExecStmt("ALTER TABLE table1 AUTO_INCREMENT = 6", nil, true)
+		require.ErrorIs(t, err, ErrIllegalArguments)
 
-	t.Run("should use primary index by default", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT * FROM table1", nil, true)
+		_, err = engine.ExecStmt("ALTER TABLE table1 AUTO_INCREMENT > 10", nil, true)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = engine.ExecStmt("ALTER TABLE table2 AUTO_INCREMENT = 10", nil, true)
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+		_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER, PRIMARY KEY id)", nil, true)
 		require.NoError(t, err)
 
-		orderBy := r.OrderBy()
-		require.NotNil(t, orderBy)
-		require.Len(t, orderBy, 1)
-		require.Equal(t, "id", orderBy[0].Column)
+		_, err = engine.ExecStmt("ALTER TABLE table2 AUTO_INCREMENT = 10", nil, true)
+		require.ErrorIs(t, err, ErrIllegalArguments)
 
-		scanSpecs := r.ScanSpecs()
-		require.NotNil(t, scanSpecs)
-		require.NotNil(t, scanSpecs.index)
-		require.True(t, scanSpecs.index.IsPrimary())
-		require.Empty(t, scanSpecs.rangesByColID)
-		require.False(t, scanSpecs.descOrder)
+		_, err = engine.ExecStmt("ALTER TABLE table1 AUTO_INCREMENT = 100", nil, true)
+		require.NoError(t, err)
 
-		err = r.Close()
+		summary, err = engine.ExecStmt("INSERT INTO table1(title) VALUES ('name100')", nil, true)
 		require.NoError(t, err)
-	})
+		require.Equal(t, int64(100), summary.LastInsertedPKs["table1"])
 
-	t.Run("should use primary index in descending order", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT * FROM table1 ORDER BY id DESC", nil, true)
+		_, err = engine.ExecStmt("ALTER TABLE table1 AUTO_INCREMENT = 200", nil, true)
 		require.NoError(t, err)
 
-		orderBy := r.OrderBy()
-		require.NotNil(t, orderBy)
-		require.Len(t, orderBy, 1)
-		require.Equal(t, "id", orderBy[0].Column)
+		// the sequence value is persisted in the catalog, even when no row uses it yet
+		err = engine.ReloadCatalog(nil)
+		require.NoError(t, err)
 
-		scanSpecs := r.ScanSpecs()
-		require.NotNil(t, scanSpecs)
-		require.NotNil(t, scanSpecs.index)
-		require.True(t, scanSpecs.index.IsPrimary())
-		require.Empty(t, scanSpecs.rangesByColID)
-		require.True(t, scanSpecs.descOrder)
+		summary, err = engine.ExecStmt("INSERT INTO table1(title) VALUES ('name200')", nil, true)
+		require.NoError(t, err)
+		require.Equal(t, int64(200), summary.LastInsertedPKs["table1"])
 
-		err = r.Close()
+		// rows inserted after the sequence was set are taken into account when reloading the catalog
+		err = engine.ReloadCatalog(nil)
 		require.NoError(t, err)
-	})
 
-	t.Run("should use index on `ts` ascending order", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT * FROM table1 ORDER BY ts", nil, true)
+		summary, err = engine.ExecStmt("INSERT INTO table1(title) VALUES ('name201')", nil, true)
 		require.NoError(t, err)
+		require.Equal(t, int64(201), summary.LastInsertedPKs["table1"])
+	})
+}
 
-		orderBy := r.OrderBy()
-		require.NotNil(t, orderBy)
-		require.Len(t, orderBy, 1)
-		require.Equal(t, "ts", orderBy[0].Column)
+func TestColumnDefaultValues(t *testing.T) {
+	catalogStore, err := store.Open("catalog_col_defaults", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_col_defaults")
 
-		scanSpecs := r.ScanSpecs()
-		require.NotNil(t, scanSpecs)
-		require.NotNil(t, scanSpecs.index)
-		require.False(t, scanSpecs.index.IsPrimary())
-		require.False(t, scanSpecs.index.IsUnique())
-		require.Len(t, scanSpecs.index.cols, 1)
-		require.Empty(t, scanSpecs.rangesByColID)
-		require.False(t, scanSpecs.descOrder)
+	dataStore, err := store.Open("sqldata_col_defaults", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_col_defaults")
 
-		err = r.Close()
-		require.NoError(t, err)
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	t.Run("invalid default values", func(t *testing.T) {
+		for _, stmt := range []string{
+			"CREATE TABLE table1 (id INTEGER, title VARCHAR DEFAULT 10, PRIMARY KEY id)",
+			"CREATE TABLE table1 (id INTEGER, title VARCHAR[2] DEFAULT 'title', PRIMARY KEY id)",
+			"CREATE TABLE table1 (id INTEGER, title VARCHAR DEFAULT @title, PRIMARY KEY id)",
+			"CREATE TABLE table1 (id INTEGER, title VARCHAR DEFAULT name, PRIMARY KEY id)",
+			"CREATE TABLE table1 (id INTEGER, title VARCHAR DEFAULT TRIM(name), PRIMARY KEY id)",
+			"CREATE TABLE table1 (id INTEGER, title VARCHAR NOT NULL DEFAULT NULL, PRIMARY KEY id)",
+			"CREATE TABLE table1 (id INTEGER AUTO_INCREMENT DEFAULT 1, PRIMARY KEY id)",
+			"CREATE TABLE table1 (id INTEGER, ts INTEGER DEFAULT CURRENT_DATABASE(), PRIMARY KEY id)",
+		} {
+			_, err = engine.ExecStmt(stmt, nil, true)
+			require.ErrorIs(t, err, ErrIllegalDefaultValue, stmt)
+		}
 	})
 
-	t.Run("should use index on `ts` descending order", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT * FROM table1 ORDER BY ts DESC", nil, true)
-		require.NoError(t, err)
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table1 (
+			id INTEGER AUTO_INCREMENT,
+			title VARCHAR[20] DEFAULT TRIM('  untitled '),
+			qty INTEGER NOT NULL DEFAULT -1,
+			active BOOLEAN DEFAULT true,
+			note VARCHAR DEFAULT NULL,
+			db VARCHAR DEFAULT CURRENT_DATABASE(),
+			ts TIMESTAMP DEFAULT NOW(),
+			PRIMARY KEY id
+		)`, nil, true)
+	require.NoError(t, err)
 
-		orderBy := r.OrderBy()
-		require.NotNil(t, orderBy)
-		require.Len(t, orderBy, 1)
-		require.Equal(t, "ts", orderBy[0].Column)
+	_, err = engine.ExecStmt("CREATE TABLE table2 (LIKE table1)", nil, true)
+	require.NoError(t, err)
 
-		scanSpecs := r.ScanSpecs()
-		require.NotNil(t, scanSpecs)
-		require.NotNil(t, scanSpecs.index)
-		require.False(t, scanSpecs.index.IsPrimary())
-		require.False(t, scanSpecs.index.IsUnique())
-		require.Len(t, scanSpecs.index.cols, 1)
-		require.Empty(t, scanSpecs.rangesByColID)
-		require.True(t, scanSpecs.descOrder)
+	assertRow := func(t *testing.T, table string, id int64, title string, qty int64, active bool) {
+		rows, _, err := engine.QueryAll(fmt.Sprintf("SELECT title, qty, active, note, db, ts FROM %s WHERE id = @id", table), map[string]interface{}{"id": id})
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
 
-		err = r.Close()
+		vals := rows[0].Values
+		require.Equal(t, title, vals[EncodeSelector("", "db1", table, "title")].Value())
+		require.Equal(t, qty, vals[EncodeSelector("", "db1", table, "qty")].Value())
+		require.Equal(t, active, vals[EncodeSelector("", "db1", table, "active")].Value())
+		require.Nil(t, vals[EncodeSelector("", "db1", table, "note")].Value())
+		require.Equal(t, "db1", vals[EncodeSelector("", "db1", table, "db")].Value())
+		require.NotZero(t, vals[EncodeSelector("", "db1", table, "ts")].Value())
+	}
+
+	for _, table := range []string{"table1", "table2"} {
+		_, err = engine.ExecStmt(fmt.Sprintf("INSERT INTO %s (active) VALUES (false)", table), nil, true)
 		require.NoError(t, err)
-	})
 
-	t.Run("should use index on `ts` with specific value", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT * FROM table1 WHERE ts = 1629902962 OR ts < 1629902963 ORDER BY ts", nil, true)
+		_, err = engine.ExecStmt(fmt.Sprintf("INSERT INTO %s (title, qty, active) VALUES ('title2', 2, DEFAULT)", table), nil, true)
 		require.NoError(t, err)
 
-		orderBy := r.OrderBy()
-		require.NotNil(t, orderBy)
-		require.Len(t, orderBy, 1)
-		require.Equal(t, "ts", orderBy[0].Column)
+		assertRow(t, table, 1, "untitled", -1, false)
+		assertRow(t, table, 2, "title2", 2, true)
+	}
 
-		scanSpecs := r.ScanSpecs()
-		require.NotNil(t, scanSpecs)
-		require.NotNil(t, scanSpecs.index)
-		require.False(t, scanSpecs.index.IsPrimary())
-		require.False(t, scanSpecs.index.IsUnique())
-		require.Len(t, scanSpecs.index.cols, 1)
-		require.Len(t, scanSpecs.rangesByColID, 1)
+	_, err = engine.ExecStmt("INSERT INTO table1 (qty) VALUES (NULL)", nil, true)
+	require.ErrorIs(t, err, ErrNotNullableColumnCannotBeNull)
 
-		tsRange := scanSpecs.rangesByColID[2]
-		require.Nil(t, tsRange.lRange)
-		require.NotNil(t, tsRange.hRange)
-		require.False(t, tsRange.hRange.inclusive)
-		require.Equal(t, int64(1629902963), tsRange.hRange.val.Value())
+	// default values are kept in the catalog
+	err = engine.ReloadCatalog(nil)
+	require.NoError(t, err)
 
-		require.False(t, scanSpecs.descOrder)
+	_, err = engine.ExecStmt("INSERT INTO table1 (id) VALUES (DEFAULT)", nil, true)
+	require.NoError(t, err)
 
-		err = r.Close()
-		require.NoError(t, err)
-	})
+	assertRow(t, "table1", 3, "untitled", -1, true)
 
-	t.Run("should use index on `ts` with specific value", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT * FROM table1 AS t WHERE t.ts = 1629902962 AND t.ts = 1629902963 ORDER BY t.ts", nil, true)
+	t.Run("dropped default values should no longer be used", func(t *testing.T) {
+		_, err = engine.ExecStmt("ALTER TABLE table3 ALTER COLUMN title DROP DEFAULT", nil, true)
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+		_, err = engine.ExecStmt("ALTER TABLE table1 ALTER COLUMN name DROP DEFAULT", nil, true)
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+		_, err = engine.ExecStmt(`
+			ALTER TABLE table1 ALTER COLUMN title DROP DEFAULT;
+			ALTER TABLE table1 ALTER COLUMN qty DROP DEFAULT;
+			ALTER TABLE table1 ALTER COLUMN note DROP DEFAULT;
+		`, nil, true)
 		require.NoError(t, err)
 
-		orderBy := r.OrderBy()
-		require.NotNil(t, orderBy)
-		require.Len(t, orderBy, 1)
-		require.Equal(t, "ts", orderBy[0].Column)
+		_, err = engine.ExecStmt("INSERT INTO table1 (title) VALUES ('title4')", nil, true)
+		require.ErrorIs(t, err, ErrNotNullableColumnCannotBeNull)
 
-		scanSpecs := r.ScanSpecs()
-		require.NotNil(t, scanSpecs)
-		require.NotNil(t, scanSpecs.index)
-		require.False(t, scanSpecs.index.IsPrimary())
-		require.False(t, scanSpecs.index.IsUnique())
-		require.Len(t, scanSpecs.index.cols, 1)
-		require.Len(t, scanSpecs.rangesByColID, 1)
+		// dropped default values are removed from the catalog
+		err = engine.ReloadCatalog(nil)
+		require.NoError(t, err)
 
-		tsRange := scanSpecs.rangesByColID[2]
-		require.NotNil(t, tsRange.lRange)
-		require.True(t, tsRange.lRange.inclusive)
-		require.Equal(t, int64(1629902963), tsRange.lRange.val.Value())
-		require.NotNil(t, tsRange.hRange)
-		require.True(t, tsRange.hRange.inclusive)
-		require.Equal(t, int64(1629902962), tsRange.hRange.val.Value())
+		_, err = engine.ExecStmt("INSERT INTO table1 (qty) VALUES (4)", nil, true)
+		require.NoError(t, err)
 
-		require.False(t, scanSpecs.descOrder)
+		rows, _, err := engine.QueryAll("SELECT title, active FROM table1 WHERE id = 4", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Nil(t, rows[0].Values[EncodeSelector("", "db1", "table1", "title")].Value())
+		require.Equal(t, true, rows[0].Values[EncodeSelector("", "db1", "table1", "active")].Value())
 
-		err = r.Close()
+		_, err = engine.ExecStmt("INSERT INTO table1 (title, qty) VALUES (DEFAULT, 5)", nil, true)
 		require.NoError(t, err)
 	})
 
-	t.Run("should use index on `ts` with specific value", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT * FROM table1 WHERE ts > 1629902962 AND ts < 1629902963 ORDER BY ts", nil, true)
-		require.NoError(t, err)
+	err = engine.Close()
+	require.NoError(t, err)
+}
 
-		orderBy := r.OrderBy()
-		require.NotNil(t, orderBy)
-		require.Len(t, orderBy, 1)
-		require.Equal(t, "ts", orderBy[0].Column)
+func TestAlterColumnType(t *testing.T) {
+	// rows are rewritten along with the catalog, thus both are kept in the same store
+	st, err := store.Open("sqldata_alter_type", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_alter_type")
 
-		scanSpecs := r.ScanSpecs()
-		require.NotNil(t, scanSpecs)
-		require.NotNil(t, scanSpecs.index)
-		require.False(t, scanSpecs.index.IsPrimary())
-		require.False(t, scanSpecs.index.IsUnique())
-		require.Len(t, scanSpecs.index.cols, 1)
-		require.Len(t, scanSpecs.rangesByColID, 1)
+	engine, err := NewEngine(st, st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
 
-		tsRange := scanSpecs.rangesByColID[2]
-		require.NotNil(t, tsRange.lRange)
-		require.False(t, tsRange.lRange.inclusive)
-		require.Equal(t, int64(1629902962), tsRange.lRange.val.Value())
-		require.NotNil(t, tsRange.hRange)
-		require.False(t, tsRange.hRange.inclusive)
-		require.Equal(t, int64(1629902963), tsRange.hRange.val.Value())
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
 
-		require.False(t, scanSpecs.descOrder)
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
 
-		err = r.Close()
-		require.NoError(t, err)
-	})
+	_, err = engine.ExecStmt(`
+		CREATE TABLE items (
+			id INTEGER AUTO_INCREMENT,
+			code INTEGER,
+			label VARCHAR DEFAULT 'none',
+			qty INTEGER DEFAULT 1,
+			PRIMARY KEY id
+		)`, nil, true)
+	require.NoError(t, err)
 
-	t.Run("should use index on `title, amount` in asc order", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT * FROM table1 USE INDEX ON (title, amount) ORDER BY title", nil, true)
+	_, err = engine.ExecStmt("CREATE INDEX ON items(qty)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO items (code, label) VALUES (10, 'ten'), (20, '20'), (NULL, 'abc')", nil, true)
+	require.NoError(t, err)
+
+	t.Run("integer values are converted to varchar", func(t *testing.T) {
+		_, err = engine.ExecStmt("ALTER TABLE items ALTER COLUMN code TYPE VARCHAR", nil, true)
 		require.NoError(t, err)
 
-		orderBy := r.OrderBy()
-		require.NotNil(t, orderBy)
-		require.Len(t, orderBy, 2)
-		require.Equal(t, "title", orderBy[0].Column)
-		require.Equal(t, "amount", orderBy[1].Column)
+		rows, _, err := engine.QueryAll("SELECT id, code FROM items", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 3)
+		require.Equal(t, "10", rows[0].Values[EncodeSelector("", "db1", "items", "code")].Value())
+		require.Equal(t, "20", rows[1].Values[EncodeSelector("", "db1", "items", "code")].Value())
+		require.Nil(t, rows[2].Values[EncodeSelector("", "db1", "items", "code")].Value())
 
-		scanSpecs := r.ScanSpecs()
-		require.NotNil(t, scanSpecs)
-		require.NotNil(t, scanSpecs.index)
-		require.False(t, scanSpecs.index.IsPrimary())
-		require.True(t, scanSpecs.index.IsUnique())
-		require.Len(t, scanSpecs.index.cols, 2)
-		require.Empty(t, scanSpecs.rangesByColID)
-		require.False(t, scanSpecs.descOrder)
+		_, err = engine.ExecStmt("INSERT INTO items (code) VALUES ('A-30')", nil, true)
+		require.NoError(t, err)
 
-		err = r.Close()
+		rows, _, err = engine.QueryAll("SELECT id FROM items WHERE code = '20'", nil)
 		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, int64(2), rows[0].Values[EncodeSelector("", "db1", "items", "id")].Value())
 	})
 
-	t.Run("should use index on `title` in asc order", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT * FROM table1 USE INDEX ON (title) ORDER BY title", nil, true)
+	t.Run("values which can not be converted reject the change", func(t *testing.T) {
+		_, err = engine.ExecStmt("ALTER TABLE items ALTER COLUMN label TYPE INTEGER", nil, true)
+		require.ErrorIs(t, err, ErrInvalidConversion)
+
+		_, err = engine.ExecStmt("ALTER TABLE items ALTER COLUMN code TYPE VARCHAR[2]", nil, true)
+		require.ErrorIs(t, err, ErrMaxLengthExceeded)
+
+		// the default value must be converted as well
+		_, err = engine.ExecStmt("DELETE FROM items WHERE label = 'ten' OR label = 'abc'", nil, true)
 		require.NoError(t, err)
 
-		orderBy := r.OrderBy()
-		require.NotNil(t, orderBy)
-		require.Len(t, orderBy, 1)
-		require.Equal(t, "title", orderBy[0].Column)
+		_, err = engine.ExecStmt("UPDATE items SET label = '4' WHERE id = 4", nil, true)
+		require.NoError(t, err)
 
-		scanSpecs := r.ScanSpecs()
-		require.NotNil(t, scanSpecs)
-		require.NotNil(t, scanSpecs.index)
-		require.False(t, scanSpecs.index.IsPrimary())
-		require.True(t, scanSpecs.index.IsUnique())
-		require.Len(t, scanSpecs.index.cols, 1)
-		require.Empty(t, scanSpecs.rangesByColID)
-		require.False(t, scanSpecs.descOrder)
+		_, err = engine.ExecStmt("ALTER TABLE items ALTER COLUMN label TYPE INTEGER", nil, true)
+		require.ErrorIs(t, err, ErrInvalidConversion)
+		require.Contains(t, err.Error(), "default value of column label")
 
-		err = r.Close()
+		rows, _, err := engine.QueryAll("SELECT label FROM items WHERE id = 2", nil)
 		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, "20", rows[0].Values[EncodeSelector("", "db1", "items", "label")].Value())
 	})
 
-	t.Run("should use index on `ts` in default order", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT * FROM table1 USE INDEX ON (ts)", nil, true)
-		require.NoError(t, err)
+	t.Run("indexed and auto-incremental columns can not be changed", func(t *testing.T) {
+		_, err = engine.ExecStmt("ALTER TABLE items ALTER COLUMN qty TYPE VARCHAR", nil, true)
+		require.ErrorIs(t, err, ErrIllegalArguments)
 
-		orderBy := r.OrderBy()
-		require.NotNil(t, orderBy)
+		_, err = engine.ExecStmt("ALTER TABLE items ALTER COLUMN id TYPE VARCHAR", nil, true)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	// the new type is persisted in the catalog
+	engine, err = NewEngine(st, st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	err = engine.EnsureCatalogReady(nil)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	table, err := engine.catalog.GetTableByName("db1", "items")
+	require.NoError(t, err)
+
+	code, err := table.GetColumnByName("code")
+	require.NoError(t, err)
+	require.Equal(t, VarcharType, code.Type())
+
+	rows, _, err := engine.QueryAll("SELECT code FROM items WHERE id > 1", nil)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	require.Equal(t, "20", rows[0].Values[EncodeSelector("", "db1", "items", "code")].Value())
+	require.Equal(t, "A-30", rows[1].Values[EncodeSelector("", "db1", "items", "code")].Value())
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestAlterColumnTypeHistory(t *testing.T) {
+	// rows are rewritten after the catalog is changed when kept in a separate store
+	catalogStore, err := store.Open("catalog_alter_type_history", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_alter_type_history")
+
+	dataStore, err := store.Open("sqldata_alter_type_history", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_alter_type_history")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE items (id INTEGER, code INTEGER, qty INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON items(qty)", nil, true)
+	require.NoError(t, err)
+
+	summary, err := engine.ExecStmt("INSERT INTO items (id, code, qty) VALUES (1, 42, 1), (2, 7, 2)", nil, true)
+	require.NoError(t, err)
+	insertTx := summary.DMTxs[0].ID
+
+	summary, err = engine.ExecStmt("UPDATE items SET code = 8 WHERE id = 2", nil, true)
+	require.NoError(t, err)
+	prevUpdateTx := summary.DMTxs[0].ID
+
+	_, err = engine.ExecStmt("ALTER TABLE items ALTER COLUMN code TYPE VARCHAR", nil, true)
+	require.NoError(t, err)
+
+	summary, err = engine.ExecStmt("UPDATE items SET code = 'A-1' WHERE id = 1", nil, true)
+	require.NoError(t, err)
+	updateTx := summary.DMTxs[0].ID
+
+	codeSel := EncodeSelector("", "db1", "items", "code")
+
+	queryCodes := func(t *testing.T, query string) []interface{} {
+		rows, _, err := engine.QueryAll(query, nil)
+		require.NoError(t, err)
+
+		codes := make([]interface{}, len(rows))
+		for i, row := range rows {
+			codes[i] = row.Values[codeSel].Value()
+		}
+
+		return codes
+	}
+
+	assertHistory := func(t *testing.T) {
+		require.Equal(t, []interface{}{"A-1", "8"}, queryCodes(t, "SELECT code FROM items"))
+		require.Equal(t, []interface{}{"A-1", "8"}, queryCodes(t, "SELECT code FROM items USE INDEX ON qty"))
+		require.Equal(t, []interface{}{"42", "7"}, queryCodes(t, fmt.Sprintf("SELECT code FROM items FOR SYSTEM_TIME AS OF TX %d", insertTx)))
+		require.Equal(t, []interface{}{"42", "8"}, queryCodes(t, fmt.Sprintf("SELECT code FROM items FOR SYSTEM_TIME AS OF TX %d", prevUpdateTx)))
+		require.Equal(t, []interface{}{"42", "8"}, queryCodes(t, fmt.Sprintf("SELECT code FROM items BEFORE TX %d", updateTx)))
+
+		r, err := engine.ChangesSince("db1", "items", 0)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var codes []interface{}
+
+		for {
+			row, err := r.Read()
+			if err == ErrNoMoreRows {
+				break
+			}
+			require.NoError(t, err)
+
+			codes = append(codes, row.Values[codeSel].Value())
+		}
+
+		// insertions and update before the change of type, the rewrite done by it and the last update
+		require.Equal(t, []interface{}{"42", "7", "8", "42", "8", "A-1"}, codes)
+	}
+
+	t.Run("rows written before the change of type are read with the new type", assertHistory)
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	// the types the column had are persisted in the catalog
+	engine, err = NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	err = engine.EnsureCatalogReady(nil)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	t.Run("rows are read with the new type once the catalog is reloaded", assertHistory)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestQueryWithCurrentDatabase(t *testing.T) {
+	catalogStore, err := store.Open("catalog_current_db", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_current_db")
+
+	dataStore, err := store.Open("sqldata_current_db", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_current_db")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	_, _, err = engine.QueryAll("SELECT CURRENT_DATABASE() FROM db1.table1", nil)
+	require.ErrorIs(t, err, ErrNoDatabaseSelected)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, db VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, db) VALUES (1, CURRENT_DATABASE())", nil, true)
+	require.NoError(t, err)
+
+	rows, _, err := engine.QueryAll("SELECT CURRENT_DATABASE() AS name, db FROM table1 WHERE db = CURRENT_DATABASE()", nil)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, "db1", rows[0].Values[EncodeSelector("", "db1", "table1", "name")].Value())
+	require.Equal(t, "db1", rows[0].Values[EncodeSelector("", "db1", "table1", "db")].Value())
+
+	params, err := engine.InferParameters("SELECT id FROM table1 WHERE CURRENT_DATABASE() = @db")
+	require.NoError(t, err)
+	require.Equal(t, map[string]SQLValueType{"db": VarcharType}, params)
+
+	_, _, err = engine.QueryAll("SELECT CURRENT_DATABASE(id) FROM table1", nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestRowNumber(t *testing.T) {
+	catalogStore, err := store.Open("catalog_row_number", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_row_number")
+
+	dataStore, err := store.Open("sqldata_row_number", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_row_number")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table1 (id INTEGER, title VARCHAR, amount INTEGER, PRIMARY KEY id);
+		CREATE INDEX ON table1(amount);
+		INSERT INTO table1 (id, title, amount) VALUES (1, 'title3', 30), (2, 'title1', 10), (3, 'title4', 40), (4, 'title2', 20);
+	`, nil, true)
+	require.NoError(t, err)
+
+	// queryRowNumbers returns the id of each row along with its row number
+	queryRowNumbers := func(t *testing.T, query string) [][2]int64 {
+		r, err := engine.QueryStmt(query, nil, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		cols, err := r.Columns()
+		require.NoError(t, err)
+		require.Len(t, cols, 2)
+		require.Equal(t, IntegerType, cols[1].Type)
+
+		var res [][2]int64
+
+		for {
+			row, err := r.Read()
+			if err == ErrNoMoreRows {
+				break
+			}
+			require.NoError(t, err)
+
+			res = append(res, [2]int64{
+				row.Values[cols[0].Selector()].Value().(int64),
+				row.Values[cols[1].Selector()].Value().(int64),
+			})
+		}
+
+		return res
+	}
+
+	t.Run("rows should be numbered in the order of the window", func(t *testing.T) {
+		require.Equal(t, [][2]int64{{1, 1}, {2, 2}, {3, 3}, {4, 4}},
+			queryRowNumbers(t, "SELECT id, ROW_NUMBER() OVER (ORDER BY id) AS rn FROM table1"))
+
+		require.Equal(t, [][2]int64{{3, 1}, {1, 2}, {4, 3}, {2, 4}},
+			queryRowNumbers(t, "SELECT id, row_number() OVER (ORDER BY amount DESC) FROM table1"))
+
+		require.Equal(t, [][2]int64{{3, 1}, {1, 2}},
+			queryRowNumbers(t, "SELECT id, ROW_NUMBER() OVER (ORDER BY amount DESC) FROM table1 WHERE amount > 20 ORDER BY amount DESC"))
+
+		require.Equal(t, [][2]int64{{2, 1}, {4, 2}, {1, 3}},
+			queryRowNumbers(t, "SELECT id, ROW_NUMBER() OVER (ORDER BY title) FROM table1 LIMIT 3"))
+	})
+
+	t.Run("numbered rows should be named after the alias", func(t *testing.T) {
+		rows, _, err := engine.QueryAll("SELECT ROW_NUMBER() OVER (ORDER BY id) AS rn, ROW_NUMBER() OVER (ORDER BY id) FROM table1 LIMIT 1", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, int64(1), rows[0].Values[EncodeSelector("", "db1", "table1", "rn")].Value())
+		require.Equal(t, int64(1), rows[0].Values[EncodeSelector("", "db1", "table1", "col1")].Value())
+	})
+
+	t.Run("unsupported windows should fail", func(t *testing.T) {
+		_, _, err = engine.QueryAll("SELECT id, ROW_NUMBER() OVER (ORDER BY title) FROM table1", nil)
+		require.ErrorIs(t, err, ErrLimitedOrderBy)
+
+		_, _, err = engine.QueryAll("SELECT id, ROW_NUMBER() OVER (ORDER BY id) FROM table1 ORDER BY amount", nil)
+		require.ErrorIs(t, err, ErrLimitedWindowFunctions)
+
+		_, _, err = engine.QueryAll("SELECT ROW_NUMBER() OVER (ORDER BY id), ROW_NUMBER() OVER (ORDER BY id DESC) FROM table1", nil)
+		require.ErrorIs(t, err, ErrLimitedWindowFunctions)
+
+		_, _, err = engine.QueryAll("SELECT ROW_NUMBER() OVER (ORDER BY id, amount) FROM table1", nil)
+		require.ErrorIs(t, err, ErrLimitedWindowFunctions)
+
+		_, _, err = engine.QueryAll("SELECT COUNT(), ROW_NUMBER() OVER (ORDER BY id) FROM table1", nil)
+		require.ErrorIs(t, err, ErrLimitedWindowFunctions)
+
+		_, _, err = engine.QueryAll("SELECT ROW_NUMBER(id) OVER (ORDER BY id) FROM table1", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, _, err = engine.QueryAll("SELECT ROW_NUMBERS() OVER (ORDER BY id) FROM table1", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, _, err = engine.QueryAll("SELECT id FROM table1 WHERE ROW_NUMBER() OVER (ORDER BY id) > 1", nil)
+		require.Error(t, err)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestRankFunctions(t *testing.T) {
+	catalogStore, err := store.Open("catalog_rank", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_rank")
+
+	dataStore, err := store.Open("sqldata_rank", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_rank")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table1 (id INTEGER, title VARCHAR, amount INTEGER, PRIMARY KEY id);
+		CREATE INDEX ON table1(amount);
+		INSERT INTO table1 (id, title, amount)
+		VALUES (1, 'b', 10), (2, 'a', 20), (3, 'b', 20), (4, 'a', 30), (5, 'c', 30), (6, 'a', 30), (7, 'd', 40);
+	`, nil, true)
+	require.NoError(t, err)
+
+	queryRanks := func(t *testing.T, query string) [][]int64 {
+		rows, cols, err := engine.QueryAll(query, nil)
+		require.NoError(t, err)
+
+		var res [][]int64
+
+		for _, row := range rows {
+			var vals []int64
+
+			for _, col := range cols {
+				vals = append(vals, row.Values[col.Selector()].Value().(int64))
+			}
+
+			res = append(res, vals)
+		}
+
+		return res
+	}
+
+	t.Run("tied rows should be ranked the same", func(t *testing.T) {
+		require.Equal(t, [][]int64{
+			{1, 1, 1, 1},
+			{2, 2, 2, 2},
+			{3, 3, 2, 2},
+			{4, 4, 4, 3},
+			{5, 5, 4, 3},
+			{6, 6, 4, 3},
+			{7, 7, 7, 4},
+		}, queryRanks(t, "SELECT id, ROW_NUMBER() OVER (ORDER BY amount), RANK() OVER (ORDER BY amount), DENSE_RANK() OVER (ORDER BY amount) FROM table1"))
+
+		require.Equal(t, [][]int64{
+			{7, 1, 1},
+			{6, 2, 2},
+			{5, 2, 2},
+			{4, 2, 2},
+			{3, 5, 3},
+			{2, 5, 3},
+			{1, 7, 4},
+		}, queryRanks(t, "SELECT id, RANK() OVER (ORDER BY amount DESC) AS r, DENSE_RANK() OVER (ORDER BY amount DESC) AS dr FROM table1"))
+	})
+
+	t.Run("rows sorted in memory should be ranked the same", func(t *testing.T) {
+		require.Equal(t, [][]int64{
+			{2, 1, 1},
+			{4, 1, 1},
+			{6, 1, 1},
+			{1, 4, 2},
+			{3, 4, 2},
+		}, queryRanks(t, "SELECT id, RANK() OVER (ORDER BY title), DENSE_RANK() OVER (ORDER BY title) FROM table1 LIMIT 5"))
+	})
+
+	_, _, err = engine.QueryAll("SELECT RANK() OVER (ORDER BY id), DENSE_RANK() OVER (ORDER BY amount) FROM table1", nil)
+	require.ErrorIs(t, err, ErrLimitedWindowFunctions)
+
+	_, _, err = engine.QueryAll("SELECT RANK(id) OVER (ORDER BY id) FROM table1", nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestWindowPartitions(t *testing.T) {
+	catalogStore, err := store.Open("catalog_partition", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_partition")
+
+	dataStore, err := store.Open("sqldata_partition", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_partition")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table1 (id INTEGER, category INTEGER, amount INTEGER, PRIMARY KEY id);
+		CREATE INDEX ON table1(category, amount);
+		INSERT INTO table1 (id, category, amount)
+		VALUES (1, 2, 10), (2, 1, 20), (3, 2, 20), (4, 1, 10), (5, 1, 20), (6, 3, 30), (7, 2, 40);
+	`, nil, true)
+	require.NoError(t, err)
+
+	queryRanks := func(t *testing.T, query string) [][]int64 {
+		rows, cols, err := engine.QueryAll(query, nil)
+		require.NoError(t, err)
+
+		var res [][]int64
+
+		for _, row := range rows {
+			var vals []int64
+
+			for _, col := range cols {
+				vals = append(vals, row.Values[col.Selector()].Value().(int64))
+			}
+
+			res = append(res, vals)
+		}
+
+		return res
+	}
+
+	t.Run("numbering should restart on each partition", func(t *testing.T) {
+		require.Equal(t, [][]int64{
+			{4, 1, 1},
+			{2, 2, 2},
+			{5, 3, 2},
+			{1, 1, 1},
+			{3, 2, 2},
+			{7, 3, 3},
+			{6, 1, 1},
+		}, queryRanks(t, "SELECT id, ROW_NUMBER() OVER (PARTITION BY category ORDER BY amount), RANK() OVER (PARTITION BY category ORDER BY amount) FROM table1"))
+
+		require.Equal(t, [][]int64{
+			{6, 1},
+			{7, 1},
+			{3, 2},
+			{1, 3},
+			{5, 1},
+			{2, 1},
+			{4, 2},
+		}, queryRanks(t, "SELECT id, DENSE_RANK() OVER (PARTITION BY category ORDER BY amount DESC) FROM table1 WHERE category > 0"))
+	})
+
+	t.Run("rows sorted in memory should be partitioned the same", func(t *testing.T) {
+		require.Equal(t, [][]int64{
+			{1, 1},
+			{4, 2},
+			{2, 1},
+			{3, 2},
+			{5, 3},
+		}, queryRanks(t, "SELECT id, ROW_NUMBER() OVER (PARTITION BY amount ORDER BY id) FROM table1 LIMIT 5"))
+	})
+
+	_, _, err = engine.QueryAll("SELECT ROW_NUMBER() OVER (PARTITION BY category ORDER BY amount) FROM table1 ORDER BY amount", nil)
+	require.ErrorIs(t, err, ErrLimitedWindowFunctions)
+
+	_, _, err = engine.QueryAll("SELECT ROW_NUMBER() OVER (PARTITION BY amount ORDER BY id) FROM table1", nil)
+	require.ErrorIs(t, err, ErrLimitedOrderBy)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestMerge(t *testing.T) {
+	catalogStore, err := store.Open("catalog_merge", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_merge")
+
+	dataStore, err := store.Open("sqldata_merge", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_merge")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table1 (id INTEGER, title VARCHAR[20], amount INTEGER, PRIMARY KEY id);
+		CREATE UNIQUE INDEX ON table1(title);
+		CREATE TABLE table2 (id INTEGER, title VARCHAR[20], amount INTEGER, PRIMARY KEY id);
+	`, nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		INSERT INTO table1 (id, title, amount) VALUES (1, 'title1', 10), (2, 'title2', 20);
+		INSERT INTO table2 (id, title, amount) VALUES (2, 'title22', 5), (3, 'title3', 30);
+	`, nil, true)
+	require.NoError(t, err)
+
+	mergeStmt := `
+		MERGE INTO table1 t USING table2 s ON t.id = s.id
+			WHEN MATCHED THEN UPDATE SET title = s.title, amount = amount + s.amount
+			WHEN NOT MATCHED THEN INSERT (id, title, amount) VALUES (s.id, s.title, s.amount)
+	`
+
+	params, err := engine.InferParameters("MERGE INTO table1 t USING table2 s ON t.id = s.id AND s.amount > @threshold WHEN MATCHED THEN UPDATE SET title = @title")
+	require.NoError(t, err)
+	require.Equal(t, map[string]SQLValueType{"threshold": IntegerType, "title": VarcharType}, params)
+
+	_, err = engine.InferParameters("MERGE INTO table1 t USING table2 s ON t.id = s.id WHEN MATCHED THEN UPDATE SET amount = s.title")
+	require.ErrorIs(t, err, ErrInvalidTypes)
+
+	t.Run("matched rows should be updated and unmatched ones inserted", func(t *testing.T) {
+		summary, err := engine.ExecStmt(mergeStmt, nil, true)
+		require.NoError(t, err)
+		require.Equal(t, 2, summary.UpdatedRows)
+
+		rows, _, err := engine.QueryAll("SELECT id, title, amount FROM table1", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 3)
+
+		expected := []struct {
+			title  string
+			amount int64
+		}{
+			{title: "title1", amount: 10},
+			{title: "title22", amount: 25},
+			{title: "title3", amount: 30},
+		}
+
+		for i, row := range rows {
+			require.Equal(t, int64(i+1), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+			require.Equal(t, expected[i].title, row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+			require.Equal(t, expected[i].amount, row.Values[EncodeSelector("", "db1", "table1", "amount")].Value())
+		}
+
+		require.Equal(t, []int64{2}, queryIDs(t, engine, "SELECT id FROM table1 USE INDEX ON title WHERE title = 'title22'", nil))
+		require.Empty(t, queryIDs(t, engine, "SELECT id FROM table1 USE INDEX ON title WHERE title = 'title2'", nil))
+	})
+
+	t.Run("rows already matched should not be inserted again", func(t *testing.T) {
+		summary, err := engine.ExecStmt(`
+			MERGE INTO table1 USING table2 ON table1.id = table2.id
+				WHEN NOT MATCHED THEN INSERT (id, title) VALUES (table2.id, table2.title)
+		`, nil, true)
+		require.NoError(t, err)
+		require.Zero(t, summary.UpdatedRows)
+	})
+
+	t.Run("a query may be used as source", func(t *testing.T) {
+		summary, err := engine.ExecStmt(`
+			MERGE INTO table1 USING (SELECT id FROM table2 WHERE amount > @threshold) AS s ON table1.id = s.id
+				WHEN MATCHED THEN UPDATE SET amount = NULL
+		`, map[string]interface{}{"threshold": 10}, true)
+		require.NoError(t, err)
+		require.Equal(t, 1, summary.UpdatedRows)
+
+		rows, _, err := engine.QueryAll("SELECT id FROM table1 WHERE amount = NULL", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, int64(3), rows[0].Values[EncodeSelector("", "db1", "table1", "id")].Value())
+	})
+
+	t.Run("invalid merges should fail", func(t *testing.T) {
+		_, err := engine.ExecStmt("MERGE INTO table3 USING table2 ON table3.id = table2.id WHEN MATCHED THEN UPDATE SET amount = 0", nil, true)
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+		_, err = engine.ExecStmt("MERGE INTO table1 USING table2 ON table1.id = table2.id WHEN MATCHED THEN UPDATE SET price = 0", nil, true)
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+		_, err = engine.ExecStmt("MERGE INTO table1 USING table2 ON table1.id = table2.id WHEN MATCHED THEN UPDATE SET id = 0", nil, true)
+		require.ErrorIs(t, err, ErrPKCanNotBeUpdated)
+
+		_, err = engine.ExecStmt("MERGE INTO table1 USING table2 ON table1.id = table2.id WHEN MATCHED THEN UPDATE SET amount = table2.title", nil, true)
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		_, err = engine.ExecStmt("MERGE INTO table1 USING table2 ON table1.id = table2.id WHEN NOT MATCHED THEN INSERT (id, title) VALUES (table2.id)", nil, true)
+		require.ErrorIs(t, err, ErrInvalidNumberOfValues)
+
+		_, err = engine.ExecStmt("MERGE INTO table1 USING table2 ON table1.id = table2.id + 10 WHEN NOT MATCHED THEN INSERT (id, title) VALUES (table2.id + 10, 'title4')", nil, true)
+		require.ErrorIs(t, err, store.ErrDuplicatedKey)
+
+		_, err = engine.ExecStmt("MERGE INTO table1 USING (SELECT id FROM table2 WHERE id = 3) AS s ON table1.id = s.id + 10 WHEN NOT MATCHED THEN INSERT (id, title) VALUES (s.id + 10, 'title1')", nil, true)
+		require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestDelete(t *testing.T) {
+	catalogStore, err := store.Open("catalog_delete", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_delete")
+
+	dataStore, err := store.Open("sqldata_delete", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_delete")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("DELETE FROM table1", nil, true)
+	require.ErrorIs(t, err, ErrNoDatabaseSelected)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`CREATE TABLE table1 (
+		id INTEGER,
+		title VARCHAR[50],
+		active BOOLEAN,
+		PRIMARY KEY id
+	)`, nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table1(title)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(active)", nil, true)
+	require.NoError(t, err)
+
+	params, err := engine.InferParameters("DELETE FROM table1 WHERE active = @active")
+	require.NoError(t, err)
+	require.NotNil(t, params)
+	require.Len(t, params, 1)
+	require.Equal(t, params["active"], BooleanType)
+
+	_, err = engine.ExecStmt("DELETE FROM table2", nil, true)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	_, err = engine.ExecStmt("DELETE FROM table1 WHERE name = 'name1'", nil, true)
+	require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+	t.Run("delete on empty table should complete without issues", func(t *testing.T) {
+		summary, err := engine.ExecStmt("DELETE FROM table1", nil, true)
+		require.NoError(t, err)
+		require.NotNil(t, summary)
+		require.Zero(t, summary.UpdatedRows)
+	})
+
+	rowCount := 10
+
+	for i := 0; i < rowCount; i++ {
+		_, err = engine.ExecStmt(fmt.Sprintf(`
+			INSERT INTO table1 (id, title, active) VALUES (%d, 'title%d', %v)`, i, i, i%2 == 0), nil, true)
+		require.NoError(t, err)
+	}
+
+	t.Run("deleting with contradiction should not produce any change", func(t *testing.T) {
+		summary, err := engine.ExecStmt("DELETE FROM table1 WHERE false", nil, true)
+		require.NoError(t, err)
+		require.NotNil(t, summary)
+		require.Zero(t, summary.UpdatedRows)
+	})
+
+	t.Run("deleting active rows should remove half of the rows", func(t *testing.T) {
+		summary, err := engine.ExecStmt("DELETE FROM table1 WHERE active = @active", map[string]interface{}{"active": true}, true)
+		require.NoError(t, err)
+		require.NotNil(t, summary)
+		require.Equal(t, rowCount/2, summary.UpdatedRows)
+
+		r, err := engine.QueryStmt("SELECT COUNT() FROM table1", nil, true)
+		require.NoError(t, err)
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(rowCount/2), row.Values[EncodeSelector("", "db1", "table1", "col0")].Value())
+
+		err = r.Close()
+		require.NoError(t, err)
+
+		r, err = engine.QueryStmt("SELECT COUNT() FROM table1 WHERE active", nil, true)
+		require.NoError(t, err)
+
+		row, err = r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(0), row.Values[EncodeSelector("", "db1", "table1", "col0")].Value())
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	catalogStore, err := store.Open("catalog_update", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_update")
+
+	dataStore, err := store.Open("sqldata_update", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_update")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("UPDATE table1 SET title = 'title11' WHERE title = 'title", nil, true)
+	require.ErrorIs(t, err, ErrNoDatabaseSelected)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`CREATE TABLE table1 (
+		id INTEGER,
+		title VARCHAR[50],
+		active BOOLEAN,
+		PRIMARY KEY id
+	)`, nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table1(title)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(active)", nil, true)
+	require.NoError(t, err)
+
+	params, err := engine.InferParameters("UPDATE table1 SET active = @active")
+	require.NoError(t, err)
+	require.NotNil(t, params)
+	require.Len(t, params, 1)
+	require.Equal(t, params["active"], BooleanType)
+
+	_, err = engine.ExecStmt("UPDATE table2 SET active = false", nil, true)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	_, err = engine.ExecStmt("UPDATE table1 SET name = 'name1'", nil, true)
+	require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+	t.Run("update on empty table should complete without issues", func(t *testing.T) {
+		summary, err := engine.ExecStmt("UPDATE table1 SET active = false", nil, true)
+		require.NoError(t, err)
+		require.NotNil(t, summary)
+		require.Zero(t, summary.UpdatedRows)
+	})
+
+	rowCount := 10
+
+	for i := 0; i < rowCount; i++ {
+		_, err = engine.ExecStmt(fmt.Sprintf(`
+			INSERT INTO table1 (id, title, active) VALUES (%d, 'title%d', %v)`, i, i, i%2 == 0), nil, true)
+		require.NoError(t, err)
+	}
+
+	t.Run("updating with contradiction should not produce any change", func(t *testing.T) {
+		summary, err := engine.ExecStmt("UPDATE table1 SET active = false WHERE false", nil, true)
+		require.NoError(t, err)
+		require.NotNil(t, summary)
+		require.Zero(t, summary.UpdatedRows)
+	})
+
+	t.Run("updating specific row should update only one row", func(t *testing.T) {
+		summary, err := engine.ExecStmt("UPDATE table1 SET active = true WHERE title = @title", map[string]interface{}{"title": "title1"}, true)
+		require.NoError(t, err)
+		require.NotNil(t, summary)
+		require.Equal(t, 1, summary.UpdatedRows)
+
+		r, err := engine.QueryStmt("SELECT COUNT() FROM table1", nil, true)
+		require.NoError(t, err)
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(rowCount), row.Values[EncodeSelector("", "db1", "table1", "col0")].Value())
+
+		err = r.Close()
+		require.NoError(t, err)
+
+		r, err = engine.QueryStmt("SELECT COUNT() FROM table1 WHERE active", nil, true)
+		require.NoError(t, err)
+
+		row, err = r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(rowCount/2+1), row.Values[EncodeSelector("", "db1", "table1", "col0")].Value())
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	queryIDsByTitle := func(title string) []int64 {
+		return queryIDs(t, engine, "SELECT id FROM table1 WHERE title = @title", map[string]interface{}{"title": title})
+	}
+
+	t.Run("updating unique indexed column to a conflicting value should fail", func(t *testing.T) {
+		_, err := engine.ExecStmt("UPDATE table1 SET title = 'title2' WHERE id = 1", nil, true)
+		require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+
+		_, err = engine.ExecStmt("UPDATE table1 SET title = 'title' WHERE id < 3", nil, true)
+		require.ErrorIs(t, err, store.ErrDuplicatedKey)
+
+		require.Equal(t, []int64{1}, queryIDsByTitle("title1"))
+		require.Equal(t, []int64{2}, queryIDsByTitle("title2"))
+		require.Empty(t, queryIDsByTitle("title"))
+	})
+
+	t.Run("updating unique indexed column to a non-conflicting value should replace the index entry", func(t *testing.T) {
+		summary, err := engine.ExecStmt("UPDATE table1 SET title = 'title11' WHERE id = 1", nil, true)
+		require.NoError(t, err)
+		require.Equal(t, 1, summary.UpdatedRows)
+
+		require.Empty(t, queryIDsByTitle("title1"))
+		require.Equal(t, []int64{1}, queryIDsByTitle("title11"))
+
+		// previous value is released and can be taken by another row
+		_, err = engine.ExecStmt("UPDATE table1 SET title = 'title1' WHERE id = 0", nil, true)
+		require.NoError(t, err)
+
+		require.Equal(t, []int64{0}, queryIDsByTitle("title1"))
+		require.Empty(t, queryIDsByTitle("title0"))
+
+		r, err := engine.QueryStmt("SELECT COUNT() FROM table1 USE INDEX ON title", nil, true)
+		require.NoError(t, err)
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(rowCount), row.Values[EncodeSelector("", "db1", "table1", "col0")].Value())
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("unique values may be exchanged between rows within a transaction", func(t *testing.T) {
+		_, err := engine.ExecStmt(`
+			BEGIN TRANSACTION
+				UPDATE table1 SET title = 'title2' WHERE id = 0;
+				UPDATE table1 SET title = 'title1' WHERE id = 2;
+			COMMIT
+		`, nil, true)
+		require.NoError(t, err)
+
+		require.Equal(t, []int64{2}, queryIDsByTitle("title1"))
+		require.Equal(t, []int64{0}, queryIDsByTitle("title2"))
+
+		_, err = engine.ExecStmt(`
+			BEGIN TRANSACTION
+				DELETE FROM table1 WHERE id = 3;
+				INSERT INTO table1 (id, title, active) VALUES (@id, 'title3', false);
+			COMMIT
+		`, map[string]interface{}{"id": rowCount}, true)
+		require.NoError(t, err)
+
+		require.Equal(t, []int64{int64(rowCount)}, queryIDsByTitle("title3"))
+
+		r, err := engine.QueryStmt("SELECT COUNT() FROM table1 USE INDEX ON title", nil, true)
+		require.NoError(t, err)
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(rowCount), row.Values[EncodeSelector("", "db1", "table1", "col0")].Value())
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("unique values must not conflict once a transaction is completed", func(t *testing.T) {
+		_, err := engine.ExecStmt(`
+			BEGIN TRANSACTION
+				UPDATE table1 SET title = 'title4' WHERE id = 0;
+				UPDATE table1 SET title = 'title0' WHERE id = 5;
+			COMMIT
+		`, nil, true)
+		require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+
+		_, err = engine.ExecStmt(`
+			BEGIN TRANSACTION
+				UPDATE table1 SET title = 'title1' WHERE id = 0;
+				UPDATE table1 SET title = 'title1' WHERE id = 1;
+				UPDATE table1 SET title = 'title2' WHERE id = 2;
+			COMMIT
+		`, nil, true)
+		require.ErrorIs(t, err, store.ErrDuplicatedKey)
+
+		require.Equal(t, []int64{2}, queryIDsByTitle("title1"))
+		require.Equal(t, []int64{0}, queryIDsByTitle("title2"))
+		require.Equal(t, []int64{1}, queryIDsByTitle("title11"))
+		require.Equal(t, []int64{4}, queryIDsByTitle("title4"))
+	})
+
+	t.Run("assignments should be evaluated against the values before the update", func(t *testing.T) {
+		_, err := engine.ExecStmt(`
+			CREATE TABLE table2 (id INTEGER, a INTEGER, b INTEGER, title VARCHAR, PRIMARY KEY id);
+			INSERT INTO table2 (id, a, b, title) VALUES (1, 10, 20, 'title1'), (2, 30, 40, 'title2');
+		`, nil, true)
+		require.NoError(t, err)
+
+		summary, err := engine.ExecStmt("UPDATE table2 SET a = b, b = a, title = 'swapped' WHERE a < b", nil, true)
+		require.NoError(t, err)
+		require.Equal(t, 2, summary.UpdatedRows)
+
+		rows, _, err := engine.QueryAll("SELECT id, a, b, title FROM table2", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+
+		for i, row := range rows {
+			require.Equal(t, int64(i+1), row.Values[EncodeSelector("", "db1", "table2", "id")].Value())
+			require.Equal(t, int64(20*i+20), row.Values[EncodeSelector("", "db1", "table2", "a")].Value())
+			require.Equal(t, int64(20*i+10), row.Values[EncodeSelector("", "db1", "table2", "b")].Value())
+			require.Equal(t, "swapped", row.Values[EncodeSelector("", "db1", "table2", "title")].Value())
+		}
+
+		_, err = engine.ExecStmt("UPDATE table2 SET a = a + b, b = a - b", nil, true)
+		require.NoError(t, err)
+
+		rows, _, err = engine.QueryAll("SELECT a, b FROM table2 WHERE id = 1", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, int64(30), rows[0].Values[EncodeSelector("", "db1", "table2", "a")].Value())
+		require.Equal(t, int64(10), rows[0].Values[EncodeSelector("", "db1", "table2", "b")].Value())
+	})
+
+	t.Run("rows with null values should be updated", func(t *testing.T) {
+		_, err := engine.ExecStmt("UPDATE table2 SET title = NULL WHERE id = 1", nil, true)
+		require.NoError(t, err)
+
+		summary, err := engine.ExecStmt("UPDATE table2 SET a = a + 1", nil, true)
+		require.NoError(t, err)
+		require.Equal(t, 2, summary.UpdatedRows)
+
+		rows, _, err := engine.QueryAll("SELECT a, title FROM table2 WHERE id = 1", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, int64(31), rows[0].Values[EncodeSelector("", "db1", "table2", "a")].Value())
+		require.Nil(t, rows[0].Values[EncodeSelector("", "db1", "table2", "title")].Value())
+	})
+}
+
+func TestUpdateWithTuples(t *testing.T) {
+	catalogStore, err := store.Open("catalog_update_tuples", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_update_tuples")
+
+	dataStore, err := store.Open("sqldata_update_tuples", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_update_tuples")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table1 (id INTEGER, title VARCHAR, amount INTEGER, PRIMARY KEY id);
+		CREATE TABLE table2 (id INTEGER, name VARCHAR, total INTEGER, PRIMARY KEY id);
+		INSERT INTO table1 (id, title, amount) VALUES (1, 'title1', 10), (2, 'title2', 20), (3, 'title3', 30);
+		INSERT INTO table2 (id, name, total) VALUES (1, 'name1', 100), (2, 'name2', 200);
+	`, nil, true)
+	require.NoError(t, err)
+
+	queryRow := func(t *testing.T, id int) (title interface{}, amount interface{}) {
+		rows, _, err := engine.QueryAll("SELECT title, amount FROM table1 WHERE id = @id", map[string]interface{}{"id": id})
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+
+		return rows[0].Values[EncodeSelector("", "db1", "table1", "title")].Value(),
+			rows[0].Values[EncodeSelector("", "db1", "table1", "amount")].Value()
+	}
+
+	t.Run("columns should be assigned from a tuple", func(t *testing.T) {
+		summary, err := engine.ExecStmt("UPDATE table1 SET (title, amount) = ('updated', amount + @n) WHERE id = 1", map[string]interface{}{"n": 5}, true)
+		require.NoError(t, err)
+		require.Equal(t, 1, summary.UpdatedRows)
+
+		title, amount := queryRow(t, 1)
+		require.Equal(t, "updated", title)
+		require.Equal(t, int64(15), amount)
+
+		title, amount = queryRow(t, 2)
+		require.Equal(t, "title2", title)
+		require.Equal(t, int64(20), amount)
+	})
+
+	t.Run("columns should be assigned from a single-row subquery", func(t *testing.T) {
+		summary, err := engine.ExecStmt("UPDATE table1 SET (title, amount) = (SELECT name, total FROM table2 WHERE id = 2) WHERE id >= 2", nil, true)
+		require.NoError(t, err)
+		require.Equal(t, 2, summary.UpdatedRows)
+
+		for _, id := range []int{2, 3} {
+			title, amount := queryRow(t, id)
+			require.Equal(t, "name2", title)
+			require.Equal(t, int64(200), amount)
+		}
+
+		_, err = engine.ExecStmt("UPDATE table1 SET (title, amount) = (SELECT name, total FROM table2 WHERE id > 2) WHERE id = 3", nil, true)
+		require.NoError(t, err)
+
+		title, amount := queryRow(t, 3)
+		require.Nil(t, title)
+		require.Nil(t, amount)
+	})
+
+	t.Run("tuples should be combined with single assignments", func(t *testing.T) {
+		_, err := engine.ExecStmt("UPDATE table1 SET amount = 1, (title) = ('title1') WHERE id = 1", nil, true)
+		require.NoError(t, err)
+
+		title, amount := queryRow(t, 1)
+		require.Equal(t, "title1", title)
+		require.Equal(t, int64(1), amount)
+
+		_, err = engine.ExecStmt("UPDATE table1 SET amount = 1, (title, amount) = ('title1', 2) WHERE id = 1", nil, true)
+		require.ErrorIs(t, err, ErrDuplicatedColumn)
+	})
+
+	t.Run("arity and types should be validated", func(t *testing.T) {
+		_, err := engine.ExecStmt("UPDATE table1 SET (title, amount) = ('title1') WHERE id = 1", nil, true)
+		require.ErrorIs(t, err, ErrInvalidNumberOfValues)
+
+		_, err = engine.ExecStmt("UPDATE table1 SET (title, amount) = (SELECT name FROM table2 WHERE id = 1) WHERE id = 1", nil, true)
+		require.ErrorIs(t, err, ErrInvalidNumberOfValues)
+
+		_, err = engine.ExecStmt("UPDATE table1 SET (title, amount) = (SELECT total, name FROM table2 WHERE id = 1) WHERE id = 1", nil, true)
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		_, err = engine.ExecStmt("UPDATE table1 SET (title, amount) = (SELECT name, total FROM table2) WHERE id = 1", nil, true)
+		require.ErrorIs(t, err, ErrMoreThanOneRow)
+
+		_, err = engine.ExecStmt("UPDATE table1 SET (id, amount) = (10, 1) WHERE id = 1", nil, true)
+		require.ErrorIs(t, err, ErrPKCanNotBeUpdated)
+
+		title, amount := queryRow(t, 1)
+		require.Equal(t, "title1", title)
+		require.Equal(t, int64(1), amount)
+	})
+
+	t.Run("parameters should be inferred from tuples", func(t *testing.T) {
+		params, err := engine.InferParameters("UPDATE table1 SET (title, amount) = (@title, @amount) WHERE id = 1")
+		require.NoError(t, err)
+		require.Equal(t, map[string]SQLValueType{"title": VarcharType, "amount": IntegerType}, params)
+
+		params, err = engine.InferParameters("UPDATE table1 SET (title, amount) = (SELECT name, total FROM table2 WHERE id = @id)")
+		require.NoError(t, err)
+		require.Equal(t, map[string]SQLValueType{"id": IntegerType}, params)
+
+		_, err = engine.InferParameters("UPDATE table1 SET (title, amount) = (@title)")
+		require.ErrorIs(t, err, ErrInvalidNumberOfValues)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestInsertOnConflict(t *testing.T) {
+	catalogStore, err := store.Open("catalog_on_conflict", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_on_conflict")
+
+	dataStore, err := store.Open("sqldata_on_conflict", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_on_conflict")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE users (id INTEGER, email VARCHAR[32], name VARCHAR, logins INTEGER, PRIMARY KEY id);
+		CREATE UNIQUE INDEX ON users(email);
+		CREATE INDEX ON users(logins);
+		INSERT INTO users (id, email, name, logins) VALUES (1, 'a@db1', 'a', 1), (2, 'b@db1', 'b', 1);
+	`, nil, true)
+	require.NoError(t, err)
+
+	queryUser := func(t *testing.T, email string) (id, name, logins interface{}) {
+		rows, _, err := engine.QueryAll("SELECT id, name, logins FROM users WHERE email = @email", map[string]interface{}{"email": email})
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+
+		return rows[0].Values[EncodeSelector("", "db1", "users", "id")].Value(),
+			rows[0].Values[EncodeSelector("", "db1", "users", "name")].Value(),
+			rows[0].Values[EncodeSelector("", "db1", "users", "logins")].Value()
+	}
+
+	t.Run("conflicts on a secondary unique index should update the existing row", func(t *testing.T) {
+		summary, err := engine.ExecStmt(`
+			INSERT INTO users (id, email, name, logins) VALUES (10, 'a@db1', 'a2', 1), (11, 'c@db1', 'c', 1)
+			ON CONFLICT (email) DO UPDATE SET name = excluded.name, logins = logins + excluded.logins`, nil, true)
+		require.NoError(t, err)
+		require.Equal(t, 2, summary.UpdatedRows)
+
+		id, name, logins := queryUser(t, "a@db1")
+		require.Equal(t, int64(1), id)
+		require.Equal(t, "a2", name)
+		require.Equal(t, int64(2), logins)
+
+		id, name, logins = queryUser(t, "c@db1")
+		require.Equal(t, int64(11), id)
+		require.Equal(t, "c", name)
+		require.Equal(t, int64(1), logins)
+
+		// index entries of the updated row should be replaced
+		rows, _, err := engine.QueryAll("SELECT id FROM users USE INDEX ON logins WHERE logins = 2", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, int64(1), rows[0].Values[EncodeSelector("", "db1", "users", "id")].Value())
+	})
+
+	t.Run("conflicts should be skipped with do nothing", func(t *testing.T) {
+		summary, err := engine.ExecStmt("INSERT INTO users (id, email, name, logins) VALUES (12, 'b@db1', 'b2', 5) ON CONFLICT (email) DO NOTHING", nil, true)
+		require.NoError(t, err)
+		require.Zero(t, summary.UpdatedRows)
+
+		_, name, _ := queryUser(t, "b@db1")
+		require.Equal(t, "b", name)
+
+		summary, err = engine.ExecStmt("INSERT INTO users (id, email, name, logins) VALUES (2, 'd@db1', 'd', 5) ON CONFLICT DO NOTHING", nil, true)
+		require.NoError(t, err)
+		require.Zero(t, summary.UpdatedRows)
+	})
+
+	t.Run("conflicts on other indexes should still fail", func(t *testing.T) {
+		_, err := engine.ExecStmt("INSERT INTO users (id, email, name, logins) VALUES (2, 'e@db1', 'e', 1) ON CONFLICT (email) DO NOTHING", nil, true)
+		require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+
+		_, err = engine.ExecStmt("INSERT INTO users (id, email, name, logins) VALUES (20, 'a@db1', 'a', 1) ON CONFLICT (id) DO NOTHING", nil, true)
+		require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+	})
+
+	t.Run("the conflict target should be a unique index", func(t *testing.T) {
+		_, err := engine.ExecStmt("INSERT INTO users (id, email, name, logins) VALUES (20, 'a@db1', 'a', 1) ON CONFLICT (logins) DO NOTHING", nil, true)
+		require.ErrorIs(t, err, ErrNoUniqueIndex)
+
+		_, err = engine.ExecStmt("INSERT INTO users (id, email, name, logins) VALUES (20, 'a@db1', 'a', 1) ON CONFLICT (name) DO NOTHING", nil, true)
+		require.ErrorIs(t, err, ErrNoUniqueIndex)
+
+		_, err = engine.ExecStmt("INSERT INTO users (id, email, name, logins) VALUES (20, 'a@db1', 'a', 1) ON CONFLICT (nickname) DO NOTHING", nil, true)
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+		_, err = engine.ExecStmt("INSERT INTO users (id, email) VALUES (20, 'a@db1') ON CONFLICT (email) DO UPDATE SET id = 20", nil, true)
+		require.ErrorIs(t, err, ErrPKCanNotBeUpdated)
+	})
+
+	t.Run("parameters should be inferred from the updates", func(t *testing.T) {
+		params, err := engine.InferParameters("INSERT INTO users (id, email) VALUES (@id, @email) ON CONFLICT (email) DO UPDATE SET logins = excluded.logins + @n")
+		require.NoError(t, err)
+		require.Equal(t, map[string]SQLValueType{"id": IntegerType, "email": VarcharType, "n": IntegerType}, params)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestUpsertReturning(t *testing.T) {
+	catalogStore, err := store.Open("catalog_upsert_returning", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_upsert_returning")
+
+	dataStore, err := store.Open("sqldata_upsert_returning", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_upsert_returning")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE users (id INTEGER, email VARCHAR[32], name VARCHAR, PRIMARY KEY id);
+		CREATE UNIQUE INDEX ON users(email);
+		INSERT INTO users (id, email, name) VALUES (1, 'a@db1', 'a');
+	`, nil, true)
+	require.NoError(t, err)
+
+	idSel := EncodeSelector("", "db1", "users", "id")
+	emailSel := EncodeSelector("", "db1", "users", "email")
+	nameSel := EncodeSelector("", "db1", "users", "name")
+
+	t.Run("upserting over an existing row should return both old and new values", func(t *testing.T) {
+		summary, err := engine.ExecStmt("UPSERT INTO users (id, email, name) VALUES (1, 'a@db1', 'a2') RETURNING OLD.*, NEW.*", nil, true)
+		require.NoError(t, err)
+		require.Len(t, summary.ReturnedRows, 1)
+
+		oldRow := summary.ReturnedRows[0].Old
+		require.NotNil(t, oldRow)
+		require.Equal(t, int64(1), oldRow.Values[idSel].Value())
+		require.Equal(t, "a@db1", oldRow.Values[emailSel].Value())
+		require.Equal(t, "a", oldRow.Values[nameSel].Value())
+
+		newRow := summary.ReturnedRows[0].New
+		require.NotNil(t, newRow)
+		require.Equal(t, int64(1), newRow.Values[idSel].Value())
+		require.Equal(t, "a@db1", newRow.Values[emailSel].Value())
+		require.Equal(t, "a2", newRow.Values[nameSel].Value())
+	})
+
+	t.Run("upserting a new row should return no old values", func(t *testing.T) {
+		summary, err := engine.ExecStmt("UPSERT INTO users (id, email) VALUES (1, 'a@db1'), (2, 'b@db1') RETURNING NEW.*, OLD.*", nil, true)
+		require.NoError(t, err)
+		require.Len(t, summary.ReturnedRows, 2)
+
+		require.Equal(t, "a2", summary.ReturnedRows[0].Old.Values[nameSel].Value())
+		require.Nil(t, summary.ReturnedRows[0].New.Values[nameSel].Value())
+
+		require.Nil(t, summary.ReturnedRows[1].Old)
+		require.Equal(t, int64(2), summary.ReturnedRows[1].New.Values[idSel].Value())
+		require.Equal(t, "b@db1", summary.ReturnedRows[1].New.Values[emailSel].Value())
+	})
+
+	t.Run("only the requested values should be returned", func(t *testing.T) {
+		summary, err := engine.ExecStmt("UPSERT INTO users (id, email, name) VALUES (2, 'b@db1', 'b') RETURNING OLD.*", nil, true)
+		require.NoError(t, err)
+		require.Len(t, summary.ReturnedRows, 1)
+		require.Nil(t, summary.ReturnedRows[0].New)
+		require.Nil(t, summary.ReturnedRows[0].Old.Values[nameSel].Value())
+
+		summary, err = engine.ExecStmt("UPSERT INTO users (id, email, name) VALUES (2, 'b@db1', 'b')", nil, true)
+		require.NoError(t, err)
+		require.Empty(t, summary.ReturnedRows)
+	})
+
+	t.Run("conflicting inserts should return the old values of the updated row", func(t *testing.T) {
+		summary, err := engine.ExecStmt(`
+			INSERT INTO users (id, email, name) VALUES (3, 'c@db1', 'c'), (4, 'b@db1', 'b3')
+			ON CONFLICT (email) DO UPDATE SET name = excluded.name
+			RETURNING OLD.*, NEW.*`, nil, true)
+		require.NoError(t, err)
+		require.Len(t, summary.ReturnedRows, 2)
+
+		require.Nil(t, summary.ReturnedRows[0].Old)
+		require.Equal(t, "c", summary.ReturnedRows[0].New.Values[nameSel].Value())
+
+		require.Equal(t, int64(2), summary.ReturnedRows[1].Old.Values[idSel].Value())
+		require.Equal(t, "b", summary.ReturnedRows[1].Old.Values[nameSel].Value())
+		require.Equal(t, int64(2), summary.ReturnedRows[1].New.Values[idSel].Value())
+		require.Equal(t, "b3", summary.ReturnedRows[1].New.Values[nameSel].Value())
+	})
+
+	t.Run("skipped conflicting inserts should not be returned", func(t *testing.T) {
+		summary, err := engine.ExecStmt("INSERT INTO users (id, email) VALUES (3, 'x@db1') ON CONFLICT DO NOTHING RETURNING NEW.*", nil, true)
+		require.NoError(t, err)
+		require.Empty(t, summary.ReturnedRows)
+	})
+
+	t.Run("unknown or repeated returned rows should fail", func(t *testing.T) {
+		_, err := engine.ExecStmt("UPSERT INTO users (id, email) VALUES (1, 'a@db1') RETURNING users.*", nil, true)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = engine.ExecStmt("UPSERT INTO users (id, email) VALUES (1, 'a@db1') RETURNING NEW.*, NEW.*", nil, true)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+}
+
+func TestLastCommittedTx(t *testing.T) {
+	catalogStore, err := store.Open("catalog_last_tx", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_last_tx")
+
+	dataStore, err := store.Open("sqldata_last_tx", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_last_tx")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	lastTxID, err := engine.LastCommittedTx()
+	require.NoError(t, err)
+	require.Zero(t, lastTxID)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	queryTxHeader := func(query string, renewSnapshot bool) *store.TxHeader {
+		r, err := engine.QueryStmt(query, nil, renewSnapshot)
+		require.NoError(t, err)
+		defer r.Close()
+
+		hdr, err := ReaderTxHeader(r)
+		require.NoError(t, err)
+
+		return hdr
+	}
+
+	hdr := queryTxHeader("SELECT id, title FROM table1", true)
+	require.Zero(t, hdr.ID)
+
+	var hdrs []*store.TxHeader
+
+	for i := 1; i <= 3; i++ {
+		summary, err := engine.ExecStmt("INSERT INTO table1(title) VALUES (@title)", map[string]interface{}{"title": fmt.Sprintf("title%d", i)}, true)
+		require.NoError(t, err)
+		require.Len(t, summary.DMTxs, 1)
+
+		currTxID, err := engine.LastCommittedTx()
+		require.NoError(t, err)
+		require.Greater(t, currTxID, lastTxID)
+		require.Equal(t, summary.DMTxs[0].ID, currTxID)
+
+		lastTxID = currTxID
+
+		// results of a query are tagged with the transaction they reflect
+		prevHdr := queryTxHeader("SELECT id, title FROM table1", false)
+		require.Equal(t, hdr.ID, prevHdr.ID)
+
+		hdr = queryTxHeader("SELECT id, title FROM table1", true)
+		require.Equal(t, summary.DMTxs[0], hdr)
+
+		hdrs = append(hdrs, hdr)
+	}
+
+	// headers of older transactions are read back from the data store
+	for i := 1; i < len(hdrs); i++ {
+		hdr := queryTxHeader(fmt.Sprintf("SELECT id, title FROM table1 BEFORE TX %d", hdrs[i].ID), false)
+		require.Equal(t, hdrs[i-1], hdr)
+	}
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	_, err = engine.LastCommittedTx()
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
+func TestVerifiableRow(t *testing.T) {
+	catalogStore, err := store.Open("catalog_verifiable_row", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_verifiable_row")
+
+	dataStore, err := store.Open("sqldata_verifiable_row", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_verifiable_row")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, _, err = engine.VerifiableRow("db1", "table1", []byte{1})
+	require.ErrorIs(t, err, ErrCatalogNotReady)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, active BOOLEAN, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active) VALUES (@id, @title, @active)",
+			map[string]interface{}{"id": i, "title": fmt.Sprintf("title%d", i), "active": i%2 == 0}, true)
+		require.NoError(t, err)
+	}
+
+	summary, err := engine.ExecStmt("UPDATE table1 SET title = 'updated' WHERE id = 2", nil, true)
+	require.NoError(t, err)
+
+	pk, err := EncodeAsKey(int64(2), IntegerType, 8)
+	require.NoError(t, err)
+
+	_, _, _, err = engine.VerifiableRow("db1", "table2", pk)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	_, _, _, err = engine.VerifiableRow("db1", "table1", nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	missingPK, err := EncodeAsKey(int64(10), IntegerType, 8)
+	require.NoError(t, err)
+
+	_, _, _, err = engine.VerifiableRow("db1", "table1", missingPK)
+	require.ErrorIs(t, err, store.ErrKeyNotFound)
+
+	row, md, proof, err := engine.VerifiableRow("db1", "table1", pk)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+	require.Equal(t, "updated", row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+	require.Equal(t, true, row.Values[EncodeSelector("", "db1", "table1", "active")].Value())
+
+	require.Equal(t, summary.DMTxs[0].ID, proof.Tx.ID)
+	require.Equal(t, summary.DMTxs[0].Alh(), proof.Tx.Alh())
+
+	entry := &store.EntrySpec{Key: proof.Key, Metadata: md, Value: proof.Value}
+	require.True(t, store.VerifyInclusion(proof.InclusionProof, entry, proof.Tx.Eh))
+
+	tamperedEntry := &store.EntrySpec{Key: proof.Key, Metadata: md, Value: append([]byte{0}, proof.Value...)}
+	require.False(t, store.VerifyInclusion(proof.InclusionProof, tamperedEntry, proof.Tx.Eh))
+
+	// the transaction holding the row is linked to the latest state of the store
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active) VALUES (10, 'title10', false)", nil, true)
+	require.NoError(t, err)
+
+	lastTxID, lastAlh := dataStore.Alh()
+
+	rowTx := dataStore.NewTx()
+	err = dataStore.ReadTx(proof.Tx.ID, rowTx)
+	require.NoError(t, err)
+
+	lastTx := dataStore.NewTx()
+	err = dataStore.ReadTx(lastTxID, lastTx)
+	require.NoError(t, err)
+
+	dualProof, err := dataStore.DualProof(rowTx, lastTx)
+	require.NoError(t, err)
+	require.True(t, store.VerifyDualProof(dualProof, proof.Tx.ID, lastTxID, proof.Tx.Alh(), lastAlh))
+
+	_, err = engine.ExecStmt("DELETE FROM table1 WHERE id = 2", nil, true)
+	require.NoError(t, err)
+
+	_, _, _, err = engine.VerifiableRow("db1", "table1", pk)
+	require.ErrorIs(t, err, store.ErrKeyNotFound)
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	_, _, _, err = engine.VerifiableRow("db1", "table1", pk)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
+func TestGetRow(t *testing.T) {
+	catalogStore, err := store.Open("catalog_get_row", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_get_row")
+
+	dataStore, err := store.Open("sqldata_get_row", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_get_row")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.GetRow("db1", "table1", 1, "code1")
+	require.ErrorIs(t, err, ErrCatalogNotReady)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, code VARCHAR[8], title VARCHAR, PRIMARY KEY (id, code))", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		INSERT INTO table1 (id, code, title) VALUES (1, 'code1', 'title1'), (1, 'code2', 'title2'), (2, 'code1', 'title3')
+	`, nil, true)
+	require.NoError(t, err)
+
+	t.Run("existing rows should be returned", func(t *testing.T) {
+		row, err := engine.GetRow("db1", "table1", 1, "code2")
+		require.NoError(t, err)
+		require.Len(t, row.Values, 3)
+		require.Equal(t, int64(1), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+		require.Equal(t, "code2", row.Values[EncodeSelector("", "db1", "table1", "code")].Value())
+		require.Equal(t, "title2", row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+
+		_, err = engine.ExecStmt("UPDATE table1 SET title = 'updated' WHERE id = 2", nil, true)
+		require.NoError(t, err)
+
+		row, err = engine.GetRow("db1", "table1", int64(2), "code1")
+		require.NoError(t, err)
+		require.Equal(t, "updated", row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+	})
+
+	t.Run("missing rows should not be found", func(t *testing.T) {
+		_, err := engine.GetRow("db1", "table1", 2, "code2")
+		require.ErrorIs(t, err, store.ErrKeyNotFound)
+
+		_, err = engine.ExecStmt("DELETE FROM table1 WHERE id = 1 AND code = 'code1'", nil, true)
+		require.NoError(t, err)
+
+		_, err = engine.GetRow("db1", "table1", 1, "code1")
+		require.ErrorIs(t, err, store.ErrKeyNotFound)
+	})
+
+	t.Run("invalid primary key values", func(t *testing.T) {
+		_, err := engine.GetRow("db1", "table2", 1)
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+		_, err = engine.GetRow("db1", "table1", 1)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = engine.GetRow("db1", "table1", 1, nil)
+		require.ErrorIs(t, err, ErrPKCanNotBeNull)
+
+		_, err = engine.GetRow("db1", "table1", "1", "code1")
+		require.ErrorIs(t, err, ErrInvalidValue)
+
+		_, err = engine.GetRow("db1", "table1", 1.5, "code1")
+		require.ErrorIs(t, err, ErrUnsupportedParameter)
+
+		_, err = engine.GetRow("db1", "table1", 1, "code123456789")
+		require.ErrorIs(t, err, ErrMaxLengthExceeded)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	_, err = engine.GetRow("db1", "table1", 1, "code1")
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
+func TestQueryAll(t *testing.T) {
+	catalogStore, err := store.Open("catalog_query_all", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_query_all")
+
+	dataStore, err := store.Open("sqldata_query_all", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_query_all")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix).WithMaxResultSize(10))
+	require.NoError(t, err)
+
+	_, _, err = engine.QueryAll("SELECT id FROM table1", nil)
+	require.ErrorIs(t, err, ErrCatalogNotReady)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, _, err = engine.QueryAll("CREATE TABLE table2 (id INTEGER, PRIMARY KEY id)", nil)
+	require.ErrorIs(t, err, ErrExpectingDQLStmt)
+
+	_, _, err = engine.QueryAll("SELECT id FROM table2", nil)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	rows, cols, err := engine.QueryAll("SELECT id, title FROM table1", nil)
+	require.NoError(t, err)
+	require.Empty(t, rows)
+	require.Len(t, cols, 2)
+
+	for i := 0; i < 10; i++ {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (@id, @title)",
+			map[string]interface{}{"id": i, "title": fmt.Sprintf("title%d", i)}, true)
+		require.NoError(t, err)
+	}
+
+	query := "SELECT id, title AS t FROM table1 WHERE id >= @id ORDER BY id DESC"
+	params := map[string]interface{}{"id": 3}
+
+	rows, cols, err = engine.QueryAll(query, params)
+	require.NoError(t, err)
+	require.Len(t, rows, 7)
+
+	r, err := engine.QueryStmt(query, params, true)
+	require.NoError(t, err)
+
+	expectedCols, err := r.Columns()
+	require.NoError(t, err)
+	require.Equal(t, expectedCols, cols)
+
+	for _, row := range rows {
+		expectedRow, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, expectedRow, row)
+	}
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	rows, _, err = engine.QueryAll("SELECT id FROM table1", nil)
+	require.NoError(t, err)
+	require.Len(t, rows, 10)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (10, 'title10')", nil, true)
+	require.NoError(t, err)
+
+	_, _, err = engine.QueryAll("SELECT id FROM table1", nil)
+	require.ErrorIs(t, err, ErrTooManyRows)
+
+	// the reader is closed even when the result is too big
+	rows, _, err = engine.QueryAll("SELECT id FROM table1 LIMIT 10", nil)
+	require.NoError(t, err)
+	require.Len(t, rows, 10)
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	_, _, err = engine.QueryAll("SELECT id FROM table1", nil)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
+func TestTransactions(t *testing.T) {
+	catalogStore, err := store.Open("catalog_tx", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_tx")
+
+	dataStore, err := store.Open("sqldata_tx", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_tx")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`CREATE TABLE table1 (
+									id INTEGER, 
+									title VARCHAR, 
+									PRIMARY KEY id
+								)`, nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		BEGIN TRANSACTION
+			CREATE INDEX ON table2(title)
+		COMMIT
+		`, nil, true)
+	require.Equal(t, ErrTableDoesNotExist, err)
+
+	_, err = engine.ExecStmt(`
+		BEGIN TRANSACTION
+			UPSERT INTO table1 (id, title) VALUES (1, 'title1');
+			UPSERT INTO table1 (id, title) VALUES (2, 'title2');
+		COMMIT
+		`, nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		BEGIN TRANSACTION
+			CREATE TABLE table2 (id INTEGER, title VARCHAR[100], age INTEGER, PRIMARY KEY id);
+			CREATE INDEX ON table2(title);
+		COMMIT
+		`, nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		BEGIN TRANSACTION
+			CREATE INDEX ON table2(age);
+			INSERT INTO table2 (id, title, age) VALUES (1, 'title1', 40);
+		COMMIT
+		`, nil, true)
+	require.Equal(t, ErrDDLorDMLTxOnly, err)
+}
+
+func TestUseSnapshot(t *testing.T) {
+	catalogStore, err := store.Open("catalog_snap", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_snap")
+
+	dataStore, err := store.Open("sqldata_snap", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_snap")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("USE SNAPSHOT SINCE TX 1", nil, true)
+	require.Equal(t, ErrNoSupported, err)
+
+	err = engine.UseSnapshot(1, 1)
+	require.Equal(t, ErrTxDoesNotExist, err)
+
+	err = engine.UseSnapshot(0, 1)
+	require.Equal(t, ErrTxDoesNotExist, err)
+
+	err = engine.UseSnapshot(1, 1)
+	require.Equal(t, ErrTxDoesNotExist, err)
+
+	err = engine.UseSnapshot(1, 2)
+	require.Equal(t, ErrIllegalArguments, err)
+
+	_, err = engine.ExecStmt(`
+		BEGIN TRANSACTION
+			UPSERT INTO table1 (id, title) VALUES (1, 'title1');
+			UPSERT INTO table1 (id, title) VALUES (2, 'title2');
+		COMMIT
+		`, nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseSnapshot(1, 0)
+	require.NoError(t, err)
+
+	err = engine.RenewSnapshot()
+	require.NoError(t, err)
+
+	err = engine.CloseSnapshot()
+	require.NoError(t, err)
+
+	err = engine.UseSnapshot(0, 1)
+	require.NoError(t, err)
+
+	err = engine.UseSnapshot(1, 1)
+	require.NoError(t, err)
+}
+
+func TestQueryAsOfTx(t *testing.T) {
+	catalogStore, err := store.Open("catalog_as_of_tx", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_as_of_tx")
+
+	dataStore, err := store.Open("sqldata_as_of_tx", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_as_of_tx")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	var txIDs []uint64
+
+	for _, stmt := range []string{
+		"INSERT INTO table1 (id, title) VALUES (1, 'title1')",
+		"INSERT INTO table1 (id, title) VALUES (2, 'title2')",
+		"UPDATE table1 SET title = 'title11' WHERE id = 1",
+		"INSERT INTO table1 (id, title) VALUES (3, 'title3')",
+	} {
+		summary, err := engine.ExecStmt(stmt, nil, true)
+		require.NoError(t, err)
+		require.Len(t, summary.DMTxs, 1)
+
+		txIDs = append(txIDs, summary.DMTxs[0].ID)
+	}
+
+	queryAsOf := func(txID uint64) []int64 {
+		return queryIDs(t, engine, fmt.Sprintf("SELECT id FROM table1 FOR SYSTEM_TIME AS OF TX %d", txID), nil)
+	}
+
+	require.Empty(t, queryAsOf(txIDs[0]-1))
+	require.Equal(t, []int64{1}, queryAsOf(txIDs[0]))
+	require.Equal(t, []int64{1, 2}, queryAsOf(txIDs[1]))
+	require.Equal(t, []int64{1, 2, 3}, queryAsOf(txIDs[3]))
+
+	// the row as it was when the transaction was committed
+	require.Equal(t, []int64{1}, queryIDs(t, engine, fmt.Sprintf("SELECT id FROM table1 FOR SYSTEM_TIME AS OF TX %d WHERE title = 'title1'", txIDs[1]), nil))
+	require.Equal(t, []int64{1}, queryIDs(t, engine, fmt.Sprintf("SELECT id FROM table1 FOR SYSTEM_TIME AS OF TX %d WHERE title = 'title11'", txIDs[2]), nil))
+	require.Empty(t, queryIDs(t, engine, "SELECT id FROM table1 WHERE title = 'title1'", nil))
+
+	// reading as of a transaction is the same as reading before the following one
+	require.Equal(t,
+		queryIDs(t, engine, fmt.Sprintf("SELECT id FROM table1 BEFORE TX %d", txIDs[2]), nil),
+		queryAsOf(txIDs[1]),
+	)
+
+	rows, _, err := engine.QueryAll(fmt.Sprintf("SELECT t1.id, t2.title FROM table1 FOR SYSTEM_TIME AS OF TX %d AS t1 INNER JOIN table1 AS t2 ON t1.id = t2.id", txIDs[0]), nil)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, "title11", rows[0].Values[EncodeSelector("", "db1", "t2", "title")].Value())
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestEncodeRawValue(t *testing.T) {
+	b, err := EncodeValue(int64(1), IntegerType, 0)
+	require.NoError(t, err)
+	require.EqualValues(t, []byte{0, 0, 0, 8, 0, 0, 0, 0, 0, 0, 0, 1}, b)
+
+	b, err = EncodeValue(true, IntegerType, 0)
+	require.ErrorIs(t, err, ErrInvalidValue)
+	require.Nil(t, b)
+
+	b, err = EncodeValue(true, BooleanType, 0)
+	require.NoError(t, err)
+	require.EqualValues(t, []byte{0, 0, 0, 1, 1}, b)
+
+	b, err = EncodeValue(uint64(1), BooleanType, 0)
+	require.ErrorIs(t, err, ErrInvalidValue)
+	require.Nil(t, b)
+
+	b, err = EncodeValue("title", VarcharType, 0)
+	require.NoError(t, err)
+	require.EqualValues(t, []byte{0, 0, 0, 5, 't', 'i', 't', 'l', 'e'}, b)
+
+	b, err = EncodeValue(uint64(1), VarcharType, 0)
+	require.ErrorIs(t, err, ErrInvalidValue)
+	require.Nil(t, b)
+
+	b, err = EncodeValue([]byte{}, BLOBType, 0)
+	require.NoError(t, err)
+	require.EqualValues(t, []byte{0, 0, 0, 0}, b)
+
+	b, err = EncodeValue(nil, BLOBType, 0)
+	require.NoError(t, err)
+	require.EqualValues(t, []byte{0, 0, 0, 0}, b)
+
+	b, err = EncodeValue(uint64(1), BLOBType, 0)
+	require.ErrorIs(t, err, ErrInvalidValue)
+	require.Nil(t, b)
+
+	b, err = EncodeValue(uint64(1), "invalid type", 0)
+	require.ErrorIs(t, err, ErrInvalidValue)
+	require.Nil(t, b)
+
+	// Max allowed key size is 32 bytes
+	b, err = EncodeValue("012345678901234567890123456789012", VarcharType, 32)
+	require.ErrorIs(t, err, ErrMaxLengthExceeded)
+	require.Nil(t, b)
+
+	_, err = EncodeValue("01234567890123456789012345678902", VarcharType, 0)
+	require.NoError(t, err)
+
+	_, err = EncodeValue("012345678901234567890123456789012", VarcharType, 0)
+	require.NoError(t, err)
+
+	b, err = EncodeValue([]byte{
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9,
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1, 2,
+	}, BLOBType, 32)
+	require.ErrorIs(t, err, ErrMaxLengthExceeded)
+	require.Nil(t, b)
+
+	_, err = EncodeValue([]byte{
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9,
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1,
+	}, BLOBType, 0)
+	require.NoError(t, err)
+
+	_, err = EncodeValue([]byte{
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9,
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1, 2,
+	}, BLOBType, 0)
+	require.NoError(t, err)
+}
+
+func TestEncodeValue(t *testing.T) {
+	b, err := EncodeValue((&Number{val: 1}).Value(), IntegerType, 0)
+	require.NoError(t, err)
+	require.EqualValues(t, []byte{0, 0, 0, 8, 0, 0, 0, 0, 0, 0, 0, 1}, b)
+
+	b, err = EncodeValue((&Bool{val: true}).Value(), IntegerType, 0)
+	require.ErrorIs(t, err, ErrInvalidValue)
+	require.Nil(t, b)
+
+	b, err = EncodeValue((&Bool{val: true}).Value(), BooleanType, 0)
+	require.NoError(t, err)
+	require.EqualValues(t, []byte{0, 0, 0, 1, 1}, b)
+
+	b, err = EncodeValue((&Number{val: 1}).Value(), BooleanType, 0)
+	require.ErrorIs(t, err, ErrInvalidValue)
+	require.Nil(t, b)
+
+	b, err = EncodeValue((&Varchar{val: "title"}).Value(), VarcharType, 0)
+	require.NoError(t, err)
+	require.EqualValues(t, []byte{0, 0, 0, 5, 't', 'i', 't', 'l', 'e'}, b)
+
+	b, err = EncodeValue((&Number{val: 1}).Value(), VarcharType, 0)
+	require.ErrorIs(t, err, ErrInvalidValue)
+	require.Nil(t, b)
+
+	b, err = EncodeValue((&Blob{val: []byte{}}).Value(), BLOBType, 50)
+	require.NoError(t, err)
+	require.EqualValues(t, []byte{0, 0, 0, 0}, b)
+
+	b, err = EncodeValue((&Blob{val: nil}).Value(), BLOBType, 50)
+	require.NoError(t, err)
+	require.EqualValues(t, []byte{0, 0, 0, 0}, b)
+
+	b, err = EncodeValue((&Number{val: 1}).Value(), BLOBType, 50)
+	require.ErrorIs(t, err, ErrInvalidValue)
+	require.Nil(t, b)
+
+	b, err = EncodeValue((&Number{val: 1}).Value(), "invalid type", 50)
+	require.ErrorIs(t, err, ErrInvalidValue)
+	require.Nil(t, b)
+
+	// Max allowed key size is 32 bytes
+	b, err = EncodeValue((&Varchar{val: "012345678901234567890123456789012"}).Value(), VarcharType, 32)
+	require.ErrorIs(t, err, ErrMaxLengthExceeded)
+	require.Nil(t, b)
+
+	_, err = EncodeValue((&Varchar{val: "01234567890123456789012345678902"}).Value(), VarcharType, 256)
+	require.NoError(t, err)
+
+	_, err = EncodeValue((&Varchar{val: "012345678901234567890123456789012"}).Value(), VarcharType, 256)
+	require.NoError(t, err)
+
+	b, err = EncodeValue((&Blob{val: []byte{
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9,
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1, 2,
+	}}).Value(), BLOBType, 32)
+	require.ErrorIs(t, err, ErrMaxLengthExceeded)
+	require.Nil(t, b)
+
+	_, err = EncodeValue((&Blob{val: []byte{
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9,
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1,
+	}}).Value(), BLOBType, 256)
+	require.NoError(t, err)
+
+	_, err = EncodeValue((&Blob{val: []byte{
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9,
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1, 2,
+	}}).Value(), BLOBType, 256)
+	require.NoError(t, err)
+}
+
+func TestClosing(t *testing.T) {
+	catalogStore, err := store.Open("catalog_closing", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_closing")
+
+	dataStore, err := store.Open("sqldata_closing", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_closing")
+
+	_, err = NewEngine(nil, nil, nil)
+	require.Equal(t, ErrIllegalArguments, err)
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.Equal(t, ErrAlreadyClosed, err)
+
+	_, err = engine.ExistDatabase("db1")
+	require.Equal(t, ErrAlreadyClosed, err)
+
+	err = engine.UseDatabase("db1")
+	require.Equal(t, ErrAlreadyClosed, err)
+
+	_, err = engine.GetDatabaseByName("db1")
+	require.Equal(t, ErrAlreadyClosed, err)
+
+	_, err = engine.GetTableByName("db1", "table1")
+	require.Equal(t, ErrAlreadyClosed, err)
+
+	_, err = engine.DatabaseInUse()
+	require.Equal(t, ErrAlreadyClosed, err)
+
+	err = engine.UseSnapshot(0, 0)
+	require.Equal(t, ErrAlreadyClosed, err)
+
+	err = engine.RenewSnapshot()
+	require.Equal(t, ErrAlreadyClosed, err)
+
+	err = engine.CloseSnapshot()
+	require.Equal(t, ErrAlreadyClosed, err)
+
+	_, err = engine.InferParameters("CREATE DATABASE db1")
+	require.Equal(t, ErrAlreadyClosed, err)
+
+	_, err = engine.InferParametersPreparedStmt(&TxStmt{})
+	require.Equal(t, ErrAlreadyClosed, err)
+
+	err = engine.EnsureCatalogReady(nil)
+	require.Equal(t, ErrAlreadyClosed, err)
+
+	err = engine.ReloadCatalog(nil)
+	require.Equal(t, ErrAlreadyClosed, err)
+}
+
+func TestQuery(t *testing.T) {
+	catalogStore, err := store.Open("catalog_q", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_q")
+
+	dataStore, err := store.Open("sqldata_q", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_q")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.QueryStmt("SELECT id FROM table1", nil, true)
+	require.Equal(t, ErrCatalogNotReady, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.QueryStmt("SELECT id FROM table1", nil, true)
+	require.Equal(t, ErrNoDatabaseSelected, err)
+
+	_, err = engine.QueryStmt("SELECT * FROM table1", nil, true)
+	require.Equal(t, ErrNoDatabaseSelected, err)
+
+	_, err = engine.ExecStmt("SELECT id FROM table1", nil, true)
+	require.Equal(t, ErrNoDatabaseSelected, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.QueryStmt("SELECT id FROM db2.table1", nil, true)
+	require.Equal(t, ErrDatabaseDoesNotExist, err)
+
+	_, err = engine.QueryStmt("SELECT id FROM table1", nil, true)
+	require.Equal(t, ErrTableDoesNotExist, err)
+
+	_, err = engine.ExecStmt(`CREATE TABLE table1 (
+								id INTEGER,
+								ts TIMESTAMP,
+								title VARCHAR,
+								active BOOLEAN,
+								payload BLOB,
+								PRIMARY KEY id)`, nil, true)
+	require.NoError(t, err)
+
+	params := make(map[string]interface{})
+	params["id"] = 0
+
+	r, err := engine.QueryStmt("SELECT id FROM db1.table1 WHERE id >= @id", nil, true)
+	require.NoError(t, err)
+
+	orderBy := r.OrderBy()
+	require.NotNil(t, orderBy)
+	require.Len(t, orderBy, 1)
+	require.Equal(t, "id", orderBy[0].Column)
+	require.Equal(t, "table1", orderBy[0].Table)
+	require.Equal(t, "db1", orderBy[0].Database)
+
+	_, err = r.Read()
+	require.Equal(t, ErrNoMoreRows, err)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT * FROM db1.table1", nil, true)
+	require.NoError(t, err)
+
+	_, err = r.Read()
+	require.Equal(t, ErrNoMoreRows, err)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	rowCount := 10
+
+	start := time.Now()
+
+	for i := 0; i < rowCount; i++ {
+		encPayload := hex.EncodeToString([]byte(fmt.Sprintf("blob%d", i)))
+		_, err = engine.ExecStmt(fmt.Sprintf(`
+			UPSERT INTO table1 (id, ts, title, active, payload)
+			VALUES (%d, NOW(), 'title%d', %v, x'%s')
+		`, i, i, i%2 == 0, encPayload), nil, true)
+		require.NoError(t, err)
+	}
+
+	t.Run("should resolve every row", func(t *testing.T) {
+		r, err = engine.QueryStmt("SELECT * FROM table1", nil, true)
+		require.NoError(t, err)
+
+		colsBySel, err := r.colsBySelector()
+		require.NoError(t, err)
+		require.Len(t, colsBySel, 5)
+
+		require.Equal(t, "db1", r.ImplicitDB())
+		require.Equal(t, "table1", r.ImplicitTable())
+
+		cols, err := r.Columns()
+		require.NoError(t, err)
+		require.Len(t, cols, 5)
+
+		for i := 0; i < rowCount; i++ {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.NotNil(t, row)
+			require.Len(t, row.Values, 5)
+			require.True(t, start.Before(row.Values[EncodeSelector("", "db1", "table1", "ts")].Value().(time.Time)))
+			require.Equal(t, int64(i), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+			require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+			require.Equal(t, i%2 == 0, row.Values[EncodeSelector("", "db1", "table1", "active")].Value())
+
+			encPayload := []byte(fmt.Sprintf("blob%d", i))
+			require.Equal(t, []byte(encPayload), row.Values[EncodeSelector("", "db1", "table1", "payload")].Value())
+		}
+
+		_, err = r.Read()
+		require.Equal(t, ErrNoMoreRows, err)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should fail reading due to non-existent column", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT id1 FROM table1", nil, true)
+		require.NoError(t, err)
+
+		row, err := r.Read()
+		require.Equal(t, ErrColumnDoesNotExist, err)
+		require.Nil(t, row)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should resolve every row with two-time table aliasing", func(t *testing.T) {
+		r, err = engine.QueryStmt(fmt.Sprintf(`
+			SELECT * FROM table1 AS mytable1 WHERE mytable1.id >= 0 LIMIT %d
+		`, rowCount), nil, true)
+		require.NoError(t, err)
+
+		colsBySel, err := r.colsBySelector()
+		require.NoError(t, err)
+		require.Len(t, colsBySel, 5)
+
+		require.Equal(t, "db1", r.ImplicitDB())
+		require.Equal(t, "mytable1", r.ImplicitTable())
+
+		cols, err := r.Columns()
+		require.NoError(t, err)
+		require.Len(t, cols, 5)
+
+		for i := 0; i < rowCount; i++ {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.NotNil(t, row)
+			require.Len(t, row.Values, 5)
+			require.True(t, start.Before(row.Values[EncodeSelector("", "db1", "mytable1", "ts")].Value().(time.Time)))
+			require.Equal(t, int64(i), row.Values[EncodeSelector("", "db1", "mytable1", "id")].Value())
+			require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "mytable1", "title")].Value())
+			require.Equal(t, i%2 == 0, row.Values[EncodeSelector("", "db1", "mytable1", "active")].Value())
+
+			encPayload := []byte(fmt.Sprintf("blob%d", i))
+			require.Equal(t, []byte(encPayload), row.Values[EncodeSelector("", "db1", "mytable1", "payload")].Value())
+		}
+
+		_, err = r.Read()
+		require.Equal(t, ErrNoMoreRows, err)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should resolve every row with column and two-time table aliasing", func(t *testing.T) {
+		r, err = engine.QueryStmt(fmt.Sprintf(`
+			SELECT mytable1.id AS D, ts, Title, payload, Active FROM table1 mytable1 WHERE mytable1.id >= 0 LIMIT %d
+		`, rowCount), nil, true)
+		require.NoError(t, err)
+
+		colsBySel, err := r.colsBySelector()
+		require.NoError(t, err)
+		require.Len(t, colsBySel, 5)
+
+		require.Equal(t, "db1", r.ImplicitDB())
+		require.Equal(t, "mytable1", r.ImplicitTable())
+
+		cols, err := r.Columns()
+		require.NoError(t, err)
+		require.Len(t, cols, 5)
+
+		for i := 0; i < rowCount; i++ {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.NotNil(t, row)
+			require.Len(t, row.Values, 5)
+			require.True(t, start.Before(row.Values[EncodeSelector("", "db1", "mytable1", "ts")].Value().(time.Time)))
+			require.Equal(t, int64(i), row.Values[EncodeSelector("", "db1", "mytable1", "d")].Value())
+			require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "mytable1", "title")].Value())
+			require.Equal(t, i%2 == 0, row.Values[EncodeSelector("", "db1", "mytable1", "active")].Value())
+
+			encPayload := []byte(fmt.Sprintf("blob%d", i))
+			require.Equal(t, []byte(encPayload), row.Values[EncodeSelector("", "db1", "mytable1", "payload")].Value())
+		}
+
+		_, err = r.Read()
+		require.Equal(t, ErrNoMoreRows, err)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should paginate rows using either LIMIT/OFFSET or OFFSET/FETCH", func(t *testing.T) {
+		require.Equal(t, []int64{3, 4, 5, 6}, queryIDs(t, engine, "SELECT id FROM table1 LIMIT 4 OFFSET 3", nil))
+		require.Equal(t, []int64{8, 9}, queryIDs(t, engine, "SELECT id FROM table1 OFFSET 8", nil))
+		require.Empty(t, queryIDs(t, engine, fmt.Sprintf("SELECT id FROM table1 LIMIT 4 OFFSET %d", rowCount), nil))
+		require.Empty(t, queryIDs(t, engine, "SELECT id FROM table1 FETCH FIRST 0 ROWS ONLY", nil))
+		require.Empty(t, queryIDs(t, engine, "SELECT id FROM table1 OFFSET 2 ROWS FETCH NEXT 0 ROWS ONLY", nil))
+		require.Empty(t, queryIDs(t, engine, "SELECT id FROM table1 LIMIT 0", nil))
+
+		require.Equal(t, queryIDs(t, engine, "SELECT id FROM table1", nil), queryIDs(t, engine, "SELECT id FROM table1 LIMIT ALL", nil))
+		require.Equal(t, queryIDs(t, engine, "SELECT id FROM table1 OFFSET 3", nil), queryIDs(t, engine, "SELECT id FROM table1 LIMIT ALL OFFSET 3", nil))
+		require.Equal(t, queryIDs(t, engine, "SELECT id FROM table1 OFFSET 3", nil), queryIDs(t, engine, "SELECT id FROM table1 OFFSET 3 LIMIT ALL", nil))
+
+		for _, p := range []struct {
+			limit  int
+			offset int
+		}{{1, 0}, {4, 3}, {5, 8}, {rowCount, rowCount}} {
+			expected := queryIDs(t, engine, fmt.Sprintf("SELECT id FROM table1 WHERE id >= 0 ORDER BY id DESC LIMIT %d OFFSET %d", p.limit, p.offset), nil)
+
+			require.Equal(t, expected, queryIDs(t, engine, fmt.Sprintf(
+				"SELECT id FROM table1 WHERE id >= 0 ORDER BY id DESC OFFSET %d ROWS FETCH NEXT %d ROWS ONLY", p.offset, p.limit), nil))
+
+			require.Equal(t, expected, queryIDs(t, engine, fmt.Sprintf(
+				"SELECT id FROM table1 WHERE id >= 0 ORDER BY id DESC FETCH FIRST %d ROWS ONLY OFFSET %d ROWS", p.limit, p.offset), nil))
+		}
+	})
+
+	t.Run("should paginate rows with parameterized limit and offset", func(t *testing.T) {
+		params, err := engine.InferParameters("SELECT id FROM table1 WHERE id >= @lowest ORDER BY id DESC LIMIT @n OFFSET @m")
+		require.NoError(t, err)
+		require.Equal(t, map[string]SQLValueType{"lowest": IntegerType, "n": IntegerType, "m": IntegerType}, params)
+
+		var pages [][]int64
+
+		for offset := 0; offset < rowCount; offset += 4 {
+			page := queryIDs(t, engine, "SELECT id FROM table1 WHERE id >= 0 ORDER BY id DESC LIMIT @n OFFSET @m", map[string]interface{}{"n": 4, "m": offset})
+			require.Equal(t, queryIDs(t, engine, fmt.Sprintf("SELECT id FROM table1 WHERE id >= 0 ORDER BY id DESC LIMIT 4 OFFSET %d", offset), nil), page)
+
+			pages = append(pages, page)
+		}
+
+		require.Len(t, pages, 3)
+		require.Equal(t, []int64{1, 0}, pages[2])
+
+		require.Equal(t, []int64{2, 3}, queryIDs(t, engine, "SELECT id FROM table1 OFFSET $1 ROWS FETCH NEXT $2 ROWS ONLY", map[string]interface{}{"param1": 2, "param2": 2}))
+
+		for _, params := range []map[string]interface{}{
+			{"n": 0, "m": 0},
+			{"n": -1, "m": 0},
+			{"n": "4", "m": 0},
+			{"n": 4, "m": -1},
+			{"n": 4, "m": true},
+		} {
+			_, err = engine.QueryStmt("SELECT id FROM table1 LIMIT @n OFFSET @m", params, true)
+			require.ErrorIs(t, err, ErrIllegalArguments)
+		}
+
+		_, err = engine.QueryStmt("SELECT id FROM table1 LIMIT @n", nil, true)
+		require.ErrorIs(t, err, ErrMissingParameter)
+	})
+
+	r, err = engine.QueryStmt("SELECT id, title, active, payload FROM table1 ORDER BY title", nil, true)
+	require.Equal(t, ErrLimitedOrderBy, err)
+	require.Nil(t, r)
+
+	r, err = engine.QueryStmt("SELECT Id, Title, Active, payload FROM Table1 ORDER BY Id DESC", nil, true)
+	require.NoError(t, err)
+
+	for i := 0; i < rowCount; i++ {
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.NotNil(t, row)
+		require.Len(t, row.Values, 4)
+
+		require.Equal(t, int64(rowCount-1-i), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+		require.Equal(t, fmt.Sprintf("title%d", rowCount-1-i), row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+		require.Equal(t, (rowCount-1-i)%2 == 0, row.Values[EncodeSelector("", "db1", "table1", "active")].Value())
+
+		encPayload := []byte(fmt.Sprintf("blob%d", rowCount-1-i))
+		require.Equal(t, []byte(encPayload), row.Values[EncodeSelector("", "db1", "table1", "payload")].Value())
+	}
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT id FROM table1 WHERE id", nil, true)
+	require.NoError(t, err)
+
+	_, err = r.Read()
+	require.Equal(t, ErrInvalidCondition, err)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	params = make(map[string]interface{})
+	params["some_param1"] = true
+
+	r, err = engine.QueryStmt("SELECT id FROM table1 WHERE active = @some_param1", nil, true)
+	require.NoError(t, err)
+
+	_, err = r.Read()
+	require.Equal(t, ErrMissingParameter, err)
+
+	r.SetParameters(params)
+
+	row, err := r.Read()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	params = make(map[string]interface{})
+	params["some_param"] = true
+
+	encPayloadPrefix := hex.EncodeToString([]byte("blob"))
+
+	r, err = engine.QueryStmt(fmt.Sprintf(`
+		SELECT id, title, active
+		FROM table1
+		WHERE active = @some_param AND title > 'title' AND payload >= x'%s' AND title LIKE 't`, encPayloadPrefix), params, true)
+	require.NoError(t, err)
+
+	for i := 0; i < rowCount/2; i += 2 {
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.NotNil(t, row)
+		require.Len(t, row.Values, 3)
+
+		require.Equal(t, int64(i), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+		require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+		require.Equal(t, params["some_param"], row.Values[EncodeSelector("", "db1", "table1", "active")].Value())
+	}
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT * FROM table1 WHERE id = 0", nil, true)
+	require.NoError(t, err)
+
+	cols, err := r.Columns()
+	require.NoError(t, err)
+	require.Len(t, cols, 5)
+
+	row, err = r.Read()
+	require.NoError(t, err)
+	require.Len(t, row.Values, 5)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT id, title, active FROM table1 WHERE id / 0", nil, true)
+	require.NoError(t, err)
+
+	_, err = r.Read()
+	require.Equal(t, ErrDivisionByZero, err)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT id, title, active FROM table1 WHERE id + 1/1 > 1 * (1 - 0)", nil, true)
+	require.NoError(t, err)
+
+	_, err = r.Read()
+	require.NoError(t, err)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT id, title, active FROM table1 WHERE id = 0 AND NOT active OR active", nil, true)
+	require.NoError(t, err)
+
+	_, err = r.Read()
+	require.NoError(t, err)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("INVALID QUERY", nil, false)
+	require.EqualError(t, err, "syntax error: unexpected IDENTIFIER")
+	require.Nil(t, r)
+
+	r, err = engine.QueryStmt("UPSERT INTO table1 (id) VALUES(1)", nil, false)
+	require.ErrorIs(t, err, ErrExpectingDQLStmt)
+	require.Nil(t, r)
+
+	r, err = engine.QueryStmt("UPSERT INTO table1 (id) VALUES(1); UPSERT INTO table1 (id) VALUES(1)", nil, false)
+	require.ErrorIs(t, err, ErrExpectingDQLStmt)
+	require.Nil(t, r)
+
+	r, err = engine.QueryPreparedStmt(nil, nil, false)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+	require.Nil(t, r)
+
+	params = make(map[string]interface{})
+	params["null_param"] = nil
+
+	r, err = engine.QueryStmt("SELECT id FROM table1 WHERE active = @null_param", params, true)
+	require.NoError(t, err)
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT * FROM table1", nil, true)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+	require.Nil(t, r)
+
+	r, err = engine.QueryStmt("SELECT * FROM table1", nil, false)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+	require.Nil(t, r)
+}
+
+func TestQueryDistinct(t *testing.T) {
+	catalogStore, err := store.Open("catalog_qd", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_qd")
+
+	dataStore, err := store.Open("sqldata_qd", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_qd")
+
+	opts := DefaultOptions().WithPrefix(sqlPrefix).WithDistinctLimit(4)
+	engine, err := NewEngine(catalogStore, dataStore, opts)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`CREATE TABLE table1 (
+								id INTEGER AUTO_INCREMENT,
+								title VARCHAR,
+								amount INTEGER,
+								active BOOLEAN,
+								PRIMARY KEY id)`, nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`INSERT INTO table1 (title, amount, active) VALUES 
+								('title1', 100, NULL),
+								('title2', 200, false),
+								('title3', 200, true),
+								('title4', 300, NULL)`, nil, true)
+	require.NoError(t, err)
+
+	t.Run("should return all titles", func(t *testing.T) {
+		params := make(map[string]interface{})
+		params["id"] = 3
+
+		r, err := engine.QueryStmt("SELECT DISTINCT title FROM table1 WHERE id <= @id", nil, true)
+		require.NoError(t, err)
+
+		r.SetParameters(params)
+
+		cols, err := r.Columns()
+		require.NoError(t, err)
+		require.Len(t, cols, 1)
+		require.Equal(t, "(db1.table1.title)", cols[0].Selector())
+
+		for i := 1; i <= 3; i++ {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Len(t, row.Values, 1)
+			require.Equal(t, fmt.Sprintf("title%d", i), row.Values["(db1.table1.title)"].Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should return two titles", func(t *testing.T) {
+		params := make(map[string]interface{})
+		params["id"] = 3
+
+		r, err := engine.QueryStmt("SELECT DISTINCT title FROM table1 WHERE id <= @id LIMIT 2", nil, true)
+		require.NoError(t, err)
+
+		r.SetParameters(params)
+
+		cols, err := r.Columns()
+		require.NoError(t, err)
+		require.Len(t, cols, 1)
+		require.Equal(t, "(db1.table1.title)", cols[0].Selector())
+
+		for i := 1; i <= 2; i++ {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Len(t, row.Values, 1)
+			require.Equal(t, fmt.Sprintf("title%d", i), row.Values["(db1.table1.title)"].Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should return two distinct amounts", func(t *testing.T) {
+		params := make(map[string]interface{})
+		params["id"] = 3
+
+		r, err := engine.QueryStmt("SELECT DISTINCT amount FROM table1 WHERE id <= @id", params, true)
+		require.NoError(t, err)
+
+		cols, err := r.Columns()
+		require.NoError(t, err)
+		require.Len(t, cols, 1)
+		require.Equal(t, "(db1.table1.amount)", cols[0].Selector())
+
+		for i := 1; i <= 2; i++ {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Len(t, row.Values, 1)
+			require.Equal(t, int64(i*100), row.Values["(db1.table1.amount)"].Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should return rows with null, false and true", func(t *testing.T) {
+		params := make(map[string]interface{})
+		params["id"] = 3
+
+		r, err := engine.QueryStmt("SELECT DISTINCT active FROM table1 WHERE id <= @id", params, true)
+		require.NoError(t, err)
+
+		cols, err := r.Columns()
+		require.NoError(t, err)
+		require.Len(t, cols, 1)
+		require.Equal(t, "(db1.table1.active)", cols[0].Selector())
+
+		for i := 0; i <= 2; i++ {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Len(t, row.Values, 1)
+
+			if i == 0 {
+				require.Nil(t, row.Values["(db1.table1.active)"].Value())
+				continue
+			}
+
+			require.Equal(t, i == 2, row.Values["(db1.table1.active)"].Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should return three rows", func(t *testing.T) {
+		params := make(map[string]interface{})
+		params["id"] = 3
+
+		r, err := engine.QueryStmt("SELECT DISTINCT amount, active FROM table1 WHERE id <= @id", params, true)
+		require.NoError(t, err)
+
+		cols, err := r.Columns()
+		require.NoError(t, err)
+		require.Len(t, cols, 2)
+		require.Equal(t, "(db1.table1.amount)", cols[0].Selector())
+		require.Equal(t, "(db1.table1.active)", cols[1].Selector())
+
+		for i := 0; i <= 2; i++ {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Len(t, row.Values, 2)
+
+			if i == 0 {
+				require.Equal(t, int64(100), row.Values["(db1.table1.amount)"].Value())
+				require.Nil(t, row.Values["(db1.table1.active)"].Value())
+				continue
+			}
+
+			require.Equal(t, int64(200), row.Values["(db1.table1.amount)"].Value())
+			require.Equal(t, i == 2, row.Values["(db1.table1.active)"].Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should return too many rows error", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT DISTINCT id FROM table1", nil, true)
+		require.NoError(t, err)
+
+		cols, err := r.Columns()
+		require.NoError(t, err)
+		require.Len(t, cols, 1)
+		require.Equal(t, "(db1.table1.id)", cols[0].Selector())
+
+		for i := 0; i < engine.distinctLimit; i++ {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Len(t, row.Values, 1)
+
+			require.Equal(t, int64(i+1), row.Values["(db1.table1.id)"].Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrTooManyRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+}
+
+func TestIndexing(t *testing.T) {
+	catalogStore, err := store.Open("catalog_indexing", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_indexing")
+
+	dataStore, err := store.Open("sqldata_indexing", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_indexing")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`CREATE TABLE table1 (
+								id INTEGER AUTO_INCREMENT, 
+								ts INTEGER, 
+								title VARCHAR[20], 
+								active BOOLEAN,
+								amount INTEGER,
+								payload BLOB, 
+								PRIMARY KEY id
+							)`, nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1 (ts)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table1 (title, amount)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1 (active, title)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table1 (title)", nil, true)
+	require.NoError(t, err)
+
+	t.Run("should fail due to unique index", func(t *testing.T) {
+		_, err = engine.ExecStmt("INSERT INTO table1 (ts, title, amount, active) VALUES (1, 'title1', 10, true), (2, 'title1', 10, false)", nil, true)
+		require.ErrorIs(t, err, store.ErrDuplicatedKey)
+	})
+
+	require.NoError(t, engine.EnsureCatalogReady(nil))
+
+	t.Run("should fail due non-available index", func(t *testing.T) {
+		_, err = engine.QueryStmt("SELECT * FROM table1 ORDER BY amount DESC", nil, true)
+		require.ErrorIs(t, err, ErrNoAvailableIndex)
+	})
+
+	require.NoError(t, engine.EnsureCatalogReady(nil))
+
+	t.Run("should use primary index by default", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT * FROM table1", nil, true)
+		require.NoError(t, err)
+
+		orderBy := r.OrderBy()
+		require.NotNil(t, orderBy)
+		require.Len(t, orderBy, 1)
+		require.Equal(t, "id", orderBy[0].Column)
+
+		scanSpecs := r.ScanSpecs()
+		require.NotNil(t, scanSpecs)
+		require.NotNil(t, scanSpecs.index)
+		require.True(t, scanSpecs.index.IsPrimary())
+		require.Empty(t, scanSpecs.rangesByColID)
+		require.False(t, scanSpecs.descOrder)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should use the index narrowing the scan the most when no ordering is specified", func(t *testing.T) {
+		testCases := []struct {
+			query        string
+			expectedCols []string
+		}{
+			{query: "SELECT * FROM table1 WHERE title = 'title1'", expectedCols: []string{"title"}},
+			{query: "SELECT * FROM table1 WHERE title = 'title1' AND amount = 10", expectedCols: []string{"title", "amount"}},
+			{query: "SELECT * FROM table1 WHERE active = true AND title > 'title1'", expectedCols: []string{"active", "title"}},
+			{query: "SELECT * FROM table1 WHERE ts > 10", expectedCols: []string{"ts"}},
+			{query: "SELECT * FROM table1 WHERE id = 1 AND title = 'title1'", expectedCols: []string{"id"}},
+			{query: "SELECT * FROM table1 WHERE amount = 10", expectedCols: []string{"id"}},
+			{query: "SELECT COUNT() AS c FROM table1 WHERE title = 'title1' GROUP BY id", expectedCols: []string{"id"}},
+		}
+
+		for _, tc := range testCases {
+			r, err := engine.QueryStmt(tc.query, nil, true)
+			require.NoError(t, err)
+
+			scanSpecs := r.ScanSpecs()
+			require.NotNil(t, scanSpecs)
+
+			cols := make([]string, len(scanSpecs.index.cols))
+			for i, col := range scanSpecs.index.cols {
+				cols[i] = col.colName
+			}
+			require.Equal(t, tc.expectedCols, cols, tc.query)
+
+			err = r.Close()
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("should use primary index in descending order", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT * FROM table1 ORDER BY id DESC", nil, true)
+		require.NoError(t, err)
+
+		orderBy := r.OrderBy()
+		require.NotNil(t, orderBy)
+		require.Len(t, orderBy, 1)
+		require.Equal(t, "id", orderBy[0].Column)
+
+		scanSpecs := r.ScanSpecs()
+		require.NotNil(t, scanSpecs)
+		require.NotNil(t, scanSpecs.index)
+		require.True(t, scanSpecs.index.IsPrimary())
+		require.Empty(t, scanSpecs.rangesByColID)
+		require.True(t, scanSpecs.descOrder)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should use index on `ts` ascending order", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT * FROM table1 ORDER BY ts", nil, true)
+		require.NoError(t, err)
+
+		orderBy := r.OrderBy()
+		require.NotNil(t, orderBy)
+		require.Len(t, orderBy, 1)
+		require.Equal(t, "ts", orderBy[0].Column)
+
+		scanSpecs := r.ScanSpecs()
+		require.NotNil(t, scanSpecs)
+		require.NotNil(t, scanSpecs.index)
+		require.False(t, scanSpecs.index.IsPrimary())
+		require.False(t, scanSpecs.index.IsUnique())
+		require.Len(t, scanSpecs.index.cols, 1)
+		require.Empty(t, scanSpecs.rangesByColID)
+		require.False(t, scanSpecs.descOrder)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should use index on `ts` descending order", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT * FROM table1 ORDER BY ts DESC", nil, true)
+		require.NoError(t, err)
+
+		orderBy := r.OrderBy()
+		require.NotNil(t, orderBy)
+		require.Len(t, orderBy, 1)
+		require.Equal(t, "ts", orderBy[0].Column)
+
+		scanSpecs := r.ScanSpecs()
+		require.NotNil(t, scanSpecs)
+		require.NotNil(t, scanSpecs.index)
+		require.False(t, scanSpecs.index.IsPrimary())
+		require.False(t, scanSpecs.index.IsUnique())
+		require.Len(t, scanSpecs.index.cols, 1)
+		require.Empty(t, scanSpecs.rangesByColID)
+		require.True(t, scanSpecs.descOrder)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should use index on `ts` with specific value", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT * FROM table1 WHERE ts = 1629902962 OR ts < 1629902963 ORDER BY ts", nil, true)
+		require.NoError(t, err)
+
+		orderBy := r.OrderBy()
+		require.NotNil(t, orderBy)
+		require.Len(t, orderBy, 1)
+		require.Equal(t, "ts", orderBy[0].Column)
+
+		scanSpecs := r.ScanSpecs()
+		require.NotNil(t, scanSpecs)
+		require.NotNil(t, scanSpecs.index)
+		require.False(t, scanSpecs.index.IsPrimary())
+		require.False(t, scanSpecs.index.IsUnique())
+		require.Len(t, scanSpecs.index.cols, 1)
+		require.Len(t, scanSpecs.rangesByColID, 1)
+
+		tsRange := scanSpecs.rangesByColID[2]
+		require.Nil(t, tsRange.lRange)
+		require.NotNil(t, tsRange.hRange)
+		require.False(t, tsRange.hRange.inclusive)
+		require.Equal(t, int64(1629902963), tsRange.hRange.val.Value())
+
+		require.False(t, scanSpecs.descOrder)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should use index on `ts` with specific value", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT * FROM table1 AS t WHERE t.ts = 1629902962 AND t.ts = 1629902963 ORDER BY t.ts", nil, true)
+		require.NoError(t, err)
+
+		orderBy := r.OrderBy()
+		require.NotNil(t, orderBy)
+		require.Len(t, orderBy, 1)
+		require.Equal(t, "ts", orderBy[0].Column)
+
+		scanSpecs := r.ScanSpecs()
+		require.NotNil(t, scanSpecs)
+		require.NotNil(t, scanSpecs.index)
+		require.False(t, scanSpecs.index.IsPrimary())
+		require.False(t, scanSpecs.index.IsUnique())
+		require.Len(t, scanSpecs.index.cols, 1)
+		require.Len(t, scanSpecs.rangesByColID, 1)
+
+		tsRange := scanSpecs.rangesByColID[2]
+		require.NotNil(t, tsRange.lRange)
+		require.True(t, tsRange.lRange.inclusive)
+		require.Equal(t, int64(1629902963), tsRange.lRange.val.Value())
+		require.NotNil(t, tsRange.hRange)
+		require.True(t, tsRange.hRange.inclusive)
+		require.Equal(t, int64(1629902962), tsRange.hRange.val.Value())
+
+		require.False(t, scanSpecs.descOrder)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should use index on `ts` with specific value", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT * FROM table1 WHERE ts > 1629902962 AND ts < 1629902963 ORDER BY ts", nil, true)
+		require.NoError(t, err)
+
+		orderBy := r.OrderBy()
+		require.NotNil(t, orderBy)
+		require.Len(t, orderBy, 1)
+		require.Equal(t, "ts", orderBy[0].Column)
+
+		scanSpecs := r.ScanSpecs()
+		require.NotNil(t, scanSpecs)
+		require.NotNil(t, scanSpecs.index)
+		require.False(t, scanSpecs.index.IsPrimary())
+		require.False(t, scanSpecs.index.IsUnique())
+		require.Len(t, scanSpecs.index.cols, 1)
+		require.Len(t, scanSpecs.rangesByColID, 1)
+
+		tsRange := scanSpecs.rangesByColID[2]
+		require.NotNil(t, tsRange.lRange)
+		require.False(t, tsRange.lRange.inclusive)
+		require.Equal(t, int64(1629902962), tsRange.lRange.val.Value())
+		require.NotNil(t, tsRange.hRange)
+		require.False(t, tsRange.hRange.inclusive)
+		require.Equal(t, int64(1629902963), tsRange.hRange.val.Value())
+
+		require.False(t, scanSpecs.descOrder)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should use index on `title, amount` in asc order", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT * FROM table1 USE INDEX ON (title, amount) ORDER BY title", nil, true)
+		require.NoError(t, err)
+
+		orderBy := r.OrderBy()
+		require.NotNil(t, orderBy)
+		require.Len(t, orderBy, 2)
+		require.Equal(t, "title", orderBy[0].Column)
+		require.Equal(t, "amount", orderBy[1].Column)
+
+		scanSpecs := r.ScanSpecs()
+		require.NotNil(t, scanSpecs)
+		require.NotNil(t, scanSpecs.index)
+		require.False(t, scanSpecs.index.IsPrimary())
+		require.True(t, scanSpecs.index.IsUnique())
+		require.Len(t, scanSpecs.index.cols, 2)
+		require.Empty(t, scanSpecs.rangesByColID)
+		require.False(t, scanSpecs.descOrder)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should use index on `title` in asc order", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT * FROM table1 USE INDEX ON (title) ORDER BY title", nil, true)
+		require.NoError(t, err)
+
+		orderBy := r.OrderBy()
+		require.NotNil(t, orderBy)
+		require.Len(t, orderBy, 1)
+		require.Equal(t, "title", orderBy[0].Column)
+
+		scanSpecs := r.ScanSpecs()
+		require.NotNil(t, scanSpecs)
+		require.NotNil(t, scanSpecs.index)
+		require.False(t, scanSpecs.index.IsPrimary())
+		require.True(t, scanSpecs.index.IsUnique())
+		require.Len(t, scanSpecs.index.cols, 1)
+		require.Empty(t, scanSpecs.rangesByColID)
+		require.False(t, scanSpecs.descOrder)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should use index on `ts` in default order", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT * FROM table1 USE INDEX ON (ts)", nil, true)
+		require.NoError(t, err)
+
+		orderBy := r.OrderBy()
+		require.NotNil(t, orderBy)
+		require.Len(t, orderBy, 1)
+		require.Equal(t, "ts", orderBy[0].Column)
+
+		scanSpecs := r.ScanSpecs()
+		require.NotNil(t, scanSpecs)
+		require.NotNil(t, scanSpecs.index)
+		require.False(t, scanSpecs.index.IsPrimary())
+		require.False(t, scanSpecs.index.IsUnique())
+		require.Len(t, scanSpecs.index.cols, 1)
+		require.Empty(t, scanSpecs.rangesByColID)
+		require.False(t, scanSpecs.descOrder)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should fail using index on `ts` when ordering by `title`", func(t *testing.T) {
+		_, err := engine.QueryStmt("SELECT * FROM table1 USE INDEX ON (ts) ORDER BY title", nil, true)
+		require.ErrorIs(t, err, ErrNoAvailableIndex)
+	})
+
+	t.Run("should use index on `title` with max value in desc order", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT * FROM table1 USE INDEX ON (title) WHERE title < 'title10' ORDER BY title DESC", nil, true)
+		require.NoError(t, err)
+
+		orderBy := r.OrderBy()
+		require.NotNil(t, orderBy)
+		require.Len(t, orderBy, 1)
+		require.Equal(t, "title", orderBy[0].Column)
+
+		scanSpecs := r.ScanSpecs()
+		require.NotNil(t, scanSpecs)
+		require.NotNil(t, scanSpecs.index)
+		require.False(t, scanSpecs.index.IsPrimary())
+		require.True(t, scanSpecs.index.IsUnique())
+		require.Len(t, scanSpecs.index.cols, 1)
+		require.Len(t, scanSpecs.rangesByColID, 1)
+
+		titleRange := scanSpecs.rangesByColID[3]
+		require.Nil(t, titleRange.lRange)
+		require.NotNil(t, titleRange.hRange)
+		require.False(t, titleRange.hRange.inclusive)
+		require.Equal(t, "title10", titleRange.hRange.val.Value())
+
+		require.True(t, scanSpecs.descOrder)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should use index on `title,amount` in desc order", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT * FROM table1 WHERE title = 'title1' ORDER BY amount DESC", nil, true)
+		require.NoError(t, err)
+
+		orderBy := r.OrderBy()
+		require.NotNil(t, orderBy)
+		require.Len(t, orderBy, 2)
+		require.Equal(t, "title", orderBy[0].Column)
+		require.Equal(t, "amount", orderBy[1].Column)
+
+		scanSpecs := r.ScanSpecs()
+		require.NotNil(t, scanSpecs)
+		require.NotNil(t, scanSpecs.index)
+		require.False(t, scanSpecs.index.IsPrimary())
+		require.True(t, scanSpecs.index.IsUnique())
+		require.Len(t, scanSpecs.index.cols, 2)
+		require.Len(t, scanSpecs.rangesByColID, 1)
+
+		titleRange := scanSpecs.rangesByColID[3]
+		require.NotNil(t, titleRange.lRange)
+		require.True(t, titleRange.lRange.inclusive)
+		require.Equal(t, "title1", titleRange.lRange.val.Value())
+		require.NotNil(t, titleRange.hRange)
+		require.True(t, titleRange.hRange.inclusive)
+		require.Equal(t, "title1", titleRange.hRange.val.Value())
+
+		require.True(t, scanSpecs.descOrder)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should use index on `ts` ascending order", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT * FROM table1 WHERE title > 'title10' ORDER BY ts ASC", nil, true)
+		require.NoError(t, err)
+
+		orderBy := r.OrderBy()
+		require.NotNil(t, orderBy)
+		require.Len(t, orderBy, 1)
+		require.Equal(t, "ts", orderBy[0].Column)
+
+		scanSpecs := r.ScanSpecs()
+		require.NotNil(t, scanSpecs)
+		require.NotNil(t, scanSpecs.index)
+		require.False(t, scanSpecs.index.IsPrimary())
+		require.False(t, scanSpecs.index.IsUnique())
+		require.Len(t, scanSpecs.index.cols, 1)
+		require.Len(t, scanSpecs.rangesByColID, 1)
+
+		titleRange := scanSpecs.rangesByColID[3]
+		require.NotNil(t, titleRange.lRange)
+		require.False(t, titleRange.lRange.inclusive)
+		require.Equal(t, "title10", titleRange.lRange.val.Value())
+		require.Nil(t, titleRange.hRange)
+
+		require.False(t, scanSpecs.descOrder)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should use index on `ts` descending order", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT * FROM table1 WHERE title > 'title10' or title = 'title1' ORDER BY ts DESC", nil, true)
+		require.NoError(t, err)
+
+		orderBy := r.OrderBy()
+		require.NotNil(t, orderBy)
 		require.Len(t, orderBy, 1)
 		require.Equal(t, "ts", orderBy[0].Column)
 
-		scanSpecs := r.ScanSpecs()
-		require.NotNil(t, scanSpecs)
-		require.NotNil(t, scanSpecs.index)
-		require.False(t, scanSpecs.index.IsPrimary())
-		require.False(t, scanSpecs.index.IsUnique())
-		require.Len(t, scanSpecs.index.cols, 1)
-		require.Empty(t, scanSpecs.rangesByColID)
-		require.False(t, scanSpecs.descOrder)
+		scanSpecs := r.ScanSpecs()
+		require.NotNil(t, scanSpecs)
+		require.NotNil(t, scanSpecs.index)
+		require.False(t, scanSpecs.index.IsPrimary())
+		require.False(t, scanSpecs.index.IsUnique())
+		require.Len(t, scanSpecs.index.cols, 1)
+		require.Len(t, scanSpecs.rangesByColID, 1)
+
+		titleRange := scanSpecs.rangesByColID[3]
+		require.NotNil(t, titleRange.lRange)
+		require.True(t, titleRange.lRange.inclusive)
+		require.Equal(t, "title1", titleRange.lRange.val.Value())
+		require.Nil(t, titleRange.hRange)
+
+		require.True(t, scanSpecs.descOrder)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should use index on `title` descending order", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT * FROM table1 WHERE title > 'title10' or title = 'title1' ORDER BY title DESC", nil, true)
+		require.NoError(t, err)
+
+		orderBy := r.OrderBy()
+		require.NotNil(t, orderBy)
+		require.Len(t, orderBy, 2)
+		require.Equal(t, "title", orderBy[0].Column)
+		require.Equal(t, "amount", orderBy[1].Column)
+
+		scanSpecs := r.ScanSpecs()
+		require.NotNil(t, scanSpecs)
+		require.NotNil(t, scanSpecs.index)
+		require.False(t, scanSpecs.index.IsPrimary())
+		require.True(t, scanSpecs.index.IsUnique())
+		require.Len(t, scanSpecs.index.cols, 2)
+		require.Len(t, scanSpecs.rangesByColID, 1)
+
+		titleRange := scanSpecs.rangesByColID[3]
+		require.NotNil(t, titleRange.lRange)
+		require.True(t, titleRange.lRange.inclusive)
+		require.Equal(t, "title1", titleRange.lRange.val.Value())
+		require.Nil(t, titleRange.hRange)
+
+		require.True(t, scanSpecs.descOrder)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should use index on `title` ascending order starting with 'title1'", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT * FROM table1 USE INDEX ON (title) WHERE title > 'title10' or title = 'title1' ORDER BY title", nil, true)
+		require.NoError(t, err)
+
+		orderBy := r.OrderBy()
+		require.NotNil(t, orderBy)
+		require.Len(t, orderBy, 1)
+		require.Equal(t, "title", orderBy[0].Column)
+
+		scanSpecs := r.ScanSpecs()
+		require.NotNil(t, scanSpecs)
+		require.NotNil(t, scanSpecs.index)
+		require.False(t, scanSpecs.index.IsPrimary())
+		require.True(t, scanSpecs.index.IsUnique())
+		require.Len(t, scanSpecs.index.cols, 1)
+		require.Len(t, scanSpecs.rangesByColID, 1)
+
+		titleRange := scanSpecs.rangesByColID[3]
+		require.NotNil(t, titleRange.lRange)
+		require.True(t, titleRange.lRange.inclusive)
+		require.Equal(t, "title1", titleRange.lRange.val.Value())
+		require.Nil(t, titleRange.hRange)
+
+		require.False(t, scanSpecs.descOrder)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should use index on `title` ascending order", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT * FROM table1 USE INDEX ON (title) WHERE title < 'title10' or title = 'title1' ORDER BY title", nil, true)
+		require.NoError(t, err)
+
+		orderBy := r.OrderBy()
+		require.NotNil(t, orderBy)
+		require.Len(t, orderBy, 1)
+		require.Equal(t, "title", orderBy[0].Column)
+
+		scanSpecs := r.ScanSpecs()
+		require.NotNil(t, scanSpecs)
+		require.NotNil(t, scanSpecs.index)
+		require.False(t, scanSpecs.index.IsPrimary())
+		require.True(t, scanSpecs.index.IsUnique())
+		require.Len(t, scanSpecs.index.cols, 1)
+		require.Len(t, scanSpecs.rangesByColID, 1)
+
+		titleRange := scanSpecs.rangesByColID[3]
+		require.Nil(t, titleRange.lRange)
+		require.NotNil(t, titleRange.hRange)
+		require.False(t, titleRange.hRange.inclusive)
+		require.Equal(t, "title10", titleRange.hRange.val.Value())
+
+		require.False(t, scanSpecs.descOrder)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should use index on `title` descending order", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT * FROM table1 USE INDEX ON (title) WHERE title < 'title10' and title = 'title1' ORDER BY title DESC", nil, true)
+		require.NoError(t, err)
+
+		orderBy := r.OrderBy()
+		require.NotNil(t, orderBy)
+		require.Len(t, orderBy, 1)
+		require.Equal(t, "title", orderBy[0].Column)
+
+		scanSpecs := r.ScanSpecs()
+		require.NotNil(t, scanSpecs)
+		require.NotNil(t, scanSpecs.index)
+		require.False(t, scanSpecs.index.IsPrimary())
+		require.True(t, scanSpecs.index.IsUnique())
+		require.Len(t, scanSpecs.index.cols, 1)
+		require.Len(t, scanSpecs.rangesByColID, 1)
+
+		titleRange := scanSpecs.rangesByColID[3]
+		require.NotNil(t, titleRange.lRange)
+		require.True(t, titleRange.lRange.inclusive)
+		require.Equal(t, "title1", titleRange.lRange.val.Value())
+		require.NotNil(t, titleRange.hRange)
+		require.True(t, titleRange.hRange.inclusive)
+		require.Equal(t, "title1", titleRange.hRange.val.Value())
+
+		require.True(t, scanSpecs.descOrder)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestExecCornerCases(t *testing.T) {
+	catalogStore, err := store.Open("catalog_q", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_q")
+
+	dataStore, err := store.Open("sqldata_q", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_q")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	summary, err := engine.ExecStmt("INVALID STATEMENT", nil, false)
+	require.EqualError(t, err, "syntax error: unexpected IDENTIFIER")
+	require.Nil(t, summary)
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	summary, err = engine.ExecStmt("CREATE TABLE t1(id INTEGER, primary key id)", nil, false)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+	require.Nil(t, summary)
+}
+
+func TestQueryWithNullables(t *testing.T) {
+	catalogStore, err := store.Open("catalog_nullable", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_nullable")
+
+	dataStore, err := store.Open("sqldata_nullable", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_nullable")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, ts TIMESTAMP, title VARCHAR, active BOOLEAN, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, ts, title) VALUES (1, TIME(), 'title1')", nil, true)
+	require.Equal(t, ErrNoSupported, err)
+
+	rowCount := 10
+
+	start := time.Now()
+
+	for i := 0; i < rowCount; i++ {
+		_, err = engine.ExecStmt(fmt.Sprintf("UPSERT INTO table1 (id, ts, title) VALUES (%d, NOW(), 'title%d')", i, i), nil, true)
+		require.NoError(t, err)
+	}
+
+	r, err := engine.QueryStmt("SELECT id, ts, title, active FROM table1 WHERE NOT(active != NULL)", nil, true)
+	require.NoError(t, err)
+
+	cols, err := r.Columns()
+	require.NoError(t, err)
+	require.Len(t, cols, 4)
+
+	for i := 0; i < rowCount; i++ {
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.NotNil(t, row)
+		require.Len(t, row.Values, 4)
+		require.True(t, start.Before(row.Values[EncodeSelector("", "db1", "table1", "ts")].Value().(time.Time)))
+		require.Equal(t, int64(i), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+		require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+		require.Equal(t, &NullValue{t: BooleanType}, row.Values[EncodeSelector("", "db1", "table1", "active")])
+	}
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	t.Run("IS TRUE, IS FALSE and IS UNKNOWN yield concrete booleans", func(t *testing.T) {
+		_, err = engine.ExecStmt("UPDATE table1 SET active = id < 3 WHERE id < 6", nil, true)
+		require.NoError(t, err)
+
+		// comparisons against NULL are not satisfied, neither their negation
+		require.Empty(t, queryIDs(t, engine, "SELECT id FROM table1 WHERE NOT (active = true) AND NOT (active = false) AND active != NULL", nil))
+
+		for _, c := range []struct {
+			cond     string
+			expected []int64
+		}{
+			{cond: "active IS TRUE", expected: []int64{0, 1, 2}},
+			{cond: "active IS FALSE", expected: []int64{3, 4, 5}},
+			{cond: "active IS UNKNOWN", expected: []int64{6, 7, 8, 9}},
+			{cond: "active IS NOT TRUE", expected: []int64{3, 4, 5, 6, 7, 8, 9}},
+			{cond: "active IS NOT FALSE", expected: []int64{0, 1, 2, 6, 7, 8, 9}},
+			{cond: "active IS NOT UNKNOWN", expected: []int64{0, 1, 2, 3, 4, 5}},
+			{cond: "NOT (active IS TRUE) AND id > 5", expected: []int64{6, 7, 8, 9}},
+			{cond: "(active AND id > 1) IS FALSE", expected: []int64{0, 1, 3, 4, 5}},
+			{cond: "@flag IS UNKNOWN", expected: []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		} {
+			require.Equal(t, c.expected, queryIDs(t, engine, "SELECT id FROM table1 WHERE "+c.cond, map[string]interface{}{"flag": nil}), c.cond)
+		}
+
+		rows, _, err := engine.QueryAll("SELECT active IS TRUE AS t, active IS UNKNOWN AS u FROM table1 WHERE id = 9", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, &Bool{val: false}, rows[0].Values[EncodeSelector("", "db1", "table1", "t")])
+		require.Equal(t, &Bool{val: true}, rows[0].Values[EncodeSelector("", "db1", "table1", "u")])
+
+		_, _, err = engine.QueryAll("SELECT id FROM table1 WHERE title IS TRUE", nil)
+		require.ErrorIs(t, err, ErrInvalidCondition)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestOrderBy(t *testing.T) {
+	catalogStore, err := store.Open("catalog_orderby", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_orderby")
+
+	dataStore, err := store.Open("sqldata_orderby", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_orderby")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(title)", nil, true)
+	require.Equal(t, ErrNoDatabaseSelected, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[100], age INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.QueryStmt("SELECT id, title, age FROM table1 ORDER BY id, title DESC", nil, true)
+	require.Equal(t, ErrLimitedOrderBy, err)
+
+	_, err = engine.QueryStmt("SELECT id, title, age FROM (SELECT id, title, age FROM table1) ORDER BY id", nil, true)
+	require.Equal(t, ErrLimitedOrderBy, err)
+
+	_, err = engine.QueryStmt("SELECT id, title, age FROM (SELECT id, title, age FROM table1 AS t1) ORDER BY age DESC", nil, true)
+	require.Equal(t, ErrLimitedOrderBy, err)
+
+	_, err = engine.QueryStmt("SELECT id, title, age FROM table2 ORDER BY title", nil, true)
+	require.Equal(t, ErrTableDoesNotExist, err)
+
+	_, err = engine.QueryStmt("SELECT id, title, age FROM table1 ORDER BY amount", nil, true)
+	require.Equal(t, ErrColumnDoesNotExist, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(title)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.QueryStmt("SELECT id, title, age FROM table1 ORDER BY age", nil, true)
+	require.Equal(t, ErrLimitedOrderBy, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(age)", nil, true)
+	require.NoError(t, err)
+
+	params := make(map[string]interface{}, 1)
+	params["age"] = nil
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, age) VALUES (1, 'title', @age)", params, true)
+	require.Equal(t, ErrIndexedColumnCanNotBeNull, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (1, 'title')", nil, true)
+	require.Equal(t, ErrIndexedColumnCanNotBeNull, err)
+
+	rowCount := 1
+
+	for i := 0; i < rowCount; i++ {
+		params := make(map[string]interface{}, 3)
+		params["id"] = i
+		params["title"] = fmt.Sprintf("title%d", i)
+		params["age"] = 40 + i
+
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, age) VALUES (@id, @title, @age)", params, true)
+		require.NoError(t, err)
+	}
+
+	r, err := engine.QueryStmt("SELECT id, title, age FROM table1 ORDER BY title", nil, true)
+	require.NoError(t, err)
+
+	orderBy := r.OrderBy()
+	require.NotNil(t, orderBy)
+	require.Len(t, orderBy, 1)
+	require.Equal(t, "title", orderBy[0].Column)
+	require.Equal(t, "table1", orderBy[0].Table)
+	require.Equal(t, "db1", orderBy[0].Database)
+
+	for i := 0; i < rowCount; i++ {
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.NotNil(t, row)
+		require.Len(t, row.Values, 3)
+
+		require.Equal(t, int64(i), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+		require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+		require.Equal(t, int64(40+i), row.Values[EncodeSelector("", "db1", "table1", "age")].Value())
+	}
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT id, title, age FROM table1 ORDER BY age", nil, true)
+	require.NoError(t, err)
+
+	for i := 0; i < rowCount; i++ {
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.NotNil(t, row)
+		require.Len(t, row.Values, 3)
+
+		require.Equal(t, int64(i), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+		require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+		require.Equal(t, int64(40+i), row.Values[EncodeSelector("", "db1", "table1", "age")].Value())
+	}
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT id, title, age FROM table1 ORDER BY age DESC", nil, true)
+	require.NoError(t, err)
+
+	for i := 0; i < rowCount; i++ {
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.NotNil(t, row)
+		require.Len(t, row.Values, 3)
+
+		require.Equal(t, int64(rowCount-1-i), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+		require.Equal(t, fmt.Sprintf("title%d", rowCount-1-i), row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+		require.Equal(t, int64(40-(rowCount-1-i)), row.Values[EncodeSelector("", "db1", "table1", "age")].Value())
+	}
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestOrderByNonIndexedColumnWithLimit(t *testing.T) {
+	catalogStore, err := store.Open("catalog_orderby_limit", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_orderby_limit")
+
+	dataStore, err := store.Open("sqldata_orderby_limit", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_orderby_limit")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[10], amount INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(title)", nil, true)
+	require.NoError(t, err)
+
+	type row struct {
+		id     int64
+		amount interface{}
+	}
+
+	var rows []row
+
+	for i := 0; i < 200; i++ {
+		var amount interface{}
+		if i%17 != 0 {
+			amount = int64(i * 37 % 50)
+		}
+
+		params := map[string]interface{}{"id": i, "title": fmt.Sprintf("title%d", i%3), "amount": amount}
+
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, amount) VALUES (@id, @title, @amount)", params, true)
+		require.NoError(t, err)
+
+		rows = append(rows, row{id: int64(i), amount: amount})
+	}
+
+	// rows with equal amounts are kept in the order they are scanned, i.e. by id
+	expectedIDs := func(minID int64, descOrder bool, limit, offset int) []int64 {
+		var sorted []row
+		for _, r := range rows {
+			if r.id > minID {
+				sorted = append(sorted, r)
+			}
+		}
+
+		sort.SliceStable(sorted, func(i, j int) bool {
+			ai, aj := sorted[i].amount, sorted[j].amount
+			if descOrder {
+				ai, aj = aj, ai
+			}
+			if ai == nil || aj == nil {
+				return ai == nil && aj != nil
+			}
+			return ai.(int64) < aj.(int64)
+		})
+
+		var ids []int64
+		for i := offset; i < len(sorted) && i < offset+limit; i++ {
+			ids = append(ids, sorted[i].id)
+		}
+
+		return ids
+	}
+
+	for _, descOrder := range []bool{false, true} {
+		for _, limit := range []int{0, 1, 5, 60, 300} {
+			for _, offset := range []int{0, 7} {
+				for _, minID := range []int64{-1, 150} {
+					query := fmt.Sprintf("SELECT id, amount FROM table1 WHERE id > %d ORDER BY amount", minID)
+					if descOrder {
+						query += " DESC"
+					}
+					query += fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset)
+
+					require.Equal(t, expectedIDs(minID, descOrder, limit, offset), queryIDs(t, engine, query, nil), query)
+				}
+			}
+		}
+	}
+
+	t.Run("rows should be sorted when the index of the column can not be used", func(t *testing.T) {
+		ids := queryIDs(t, engine, "SELECT id FROM table1 USE INDEX ON title WHERE title = 'title1' ORDER BY id DESC LIMIT 3", nil)
+		require.Equal(t, []int64{199, 196, 193}, ids)
+	})
+
+	t.Run("rows should be sorted after being joined", func(t *testing.T) {
+		ids := queryIDs(t, engine, `
+			SELECT table1.id
+			FROM table1
+			INNER JOIN table1 AS t2 ON t2.id = table1.id + 1
+			WHERE table1.id < 20
+			ORDER BY t2.amount DESC LIMIT 2`, nil)
+		require.Equal(t, []int64{3, 7}, ids)
+	})
+
+	_, _, err = engine.QueryAll("SELECT id FROM table1 ORDER BY amount", nil)
+	require.ErrorIs(t, err, ErrLimitedOrderBy)
+
+	_, _, err = engine.QueryAll("SELECT DISTINCT amount FROM table1 ORDER BY amount LIMIT 10", nil)
+	require.ErrorIs(t, err, ErrLimitedOrderBy)
+
+	_, _, err = engine.QueryAll("SELECT COUNT() FROM table1 ORDER BY amount LIMIT 10", nil)
+	require.ErrorIs(t, err, ErrLimitedOrderBy)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestNullableIndexes(t *testing.T) {
+	catalogStore, err := store.Open("catalog_nullable_index", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_nullable_index")
+
+	dataStore, err := store.Open("sqldata_nullable_index", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_nullable_index")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE users (id INTEGER, email VARCHAR[64], team INTEGER, PRIMARY KEY id);
+		CREATE UNIQUE INDEX ON users(email) NULLS DISTINCT;
+		CREATE INDEX ON users(team, email) NULLS DISTINCT;
+		CREATE TABLE teams (id INTEGER, name VARCHAR[64], PRIMARY KEY id);
+		CREATE UNIQUE INDEX ON teams(name);
+	`, nil, true)
+	require.NoError(t, err)
+
+	queryIDs := func(t *testing.T, q string) []int64 {
+		rows, _, err := engine.QueryAll(q, nil)
+		require.NoError(t, err)
+
+		ids := make([]int64, len(rows))
+		for i, row := range rows {
+			ids[i] = row.Values[EncodeSelector("", "db1", "users", "id")].Value().(int64)
+		}
+
+		return ids
+	}
+
+	t.Run("NULLs should be distinct in a nullable unique index", func(t *testing.T) {
+		_, err = engine.ExecStmt("INSERT INTO users (id, email, team) VALUES (1, NULL, NULL), (2, NULL, 1), (3, 'a@db1', NULL)", nil, true)
+		require.NoError(t, err)
+
+		_, err = engine.ExecStmt("INSERT INTO users (id) VALUES (4)", nil, true)
+		require.NoError(t, err)
+
+		_, err = engine.ExecStmt("INSERT INTO users (id, email) VALUES (5, 'a@db1')", nil, true)
+		require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+
+		require.Equal(t, []int64{1, 2, 3, 4}, queryIDs(t, "SELECT id FROM users"))
+		require.Equal(t, []int64{3}, queryIDs(t, "SELECT id FROM users WHERE email = 'a@db1'"))
+	})
+
+	t.Run("NULLs should be sorted before any other value", func(t *testing.T) {
+		require.Equal(t, []int64{1, 2, 4, 3}, queryIDs(t, "SELECT id FROM users ORDER BY email"))
+		require.Equal(t, []int64{3, 4, 2, 1}, queryIDs(t, "SELECT id FROM users ORDER BY email DESC"))
+		require.Equal(t, []int64{1, 4, 3, 2}, queryIDs(t, "SELECT id FROM users USE INDEX ON (team, email)"))
+		require.Equal(t, []int64{2}, queryIDs(t, "SELECT id FROM users WHERE team = 1"))
+	})
+
+	t.Run("indexed values should be updatable from and to NULL", func(t *testing.T) {
+		_, err = engine.ExecStmt("UPDATE users SET email = 'b@db1' WHERE id = 1", nil, true)
+		require.NoError(t, err)
+
+		_, err = engine.ExecStmt("UPDATE users SET email = NULL WHERE id = 3", nil, true)
+		require.NoError(t, err)
+
+		require.Equal(t, []int64{2, 3, 4, 1}, queryIDs(t, "SELECT id FROM users ORDER BY email"))
+
+		_, err = engine.ExecStmt("UPSERT INTO users (id, email) VALUES (5, 'a@db1')", nil, true)
+		require.NoError(t, err)
+
+		_, err = engine.ExecStmt("DELETE FROM users WHERE email = 'a@db1' OR id = 2", nil, true)
+		require.NoError(t, err)
+
+		require.Equal(t, []int64{3, 4, 1}, queryIDs(t, "SELECT id FROM users ORDER BY email"))
+	})
+
+	t.Run("NULLs should still be rejected by other indexes", func(t *testing.T) {
+		_, err = engine.ExecStmt("INSERT INTO teams (id) VALUES (1)", nil, true)
+		require.ErrorIs(t, err, ErrIndexedColumnCanNotBeNull)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	// nullable indexes are persisted in the catalog
+	engine, err = NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	err = engine.EnsureCatalogReady(nil)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO users (id, email) VALUES (6, NULL)", nil, true)
+	require.NoError(t, err)
+
+	require.Equal(t, []int64{3, 4, 6, 1}, queryIDs(t, "SELECT id FROM users ORDER BY email"))
+
+	table, err := engine.catalog.GetTableByName("db1", "users")
+	require.NoError(t, err)
+
+	for _, index := range table.indexes {
+		require.Equal(t, !index.IsPrimary(), index.IsNullable())
+	}
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestQueryStmtFromCursor(t *testing.T) {
+	catalogStore, err := store.Open("catalog_cursor", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_cursor")
+
+	dataStore, err := store.Open("sqldata_cursor", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_cursor")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table1 (id INTEGER, team INTEGER, PRIMARY KEY id);
+		CREATE INDEX ON table1(team);
+		CREATE TABLE table2 (id INTEGER, PRIMARY KEY id);
+	`, nil, true)
+	require.NoError(t, err)
+
+	for i := 1; i <= 25; i++ {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, team) VALUES (@id, @team)", map[string]interface{}{"id": i, "team": i % 3}, true)
+		require.NoError(t, err)
+	}
+
+	_, err = engine.ExecStmt("INSERT INTO table2 (id) VALUES (1)", nil, true)
+	require.NoError(t, err)
+
+	// readPage returns the ids of the rows of a page together with the cursor to the following one
+	readPage := func(t *testing.T, q string, cursor []byte) ([]int64, []byte) {
+		r, err := engine.QueryStmtFromCursor(q, nil, cursor)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var ids []int64
+
+		for {
+			row, err := r.Read()
+			if err == ErrNoMoreRows {
+				break
+			}
+			require.NoError(t, err)
+
+			ids = append(ids, row.Values[EncodeSelector("", "db1", "table1", "id")].Value().(int64))
+		}
+
+		next, err := r.Cursor()
+		require.NoError(t, err)
+
+		if next == nil {
+			next = cursor
+		}
+
+		return ids, next
+	}
+
+	readAll := func(t *testing.T, q string) []int64 {
+		var ids []int64
+		var cursor []byte
+
+		for {
+			page, next := readPage(t, q, cursor)
+			if len(page) == 0 {
+				return ids
+			}
+
+			require.LessOrEqual(t, len(page), 10)
+			require.NotEqual(t, cursor, next)
+
+			ids = append(ids, page...)
+			cursor = next
+		}
+	}
+
+	t.Run("pages should neither overlap nor leave gaps", func(t *testing.T) {
+		ids := readAll(t, "SELECT id FROM table1 LIMIT 10")
+		require.Len(t, ids, 25)
+
+		for i, id := range ids {
+			require.Equal(t, int64(i+1), id)
+		}
+
+		ids = readAll(t, "SELECT id FROM table1 ORDER BY id DESC LIMIT 10")
+		require.Len(t, ids, 25)
+
+		for i, id := range ids {
+			require.Equal(t, int64(25-i), id)
+		}
+	})
+
+	t.Run("pages should be stable over non-unique indexes", func(t *testing.T) {
+		ids := readAll(t, "SELECT id, team FROM table1 WHERE team > 0 ORDER BY team LIMIT 10")
+		require.Equal(t, []int64{1, 4, 7, 10, 13, 16, 19, 22, 25, 2, 5, 8, 11, 14, 17, 20, 23}, ids)
+	})
+
+	t.Run("rows written between pages should only be read after the cursor", func(t *testing.T) {
+		page, cursor := readPage(t, "SELECT id FROM table1 LIMIT 10", nil)
+		require.Equal(t, []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, page)
+
+		_, err = engine.ExecStmt("DELETE FROM table1 WHERE id = 11 OR id = 3", nil, true)
+		require.NoError(t, err)
+
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, team) VALUES (0, 0), (26, 2)", nil, true)
+		require.NoError(t, err)
+
+		page, cursor = readPage(t, "SELECT id FROM table1 LIMIT 10", cursor)
+		require.Equal(t, []int64{12, 13, 14, 15, 16, 17, 18, 19, 20, 21}, page)
+
+		page, _ = readPage(t, "SELECT id FROM table1 LIMIT 10", cursor)
+		require.Equal(t, []int64{22, 23, 24, 25, 26}, page)
+	})
+
+	t.Run("cursors should not be supported when rows are not returned as scanned", func(t *testing.T) {
+		_, cursor := readPage(t, "SELECT id FROM table1 LIMIT 1", nil)
+
+		for _, q := range []string{
+			"SELECT COUNT() FROM table1",
+			"SELECT DISTINCT team FROM table1",
+			"SELECT table1.id FROM table1 INNER JOIN table2 ON table1.id = table2.id",
+			"SELECT id FROM (SELECT id FROM table1)",
+		} {
+			r, err := engine.QueryStmt(q, nil, true)
+			require.NoError(t, err)
+
+			_, err = r.Read()
+			require.NoError(t, err)
+
+			_, err = r.Cursor()
+			require.ErrorIs(t, err, ErrNoSupported, q)
+
+			err = r.Close()
+			require.NoError(t, err)
+
+			_, err = engine.QueryStmtFromCursor(q, nil, cursor)
+			require.ErrorIs(t, err, ErrNoSupported, q)
+		}
+	})
+
+	t.Run("cursors should only resume scans of the same index", func(t *testing.T) {
+		_, cursor := readPage(t, "SELECT id FROM table1 LIMIT 1", nil)
+
+		_, err := engine.QueryStmtFromCursor("SELECT id FROM table2", nil, cursor)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = engine.QueryStmtFromCursor("SELECT id FROM table1 ORDER BY team", nil, cursor)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = engine.QueryStmtFromCursor("UPSERT INTO table2 (id) VALUES (2)", nil, cursor)
+		require.ErrorIs(t, err, ErrExpectingDQLStmt)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestWhereCurrentOf(t *testing.T) {
+	catalogStore, err := store.Open("catalog_currentof", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_currentof")
+
+	dataStore, err := store.Open("sqldata_currentof", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_currentof")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table1 (id INTEGER, title VARCHAR[50], team INTEGER, active BOOLEAN, PRIMARY KEY id);
+		CREATE UNIQUE INDEX ON table1(title);
+		CREATE INDEX ON table1(team);
+		CREATE TABLE table2 (id INTEGER, PRIMARY KEY id);
+	`, nil, true)
+	require.NoError(t, err)
+
+	rowCount := 10
+
+	for i := 1; i <= rowCount; i++ {
+		_, err = engine.ExecStmt(
+			"INSERT INTO table1 (id, title, team, active) VALUES (@id, @title, @team, true)",
+			map[string]interface{}{"id": i, "title": fmt.Sprintf("title%d", i), "team": i % 2},
+			true,
+		)
+		require.NoError(t, err)
+	}
+
+	params, err := engine.InferParameters("DELETE FROM table1 WHERE CURRENT OF @cursor")
+	require.NoError(t, err)
+	require.Equal(t, map[string]SQLValueType{"cursor": BLOBType}, params)
+
+	params, err = engine.InferParameters("UPDATE table1 SET active = @active WHERE CURRENT OF @cursor")
+	require.NoError(t, err)
+	require.Equal(t, map[string]SQLValueType{"cursor": BLOBType, "active": BooleanType}, params)
+
+	// readNext reads the row following the cursor, or the first one when no cursor is given
+	readNext := func(t *testing.T, q string, cursor []byte) (int64, []byte) {
+		r, err := engine.QueryStmtFromCursor(q, nil, cursor)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read()
+		if err == ErrNoMoreRows {
+			return 0, nil
+		}
+		require.NoError(t, err)
+
+		cursor, err = r.Cursor()
+		require.NoError(t, err)
+
+		return row.Values[EncodeSelector("", "db1", "table1", "id")].Value().(int64), cursor
+	}
+
+	ids := func(t *testing.T, q string) []int64 {
+		rows, cols, err := engine.QueryAll(q, nil)
+		require.NoError(t, err)
+
+		ids := make([]int64, len(rows))
+
+		for i, row := range rows {
+			ids[i] = row.Values[cols[0].Selector()].Value().(int64)
+		}
+
+		return ids
+	}
+
+	t.Run("the row the cursor is positioned at should be updated", func(t *testing.T) {
+		var cursor []byte
+
+		for {
+			var id int64
+
+			id, cursor = readNext(t, "SELECT id FROM table1 WHERE team = 1 ORDER BY team LIMIT 1", cursor)
+			if cursor == nil {
+				break
+			}
+
+			summary, err := engine.ExecStmt("UPDATE table1 SET active = false WHERE CURRENT OF @cursor", map[string]interface{}{"cursor": cursor}, true)
+			require.NoError(t, err)
+			require.Equal(t, 1, summary.UpdatedRows)
+
+			require.Equal(t, []int64{id}, ids(t, fmt.Sprintf("SELECT id FROM table1 WHERE id = %d AND NOT active", id)))
+		}
+
+		require.Equal(t, []int64{1, 3, 5, 7, 9}, ids(t, "SELECT id FROM table1 WHERE NOT active"))
+	})
+
+	t.Run("the row the cursor is positioned at should be deleted", func(t *testing.T) {
+		for _, q := range []string{
+			"SELECT id FROM table1 WHERE id > 6 LIMIT 1",
+			"SELECT id FROM table1 WHERE title = 'title2' ORDER BY title LIMIT 1",
+			"SELECT id FROM table1 WHERE team = 0 ORDER BY team LIMIT 1",
+		} {
+			_, cursor := readNext(t, q, nil)
+			require.NotNil(t, cursor)
+
+			summary, err := engine.ExecStmt("DELETE FROM table1 WHERE CURRENT OF @cursor", map[string]interface{}{"cursor": cursor}, true)
+			require.NoError(t, err)
+			require.Equal(t, 1, summary.UpdatedRows)
+		}
+
+		require.Equal(t, []int64{1, 3, 5, 6, 8, 9, 10}, ids(t, "SELECT id FROM table1"))
+	})
+
+	t.Run("deleting a row the cursor was positioned at should leave it deleted", func(t *testing.T) {
+		_, cursor := readNext(t, "SELECT id FROM table1 WHERE title = 'title3' ORDER BY title LIMIT 1", nil)
+
+		summary, err := engine.ExecStmt("DELETE FROM table1 WHERE CURRENT OF @cursor", map[string]interface{}{"cursor": cursor}, true)
+		require.NoError(t, err)
+		require.Equal(t, 1, summary.UpdatedRows)
+
+		summary, err = engine.ExecStmt("DELETE FROM table1 WHERE CURRENT OF @cursor", map[string]interface{}{"cursor": cursor}, true)
+		require.NoError(t, err)
+		require.Zero(t, summary.UpdatedRows)
+
+		require.Equal(t, []int64{1, 5, 6, 8, 9, 10}, ids(t, "SELECT id FROM table1"))
+	})
+
+	t.Run("cursors should only be used over the table they were read from", func(t *testing.T) {
+		_, cursor := readNext(t, "SELECT id FROM table1 LIMIT 1", nil)
+
+		_, err := engine.ExecStmt("DELETE FROM table2 WHERE CURRENT OF @cursor", map[string]interface{}{"cursor": cursor}, true)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = engine.ExecStmt("DELETE FROM table1 WHERE CURRENT OF @cursor", map[string]interface{}{"cursor": 1}, true)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestQueryWithRowFiltering(t *testing.T) {
+	catalogStore, err := store.Open("catalog_where", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_where")
+
+	dataStore, err := store.Open("sqldata_where", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_where")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, active BOOLEAN, payload BLOB, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	rowCount := 10
+
+	for i := 0; i < rowCount; i++ {
+		encPayload := hex.EncodeToString([]byte(fmt.Sprintf("blob%d", i)))
+		_, err = engine.ExecStmt(fmt.Sprintf(`
+			UPSERT INTO table1 (id, title, active, payload) VALUES (%d, 'title%d', %v, x'%s')
+		`, i, i, i%2 == 0, encPayload), nil, true)
+		require.NoError(t, err)
+	}
+
+	r, err := engine.QueryStmt("SELECT id, title, active FROM table1 WHERE false", nil, true)
+	require.NoError(t, err)
+
+	_, err = r.Read()
+	require.Equal(t, ErrNoMoreRows, err)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT id, title, active FROM table1 WHERE false OR true", nil, true)
+	require.NoError(t, err)
+
+	for i := 0; i < rowCount; i++ {
+		_, err := r.Read()
+		require.NoError(t, err)
+	}
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT id, title, active FROM table1 WHERE 1 < 2", nil, true)
+	require.NoError(t, err)
+
+	for i := 0; i < rowCount; i++ {
+		_, err := r.Read()
+		require.NoError(t, err)
+	}
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT id, title, active FROM table1 WHERE 1 >= 2", nil, true)
+	require.NoError(t, err)
+
+	_, err = r.Read()
+	require.Equal(t, ErrNoMoreRows, err)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT id, title, active FROM table1 WHERE 1 = true", nil, true)
+	require.NoError(t, err)
+
+	_, err = r.Read()
+	require.Equal(t, ErrNotComparableValues, err)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT id, title, active FROM table1 WHERE NOT table1.active", nil, true)
+	require.NoError(t, err)
+
+	for i := 0; i < rowCount/2; i++ {
+		_, err := r.Read()
+		require.NoError(t, err)
+	}
+
+	_, err = r.Read()
+	require.Equal(t, ErrNoMoreRows, err)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT id, title, active FROM table1 WHERE table1.id > 4", nil, true)
+	require.NoError(t, err)
+
+	for i := 0; i < rowCount/2; i++ {
+		_, err := r.Read()
+		require.NoError(t, err)
+	}
+
+	_, err = r.Read()
+	require.Equal(t, ErrNoMoreRows, err)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(fmt.Sprintf("UPSERT INTO table1 (id, title) VALUES (%d, 'title%d')", rowCount, rowCount), nil, true)
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT id, title FROM table1 WHERE active = null AND payload = null", nil, true)
+	require.NoError(t, err)
+
+	_, err = r.Read()
+	require.NoError(t, err)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT id, title FROM table1 WHERE active = null AND payload = null AND active = payload", nil, true)
+	require.NoError(t, err)
+
+	_, err = r.Read()
+	require.Equal(t, ErrNotComparableValues, err)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestQueryWithStringFunctions(t *testing.T) {
+	catalogStore, err := store.Open("catalog_string_fns", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_string_fns")
+
+	dataStore, err := store.Open("sqldata_string_fns", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_string_fns")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, active BOOLEAN, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	titles := []string{"  title 1  ", "\ttitle 2\n", "title 3", ""}
+
+	for i, title := range titles {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active) VALUES (@id, @title, true)",
+			map[string]interface{}{"id": i, "title": title}, true)
+		require.NoError(t, err)
+	}
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, active) VALUES (10, false)", nil, true)
+	require.NoError(t, err)
+
+	rows, _, err := engine.QueryAll(`
+		SELECT TRIM(title) AS t, LTRIM(title) AS l, RTRIM(title) AS r, REPLACE(title, 'title', @newval) AS rep
+		FROM table1`, map[string]interface{}{"newval": "row"})
+	require.NoError(t, err)
+	require.Len(t, rows, len(titles)+1)
+
+	expected := [][]string{
+		{"title 1", "title 1  ", "  title 1", "  row 1  "},
+		{"title 2", "title 2\n", "\ttitle 2", "\trow 2\n"},
+		{"title 3", "title 3", "title 3", "row 3"},
+		{"", "", "", ""},
+	}
+
+	for i, row := range rows[:len(titles)] {
+		for j, col := range []string{"t", "l", "r", "rep"} {
+			require.Equal(t, expected[i][j], row.Values[EncodeSelector("", "db1", "table1", col)].Value())
+		}
+	}
+
+	// null arguments make the result null
+	for _, col := range []string{"t", "l", "r", "rep"} {
+		require.Nil(t, rows[len(titles)].Values[EncodeSelector("", "db1", "table1", col)].Value())
+	}
+
+	rows, _, err = engine.QueryAll("SELECT id FROM table1 WHERE TRIM(title) = 'title 2'", nil)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, int64(1), rows[0].Values[EncodeSelector("", "db1", "table1", "id")].Value())
+
+	rows, _, err = engine.QueryAll("SELECT id FROM table1 WHERE REPLACE(TRIM(title), ' ', '') = 'title3'", nil)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, int64(2), rows[0].Values[EncodeSelector("", "db1", "table1", "id")].Value())
+
+	_, err = engine.ExecStmt("UPDATE table1 SET title = TRIM(title) WHERE id = 0", nil, true)
+	require.NoError(t, err)
+
+	rows, _, err = engine.QueryAll("SELECT title FROM table1 WHERE id = 0", nil)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, "title 1", rows[0].Values[EncodeSelector("", "db1", "table1", "title")].Value())
+
+	_, _, err = engine.QueryAll("SELECT TRIM(id) FROM table1", nil)
+	require.ErrorIs(t, err, ErrInvalidTypes)
+
+	_, _, err = engine.QueryAll("SELECT REPLACE(title, 'a') FROM table1", nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = engine.InferParameters("SELECT TRIM(@title) FROM table1")
+	require.NoError(t, err)
+
+	params, err := engine.InferParameters("SELECT id FROM table1 WHERE TRIM(@title) = title")
+	require.NoError(t, err)
+	require.Equal(t, map[string]SQLValueType{"title": VarcharType}, params)
+
+	_, err = engine.InferParameters("SELECT id FROM table1 WHERE LTRIM(id) = title")
+	require.ErrorIs(t, err, ErrInvalidTypes)
+}
+
+func TestQueryWithConstantCondition(t *testing.T) {
+	catalogStore, err := store.Open("catalog_constant_where", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_constant_where")
+
+	dataStore, err := store.Open("sqldata_constant_where", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_constant_where")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER, amount INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	rowCount := 10
+
+	for i := 0; i < rowCount; i++ {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (@id, @title)",
+			map[string]interface{}{"id": i, "title": fmt.Sprintf("title%d", i)}, true)
+		require.NoError(t, err)
+
+		_, err = engine.ExecStmt("INSERT INTO table2 (id, amount) VALUES (@id, @amount)",
+			map[string]interface{}{"id": i, "amount": i * 10}, true)
+		require.NoError(t, err)
+	}
+
+	// readers returns the chain of readers starting from the outermost one
+	readers := func(r RowReader) []RowReader {
+		chain := []RowReader{r}
+
+		for {
+			switch cr := r.(type) {
+			case *projectedRowReader:
+				r = cr.rowReader
+			case *conditionalRowReader:
+				r = cr.rowReader
+			case *jointRowReader:
+				r = cr.rowReader
+			case *groupedRowReader:
+				r = cr.rowReader
+			default:
+				return chain
+			}
+
+			chain = append(chain, r)
+		}
+	}
+
+	testCases := []struct {
+		query     string
+		params    map[string]interface{}
+		rowCount  int
+		filtered  bool
+		scanFree  bool
+		aggregate bool
+	}{
+		{query: "SELECT id, title FROM table1 WHERE false", rowCount: 0, scanFree: true},
+		{query: "SELECT id, title FROM table1 WHERE 1 > 2 OR NOT true", rowCount: 0, scanFree: true},
+		{query: "SELECT id, title FROM table1 WHERE true", rowCount: rowCount},
+		{query: "SELECT id, title FROM table1 WHERE 'a' = 'a' AND 2 >= 1", rowCount: rowCount},
+		{query: "SELECT id, title FROM table1 WHERE id >= 5 AND false", rowCount: 0, filtered: true},
+		{query: "SELECT id, title FROM table1 WHERE @cond", params: map[string]interface{}{"cond": false}, rowCount: 0, filtered: true},
+		{query: "SELECT COUNT() AS c FROM table1 WHERE false", rowCount: 1, scanFree: true, aggregate: true},
+		{
+			query:    "SELECT table1.id, table2.amount FROM table1 INNER JOIN table2 ON table1.id = table2.id WHERE false",
+			rowCount: 0,
+			scanFree: true,
+		},
+		{
+			query:    "SELECT table1.id, table2.amount FROM table1 INNER JOIN table2 ON table1.id = table2.id WHERE true",
+			rowCount: rowCount,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			r, err := engine.QueryStmt(tc.query, tc.params, true)
+			require.NoError(t, err)
+
+			chain := readers(r)
+
+			raw, isRaw := chain[len(chain)-1].(*rawRowReader)
+			require.True(t, isRaw)
+			require.Equal(t, tc.scanFree, raw.reader == nil)
+
+			filtered := false
+			for _, cr := range chain {
+				_, isCond := cr.(*conditionalRowReader)
+				filtered = filtered || isCond
+			}
+			require.Equal(t, tc.filtered, filtered)
+
+			cols, err := r.Columns()
+			require.NoError(t, err)
+			require.NotEmpty(t, cols)
+
+			n := 0
+			for {
+				row, err := r.Read()
+				if err == ErrNoMoreRows {
+					break
+				}
+				require.NoError(t, err)
+
+				if tc.aggregate {
+					require.Equal(t, int64(0), row.Values[EncodeSelector("", "db1", "table1", "c")].Value())
+				}
+
+				n++
+			}
+			require.Equal(t, tc.rowCount, n)
+
+			err = r.Close()
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestQueryWithInClause(t *testing.T) {
+	catalogStore, err := store.Open("catalog_where_in", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_where_in")
+
+	dataStore, err := store.Open("sqldata_where_in", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_where_in")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[50], active BOOLEAN, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(title)", nil, true)
+	require.NoError(t, err)
+
+	rowCount := 10
+
+	for i := 0; i < rowCount; i++ {
+		_, err = engine.ExecStmt(fmt.Sprintf(`
+			INSERT INTO table1 (id, title, active) VALUES (%d, 'title%d', %v)
+		`, i, i, i%2 == 0), nil, true)
+		require.NoError(t, err)
+	}
+
+	inListExp := &InListExp{}
+	require.False(t, inListExp.isConstant())
+
+	t.Run("infer parameters without parameters should return an empty list", func(t *testing.T) {
+		params, err := engine.InferParameters("SELECT id, title, active FROM table1 WHERE title IN ('title0', 'title1')")
+		require.NoError(t, err)
+		require.Empty(t, params)
+	})
+
+	t.Run("infer inference with wrong types should return an error", func(t *testing.T) {
+		_, err := engine.InferParameters("SELECT id, title, active FROM table1 WHERE 100 + title IN ('title0', 'title1')")
+		require.ErrorIs(t, err, ErrInvalidTypes)
+	})
+
+	t.Run("infer inference with valid types should succeed", func(t *testing.T) {
+		params, err := engine.InferParameters("SELECT id, title, active FROM table1 WHERE active AND title IN ('title0', 'title1')")
+		require.NoError(t, err)
+		require.Empty(t, params)
+	})
+
+	t.Run("infer parameters should return matching type", func(t *testing.T) {
+		params, err := engine.InferParameters("SELECT id, title, active FROM table1 WHERE title IN (@param0, @param1)")
+		require.NoError(t, err)
+		require.Len(t, params, 2)
+		require.Equal(t, VarcharType, params["param0"])
+		require.Equal(t, VarcharType, params["param1"])
+	})
+
+	t.Run("infer parameters with type conflicts should return an error", func(t *testing.T) {
+		_, err := engine.InferParameters("SELECT id, title, active FROM table1 WHERE active = @param1 and title IN (@param0, @param1)")
+		require.ErrorIs(t, err, ErrInferredMultipleTypes)
+	})
+
+	t.Run("infer parameters with unexistent column should return an error", func(t *testing.T) {
+		_, err := engine.InferParameters("SELECT id, title, active FROM table1 WHERE invalidColumn IN ('title1', 'title2')")
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
+	})
+
+	t.Run("in clause with invalid column should return an error", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT id, title, active FROM table1 WHERE invalidColumn IN (1, 2)", nil, true)
+		require.NoError(t, err)
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("in clause with invalid type should return an error", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT id, title, active FROM table1 WHERE title IN (1, 2)", nil, true)
+		require.NoError(t, err)
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNotComparableValues)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("in clause should succeed reading two rows", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT id, title, active FROM table1 WHERE title IN ('title0', 'title1')", nil, true)
+		require.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.NotNil(t, row)
+			require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+		}
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("in clause with invalid values should return an error", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT id, title, active FROM table1 WHERE title IN ('title0', true + 'title1')", nil, true)
+		require.NoError(t, err)
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrInvalidValue)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("in clause should succeed reading rows NOT included in 'IN' clause", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT id, title, active FROM table1 WHERE title NOT IN ('title1', 'title0')", nil, true)
+		require.NoError(t, err)
+
+		for i := 2; i < rowCount; i++ {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.NotNil(t, row)
+			require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+		}
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("not in clause with a NULL value should not match any row", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT id FROM table1 WHERE title NOT IN ('title1', NULL)", nil, true)
+		require.NoError(t, err)
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+
+		r, err = engine.QueryStmt("SELECT id FROM table1 WHERE NOT (title NOT IN ('title1', NULL)) OR active = false", nil, true)
+		require.NoError(t, err)
+
+		for i := 1; i < rowCount; i += 2 {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Equal(t, int64(i), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("in clause with a NULL value should match the listed values", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT id FROM table1 WHERE title IN ('title1', NULL) AND active = false", nil, true)
+		require.NoError(t, err)
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(1), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("in clause should succeed reading using 'IN' clause in join condition", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT * FROM table1 as t1 INNER JOIN table1 as t2 ON t1.title IN (t2.title) ORDER BY title", nil, true)
+		require.NoError(t, err)
+
+		for i := 0; i < rowCount; i++ {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.NotNil(t, row)
+			require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "t1", "title")].Value())
+		}
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestQueryWithInClauseRange(t *testing.T) {
+	catalogStore, err := store.Open("catalog_in_range", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_in_range")
+
+	dataStore, err := store.Open("sqldata_in_range", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_in_range")
+
+	// scans not narrowed by the IN clause would exceed the limit
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix).WithMaxScanRows(50))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, code INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(code)", nil, true)
+	require.NoError(t, err)
+
+	rowCount := 1000
+
+	for i := 0; i < rowCount; i += 100 {
+		var rows []string
+
+		for j := i; j < i+100; j++ {
+			rows = append(rows, fmt.Sprintf("(%d, %d)", j, j%200))
+		}
+
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, code) VALUES "+strings.Join(rows, ", "), nil, true)
+		require.NoError(t, err)
+	}
+
+	table, err := engine.GetTableByName("db1", "table1")
+	require.NoError(t, err)
+
+	codeCol, err := table.GetColumnByName("code")
+	require.NoError(t, err)
+
+	query := func(query string, params map[string]interface{}) (ids []int64, ranges map[uint32]*typedValueRange) {
+		r, err := engine.QueryStmt(query, params, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		rows, _, err := engine.readAll(r)
+		require.NoError(t, err)
+
+		return rowIDs(rows), r.ScanSpecs().rangesByColID
+	}
+
+	t.Run("in clause over an indexed column should be resolved with an index range", func(t *testing.T) {
+		ids, ranges := query("SELECT id FROM table1 WHERE code IN (12, 7, 10, NULL)", nil)
+		require.ElementsMatch(t, []int64{7, 10, 12, 207, 210, 212, 407, 410, 412, 607, 610, 612, 807, 810, 812}, ids)
+
+		require.Contains(t, ranges, codeCol.id)
+		require.Equal(t, &typedValueSemiRange{val: &Number{val: 7}, inclusive: true}, ranges[codeCol.id].lRange)
+		require.Equal(t, &typedValueSemiRange{val: &Number{val: 12}, inclusive: true}, ranges[codeCol.id].hRange)
+	})
+
+	t.Run("in clause with parameters should be resolved with an index range", func(t *testing.T) {
+		ids, _ := query("SELECT id FROM table1 WHERE code IN (@a, @b)", map[string]interface{}{"a": 150, "b": 149})
+		require.ElementsMatch(t, []int64{149, 150, 349, 350, 549, 550, 749, 750, 949, 950}, ids)
+
+		r, err := engine.QueryStmt("SELECT id FROM table1 USE INDEX ON code WHERE code IN (@a, @b)", nil, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		require.NotContains(t, r.ScanSpecs().rangesByColID, codeCol.id)
+
+		err = r.SetParameters(map[string]interface{}{"a": 3, "b": 1})
+		require.NoError(t, err)
+
+		rows, _, err := engine.readAll(r)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []int64{1, 3, 201, 203, 401, 403, 601, 603, 801, 803}, rowIDs(rows))
+	})
+
+	t.Run("in clause over the primary key should be resolved with an index range", func(t *testing.T) {
+		ids, _ := query("SELECT id FROM table1 WHERE id IN (990, 970, 999)", nil)
+		require.Equal(t, []int64{970, 990, 999}, ids)
+	})
+
+	t.Run("not in clause should not narrow the scan", func(t *testing.T) {
+		_, _, err := engine.QueryAll("SELECT id FROM table1 WHERE code NOT IN (1, 2)", nil)
+		require.ErrorIs(t, err, ErrScanLimitExceeded)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestCompositeIndexRange(t *testing.T) {
+	catalogStore, err := store.Open("catalog_composite_range", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_composite_range")
+
+	dataStore, err := store.Open("sqldata_composite_range", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_composite_range")
+
+	// scans not narrowed by both columns of the index would exceed the limit
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix).WithMaxScanRows(50))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, a INTEGER, b INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(a, b)", nil, true)
+	require.NoError(t, err)
+
+	for i := 0; i < 1000; i += 100 {
+		var rows []string
+
+		for j := i; j < i+100; j++ {
+			rows = append(rows, fmt.Sprintf("(%d, %d, %d)", j, j%10, j/10))
+		}
+
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, a, b) VALUES "+strings.Join(rows, ", "), nil, true)
+		require.NoError(t, err)
+	}
+
+	table, err := engine.GetTableByName("db1", "table1")
+	require.NoError(t, err)
+
+	aCol, err := table.GetColumnByName("a")
+	require.NoError(t, err)
+
+	bCol, err := table.GetColumnByName("b")
+	require.NoError(t, err)
+
+	index := table.indexes[indexKeyFrom([]*Column{aCol, bCol})]
+	require.NotNil(t, index)
+
+	encKey := func(col *Column, val int64) []byte {
+		encVal, err := index.encodeKeyValue(col, &Number{val: val})
+		require.NoError(t, err)
+		return encVal
+	}
+
+	prefix := engine.mapKey(index.prefix(), EncodeID(table.db.id), EncodeID(table.id), EncodeID(index.id))
+
+	t.Run("equality on the first column and range on the second one should narrow the scan", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT id FROM table1 WHERE a = 1 AND b > 95", nil, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		scanSpecs := r.ScanSpecs()
+		require.Equal(t, index, scanSpecs.index)
+		require.Len(t, scanSpecs.rangesByColID, 2)
+
+		aRange := scanSpecs.rangesByColID[aCol.id]
+		require.True(t, aRange.unitary())
+		require.Equal(t, int64(1), aRange.lRange.val.Value())
+
+		bRange := scanSpecs.rangesByColID[bCol.id]
+		require.Equal(t, &typedValueSemiRange{val: &Number{val: 95}, inclusive: false}, bRange.lRange)
+		require.Nil(t, bRange.hRange)
+
+		spec, err := keyReaderSpecFrom(engine, table, scanSpecs)
+		require.NoError(t, err)
+
+		seekKey := append(append(append([]byte{}, prefix...), encKey(aCol, 1)...), encKey(bCol, 95)...)
+		require.Equal(t, seekKey, spec.SeekKey)
+
+		endKey := append(append(append([]byte{}, prefix...), encKey(aCol, 1)...), index.maxKeyValOf(bCol)...)
+		endKey = append(endKey, table.primaryIndex.maxKeyValOf(table.primaryIndex.cols[0])...)
+		require.Equal(t, endKey, spec.EndKey)
+
+		rows, _, err := engine.readAll(r)
+		require.NoError(t, err)
+		require.Equal(t, []int64{961, 971, 981, 991}, rowIDs(rows))
+	})
+
+	t.Run("bounded range on the second column should narrow the scan in both directions", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT id FROM table1 WHERE a = @a AND b >= @lower AND b < @upper ORDER BY b DESC", map[string]interface{}{"a": 2, "lower": 10, "upper": 14}, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		scanSpecs := r.ScanSpecs()
+		require.Equal(t, index, scanSpecs.index)
+		require.True(t, scanSpecs.descOrder)
+
+		spec, err := keyReaderSpecFrom(engine, table, scanSpecs)
+		require.NoError(t, err)
+
+		seekKey := append(append(append([]byte{}, prefix...), encKey(aCol, 2)...), encKey(bCol, 14)...)
+		seekKey = append(seekKey, table.primaryIndex.maxKeyValOf(table.primaryIndex.cols[0])...)
+		require.Equal(t, seekKey, spec.SeekKey)
+
+		endKey := append(append(append([]byte{}, prefix...), encKey(aCol, 2)...), encKey(bCol, 10)...)
+		require.Equal(t, endKey, spec.EndKey)
+
+		rows, _, err := engine.readAll(r)
+		require.NoError(t, err)
+		require.Equal(t, []int64{132, 122, 112, 102}, rowIDs(rows))
+	})
+
+	t.Run("range on the second column alone can not narrow the scan", func(t *testing.T) {
+		_, _, err := engine.QueryAll("SELECT id FROM table1 WHERE b > 95", nil)
+		require.ErrorIs(t, err, ErrScanLimitExceeded)
+	})
+}
+
+func TestIndexTiesOrderedByPK(t *testing.T) {
+	catalogStore, err := store.Open("catalog_index_ties", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_index_ties")
+
+	dataStore, err := store.Open("sqldata_index_ties", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_index_ties")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, category VARCHAR[10], PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(category)", nil, true)
+	require.NoError(t, err)
+
+	// rows sharing the indexed value are written in no particular order of their primary keys
+	for _, id := range []int64{7, -3, 12, 1, 9, -8, 4, 0, 15, 2} {
+		category := "a"
+		if id%2 == 0 {
+			category = "b"
+		}
+
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, category) VALUES (@id, @category)", map[string]interface{}{"id": id, "category": category}, true)
+		require.NoError(t, err)
+	}
+
+	// the primary key is the last part of the keys of non-unique indexes, thus rows with the same value are sorted by it
+	expected := []int64{-3, 1, 7, 9, 15, -8, 0, 2, 4, 12}
+
+	t.Run("ties are sorted by primary key", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			require.Equal(t, expected, queryIDs(t, engine, "SELECT id FROM table1 ORDER BY category", nil))
+		}
+
+		require.Equal(t, expected[5:], queryIDs(t, engine, "SELECT id FROM table1 WHERE category = 'b' ORDER BY category", nil))
+		require.Equal(t, expected[:5], queryIDs(t, engine, "SELECT id FROM table1 USE INDEX ON (category) WHERE category < 'b'", nil))
+	})
+
+	t.Run("descending scans sort ties by descending primary key", func(t *testing.T) {
+		reversed := make([]int64, len(expected))
+		for i, id := range expected {
+			reversed[len(expected)-1-i] = id
+		}
+
+		require.Equal(t, reversed, queryIDs(t, engine, "SELECT id FROM table1 ORDER BY category DESC", nil))
+	})
+
+	t.Run("pages do not overlap", func(t *testing.T) {
+		var ids []int64
+
+		for offset := 0; offset < len(expected); offset += 3 {
+			ids = append(ids, queryIDs(t, engine, "SELECT id FROM table1 ORDER BY category LIMIT 3 OFFSET @skip", map[string]interface{}{"skip": offset})...)
+		}
+
+		require.Equal(t, expected, ids)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestQueryWithBlobParams(t *testing.T) {
+	catalogStore, err := store.Open("catalog_blob_params", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_blob_params")
+
+	dataStore, err := store.Open("sqldata_blob_params", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_blob_params")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
 
-		err = r.Close()
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, payload BLOB[4], PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(payload)", nil, true)
+	require.NoError(t, err)
+
+	rowCount := 10
+
+	for i := 0; i < rowCount; i++ {
+		params := map[string]interface{}{"id": i, "payload": []byte{0xCA, 0xFE, byte(i)}}
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, payload) VALUES (@id, @payload)", params, true)
 		require.NoError(t, err)
-	})
+	}
 
-	t.Run("should fail using index on `ts` when ordering by `title`", func(t *testing.T) {
-		_, err := engine.QueryStmt("SELECT * FROM table1 USE INDEX ON (ts) ORDER BY title", nil, true)
-		require.ErrorIs(t, err, ErrNoAvailableIndex)
+	params := map[string]interface{}{
+		"p": []byte{0xCA, 0xFE, 7},
+		"a": []byte{0xCA, 0xFE, 2},
+		"b": []byte{0xCA, 0xFE, 5},
+		"c": []byte{0xCA, 0xFE, 0xFF},
+	}
+
+	testCases := []struct {
+		where    string
+		expected []int64
+	}{
+		{where: "payload >= @p", expected: []int64{7, 8, 9}},
+		{where: "payload = @a", expected: []int64{2}},
+		{where: "payload > @a AND payload <= @b", expected: []int64{3, 4, 5}},
+		{where: "payload IN (@a, @b, @c)", expected: []int64{2, 5}},
+		{where: "payload NOT IN (@a, @b, @c) AND payload < @p", expected: []int64{0, 1, 3, 4, 6}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.where, func(t *testing.T) {
+			require.Equal(t, tc.expected, queryIDs(t, engine, "SELECT id FROM table1 WHERE "+tc.where, params))
+			require.Equal(t, tc.expected, queryIDs(t, engine, "SELECT id FROM table1 USE INDEX ON payload WHERE "+tc.where, params))
+
+			inferredParams, err := engine.InferParameters("SELECT id FROM table1 USE INDEX ON payload WHERE " + tc.where)
+			require.NoError(t, err)
+
+			for _, paramType := range inferredParams {
+				require.Equal(t, BLOBType, paramType)
+			}
+		})
+	}
+
+	t.Run("invalid blob params", func(t *testing.T) {
+		_, err = engine.QueryStmt("SELECT id FROM table1 USE INDEX ON payload WHERE payload = @p", map[string]interface{}{"p": "cafe"}, true)
+		require.ErrorIs(t, err, ErrInvalidValue)
+
+		_, err = engine.QueryStmt("SELECT id FROM table1 USE INDEX ON payload WHERE payload = @p", map[string]interface{}{"p": []byte{1, 2, 3, 4, 5}}, true)
+		require.ErrorIs(t, err, ErrMaxLengthExceeded)
 	})
 
-	t.Run("should use index on `title` with max value in desc order", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT * FROM table1 USE INDEX ON (title) WHERE title < 'title10' ORDER BY title DESC", nil, true)
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestQueryWithMaxScanRows(t *testing.T) {
+	catalogStore, err := store.Open("catalog_max_scan", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_max_scan")
+
+	dataStore, err := store.Open("sqldata_max_scan", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_max_scan")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix).WithMaxScanRows(10))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, active BOOLEAN, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, active) VALUES (@id, @active)", map[string]interface{}{"id": i, "active": i == 9}, true)
 		require.NoError(t, err)
+	}
 
-		orderBy := r.OrderBy()
-		require.NotNil(t, orderBy)
-		require.Len(t, orderBy, 1)
-		require.Equal(t, "title", orderBy[0].Column)
+	// all the rows can be scanned
+	require.Equal(t, []int64{9}, queryIDs(t, engine, "SELECT id FROM table1 WHERE active", nil))
 
-		scanSpecs := r.ScanSpecs()
-		require.NotNil(t, scanSpecs)
-		require.NotNil(t, scanSpecs.index)
-		require.False(t, scanSpecs.index.IsPrimary())
-		require.True(t, scanSpecs.index.IsUnique())
-		require.Len(t, scanSpecs.index.cols, 1)
-		require.Len(t, scanSpecs.rangesByColID, 1)
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, active) VALUES (10, true)", nil, true)
+	require.NoError(t, err)
 
-		titleRange := scanSpecs.rangesByColID[3]
-		require.Nil(t, titleRange.lRange)
-		require.NotNil(t, titleRange.hRange)
-		require.False(t, titleRange.hRange.inclusive)
-		require.Equal(t, "title10", titleRange.hRange.val.Value())
+	// the filter matches few rows but all of them have to be scanned
+	_, _, err = engine.QueryAll("SELECT id FROM table1 WHERE active", nil)
+	require.ErrorIs(t, err, ErrScanLimitExceeded)
 
-		require.True(t, scanSpecs.descOrder)
+	// the limit is checked on scanned rows, not on rows in the result
+	_, _, err = engine.QueryAll("SELECT COUNT() AS c FROM table1", nil)
+	require.ErrorIs(t, err, ErrScanLimitExceeded)
 
-		err = r.Close()
+	// narrower scans are not affected
+	require.Equal(t, []int64{9, 10}, queryIDs(t, engine, "SELECT id FROM table1 WHERE id >= 5 AND active", nil))
+	require.Equal(t, []int64{0, 1}, queryIDs(t, engine, "SELECT id FROM table1 LIMIT 2", nil))
+
+	_, err = engine.ExecStmt("UPDATE table1 SET active = false WHERE active", nil, true)
+	require.ErrorIs(t, err, ErrScanLimitExceeded)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestQueryWithLikePrefix(t *testing.T) {
+	catalogStore, err := store.Open("catalog_like_prefix", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_like_prefix")
+
+	dataStore, err := store.Open("sqldata_like_prefix", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_like_prefix")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[20], PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(title)", nil, true)
+	require.NoError(t, err)
+
+	for i := 0; i < 30; i++ {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (@id, @title)", map[string]interface{}{"id": i, "title": fmt.Sprintf("title%d", i)}, true)
 		require.NoError(t, err)
-	})
+	}
 
-	t.Run("should use index on `title,amount` in desc order", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT * FROM table1 WHERE title = 'title1' ORDER BY amount DESC", nil, true)
+	table, err := engine.GetTableByName("db1", "table1")
+	require.NoError(t, err)
+
+	titleCol, err := table.GetColumnByName("title")
+	require.NoError(t, err)
+
+	query := func(query string, params map[string]interface{}) (ids []int64, ranges map[uint32]*typedValueRange) {
+		r, err := engine.QueryStmt(query, params, true)
 		require.NoError(t, err)
+		defer r.Close()
 
-		orderBy := r.OrderBy()
-		require.NotNil(t, orderBy)
-		require.Len(t, orderBy, 2)
-		require.Equal(t, "title", orderBy[0].Column)
-		require.Equal(t, "amount", orderBy[1].Column)
+		rows, _, err := engine.readAll(r)
+		require.NoError(t, err)
 
-		scanSpecs := r.ScanSpecs()
-		require.NotNil(t, scanSpecs)
-		require.NotNil(t, scanSpecs.index)
-		require.False(t, scanSpecs.index.IsPrimary())
-		require.True(t, scanSpecs.index.IsUnique())
-		require.Len(t, scanSpecs.index.cols, 2)
-		require.Len(t, scanSpecs.rangesByColID, 1)
+		return rowIDs(rows), r.ScanSpecs().rangesByColID
+	}
 
-		titleRange := scanSpecs.rangesByColID[3]
-		require.NotNil(t, titleRange.lRange)
-		require.True(t, titleRange.lRange.inclusive)
-		require.Equal(t, "title1", titleRange.lRange.val.Value())
-		require.NotNil(t, titleRange.hRange)
-		require.True(t, titleRange.hRange.inclusive)
-		require.Equal(t, "title1", titleRange.hRange.val.Value())
+	t.Run("prefix pattern should be resolved with an index range", func(t *testing.T) {
+		ids, ranges := query("SELECT id FROM table1 USE INDEX ON title WHERE title LIKE '^title1'", nil)
+		require.Equal(t, []int64{1, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19}, ids)
 
-		require.True(t, scanSpecs.descOrder)
+		require.Contains(t, ranges, titleCol.id)
+		require.Equal(t, &typedValueSemiRange{val: &Varchar{val: "title1"}, inclusive: true}, ranges[titleCol.id].lRange)
+		require.Equal(t, &typedValueSemiRange{val: &Varchar{val: "title2"}}, ranges[titleCol.id].hRange)
 
-		err = r.Close()
-		require.NoError(t, err)
+		scannedIDs, _ := query("SELECT id FROM table1 WHERE title LIKE '^title1'", nil)
+		require.ElementsMatch(t, scannedIDs, ids)
+
+		paramIDs, ranges := query("SELECT id FROM table1 USE INDEX ON title WHERE title LIKE @pattern", map[string]interface{}{"pattern": "^title2[0-9]"})
+		require.Equal(t, []int64{20, 21, 22, 23, 24, 25, 26, 27, 28, 29}, paramIDs)
+		require.Equal(t, &typedValueSemiRange{val: &Varchar{val: "title2"}, inclusive: true}, ranges[titleCol.id].lRange)
 	})
 
-	t.Run("should use index on `ts` ascending order", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT * FROM table1 WHERE title > 'title10' ORDER BY ts ASC", nil, true)
-		require.NoError(t, err)
+	t.Run("prefix pattern provided once resolved should be resolved with an index range", func(t *testing.T) {
+		queryWithParams := func(pattern string) (ids []int64, ranges map[uint32]*typedValueRange) {
+			r, err := engine.QueryStmt("SELECT id FROM table1 USE INDEX ON title WHERE title LIKE @pattern", nil, true)
+			require.NoError(t, err)
+			defer r.Close()
 
-		orderBy := r.OrderBy()
-		require.NotNil(t, orderBy)
-		require.Len(t, orderBy, 1)
-		require.Equal(t, "ts", orderBy[0].Column)
+			require.NotContains(t, r.ScanSpecs().rangesByColID, titleCol.id)
 
-		scanSpecs := r.ScanSpecs()
-		require.NotNil(t, scanSpecs)
-		require.NotNil(t, scanSpecs.index)
-		require.False(t, scanSpecs.index.IsPrimary())
-		require.False(t, scanSpecs.index.IsUnique())
-		require.Len(t, scanSpecs.index.cols, 1)
-		require.Len(t, scanSpecs.rangesByColID, 1)
+			err = r.SetParameters(map[string]interface{}{"pattern": pattern})
+			require.NoError(t, err)
 
-		titleRange := scanSpecs.rangesByColID[3]
-		require.NotNil(t, titleRange.lRange)
-		require.False(t, titleRange.lRange.inclusive)
-		require.Equal(t, "title10", titleRange.lRange.val.Value())
-		require.Nil(t, titleRange.hRange)
+			for {
+				row, err := r.Read()
+				if err == ErrNoMoreRows {
+					break
+				}
+				require.NoError(t, err)
 
-		require.False(t, scanSpecs.descOrder)
+				ids = append(ids, row.Values[EncodeSelector("", "db1", "table1", "id")].Value().(int64))
+			}
 
-		err = r.Close()
-		require.NoError(t, err)
+			return ids, r.ScanSpecs().rangesByColID
+		}
+
+		ids, ranges := queryWithParams("^title2")
+		require.Equal(t, []int64{2, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29}, ids)
+		require.Equal(t, &typedValueSemiRange{val: &Varchar{val: "title2"}, inclusive: true}, ranges[titleCol.id].lRange)
+		require.Equal(t, &typedValueSemiRange{val: &Varchar{val: "title3"}}, ranges[titleCol.id].hRange)
+
+		ids, ranges = queryWithParams(".*9$")
+		require.Equal(t, []int64{19, 29, 9}, ids)
+		require.NotContains(t, ranges, titleCol.id)
 	})
 
-	t.Run("should use index on `ts` descending order", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT * FROM table1 WHERE title > 'title10' or title = 'title1' ORDER BY ts DESC", nil, true)
+	t.Run("prefix pattern should refine other ranges", func(t *testing.T) {
+		ids, ranges := query("SELECT id FROM table1 USE INDEX ON title WHERE title LIKE '^title1' AND title < 'title15'", nil)
+		require.Equal(t, []int64{1, 10, 11, 12, 13, 14}, ids)
+		require.Equal(t, &typedValueSemiRange{val: &Varchar{val: "title15"}}, ranges[titleCol.id].hRange)
+	})
+
+	t.Run("patterns without a literal prefix should be resolved by filtering", func(t *testing.T) {
+		for _, pattern := range []string{"itle1", ".*1$", "(?i)^TITLE1"} {
+			ids, ranges := query("SELECT id FROM table1 USE INDEX ON title WHERE title LIKE @pattern", map[string]interface{}{"pattern": pattern})
+			require.NotEmpty(t, ids)
+			require.NotContains(t, ranges, titleCol.id)
+		}
+
+		ids, ranges := query("SELECT id FROM table1 USE INDEX ON title WHERE title NOT LIKE '^title1'", nil)
+		require.Len(t, ids, 19)
+		require.NotContains(t, ranges, titleCol.id)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestQueryWithLikeEscape(t *testing.T) {
+	catalogStore, err := store.Open("catalog_like_escape", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_like_escape")
+
+	dataStore, err := store.Open("sqldata_like_escape", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_like_escape")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[20], PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(title)", nil, true)
+	require.NoError(t, err)
+
+	titles := []string{"100%", "1000", "a_b", "axb", "a.b", "(a)*", "50% off", "line1\nline2"}
+
+	for i, title := range titles {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (@id, @title)", map[string]interface{}{"id": i, "title": title}, true)
 		require.NoError(t, err)
+	}
 
-		orderBy := r.OrderBy()
-		require.NotNil(t, orderBy)
-		require.Len(t, orderBy, 1)
-		require.Equal(t, "ts", orderBy[0].Column)
+	testCases := []struct {
+		query       string
+		params      map[string]interface{}
+		expectedIDs []int64
+	}{
+		{query: "SELECT id FROM table1 WHERE title LIKE '100\\%' ESCAPE '\\'", expectedIDs: []int64{0}},
+		{query: "SELECT id FROM table1 WHERE title LIKE '100%' ESCAPE '\\'", expectedIDs: []int64{0, 1}},
+		{query: "SELECT id FROM table1 WHERE title LIKE 'a!_b' ESCAPE '!'", expectedIDs: []int64{2}},
+		{query: "SELECT id FROM table1 WHERE title LIKE 'a_b' ESCAPE '!'", expectedIDs: []int64{2, 3, 4}},
+		{query: "SELECT id FROM table1 WHERE title NOT LIKE 'a_b' ESCAPE '!'", expectedIDs: []int64{0, 1, 5, 6, 7}},
+		{query: "SELECT id FROM table1 WHERE title LIKE 'a.b' ESCAPE '!'", expectedIDs: []int64{4}},
+		{query: "SELECT id FROM table1 WHERE title LIKE '(a)*' ESCAPE '!'", expectedIDs: []int64{5}},
+		{query: "SELECT id FROM table1 WHERE title LIKE '%!%%' ESCAPE '!'", expectedIDs: []int64{0, 6}},
+		{query: "SELECT id FROM table1 WHERE title LIKE 'line1%line2' ESCAPE '!'", expectedIDs: []int64{7}},
+		{query: "SELECT id FROM table1 WHERE title LIKE @pattern ESCAPE '!'", params: map[string]interface{}{"pattern": "50!%%"}, expectedIDs: []int64{6}},
+		{query: "SELECT id FROM table1 WHERE title NOT LIKE @pattern ESCAPE '!'", params: map[string]interface{}{"pattern": nil}, expectedIDs: nil},
+		// regular expressions are still used when no escape is specified
+		{query: "SELECT id FROM table1 WHERE title LIKE '^a.b$'", expectedIDs: []int64{2, 3, 4}},
+	}
 
-		scanSpecs := r.ScanSpecs()
-		require.NotNil(t, scanSpecs)
-		require.NotNil(t, scanSpecs.index)
-		require.False(t, scanSpecs.index.IsPrimary())
-		require.False(t, scanSpecs.index.IsUnique())
-		require.Len(t, scanSpecs.index.cols, 1)
-		require.Len(t, scanSpecs.rangesByColID, 1)
+	for i, tc := range testCases {
+		// rows may be read through the title index
+		require.ElementsMatch(t, tc.expectedIDs, queryIDs(t, engine, tc.query, tc.params), fmt.Sprintf("failed on iteration %d", i))
+	}
 
-		titleRange := scanSpecs.rangesByColID[3]
-		require.NotNil(t, titleRange.lRange)
-		require.True(t, titleRange.lRange.inclusive)
-		require.Equal(t, "title1", titleRange.lRange.val.Value())
-		require.Nil(t, titleRange.hRange)
+	t.Run("null values match neither LIKE nor NOT LIKE", func(t *testing.T) {
+		_, err = engine.ExecStmt(`
+			CREATE TABLE table2 (id INTEGER, title VARCHAR[20], PRIMARY KEY id);
+			INSERT INTO table2 (id, title) VALUES (1, 'a_b'), (2, NULL);
+		`, nil, true)
+		require.NoError(t, err)
 
-		require.True(t, scanSpecs.descOrder)
+		rows, _, err := engine.QueryAll("SELECT id FROM table2 WHERE title LIKE 'a!_b' ESCAPE '!'", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
 
-		err = r.Close()
+		rows, _, err = engine.QueryAll("SELECT id FROM table2 WHERE title NOT LIKE 'a!_b' ESCAPE '!'", nil)
 		require.NoError(t, err)
+		require.Empty(t, rows)
+
+		rows, _, err = engine.QueryAll("SELECT id FROM table2 WHERE (title LIKE '^a') IS UNKNOWN", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, int64(2), rows[0].Values[EncodeSelector("", "db1", "table2", "id")].Value())
 	})
 
-	t.Run("should use index on `title` descending order", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT * FROM table1 WHERE title > 'title10' or title = 'title1' ORDER BY title DESC", nil, true)
+	t.Run("escaped patterns with a literal prefix should be resolved with an index range", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT id FROM table1 USE INDEX ON title WHERE title LIKE '50!%%' ESCAPE '!'", nil, true)
 		require.NoError(t, err)
+		defer r.Close()
 
-		orderBy := r.OrderBy()
-		require.NotNil(t, orderBy)
-		require.Len(t, orderBy, 2)
-		require.Equal(t, "title", orderBy[0].Column)
-		require.Equal(t, "amount", orderBy[1].Column)
+		table, err := engine.GetTableByName("db1", "table1")
+		require.NoError(t, err)
 
-		scanSpecs := r.ScanSpecs()
-		require.NotNil(t, scanSpecs)
-		require.NotNil(t, scanSpecs.index)
-		require.False(t, scanSpecs.index.IsPrimary())
-		require.True(t, scanSpecs.index.IsUnique())
-		require.Len(t, scanSpecs.index.cols, 2)
-		require.Len(t, scanSpecs.rangesByColID, 1)
+		titleCol, err := table.GetColumnByName("title")
+		require.NoError(t, err)
 
-		titleRange := scanSpecs.rangesByColID[3]
-		require.NotNil(t, titleRange.lRange)
-		require.True(t, titleRange.lRange.inclusive)
-		require.Equal(t, "title1", titleRange.lRange.val.Value())
-		require.Nil(t, titleRange.hRange)
+		ranges := r.ScanSpecs().rangesByColID
+		require.Contains(t, ranges, titleCol.id)
+		require.Equal(t, &typedValueSemiRange{val: &Varchar{val: "50%"}, inclusive: true}, ranges[titleCol.id].lRange)
+	})
+
+	_, _, err = engine.QueryAll("SELECT id FROM table1 WHERE title LIKE 'a!' ESCAPE '!'", nil)
+	require.ErrorIs(t, err, ErrInvalidCondition)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
 
-		require.True(t, scanSpecs.descOrder)
+func TestQueryWithValues(t *testing.T) {
+	catalogStore, err := store.Open("catalog_values", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_values")
 
-		err = r.Close()
-		require.NoError(t, err)
-	})
+	dataStore, err := store.Open("sqldata_values", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_values")
 
-	t.Run("should use index on `title` ascending order starting with 'title1'", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT * FROM table1 USE INDEX ON (title) WHERE title > 'title10' or title = 'title1' ORDER BY title", nil, true)
-		require.NoError(t, err)
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
 
-		orderBy := r.OrderBy()
-		require.NotNil(t, orderBy)
-		require.Len(t, orderBy, 1)
-		require.Equal(t, "title", orderBy[0].Column)
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
 
-		scanSpecs := r.ScanSpecs()
-		require.NotNil(t, scanSpecs)
-		require.NotNil(t, scanSpecs.index)
-		require.False(t, scanSpecs.index.IsPrimary())
-		require.True(t, scanSpecs.index.IsUnique())
-		require.Len(t, scanSpecs.index.cols, 1)
-		require.Len(t, scanSpecs.rangesByColID, 1)
+	_, _, err = engine.QueryAll("VALUES (1, 'a')", nil)
+	require.ErrorIs(t, err, ErrNoDatabaseSelected)
 
-		titleRange := scanSpecs.rangesByColID[3]
-		require.NotNil(t, titleRange.lRange)
-		require.True(t, titleRange.lRange.inclusive)
-		require.Equal(t, "title1", titleRange.lRange.val.Value())
-		require.Nil(t, titleRange.hRange)
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
 
-		require.False(t, scanSpecs.descOrder)
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
 
-		err = r.Close()
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (1, 'title1'), (2, 'title2'), (3, 'title3')", nil, true)
+	require.NoError(t, err)
+
+	t.Run("values should be queried as a top-level statement", func(t *testing.T) {
+		rows, cols, err := engine.QueryAll("VALUES (1, 'a'), (2, @b), (3, NULL)", map[string]interface{}{"b": "b"})
 		require.NoError(t, err)
+
+		require.Equal(t, []ColDescriptor{
+			{Database: "db1", Table: "values", Column: "column1", Type: IntegerType},
+			{Database: "db1", Table: "values", Column: "column2", Type: VarcharType},
+		}, cols)
+
+		require.Len(t, rows, 3)
+
+		for i, expected := range []interface{}{"a", "b", nil} {
+			require.Equal(t, int64(i+1), rows[i].Values[EncodeSelector("", "db1", "values", "column1")].Value())
+			require.Equal(t, expected, rows[i].Values[EncodeSelector("", "db1", "values", "column2")].Value())
+		}
 	})
 
-	t.Run("should use index on `title` ascending order", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT * FROM table1 USE INDEX ON (title) WHERE title < 'title10' or title = 'title1' ORDER BY title", nil, true)
+	t.Run("values should be queried as a data source", func(t *testing.T) {
+		rows, _, err := engine.QueryAll("SELECT column1 AS id FROM (VALUES (1, true), (2, false), (3, true)) AS v WHERE column2", nil)
 		require.NoError(t, err)
+		require.Len(t, rows, 2)
+		require.Equal(t, int64(1), rows[0].Values[EncodeSelector("", "db1", "v", "id")].Value())
+		require.Equal(t, int64(3), rows[1].Values[EncodeSelector("", "db1", "v", "id")].Value())
 
-		orderBy := r.OrderBy()
-		require.NotNil(t, orderBy)
-		require.Len(t, orderBy, 1)
-		require.Equal(t, "title", orderBy[0].Column)
+		rows, _, err = engine.QueryAll("SELECT COUNT() AS c FROM (VALUES (1), (2), (3)) AS v", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, int64(3), rows[0].Values[EncodeSelector("", "db1", "v", "c")].Value())
+	})
 
-		scanSpecs := r.ScanSpecs()
-		require.NotNil(t, scanSpecs)
-		require.NotNil(t, scanSpecs.index)
-		require.False(t, scanSpecs.index.IsPrimary())
-		require.True(t, scanSpecs.index.IsUnique())
-		require.Len(t, scanSpecs.index.cols, 1)
-		require.Len(t, scanSpecs.rangesByColID, 1)
+	t.Run("values should be joined against a table", func(t *testing.T) {
+		rows, _, err := engine.QueryAll(`
+			SELECT v.column1 AS id, v.column2 AS label, table1.title
+			FROM (VALUES (3, 'c'), (1, 'a'), (4, 'd')) AS v
+			INNER JOIN table1 ON table1.id = v.column1`, nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
 
-		titleRange := scanSpecs.rangesByColID[3]
-		require.Nil(t, titleRange.lRange)
-		require.NotNil(t, titleRange.hRange)
-		require.False(t, titleRange.hRange.inclusive)
-		require.Equal(t, "title10", titleRange.hRange.val.Value())
+		require.Equal(t, int64(3), rows[0].Values[EncodeSelector("", "db1", "v", "id")].Value())
+		require.Equal(t, "c", rows[0].Values[EncodeSelector("", "db1", "v", "label")].Value())
+		require.Equal(t, "title3", rows[0].Values[EncodeSelector("", "db1", "table1", "title")].Value())
 
-		require.False(t, scanSpecs.descOrder)
+		require.Equal(t, int64(1), rows[1].Values[EncodeSelector("", "db1", "v", "id")].Value())
+		require.Equal(t, "title1", rows[1].Values[EncodeSelector("", "db1", "table1", "title")].Value())
 
-		err = r.Close()
+		rows, _, err = engine.QueryAll(`
+			SELECT id, v.column2 AS label
+			FROM table1
+			INNER JOIN (VALUES (2, 'b'), (3, 'c')) AS v ON v.column1 = table1.id`, nil)
 		require.NoError(t, err)
+		require.Equal(t, []int64{2, 3}, rowIDs(rows))
 	})
 
-	t.Run("should use index on `title` descending order", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT * FROM table1 USE INDEX ON (title) WHERE title < 'title10' and title = 'title1' ORDER BY title DESC", nil, true)
+	t.Run("parameters in values should be inferred", func(t *testing.T) {
+		params, err := engine.InferParameters("VALUES (1, @title), (@id, 'b')")
 		require.NoError(t, err)
+		require.Equal(t, map[string]SQLValueType{"id": IntegerType, "title": VarcharType}, params)
+	})
 
-		orderBy := r.OrderBy()
-		require.NotNil(t, orderBy)
-		require.Len(t, orderBy, 1)
-		require.Equal(t, "title", orderBy[0].Column)
+	_, _, err = engine.QueryAll("VALUES (1, 'a'), (2)", nil)
+	require.ErrorIs(t, err, ErrInvalidNumberOfValues)
 
-		scanSpecs := r.ScanSpecs()
-		require.NotNil(t, scanSpecs)
-		require.NotNil(t, scanSpecs.index)
-		require.False(t, scanSpecs.index.IsPrimary())
-		require.True(t, scanSpecs.index.IsUnique())
-		require.Len(t, scanSpecs.index.cols, 1)
-		require.Len(t, scanSpecs.rangesByColID, 1)
+	_, _, err = engine.QueryAll("VALUES (1), ('a')", nil)
+	require.ErrorIs(t, err, ErrInvalidTypes)
 
-		titleRange := scanSpecs.rangesByColID[3]
-		require.NotNil(t, titleRange.lRange)
-		require.True(t, titleRange.lRange.inclusive)
-		require.Equal(t, "title1", titleRange.lRange.val.Value())
-		require.NotNil(t, titleRange.hRange)
-		require.True(t, titleRange.hRange.inclusive)
-		require.Equal(t, "title1", titleRange.hRange.val.Value())
+	_, _, err = engine.QueryAll("VALUES (1), (@id)", map[string]interface{}{"id": "a"})
+	require.ErrorIs(t, err, ErrInvalidTypes)
 
-		require.True(t, scanSpecs.descOrder)
+	_, _, err = engine.QueryAll("SELECT column1 FROM (VALUES (1), (2)) AS v ORDER BY column1", nil)
+	require.ErrorIs(t, err, ErrLimitedOrderBy)
 
-		err = r.Close()
-		require.NoError(t, err)
-	})
+	_, _, err = engine.QueryAll("SELECT column1, COUNT() FROM (VALUES (1), (2)) AS v GROUP BY column1", nil)
+	require.ErrorIs(t, err, ErrLimitedGroupBy)
 
 	err = engine.Close()
 	require.NoError(t, err)
 }
 
-func TestExecCornerCases(t *testing.T) {
-	catalogStore, err := store.Open("catalog_q", store.DefaultOptions())
+func TestTableStmt(t *testing.T) {
+	catalogStore, err := store.Open("catalog_table_stmt", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_q")
+	defer os.RemoveAll("catalog_table_stmt")
 
-	dataStore, err := store.Open("sqldata_q", store.DefaultOptions())
+	dataStore, err := store.Open("sqldata_table_stmt", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_q")
+	defer os.RemoveAll("sqldata_table_stmt")
 
 	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
 
-	summary, err := engine.ExecStmt("INVALID STATEMENT", nil, false)
-	require.EqualError(t, err, "syntax error: unexpected IDENTIFIER")
-	require.Nil(t, summary)
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
 
-	err = engine.Close()
+	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	summary, err = engine.ExecStmt("CREATE TABLE t1(id INTEGER, primary key id)", nil, false)
-	require.ErrorIs(t, err, ErrAlreadyClosed)
-	require.Nil(t, summary)
-}
+	_, _, err = engine.QueryAll("TABLE table1", nil)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
 
-func TestQueryWithNullables(t *testing.T) {
-	catalogStore, err := store.Open("catalog_nullable", store.DefaultOptions())
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, active BOOLEAN, PRIMARY KEY id)", nil, true)
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_nullable")
 
-	dataStore, err := store.Open("sqldata_nullable", store.DefaultOptions())
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active) VALUES (1, 'title1', true), (2, NULL, false), (3, 'title3', NULL)", nil, true)
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_nullable")
+
+	expectedRows, expectedCols, err := engine.QueryAll("SELECT * FROM table1", nil)
+	require.NoError(t, err)
+	require.Len(t, expectedRows, 3)
+
+	rows, cols, err := engine.QueryAll("TABLE table1", nil)
+	require.NoError(t, err)
+	require.Equal(t, expectedCols, cols)
+	require.Equal(t, expectedRows, rows)
+
+	rows, cols, err = engine.QueryAll("TABLE db1.table1", nil)
+	require.NoError(t, err)
+	require.Equal(t, expectedCols, cols)
+	require.Equal(t, expectedRows, rows)
+
+	rows, _, err = engine.QueryAll("SELECT COUNT() AS c FROM (TABLE table1) AS t WHERE active", nil)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, int64(1), rows[0].Values[EncodeSelector("", "db1", "t", "c")].Value())
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+func TestQueryWithCTEs(t *testing.T) {
+	catalogStore, err := store.Open("catalog_cte", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_cte")
+
+	dataStore, err := store.Open("sqldata_cte", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_cte")
 
 	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
@@ -2287,460 +7311,683 @@ func TestQueryWithNullables(t *testing.T) {
 	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, ts INTEGER, title VARCHAR, active BOOLEAN, PRIMARY KEY id)", nil, true)
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, active BOOLEAN, PRIMARY KEY id)", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("INSERT INTO table1 (id, ts, title) VALUES (1, TIME(), 'title1')", nil, true)
-	require.Equal(t, ErrNoSupported, err)
+	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER AUTO_INCREMENT, table1_id INTEGER, amount INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
 
-	rowCount := 10
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active) VALUES (1, 'title1', true), (2, 'title2', false), (3, 'title3', true)", nil, true)
+	require.NoError(t, err)
 
-	start := time.Now().UnixNano()
+	_, err = engine.ExecStmt("INSERT INTO table2 (table1_id, amount) VALUES (1, 10), (2, 20), (3, 30), (3, 40)", nil, true)
+	require.NoError(t, err)
 
-	for i := 0; i < rowCount; i++ {
-		_, err = engine.ExecStmt(fmt.Sprintf("UPSERT INTO table1 (id, ts, title) VALUES (%d, NOW(), 'title%d')", i, i), nil, true)
+	t.Run("a common table expression should be joined to a table", func(t *testing.T) {
+		rows, _, err := engine.QueryAll(`
+			WITH active_ones AS (SELECT id, title FROM table1 WHERE active)
+			SELECT table2.id, active_ones.title, table2.amount
+			FROM table2
+			INNER JOIN active_ones ON active_ones.id = table2.table1_id
+			WHERE table2.amount > @amount`, map[string]interface{}{"amount": 10})
 		require.NoError(t, err)
-	}
+		require.Len(t, rows, 2)
 
-	r, err := engine.QueryStmt("SELECT id, ts, title, active FROM table1 WHERE NOT(active != NULL)", nil, true)
-	require.NoError(t, err)
+		for i, expected := range []struct {
+			title  string
+			amount int64
+		}{{"title3", 30}, {"title3", 40}} {
+			require.Equal(t, expected.title, rows[i].Values[EncodeSelector("", "db1", "active_ones", "title")].Value())
+			require.Equal(t, expected.amount, rows[i].Values[EncodeSelector("", "db1", "table2", "amount")].Value())
+		}
+	})
 
-	cols, err := r.Columns()
-	require.NoError(t, err)
-	require.Len(t, cols, 4)
+	t.Run("a common table expression should be referenced several times and by later expressions", func(t *testing.T) {
+		rows, _, err := engine.QueryAll(`
+			WITH t1 AS (SELECT id FROM table1), t2 AS (SELECT id FROM t1 WHERE id > 1)
+			SELECT a.id, b.id AS other_id
+			FROM t2 AS a
+			INNER JOIN t2 AS b ON b.id = a.id`, nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+		require.Equal(t, int64(2), rows[0].Values[EncodeSelector("", "db1", "a", "id")].Value())
+		require.Equal(t, int64(2), rows[0].Values[EncodeSelector("", "db1", "b", "other_id")].Value())
+		require.Equal(t, int64(3), rows[1].Values[EncodeSelector("", "db1", "a", "id")].Value())
+	})
 
-	for i := 0; i < rowCount; i++ {
-		row, err := r.Read()
+	t.Run("a common table expression should hide a table with the same name", func(t *testing.T) {
+		rows, _, err := engine.QueryAll("WITH table1 AS (SELECT id FROM db1.table1 WHERE id = 2) SELECT id FROM table1", nil)
 		require.NoError(t, err)
-		require.NotNil(t, row)
-		require.Len(t, row.Values, 4)
-		require.Less(t, int64(start), row.Values[EncodeSelector("", "db1", "table1", "ts")].Value())
-		require.Equal(t, int64(i), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
-		require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
-		require.Equal(t, &NullValue{t: BooleanType}, row.Values[EncodeSelector("", "db1", "table1", "active")])
-	}
+		require.Equal(t, []int64{2}, rowIDs(rows))
+	})
 
-	err = r.Close()
-	require.NoError(t, err)
+	t.Run("parameters in common table expressions should be inferred", func(t *testing.T) {
+		params, err := engine.InferParameters("WITH t1 AS (SELECT id FROM table1 WHERE title = @title) SELECT id FROM t1 WHERE id > @id")
+		require.NoError(t, err)
+		require.Equal(t, map[string]SQLValueType{"id": IntegerType, "title": VarcharType}, params)
+	})
+
+	_, _, err = engine.QueryAll("WITH t1 AS (SELECT id FROM table1), t1 AS (SELECT id FROM table2) SELECT id FROM t1", nil)
+	require.ErrorIs(t, err, ErrDuplicatedTableExpression)
+
+	_, _, err = engine.QueryAll("WITH t1 AS (SELECT id FROM t2), t2 AS (SELECT id FROM table1) SELECT id FROM t1", nil)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	_, _, err = engine.QueryAll("WITH t1 AS (SELECT id FROM t1) SELECT id FROM t1", nil)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	_, _, err = engine.QueryAll("WITH t1 AS (SELECT id FROM table1) SELECT id FROM t1 BEFORE TX 1", nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
 
 	err = engine.Close()
 	require.NoError(t, err)
 }
 
-func TestOrderBy(t *testing.T) {
-	catalogStore, err := store.Open("catalog_orderby", store.DefaultOptions())
+func TestCatalogVersionAndSchemaHash(t *testing.T) {
+	catalogStore, err := store.Open("catalog_version", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_orderby")
+	defer os.RemoveAll("catalog_version")
 
-	dataStore, err := store.Open("sqldata_orderby", store.DefaultOptions())
+	dataStore, err := store.Open("sqldata_version", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_orderby")
+	defer os.RemoveAll("sqldata_version")
 
 	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
-	require.NoError(t, err)
-
-	_, err = engine.ExecStmt("CREATE INDEX ON table1(title)", nil, true)
-	require.Equal(t, ErrNoDatabaseSelected, err)
+	_, err = engine.CatalogVersion()
+	require.ErrorIs(t, err, ErrCatalogNotReady)
 
-	err = engine.UseDatabase("db1")
+	err = engine.EnsureCatalogReady(nil)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[100], age INTEGER, PRIMARY KEY id)", nil, true)
+	version, err := engine.CatalogVersion()
 	require.NoError(t, err)
+	require.Zero(t, version)
 
-	_, err = engine.QueryStmt("SELECT id, title, age FROM table1 ORDER BY id, title DESC", nil, true)
-	require.Equal(t, ErrLimitedOrderBy, err)
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
 
-	_, err = engine.QueryStmt("SELECT id, title, age FROM (SELECT id, title, age FROM table1) ORDER BY id", nil, true)
-	require.Equal(t, ErrLimitedOrderBy, err)
+	_, err = engine.ExecStmt("CREATE DATABASE db2", nil, true)
+	require.NoError(t, err)
 
-	_, err = engine.QueryStmt("SELECT id, title, age FROM (SELECT id, title, age FROM table1 AS t1) ORDER BY age DESC", nil, true)
-	require.Equal(t, ErrLimitedOrderBy, err)
+	_, err = engine.SchemaHash("db3")
+	require.ErrorIs(t, err, ErrDatabaseDoesNotExist)
 
-	_, err = engine.QueryStmt("SELECT id, title, age FROM table2 ORDER BY title", nil, true)
-	require.Equal(t, ErrTableDoesNotExist, err)
+	emptyHash, err := engine.SchemaHash("db1")
+	require.NoError(t, err)
 
-	_, err = engine.QueryStmt("SELECT id, title, age FROM table1 ORDER BY amount", nil, true)
-	require.Equal(t, ErrColumnDoesNotExist, err)
+	lastVersion, err := engine.CatalogVersion()
+	require.NoError(t, err)
+	require.Greater(t, lastVersion, version)
 
-	_, err = engine.ExecStmt("CREATE INDEX ON table1(title)", nil, true)
+	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	_, err = engine.QueryStmt("SELECT id, title, age FROM table1 ORDER BY age", nil, true)
-	require.Equal(t, ErrLimitedOrderBy, err)
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR[10] NOT NULL, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE INDEX ON table1(age)", nil, true)
+	version, err = engine.CatalogVersion()
 	require.NoError(t, err)
+	require.Greater(t, version, lastVersion)
+	lastVersion = version
 
-	params := make(map[string]interface{}, 1)
-	params["age"] = nil
-	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, age) VALUES (1, 'title', @age)", params, true)
-	require.Equal(t, ErrIndexedColumnCanNotBeNull, err)
+	tableHash, err := engine.SchemaHash("db1")
+	require.NoError(t, err)
+	require.NotEqual(t, emptyHash, tableHash)
 
-	_, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (1, 'title')", nil, true)
-	require.Equal(t, ErrIndexedColumnCanNotBeNull, err)
+	t.Run("data changes should not change the catalog version nor the schema hash", func(t *testing.T) {
+		_, err = engine.ExecStmt("INSERT INTO table1 (title) VALUES ('title1')", nil, true)
+		require.NoError(t, err)
 
-	rowCount := 1
+		version, err := engine.CatalogVersion()
+		require.NoError(t, err)
+		require.Equal(t, lastVersion, version)
 
-	for i := 0; i < rowCount; i++ {
-		params := make(map[string]interface{}, 3)
-		params["id"] = i
-		params["title"] = fmt.Sprintf("title%d", i)
-		params["age"] = 40 + i
+		h, err := engine.SchemaHash("db1")
+		require.NoError(t, err)
+		require.Equal(t, tableHash, h)
+	})
 
-		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, age) VALUES (@id, @title, @age)", params, true)
+	t.Run("same schemas should have the same hash", func(t *testing.T) {
+		h, err := engine.SchemaHash("db2")
 		require.NoError(t, err)
-	}
+		require.Equal(t, emptyHash, h)
 
-	r, err := engine.QueryStmt("SELECT id, title, age FROM table1 ORDER BY title", nil, true)
-	require.NoError(t, err)
+		err = engine.UseDatabase("db2")
+		require.NoError(t, err)
 
-	orderBy := r.OrderBy()
-	require.NotNil(t, orderBy)
-	require.Len(t, orderBy, 1)
-	require.Equal(t, "title", orderBy[0].Column)
-	require.Equal(t, "table1", orderBy[0].Table)
-	require.Equal(t, "db1", orderBy[0].Database)
+		_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR[10] NOT NULL, PRIMARY KEY id)", nil, true)
+		require.NoError(t, err)
 
-	for i := 0; i < rowCount; i++ {
-		row, err := r.Read()
+		h, err = engine.SchemaHash("db2")
 		require.NoError(t, err)
-		require.NotNil(t, row)
-		require.Len(t, row.Values, 3)
+		require.Equal(t, tableHash, h)
 
-		require.Equal(t, int64(i), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
-		require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
-		require.Equal(t, int64(40+i), row.Values[EncodeSelector("", "db1", "table1", "age")].Value())
-	}
+		_, err = engine.ExecStmt("CREATE INDEX ON table1(title)", nil, true)
+		require.NoError(t, err)
 
-	err = r.Close()
-	require.NoError(t, err)
+		err = engine.UseDatabase("db1")
+		require.NoError(t, err)
 
-	r, err = engine.QueryStmt("SELECT id, title, age FROM table1 ORDER BY age", nil, true)
-	require.NoError(t, err)
+		h, err = engine.SchemaHash("db2")
+		require.NoError(t, err)
+		require.NotEqual(t, tableHash, h)
 
-	for i := 0; i < rowCount; i++ {
-		row, err := r.Read()
+		version, err := engine.CatalogVersion()
 		require.NoError(t, err)
-		require.NotNil(t, row)
-		require.Len(t, row.Values, 3)
+		require.Greater(t, version, lastVersion)
+		lastVersion = version
+	})
 
-		require.Equal(t, int64(i), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
-		require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
-		require.Equal(t, int64(40+i), row.Values[EncodeSelector("", "db1", "table1", "age")].Value())
-	}
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, PRIMARY KEY id)", nil, true)
+	require.ErrorIs(t, err, ErrTableAlreadyExists)
 
-	err = r.Close()
+	err = engine.Close()
 	require.NoError(t, err)
 
-	r, err = engine.QueryStmt("SELECT id, title, age FROM table1 ORDER BY age DESC", nil, true)
+	_, err = engine.CatalogVersion()
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	_, err = engine.SchemaHash("db1")
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	// both the version and the schema are persisted in the catalog
+	engine, err = NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
 
-	for i := 0; i < rowCount; i++ {
-		row, err := r.Read()
-		require.NoError(t, err)
-		require.NotNil(t, row)
-		require.Len(t, row.Values, 3)
+	err = engine.EnsureCatalogReady(nil)
+	require.NoError(t, err)
 
-		require.Equal(t, int64(rowCount-1-i), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
-		require.Equal(t, fmt.Sprintf("title%d", rowCount-1-i), row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
-		require.Equal(t, int64(40-(rowCount-1-i)), row.Values[EncodeSelector("", "db1", "table1", "age")].Value())
-	}
+	version, err = engine.CatalogVersion()
+	require.NoError(t, err)
+	require.Equal(t, lastVersion, version)
 
-	err = r.Close()
+	h, err := engine.SchemaHash("db1")
 	require.NoError(t, err)
+	require.Equal(t, tableHash, h)
 
 	err = engine.Close()
 	require.NoError(t, err)
 }
 
-func TestQueryWithRowFiltering(t *testing.T) {
-	catalogStore, err := store.Open("catalog_where", store.DefaultOptions())
+func TestComments(t *testing.T) {
+	catalogStore, err := store.Open("catalog_comments", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_where")
+	defer os.RemoveAll("catalog_comments")
 
-	dataStore, err := store.Open("sqldata_where", store.DefaultOptions())
+	dataStore, err := store.Open("sqldata_comments", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_where")
+	defer os.RemoveAll("sqldata_comments")
 
 	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
 
+	err = engine.EnsureCatalogReady(nil)
+	require.NoError(t, err)
+
 	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
 	require.NoError(t, err)
 
 	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, active BOOLEAN, payload BLOB, PRIMARY KEY id)", nil, true)
+	_, err = engine.ExecStmt("COMMENT ON TABLE table1 IS 'orders'", nil, true)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, amount INTEGER, PRIMARY KEY id)", nil, true)
 	require.NoError(t, err)
 
-	rowCount := 10
+	_, err = engine.ExecStmt("COMMENT ON COLUMN table1.price IS 'in cents'", nil, true)
+	require.ErrorIs(t, err, ErrColumnDoesNotExist)
 
-	for i := 0; i < rowCount; i++ {
-		encPayload := hex.EncodeToString([]byte(fmt.Sprintf("blob%d", i)))
-		_, err = engine.ExecStmt(fmt.Sprintf(`
-			UPSERT INTO table1 (id, title, active, payload) VALUES (%d, 'title%d', %v, x'%s')
-		`, i, i, i%2 == 0, encPayload), nil, true)
+	_, err = engine.ExecStmt("COMMENT ON TABLE table1 IS 'orders'", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("COMMENT ON COLUMN table1.amount IS 'in cents'", nil, true)
+	require.NoError(t, err)
+
+	checkComments := func(tableComment, colComment string) {
+		db, err := engine.catalog.GetDatabaseByName("db1")
+		require.NoError(t, err)
+
+		table, err := db.GetTableByName("table1")
+		require.NoError(t, err)
+		require.Equal(t, tableComment, table.Comment())
+
+		col, err := table.GetColumnByName("amount")
+		require.NoError(t, err)
+		require.Equal(t, colComment, col.Comment())
+
+		col, err = table.GetColumnByName("id")
 		require.NoError(t, err)
+		require.Empty(t, col.Comment())
 	}
 
-	r, err := engine.QueryStmt("SELECT id, title, active FROM table1 WHERE false", nil, true)
+	checkComments("orders", "in cents")
+
+	err = engine.ReloadCatalog(nil)
 	require.NoError(t, err)
 
-	_, err = r.Read()
-	require.Equal(t, ErrNoMoreRows, err)
+	checkComments("orders", "in cents")
 
-	err = r.Close()
+	_, err = engine.ExecStmt("COMMENT ON COLUMN table1.amount IS NULL", nil, true)
 	require.NoError(t, err)
 
-	r, err = engine.QueryStmt("SELECT id, title, active FROM table1 WHERE false OR true", nil, true)
+	checkComments("orders", "")
+
+	err = engine.ReloadCatalog(nil)
 	require.NoError(t, err)
 
-	for i := 0; i < rowCount; i++ {
-		_, err := r.Read()
-		require.NoError(t, err)
-	}
+	checkComments("orders", "")
+}
 
-	err = r.Close()
+func TestConcurrentCatalogLoads(t *testing.T) {
+	catalogStore, err := store.Open("catalog_concurrent_loads", store.DefaultOptions())
 	require.NoError(t, err)
+	defer os.RemoveAll("catalog_concurrent_loads")
 
-	r, err = engine.QueryStmt("SELECT id, title, active FROM table1 WHERE 1 < 2", nil, true)
+	dataStore, err := store.Open("sqldata_concurrent_loads", store.DefaultOptions())
 	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_concurrent_loads")
 
-	for i := 0; i < rowCount; i++ {
-		_, err := r.Read()
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			errs <- engine.EnsureCatalogReady(nil)
+		}()
+
+		go func() {
+			defer wg.Done()
+			errs <- engine.ReloadCatalog(nil)
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
 		require.NoError(t, err)
 	}
 
-	err = r.Close()
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
 	require.NoError(t, err)
 
-	r, err = engine.QueryStmt("SELECT id, title, active FROM table1 WHERE 1 >= 2", nil, true)
+	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	_, err = r.Read()
-	require.Equal(t, ErrNoMoreRows, err)
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
 
-	err = r.Close()
+	_, err = engine.ExecStmt("INSERT INTO table1 (title) VALUES ('title1'), ('title2')", nil, true)
 	require.NoError(t, err)
 
-	r, err = engine.QueryStmt("SELECT id, title, active FROM table1 WHERE 1 = true", nil, true)
+	errs = make(chan error, 30)
+
+	for i := 0; i < 10; i++ {
+		wg.Add(3)
+
+		go func() {
+			defer wg.Done()
+			errs <- engine.EnsureCatalogReady(nil)
+		}()
+
+		go func() {
+			defer wg.Done()
+			errs <- engine.ReloadCatalog(nil)
+		}()
+
+		go func(i int) {
+			defer wg.Done()
+			_, err := engine.ExecStmt(fmt.Sprintf("CREATE TABLE table_%d (id INTEGER, PRIMARY KEY id)", i), nil, true)
+			errs <- err
+		}(i + 2)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	db, err := engine.GetDatabaseByName("db1")
 	require.NoError(t, err)
+	require.Len(t, db.GetTables(), 11)
 
-	_, err = r.Read()
-	require.Equal(t, ErrNotComparableValues, err)
+	hash, err := engine.SchemaHash("db1")
+	require.NoError(t, err)
 
-	err = r.Close()
+	err = engine.ReloadCatalog(nil)
 	require.NoError(t, err)
 
-	r, err = engine.QueryStmt("SELECT id, title, active FROM table1 WHERE NOT table1.active", nil, true)
+	reloadedHash, err := engine.SchemaHash("db1")
 	require.NoError(t, err)
+	require.Equal(t, hash, reloadedHash)
 
-	for i := 0; i < rowCount/2; i++ {
-		_, err := r.Read()
-		require.NoError(t, err)
-	}
+	summary, err := engine.ExecStmt("INSERT INTO table1 (title) VALUES ('title3')", nil, true)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), summary.LastInsertedPKs["table1"])
 
-	_, err = r.Read()
-	require.Equal(t, ErrNoMoreRows, err)
+	err = engine.Close()
+	require.NoError(t, err)
 
-	err = r.Close()
+	err = engine.EnsureCatalogReady(nil)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	err = engine.ReloadCatalog(nil)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
+func TestChangesSince(t *testing.T) {
+	catalogStore, err := store.Open("catalog_changes", store.DefaultOptions())
 	require.NoError(t, err)
+	defer os.RemoveAll("catalog_changes")
 
-	r, err = engine.QueryStmt("SELECT id, title, active FROM table1 WHERE table1.id > 4", nil, true)
+	dataStore, err := store.Open("sqldata_changes", store.DefaultOptions())
 	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_changes")
 
-	for i := 0; i < rowCount/2; i++ {
-		_, err := r.Read()
-		require.NoError(t, err)
-	}
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
 
-	_, err = r.Read()
-	require.Equal(t, ErrNoMoreRows, err)
+	_, err = engine.ChangesSince("db1", "table1", 0)
+	require.ErrorIs(t, err, ErrCatalogNotReady)
 
-	err = r.Close()
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt(fmt.Sprintf("UPSERT INTO table1 (id, title) VALUES (%d, 'title%d')", rowCount, rowCount), nil, true)
+	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	r, err = engine.QueryStmt("SELECT id, title FROM table1 WHERE active = null AND payload = null", nil, true)
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, PRIMARY KEY id)", nil, true)
 	require.NoError(t, err)
 
-	_, err = r.Read()
+	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER, PRIMARY KEY id)", nil, true)
 	require.NoError(t, err)
 
-	err = r.Close()
+	_, err = engine.ChangesSince("db1", "table3", 0)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	summary, err := engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (1, 'title1'), (2, 'title2')", nil, true)
 	require.NoError(t, err)
+	insertTx := summary.DMTxs[0].ID
 
-	r, err = engine.QueryStmt("SELECT id, title FROM table1 WHERE active = null AND payload = null AND active = payload", nil, true)
+	_, err = engine.ExecStmt("INSERT INTO table2 (id) VALUES (1)", nil, true)
 	require.NoError(t, err)
 
-	_, err = r.Read()
-	require.Equal(t, ErrNotComparableValues, err)
+	summary, err = engine.ExecStmt("UPDATE table1 SET title = 'title11' WHERE id = 1", nil, true)
+	require.NoError(t, err)
+	updateTx := summary.DMTxs[0].ID
 
-	err = r.Close()
+	summary, err = engine.ExecStmt("DELETE FROM table1 WHERE id = 2", nil, true)
+	require.NoError(t, err)
+	deleteTx := summary.DMTxs[0].ID
+
+	summary, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (2, 'title22')", nil, true)
 	require.NoError(t, err)
+	reinsertTx := summary.DMTxs[0].ID
+
+	type change struct {
+		tx    int64
+		op    string
+		id    int64
+		title string
+	}
+
+	changesSince := func(sinceTx uint64) []change {
+		r, err := engine.ChangesSince("db1", "table1", sinceTx)
+		require.NoError(t, err)
+		defer r.Close()
+
+		cols, err := r.Columns()
+		require.NoError(t, err)
+		require.Len(t, cols, 4)
+		require.Equal(t, ChangeTxCol, cols[2].Column)
+		require.Equal(t, ChangeOpCol, cols[3].Column)
+
+		var changes []change
+
+		for {
+			row, err := r.Read()
+			if err == ErrNoMoreRows {
+				break
+			}
+			require.NoError(t, err)
+
+			changes = append(changes, change{
+				tx:    row.Values[cols[2].Selector()].Value().(int64),
+				op:    row.Values[cols[3].Selector()].Value().(string),
+				id:    row.Values[cols[0].Selector()].Value().(int64),
+				title: row.Values[cols[1].Selector()].Value().(string),
+			})
+		}
+
+		return changes
+	}
+
+	require.Equal(t, []change{
+		{tx: int64(insertTx), op: ChangeInsert, id: 1, title: "title1"},
+		{tx: int64(insertTx), op: ChangeInsert, id: 2, title: "title2"},
+		{tx: int64(updateTx), op: ChangeUpdate, id: 1, title: "title11"},
+		{tx: int64(deleteTx), op: ChangeDelete, id: 2, title: "title2"},
+		{tx: int64(reinsertTx), op: ChangeInsert, id: 2, title: "title22"},
+	}, changesSince(0))
+
+	require.Equal(t, []change{
+		{tx: int64(deleteTx), op: ChangeDelete, id: 2, title: "title2"},
+		{tx: int64(reinsertTx), op: ChangeInsert, id: 2, title: "title22"},
+	}, changesSince(updateTx))
+
+	require.Empty(t, changesSince(reinsertTx))
+	require.Empty(t, changesSince(reinsertTx+10))
 
 	err = engine.Close()
 	require.NoError(t, err)
+
+	_, err = engine.ChangesSince("db1", "table1", 0)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
 }
 
-func TestQueryWithInClause(t *testing.T) {
-	catalogStore, err := store.Open("catalog_where_in", store.DefaultOptions())
+func TestIndexCardinality(t *testing.T) {
+	catalogStore, err := store.Open("catalog_cardinality", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_where_in")
+	defer os.RemoveAll("catalog_cardinality")
 
-	dataStore, err := store.Open("sqldata_where_in", store.DefaultOptions())
+	dataStore, err := store.Open("sqldata_cardinality", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_where_in")
+	defer os.RemoveAll("sqldata_cardinality")
 
 	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
 
+	_, err = engine.IndexCardinality("db1", "table1", []string{"id"})
+	require.ErrorIs(t, err, ErrCatalogNotReady)
+
 	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
 	require.NoError(t, err)
 
 	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[50], active BOOLEAN, PRIMARY KEY id)", nil, true)
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table1 (
+			id INTEGER AUTO_INCREMENT,
+			country VARCHAR[8],
+			city VARCHAR[16],
+			active BOOLEAN,
+			PRIMARY KEY id
+		)`, nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE INDEX ON table1(title)", nil, true)
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(country)", nil, true)
 	require.NoError(t, err)
 
-	rowCount := 10
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(country, city)", nil, true)
+	require.NoError(t, err)
 
-	for i := 0; i < rowCount; i++ {
-		_, err = engine.ExecStmt(fmt.Sprintf(`
-			INSERT INTO table1 (id, title, active) VALUES (%d, 'title%d', %v)
-		`, i, i, i%2 == 0), nil, true)
-		require.NoError(t, err)
-	}
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(active)", nil, true)
+	require.NoError(t, err)
 
-	inListExp := &InListExp{}
-	require.False(t, inListExp.isConstant())
+	cardinality, err := engine.IndexCardinality("db1", "table1", []string{"country"})
+	require.NoError(t, err)
+	require.Zero(t, cardinality)
 
-	t.Run("infer parameters without parameters should return an empty list", func(t *testing.T) {
-		params, err := engine.InferParameters("SELECT id, title, active FROM table1 WHERE title IN ('title0', 'title1')")
+	_, err = engine.ExecStmt(`
+		INSERT INTO table1 (country, city, active) VALUES
+			('AR', 'Cordoba', true),
+			('AR', 'Rosario', false),
+			('AR', 'Cordoba', true),
+			('IT', 'Milan', true),
+			('IT', 'Rome', true),
+			('ES', 'Madrid', false)
+		`, nil, true)
+	require.NoError(t, err)
+
+	t.Run("distinct values should be counted", func(t *testing.T) {
+		cardinality, err := engine.IndexCardinality("db1", "table1", []string{"id"})
 		require.NoError(t, err)
-		require.Empty(t, params)
-	})
+		require.Equal(t, uint64(6), cardinality)
 
-	t.Run("infer inference with wrong types should return an error", func(t *testing.T) {
-		_, err := engine.InferParameters("SELECT id, title, active FROM table1 WHERE 100 + title IN ('title0', 'title1')")
-		require.ErrorIs(t, err, ErrInvalidTypes)
-	})
+		cardinality, err = engine.IndexCardinality("db1", "table1", []string{"country"})
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), cardinality)
 
-	t.Run("infer inference with valid types should succeed", func(t *testing.T) {
-		params, err := engine.InferParameters("SELECT id, title, active FROM table1 WHERE active AND title IN ('title0', 'title1')")
+		cardinality, err = engine.IndexCardinality("db1", "table1", []string{"country", "city"})
 		require.NoError(t, err)
-		require.Empty(t, params)
-	})
+		require.Equal(t, uint64(5), cardinality)
 
-	t.Run("infer parameters should return matching type", func(t *testing.T) {
-		params, err := engine.InferParameters("SELECT id, title, active FROM table1 WHERE title IN (@param0, @param1)")
+		cardinality, err = engine.IndexCardinality("db1", "table1", []string{"active"})
 		require.NoError(t, err)
-		require.Len(t, params, 2)
-		require.Equal(t, VarcharType, params["param0"])
-		require.Equal(t, VarcharType, params["param1"])
+		require.Equal(t, uint64(2), cardinality)
 	})
 
-	t.Run("infer parameters with type conflicts should return an error", func(t *testing.T) {
-		_, err := engine.InferParameters("SELECT id, title, active FROM table1 WHERE active = @param1 and title IN (@param0, @param1)")
-		require.ErrorIs(t, err, ErrInferredMultipleTypes)
-	})
+	t.Run("updated and deleted rows should not be counted", func(t *testing.T) {
+		_, err = engine.ExecStmt("UPDATE table1 SET country = 'AR' WHERE city = 'Madrid'", nil, true)
+		require.NoError(t, err)
 
-	t.Run("infer parameters with unexistent column should return an error", func(t *testing.T) {
-		_, err := engine.InferParameters("SELECT id, title, active FROM table1 WHERE invalidColumn IN ('title1', 'title2')")
-		require.ErrorIs(t, err, ErrColumnDoesNotExist)
-	})
+		_, err = engine.ExecStmt("DELETE FROM table1 WHERE city = 'Milan'", nil, true)
+		require.NoError(t, err)
 
-	t.Run("in clause with invalid column should return an error", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT id, title, active FROM table1 WHERE invalidColumn IN (1, 2)", nil, true)
+		cardinality, err := engine.IndexCardinality("db1", "table1", []string{"id"})
 		require.NoError(t, err)
+		require.Equal(t, uint64(5), cardinality)
 
-		_, err = r.Read()
-		require.ErrorIs(t, err, ErrColumnDoesNotExist)
+		cardinality, err = engine.IndexCardinality("db1", "table1", []string{"country"})
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), cardinality)
 
-		err = r.Close()
+		cardinality, err = engine.IndexCardinality("db1", "table1", []string{"country", "city"})
 		require.NoError(t, err)
+		require.Equal(t, uint64(4), cardinality)
 	})
 
-	t.Run("in clause with invalid type should return an error", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT id, title, active FROM table1 WHERE title IN (1, 2)", nil, true)
-		require.NoError(t, err)
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := engine.IndexCardinality("db1", "table2", []string{"id"})
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
 
-		_, err = r.Read()
-		require.ErrorIs(t, err, ErrNotComparableValues)
+		_, err = engine.IndexCardinality("db1", "table1", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
 
-		err = r.Close()
-		require.NoError(t, err)
+		_, err = engine.IndexCardinality("db1", "table1", []string{"population"})
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+		_, err = engine.IndexCardinality("db1", "table1", []string{"city"})
+		require.ErrorIs(t, err, ErrNoAvailableIndex)
+
+		_, err = engine.IndexCardinality("db1", "table1", []string{"city", "country"})
+		require.ErrorIs(t, err, ErrNoAvailableIndex)
 	})
 
-	t.Run("in clause should succeed reading two rows", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT id, title, active FROM table1 WHERE title IN ('title0', 'title1')", nil, true)
-		require.NoError(t, err)
+	err = engine.Close()
+	require.NoError(t, err)
+
+	_, err = engine.IndexCardinality("db1", "table1", []string{"id"})
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
+func TestTableStorageStats(t *testing.T) {
+	catalogStore, err := store.Open("catalog_storagestats", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_storagestats")
+
+	dataStore, err := store.Open("sqldata_storagestats", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_storagestats")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
 
-		for i := 0; i < 2; i++ {
-			row, err := r.Read()
-			require.NoError(t, err)
-			require.NotNil(t, row)
-			require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
-		}
+	_, _, _, err = engine.TableStorageStats("db1", "table1")
+	require.ErrorIs(t, err, ErrCatalogNotReady)
 
-		err = r.Close()
-		require.NoError(t, err)
-	})
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
 
-	t.Run("in clause with invalid values should return an error", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT id, title, active FROM table1 WHERE title IN ('title0', true + 'title1')", nil, true)
-		require.NoError(t, err)
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
 
-		_, err = r.Read()
-		require.ErrorIs(t, err, ErrInvalidValue)
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR[16], PRIMARY KEY id);
+		CREATE TABLE table2 (id INTEGER AUTO_INCREMENT, title VARCHAR[16], PRIMARY KEY id);
+		CREATE UNIQUE INDEX ON table2(title);
+	`, nil, true)
+	require.NoError(t, err)
 
-		err = r.Close()
+	_, _, _, err = engine.TableStorageStats("db1", "table3")
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	t.Run("empty tables should take no storage", func(t *testing.T) {
+		rows, keyBytes, valueBytes, err := engine.TableStorageStats("db1", "table1")
 		require.NoError(t, err)
+		require.Zero(t, rows)
+		require.Zero(t, keyBytes)
+		require.Zero(t, valueBytes)
 	})
 
-	t.Run("in clause should succeed reading rows NOT included in 'IN' clause", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT id, title, active FROM table1 WHERE title NOT IN ('title1', 'title0')", nil, true)
+	for _, table := range []string{"table1", "table2"} {
+		_, err = engine.ExecStmt(fmt.Sprintf("INSERT INTO %s (title) VALUES ('title1'), ('title2'), ('title3')", table), nil, true)
 		require.NoError(t, err)
+	}
 
-		for i := 2; i < rowCount; i++ {
-			row, err := r.Read()
-			require.NoError(t, err)
-			require.NotNil(t, row)
-			require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
-		}
+	t.Run("populated tables should account rows and index entries", func(t *testing.T) {
+		rows1, keyBytes1, valueBytes1, err := engine.TableStorageStats("db1", "table1")
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), rows1)
+		require.NotZero(t, keyBytes1)
+		require.NotZero(t, valueBytes1)
 
-		err = r.Close()
+		rows2, keyBytes2, valueBytes2, err := engine.TableStorageStats("db1", "table2")
 		require.NoError(t, err)
+		require.Equal(t, uint64(3), rows2)
+		require.Greater(t, keyBytes2, keyBytes1)
+		require.Greater(t, valueBytes2, valueBytes1)
 	})
 
-	t.Run("in clause should succeed reading using 'IN' clause in join condition", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT * FROM table1 as t1 INNER JOIN table1 as t2 ON t1.title IN (t2.title) ORDER BY title", nil, true)
+	t.Run("deleted rows should not be accounted", func(t *testing.T) {
+		_, err = engine.ExecStmt("DELETE FROM table1", nil, true)
 		require.NoError(t, err)
 
-		for i := 0; i < rowCount; i++ {
-			row, err := r.Read()
-			require.NoError(t, err)
-			require.NotNil(t, row)
-			require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "t1", "title")].Value())
-		}
-
-		err = r.Close()
+		rows, keyBytes, valueBytes, err := engine.TableStorageStats("db1", "table1")
 		require.NoError(t, err)
+		require.Zero(t, rows)
+		require.Zero(t, keyBytes)
+		require.Zero(t, valueBytes)
 	})
 
 	err = engine.Close()
 	require.NoError(t, err)
+
+	_, _, _, err = engine.TableStorageStats("db1", "table1")
+	require.ErrorIs(t, err, ErrAlreadyClosed)
 }
 
 func TestAggregations(t *testing.T) {
@@ -2843,6 +8090,220 @@ func TestAggregations(t *testing.T) {
 	err = r.Close()
 	require.NoError(t, err)
 
+	t.Run("aggregations within expressions are rejected", func(t *testing.T) {
+		for _, q := range []string{
+			"SELECT COUNT() + 1 FROM table1",
+			"SELECT age, COUNT() + 1 FROM table1 USE INDEX ON age GROUP BY age",
+			"SELECT age, NOT MAX(active) FROM table1 USE INDEX ON age GROUP BY age",
+			"SELECT MAX(age) > 40 AS old FROM table1",
+		} {
+			_, _, err = engine.QueryAll(q, nil)
+			require.ErrorIs(t, err, ErrLimitedAggregation, q)
+		}
+	})
+
+	t.Run("non-aggregated projections must be grouped", func(t *testing.T) {
+		for _, q := range []string{
+			"SELECT id, COUNT() FROM table1",
+			"SELECT COUNT(), age + 1 FROM table1",
+			"SELECT age, id, COUNT() FROM table1 USE INDEX ON age GROUP BY age",
+		} {
+			_, _, err = engine.QueryAll(q, nil)
+			require.ErrorIs(t, err, ErrColumnNotInGroupBy, q)
+		}
+
+		rows, _, err := engine.QueryAll("SELECT COUNT() AS c, 1 + 1 AS two FROM table1", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, int64(rowCount), rows[0].Values[EncodeSelector("", "db1", "table1", "c")].Value())
+		require.Equal(t, int64(2), rows[0].Values[EncodeSelector("", "db1", "table1", "two")].Value())
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestNullAggregations(t *testing.T) {
+	catalogStore, err := store.Open("catalog_nullagg", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_nullagg")
+
+	dataStore, err := store.Open("sqldata_nullagg", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_nullagg")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix).WithNullAggregations(true))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table1 (id INTEGER, title VARCHAR, age INTEGER, active BOOLEAN, PRIMARY KEY id);
+		INSERT INTO table1 (id, title, age, active) VALUES (1, 'title1', 31, true), (2, 'title2', 33, false);
+	`, nil, true)
+	require.NoError(t, err)
+
+	t.Run("aggregations other than COUNT should be NULL over an empty set", func(t *testing.T) {
+		rows, cols, err := engine.QueryAll(`
+			SELECT COUNT(), COUNT(DISTINCT age), SUM(age), MIN(title), MAX(age), AVG(age), SUM(DISTINCT age), BOOL_AND(active)
+			FROM table1 WHERE id > 2`, nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Len(t, cols, 8)
+
+		require.Equal(t, int64(0), rows[0].Values[cols[0].Selector()].Value())
+		require.Equal(t, int64(0), rows[0].Values[cols[1].Selector()].Value())
+
+		for _, col := range cols[2:] {
+			val := rows[0].Values[col.Selector()]
+
+			require.IsType(t, &NullValue{}, val, col.Selector())
+			require.Nil(t, val.Value(), col.Selector())
+			require.Equal(t, col.Type, val.Type(), col.Selector())
+		}
+	})
+
+	t.Run("aggregations should not be affected over a non-empty set", func(t *testing.T) {
+		rows, cols, err := engine.QueryAll("SELECT COUNT(), SUM(age), MIN(title), MAX(age), AVG(age) FROM table1", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+
+		require.Equal(t, int64(2), rows[0].Values[cols[0].Selector()].Value())
+		require.Equal(t, int64(64), rows[0].Values[cols[1].Selector()].Value())
+		require.Equal(t, "title1", rows[0].Values[cols[2].Selector()].Value())
+		require.Equal(t, int64(33), rows[0].Values[cols[3].Selector()].Value())
+		require.Equal(t, int64(32), rows[0].Values[cols[4].Selector()].Value())
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestConstantExpressions(t *testing.T) {
+	catalogStore, err := store.Open("catalog_constant_exps", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_constant_exps")
+
+	dataStore, err := store.Open("sqldata_constant_exps", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_constant_exps")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id) VALUES (1), (2), (3)", nil, true)
+	require.NoError(t, err)
+
+	rows, _, err := engine.QueryAll("SELECT 1 + 2, id, id * (2 + @n) AS x FROM table1 WHERE id > 5 - 4 AND NOT false", map[string]interface{}{"n": 1})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	for i, row := range rows {
+		id := int64(i + 2)
+		require.Equal(t, int64(3), row.Values[EncodeSelector("", "db1", "table1", "col0")].Value())
+		require.Equal(t, id, row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+		require.Equal(t, id*3, row.Values[EncodeSelector("", "db1", "table1", "x")].Value())
+	}
+
+	for n := 1; n <= 3; n++ {
+		rows, _, err = engine.QueryAll("SELECT id + @n AS x FROM table1 WHERE id < 1 + @n", map[string]interface{}{"n": n})
+		require.NoError(t, err)
+		require.Len(t, rows, n)
+		require.Equal(t, int64(1+n), rows[0].Values[EncodeSelector("", "db1", "table1", "x")].Value())
+	}
+
+	_, _, err = engine.QueryAll("SELECT id / (1 - 1) FROM table1", nil)
+	require.ErrorIs(t, err, ErrDivisionByZero)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestArithmeticOverflow(t *testing.T) {
+	catalogStore, err := store.Open("catalog_overflow", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_overflow")
+
+	dataStore, err := store.Open("sqldata_overflow", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_overflow")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, amount INTEGER, PRIMARY KEY id);
+		INSERT INTO table1 (amount) VALUES (@lowest), (@highest), (-10), (NULL);
+	`, map[string]interface{}{"lowest": int64(math.MinInt64), "highest": int64(math.MaxInt64)}, true)
+	require.NoError(t, err)
+
+	t.Run("abs should return the absolute value", func(t *testing.T) {
+		rows, _, err := engine.QueryAll("SELECT ABS(amount) AS a FROM table1 WHERE id > 1", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 3)
+		require.Equal(t, int64(math.MaxInt64), rows[0].Values[EncodeSelector("", "db1", "table1", "a")].Value())
+		require.Equal(t, int64(10), rows[1].Values[EncodeSelector("", "db1", "table1", "a")].Value())
+		require.Nil(t, rows[2].Values[EncodeSelector("", "db1", "table1", "a")].Value())
+
+		rows, _, err = engine.QueryAll("SELECT ABS(@n + 1) AS a FROM table1 WHERE id = 1", map[string]interface{}{"n": int64(math.MinInt64)})
+		require.NoError(t, err)
+		require.Equal(t, int64(math.MaxInt64), rows[0].Values[EncodeSelector("", "db1", "table1", "a")].Value())
+	})
+
+	t.Run("abs and negation of the lowest integer should overflow", func(t *testing.T) {
+		for _, q := range []string{
+			"SELECT ABS(amount) FROM table1",
+			"SELECT -amount FROM table1",
+			"SELECT ABS(@n) FROM table1",
+			"SELECT -@n FROM table1",
+		} {
+			_, _, err = engine.QueryAll(q, map[string]interface{}{"n": int64(math.MinInt64)})
+			require.ErrorIs(t, err, ErrArithmeticOverflow, q)
+		}
+
+		rows, _, err := engine.QueryAll("SELECT -amount AS neg FROM table1 WHERE id = 2", nil)
+		require.NoError(t, err)
+		require.Equal(t, int64(-math.MaxInt64), rows[0].Values[EncodeSelector("", "db1", "table1", "neg")].Value())
+	})
+
+	t.Run("results out of range should overflow", func(t *testing.T) {
+		for _, q := range []string{
+			"SELECT amount - 1 FROM table1 WHERE id = 1",
+			"SELECT amount + 1 FROM table1 WHERE id = 2",
+			"SELECT amount * 2 FROM table1 WHERE id = 2",
+			"SELECT amount * -1 FROM table1 WHERE id = 1",
+			"SELECT amount / -1 FROM table1 WHERE id = 1",
+		} {
+			_, _, err = engine.QueryAll(q, nil)
+			require.ErrorIs(t, err, ErrArithmeticOverflow, q)
+		}
+	})
+
+	_, _, err = engine.QueryAll("SELECT ABS(amount, 1) FROM table1", nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = engine.InferParameters("SELECT ABS(@n) FROM table1")
+	require.NoError(t, err)
+
 	err = engine.Close()
 	require.NoError(t, err)
 }
@@ -2903,7 +8364,217 @@ func TestCount(t *testing.T) {
 	_, err = r.Read()
 	require.ErrorIs(t, err, ErrNoMoreRows)
 
-	err = r.Close()
+	err = r.Close()
+	require.NoError(t, err)
+
+	t.Run("distinct counting", func(t *testing.T) {
+		// COUNT(DISTINCT col) counts distinct values while SELECT DISTINCT deduplicates the aggregated rows
+		rows, _, err := engine.QueryAll("SELECT COUNT() AS c, COUNT(DISTINCT val1) AS d FROM t1", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, int64(30), rows[0].Values["(db1.t1.c)"].Value())
+		require.Equal(t, int64(3), rows[0].Values["(db1.t1.d)"].Value())
+
+		rows, _, err = engine.QueryAll("SELECT COUNT(DISTINCT val1) AS d FROM t1 GROUP BY val1 ORDER BY val1", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 3)
+
+		for _, row := range rows {
+			require.Equal(t, int64(1), row.Values["(db1.t1.d)"].Value())
+		}
+
+		rows, _, err = engine.QueryAll("SELECT DISTINCT COUNT() AS c FROM t1 GROUP BY val1 ORDER BY val1", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, int64(10), rows[0].Values["(db1.t1.c)"].Value())
+
+		rows, _, err = engine.QueryAll("SELECT COUNT(DISTINCT val1) AS d FROM t1 WHERE val1 > 10", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, int64(0), rows[0].Values["(db1.t1.d)"].Value())
+
+		_, _, err = engine.QueryAll("SELECT COUNT(DISTINCT val2) FROM t1", nil)
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+		_, _, err = engine.QueryAll("SELECT MAX(DISTINCT val1) FROM t1", nil)
+		require.ErrorIs(t, err, ErrLimitedAggregation)
+	})
+}
+
+func TestDistinctAggregations(t *testing.T) {
+	catalogStore, err := store.Open("catalog_distinct_agg", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_distinct_agg")
+
+	dataStore, err := store.Open("sqldata_distinct_agg", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_distinct_agg")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE t1(id INTEGER AUTO_INCREMENT, category VARCHAR[8], amount INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON t1(category)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		INSERT INTO t1(category, amount) VALUES ('a', 10), ('a', 10), ('a', 20), ('b', 5), ('b', 5), ('b', 5)
+	`, nil, true)
+	require.NoError(t, err)
+
+	t.Run("distinct values should be aggregated once", func(t *testing.T) {
+		rows, _, err := engine.QueryAll(`
+			SELECT SUM(amount) AS s, SUM(DISTINCT amount) AS ds, AVG(amount) AS a, AVG(DISTINCT amount) AS da
+			FROM t1`, nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, int64(55), rows[0].Values["(db1.t1.s)"].Value())
+		require.Equal(t, int64(35), rows[0].Values["(db1.t1.ds)"].Value())
+		require.Equal(t, int64(9), rows[0].Values["(db1.t1.a)"].Value())
+		require.Equal(t, int64(11), rows[0].Values["(db1.t1.da)"].Value())
+	})
+
+	t.Run("distinct values should be tracked per group", func(t *testing.T) {
+		rows, _, err := engine.QueryAll(`
+			SELECT category, SUM(amount) AS s, SUM(DISTINCT amount) AS ds, AVG(amount) AS a, AVG(DISTINCT amount) AS da
+			FROM t1
+			GROUP BY category
+			ORDER BY category`, nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+
+		require.Equal(t, "a", rows[0].Values["(db1.t1.category)"].Value())
+		require.Equal(t, int64(40), rows[0].Values["(db1.t1.s)"].Value())
+		require.Equal(t, int64(30), rows[0].Values["(db1.t1.ds)"].Value())
+		require.Equal(t, int64(13), rows[0].Values["(db1.t1.a)"].Value())
+		require.Equal(t, int64(15), rows[0].Values["(db1.t1.da)"].Value())
+
+		require.Equal(t, "b", rows[1].Values["(db1.t1.category)"].Value())
+		require.Equal(t, int64(15), rows[1].Values["(db1.t1.s)"].Value())
+		require.Equal(t, int64(5), rows[1].Values["(db1.t1.ds)"].Value())
+		require.Equal(t, int64(5), rows[1].Values["(db1.t1.a)"].Value())
+		require.Equal(t, int64(5), rows[1].Values["(db1.t1.da)"].Value())
+	})
+
+	t.Run("null values should not be aggregated", func(t *testing.T) {
+		_, err = engine.ExecStmt("INSERT INTO t1(category, amount) VALUES ('a', NULL), ('c', NULL)", nil, true)
+		require.NoError(t, err)
+
+		rows, _, err := engine.QueryAll(`
+			SELECT COUNT(DISTINCT amount) AS dc, SUM(DISTINCT amount) AS ds, AVG(DISTINCT amount) AS da
+			FROM t1
+			GROUP BY category
+			ORDER BY category`, nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 3)
+
+		for i, expected := range [][]int64{{2, 30, 15}, {1, 5, 5}, {0, 0, 0}} {
+			require.Equal(t, expected[0], rows[i].Values["(db1.t1.dc)"].Value())
+			require.Equal(t, expected[1], rows[i].Values["(db1.t1.ds)"].Value())
+			require.Equal(t, expected[2], rows[i].Values["(db1.t1.da)"].Value())
+		}
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestCountFromIndexKeys(t *testing.T) {
+	catalogStore, err := store.Open("catalog_count_keys", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_count_keys")
+
+	dataStore, err := store.Open("sqldata_count_keys", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_count_keys")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE t1(id INTEGER, val1 INTEGER, title VARCHAR[10], note VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON t1(val1)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON t1(title)", nil, true)
+	require.NoError(t, err)
+
+	for i := 0; i < 30; i++ {
+		params := map[string]interface{}{"id": i, "val1": i % 4, "title": fmt.Sprintf("title%d", i)}
+
+		_, err = engine.ExecStmt("INSERT INTO t1(id, val1, title, note) VALUES(@id, @val1, @title, 'note')", params, true)
+		require.NoError(t, err)
+	}
+
+	_, err = engine.ExecStmt("DELETE FROM t1 WHERE id = 10", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("UPDATE t1 SET val1 = 3 WHERE id < 4", nil, true)
+	require.NoError(t, err)
+
+	count := func(q string, params map[string]interface{}, keysOnly bool) int64 {
+		r, err := engine.QueryStmt(q, params, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		require.Equal(t, keysOnly, r.ScanSpecs().keysOnly, q)
+
+		row, err := r.Read()
+		require.NoError(t, err)
+
+		for _, v := range row.Values {
+			return v.Value().(int64)
+		}
+
+		return -1
+	}
+
+	for _, c := range []struct {
+		cond   string
+		params map[string]interface{}
+		index  string
+	}{
+		{cond: "id > 4"},
+		{cond: "id >= @lowest AND id < 20", params: map[string]interface{}{"lowest": 5}},
+		{cond: "id > 100"},
+		{cond: "val1 = 3", index: "val1"},
+		{cond: "val1 >= 1 AND val1 < 3", index: "val1"},
+		{cond: "title LIKE '^title1'", index: "title"},
+		{cond: "NOT (title = 'title25')", index: "title"},
+	} {
+		indexOn := ""
+		if c.index != "" {
+			indexOn = " USE INDEX ON " + c.index
+		}
+
+		counted := count("SELECT COUNT() FROM t1"+indexOn+" WHERE "+c.cond, c.params, true)
+		scanned := count("SELECT COUNT() FROM t1"+indexOn+" WHERE "+c.cond+" AND note = 'note'", c.params, false)
+
+		require.Equal(t, scanned, counted, c.cond)
+	}
+
+	require.Equal(t, int64(29), count("SELECT COUNT() FROM t1 USE INDEX ON title", nil, true))
+	require.Equal(t, int64(10), count("SELECT COUNT() FROM t1 AS t USE INDEX ON val1 WHERE t.val1 = 3", nil, true))
+
+	// conditions over non-indexed columns still require rows to be fetched
+	require.Equal(t, int64(29), count("SELECT COUNT() FROM t1 WHERE id >= 0 AND note = 'note'", nil, false))
+
+	err = engine.Close()
 	require.NoError(t, err)
 }
 
@@ -3038,10 +8709,277 @@ func TestGroupByHaving(t *testing.T) {
 	err = r.Close()
 	require.NoError(t, err)
 
+	_, err = engine.QueryStmt("SELECT title, COUNT() FROM table1 GROUP BY active ORDER BY active", nil, true)
+	require.ErrorIs(t, err, ErrColumnNotInGroupBy)
+
+	_, err = engine.QueryStmt("SELECT active AND title = 'title1', COUNT() FROM table1 GROUP BY active ORDER BY active", nil, true)
+	require.ErrorIs(t, err, ErrColumnNotInGroupBy)
+
+	_, err = engine.QueryStmt("SELECT active + 1, COUNT() FROM table1 GROUP BY active ORDER BY active", nil, true)
+	require.ErrorIs(t, err, ErrInvalidTypes)
+
+	r, err = engine.QueryStmt(`
+		SELECT active, active OR false, NOT active AS inactive, COUNT() AS c
+		FROM table1
+		GROUP BY active
+		ORDER BY active`, nil, true)
+	require.NoError(t, err)
+
+	cols, err := r.Columns()
+	require.NoError(t, err)
+	require.Len(t, cols, 4)
+	require.Equal(t, "col1", cols[1].Column)
+	require.Equal(t, BooleanType, cols[1].Type)
+	require.Equal(t, "inactive", cols[2].Column)
+	require.Equal(t, BooleanType, cols[2].Type)
+
+	for i := 0; i < 2; i++ {
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Len(t, row.Values, 4)
+
+		active := i == 1
+
+		require.Equal(t, active, row.Values[EncodeSelector("", "db1", "table1", "active")].Value())
+		require.Equal(t, active, row.Values[EncodeSelector("", "db1", "table1", "col1")].Value())
+		require.Equal(t, !active, row.Values[EncodeSelector("", "db1", "table1", "inactive")].Value())
+		require.Equal(t, int64(rowCount/2), row.Values[EncodeSelector("", "db1", "table1", "c")].Value())
+	}
+
+	_, err = r.Read()
+	require.Equal(t, ErrNoMoreRows, err)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestGroupByRollup(t *testing.T) {
+	catalogStore, err := store.Open("catalog_rollup", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_rollup")
+
+	dataStore, err := store.Open("sqldata_rollup", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_rollup")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE sales (id INTEGER AUTO_INCREMENT, region VARCHAR[8], city VARCHAR[16], amount INTEGER, PRIMARY KEY id);
+		CREATE INDEX ON sales(region, city);
+
+		INSERT INTO sales (region, city, amount) VALUES
+			('AR', 'Rosario', 10),
+			('IT', 'Milan', 20),
+			('AR', 'Cordoba', 30),
+			('AR', 'Rosario', 40),
+			('IT', 'Milan', 50),
+			('ES', 'Madrid', 60);
+	`, nil, true)
+	require.NoError(t, err)
+
+	// rows are given as region, city, count and total, NULL values as nil
+	queryRows := func(t *testing.T, q string) [][]interface{} {
+		rows, cols, err := engine.QueryAll(q, nil)
+		require.NoError(t, err)
+
+		res := make([][]interface{}, len(rows))
+
+		for i, row := range rows {
+			for _, col := range cols {
+				res[i] = append(res[i], row.Values[col.Selector()].Value())
+			}
+		}
+
+		return res
+	}
+
+	t.Run("subtotals should be returned after the groups they enclose", func(t *testing.T) {
+		rows := queryRows(t, `
+			SELECT region, city, COUNT() AS c, SUM(amount) AS total
+			FROM sales
+			GROUP BY ROLLUP(region, city)
+			ORDER BY region, city`)
+
+		require.Equal(t, [][]interface{}{
+			{"AR", "Cordoba", int64(1), int64(30)},
+			{"AR", "Rosario", int64(2), int64(50)},
+			{"AR", nil, int64(3), int64(80)},
+			{"ES", "Madrid", int64(1), int64(60)},
+			{"ES", nil, int64(1), int64(60)},
+			{"IT", "Milan", int64(2), int64(70)},
+			{"IT", nil, int64(2), int64(70)},
+			{nil, nil, int64(6), int64(210)},
+		}, rows)
+	})
+
+	t.Run("a single rolled up column should add the grand total", func(t *testing.T) {
+		rows := queryRows(t, `
+			SELECT region, COUNT() AS c, MAX(amount) AS m
+			FROM sales
+			GROUP BY ROLLUP(region)
+			ORDER BY region`)
+
+		require.Equal(t, [][]interface{}{
+			{"AR", int64(3), int64(40)},
+			{"ES", int64(1), int64(60)},
+			{"IT", int64(2), int64(50)},
+			{nil, int64(6), int64(60)},
+		}, rows)
+	})
+
+	t.Run("subtotals should be filtered by the having clause", func(t *testing.T) {
+		rows := queryRows(t, `
+			SELECT region, city, SUM(amount) AS total
+			FROM sales
+			GROUP BY ROLLUP(region, city)
+			HAVING SUM(amount) >= 70
+			ORDER BY region, city`)
+
+		require.Equal(t, [][]interface{}{
+			{"AR", nil, int64(80)},
+			{"IT", "Milan", int64(70)},
+			{"IT", nil, int64(70)},
+			{nil, nil, int64(210)},
+		}, rows)
+	})
+
+	t.Run("grouping by several columns should not add subtotals", func(t *testing.T) {
+		rows := queryRows(t, `
+			SELECT region, city, COUNT() AS c
+			FROM sales
+			GROUP BY region, city
+			ORDER BY region, city`)
+
+		require.Equal(t, [][]interface{}{
+			{"AR", "Cordoba", int64(1)},
+			{"AR", "Rosario", int64(2)},
+			{"ES", "Madrid", int64(1)},
+			{"IT", "Milan", int64(2)},
+		}, rows)
+	})
+
+	t.Run("rows should be sorted by several indexed columns in the same direction", func(t *testing.T) {
+		rows := queryRows(t, "SELECT region, city, amount FROM sales ORDER BY region DESC, city DESC")
+
+		require.Equal(t, [][]interface{}{
+			{"IT", "Milan", int64(50)},
+			{"IT", "Milan", int64(20)},
+			{"ES", "Madrid", int64(60)},
+			{"AR", "Rosario", int64(40)},
+			{"AR", "Rosario", int64(10)},
+			{"AR", "Cordoba", int64(30)},
+		}, rows)
+
+		_, err := engine.QueryStmt("SELECT region, city FROM sales ORDER BY region, city DESC", nil, true)
+		require.ErrorIs(t, err, ErrLimitedOrderBy)
+	})
+
+	t.Run("rolled up columns should be sorted as grouped", func(t *testing.T) {
+		_, err := engine.QueryStmt("SELECT city, COUNT() FROM sales GROUP BY ROLLUP(city)", nil, true)
+		require.ErrorIs(t, err, ErrLimitedGroupBy)
+
+		_, err = engine.QueryStmt("SELECT region, city, COUNT() FROM sales GROUP BY ROLLUP(city, region) ORDER BY region, city", nil, true)
+		require.ErrorIs(t, err, ErrLimitedGroupBy)
+	})
+
 	err = engine.Close()
 	require.NoError(t, err)
 }
 
+func TestBoolAggregations(t *testing.T) {
+	catalogStore, err := store.Open("catalog_bool_agg", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_bool_agg")
+
+	dataStore, err := store.Open("sqldata_bool_agg", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_bool_agg")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, region INTEGER, active BOOLEAN, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(region)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		INSERT INTO table1 (id, region, active)
+		VALUES
+			(1, 1, true), (2, 1, true),
+			(3, 2, true), (4, 2, false), (5, 2, NULL),
+			(6, 3, false), (7, 3, NULL),
+			(8, 4, NULL)`, nil, true)
+	require.NoError(t, err)
+
+	rows, _, err := engine.QueryAll(`
+		SELECT region, BOOL_AND(active) AS all_active, BOOL_OR(active) AS any_active
+		FROM table1
+		GROUP BY region
+		ORDER BY region`, nil)
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+
+	expected := []struct {
+		region    int64
+		allActive bool
+		anyActive bool
+	}{
+		{region: 1, allActive: true, anyActive: true},
+		{region: 2, allActive: false, anyActive: true},
+		{region: 3, allActive: false, anyActive: false},
+		{region: 4, allActive: true, anyActive: false},
+	}
+
+	for i, row := range rows {
+		require.Equal(t, expected[i].region, row.Values[EncodeSelector("", "db1", "table1", "region")].Value())
+		require.Equal(t, expected[i].allActive, row.Values[EncodeSelector("", "db1", "table1", "all_active")].Value())
+		require.Equal(t, expected[i].anyActive, row.Values[EncodeSelector("", "db1", "table1", "any_active")].Value())
+	}
+
+	rows, _, err = engine.QueryAll(`
+		SELECT region, BOOL_OR(active)
+		FROM table1
+		GROUP BY region
+		HAVING BOOL_OR(active)
+		ORDER BY region`, nil)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	require.Equal(t, int64(1), rows[0].Values[EncodeSelector("", "db1", "table1", "region")].Value())
+	require.Equal(t, int64(2), rows[1].Values[EncodeSelector("", "db1", "table1", "region")].Value())
+
+	rows, _, err = engine.QueryAll("SELECT BOOL_AND(active), BOOL_OR(active) FROM table1 WHERE id > 10", nil)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, true, rows[0].Values[EncodeSelector("", "db1", "table1", "col0")].Value())
+	require.Equal(t, false, rows[0].Values[EncodeSelector("", "db1", "table1", "col1")].Value())
+
+	_, _, err = engine.QueryAll("SELECT BOOL_AND(id) FROM table1", nil)
+	require.ErrorIs(t, err, ErrNotComparableValues)
+
+	params, err := engine.InferParameters("SELECT region, BOOL_AND(active) FROM table1 GROUP BY region HAVING BOOL_AND(active) = @p ORDER BY region")
+	require.NoError(t, err)
+	require.Equal(t, map[string]SQLValueType{"p": BooleanType}, params)
+}
+
 func TestJoins(t *testing.T) {
 	catalogStore, err := store.Open("catalog_innerjoin", store.DefaultOptions())
 	require.NoError(t, err)
@@ -3166,6 +9104,135 @@ func TestJoins(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestJoinsSortedByJoinedTable(t *testing.T) {
+	catalogStore, err := store.Open("catalog_innerjoin_sorted", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_innerjoin_sorted")
+
+	dataStore, err := store.Open("sqldata_innerjoin_sorted", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_innerjoin_sorted")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table1 (id INTEGER, title VARCHAR, fkid1 INTEGER, fkid2 INTEGER, PRIMARY KEY id);
+		CREATE TABLE table2 (id INTEGER, amount INTEGER, PRIMARY KEY id);
+		CREATE INDEX ON table2(amount);
+		CREATE TABLE table3 (id INTEGER, age INTEGER, PRIMARY KEY id);
+	`, nil, true)
+	require.NoError(t, err)
+
+	rowCount := 10
+
+	for i := 0; i < rowCount; i++ {
+		_, err = engine.ExecStmt(fmt.Sprintf(`
+			UPSERT INTO table1 (id, title, fkid1, fkid2) VALUES (%d, 'title%d', %d, %d)`, i, i, rowCount-1-i, i), nil, true)
+		require.NoError(t, err)
+
+		_, err = engine.ExecStmt(fmt.Sprintf("UPSERT INTO table2 (id, amount) VALUES (%d, %d)", rowCount-1-i, i*i), nil, true)
+		require.NoError(t, err)
+
+		_, err = engine.ExecStmt(fmt.Sprintf("UPSERT INTO table3 (id, age) VALUES (%d, %d)", i, 30+i), nil, true)
+		require.NoError(t, err)
+	}
+
+	t.Run("the joined table should drive the join", func(t *testing.T) {
+		r, err := engine.QueryStmt(`
+			SELECT *
+			FROM table1 INNER JOIN table2 ON table1.fkid1 = table2.id
+			INNER JOIN table3 ON table1.fkid2 = table3.id
+			WHERE id >= 2 AND table3.age >= 30
+			ORDER BY table2.amount DESC`, nil, true)
+		require.NoError(t, err)
+
+		require.Equal(t, "table2", r.ScanSpecs().index.table.name)
+
+		// columns are kept in the order of the tables of the query
+		cols, err := r.Columns()
+		require.NoError(t, err)
+		require.Len(t, cols, 8)
+		require.Equal(t, EncodeSelector("", "db1", "table1", "id"), cols[0].Selector())
+		require.Equal(t, EncodeSelector("", "db1", "table2", "id"), cols[4].Selector())
+		require.Equal(t, EncodeSelector("", "db1", "table3", "age"), cols[7].Selector())
+
+		// unqualified columns still refer to table1
+		for i := 0; i < rowCount-2; i++ {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Len(t, row.Values, 8)
+
+			require.Equal(t, int64(rowCount-1-i), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+			require.Equal(t, int64(i), row.Values[EncodeSelector("", "db1", "table2", "id")].Value())
+			require.Equal(t, int64((rowCount-1-i)*(rowCount-1-i)), row.Values[EncodeSelector("", "db1", "table2", "amount")].Value())
+			require.Equal(t, int64(30+rowCount-1-i), row.Values[EncodeSelector("", "db1", "table3", "age")].Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("aliased tables should be sorted as well", func(t *testing.T) {
+		r, err := engine.QueryStmt(`
+			SELECT t1.id, t2.amount
+			FROM table1 AS t1 INNER JOIN table2 AS t2 ON t1.fkid1 = t2.id
+			ORDER BY t2.amount`, nil, true)
+		require.NoError(t, err)
+
+		for i := 0; i < rowCount; i++ {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Equal(t, int64(i), row.Values[EncodeSelector("", "db1", "t1", "id")].Value())
+			require.Equal(t, int64(i*i), row.Values[EncodeSelector("", "db1", "t2", "amount")].Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("non-indexed columns of the joined table can only be sorted in memory", func(t *testing.T) {
+		query := `
+			SELECT table1.id, table3.age
+			FROM table3 INNER JOIN table1 ON table1.fkid2 = table3.id
+			ORDER BY table1.title DESC`
+
+		_, err := engine.QueryStmt(query, nil, true)
+		require.ErrorIs(t, err, ErrLimitedOrderBy)
+
+		r, err := engine.QueryStmt(query+" LIMIT 3", nil, true)
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Equal(t, int64(rowCount-1-i), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+			require.Equal(t, int64(30+rowCount-1-i), row.Values[EncodeSelector("", "db1", "table3", "age")].Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
 func TestJoinsWithJointTable(t *testing.T) {
 	catalogStore, err := store.Open("catalog_innerjoin_joint", store.DefaultOptions())
 	require.NoError(t, err)
@@ -3309,6 +9376,67 @@ func TestNestedJoins(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestMaxJoinDepth(t *testing.T) {
+	catalogStore, err := store.Open("catalog_maxjoindepth", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_maxjoindepth")
+
+	dataStore, err := store.Open("sqldata_maxjoindepth", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_maxjoindepth")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix).WithMaxJoinDepth(3))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table1 (id INTEGER, fkid INTEGER, PRIMARY KEY id);
+		INSERT INTO table1 (id, fkid) VALUES (1, 1);
+	`, nil, true)
+	require.NoError(t, err)
+
+	t.Run("queries joining up to the max number of relations should be resolved", func(t *testing.T) {
+		rows, _, err := engine.QueryAll(`
+			SELECT t1.id
+			FROM table1 t1
+			INNER JOIN table1 t2 ON t1.fkid = t2.id
+			INNER JOIN table1 t3 ON t2.fkid = t3.id`, nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+	})
+
+	t.Run("queries joining more than the max number of relations should be rejected", func(t *testing.T) {
+		_, err := engine.QueryStmt(`
+			SELECT t1.id
+			FROM table1 t1
+			INNER JOIN table1 t2 ON t1.fkid = t2.id
+			INNER JOIN table1 t3 ON t2.fkid = t3.id
+			INNER JOIN table1 t4 ON t3.fkid = t4.id`, nil, true)
+		require.ErrorIs(t, err, ErrJoinDepthExceeded)
+	})
+
+	t.Run("relations joined within derived tables should be counted", func(t *testing.T) {
+		_, err := engine.QueryStmt(`
+			SELECT id
+			FROM (
+				SELECT t1.id
+				FROM table1 t1
+				INNER JOIN table1 t2 ON t1.fkid = t2.id
+				INNER JOIN table1 t3 ON t2.fkid = t3.id
+			) AS t
+			INNER JOIN table1 t4 ON t.id = t4.id`, nil, true)
+		require.ErrorIs(t, err, ErrJoinDepthExceeded)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
 func TestReOpening(t *testing.T) {
 	catalogStore, err := store.Open("catalog_reopening", store.DefaultOptions())
 	require.NoError(t, err)
@@ -3438,20 +9566,105 @@ func TestSubQuery(t *testing.T) {
 	r, err = engine.QueryStmt("SELECT id, title, active FROM (SELECT id, title, active FROM table1) WHERE active", nil, true)
 	require.NoError(t, err)
 
-	_, err = r.Read()
+	_, err = r.Read()
+	require.NoError(t, err)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT id, title, active FROM (SELECT id, title, active FROM table1) WHERE title", nil, true)
+	require.NoError(t, err)
+
+	_, err = r.Read()
+	require.Equal(t, ErrInvalidCondition, err)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestSubQueryNestedAliases(t *testing.T) {
+	catalogStore, err := store.Open("catalog_subq_aliases", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_subq_aliases")
+
+	dataStore, err := store.Open("sqldata_subq_aliases", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_subq_aliases")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	err = r.Close()
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, PRIMARY KEY id)", nil, true)
 	require.NoError(t, err)
 
-	r, err = engine.QueryStmt("SELECT id, title, active FROM (SELECT id, title, active FROM table1) WHERE title", nil, true)
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (1, 'title1'), (2, 'title2'), (3, 'title3')", nil, true)
 	require.NoError(t, err)
 
-	_, err = r.Read()
-	require.Equal(t, ErrInvalidCondition, err)
+	t.Run("the innermost alias should be referenced by the outer query", func(t *testing.T) {
+		rows, cols, err := engine.QueryAll(`
+			SELECT name
+			FROM (
+				SELECT id, name
+				FROM (SELECT id, title AS name FROM table1) AS q1
+			) AS q2
+			WHERE name != 'title2'`, nil)
+		require.NoError(t, err)
 
-	err = r.Close()
-	require.NoError(t, err)
+		require.Len(t, cols, 1)
+		require.Equal(t, EncodeSelector("", "db1", "q2", "name"), cols[0].Selector())
+
+		require.Len(t, rows, 2)
+		require.Equal(t, "title1", rows[0].Values[EncodeSelector("", "db1", "q2", "name")].Value())
+		require.Equal(t, "title3", rows[1].Values[EncodeSelector("", "db1", "q2", "name")].Value())
+	})
+
+	t.Run("aliases should be applied at every level", func(t *testing.T) {
+		rows, _, err := engine.QueryAll(`
+			SELECT q2.n, t
+			FROM (
+				SELECT q1.num AS n, name AS t
+				FROM (SELECT id AS num, title AS name FROM table1) AS q1
+				WHERE q1.num > 1
+			) AS q2
+			WHERE t != 'title3'`, nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, int64(2), rows[0].Values[EncodeSelector("", "db1", "q2", "n")].Value())
+		require.Equal(t, "title2", rows[0].Values[EncodeSelector("", "db1", "q2", "t")].Value())
+	})
+
+	t.Run("aliases may swap column names", func(t *testing.T) {
+		rows, _, err := engine.QueryAll(`
+			SELECT id, title
+			FROM (
+				SELECT title AS id, id AS title
+				FROM (SELECT id AS title, title AS id FROM table1) AS q1
+			) AS q2
+			WHERE id = 1`, nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, int64(1), rows[0].Values[EncodeSelector("", "db1", "q2", "id")].Value())
+		require.Equal(t, "title1", rows[0].Values[EncodeSelector("", "db1", "q2", "title")].Value())
+	})
+
+	t.Run("aliases of a nested query should not be visible by name of the nested query", func(t *testing.T) {
+		_, _, err := engine.QueryAll(`
+			SELECT q1.name
+			FROM (
+				SELECT id, name
+				FROM (SELECT id, title AS name FROM table1) AS q1
+			) AS q2`, nil)
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
+	})
 
 	err = engine.Close()
 	require.NoError(t, err)
@@ -4236,3 +10449,530 @@ func TestEncodeAsKeyEdgeCases(t *testing.T) {
 		require.ErrorIs(t, err, ErrMaxLengthExceeded)
 	})
 }
+
+func TestDecodeKeyValue(t *testing.T) {
+	for _, c := range []struct {
+		val    TypedValue
+		maxLen int
+	}{
+		{val: &Number{val: -10}, maxLen: 8},
+		{val: &Number{val: math.MaxInt64}, maxLen: 8},
+		{val: &Bool{val: true}, maxLen: 1},
+		{val: &Varchar{val: "title1"}, maxLen: 10},
+		{val: &Varchar{val: ""}, maxLen: 10},
+		{val: &Blob{val: []byte{1, 2, 3}}, maxLen: 3},
+	} {
+		encVal, err := EncodeAsKey(c.val.Value(), c.val.Type(), c.maxLen)
+		require.NoError(t, err)
+
+		val, n, err := decodeKeyValue(encVal, c.val.Type(), c.maxLen)
+		require.NoError(t, err)
+		require.Equal(t, len(encVal), n)
+		require.Equal(t, c.val, val)
+	}
+
+	_, _, err := decodeKeyValue([]byte{1, 2}, IntegerType, 8)
+	require.ErrorIs(t, err, ErrCorruptedData)
+
+	_, _, err = decodeKeyValue([]byte{1, 2, 0, 0, 0, 3}, VarcharType, 2)
+	require.ErrorIs(t, err, ErrCorruptedData)
+
+	_, _, err = decodeKeyValue([]byte{1}, "NOTATYPE", 1)
+	require.ErrorIs(t, err, ErrCorruptedData)
+}
+
+func TestIndexCache(t *testing.T) {
+	catalogStore, err := store.Open("catalog_index_cache", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_index_cache")
+
+	dataStore, err := store.Open("sqldata_index_cache", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_index_cache")
+
+	_, err = NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix).WithIndexCacheSize(-1))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	cachedEngine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix).WithIndexCacheSize(10))
+	require.NoError(t, err)
+
+	_, err = cachedEngine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = cachedEngine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = cachedEngine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[50], amount INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = cachedEngine.ExecStmt("CREATE INDEX ON table1(amount)", nil, true)
+	require.NoError(t, err)
+
+	rowCount := 20
+
+	for i := 0; i < rowCount; i++ {
+		params := map[string]interface{}{"id": i, "title": fmt.Sprintf("title%d", i), "amount": i % 5}
+		_, err = cachedEngine.ExecStmt("INSERT INTO table1 (id, title, amount) VALUES (@id, @title, @amount)", params, true)
+		require.NoError(t, err)
+	}
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	err = engine.EnsureCatalogReady(nil)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	queryRows := func(e *Engine, query string) []*Row {
+		rows, _, err := e.QueryAll(query, nil)
+		require.NoError(t, err)
+
+		return rows
+	}
+
+	queries := []string{
+		"SELECT id, title, amount FROM table1 USE INDEX ON amount",
+		"SELECT id, title, amount FROM table1 USE INDEX ON amount WHERE amount = 3",
+		"SELECT id, title, amount FROM table1 USE INDEX ON amount WHERE amount >= 1 AND amount < 3 ORDER BY amount DESC",
+	}
+
+	for _, q := range queries {
+		expected := queryRows(engine, q)
+		require.NotEmpty(t, expected)
+
+		// first read populates the cache while the second one is served from it
+		require.Equal(t, expected, queryRows(cachedEngine, q))
+		require.Equal(t, expected, queryRows(cachedEngine, q))
+	}
+
+	require.Equal(t, 10, cachedEngine.indexCache.Size())
+
+	_, err = cachedEngine.ExecStmt("UPDATE table1 SET title = 'updated' WHERE amount = 3", nil, true)
+	require.NoError(t, err)
+
+	for _, q := range queries {
+		require.Equal(t, queryRows(engine, q), queryRows(cachedEngine, q))
+	}
+
+	rows := queryRows(cachedEngine, "SELECT title FROM table1 USE INDEX ON amount WHERE amount = 3")
+	require.Len(t, rows, rowCount/5)
+
+	for _, row := range rows {
+		require.Equal(t, "updated", row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+	}
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	err = cachedEngine.Close()
+	require.NoError(t, err)
+}
+
+func BenchmarkIndexCache(b *testing.B) {
+	for _, cacheSize := range []int{0, 1_000} {
+		b.Run(fmt.Sprintf("cache_size_%d", cacheSize), func(b *testing.B) {
+			catalogStore, err := store.Open("catalog_index_cache_bench", store.DefaultOptions())
+			require.NoError(b, err)
+			defer os.RemoveAll("catalog_index_cache_bench")
+			defer catalogStore.Close()
+
+			dataStore, err := store.Open("sqldata_index_cache_bench", store.DefaultOptions())
+			require.NoError(b, err)
+			defer os.RemoveAll("sqldata_index_cache_bench")
+			defer dataStore.Close()
+
+			engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix).WithIndexCacheSize(cacheSize))
+			require.NoError(b, err)
+			defer engine.Close()
+
+			_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+			require.NoError(b, err)
+
+			err = engine.UseDatabase("db1")
+			require.NoError(b, err)
+
+			_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[50], amount INTEGER, PRIMARY KEY id)", nil, true)
+			require.NoError(b, err)
+
+			_, err = engine.ExecStmt("CREATE INDEX ON table1(amount)", nil, true)
+			require.NoError(b, err)
+
+			for i := 0; i < 100; i++ {
+				params := map[string]interface{}{"id": i, "title": fmt.Sprintf("title%d", i), "amount": i % 10}
+				_, err = engine.ExecStmt("INSERT INTO table1 (id, title, amount) VALUES (@id, @title, @amount)", params, true)
+				require.NoError(b, err)
+			}
+
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				r, err := engine.QueryStmt("SELECT id, title FROM table1 USE INDEX ON amount WHERE amount = 5", nil, false)
+				require.NoError(b, err)
+
+				for {
+					_, err = r.Read()
+					if err == ErrNoMoreRows {
+						break
+					}
+					require.NoError(b, err)
+				}
+
+				err = r.Close()
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+func BenchmarkLikePrefix(b *testing.B) {
+	catalogStore, err := store.Open("catalog_like_prefix_bench", store.DefaultOptions())
+	require.NoError(b, err)
+	defer os.RemoveAll("catalog_like_prefix_bench")
+	defer catalogStore.Close()
+
+	dataStore, err := store.Open("sqldata_like_prefix_bench", store.DefaultOptions())
+	require.NoError(b, err)
+	defer os.RemoveAll("sqldata_like_prefix_bench")
+	defer dataStore.Close()
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(b, err)
+	defer engine.Close()
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(b, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(b, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[20], PRIMARY KEY id)", nil, true)
+	require.NoError(b, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(title)", nil, true)
+	require.NoError(b, err)
+
+	for i := 0; i < 1_000; i++ {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (@id, @title)", map[string]interface{}{"id": i, "title": fmt.Sprintf("title%d", i)}, true)
+		require.NoError(b, err)
+	}
+
+	for _, pattern := range []string{"^title99", "title99"} {
+		b.Run(pattern, func(b *testing.B) {
+			params := map[string]interface{}{"pattern": pattern}
+
+			for i := 0; i < b.N; i++ {
+				r, err := engine.QueryStmt("SELECT id FROM table1 USE INDEX ON title WHERE title LIKE @pattern", params, false)
+				require.NoError(b, err)
+
+				for {
+					_, err = r.Read()
+					if err == ErrNoMoreRows {
+						break
+					}
+					require.NoError(b, err)
+				}
+
+				err = r.Close()
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+func BenchmarkFilteredCount(b *testing.B) {
+	catalogStore, err := store.Open("catalog_filtered_count_bench", store.DefaultOptions())
+	require.NoError(b, err)
+	defer os.RemoveAll("catalog_filtered_count_bench")
+	defer catalogStore.Close()
+
+	dataStore, err := store.Open("sqldata_filtered_count_bench", store.DefaultOptions())
+	require.NoError(b, err)
+	defer os.RemoveAll("sqldata_filtered_count_bench")
+	defer dataStore.Close()
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(b, err)
+	defer engine.Close()
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(b, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(b, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, note VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(b, err)
+
+	for i := 0; i < 1_000; i++ {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, note) VALUES (@id, 'note')", map[string]interface{}{"id": i}, true)
+		require.NoError(b, err)
+	}
+
+	for _, q := range []string{
+		"SELECT COUNT() FROM table1 WHERE id > 4",
+		"SELECT COUNT() FROM table1 WHERE id > 4 AND note = 'note'",
+	} {
+		b.Run(q, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _, err := engine.QueryAll(q, nil)
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+func BenchmarkOrderByNonIndexedColumnWithLimit(b *testing.B) {
+	catalogStore, err := store.Open("catalog_orderby_limit_bench", store.DefaultOptions())
+	require.NoError(b, err)
+	defer os.RemoveAll("catalog_orderby_limit_bench")
+	defer catalogStore.Close()
+
+	dataStore, err := store.Open("sqldata_orderby_limit_bench", store.DefaultOptions())
+	require.NoError(b, err)
+	defer os.RemoveAll("sqldata_orderby_limit_bench")
+	defer dataStore.Close()
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(b, err)
+	defer engine.Close()
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(b, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(b, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, amount INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(b, err)
+
+	for i := 0; i < 100; i++ {
+		var values []string
+		for j := 0; j < 1_000; j++ {
+			id := i*1_000 + j
+			values = append(values, fmt.Sprintf("(%d, %d)", id, id*7919%100_000))
+		}
+
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, amount) VALUES "+strings.Join(values, ", "), nil, true)
+		require.NoError(b, err)
+	}
+
+	for _, limit := range []int{10, 1_000} {
+		b.Run(fmt.Sprintf("limit_%d", limit), func(b *testing.B) {
+			query := fmt.Sprintf("SELECT id FROM table1 ORDER BY amount DESC LIMIT %d", limit)
+
+			for i := 0; i < b.N; i++ {
+				r, err := engine.QueryStmt(query, nil, false)
+				require.NoError(b, err)
+
+				for {
+					_, err = r.Read()
+					if err == ErrNoMoreRows {
+						break
+					}
+					require.NoError(b, err)
+				}
+
+				err = r.Close()
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+// queryIDs returns the ids of the rows of db1.table1 resulting from the query
+func queryIDs(t *testing.T, engine *Engine, query string, params map[string]interface{}) []int64 {
+	rows, _, err := engine.QueryAll(query, params)
+	require.NoError(t, err)
+
+	return rowIDs(rows)
+}
+
+func rowIDs(rows []*Row) []int64 {
+	var ids []int64
+
+	for _, row := range rows {
+		ids = append(ids, row.Values[EncodeSelector("", "db1", "table1", "id")].Value().(int64))
+	}
+
+	return ids
+}
+
+func BenchmarkInClauseOnIndexedColumn(b *testing.B) {
+	catalogStore, err := store.Open("catalog_in_clause_bench", store.DefaultOptions())
+	require.NoError(b, err)
+	defer os.RemoveAll("catalog_in_clause_bench")
+	defer catalogStore.Close()
+
+	dataStore, err := store.Open("sqldata_in_clause_bench", store.DefaultOptions())
+	require.NoError(b, err)
+	defer os.RemoveAll("sqldata_in_clause_bench")
+	defer dataStore.Close()
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(b, err)
+	defer engine.Close()
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(b, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(b, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, code INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(b, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(code)", nil, true)
+	require.NoError(b, err)
+
+	for i := 0; i < 10_000; i += 100 {
+		var rows []string
+
+		for j := i; j < i+100; j++ {
+			rows = append(rows, fmt.Sprintf("(%d, %d)", j, j))
+		}
+
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, code) VALUES "+strings.Join(rows, ", "), nil, true)
+		require.NoError(b, err)
+	}
+
+	// only the index range from the smallest to the biggest value is scanned
+	q := "SELECT id FROM table1 WHERE code IN (5002, 5000, 5005)"
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		rows, _, err := engine.QueryAll(q, nil)
+		require.NoError(b, err)
+		require.Len(b, rows, 3)
+	}
+}
+
+func BenchmarkConstantExpressions(b *testing.B) {
+	catalogStore, err := store.Open("catalog_constant_exps_bench", store.DefaultOptions())
+	require.NoError(b, err)
+	defer os.RemoveAll("catalog_constant_exps_bench")
+	defer catalogStore.Close()
+
+	dataStore, err := store.Open("sqldata_constant_exps_bench", store.DefaultOptions())
+	require.NoError(b, err)
+	defer os.RemoveAll("sqldata_constant_exps_bench")
+	defer dataStore.Close()
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(b, err)
+	defer engine.Close()
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(b, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(b, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(b, err)
+
+	for i := 0; i < 1_000; i++ {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id) VALUES (@id)", map[string]interface{}{"id": i}, true)
+		require.NoError(b, err)
+	}
+
+	// constant subexpressions are evaluated once per query instead of once per row
+	q := "SELECT id, (1 + 2) * (3 + 4) - @p AS c FROM table1 WHERE id >= (10 - 10) * @p AND NOT (1 > 2)"
+	params := map[string]interface{}{"p": 5}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		r, err := engine.QueryStmt(q, params, false)
+		require.NoError(b, err)
+
+		for {
+			_, err = r.Read()
+			if err == ErrNoMoreRows {
+				break
+			}
+			require.NoError(b, err)
+		}
+
+		err = r.Close()
+		require.NoError(b, err)
+	}
+}
+
+func TestKeywordsAsColumnNames(t *testing.T) {
+	catalogStore, err := store.Open("catalog_keyword_cols", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_keyword_cols")
+
+	dataStore, err := store.Open("sqldata_keyword_cols", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_keyword_cols")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	keywords := []string{
+		"offset", "fetch", "first", "next", "row", "rows", "only", "including", "indexes",
+		"escape", "with", "comment", "merge", "using", "when", "matched", "then", "for",
+		"system_time", "over", "partition", "conflict", "do", "nothing", "generated", "always",
+		"stored", "unknown", "returning", "nulls", "current", "of", "rollup", "type", "all",
+	}
+
+	// DEFAULT stands for the default value of a column wherever a value is expected,
+	// a column named after it is referenced through its table
+	cols := append([]string{"default"}, keywords...)
+
+	colsSpec := make([]string, len(cols))
+	for i, col := range cols {
+		colsSpec[i] = fmt.Sprintf("%s INTEGER DEFAULT %d", col, i)
+	}
+
+	_, err = engine.ExecStmt(fmt.Sprintf("CREATE TABLE keywords (id INTEGER, %s, PRIMARY KEY id)", strings.Join(colsSpec, ", ")), nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(fmt.Sprintf("CREATE INDEX ON keywords(%s)", cols[0]), nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(fmt.Sprintf("INSERT INTO keywords (id, %s) VALUES (1, DEFAULT)", cols[0]), nil, true)
+	require.NoError(t, err)
+
+	updates := make([]string, len(cols))
+	for i, col := range cols {
+		updates[i] = fmt.Sprintf("%s = keywords.%s + 10", col, col)
+	}
+
+	_, err = engine.ExecStmt(fmt.Sprintf("UPDATE keywords SET %s WHERE id = 1", strings.Join(updates, ", ")), nil, true)
+	require.NoError(t, err)
+
+	selectors := make([]string, len(cols))
+	for i, col := range cols {
+		selectors[i] = "keywords." + col
+	}
+
+	rows, _, err := engine.QueryAll(fmt.Sprintf("SELECT %s FROM keywords WHERE keywords.%s = 10", strings.Join(selectors, ", "), cols[0]), nil)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+
+	for i, col := range cols {
+		require.Equal(t, int64(i+10), rows[0].Values[EncodeSelector("", "db1", "keywords", col)].Value(), col)
+	}
+
+	rows, _, err = engine.QueryAll(fmt.Sprintf("SELECT %s FROM keywords WHERE %s = 11", strings.Join(keywords, ", "), keywords[0]), nil)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+
+	for i, col := range keywords {
+		require.Equal(t, int64(i+11), rows[0].Values[EncodeSelector("", "db1", "keywords", col)].Value(), col)
+	}
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
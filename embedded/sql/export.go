@@ -0,0 +1,352 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NullRendering tells how NULL values are written by ExportCSV and QueryToNDJSON
+type NullRendering int
+
+const (
+	// NullAsDefault writes NULL values as empty CSV fields and as JSON null
+	NullAsDefault NullRendering = iota
+	// NullAsEmpty writes NULL values as empty CSV fields and as empty JSON strings
+	NullAsEmpty
+	// NullAsLiteral writes NULL values as the text NULL, both in CSV fields and in JSON strings
+	NullAsLiteral
+	// NullAsJSONNull writes NULL values as JSON null, CSV fields get the text null
+	NullAsJSONNull
+)
+
+// QueryToNDJSON runs the query and writes each resulting row into w as soon as it's read, encoded as a JSON object
+// keyed by column name and followed by a newline. Null values are written as set with WithExportNulls,
+// null by default, and BLOB values as base64 strings.
+func (e *Engine) QueryToNDJSON(sql string, params map[string]interface{}, w io.Writer) error {
+	return e.export(sql, params, w, writeNDJSON)
+}
+
+// ExportCSV runs the query and writes the resulting rows into w as RFC 4180 CSV records, preceded by a header
+// record with the column names. Null values are written as set with WithExportNulls, empty fields by default,
+// and BLOB values as hex strings.
+func (e *Engine) ExportCSV(sql string, params map[string]interface{}, w io.Writer) error {
+	return e.export(sql, params, w, writeCSV)
+}
+
+// ExportSchema writes into w the statements creating the tables of the database in the order they were created,
+// each one followed by the statements creating its secondary indexes and setting its comments.
+// Running them against an empty database reproduces the schema, rows are not exported.
+func (e *Engine) ExportSchema(dbName string, w io.Writer) error {
+	if w == nil {
+		return ErrIllegalArguments
+	}
+
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	if e.closed {
+		return ErrAlreadyClosed
+	}
+
+	if e.catalog == nil {
+		return ErrCatalogNotReady
+	}
+
+	db, err := e.catalog.GetDatabaseByName(dbName)
+	if err != nil {
+		return err
+	}
+
+	tables := db.GetTables()
+	sort.Slice(tables, func(i, j int) bool { return tables[i].id < tables[j].id })
+
+	// nothing is written unless the whole schema can be exported
+	var b strings.Builder
+
+	for _, table := range tables {
+		err = writeTableSchema(&b, table)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = io.WriteString(w, b.String())
+
+	return err
+}
+
+func writeTableSchema(b *strings.Builder, table *Table) error {
+	fmt.Fprintf(b, "CREATE TABLE %s (\n", table.name)
+
+	for _, col := range table.cols {
+		colSQL, err := colSpecSQL(col)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(b, "\t%s,\n", colSQL)
+	}
+
+	fmt.Fprintf(b, "\tPRIMARY KEY (%s)\n);\n", strings.Join(colNames(table.primaryIndex.cols), ", "))
+
+	indexes := make([]*Index, 0, len(table.indexes))
+
+	for _, index := range table.indexes {
+		if !index.IsPrimary() {
+			indexes = append(indexes, index)
+		}
+	}
+
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i].id < indexes[j].id })
+
+	for _, index := range indexes {
+		unique := ""
+		if index.unique {
+			unique = "UNIQUE "
+		}
+
+		nullsDistinct := ""
+		if index.nullable {
+			nullsDistinct = " NULLS DISTINCT"
+		}
+
+		fmt.Fprintf(b, "CREATE %sINDEX ON %s(%s)%s;\n", unique, table.name, strings.Join(colNames(index.cols), ", "), nullsDistinct)
+	}
+
+	if table.comment != "" {
+		fmt.Fprintf(b, "COMMENT ON TABLE %s IS '%s';\n", table.name, table.comment)
+	}
+
+	for _, col := range table.cols {
+		if col.comment != "" {
+			fmt.Fprintf(b, "COMMENT ON COLUMN %s.%s IS '%s';\n", table.name, col.colName, col.comment)
+		}
+	}
+
+	return nil
+}
+
+// colSpecSQL returns the definition of the column as written in CREATE TABLE statements
+func colSpecSQL(col *Column) (string, error) {
+	sql := col.colName + " " + string(col.colType)
+
+	if col.maxLen > 0 {
+		sql += fmt.Sprintf("[%d]", col.maxLen)
+	}
+
+	if col.generatedAs != nil {
+		expSQL, err := generatedExpSQL(col.generatedAs)
+		if err != nil {
+			return "", err
+		}
+
+		sql += " AS (" + expSQL + ") STORED"
+	}
+
+	if col.autoIncrement {
+		sql += " AUTO_INCREMENT"
+	}
+
+	if col.notNull {
+		sql += " NOT NULL"
+	}
+
+	if col.defaultValue != nil {
+		expSQL, err := generatedExpSQL(col.defaultValue)
+		if err != nil {
+			return "", err
+		}
+
+		sql += " DEFAULT " + expSQL
+	}
+
+	return sql, nil
+}
+
+func (e *Engine) export(sql string, params map[string]interface{}, w io.Writer, write func(r RowReader, w io.Writer, nulls NullRendering) error) error {
+	if w == nil {
+		return ErrIllegalArguments
+	}
+
+	r, err := e.queryStmt(nil, sql, params, true)
+	if err != nil {
+		return err
+	}
+
+	err = write(r, w, e.exportNulls)
+	if err != nil {
+		r.Close()
+		return err
+	}
+
+	return r.Close()
+}
+
+func writeNDJSON(r RowReader, w io.Writer, nulls NullRendering) error {
+	cols, err := r.Columns()
+	if err != nil {
+		return err
+	}
+
+	keys := make([][]byte, len(cols))
+
+	nullJSON := []byte("null")
+
+	switch nulls {
+	case NullAsEmpty:
+		nullJSON = []byte(`""`)
+	case NullAsLiteral:
+		nullJSON = []byte(`"NULL"`)
+	}
+
+	for i, col := range cols {
+		keys[i], err = json.Marshal(col.Column)
+		if err != nil {
+			return err
+		}
+	}
+
+	var buf bytes.Buffer
+
+	for {
+		row, err := r.Read()
+		if err == ErrNoMoreRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		buf.Reset()
+		buf.WriteByte('{')
+
+		for i, col := range cols {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			buf.Write(keys[i])
+			buf.WriteByte(':')
+
+			val := row.Values[col.Selector()]
+
+			if _, isNull := val.(*NullValue); isNull {
+				buf.Write(nullJSON)
+				continue
+			}
+
+			v, err := json.Marshal(val.Value())
+			if err != nil {
+				return err
+			}
+
+			buf.Write(v)
+		}
+
+		buf.WriteString("}\n")
+
+		_, err = w.Write(buf.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func writeCSV(r RowReader, w io.Writer, nulls NullRendering) error {
+	cols, err := r.Columns()
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+
+	record := make([]string, len(cols))
+
+	nullField := ""
+
+	switch nulls {
+	case NullAsLiteral:
+		nullField = "NULL"
+	case NullAsJSONNull:
+		nullField = "null"
+	}
+
+	for i, col := range cols {
+		record[i] = col.Column
+	}
+
+	err = cw.Write(record)
+	if err != nil {
+		return err
+	}
+
+	for {
+		row, err := r.Read()
+		if err == ErrNoMoreRows {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		for i, col := range cols {
+			val := row.Values[col.Selector()]
+
+			if _, isNull := val.(*NullValue); isNull {
+				record[i] = nullField
+				continue
+			}
+
+			record[i] = csvField(val)
+		}
+
+		err = cw.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func csvField(v TypedValue) string {
+	switch v := v.Value().(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case []byte:
+		return hex.EncodeToString(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+
+	return ""
+}
@@ -0,0 +1,361 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryToNDJSON(t *testing.T) {
+	catalogStore, err := store.Open("catalog_ndjson", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_ndjson")
+
+	dataStore, err := store.Open("sqldata_ndjson", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_ndjson")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, active BOOLEAN, payload BLOB, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		INSERT INTO table1 (id, title, active, payload)
+		VALUES (1, 'title1', true, x'0a0b'), (2, 'title "2"', false, NULL)`, nil, true)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+
+	err = engine.QueryToNDJSON("SELECT id, title, active, payload FROM table1", nil, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = engine.QueryToNDJSON("SELECT id FROM table2", nil, &buf)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	err = engine.QueryToNDJSON("SELECT id, title AS name, active, payload FROM table1 WHERE id > @id", map[string]interface{}{"id": 0}, &buf)
+	require.NoError(t, err)
+
+	type row struct {
+		ID      int64
+		Name    string
+		Active  bool
+		Payload []byte
+	}
+
+	var rows []row
+
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var r row
+
+		err = json.Unmarshal(scanner.Bytes(), &r)
+		require.NoError(t, err)
+
+		rows = append(rows, r)
+	}
+	require.NoError(t, scanner.Err())
+
+	require.Equal(t, []row{
+		{ID: 1, Name: "title1", Active: true, Payload: []byte{0x0a, 0x0b}},
+		{ID: 2, Name: "title \"2\"", Active: false},
+	}, rows)
+
+	buf.Reset()
+
+	err = engine.QueryToNDJSON("SELECT id FROM table1 WHERE id > 2", nil, &buf)
+	require.NoError(t, err)
+	require.Zero(t, buf.Len())
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	err = engine.QueryToNDJSON("SELECT id FROM table1", nil, &buf)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
+func TestExportCSV(t *testing.T) {
+	catalogStore, err := store.Open("catalog_csv", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_csv")
+
+	dataStore, err := store.Open("sqldata_csv", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_csv")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, active BOOLEAN, payload BLOB, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		INSERT INTO table1 (id, title, active, payload)
+		VALUES (1, 'title1', true, x'0a0b'), (2, 'title "2", and more', false, NULL), (3, NULL, NULL, x'')`, nil, true)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+
+	err = engine.ExportCSV("SELECT id, title, active, payload FROM table1", nil, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = engine.ExportCSV("SELECT id FROM table2", nil, &buf)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	err = engine.ExportCSV("SELECT id, title AS name, active, payload FROM table1 WHERE id > @id", map[string]interface{}{"id": 0}, &buf)
+	require.NoError(t, err)
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		{"id", "name", "active", "payload"},
+		{"1", "title1", "true", "0a0b"},
+		{"2", "title \"2\", and more", "false", ""},
+		{"3", "", "", ""},
+	}, records)
+
+	buf.Reset()
+
+	err = engine.ExportCSV("SELECT id FROM table1 WHERE id > 3", nil, &buf)
+	require.NoError(t, err)
+	require.Equal(t, "id\n", buf.String())
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	err = engine.ExportCSV("SELECT id FROM table1", nil, &buf)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
+func TestExportNulls(t *testing.T) {
+	catalogStore, err := store.Open("catalog_export_nulls", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_export_nulls")
+
+	dataStore, err := store.Open("sqldata_export_nulls", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_export_nulls")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (1, NULL), (2, 'NULL')", nil, true)
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		nulls  NullRendering
+		ndjson string
+		csv    string
+	}{
+		{
+			nulls:  NullAsDefault,
+			ndjson: "{\"id\":1,\"title\":null}\n{\"id\":2,\"title\":\"NULL\"}\n",
+			csv:    "id,title\n1,\n2,NULL\n",
+		},
+		{
+			nulls:  NullAsEmpty,
+			ndjson: "{\"id\":1,\"title\":\"\"}\n{\"id\":2,\"title\":\"NULL\"}\n",
+			csv:    "id,title\n1,\n2,NULL\n",
+		},
+		{
+			nulls:  NullAsLiteral,
+			ndjson: "{\"id\":1,\"title\":\"NULL\"}\n{\"id\":2,\"title\":\"NULL\"}\n",
+			csv:    "id,title\n1,NULL\n2,NULL\n",
+		},
+		{
+			nulls:  NullAsJSONNull,
+			ndjson: "{\"id\":1,\"title\":null}\n{\"id\":2,\"title\":\"NULL\"}\n",
+			csv:    "id,title\n1,null\n2,NULL\n",
+		},
+	} {
+		engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix).WithExportNulls(tc.nulls))
+		require.NoError(t, err)
+
+		err = engine.EnsureCatalogReady(nil)
+		require.NoError(t, err)
+
+		err = engine.UseDatabase("db1")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+
+		err = engine.QueryToNDJSON("SELECT id, title FROM table1", nil, &buf)
+		require.NoError(t, err)
+		require.Equal(t, tc.ndjson, buf.String())
+
+		buf.Reset()
+
+		err = engine.ExportCSV("SELECT id, title FROM table1", nil, &buf)
+		require.NoError(t, err)
+		require.Equal(t, tc.csv, buf.String())
+
+		err = engine.Close()
+		require.NoError(t, err)
+	}
+}
+
+func TestExportSchema(t *testing.T) {
+	catalogStore, err := store.Open("catalog_export_schema", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_export_schema")
+
+	dataStore, err := store.Open("sqldata_export_schema", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_export_schema")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db2", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table1 (
+			id INTEGER AUTO_INCREMENT,
+			title VARCHAR[50] NOT NULL DEFAULT 'untitled',
+			amount INTEGER DEFAULT (0 - 1),
+			total INTEGER AS (amount * 2) STORED,
+			created TIMESTAMP DEFAULT NOW(),
+			PRIMARY KEY id
+		);
+		CREATE UNIQUE INDEX ON table1(title);
+		CREATE INDEX ON table1(amount, created) NULLS DISTINCT;
+		CREATE TABLE table2 (id INTEGER, code VARCHAR[10], PRIMARY KEY (id, code));
+		CREATE TABLE table3 (id INTEGER, PRIMARY KEY id);
+		DROP TABLE table3;
+		ALTER TABLE table2 ADD COLUMN payload BLOB;
+		COMMENT ON TABLE table1 IS 'exported table';
+		COMMENT ON COLUMN table1.title IS 'the title, unique';
+		COMMENT ON COLUMN table2.code IS 'a code';
+	`, nil, true)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+
+	err = engine.ExportSchema("db1", nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = engine.ExportSchema("db3", &buf)
+	require.ErrorIs(t, err, ErrDatabaseDoesNotExist)
+
+	err = engine.ExportSchema("db1", &buf)
+	require.NoError(t, err)
+
+	schema := buf.String()
+
+	require.Equal(t, `CREATE TABLE table1 (
+	id INTEGER AUTO_INCREMENT,
+	title VARCHAR[50] NOT NULL DEFAULT 'untitled',
+	amount INTEGER DEFAULT (0 - 1),
+	total INTEGER AS ((amount * 2)) STORED,
+	created TIMESTAMP DEFAULT NOW(),
+	PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX ON table1(title);
+CREATE INDEX ON table1(amount, created) NULLS DISTINCT;
+COMMENT ON TABLE table1 IS 'exported table';
+COMMENT ON COLUMN table1.title IS 'the title, unique';
+CREATE TABLE table2 (
+	id INTEGER,
+	code VARCHAR[10],
+	payload BLOB,
+	PRIMARY KEY (id, code)
+);
+COMMENT ON COLUMN table2.code IS 'a code';
+`, schema)
+
+	t.Run("the exported schema is reproduced on import", func(t *testing.T) {
+		err = engine.UseDatabase("db2")
+		require.NoError(t, err)
+
+		_, err = engine.ExecStmt(schema, nil, true)
+		require.NoError(t, err)
+
+		table, err := engine.GetTableByName("db2", "table1")
+		require.NoError(t, err)
+		require.Equal(t, "exported table", table.Comment())
+
+		col, err := table.GetColumnByName("title")
+		require.NoError(t, err)
+		require.Equal(t, "the title, unique", col.Comment())
+
+		table, err = engine.GetTableByName("db2", "table2")
+		require.NoError(t, err)
+		require.Empty(t, table.Comment())
+
+		col, err = table.GetColumnByName("code")
+		require.NoError(t, err)
+		require.Equal(t, "a code", col.Comment())
+
+		hash1, err := engine.SchemaHash("db1")
+		require.NoError(t, err)
+
+		hash2, err := engine.SchemaHash("db2")
+		require.NoError(t, err)
+		require.Equal(t, hash1, hash2)
+
+		buf.Reset()
+
+		err = engine.ExportSchema("db2", &buf)
+		require.NoError(t, err)
+		require.Equal(t, schema, buf.String())
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	err = engine.ExportSchema("db1", &buf)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
@@ -0,0 +1,290 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// validateGeneratedExp checks the expression of a generated column is deterministic i.e. it only depends
+// on constants and on other columns of the same row, and that it yields values of the type of the column
+func validateGeneratedExp(col *Column) error {
+	if col.autoIncrement || col.defaultValue != nil {
+		return fmt.Errorf("%w (generated columns can not have a default value nor be auto-incremental)", ErrIllegalGeneratedColumn)
+	}
+
+	err := validateDeterministicExp(col.generatedAs, col)
+	if err != nil {
+		return err
+	}
+
+	cols := make(map[string]ColDescriptor, len(col.table.cols))
+
+	for _, c := range col.table.cols {
+		des := ColDescriptor{Database: col.table.db.name, Table: col.table.name, Column: c.colName, Type: c.colType}
+		cols[des.Selector()] = des
+	}
+
+	return col.generatedAs.requiresType(col.colType, cols, map[string]SQLValueType{}, col.table.db.name, col.table.name)
+}
+
+func validateDeterministicExp(exp ValueExp, col *Column) error {
+	switch e := exp.(type) {
+	case TypedValue:
+		return nil
+	case *ColSelector:
+		if e.db != "" || (e.table != "" && e.table != col.table.name) {
+			return fmt.Errorf("%w (only columns of the same table can be referenced)", ErrIllegalGeneratedColumn)
+		}
+
+		refCol, err := col.table.GetColumnByName(e.col)
+		if err != nil {
+			return err
+		}
+
+		if refCol.generatedAs != nil || refCol.autoIncrement {
+			return fmt.Errorf("%w (generated and auto-incremental columns can not be referenced)", ErrIllegalGeneratedColumn)
+		}
+
+		return nil
+	case *NumExp:
+		err := validateDeterministicExp(e.left, col)
+		if err != nil {
+			return err
+		}
+
+		return validateDeterministicExp(e.right, col)
+	case *CmpBoolExp:
+		err := validateDeterministicExp(e.left, col)
+		if err != nil {
+			return err
+		}
+
+		return validateDeterministicExp(e.right, col)
+	case *BinBoolExp:
+		err := validateDeterministicExp(e.left, col)
+		if err != nil {
+			return err
+		}
+
+		return validateDeterministicExp(e.right, col)
+	case *NotBoolExp:
+		return validateDeterministicExp(e.exp, col)
+	case *IsBoolExp:
+		return validateDeterministicExp(e.exp, col)
+	case *SysFn:
+		// functions without arguments depend on the time or context of the evaluation
+		n, ok := e.arity()
+		if !ok || n == 0 {
+			return fmt.Errorf("%w (%s is not deterministic)", ErrIllegalGeneratedColumn, strings.ToUpper(e.fn))
+		}
+
+		for _, p := range e.params {
+			err := validateDeterministicExp(p, col)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("%w (expecting an expression over constants and columns of the same row)", ErrIllegalGeneratedColumn)
+}
+
+// generatedExpSQL returns the expression of a generated column as SQL, so it can be stored in the catalog
+func generatedExpSQL(exp ValueExp) (string, error) {
+	switch e := exp.(type) {
+	case *NullValue:
+		return "NULL", nil
+	case *Number:
+		if e.val < 0 {
+			return fmt.Sprintf("(0 - %d)", -e.val), nil
+		}
+
+		return fmt.Sprintf("%d", e.val), nil
+	case *Varchar:
+		if strings.ContainsRune(e.val, '\'') {
+			return "", fmt.Errorf("%w (quotes are not supported in strings)", ErrIllegalGeneratedColumn)
+		}
+
+		return "'" + e.val + "'", nil
+	case *Bool:
+		if e.val {
+			return "TRUE", nil
+		}
+
+		return "FALSE", nil
+	case *Blob:
+		return "x'" + hex.EncodeToString(e.val) + "'", nil
+	case *ColSelector:
+		return e.col, nil
+	case *NumExp:
+		return binaryExpSQL(e.left, numOperatorSQL[e.op], e.right)
+	case *CmpBoolExp:
+		return binaryExpSQL(e.left, cmpOperatorSQL[e.op], e.right)
+	case *BinBoolExp:
+		op := "AND"
+		if e.op == OR {
+			op = "OR"
+		}
+
+		return binaryExpSQL(e.left, op, e.right)
+	case *NotBoolExp:
+		sql, err := generatedExpSQL(e.exp)
+		if err != nil {
+			return "", err
+		}
+
+		return "(NOT " + sql + ")", nil
+	case *IsBoolExp:
+		sql, err := generatedExpSQL(e.exp)
+		if err != nil {
+			return "", err
+		}
+
+		is := " IS "
+		if e.not {
+			is = " IS NOT "
+		}
+
+		if e.unknown {
+			return "(" + sql + is + "UNKNOWN)", nil
+		}
+
+		if e.val {
+			return "(" + sql + is + "TRUE)", nil
+		}
+
+		return "(" + sql + is + "FALSE)", nil
+	case *SysFn:
+		params := make([]string, len(e.params))
+
+		for i, p := range e.params {
+			sql, err := generatedExpSQL(p)
+			if err != nil {
+				return "", err
+			}
+
+			params[i] = sql
+		}
+
+		return strings.ToUpper(e.fn) + "(" + strings.Join(params, ", ") + ")", nil
+	}
+
+	return "", fmt.Errorf("%w (expecting an expression over constants and columns of the same row)", ErrIllegalGeneratedColumn)
+}
+
+var numOperatorSQL = map[NumOperator]string{
+	ADDOP:  "+",
+	SUBSOP: "-",
+	DIVOP:  "/",
+	MULTOP: "*",
+}
+
+var cmpOperatorSQL = map[CmpOperator]string{
+	EQ: "=",
+	NE: "!=",
+	LT: "<",
+	LE: "<=",
+	GT: ">",
+	GE: ">=",
+}
+
+func binaryExpSQL(left ValueExp, op string, right ValueExp) (string, error) {
+	lsql, err := generatedExpSQL(left)
+	if err != nil {
+		return "", err
+	}
+
+	rsql, err := generatedExpSQL(right)
+	if err != nil {
+		return "", err
+	}
+
+	return "(" + lsql + " " + op + " " + rsql + ")", nil
+}
+
+// parseGeneratedExp parses the expression of a generated column as stored in the catalog
+func parseGeneratedExp(sql string) (ValueExp, error) {
+	stmts, err := ParseString("SELECT " + sql + " FROM t")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stmts) != 1 {
+		return nil, ErrCorruptedData
+	}
+
+	stmt, ok := stmts[0].(*SelectStmt)
+	if !ok || len(stmt.selectors) != 1 {
+		return nil, ErrCorruptedData
+	}
+
+	switch sel := stmt.selectors[0].(type) {
+	case *ExpSelector:
+		return sel.exp, nil
+	case *ColSelector:
+		return sel, nil
+	}
+
+	return nil, ErrCorruptedData
+}
+
+// setGeneratedValues computes the values of the generated columns of the table from the other values of the row
+func (e *Engine) setGeneratedValues(table *Table, valuesByColID map[uint32]TypedValue) error {
+	var row *Row
+
+	for _, col := range table.cols {
+		if col.generatedAs == nil {
+			continue
+		}
+
+		if row == nil {
+			row = &Row{Values: make(map[string]TypedValue, len(table.cols))}
+
+			for _, c := range table.cols {
+				val, notNull := valuesByColID[c.id]
+				if !notNull {
+					val = &NullValue{t: c.colType}
+				}
+
+				row.Values[EncodeSelector("", table.db.name, table.name, c.colName)] = val
+			}
+		}
+
+		val, err := col.generatedAs.reduce(e.catalog, row, table.db.name, table.name)
+		if err != nil {
+			return err
+		}
+
+		_, isNull := val.(*NullValue)
+		if isNull {
+			if col.notNull {
+				return ErrNotNullableColumnCannotBeNull
+			}
+
+			delete(valuesByColID, col.id)
+			continue
+		}
+
+		valuesByColID[col.id] = e.truncatedValue(col, val)
+	}
+
+	return nil
+}
@@ -15,7 +15,11 @@ limitations under the License.
 */
 package sql
 
-import "github.com/codenotary/immudb/embedded/store"
+import (
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
 
 type groupedRowReader struct {
 	e *Engine
@@ -26,29 +30,98 @@ type groupedRowReader struct {
 
 	groupBy []*ColSelector
 
-	currRow  *Row
+	// rows are aggregated by each grouping set, with ROLLUP they are the prefixes of the grouping columns
+	// from the longest to the empty one, thus the groups of a set are completed before the enclosing group
+	groupingSets [][]*ColSelector
+
+	currRows []*Row // the group being aggregated for each grouping set
+	pending  []*Row // completed groups not yet returned
 	nonEmpty bool
+
+	cols colsCache
 }
 
-func (e *Engine) newGroupedRowReader(rowReader RowReader, selectors []Selector, groupBy []*ColSelector) (*groupedRowReader, error) {
-	if rowReader == nil || len(selectors) == 0 || len(groupBy) > 1 {
+func (e *Engine) newGroupedRowReader(rowReader RowReader, selectors []Selector, groupBy []*ColSelector, rollup bool) (*groupedRowReader, error) {
+	if rowReader == nil || len(selectors) == 0 || (rollup && len(groupBy) == 0) {
 		return nil, ErrIllegalArguments
 	}
 
-	// TODO: leverage multi-column indexing
-	if len(groupBy) == 1 &&
-		rowReader.OrderBy()[0].Selector() != EncodeSelector(groupBy[0].resolve(rowReader.ImplicitDB(), rowReader.ImplicitTable())) {
+	// rows are grouped as they are read, thus they must be sorted by the grouping columns
+	orderBy := rowReader.OrderBy()
+
+	if len(orderBy) < len(groupBy) {
 		return nil, ErrLimitedGroupBy
 	}
 
+	for i, col := range groupBy {
+		if orderBy[i].Selector() != EncodeSelector(col.resolve(rowReader.ImplicitDB(), rowReader.ImplicitTable())) {
+			return nil, ErrLimitedGroupBy
+		}
+	}
+
+	err := validateGroupedSelectors(rowReader, selectors, groupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	groupingSets := [][]*ColSelector{groupBy}
+
+	if rollup {
+		for i := len(groupBy) - 1; i >= 0; i-- {
+			groupingSets = append(groupingSets, groupBy[:i])
+		}
+	}
+
 	return &groupedRowReader{
-		e:         e,
-		rowReader: rowReader,
-		selectors: selectors,
-		groupBy:   groupBy,
+		e:            e,
+		rowReader:    rowReader,
+		selectors:    selectors,
+		groupBy:      groupBy,
+		groupingSets: groupingSets,
+		currRows:     make([]*Row, len(groupingSets)),
 	}, nil
 }
 
+// validateGroupedSelectors checks every non-aggregated selector can be evaluated just from grouping columns
+func validateGroupedSelectors(rowReader RowReader, selectors []Selector, groupBy []*ColSelector) error {
+	cols, err := rowReader.colsBySelector()
+	if err != nil {
+		return err
+	}
+
+	groupedCols := make(map[string]ColDescriptor, len(groupBy))
+
+	for _, col := range groupBy {
+		encSel := EncodeSelector(col.resolve(rowReader.ImplicitDB(), rowReader.ImplicitTable()))
+
+		colDesc, ok := cols[encSel]
+		if !ok {
+			return ErrColumnDoesNotExist
+		}
+
+		groupedCols[encSel] = colDesc
+	}
+
+	for _, sel := range selectors {
+		_, isAggregation := sel.(*AggColSelector)
+		if isAggregation {
+			continue
+		}
+
+		_, err := sel.inferType(cols, make(map[string]SQLValueType), rowReader.ImplicitDB(), rowReader.ImplicitTable())
+		if err != nil {
+			return err
+		}
+
+		_, err = sel.inferType(groupedCols, make(map[string]SQLValueType), rowReader.ImplicitDB(), rowReader.ImplicitTable())
+		if err != nil {
+			return ErrColumnNotInGroupBy
+		}
+	}
+
+	return nil
+}
+
 func (gr *groupedRowReader) ImplicitDB() string {
 	return gr.rowReader.ImplicitDB()
 }
@@ -65,7 +138,20 @@ func (gr *groupedRowReader) ScanSpecs() *ScanSpecs {
 	return gr.rowReader.ScanSpecs()
 }
 
+func (gr *groupedRowReader) TxHeader() (*store.TxHeader, error) {
+	return ReaderTxHeader(gr.rowReader)
+}
+
+// Cursor is not supported, groups are built out of many rows of the scan
+func (gr *groupedRowReader) Cursor() ([]byte, error) {
+	return nil, ErrNoSupported
+}
+
 func (gr *groupedRowReader) Columns() ([]ColDescriptor, error) {
+	return gr.cols.columns(gr.resolveColumns)
+}
+
+func (gr *groupedRowReader) resolveColumns() ([]ColDescriptor, error) {
 	colsBySel, err := gr.colsBySelector()
 	if err != nil {
 		return nil, err
@@ -82,11 +168,20 @@ func (gr *groupedRowReader) Columns() ([]ColDescriptor, error) {
 }
 
 func (gr *groupedRowReader) colsBySelector() (map[string]ColDescriptor, error) {
-	colDescriptors, err := gr.rowReader.colsBySelector()
+	return gr.cols.columnsBySelector(gr.resolveColsBySelector)
+}
+
+func (gr *groupedRowReader) resolveColsBySelector() (map[string]ColDescriptor, error) {
+	dsColDescriptors, err := gr.rowReader.colsBySelector()
 	if err != nil {
 		return nil, err
 	}
 
+	colDescriptors := make(map[string]ColDescriptor, len(dsColDescriptors)+len(gr.selectors))
+	for sel, des := range dsColDescriptors {
+		colDescriptors[sel] = des
+	}
+
 	for _, sel := range gr.selectors {
 		aggFn, db, table, col := sel.resolve(gr.rowReader.ImplicitDB(), gr.rowReader.ImplicitTable())
 
@@ -116,8 +211,11 @@ func (gr *groupedRowReader) colsBySelector() (map[string]ColDescriptor, error) {
 
 		if aggFn == MAX || aggFn == MIN {
 			colDescriptors[encSel] = colDesc
+		} else if aggFn == BOOL_AND || aggFn == BOOL_OR {
+			des.Type = BooleanType
+			colDescriptors[encSel] = des
 		} else {
-			// SUM, AVG
+			// SUM, AVG and the DISTINCT aggregations
 			colDescriptors[encSel] = des
 		}
 	}
@@ -153,10 +251,10 @@ func zeroForType(t SQLValueType) TypedValue {
 		{
 			return &Blob{}
 		}
-		/*case TimestampType:
+	case TimestampType:
 		{
-			return &Number{}
-		}*/
+			return &Timestamp{val: time.Unix(0, 0).UTC()}
+		}
 	}
 	return nil
 }
@@ -171,6 +269,13 @@ func (gr *groupedRowReader) SetParameters(params map[string]interface{}) error {
 
 func (gr *groupedRowReader) Read() (*Row, error) {
 	for {
+		if len(gr.pending) > 0 {
+			r := gr.pending[0]
+			gr.pending = gr.pending[1:]
+
+			return r, nil
+		}
+
 		row, err := gr.rowReader.Read()
 		if err == store.ErrNoMoreEntries {
 			if !gr.nonEmpty && allAgregations(gr.selectors) {
@@ -187,8 +292,14 @@ func (gr *groupedRowReader) Read() (*Row, error) {
 					encSel := EncodeSelector(aggFn, db, table, col)
 
 					var zero TypedValue
-					if aggFn == COUNT || aggFn == SUM || aggFn == AVG {
+					if aggFn == COUNT || aggFn == COUNT_DISTINCT {
+						zero = zeroForType(IntegerType)
+					} else if gr.e.nullAggregations {
+						zero = &NullValue{t: colsBySelector[encSel].Type}
+					} else if aggFn == SUM || aggFn == AVG || aggFn == SUM_DISTINCT || aggFn == AVG_DISTINCT {
 						zero = zeroForType(IntegerType)
+					} else if aggFn == BOOL_AND {
+						zero = &Bool{val: true}
 					} else {
 						zero = zeroForType(colsBySelector[encSel].Type)
 					}
@@ -201,14 +312,18 @@ func (gr *groupedRowReader) Read() (*Row, error) {
 				return zeroRow, nil
 			}
 
-			if gr.currRow == nil {
-				return nil, err
+			for i, currRow := range gr.currRows {
+				if currRow != nil {
+					gr.pending = append(gr.pending, currRow)
+					gr.currRows[i] = nil
+				}
 			}
 
-			r := gr.currRow
-			gr.currRow = nil
+			if len(gr.pending) == 0 {
+				return nil, err
+			}
 
-			return r, nil
+			continue
 		}
 		if err != nil {
 			return nil, err
@@ -216,61 +331,89 @@ func (gr *groupedRowReader) Read() (*Row, error) {
 
 		gr.nonEmpty = true
 
-		if gr.currRow == nil {
-			gr.currRow = row
-			err = gr.initAggregations()
+		for i, groupingSet := range gr.groupingSets {
+			currRow := gr.currRows[i]
+
+			if currRow != nil {
+				compatible, err := currRow.compatible(row, groupingSet, gr.rowReader.ImplicitDB(), gr.rowReader.ImplicitTable())
+				if err != nil {
+					return nil, err
+				}
+
+				if compatible {
+					// compatible rows get merged
+					err = gr.updateAggregations(currRow, row)
+					if err != nil {
+						return nil, err
+					}
+
+					continue
+				}
+
+				gr.pending = append(gr.pending, currRow)
+			}
+
+			gr.currRows[i], err = gr.newGroup(row, groupingSet)
 			if err != nil {
 				return nil, err
 			}
-			continue
 		}
+	}
+}
 
-		compatible, err := gr.currRow.compatible(row, gr.groupBy, gr.rowReader.ImplicitDB(), gr.rowReader.ImplicitTable())
-		if err != nil {
-			return nil, err
-		}
+// newGroup returns the row holding the aggregations of the group the row belongs to within the grouping set,
+// the grouping columns rolled up, i.e. not in the grouping set, are set to NULL
+func (gr *groupedRowReader) newGroup(row *Row, groupingSet []*ColSelector) (*Row, error) {
+	groupRow := &Row{Values: make(map[string]TypedValue, len(row.Values)+len(gr.selectors))}
 
-		if !compatible {
-			r := gr.currRow
-			gr.currRow = row
+	for sel, val := range row.Values {
+		groupRow.Values[sel] = val
+	}
 
-			err = gr.initAggregations()
-			if err != nil {
-				return nil, err
-			}
+	err := gr.initAggregations(groupRow)
+	if err != nil {
+		return nil, err
+	}
 
-			return r, nil
-		}
+	for _, col := range gr.groupBy[len(groupingSet):] {
+		encSel := EncodeSelector(col.resolve(gr.rowReader.ImplicitDB(), gr.rowReader.ImplicitTable()))
+		groupRow.Values[encSel] = &NullValue{t: row.Values[encSel].Type()}
+	}
 
-		// Compatible rows get merged
-		for _, v := range gr.currRow.Values {
-			aggV, isAggregatedValue := v.(AggregatedValue)
+	return groupRow, nil
+}
 
-			if isAggregatedValue {
-				if aggV.ColBounded() {
-					val, exists := row.Values[aggV.Selector()]
-					if !exists {
-						return nil, ErrColumnDoesNotExist
-					}
+// updateAggregations updates the aggregations of the group with the values of the row
+func (gr *groupedRowReader) updateAggregations(groupRow *Row, row *Row) error {
+	for _, v := range groupRow.Values {
+		aggV, isAggregatedValue := v.(AggregatedValue)
 
-					err = aggV.updateWith(val)
-					if err != nil {
-						return nil, err
-					}
+		if isAggregatedValue {
+			if aggV.ColBounded() {
+				val, exists := row.Values[aggV.Selector()]
+				if !exists {
+					return ErrColumnDoesNotExist
 				}
 
-				if !aggV.ColBounded() {
-					err = aggV.updateWith(nil)
-					if err != nil {
-						return nil, err
-					}
+				err := aggV.updateWith(val)
+				if err != nil {
+					return err
+				}
+			}
+
+			if !aggV.ColBounded() {
+				err := aggV.updateWith(nil)
+				if err != nil {
+					return err
 				}
 			}
 		}
 	}
+
+	return nil
 }
 
-func (gr *groupedRowReader) initAggregations() error {
+func (gr *groupedRowReader) initAggregations(groupRow *Row) error {
 	// augment row with aggregated values
 	for _, sel := range gr.selectors {
 		aggFn, db, table, col := sel.resolve(gr.rowReader.ImplicitDB(), gr.rowReader.ImplicitTable())
@@ -284,33 +427,62 @@ func (gr *groupedRowReader) initAggregations() error {
 					return ErrLimitedCount
 				}
 
-				gr.currRow.Values[encSel] = &CountValue{sel: EncodeSelector("", db, table, col)}
+				groupRow.Values[encSel] = &CountValue{sel: EncodeSelector("", db, table, col)}
+			}
+		case COUNT_DISTINCT:
+			{
+				groupRow.Values[encSel] = &DistinctValue{
+					AggregatedValue: &CountValue{sel: EncodeSelector("", db, table, col)},
+					limit:           gr.e.distinctLimit,
+				}
+			}
+		case SUM_DISTINCT:
+			{
+				groupRow.Values[encSel] = &DistinctValue{
+					AggregatedValue: &SumValue{sel: EncodeSelector("", db, table, col)},
+					limit:           gr.e.distinctLimit,
+				}
+			}
+		case AVG_DISTINCT:
+			{
+				groupRow.Values[encSel] = &DistinctValue{
+					AggregatedValue: &AVGValue{sel: EncodeSelector("", db, table, col)},
+					limit:           gr.e.distinctLimit,
+				}
 			}
 		case SUM:
 			{
-				gr.currRow.Values[encSel] = &SumValue{sel: EncodeSelector("", db, table, col)}
+				groupRow.Values[encSel] = &SumValue{sel: EncodeSelector("", db, table, col)}
 			}
 		case MIN:
 			{
-				gr.currRow.Values[encSel] = &MinValue{sel: EncodeSelector("", db, table, col)}
+				groupRow.Values[encSel] = &MinValue{sel: EncodeSelector("", db, table, col)}
 			}
 		case MAX:
 			{
-				gr.currRow.Values[encSel] = &MaxValue{sel: EncodeSelector("", db, table, col)}
+				groupRow.Values[encSel] = &MaxValue{sel: EncodeSelector("", db, table, col)}
 			}
 		case AVG:
 			{
-				gr.currRow.Values[encSel] = &AVGValue{sel: EncodeSelector("", db, table, col)}
+				groupRow.Values[encSel] = &AVGValue{sel: EncodeSelector("", db, table, col)}
+			}
+		case BOOL_AND:
+			{
+				groupRow.Values[encSel] = &BoolAndValue{b: true, sel: EncodeSelector("", db, table, col)}
+			}
+		case BOOL_OR:
+			{
+				groupRow.Values[encSel] = &BoolOrValue{sel: EncodeSelector("", db, table, col)}
 			}
 		}
 	}
 
-	for _, v := range gr.currRow.Values {
+	for _, v := range groupRow.Values {
 		aggV, isAggregatedValue := v.(AggregatedValue)
 
 		if isAggregatedValue {
 			if aggV.ColBounded() {
-				val, exists := gr.currRow.Values[aggV.Selector()]
+				val, exists := groupRow.Values[aggV.Selector()]
 				if !exists {
 					return ErrColumnDoesNotExist
 				}
@@ -17,6 +17,7 @@ package sql
 
 import (
 	"os"
+	"reflect"
 	"testing"
 
 	"github.com/codenotary/immudb/embedded/store"
@@ -38,7 +39,7 @@ func TestGroupedRowReader(t *testing.T) {
 	err = engine.EnsureCatalogReady(nil)
 	require.NoError(t, err)
 
-	_, err = engine.newGroupedRowReader(nil, nil, nil)
+	_, err = engine.newGroupedRowReader(nil, nil, nil, false)
 	require.Equal(t, ErrIllegalArguments, err)
 
 	db, err := engine.catalog.newDatabase(1, "db1")
@@ -47,7 +48,7 @@ func TestGroupedRowReader(t *testing.T) {
 	table, err := db.newTable("table1", []*ColSpec{{colName: "id", colType: IntegerType}})
 	require.NoError(t, err)
 
-	index, err := table.newIndex(true, []uint32{1})
+	index, err := table.newIndex(true, false, []uint32{1})
 	require.NoError(t, err)
 	require.NotNil(t, index)
 	require.Equal(t, table.primaryIndex, index)
@@ -58,7 +59,7 @@ func TestGroupedRowReader(t *testing.T) {
 	r, err := engine.newRawRowReader(snap, table, 0, "", &ScanSpecs{index: table.primaryIndex})
 	require.NoError(t, err)
 
-	gr, err := engine.newGroupedRowReader(r, []Selector{&ColSelector{col: "id"}}, []*ColSelector{{col: "id"}})
+	gr, err := engine.newGroupedRowReader(r, []Selector{&ColSelector{col: "id"}}, []*ColSelector{{col: "id"}}, false)
 	require.NoError(t, err)
 
 	orderBy := gr.OrderBy()
@@ -71,6 +72,22 @@ func TestGroupedRowReader(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, cols, 1)
 
+	cachedCols, err := gr.Columns()
+	require.NoError(t, err)
+	require.True(t, &cols[0] == &cachedCols[0], "columns must be resolved only once")
+
+	colsBySel, err := gr.colsBySelector()
+	require.NoError(t, err)
+	require.Len(t, colsBySel, 1)
+
+	cachedColsBySel, err := gr.colsBySelector()
+	require.NoError(t, err)
+	require.Equal(t, reflect.ValueOf(colsBySel).Pointer(), reflect.ValueOf(cachedColsBySel).Pointer())
+
+	rawColsBySel, err := r.colsBySelector()
+	require.NoError(t, err)
+	require.Len(t, rawColsBySel, 1)
+
 	scanSpecs := gr.ScanSpecs()
 	require.NotNil(t, scanSpecs)
 	require.NotNil(t, scanSpecs.index)
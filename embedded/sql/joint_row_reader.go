@@ -33,10 +33,16 @@ type jointRowReader struct {
 
 	joins []*JoinSpec
 
+	// the first joined table drives the join in place of the table the query is defined over,
+	// which is read as the first join, thus it's still the implicit table and its columns come first
+	drivenByJoin bool
+
 	rowReaders       []RowReader
 	rowReadersValues []map[string]TypedValue
 
 	params map[string]interface{}
+
+	cols colsCache
 }
 
 func (e *Engine) newJointRowReader(db *Database, snap *store.Snapshot, params map[string]interface{}, rowReader RowReader, joins []*JoinSpec) (*jointRowReader, error) {
@@ -67,6 +73,10 @@ func (jointr *jointRowReader) ImplicitDB() string {
 }
 
 func (jointr *jointRowReader) ImplicitTable() string {
+	if jointr.drivenByJoin {
+		return jointr.joins[0].ds.Alias()
+	}
+
 	return jointr.rowReader.ImplicitTable()
 }
 
@@ -78,16 +88,34 @@ func (jointr *jointRowReader) ScanSpecs() *ScanSpecs {
 	return jointr.rowReader.ScanSpecs()
 }
 
+func (jointr *jointRowReader) TxHeader() (*store.TxHeader, error) {
+	return ReaderTxHeader(jointr.rowReader)
+}
+
+// Cursor is not supported, a row of the scan may be joined with many others
+func (jointr *jointRowReader) Cursor() ([]byte, error) {
+	return nil, ErrNoSupported
+}
+
 func (jointr *jointRowReader) Columns() ([]ColDescriptor, error) {
-	return jointr.colsByPos()
+	return jointr.cols.columns(jointr.colsByPos)
 }
 
 func (jointr *jointRowReader) colsBySelector() (map[string]ColDescriptor, error) {
-	colDescriptors, err := jointr.rowReader.colsBySelector()
+	return jointr.cols.columnsBySelector(jointr.resolveColsBySelector)
+}
+
+func (jointr *jointRowReader) resolveColsBySelector() (map[string]ColDescriptor, error) {
+	dsColDescriptors, err := jointr.rowReader.colsBySelector()
 	if err != nil {
 		return nil, err
 	}
 
+	colDescriptors := make(map[string]ColDescriptor, len(dsColDescriptors))
+	for sel, des := range dsColDescriptors {
+		colDescriptors[sel] = des
+	}
+
 	for _, jspec := range jointr.joins {
 
 		// TODO (byo) optimize this by getting selector list only or opening all joint readers
@@ -123,12 +151,15 @@ func (jointr *jointRowReader) colsBySelector() (map[string]ColDescriptor, error)
 }
 
 func (jointr *jointRowReader) colsByPos() ([]ColDescriptor, error) {
-	colDescriptors, err := jointr.rowReader.Columns()
+	dsColDescriptors, err := jointr.rowReader.Columns()
 	if err != nil {
 		return nil, err
 	}
 
-	for _, jspec := range jointr.joins {
+	colDescriptors := make([]ColDescriptor, len(dsColDescriptors))
+	copy(colDescriptors, dsColDescriptors)
+
+	for i, jspec := range jointr.joins {
 
 		// TODO (byo) optimize this by getting selector list only or opening all joint readers
 		//            on jointRowReader creation,
@@ -145,6 +176,11 @@ func (jointr *jointRowReader) colsByPos() ([]ColDescriptor, error) {
 			return nil, err
 		}
 
+		if i == 0 && jointr.drivenByJoin {
+			colDescriptors = append(append([]ColDescriptor{}, cd...), colDescriptors...)
+			continue
+		}
+
 		colDescriptors = append(colDescriptors, cd...)
 	}
 
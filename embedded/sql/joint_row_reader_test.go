@@ -49,7 +49,7 @@ func TestJointRowReader(t *testing.T) {
 	table, err := db.newTable("table1", []*ColSpec{{colName: "id", colType: IntegerType}, {colName: "number", colType: IntegerType}})
 	require.NoError(t, err)
 
-	index, err := table.newIndex(true, []uint32{1})
+	index, err := table.newIndex(true, false, []uint32{1})
 	require.NoError(t, err)
 	require.NotNil(t, index)
 	require.Equal(t, table.primaryIndex, index)
@@ -138,6 +138,12 @@ func TestJointRowReader(t *testing.T) {
 			cols, err := jr.Columns()
 			require.ErrorIs(t, err, errDummy)
 			require.Nil(t, cols)
+
+			jr.rowReader.(*dummyRowReader).failReturningColumns = false
+
+			cols, err = jr.Columns()
+			require.ErrorIs(t, err, errDummy, "first error must be kept")
+			require.Nil(t, cols)
 		})
 
 		t.Run("must propagate error from joined reader on colsBySelector", func(t *testing.T) {
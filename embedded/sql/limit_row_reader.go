@@ -15,20 +15,24 @@ limitations under the License.
 */
 package sql
 
+import "github.com/codenotary/immudb/embedded/store"
+
 type limitRowReader struct {
 	e *Engine
 
 	rowReader RowReader
 
-	limit int
-	read  int
+	limit  int // negative means no limit
+	offset int
+	read   int
 }
 
-func (e *Engine) newLimitRowReader(rowReader RowReader, limit int) (*limitRowReader, error) {
+func (e *Engine) newLimitRowReader(rowReader RowReader, limit, offset int) (*limitRowReader, error) {
 	return &limitRowReader{
 		e:         e,
 		rowReader: rowReader,
 		limit:     limit,
+		offset:    offset,
 	}, nil
 }
 
@@ -52,6 +56,14 @@ func (lr *limitRowReader) ScanSpecs() *ScanSpecs {
 	return lr.rowReader.ScanSpecs()
 }
 
+func (lr *limitRowReader) TxHeader() (*store.TxHeader, error) {
+	return ReaderTxHeader(lr.rowReader)
+}
+
+func (lr *limitRowReader) Cursor() ([]byte, error) {
+	return lr.rowReader.Cursor()
+}
+
 func (lr *limitRowReader) Columns() ([]ColDescriptor, error) {
 	return lr.rowReader.Columns()
 }
@@ -65,10 +77,17 @@ func (lr *limitRowReader) InferParameters(params map[string]SQLValueType) error
 }
 
 func (lr *limitRowReader) Read() (*Row, error) {
-	if lr.read >= lr.limit {
+	if lr.limit >= 0 && lr.read >= lr.limit {
 		return nil, ErrNoMoreRows
 	}
 
+	for ; lr.offset > 0; lr.offset-- {
+		_, err := lr.rowReader.Read()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	row, err := lr.rowReader.Read()
 	if err != nil {
 		return nil, err
@@ -37,7 +37,7 @@ func TestLimitRowReader(t *testing.T) {
 
 	dummyr := &dummyRowReader{failReturningColumns: false}
 
-	rowReader, err := engine.newLimitRowReader(dummyr, 1)
+	rowReader, err := engine.newLimitRowReader(dummyr, 1, 0)
 	require.NoError(t, err)
 
 	require.Equal(t, dummyr.ImplicitDB(), rowReader.ImplicitDB())
@@ -45,6 +45,9 @@ func TestLimitRowReader(t *testing.T) {
 	require.Equal(t, dummyr.OrderBy(), rowReader.OrderBy())
 	require.Equal(t, dummyr.ScanSpecs(), rowReader.ScanSpecs())
 
+	_, err = rowReader.TxHeader()
+	require.ErrorIs(t, err, errDummy)
+
 	dummyr.failReturningColumns = true
 	_, err = rowReader.Columns()
 	require.Equal(t, errDummy, err)
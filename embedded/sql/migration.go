@@ -0,0 +1,131 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+// Migration is a named unit of schema or data changes, applied at most once per database
+type Migration struct {
+	ID  string
+	SQL string
+}
+
+// migrationStmt runs the statements of a migration within a single transaction,
+// which also records the migration as applied. Already applied migrations are skipped
+type migrationStmt struct {
+	id string
+	tx *TxStmt
+}
+
+func (stmt *migrationStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	return stmt.tx.inferParameters(e, implicitDB, params)
+}
+
+func (stmt *migrationStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	if implicitDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
+
+	mkey := e.mapKey(migrationPrefix, EncodeID(implicitDB.id), []byte(stmt.id))
+
+	applied, err := e.isMigrationApplied(mkey)
+	if err != nil {
+		return nil, err
+	}
+
+	if applied {
+		return newTxSummary(implicitDB), nil
+	}
+
+	summary, err = stmt.tx.compileUsing(e, implicitDB, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// the migration is recorded in the same store its statements are written to,
+	// so it's only seen as applied once its changes are committed
+	entry := &store.EntrySpec{Key: mkey, Value: []byte{}}
+
+	if len(summary.des) > 0 {
+		summary.des = append(summary.des, entry)
+	} else {
+		summary.ces = append(summary.ces, entry)
+	}
+
+	return summary, nil
+}
+
+func (e *Engine) isMigrationApplied(mkey []byte) (bool, error) {
+	stores := []*store.ImmuStore{e.catalogStore}
+	if e.dataStore != e.catalogStore {
+		stores = append(stores, e.dataStore)
+	}
+
+	for _, st := range stores {
+		lastTxID, _ := st.Alh()
+		err := st.WaitForIndexingUpto(lastTxID, nil)
+		if err != nil {
+			return false, err
+		}
+
+		_, err = st.Get(mkey)
+		if err == nil {
+			return true, nil
+		}
+		if err != store.ErrKeyNotFound {
+			return false, err
+		}
+	}
+
+	return false, nil
+}
+
+// ApplyMigrations runs, in order, the migrations not yet applied to the database in use.
+// Each migration runs within its own transaction, thus it can not combine DDL and DML statements.
+// Migrations are applied until the first failing one, whose error is returned
+func (e *Engine) ApplyMigrations(migrations []Migration) error {
+	if len(migrations) == 0 {
+		return ErrIllegalArguments
+	}
+
+	ids := make(map[string]struct{}, len(migrations))
+	stmts := make([]SQLStmt, len(migrations))
+
+	for i, m := range migrations {
+		_, duplicated := ids[m.ID]
+		if m.ID == "" || duplicated {
+			return fmt.Errorf("%w (migration ids must be unique and not empty)", ErrIllegalArguments)
+		}
+
+		ids[m.ID] = struct{}{}
+
+		parsed, err := Parse(strings.NewReader(m.SQL))
+		if err != nil {
+			return fmt.Errorf("migration %s: %w", m.ID, err)
+		}
+
+		stmts[i] = &migrationStmt{id: m.ID, tx: &TxStmt{stmts: parsed}}
+	}
+
+	_, err := e.execPreparedStmts(nil, stmts, nil, true)
+
+	return err
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"os"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyMigrations(t *testing.T) {
+	catalogStore, err := store.Open("catalog_migrations", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_migrations")
+
+	dataStore, err := store.Open("sqldata_migrations", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_migrations")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	err = engine.ApplyMigrations([]Migration{{ID: "1", SQL: "CREATE TABLE t1 (id INTEGER, PRIMARY KEY id)"}})
+	require.ErrorIs(t, err, ErrNoDatabaseSelected)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	migrations := []Migration{
+		{ID: "001_users", SQL: "CREATE TABLE users (id INTEGER AUTO_INCREMENT, name VARCHAR[64], PRIMARY KEY id)"},
+		{ID: "002_users_index", SQL: "CREATE INDEX ON users(name)"},
+		{ID: "003_admin", SQL: "INSERT INTO users (name) VALUES ('admin'); INSERT INTO users (name) VALUES ('guest')"},
+	}
+
+	countUsers := func(t *testing.T) int {
+		rows, _, err := engine.QueryAll("SELECT id FROM users", nil)
+		require.NoError(t, err)
+		return len(rows)
+	}
+
+	t.Run("invalid migrations should fail", func(t *testing.T) {
+		err := engine.ApplyMigrations(nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		err = engine.ApplyMigrations([]Migration{{SQL: "CREATE TABLE t1 (id INTEGER, PRIMARY KEY id)"}})
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		err = engine.ApplyMigrations([]Migration{migrations[0], migrations[0]})
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		err = engine.ApplyMigrations([]Migration{{ID: "1", SQL: "CREATE TABLE"}})
+		require.Error(t, err)
+
+		_, err = engine.catalog.GetTableByName("db1", "users")
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
+	})
+
+	t.Run("migrations should be applied in order", func(t *testing.T) {
+		err := engine.ApplyMigrations(migrations)
+		require.NoError(t, err)
+
+		table, err := engine.catalog.GetTableByName("db1", "users")
+		require.NoError(t, err)
+		require.Len(t, table.indexes, 2)
+
+		require.Equal(t, 2, countUsers(t))
+	})
+
+	t.Run("applied migrations should be skipped", func(t *testing.T) {
+		err := engine.ApplyMigrations(migrations)
+		require.NoError(t, err)
+
+		require.Equal(t, 2, countUsers(t))
+
+		err = engine.ApplyMigrations(append(migrations, Migration{ID: "004_guest", SQL: "DELETE FROM users WHERE name = 'guest'"}))
+		require.NoError(t, err)
+
+		require.Equal(t, 1, countUsers(t))
+	})
+
+	t.Run("a failing migration should stop the following ones", func(t *testing.T) {
+		err := engine.ApplyMigrations([]Migration{
+			{ID: "005_user", SQL: "INSERT INTO users (name) VALUES ('user')"},
+			{ID: "006_mixed", SQL: "CREATE TABLE t1 (id INTEGER, PRIMARY KEY id); INSERT INTO users (name) VALUES ('mixed')"},
+			{ID: "007_user", SQL: "INSERT INTO users (name) VALUES ('user2')"},
+		})
+		require.ErrorIs(t, err, ErrDDLorDMLTxOnly)
+
+		err = engine.ApplyMigrations([]Migration{
+			{ID: "005_user", SQL: "INSERT INTO users (name) VALUES ('user')"},
+			{ID: "006_mixed", SQL: "CREATE TABLE t1 (id INTEGER, PRIMARY KEY id)"},
+			{ID: "007_user", SQL: "INSERT INTO users (name) VALUES ('user2')"},
+		})
+		require.NoError(t, err)
+
+		// 005_user was applied by the failed attempt thus only 007_user adds a new user
+		require.Equal(t, 3, countUsers(t))
+
+		_, err = engine.catalog.GetTableByName("db1", "t1")
+		require.NoError(t, err)
+	})
+
+	t.Run("migrations should be tracked per database", func(t *testing.T) {
+		_, err := engine.ExecStmt("CREATE DATABASE db2", nil, true)
+		require.NoError(t, err)
+
+		err = engine.UseDatabase("db2")
+		require.NoError(t, err)
+
+		err = engine.ApplyMigrations(migrations)
+		require.NoError(t, err)
+
+		_, err = engine.catalog.GetTableByName("db2", "users")
+		require.NoError(t, err)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
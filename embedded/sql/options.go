@@ -15,21 +15,35 @@ limitations under the License.
 */
 package sql
 
-var defultDistinctLimit = 1 << 20 // ~ 1mi rows
+var defultDistinctLimit = 1 << 20  // ~ 1mi rows
+var defaultMaxResultSize = 1 << 20 // ~ 1mi rows
 
 type Options struct {
-	prefix        []byte
-	distinctLimit int
+	prefix             []byte
+	distinctLimit      int
+	indexCacheSize     int
+	maxResultSize      int
+	maxScanRows        int
+	maxIndexesPerTable int
+	maxVarcharValueLen int
+	maxJoinDepth       int
+	truncateValues     bool
+	nullAggregations   bool
+	exportNulls        NullRendering
+	auditHook          AuditHook
 }
 
 func DefaultOptions() *Options {
 	return &Options{
 		distinctLimit: defultDistinctLimit,
+		maxResultSize: defaultMaxResultSize,
 	}
 }
 
 func ValidOpts(opts *Options) bool {
-	return opts != nil && opts.distinctLimit > 0
+	return opts != nil && opts.distinctLimit > 0 && opts.indexCacheSize >= 0 && opts.maxResultSize > 0 && opts.maxScanRows >= 0 &&
+		opts.maxIndexesPerTable >= 0 && opts.maxVarcharValueLen >= 0 && opts.maxJoinDepth >= 0 &&
+		opts.exportNulls >= NullAsDefault && opts.exportNulls <= NullAsJSONNull
 }
 
 func (opts *Options) WithPrefix(prefix []byte) *Options {
@@ -41,3 +55,72 @@ func (opts *Options) WithDistinctLimit(distinctLimit int) *Options {
 	opts.distinctLimit = distinctLimit
 	return opts
 }
+
+// WithIndexCacheSize sets the number of rows kept in memory when accessing them through secondary indexes,
+// a size of zero (the default) disables caching
+func (opts *Options) WithIndexCacheSize(indexCacheSize int) *Options {
+	opts.indexCacheSize = indexCacheSize
+	return opts
+}
+
+// WithMaxResultSize sets the maximum number of rows QueryAll loads into memory
+func (opts *Options) WithMaxResultSize(maxResultSize int) *Options {
+	opts.maxResultSize = maxResultSize
+	return opts
+}
+
+// WithMaxScanRows sets the maximum number of rows a table scan may read before they are filtered,
+// a value of zero (the default) means no limit
+func (opts *Options) WithMaxScanRows(maxScanRows int) *Options {
+	opts.maxScanRows = maxScanRows
+	return opts
+}
+
+// WithMaxIndexesPerTable sets the maximum number of secondary indexes a table may have, as every index
+// adds a key written per row. A value of zero (the default) means no limit
+func (opts *Options) WithMaxIndexesPerTable(maxIndexesPerTable int) *Options {
+	opts.maxIndexesPerTable = maxIndexesPerTable
+	return opts
+}
+
+// WithMaxVarcharValueLen sets the maximum length of the values stored into VARCHAR columns declared without a length,
+// longer values are rejected with ErrMaxLengthExceeded. A value of zero (the default) means no limit
+func (opts *Options) WithMaxVarcharValueLen(maxVarcharValueLen int) *Options {
+	opts.maxVarcharValueLen = maxVarcharValueLen
+	return opts
+}
+
+// WithMaxJoinDepth sets the maximum number of relations a query may join, including those joined within derived tables,
+// deeper queries are rejected with ErrJoinDepthExceeded. A value of zero (the default) means no limit
+func (opts *Options) WithMaxJoinDepth(maxJoinDepth int) *Options {
+	opts.maxJoinDepth = maxJoinDepth
+	return opts
+}
+
+// WithTruncateValues sets whether VARCHAR and BLOB values longer than the max length of the column they are
+// assigned to are truncated, by default (false) they are rejected with ErrMaxLengthExceeded
+func (opts *Options) WithTruncateValues(truncateValues bool) *Options {
+	opts.truncateValues = truncateValues
+	return opts
+}
+
+// WithNullAggregations sets whether aggregations other than COUNT evaluate to NULL over an empty set of rows,
+// as standard SQL does. By default (false) they evaluate to the zero value of their type
+func (opts *Options) WithNullAggregations(nullAggregations bool) *Options {
+	opts.nullAggregations = nullAggregations
+	return opts
+}
+
+// WithExportNulls sets how NULL values are written by ExportCSV and QueryToNDJSON, by default (NullAsDefault)
+// they are written as empty CSV fields and as JSON null
+func (opts *Options) WithExportNulls(exportNulls NullRendering) *Options {
+	opts.exportNulls = exportNulls
+	return opts
+}
+
+// WithAuditHook sets a function called with every statement given as text, i.e. to ExecStmt, QueryStmt, QueryAll
+// or QueryToNDJSON, both when it succeeds and when it fails
+func (opts *Options) WithAuditHook(auditHook AuditHook) *Options {
+	opts.auditHook = auditHook
+	return opts
+}
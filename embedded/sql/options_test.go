@@ -36,5 +36,70 @@ func TestOptions(t *testing.T) {
 	opts.WithPrefix([]byte("sqlPrefix"))
 	require.Equal(t, []byte("sqlPrefix"), opts.prefix)
 
+	require.False(t, ValidOpts(opts))
+
+	opts.WithMaxResultSize(defaultMaxResultSize)
+	require.Equal(t, defaultMaxResultSize, opts.maxResultSize)
+
+	require.True(t, ValidOpts(opts))
+
+	opts.WithIndexCacheSize(-1)
+	require.False(t, ValidOpts(opts))
+
+	opts.WithIndexCacheSize(100)
+	require.Equal(t, 100, opts.indexCacheSize)
+
+	require.True(t, ValidOpts(opts))
+
+	opts.WithMaxScanRows(-1)
+	require.False(t, ValidOpts(opts))
+
+	opts.WithMaxScanRows(1000)
+	require.Equal(t, 1000, opts.maxScanRows)
+
+	require.True(t, ValidOpts(opts))
+
+	opts.WithMaxIndexesPerTable(-1)
+	require.False(t, ValidOpts(opts))
+
+	opts.WithMaxIndexesPerTable(4)
+	require.Equal(t, 4, opts.maxIndexesPerTable)
+
+	require.True(t, ValidOpts(opts))
+
+	opts.WithMaxVarcharValueLen(-1)
+	require.False(t, ValidOpts(opts))
+
+	opts.WithMaxVarcharValueLen(1024)
+	require.Equal(t, 1024, opts.maxVarcharValueLen)
+
+	require.True(t, ValidOpts(opts))
+
+	opts.WithMaxJoinDepth(-1)
+	require.False(t, ValidOpts(opts))
+
+	opts.WithMaxJoinDepth(3)
+	require.Equal(t, 3, opts.maxJoinDepth)
+
+	require.True(t, ValidOpts(opts))
+
+	require.False(t, opts.truncateValues)
+
+	opts.WithTruncateValues(true)
+	require.True(t, opts.truncateValues)
+
+	require.False(t, opts.nullAggregations)
+
+	opts.WithNullAggregations(true)
+	require.True(t, opts.nullAggregations)
+
+	require.Equal(t, NullAsDefault, opts.exportNulls)
+
+	opts.WithExportNulls(NullAsJSONNull + 1)
+	require.False(t, ValidOpts(opts))
+
+	opts.WithExportNulls(NullAsLiteral)
+	require.Equal(t, NullAsLiteral, opts.exportNulls)
+
 	require.True(t, ValidOpts(opts))
 }
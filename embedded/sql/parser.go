@@ -18,6 +18,7 @@ package sql
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -44,6 +45,7 @@ var reservedWords = map[string]int{
 	"ON":             ON,
 	"ALTER":          ALTER,
 	"ADD":            ADD,
+	"DROP":           DROP,
 	"COLUMN":         COLUMN,
 	"INSERT":         INSERT,
 	"UPSERT":         UPSERT,
@@ -60,23 +62,60 @@ var reservedWords = map[string]int{
 	"FROM":           FROM,
 	"BEFORE":         BEFORE,
 	"TX":             TX,
+	"FOR":            FOR,
+	"SYSTEM_TIME":    SYSTEM_TIME,
+	"OF":             OF,
+	"CURRENT":        CURRENT,
 	"JOIN":           JOIN,
 	"HAVING":         HAVING,
 	"WHERE":          WHERE,
 	"GROUP":          GROUP,
 	"BY":             BY,
 	"LIMIT":          LIMIT,
+	"OFFSET":         OFFSET,
+	"FETCH":          FETCH,
+	"FIRST":          FIRST,
+	"NEXT":           NEXT,
+	"ROW":            ROW,
+	"ROWS":           ROWS,
+	"ONLY":           ONLY,
 	"ORDER":          ORDER,
 	"AS":             AS,
 	"ASC":            ASC,
 	"DESC":           DESC,
 	"NOT":            NOT,
 	"LIKE":           LIKE,
+	"ESCAPE":         ESCAPE,
 	"EXISTS":         EXISTS,
+	"INCLUDING":      INCLUDING,
+	"INDEXES":        INDEXES,
 	"IN":             IN,
 	"AUTO_INCREMENT": AUTO_INCREMENT,
 	"NULL":           NULL,
+	"DEFAULT":        DEFAULT,
+	"GENERATED":      GENERATED,
+	"ALWAYS":         ALWAYS,
+	"STORED":         STORED,
 	"IF":             IF,
+	"WITH":           WITH,
+	"COMMENT":        COMMENT,
+	"IS":             IS,
+	"OVER":           OVER,
+	"UNKNOWN":        UNKNOWN,
+	"ROLLUP":         ROLLUP,
+	"PARTITION":      PARTITION,
+	"MERGE":          MERGE,
+	"USING":          USING,
+	"WHEN":           WHEN,
+	"MATCHED":        MATCHED,
+	"THEN":           THEN,
+	"CONFLICT":       CONFLICT,
+	"DO":             DO,
+	"NOTHING":        NOTHING,
+	"RETURNING":      RETURNING,
+	"NULLS":          NULLS,
+	"TYPE":           TYPE_KW,
+	"ALL":            ALL,
 }
 
 var joinTypes = map[string]JoinType{
@@ -94,11 +133,13 @@ var types = map[string]SQLValueType{
 }
 
 var aggregateFns = map[string]AggregateFn{
-	"COUNT": COUNT,
-	"SUM":   SUM,
-	"MAX":   MAX,
-	"MIN":   MIN,
-	"AVG":   AVG,
+	"COUNT":    COUNT,
+	"SUM":      SUM,
+	"MAX":      MAX,
+	"MIN":      MIN,
+	"AVG":      AVG,
+	"BOOL_AND": BOOL_AND,
+	"BOOL_OR":  BOOL_OR,
 }
 
 var boolValues = map[string]bool{
@@ -166,13 +207,17 @@ func (ar *aheadByteReader) NextByte() (byte, error) {
 	return ar.nextChar, ar.nextErr
 }
 
+func init() {
+	// set once as statements may be concurrently parsed
+	yyErrorVerbose = true
+}
+
 func ParseString(sql string) ([]SQLStmt, error) {
 	return Parse(strings.NewReader(sql))
 }
 
 func Parse(r io.ByteReader) ([]SQLStmt, error) {
 	lexer := newLexer(r)
-	yyErrorVerbose = true
 
 	yyParse(lexer)
 
@@ -276,6 +321,34 @@ func (l *lexer) Lex(lval *yySymType) int {
 		}
 
 		w := fmt.Sprintf("%c%s", ch, tail)
+
+		if isBase64BLOBPrefix(w) && isQuote(l.r.nextChar) {
+			l.r.ReadByte() // consume starting quote
+
+			tail, err := l.readString()
+			if err != nil {
+				lval.err = err
+				return ERROR
+			}
+
+			if !isQuote(l.r.nextChar) {
+				lval.err = fmt.Errorf("syntax error: unexpected char %c, expecting quote", l.r.nextChar)
+				return ERROR
+			}
+
+			l.r.ReadByte() // consume closing quote
+
+			val, err := base64.StdEncoding.DecodeString(tail)
+			if err != nil {
+				l.err = fmt.Errorf("%w (malformed base64 BLOB literal)", ErrInvalidValue)
+				lval.err = l.err
+				return ERROR
+			}
+
+			lval.blob = val
+			return BLOB
+		}
+
 		tid := strings.ToUpper(w)
 
 		sqlType, ok := types[tid]
@@ -310,6 +383,8 @@ func (l *lexer) Lex(lval *yySymType) int {
 
 		tkn, ok := reservedWords[tid]
 		if ok {
+			// keywords which are not reserved may be taken as identifiers
+			lval.id = strings.ToLower(w)
 			return tkn
 		}
 
@@ -436,6 +511,11 @@ func (l *lexer) Lex(lval *yySymType) int {
 }
 
 func (l *lexer) Error(err string) {
+	// an invalid value found while scanning is reported instead of the syntax error it causes
+	if errors.Is(l.err, ErrInvalidValue) {
+		return
+	}
+
 	l.err = errors.New(err)
 }
 
@@ -488,6 +568,10 @@ func isBLOBPrefix(ch byte) bool {
 	return 'x' == ch
 }
 
+func isBase64BLOBPrefix(w string) bool {
+	return "b64" == w
+}
+
 func isSeparator(ch byte) bool {
 	return ';' == ch
 }
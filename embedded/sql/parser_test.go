@@ -143,6 +143,36 @@ func TestCreateTableStmt(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "CREATE TABLE table1 (id INTEGER, db VARCHAR NOT NULL DEFAULT CURRENT_DATABASE(), qty INTEGER DEFAULT -1, PRIMARY KEY id)",
+			expectedOutput: []SQLStmt{
+				&CreateTableStmt{
+					table:       "table1",
+					ifNotExists: false,
+					colsSpec: []*ColSpec{
+						{colName: "id", colType: IntegerType},
+						{colName: "db", colType: VarcharType, notNull: true, defaultValue: &SysFn{fn: "current_database"}},
+						{colName: "qty", colType: IntegerType, defaultValue: &NumExp{left: &Number{val: 0}, op: SUBSOP, right: &Number{val: 1}}},
+					},
+					pkColNames: []string{"id"},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "CREATE TABLE table1 (id INTEGER, price INTEGER, total INTEGER GENERATED ALWAYS AS (price * 2) STORED NOT NULL, PRIMARY KEY id)",
+			expectedOutput: []SQLStmt{
+				&CreateTableStmt{
+					table:       "table1",
+					ifNotExists: false,
+					colsSpec: []*ColSpec{
+						{colName: "id", colType: IntegerType},
+						{colName: "price", colType: IntegerType},
+						{colName: "total", colType: IntegerType, notNull: true, generatedAs: &NumExp{left: &ColSelector{col: "price"}, op: MULTOP, right: &Number{val: 2}}},
+					},
+					pkColNames: []string{"id"},
+				}},
+			expectedError: nil,
+		},
 		{
 			input: "CREATE TABLE xtable1 (xid INTEGER, PRIMARY KEY xid)",
 			expectedOutput: []SQLStmt{
@@ -182,6 +212,21 @@ func TestCreateTableStmt(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input:          "CREATE TABLE table2 (LIKE table1)",
+			expectedOutput: []SQLStmt{&CreateTableLikeStmt{table: "table2", sourceTable: "table1"}},
+			expectedError:  nil,
+		},
+		{
+			input:          "CREATE TABLE IF NOT EXISTS table2 (LIKE table1 INCLUDING INDEXES)",
+			expectedOutput: []SQLStmt{&CreateTableLikeStmt{table: "table2", ifNotExists: true, sourceTable: "table1", includingIndexes: true}},
+			expectedError:  nil,
+		},
+		{
+			input:          "CREATE TABLE table2 (LIKE table1 INCLUDING)",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected ')', expecting INDEXES"),
+		},
 		{
 			input:          "CREATE table1",
 			expectedOutput: nil,
@@ -195,7 +240,40 @@ func TestCreateTableStmt(t *testing.T) {
 		{
 			input:          "CREATE TABLE table1()",
 			expectedOutput: []SQLStmt{&CreateTableStmt{table: "table1"}},
-			expectedError:  errors.New("syntax error: unexpected ')', expecting IDENTIFIER"),
+			expectedError:  errors.New("syntax error: unexpected ')'"),
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+
+		if tc.expectedError == nil {
+			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
+		}
+	}
+}
+
+func TestDropTableStmt(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput []SQLStmt
+		expectedError  error
+	}{
+		{
+			input:          "DROP TABLE table1",
+			expectedOutput: []SQLStmt{&DropTableStmt{table: "table1"}},
+			expectedError:  nil,
+		},
+		{
+			input:          "DROP TABLE IF EXISTS table1",
+			expectedOutput: []SQLStmt{&DropTableStmt{table: "table1", ifExists: true}},
+			expectedError:  nil,
+		},
+		{
+			input:          "DROP TABLE",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected $end"),
 		},
 	}
 
@@ -235,6 +313,54 @@ func TestCreateIndexStmt(t *testing.T) {
 			expectedOutput: []SQLStmt{&CreateIndexStmt{unique: true, table: "table1", cols: []string{"id", "title"}}},
 			expectedError:  nil,
 		},
+		{
+			input:          "CREATE UNIQUE INDEX ON table1(email) NULLS DISTINCT",
+			expectedOutput: []SQLStmt{&CreateIndexStmt{unique: true, nullable: true, table: "table1", cols: []string{"email"}}},
+			expectedError:  nil,
+		},
+		{
+			input:          "CREATE INDEX IF NOT EXISTS ON table1(id, title) NULLS DISTINCT",
+			expectedOutput: []SQLStmt{&CreateIndexStmt{ifNotExists: true, nullable: true, table: "table1", cols: []string{"id", "title"}}},
+			expectedError:  nil,
+		},
+		{
+			input:          "CREATE INDEX ON table1(id) NULLS",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected $end, expecting DISTINCT"),
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+
+		if tc.expectedError == nil {
+			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
+		}
+	}
+}
+
+func TestDropIndexStmt(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput []SQLStmt
+		expectedError  error
+	}{
+		{
+			input:          "DROP INDEX ON table1(title)",
+			expectedOutput: []SQLStmt{&DropIndexStmt{table: "table1", cols: []string{"title"}}},
+			expectedError:  nil,
+		},
+		{
+			input:          "DROP INDEX IF EXISTS ON table1(title, active)",
+			expectedOutput: []SQLStmt{&DropIndexStmt{ifExists: true, table: "table1", cols: []string{"title", "active"}}},
+			expectedError:  nil,
+		},
+		{
+			input:          "DROP INDEX table1(title)",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected IDENTIFIER, expecting ON"),
+		},
 	}
 
 	for i, tc := range testCases {
@@ -271,10 +397,252 @@ func TestAlterTableStmt(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "ALTER TABLE table1 AUTO_INCREMENT = 100",
+			expectedOutput: []SQLStmt{
+				&AlterAutoIncrementStmt{
+					table:     "table1",
+					op:        EQ,
+					nextValue: 100,
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "ALTER TABLE table1 ALTER COLUMN title DROP DEFAULT",
+			expectedOutput: []SQLStmt{
+				&DropDefaultStmt{
+					table: "table1",
+					col:   "title",
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "ALTER TABLE table1 ALTER COLUMN title TYPE VARCHAR[50]",
+			expectedOutput: []SQLStmt{
+				&AlterColumnTypeStmt{
+					table:   "table1",
+					col:     "title",
+					colType: VarcharType,
+					maxLen:  50,
+				}},
+			expectedError: nil,
+		},
 		{
 			input:          "ALTER TABLE table1 COLUMN title VARCHAR",
 			expectedOutput: nil,
-			expectedError:  errors.New("syntax error: unexpected COLUMN, expecting ADD"),
+			expectedError:  errors.New("syntax error: unexpected COLUMN, expecting ALTER or ADD or AUTO_INCREMENT"),
+		},
+		{
+			input:          "ALTER TABLE table1 ALTER COLUMN title DROP",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected $end, expecting DEFAULT"),
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+
+		if tc.expectedError == nil {
+			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
+		}
+	}
+}
+
+func TestUpdateStmt(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput []SQLStmt
+		expectedError  error
+	}{
+		{
+			input: "UPDATE table1 SET title = 'a', (active, amount) = (true, @amount) WHERE id = 1",
+			expectedOutput: []SQLStmt{
+				&UpdateStmt{
+					tableRef: &tableRef{table: "table1"},
+					updates: []*colUpdate{
+						{col: "title", op: EQ, val: &Varchar{val: "a"}},
+					},
+					tupleUpdates: []*tupleUpdate{
+						{cols: []string{"active", "amount"}, op: EQ, vals: []ValueExp{&Bool{val: true}, &Param{id: "amount"}}},
+					},
+					where: &CmpBoolExp{
+						op:    EQ,
+						left:  &ColSelector{col: "id"},
+						right: &Number{val: 1},
+					},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input: "UPDATE table1 SET (title, amount) = (SELECT name, total FROM table2)",
+			expectedOutput: []SQLStmt{
+				&UpdateStmt{
+					tableRef: &tableRef{table: "table1"},
+					tupleUpdates: []*tupleUpdate{
+						{
+							cols: []string{"title", "amount"},
+							op:   EQ,
+							q: &SelectStmt{
+								ds:        &tableRef{table: "table2"},
+								selectors: []Selector{&ColSelector{col: "name"}, &ColSelector{col: "total"}},
+							},
+						},
+					},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input: "UPDATE table1 SET title = 'a' WHERE CURRENT OF @cursor",
+			expectedOutput: []SQLStmt{
+				&UpdateStmt{
+					tableRef: &tableRef{table: "table1"},
+					updates: []*colUpdate{
+						{col: "title", op: EQ, val: &Varchar{val: "a"}},
+					},
+					currentOf: &Param{id: "cursor"},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input: "DELETE FROM table1 WHERE CURRENT OF @cursor",
+			expectedOutput: []SQLStmt{
+				&DeleteFromStmt{
+					tableRef:  &tableRef{table: "table1"},
+					currentOf: &Param{id: "cursor"},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input:          "UPDATE table1 SET (title, amount) = 1",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected NUMBER, expecting '('"),
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+
+		if tc.expectedError == nil {
+			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
+		}
+	}
+}
+
+func TestMergeStmt(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput []SQLStmt
+		expectedError  error
+	}{
+		{
+			input: `MERGE INTO table1 t USING table2 AS s ON t.id = s.id
+					WHEN MATCHED THEN UPDATE SET title = s.title
+					WHEN NOT MATCHED THEN INSERT (id, title) VALUES (s.id, s.title)`,
+			expectedOutput: []SQLStmt{
+				&MergeStmt{
+					target: &tableRef{table: "table1", as: "t"},
+					source: &tableRef{table: "table2", as: "s"},
+					on: &CmpBoolExp{
+						op:    EQ,
+						left:  &ColSelector{table: "t", col: "id"},
+						right: &ColSelector{table: "s", col: "id"},
+					},
+					updates: []*colUpdate{
+						{col: "title", op: EQ, val: &ColSelector{table: "s", col: "title"}},
+					},
+					insertCols:   []string{"id", "title"},
+					insertValues: []ValueExp{&ColSelector{table: "s", col: "id"}, &ColSelector{table: "s", col: "title"}},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input: "MERGE INTO table1 USING (SELECT id FROM table2) s ON table1.id = s.id WHEN MATCHED THEN UPDATE SET active = true",
+			expectedOutput: []SQLStmt{
+				&MergeStmt{
+					target: &tableRef{table: "table1"},
+					source: &SelectStmt{
+						ds:        &tableRef{table: "table2"},
+						selectors: []Selector{&ColSelector{col: "id"}},
+						as:        "s",
+					},
+					on: &CmpBoolExp{
+						op:    EQ,
+						left:  &ColSelector{table: "table1", col: "id"},
+						right: &ColSelector{table: "s", col: "id"},
+					},
+					updates: []*colUpdate{
+						{col: "active", op: EQ, val: &Bool{val: true}},
+					},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input: "MERGE INTO table1 USING table2 ON table1.id = table2.id WHEN NOT MATCHED THEN INSERT (id) VALUES (table2.id)",
+			expectedOutput: []SQLStmt{
+				&MergeStmt{
+					target: &tableRef{table: "table1"},
+					source: &tableRef{table: "table2"},
+					on: &CmpBoolExp{
+						op:    EQ,
+						left:  &ColSelector{table: "table1", col: "id"},
+						right: &ColSelector{table: "table2", col: "id"},
+					},
+					insertCols:   []string{"id"},
+					insertValues: []ValueExp{&ColSelector{table: "table2", col: "id"}},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input:          "MERGE INTO table1 USING table2 ON table1.id = table2.id",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected $end"),
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+
+		if tc.expectedError == nil {
+			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
+		}
+	}
+}
+
+func TestCommentStmt(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput []SQLStmt
+		expectedError  error
+	}{
+		{
+			input:          "COMMENT ON TABLE table1 IS 'holds the orders'",
+			expectedOutput: []SQLStmt{&CommentStmt{table: "table1", comment: "holds the orders"}},
+			expectedError:  nil,
+		},
+		{
+			input:          "COMMENT ON COLUMN table1.amount IS 'in cents'",
+			expectedOutput: []SQLStmt{&CommentStmt{table: "table1", col: "amount", comment: "in cents"}},
+			expectedError:  nil,
+		},
+		{
+			input:          "comment on column table1.amount is null",
+			expectedOutput: []SQLStmt{&CommentStmt{table: "table1", col: "amount"}},
+			expectedError:  nil,
+		},
+		{
+			input:          "COMMENT ON TABLE table1 IS 1",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected NUMBER, expecting NULL or VARCHAR"),
 		},
 	}
 
@@ -418,15 +786,109 @@ func TestInsertIntoStmt(t *testing.T) {
 			},
 			expectedError: nil,
 		},
+		{
+			input: "INSERT INTO table1(id, title) VALUES (1, DEFAULT), (DEFAULT, 'title2')",
+			expectedOutput: []SQLStmt{
+				&UpsertIntoStmt{
+					isInsert: true,
+					tableRef: &tableRef{table: "table1"},
+					cols:     []string{"id", "title"},
+					rows: []*RowSpec{
+						{Values: []ValueExp{&Number{val: 1}, &DefaultValue{}}},
+						{Values: []ValueExp{&DefaultValue{}, &Varchar{val: "title2"}}},
+					},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input: "INSERT INTO table1(id, email) VALUES (1, 'a@b.c') ON CONFLICT (email) DO UPDATE SET id = excluded.id",
+			expectedOutput: []SQLStmt{
+				&UpsertIntoStmt{
+					isInsert: true,
+					tableRef: &tableRef{table: "table1"},
+					cols:     []string{"id", "email"},
+					rows: []*RowSpec{
+						{Values: []ValueExp{&Number{val: 1}, &Varchar{val: "a@b.c"}}},
+					},
+					onConflict: &conflictClause{
+						target: []string{"email"},
+						updates: []*colUpdate{
+							{col: "id", op: EQ, val: &ColSelector{table: "excluded", col: "id"}},
+						},
+					},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input: "INSERT INTO table1(id) VALUES (1) ON CONFLICT DO NOTHING",
+			expectedOutput: []SQLStmt{
+				&UpsertIntoStmt{
+					isInsert: true,
+					tableRef: &tableRef{table: "table1"},
+					cols:     []string{"id"},
+					rows: []*RowSpec{
+						{Values: []ValueExp{&Number{val: 1}}},
+					},
+					onConflict: &conflictClause{},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input: "UPSERT INTO table1(id, title) VALUES (1, 'title1') RETURNING OLD.*, NEW.*",
+			expectedOutput: []SQLStmt{
+				&UpsertIntoStmt{
+					tableRef: &tableRef{table: "table1"},
+					cols:     []string{"id", "title"},
+					rows: []*RowSpec{
+						{Values: []ValueExp{&Number{val: 1}, &Varchar{val: "title1"}}},
+					},
+					returning: []string{"old", "new"},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input: "INSERT INTO table1(id) VALUES (1) ON CONFLICT DO UPDATE SET id = 2 RETURNING new.*",
+			expectedOutput: []SQLStmt{
+				&UpsertIntoStmt{
+					isInsert: true,
+					tableRef: &tableRef{table: "table1"},
+					cols:     []string{"id"},
+					rows: []*RowSpec{
+						{Values: []ValueExp{&Number{val: 1}}},
+					},
+					onConflict: &conflictClause{
+						updates: []*colUpdate{
+							{col: "id", op: EQ, val: &Number{val: 2}},
+						},
+					},
+					returning: []string{"new"},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input:          "UPSERT INTO table1(id) VALUES (1) RETURNING *",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected '*', expecting IDENTIFIER"),
+		},
+		{
+			input:          "UPSERT INTO table1(id) VALUES (1) ON CONFLICT DO NOTHING",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected ON"),
+		},
 		{
 			input:          "UPSERT INTO table1() VALUES (2, 'untitled')",
 			expectedOutput: nil,
-			expectedError:  errors.New("syntax error: unexpected ')', expecting IDENTIFIER"),
+			expectedError:  errors.New("syntax error: unexpected ')'"),
 		},
 		{
 			input:          "UPSERT INTO VALUES (2)",
 			expectedOutput: nil,
-			expectedError:  errors.New("syntax error: unexpected VALUES, expecting IDENTIFIER"),
+			expectedError:  errors.New("syntax error: unexpected VALUES"),
 		},
 	}
 
@@ -638,31 +1100,91 @@ func TestSelectStmt(t *testing.T) {
 		expectedError  error
 	}{
 		{
-			input: "SELECT id, title FROM table1",
+			input: "SELECT id, title FROM table1",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+						&ColSelector{col: "title"},
+					},
+					ds: &tableRef{table: "table1"},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id, title FROM db1.table1 AS t1",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+						&ColSelector{col: "title"},
+					},
+					ds: &tableRef{db: "db1", table: "table1", as: "t1"},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id, title FROM db1.table1 FOR SYSTEM_TIME AS OF TX 10 AS t1",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+						&ColSelector{col: "title"},
+					},
+					ds: &tableRef{db: "db1", table: "table1", asBefore: 11, as: "t1"},
+				}},
+			expectedError: nil,
+		},
+		{
+			input:          "SELECT id, title FROM table1 FOR SYSTEM_TIME AS OF 10",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected NUMBER, expecting TX"),
+		},
+		{
+			input: "SELECT id, ROW_NUMBER() OVER (ORDER BY id DESC) AS rn FROM table1",
 			expectedOutput: []SQLStmt{
 				&SelectStmt{
-					distinct: false,
 					selectors: []Selector{
 						&ColSelector{col: "id"},
-						&ColSelector{col: "title"},
+						&WindowFnSelector{
+							fn:      "row_number",
+							orderBy: []*OrdCol{{sel: &ColSelector{col: "id"}, descOrder: true}},
+							as:      "rn",
+						},
 					},
 					ds: &tableRef{table: "table1"},
 				}},
 			expectedError: nil,
 		},
 		{
-			input: "SELECT id, title FROM db1.table1 AS t1",
+			input: "SELECT id, RANK() OVER (PARTITION BY category ORDER BY amount) FROM table1",
 			expectedOutput: []SQLStmt{
 				&SelectStmt{
-					distinct: false,
 					selectors: []Selector{
 						&ColSelector{col: "id"},
-						&ColSelector{col: "title"},
+						&WindowFnSelector{
+							fn:          "rank",
+							partitionBy: []*ColSelector{{col: "category"}},
+							orderBy:     []*OrdCol{{sel: &ColSelector{col: "amount"}}},
+						},
 					},
-					ds: &tableRef{db: "db1", table: "table1", as: "t1"},
+					ds: &tableRef{table: "table1"},
 				}},
 			expectedError: nil,
 		},
+		{
+			input:          "SELECT RANK() OVER (PARTITION BY category) FROM table1",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected ')', expecting ORDER"),
+		},
+		{
+			input:          "SELECT ROW_NUMBER() OVER () FROM table1",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected ')', expecting ORDER"),
+		},
 		{
 			input: "SELECT t1.id, title FROM db1.table1 t1",
 			expectedOutput: []SQLStmt{
@@ -696,6 +1218,29 @@ func TestSelectStmt(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "SELECT id FROM table1 WHERE payload >= b64'rtA5Pw=='",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+					},
+					ds: &tableRef{table: "table1"},
+					where: &CmpBoolExp{
+						op: GE,
+						left: &ColSelector{
+							col: "payload",
+						},
+						right: &Blob{val: bs},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input:          "SELECT id FROM table1 WHERE payload >= b64'rtA5Pw='",
+			expectedOutput: nil,
+			expectedError:  fmt.Errorf("%w (malformed base64 BLOB literal)", ErrInvalidValue),
+		},
 		{
 			input: "SELECT DISTINCT id, time, name FROM table1 WHERE country = 'US' AND time <= NOW() AND name = @pname",
 			expectedOutput: []SQLStmt{
@@ -887,13 +1432,101 @@ func TestSelectStmt(t *testing.T) {
 							&ColSelector{col: "col1", as: "id"},
 							&ColSelector{col: "col2", as: "title"},
 						},
-						ds:    &tableRef{table: "table2"},
-						limit: 100,
+						ds:       &tableRef{table: "table2"},
+						limit:    100,
+						hasLimit: true,
+					},
+					limit:    10,
+					hasLimit: true,
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "VALUES (1, 'a'), (2, @title)",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					ds: &valuesDataSource{
+						rows: []*RowSpec{
+							{Values: []ValueExp{&Number{val: 1}, &Varchar{val: "a"}}},
+							{Values: []ValueExp{&Number{val: 2}, &Param{id: "title"}}},
+						},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "TABLE table1",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					ds: &tableRef{table: "table1"},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT title FROM (TABLE db1.table1) AS t",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&ColSelector{col: "title"},
+					},
+					ds: &SelectStmt{
+						ds: &tableRef{db: "db1", table: "table1"},
+						as: "t",
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT column1 FROM (VALUES (1), (2)) AS v",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&ColSelector{col: "column1"},
+					},
+					ds: &SelectStmt{
+						ds: &valuesDataSource{
+							rows: []*RowSpec{
+								{Values: []ValueExp{&Number{val: 1}}},
+								{Values: []ValueExp{&Number{val: 2}}},
+							},
+						},
+						as: "v",
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "WITH t1 AS (SELECT id FROM table1), t2 AS (SELECT id FROM t1) SELECT id FROM t2",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+					},
+					ds: &tableRef{table: "t2"},
+					ctes: []*commonTableExp{
+						{
+							name: "t1",
+							query: &SelectStmt{
+								selectors: []Selector{&ColSelector{col: "id"}},
+								ds:        &tableRef{table: "table1"},
+							},
+						},
+						{
+							name: "t2",
+							query: &SelectStmt{
+								selectors: []Selector{&ColSelector{col: "id"}},
+								ds:        &tableRef{table: "t1"},
+							},
+						},
 					},
-					limit: 10,
 				}},
 			expectedError: nil,
 		},
+		{
+			input:          "WITH t1 SELECT id FROM t1",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected SELECT, expecting AS"),
+		},
 		{
 			input: "SELECT id, name, time FROM table1 WHERE time >= '20210101 00:00:00.000' AND time < '20210211 00:00:00.000'",
 			expectedOutput: []SQLStmt{
@@ -925,6 +1558,119 @@ func TestSelectStmt(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "SELECT id FROM table1 LIMIT 10 OFFSET 20",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{&ColSelector{col: "id"}},
+					ds:        &tableRef{table: "table1"},
+					limit:     10,
+					hasLimit:  true,
+					offset:    20,
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id FROM table1 LIMIT ALL OFFSET 20",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{&ColSelector{col: "id"}},
+					ds:        &tableRef{table: "table1"},
+					offset:    20,
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id FROM table1 OFFSET 20",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{&ColSelector{col: "id"}},
+					ds:        &tableRef{table: "table1"},
+					offset:    20,
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id FROM table1 ORDER BY id OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{&ColSelector{col: "id"}},
+					ds:        &tableRef{table: "table1"},
+					orderBy:   []*OrdCol{{sel: &ColSelector{col: "id"}}},
+					limit:     10,
+					hasLimit:  true,
+					offset:    20,
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id FROM table1 FETCH FIRST 1 ROW ONLY OFFSET 1 ROW",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{&ColSelector{col: "id"}},
+					ds:        &tableRef{table: "table1"},
+					limit:     1,
+					hasLimit:  true,
+					offset:    1,
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id FROM table1 OFFSET $2 ROWS FETCH NEXT $1 ROWS ONLY",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors:   []Selector{&ColSelector{col: "id"}},
+					ds:          &tableRef{table: "table1"},
+					limitParam:  &Param{id: "param1", pos: 1},
+					hasLimit:    true,
+					offsetParam: &Param{id: "param2", pos: 2},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id FROM table1 LIMIT @n OFFSET 2",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors:  []Selector{&ColSelector{col: "id"}},
+					ds:         &tableRef{table: "table1"},
+					limitParam: &Param{id: "n"},
+					hasLimit:   true,
+					offset:     2,
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id FROM table1 FETCH FIRST 0 ROWS ONLY",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{&ColSelector{col: "id"}},
+					ds:        &tableRef{table: "table1"},
+					limit:     0,
+					hasLimit:  true,
+				}},
+			expectedError: nil,
+		},
+		{
+			// pagination keywords are not reserved thus can be used as identifiers
+			input: "SELECT rows FROM offset OFFSET 1 ROW",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{&ColSelector{col: "rows"}},
+					ds:        &tableRef{table: "offset"},
+					offset:    1,
+				}},
+			expectedError: nil,
+		},
+		{
+			input:          "SELECT id FROM table1 OFFSET 20 ROWS FETCH NEXT 10 ROWS",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected $end, expecting ONLY"),
+		},
+		{
+			input:          "SELECT id FROM table1 LIMIT 10 LIMIT 20",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected LIMIT"),
+		},
 	}
 
 	for i, tc := range testCases {
@@ -976,6 +1722,83 @@ func TestAggFnStmt(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "SELECT active, active OR false AS a, COUNT() FROM table1 GROUP BY active",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{col: "active"},
+						&ExpSelector{
+							exp: &BinBoolExp{
+								op:    OR,
+								left:  &ColSelector{col: "active"},
+								right: &Bool{val: false},
+							},
+							as: "a",
+						},
+						&AggColSelector{aggFn: COUNT, col: "*"},
+					},
+					ds: &tableRef{table: "table1"},
+					groupBy: []*ColSelector{
+						{col: "active"},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT region, bool_and(active), BOOL_OR(active) AS any_active FROM table1 GROUP BY region",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{col: "region"},
+						&AggColSelector{aggFn: BOOL_AND, col: "active"},
+						&AggColSelector{aggFn: BOOL_OR, col: "active", as: "any_active"},
+					},
+					ds: &tableRef{table: "table1"},
+					groupBy: []*ColSelector{
+						{col: "region"},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT region, city, SUM(amount) FROM table1 GROUP BY ROLLUP(region, city) ORDER BY region, city",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{col: "region"},
+						&ColSelector{col: "city"},
+						&AggColSelector{aggFn: SUM, col: "amount"},
+					},
+					ds: &tableRef{table: "table1"},
+					groupBy: []*ColSelector{
+						{col: "region"},
+						{col: "city"},
+					},
+					rollup: true,
+					orderBy: []*OrdCol{
+						{sel: &ColSelector{col: "region"}},
+						{sel: &ColSelector{col: "city"}},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT DISTINCT COUNT(), COUNT(DISTINCT t.region) AS regions FROM table1 AS t",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: true,
+					selectors: []Selector{
+						&AggColSelector{aggFn: COUNT, col: "*"},
+						&AggColSelector{aggFn: COUNT, distinct: true, table: "t", col: "region", as: "regions"},
+					},
+					ds: &tableRef{table: "table1", as: "t"},
+				}},
+			expectedError: nil,
+		},
 	}
 
 	for i, tc := range testCases {
@@ -1145,6 +1968,42 @@ func TestExpressions(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "SELECT id FROM table1 WHERE active IS NOT TRUE AND (id > 1) IS UNKNOWN",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+					},
+					ds: &tableRef{table: "table1"},
+					where: &BinBoolExp{
+						op:    AND,
+						left:  &IsBoolExp{exp: &ColSelector{col: "active"}, not: true, val: true},
+						right: &IsBoolExp{exp: &CmpBoolExp{op: GT, left: &ColSelector{col: "id"}, right: &Number{val: 1}}, unknown: true},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id FROM table1 WHERE title NOT LIKE 'J\\%O%' ESCAPE '\\'",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+					},
+					ds: &tableRef{table: "table1"},
+					where: &LikeBoolExp{
+						val:        &ColSelector{col: "title"},
+						notLike:    true,
+						pattern:    &Varchar{val: "J\\%O%"},
+						withEscape: true,
+						escape:     "\\",
+					},
+				}},
+			expectedError: nil,
+		},
 		{
 			input: "SELECT id FROM table1 WHERE table1.title LIKE @param1",
 			expectedOutput: []SQLStmt{
@@ -1236,6 +2095,35 @@ func TestExpressions(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "SELECT TRIM(title) AS t, REPLACE(title, 'a', @newval) FROM table1 WHERE RTRIM(title) = 'a'",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&ExpSelector{
+							exp: &SysFn{fn: "trim", params: []ValueExp{&ColSelector{col: "title"}}},
+							as:  "t",
+						},
+						&ExpSelector{
+							exp: &SysFn{
+								fn: "replace",
+								params: []ValueExp{
+									&ColSelector{col: "title"},
+									&Varchar{val: "a"},
+									&Param{id: "newval"},
+								},
+							},
+						},
+					},
+					ds: &tableRef{table: "table1"},
+					where: &CmpBoolExp{
+						op:    EQ,
+						left:  &SysFn{fn: "rtrim", params: []ValueExp{&ColSelector{col: "title"}}},
+						right: &Varchar{val: "a"},
+					},
+				}},
+			expectedError: nil,
+		},
 	}
 
 	for i, tc := range testCases {
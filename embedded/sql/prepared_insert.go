@@ -0,0 +1,96 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PreparedInsert inserts rows into a table without parsing nor planning an INSERT statement for each of them,
+// the statement is built just once when the insertion is prepared, values are then bound as parameters
+type PreparedInsert struct {
+	e    *Engine
+	stmt *UpsertIntoStmt
+}
+
+// PrepareInsert returns a handle to insert rows into the given columns of a table of the database in use
+func (e *Engine) PrepareInsert(table string, cols []string) (*PreparedInsert, error) {
+	if len(cols) == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	if e.closed {
+		return nil, ErrAlreadyClosed
+	}
+
+	if e.catalog == nil {
+		return nil, ErrCatalogNotReady
+	}
+
+	db, err := e.databaseInUse(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := db.GetTableByName(strings.ToLower(table))
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := &UpsertIntoStmt{
+		isInsert: true,
+		tableRef: &tableRef{db: db.name, table: t.name},
+		cols:     make([]string, len(cols)),
+		rows:     []*RowSpec{{Values: make([]ValueExp, len(cols))}},
+	}
+
+	for i, colName := range cols {
+		col, err := t.GetColumnByName(strings.ToLower(colName))
+		if err != nil {
+			return nil, err
+		}
+
+		stmt.cols[i] = col.colName
+		stmt.rows[0].Values[i] = &Param{id: fmt.Sprintf("param%d", i+1), pos: i + 1}
+	}
+
+	// columns are validated as done when executing the statement e.g. duplicated or generated columns
+	_, err = stmt.validate(t)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PreparedInsert{e: e, stmt: stmt}, nil
+}
+
+// Exec inserts a row holding the given values, given in the order of the columns the insertion was prepared with
+func (pi *PreparedInsert) Exec(values ...interface{}) (*ExecSummary, error) {
+	if len(values) != len(pi.stmt.cols) {
+		return nil, fmt.Errorf("%w (expecting %d values)", ErrIllegalArguments, len(pi.stmt.cols))
+	}
+
+	params := make(map[string]interface{}, len(values))
+
+	for i, v := range values {
+		params[fmt.Sprintf("param%d", i+1)] = v
+	}
+
+	return pi.e.ExecPreparedStmts([]SQLStmt{pi.stmt}, params, true)
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreparedInsert(t *testing.T) {
+	catalogStore, err := store.Open("catalog_prepared_insert", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_prepared_insert")
+
+	dataStore, err := store.Open("sqldata_prepared_insert", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_prepared_insert")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.PrepareInsert("table1", []string{"id"})
+	require.ErrorIs(t, err, ErrCatalogNotReady)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.PrepareInsert("table1", []string{"id"})
+	require.ErrorIs(t, err, ErrNoDatabaseSelected)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR[20], active BOOLEAN NOT NULL, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.PrepareInsert("table1", nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = engine.PrepareInsert("table2", []string{"id"})
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	_, err = engine.PrepareInsert("table1", []string{"title", "amount"})
+	require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+	_, err = engine.PrepareInsert("table1", []string{"title", "title"})
+	require.ErrorIs(t, err, ErrDuplicatedColumn)
+
+	ins, err := engine.PrepareInsert("Table1", []string{"Title", "active"})
+	require.NoError(t, err)
+
+	_, err = ins.Exec("title")
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = ins.Exec("title", nil)
+	require.ErrorIs(t, err, ErrNotNullableColumnCannotBeNull)
+
+	_, err = ins.Exec(10, true)
+	require.ErrorIs(t, err, ErrInvalidValue)
+
+	rowCount := 100
+
+	for i := 0; i < rowCount; i++ {
+		summary, err := ins.Exec(fmt.Sprintf("title%d", i), i%2 == 0)
+		require.NoError(t, err)
+		require.Equal(t, int64(i+1), summary.LastInsertedPKs["table1"])
+	}
+
+	rows, _, err := engine.QueryAll("SELECT id, title, active FROM table1", nil)
+	require.NoError(t, err)
+	require.Len(t, rows, rowCount)
+
+	for i, row := range rows {
+		require.Equal(t, int64(i+1), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+		require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+		require.Equal(t, i%2 == 0, row.Values[EncodeSelector("", "db1", "table1", "active")].Value())
+	}
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	_, err = engine.PrepareInsert("table1", []string{"id"})
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	_, err = ins.Exec("title", true)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
+func BenchmarkPreparedInsert(b *testing.B) {
+	catalogStore, err := store.Open("catalog_prepared_insert_bench", store.DefaultOptions())
+	require.NoError(b, err)
+	defer os.RemoveAll("catalog_prepared_insert_bench")
+	defer catalogStore.Close()
+
+	dataStore, err := store.Open("sqldata_prepared_insert_bench", store.DefaultOptions())
+	require.NoError(b, err)
+	defer os.RemoveAll("sqldata_prepared_insert_bench")
+	defer dataStore.Close()
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(b, err)
+	defer engine.Close()
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(b, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(b, err)
+
+	// each variant inserts into its own table so both start from the same state
+	for _, table := range []string{"table1", "table2"} {
+		_, err = engine.ExecStmt(fmt.Sprintf("CREATE TABLE %s (id INTEGER AUTO_INCREMENT, title VARCHAR[20], amount INTEGER, PRIMARY KEY id)", table), nil, true)
+		require.NoError(b, err)
+	}
+
+	b.Run("ExecStmt", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := engine.ExecStmt("INSERT INTO table1 (title, amount) VALUES (@title, @amount)", map[string]interface{}{"title": "title", "amount": i}, true)
+			require.NoError(b, err)
+		}
+	})
+
+	b.Run("PreparedInsert", func(b *testing.B) {
+		ins, err := engine.PrepareInsert("table2", []string{"title", "amount"})
+		require.NoError(b, err)
+
+		for i := 0; i < b.N; i++ {
+			_, err := ins.Exec("title", i)
+			require.NoError(b, err)
+		}
+	})
+}
@@ -15,7 +15,11 @@ limitations under the License.
 */
 package sql
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
 
 type projectedRowReader struct {
 	e *Engine
@@ -25,9 +29,19 @@ type projectedRowReader struct {
 	tableAlias string
 
 	selectors []Selector
+
+	params map[string]interface{}
+
+	// expressions of the selectors with params substituted and constants folded, set on the first read
+	exps []ValueExp
+
+	cols colsCache
+
+	// rows are not scanned by the projected query but read from a derived table
+	derived bool
 }
 
-func (e *Engine) newProjectedRowReader(rowReader RowReader, tableAlias string, selectors []Selector) (*projectedRowReader, error) {
+func (e *Engine) newProjectedRowReader(rowReader RowReader, tableAlias string, selectors []Selector, params map[string]interface{}) (*projectedRowReader, error) {
 	// case: SELECT *
 	if len(selectors) == 0 {
 		cols, err := rowReader.Columns()
@@ -50,6 +64,7 @@ func (e *Engine) newProjectedRowReader(rowReader RowReader, tableAlias string, s
 		rowReader:  rowReader,
 		tableAlias: tableAlias,
 		selectors:  selectors,
+		params:     params,
 	}, nil
 }
 
@@ -73,7 +88,53 @@ func (pr *projectedRowReader) ScanSpecs() *ScanSpecs {
 	return pr.rowReader.ScanSpecs()
 }
 
+func (pr *projectedRowReader) TxHeader() (*store.TxHeader, error) {
+	return ReaderTxHeader(pr.rowReader)
+}
+
+func (pr *projectedRowReader) Cursor() ([]byte, error) {
+	// the position within a derived table can not be used to resume the enclosing query
+	if pr.derived {
+		return nil, ErrNoSupported
+	}
+
+	return pr.rowReader.Cursor()
+}
+
+// projectedAs returns the column descriptor (without type) the i-th selector is projected as
+func (pr *projectedRowReader) projectedAs(i int, sel Selector) ColDescriptor {
+	aggFn, db, table, col := sel.resolve(pr.rowReader.ImplicitDB(), pr.rowReader.ImplicitTable())
+
+	if pr.tableAlias != "" {
+		db = pr.ImplicitDB()
+		table = pr.tableAlias
+	}
+
+	_, isExp := sel.(*ExpSelector)
+
+	if aggFn == "" && sel.alias() != "" {
+		col = sel.alias()
+	}
+
+	if aggFn != "" || isExp {
+		col = sel.alias()
+		if col == "" {
+			col = fmt.Sprintf("col%d", i)
+		}
+	}
+
+	return ColDescriptor{
+		Database: db,
+		Table:    table,
+		Column:   col,
+	}
+}
+
 func (pr *projectedRowReader) Columns() ([]ColDescriptor, error) {
+	return pr.cols.columns(pr.resolveColumns)
+}
+
+func (pr *projectedRowReader) resolveColumns() ([]ColDescriptor, error) {
 	colsBySel, err := pr.colsBySelector()
 	if err != nil {
 		return nil, err
@@ -82,31 +143,7 @@ func (pr *projectedRowReader) Columns() ([]ColDescriptor, error) {
 	colsByPos := make([]ColDescriptor, len(pr.selectors))
 
 	for i, sel := range pr.selectors {
-		aggFn, db, table, col := sel.resolve(pr.rowReader.ImplicitDB(), pr.rowReader.ImplicitTable())
-
-		if pr.tableAlias != "" {
-			db = pr.ImplicitDB()
-			table = pr.tableAlias
-		}
-
-		if aggFn == "" && sel.alias() != "" {
-			col = sel.alias()
-		}
-
-		if aggFn != "" {
-			aggFn = ""
-			col = sel.alias()
-			if col == "" {
-				col = fmt.Sprintf("col%d", i)
-			}
-		}
-
-		colsByPos[i] = ColDescriptor{
-			AggFn:    aggFn,
-			Database: db,
-			Table:    table,
-			Column:   col,
-		}
+		colsByPos[i] = pr.projectedAs(i, sel)
 
 		encSel := colsByPos[i].Selector()
 
@@ -117,6 +154,10 @@ func (pr *projectedRowReader) Columns() ([]ColDescriptor, error) {
 }
 
 func (pr *projectedRowReader) colsBySelector() (map[string]ColDescriptor, error) {
+	return pr.cols.columnsBySelector(pr.resolveColsBySelector)
+}
+
+func (pr *projectedRowReader) resolveColsBySelector() (map[string]ColDescriptor, error) {
 	dsColDescriptors, err := pr.rowReader.colsBySelector()
 	if err != nil {
 		return nil, err
@@ -125,38 +166,25 @@ func (pr *projectedRowReader) colsBySelector() (map[string]ColDescriptor, error)
 	colDescriptors := make(map[string]ColDescriptor, len(pr.selectors))
 
 	for i, sel := range pr.selectors {
-		aggFn, db, table, col := sel.resolve(pr.rowReader.ImplicitDB(), pr.rowReader.ImplicitTable())
-
-		encSel := EncodeSelector(aggFn, db, table, col)
+		des := pr.projectedAs(i, sel)
 
-		colDesc, ok := dsColDescriptors[encSel]
-		if !ok {
-			return nil, ErrColumnDoesNotExist
-		}
-
-		if pr.tableAlias != "" {
-			db = pr.ImplicitDB()
-			table = pr.tableAlias
-		}
+		expSel, isExp := sel.(*ExpSelector)
+		if isExp {
+			t, err := expSel.exp.inferType(dsColDescriptors, make(map[string]SQLValueType), pr.rowReader.ImplicitDB(), pr.rowReader.ImplicitTable())
+			if err != nil {
+				return nil, err
+			}
 
-		if aggFn == "" && sel.alias() != "" {
-			col = sel.alias()
-		}
+			des.Type = t
+		} else {
+			encSel := EncodeSelector(sel.resolve(pr.rowReader.ImplicitDB(), pr.rowReader.ImplicitTable()))
 
-		if aggFn != "" {
-			aggFn = ""
-			col = sel.alias()
-			if col == "" {
-				col = fmt.Sprintf("col%d", i)
+			colDesc, ok := dsColDescriptors[encSel]
+			if !ok {
+				return nil, ErrColumnDoesNotExist
 			}
-		}
 
-		des := ColDescriptor{
-			AggFn:    aggFn,
-			Database: db,
-			Table:    table,
-			Column:   col,
-			Type:     colDesc.Type,
+			des.Type = colDesc.Type
 		}
 
 		colDescriptors[des.Selector()] = des
@@ -166,11 +194,63 @@ func (pr *projectedRowReader) colsBySelector() (map[string]ColDescriptor, error)
 }
 
 func (pr *projectedRowReader) InferParameters(params map[string]SQLValueType) error {
-	return pr.rowReader.InferParameters(params)
+	err := pr.rowReader.InferParameters(params)
+	if err != nil {
+		return err
+	}
+
+	cols, err := pr.rowReader.colsBySelector()
+	if err != nil {
+		return err
+	}
+
+	for _, sel := range pr.selectors {
+		expSel, isExp := sel.(*ExpSelector)
+		if !isExp {
+			continue
+		}
+
+		_, err = expSel.exp.inferType(cols, params, pr.rowReader.ImplicitDB(), pr.rowReader.ImplicitTable())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (pr *projectedRowReader) SetParameters(params map[string]interface{}) error {
-	return pr.rowReader.SetParameters(params)
+	err := pr.rowReader.SetParameters(params)
+	if err != nil {
+		return err
+	}
+
+	pr.params, err = normalizeParams(params)
+	pr.exps = nil
+
+	return err
+}
+
+func (pr *projectedRowReader) compileExps() error {
+	exps := make([]ValueExp, len(pr.selectors))
+
+	for i, sel := range pr.selectors {
+		expSel, isExp := sel.(*ExpSelector)
+		if !isExp {
+			continue
+		}
+
+		exp, err := expSel.exp.substitute(pr.params)
+		if err != nil {
+			return err
+		}
+
+		exps[i] = foldConstants(exp)
+	}
+
+	pr.exps = exps
+
+	return nil
 }
 
 func (pr *projectedRowReader) Read() (*Row, error) {
@@ -179,38 +259,40 @@ func (pr *projectedRowReader) Read() (*Row, error) {
 		return nil, err
 	}
 
+	if pr.exps == nil {
+		err = pr.compileExps()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	prow := &Row{
 		Values: make(map[string]TypedValue, len(pr.selectors)),
 	}
 
 	for i, sel := range pr.selectors {
-		aggFn, db, table, col := sel.resolve(pr.rowReader.ImplicitDB(), pr.rowReader.ImplicitTable())
+		var val TypedValue
 
-		encSel := EncodeSelector(aggFn, db, table, col)
-
-		val, ok := row.Values[encSel]
-		if !ok {
-			return nil, ErrColumnDoesNotExist
-		}
+		exp := pr.exps[i]
+		if exp != nil {
+			val, err = exp.reduce(pr.e.catalog, row, pr.rowReader.ImplicitDB(), pr.rowReader.ImplicitTable())
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			encSel := EncodeSelector(sel.resolve(pr.rowReader.ImplicitDB(), pr.rowReader.ImplicitTable()))
 
-		if pr.tableAlias != "" {
-			db = pr.ImplicitDB()
-			table = pr.tableAlias
-		}
+			v, ok := row.Values[encSel]
+			if !ok {
+				return nil, ErrColumnDoesNotExist
+			}
 
-		if aggFn == "" && sel.alias() != "" {
-			col = sel.alias()
+			val = v
 		}
 
-		if aggFn != "" {
-			aggFn = ""
-			col = sel.alias()
-			if col == "" {
-				col = fmt.Sprintf("col%d", i)
-			}
-		}
+		des := pr.projectedAs(i, sel)
 
-		prow.Values[EncodeSelector(aggFn, db, table, col)] = val
+		prow.Values[des.Selector()] = val
 	}
 
 	return prow, nil
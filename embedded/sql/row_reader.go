@@ -17,8 +17,10 @@ limitations under the License.
 package sql
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/binary"
+	"fmt"
 
 	"github.com/codenotary/immudb/embedded/store"
 )
@@ -33,9 +35,28 @@ type RowReader interface {
 	OrderBy() []ColDescriptor
 	ScanSpecs() *ScanSpecs
 	InferParameters(params map[string]SQLValueType) error
+	// Cursor returns an opaque token encoding the position of the last row read, Engine.QueryStmtFromCursor
+	// resumes the query right after it. It's nil when no row has been read yet
+	Cursor() ([]byte, error)
 	colsBySelector() (map[string]ColDescriptor, error)
 }
 
+// TxHeaderReader is implemented by row readers able to tell the last transaction reflected by the rows being read,
+// readers returned by the engine implement it
+type TxHeaderReader interface {
+	TxHeader() (*store.TxHeader, error)
+}
+
+// ReaderTxHeader returns the header of the last transaction reflected by the rows read from r
+func ReaderTxHeader(r RowReader) (*store.TxHeader, error) {
+	hr, ok := r.(TxHeaderReader)
+	if !ok {
+		return nil, ErrNoSupported
+	}
+
+	return hr.TxHeader()
+}
+
 type Row struct {
 	Values map[string]TypedValue
 }
@@ -105,7 +126,9 @@ type rawRowReader struct {
 	colsByPos  []ColDescriptor
 	colsBySel  map[string]ColDescriptor
 	scanSpecs  *ScanSpecs
-	reader     *store.KeyReader
+	reader     *store.KeyReader // nil when no rows can satisfy the query
+	scanned    int
+	lastKey    []byte // key of the last entry read, it's the position encoded in cursors
 }
 
 type ColDescriptor struct {
@@ -125,14 +148,18 @@ func (e *Engine) newRawRowReader(snap *store.Snapshot, table *Table, asBefore ui
 		return nil, ErrIllegalArguments
 	}
 
-	rSpec, err := keyReaderSpecFrom(e, table, scanSpecs)
-	if err != nil {
-		return nil, err
-	}
+	var r *store.KeyReader
 
-	r, err := snap.NewKeyReader(rSpec)
-	if err != nil {
-		return nil, err
+	if !scanSpecs.noRows {
+		rSpec, err := keyReaderSpecFrom(e, table, scanSpecs)
+		if err != nil {
+			return nil, err
+		}
+
+		r, err = snap.NewKeyReader(rSpec)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if tableAlias == "" {
@@ -187,12 +214,12 @@ func keyReaderSpecFrom(e *Engine, table *Table, scanSpecs *ScanSpecs) (spec *sto
 		if !ok {
 			if scanSpecs.descOrder {
 				if !seekKeyReady {
-					seekKey = append(seekKey, maxKeyValOf(col.colType)...)
+					seekKey = append(seekKey, scanSpecs.index.maxKeyValOf(col)...)
 				}
 				endKeyReady = true
 			} else {
 				if !endKeyReady {
-					endKey = append(endKey, maxKeyValOf(col.colType)...)
+					endKey = append(endKey, scanSpecs.index.maxKeyValOf(col)...)
 				}
 				seekKeyReady = true
 			}
@@ -203,11 +230,11 @@ func keyReaderSpecFrom(e *Engine, table *Table, scanSpecs *ScanSpecs) (spec *sto
 		if scanSpecs.descOrder {
 			if !seekKeyReady {
 				if colRange.hRange == nil {
-					seekKey = append(seekKey, maxKeyValOf(col.colType)...)
+					seekKey = append(seekKey, scanSpecs.index.maxKeyValOf(col)...)
 				}
 
 				if colRange.hRange != nil {
-					encVal, err := EncodeAsKey(colRange.hRange.val.Value(), col.colType, col.MaxLen())
+					encVal, err := scanSpecs.index.encodeKeyValue(col, colRange.hRange.val)
 					if err != nil {
 						return nil, err
 					}
@@ -219,7 +246,7 @@ func keyReaderSpecFrom(e *Engine, table *Table, scanSpecs *ScanSpecs) (spec *sto
 				endKeyReady = colRange.lRange == nil
 
 				if colRange.lRange != nil {
-					encVal, err := EncodeAsKey(colRange.lRange.val.Value(), col.colType, col.MaxLen())
+					encVal, err := scanSpecs.index.encodeKeyValue(col, colRange.lRange.val)
 					if err != nil {
 						return nil, err
 					}
@@ -233,7 +260,7 @@ func keyReaderSpecFrom(e *Engine, table *Table, scanSpecs *ScanSpecs) (spec *sto
 				seekKeyReady = colRange.lRange == nil
 
 				if colRange.lRange != nil {
-					encVal, err := EncodeAsKey(colRange.lRange.val.Value(), col.colType, col.MaxLen())
+					encVal, err := scanSpecs.index.encodeKeyValue(col, colRange.lRange.val)
 					if err != nil {
 						return nil, err
 					}
@@ -243,11 +270,11 @@ func keyReaderSpecFrom(e *Engine, table *Table, scanSpecs *ScanSpecs) (spec *sto
 
 			if !endKeyReady {
 				if colRange.hRange == nil {
-					endKey = append(endKey, maxKeyValOf(col.colType)...)
+					endKey = append(endKey, scanSpecs.index.maxKeyValOf(col)...)
 				}
 
 				if colRange.hRange != nil {
-					encVal, err := EncodeAsKey(colRange.hRange.val.Value(), col.colType, col.MaxLen())
+					encVal, err := scanSpecs.index.encodeKeyValue(col, colRange.hRange.val)
 					if err != nil {
 						return nil, err
 					}
@@ -262,20 +289,36 @@ func keyReaderSpecFrom(e *Engine, table *Table, scanSpecs *ScanSpecs) (spec *sto
 
 		if scanSpecs.descOrder {
 			for _, col := range table.primaryIndex.cols {
-				seekKey = append(seekKey, maxKeyValOf(col.colType)...)
+				seekKey = append(seekKey, table.primaryIndex.maxKeyValOf(col)...)
 			}
 		}
 
 		if !scanSpecs.descOrder {
 			for _, col := range table.primaryIndex.cols {
-				endKey = append(endKey, maxKeyValOf(col.colType)...)
+				endKey = append(endKey, table.primaryIndex.maxKeyValOf(col)...)
 			}
 		}
 	}
 
+	inclusiveSeek := true
+
+	// rows are read after the position of a cursor, unless it precedes the range to be scanned
+	if scanSpecs.after != nil {
+		if !bytes.HasPrefix(scanSpecs.after, prefix) {
+			return nil, fmt.Errorf("%w (cursor does not belong to the scanned index)", ErrIllegalArguments)
+		}
+
+		cmp := bytes.Compare(scanSpecs.after, seekKey)
+
+		if (!scanSpecs.descOrder && cmp >= 0) || (scanSpecs.descOrder && cmp <= 0) {
+			seekKey = scanSpecs.after
+			inclusiveSeek = false
+		}
+	}
+
 	return &store.KeyReaderSpec{
 		SeekKey:       seekKey,
-		InclusiveSeek: true,
+		InclusiveSeek: inclusiveSeek,
 		EndKey:        endKey,
 		InclusiveEnd:  true,
 		Prefix:        prefix,
@@ -311,6 +354,31 @@ func (r *rawRowReader) ScanSpecs() *ScanSpecs {
 	return r.scanSpecs
 }
 
+// Cursor returns the key of the last entry read, which identifies the row in the scanned index,
+// thus the scan is resumed right after it regardless of the rows written since then
+func (r *rawRowReader) Cursor() ([]byte, error) {
+	if len(r.lastKey) == 0 {
+		return nil, nil
+	}
+
+	cursor := make([]byte, len(r.lastKey))
+	copy(cursor, r.lastKey)
+
+	return cursor, nil
+}
+
+// TxHeader returns the header of the last transaction reflected by the rows being read,
+// it's the one preceding asBefore when rows are read as before a given transaction
+func (r *rawRowReader) TxHeader() (*store.TxHeader, error) {
+	txID := r.snap.Ts()
+
+	if r.asBefore > 0 && r.asBefore <= txID {
+		txID = r.asBefore - 1
+	}
+
+	return r.e.txHeader(txID)
+}
+
 func (r *rawRowReader) Columns() ([]ColDescriptor, error) {
 	ret := make([]ColDescriptor, len(r.colsByPos))
 	for i := range r.colsByPos {
@@ -331,11 +399,60 @@ func (r *rawRowReader) InferParameters(params map[string]SQLValueType) error {
 	return nil
 }
 
+// SetParameters narrows the scan with the ranges depending on parameters not provided when the query was resolved
+// e.g. title LIKE @pattern in a prepared statement, the scan is left as it is once rows have been read
 func (r *rawRowReader) SetParameters(params map[string]interface{}) error {
+	if r.reader == nil || r.scanSpecs.cond == nil || r.scanned > 0 {
+		return nil
+	}
+
+	nparams, err := normalizeParams(params)
+	if err != nil {
+		return err
+	}
+
+	rangesByColID := make(map[uint32]*typedValueRange)
+
+	err = r.scanSpecs.cond.selectorRanges(r.table, r.tableAlias, nparams, rangesByColID)
+	if err != nil {
+		return err
+	}
+
+	scanSpecs := &ScanSpecs{
+		index:         r.scanSpecs.index,
+		rangesByColID: rangesByColID,
+		descOrder:     r.scanSpecs.descOrder,
+		cond:          r.scanSpecs.cond,
+		after:         r.scanSpecs.after,
+	}
+
+	rSpec, err := keyReaderSpecFrom(r.e, r.table, scanSpecs)
+	if err != nil {
+		return err
+	}
+
+	reader, err := r.snap.NewKeyReader(rSpec)
+	if err != nil {
+		return err
+	}
+
+	err = r.reader.Close()
+	if err != nil {
+		reader.Close()
+		return err
+	}
+
+	r.reader = reader
+	r.scanSpecs = scanSpecs
+
 	return nil
 }
 
 func (r *rawRowReader) Read() (row *Row, err error) {
+	if r.reader == nil {
+		return nil, ErrNoMoreRows
+	}
+
 	var mkey []byte
 	var vref *store.ValueRef
 
@@ -348,7 +465,20 @@ func (r *rawRowReader) Read() (row *Row, err error) {
 		return nil, err
 	}
 
+	r.scanned++
+
+	if r.e.maxScanRows > 0 && r.scanned > r.e.maxScanRows {
+		return nil, ErrScanLimitExceeded
+	}
+
+	r.lastKey = append(r.lastKey[:0], mkey...)
+
+	if r.scanSpecs.keysOnly {
+		return r.e.decodeIndexKey(r.table, r.tableAlias, r.scanSpecs.index, mkey)
+	}
+
 	var v []byte
+	var txID uint64
 
 	//decompose key, determine if it's pk, when it's pk, the value holds the actual row data
 	if r.scanSpecs.index.IsPrimary() {
@@ -356,6 +486,8 @@ func (r *rawRowReader) Read() (row *Row, err error) {
 		if err != nil {
 			return nil, err
 		}
+
+		txID = vref.Tx()
 	} else {
 		var encPKVals []byte
 
@@ -373,21 +505,73 @@ func (r *rawRowReader) Read() (row *Row, err error) {
 			}
 		}
 
-		vref, err = r.snap.Get(r.e.mapKey(PIndexPrefix, EncodeID(r.table.db.id), EncodeID(r.table.id), EncodeID(PKIndexID), encPKVals))
+		v, txID, err = r.e.resolvePKEntry(r.snap, r.e.mapKey(PIndexPrefix, EncodeID(r.table.db.id), EncodeID(r.table.id), EncodeID(PKIndexID), encPKVals))
 		if err != nil {
 			return nil, err
 		}
+	}
 
-		v, err = vref.Resolve()
+	return decodeRow(r.table, r.tableAlias, v, txID)
+}
+
+// decodeIndexKey decodes the values of the indexed columns from the key of an index entry,
+// the rest of the columns of the row are set to NULL
+func (e *Engine) decodeIndexKey(table *Table, tableAlias string, index *Index, mkey []byte) (*Row, error) {
+	enc, err := e.trimPrefix(mkey, []byte(index.prefix()))
+	if err != nil {
+		return nil, ErrCorruptedData
+	}
+
+	if len(enc) < EncIDLen*3 {
+		return nil, ErrCorruptedData
+	}
+
+	values := make(map[string]TypedValue, len(table.Cols()))
+
+	for _, col := range table.Cols() {
+		values[EncodeSelector("", table.db.name, tableAlias, col.colName)] = &NullValue{t: col.colType}
+	}
+
+	off := EncIDLen * 3
+
+	for _, col := range index.cols {
+		if index.IsNullable() {
+			if len(enc) <= off {
+				return nil, ErrCorruptedData
+			}
+
+			if enc[off] == nullKeyMarker {
+				off += index.keyValLen(col)
+				continue
+			}
+
+			off++
+		}
+
+		val, n, err := decodeKeyValue(enc[off:], col.colType, col.MaxLen())
 		if err != nil {
 			return nil, err
 		}
+
+		off += n
+		values[EncodeSelector("", table.db.name, tableAlias, col.colName)] = val
 	}
 
-	values := make(map[string]TypedValue, len(r.table.Cols()))
+	return &Row{Values: values}, nil
+}
+
+// decodeRow decodes the value of a primary index entry written at the given transaction into a row of the table
+func decodeRow(table *Table, tableAlias string, v []byte, txID uint64) (*Row, error) {
+	values := make(map[string]TypedValue, len(table.Cols()))
+
+	for _, col := range table.Cols() {
+		if col.missingValue != nil && txID <= col.missingTx {
+			// rows written before the column was added hold no value for it
+			values[EncodeSelector("", table.db.name, tableAlias, col.colName)] = col.missingValue
+			continue
+		}
 
-	for _, col := range r.table.Cols() {
-		values[EncodeSelector("", r.table.db.name, r.tableAlias, col.colName)] = &NullValue{t: col.colType}
+		values[EncodeSelector("", table.db.name, tableAlias, col.colName)] = &NullValue{t: col.colType}
 	}
 
 	if len(v) < EncLenLen {
@@ -407,18 +591,29 @@ func (r *rawRowReader) Read() (row *Row, err error) {
 		colID := binary.BigEndian.Uint32(v[voff:])
 		voff += EncIDLen
 
-		col, err := r.table.GetColumnByID(colID)
+		col, err := table.GetColumnByID(colID)
 		if err != nil {
 			return nil, ErrCorruptedData
 		}
 
-		val, n, err := DecodeValue(v[voff:], col.colType)
+		colType := col.TypeAt(txID)
+
+		val, n, err := DecodeValue(v[voff:], colType)
 		if err != nil {
 			return nil, err
 		}
 
 		voff += n
-		values[EncodeSelector("", r.table.db.name, r.tableAlias, col.colName)] = val
+
+		if colType != col.colType {
+			// values written before the type of the column was altered are read as values of its current type
+			val, err = convertValue(val, col.colType)
+			if err != nil {
+				return nil, fmt.Errorf("%w (column %s)", err, col.colName)
+			}
+		}
+
+		values[EncodeSelector("", table.db.name, tableAlias, col.colName)] = val
 	}
 
 	if len(v)-voff > 0 {
@@ -428,6 +623,85 @@ func (r *rawRowReader) Read() (row *Row, err error) {
 	return &Row{Values: values}, nil
 }
 
+type indexCacheKey struct {
+	snapTs uint64
+	pkKey  string
+}
+
+// pkEntry is the value of a primary index entry along with the transaction it was written in
+type pkEntry struct {
+	v    []byte
+	txID uint64
+}
+
+// resolvePKEntry returns the value of the primary index entry and the transaction it was written in,
+// entries are cached per snapshot as the content of a snapshot never changes
+func (e *Engine) resolvePKEntry(snap *store.Snapshot, pkKey []byte) ([]byte, uint64, error) {
+	var cacheKey indexCacheKey
+
+	if e.indexCache != nil {
+		cacheKey = indexCacheKey{snapTs: snap.Ts(), pkKey: string(pkKey)}
+
+		entry, err := e.indexCache.Get(cacheKey)
+		if err == nil {
+			return entry.(*pkEntry).v, entry.(*pkEntry).txID, nil
+		}
+	}
+
+	vref, err := snap.Get(pkKey)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	v, err := vref.Resolve()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if e.indexCache != nil {
+		_, _, err = e.indexCache.Put(cacheKey, &pkEntry{v: v, txID: vref.Tx()})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return v, vref.Tx(), nil
+}
+
+// colsCache keeps the column descriptors of a row reader once resolved,
+// the first error encountered is kept as well and returned on subsequent calls
+type colsCache struct {
+	colsByPos         []ColDescriptor
+	colsByPosErr      error
+	colsByPosResolved bool
+
+	colsBySel         map[string]ColDescriptor
+	colsBySelErr      error
+	colsBySelResolved bool
+}
+
+func (c *colsCache) columns(resolve func() ([]ColDescriptor, error)) ([]ColDescriptor, error) {
+	if !c.colsByPosResolved {
+		c.colsByPos, c.colsByPosErr = resolve()
+		c.colsByPosResolved = true
+	}
+
+	return c.colsByPos, c.colsByPosErr
+}
+
+func (c *colsCache) columnsBySelector(resolve func() (map[string]ColDescriptor, error)) (map[string]ColDescriptor, error) {
+	if !c.colsBySelResolved {
+		c.colsBySel, c.colsBySelErr = resolve()
+		c.colsBySelResolved = true
+	}
+
+	return c.colsBySel, c.colsBySelErr
+}
+
 func (r *rawRowReader) Close() error {
+	if r.reader == nil {
+		return nil
+	}
+
 	return r.reader.Close()
 }
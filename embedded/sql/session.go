@@ -0,0 +1,163 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"io"
+	"math"
+	"strings"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/embedded/tbtree"
+)
+
+// Session runs statements against its own database instead of the one selected with UseDatabase,
+// thus different sessions can be concurrently used on the same engine.
+// Each session queries its own snapshot, so readers left open in one session don't prevent others
+// from seeing the latest committed data. Snapshot settings set with UseSnapshot are still shared with the engine.
+// Sessions must be closed in order to release their snapshot.
+type Session struct {
+	e  *Engine
+	db string
+
+	snapshot *store.Snapshot // guarded by the engine snapshotMutex
+
+	closed bool
+}
+
+func (e *Engine) SessionWithDatabase(dbName string) (*Session, error) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	if e.closed {
+		return nil, ErrAlreadyClosed
+	}
+
+	// TODO (jeroiraz): won't be needed when in-memory catalog becomes transactional
+	if e.catalog == nil {
+		return nil, ErrCatalogNotReady
+	}
+
+	db, err := e.catalog.GetDatabaseByName(dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Session{e: e, db: db.name}, nil
+}
+
+func (s *Session) Close() error {
+	s.e.mutex.Lock()
+	defer s.e.mutex.Unlock()
+
+	if s.closed {
+		return ErrAlreadyClosed
+	}
+
+	s.e.snapshotMutex.Lock()
+	defer s.e.snapshotMutex.Unlock()
+
+	if s.snapshot != nil {
+		err := s.snapshot.Close()
+		if err != nil {
+			return err
+		}
+
+		s.snapshot = nil
+	}
+
+	s.closed = true
+
+	return nil
+}
+
+// getSnapshot returns the snapshot used by the session, a new one is taken when renewSnapshot is set
+// unless readers over the current one are still open
+func (s *Session) getSnapshot(renewSnapshot bool) (*store.Snapshot, error) {
+	if s.snapshot == nil || renewSnapshot {
+		snap, err := s.e.snapshotSince(s.snapshot, math.MaxUint64)
+		if err == tbtree.ErrReadersNotClosed {
+			// the snapshot in use is kept while it's still being read
+			return s.snapshot, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		s.snapshot = snap
+	}
+
+	return s.snapshot, nil
+}
+
+func (s *Session) DatabaseInUse() (*Database, error) {
+	s.e.mutex.RLock()
+	defer s.e.mutex.RUnlock()
+
+	if s.e.closed {
+		return nil, ErrAlreadyClosed
+	}
+
+	// TODO (jeroiraz): won't be needed when in-memory catalog becomes transactional
+	if s.e.catalog == nil {
+		return nil, ErrCatalogNotReady
+	}
+
+	return s.e.databaseInUse(s)
+}
+
+func (s *Session) InferParameters(sql string) (map[string]SQLValueType, error) {
+	s.e.mutex.RLock()
+	defer s.e.mutex.RUnlock()
+
+	if s.e.closed {
+		return nil, ErrAlreadyClosed
+	}
+
+	return s.e.inferParametersFrom(s, strings.NewReader(sql))
+}
+
+func (s *Session) InferParametersPreparedStmt(stmt SQLStmt) (map[string]SQLValueType, error) {
+	return s.e.inferParametersPreparedStmt(s, stmt)
+}
+
+func (s *Session) QueryStmt(sql string, params map[string]interface{}, renewSnapshot bool) (RowReader, error) {
+	return s.e.queryStmt(s, sql, params, renewSnapshot)
+}
+
+func (s *Session) Query(sql io.ByteReader, params map[string]interface{}, renewSnapshot bool) (RowReader, error) {
+	return s.e.query(s, sql, params, renewSnapshot)
+}
+
+func (s *Session) QueryPreparedStmt(stmt *SelectStmt, params map[string]interface{}, renewSnapshot bool) (RowReader, error) {
+	return s.e.queryPreparedStmt(s, stmt, params, renewSnapshot)
+}
+
+func (s *Session) QueryAll(sql string, params map[string]interface{}) ([]*Row, []ColDescriptor, error) {
+	return s.e.queryAll(s, sql, params)
+}
+
+func (s *Session) ExecStmt(sql string, params map[string]interface{}, waitForIndexing bool) (*ExecSummary, error) {
+	return s.e.execStmt(s, sql, params, waitForIndexing)
+}
+
+func (s *Session) Exec(sql io.ByteReader, params map[string]interface{}, waitForIndexing bool) (*ExecSummary, error) {
+	return s.e.exec(s, sql, params, waitForIndexing)
+}
+
+func (s *Session) ExecPreparedStmts(stmts []SQLStmt, params map[string]interface{}, waitForIndexing bool) (*ExecSummary, error) {
+	return s.e.execPreparedStmts(s, stmts, params, waitForIndexing)
+}
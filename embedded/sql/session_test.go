@@ -0,0 +1,264 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/embedded/tbtree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionWithDatabase(t *testing.T) {
+	catalogStore, err := store.Open("catalog_session", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_session")
+
+	dataStore, err := store.Open("sqldata_session", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_session")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.SessionWithDatabase("db1")
+	require.ErrorIs(t, err, ErrCatalogNotReady)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db2", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.SessionWithDatabase("db3")
+	require.ErrorIs(t, err, ErrDatabaseDoesNotExist)
+
+	session1, err := engine.SessionWithDatabase("db1")
+	require.NoError(t, err)
+
+	session2, err := engine.SessionWithDatabase("db2")
+	require.NoError(t, err)
+
+	// the engine itself has no database selected
+	_, err = engine.DatabaseInUse()
+	require.ErrorIs(t, err, ErrNoDatabaseSelected)
+
+	db, err := session1.DatabaseInUse()
+	require.NoError(t, err)
+	require.Equal(t, "db1", db.Name())
+
+	db, err = session2.DatabaseInUse()
+	require.NoError(t, err)
+	require.Equal(t, "db2", db.Name())
+
+	sessions := []*Session{session1, session2}
+
+	for _, s := range sessions {
+		_, err = s.ExecStmt("CREATE TABLE table1 (id INTEGER, db VARCHAR, PRIMARY KEY id)", nil, true)
+		require.NoError(t, err)
+	}
+
+	params, err := session1.InferParameters("SELECT id FROM table1 WHERE db = @db")
+	require.NoError(t, err)
+	require.Equal(t, map[string]SQLValueType{"db": VarcharType}, params)
+
+	rowCount := 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(sessions)+1)
+
+	for _, s := range sessions {
+		wg.Add(1)
+
+		go func(s *Session) {
+			defer wg.Done()
+
+			for i := 0; i < rowCount; i++ {
+				_, err := s.ExecStmt("INSERT INTO table1 (id, db) VALUES (@id, @db)", map[string]interface{}{"id": i, "db": s.db}, true)
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				rows, _, err := s.QueryAll("SELECT id, db FROM table1", nil)
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				for _, row := range rows {
+					if row.Values[EncodeSelector("", s.db, "table1", "db")].Value() != s.db {
+						errs <- fmt.Errorf("unexpected row read from session using %s", s.db)
+						return
+					}
+				}
+			}
+		}(s)
+	}
+
+	// switching the database used by the engine does not affect sessions
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < rowCount; i++ {
+			err := engine.UseDatabase(fmt.Sprintf("db%d", i%2+1))
+			if err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	for _, s := range sessions {
+		rows, cols, err := s.QueryAll("SELECT COUNT() AS c FROM table1 WHERE db = @db", map[string]interface{}{"db": s.db})
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, s.db, cols[0].Database)
+		require.Equal(t, int64(rowCount), rows[0].Values[EncodeSelector("", s.db, "table1", "c")].Value())
+	}
+
+	// statements in a session may still refer to other databases
+	r, err := session1.QueryStmt("SELECT COUNT() AS c FROM db2.table1", nil, true)
+	require.NoError(t, err)
+
+	row, err := r.Read()
+	require.NoError(t, err)
+	require.Equal(t, int64(rowCount), row.Values[EncodeSelector("", "db2", "table1", "c")].Value())
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = session2.Close()
+	require.NoError(t, err)
+
+	err = session2.Close()
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	_, _, err = session2.QueryAll("SELECT id FROM table1", nil)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	_, err = session2.ExecStmt("INSERT INTO table1 (id, db) VALUES (100, 'db2')", nil, true)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	_, err = session1.ExecStmt("INSERT INTO table1 (id, db) VALUES (100, 'db1')", nil, true)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	_, err = session1.QueryStmt("SELECT id FROM table1", nil, true)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	_, err = session1.DatabaseInUse()
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	_, err = engine.SessionWithDatabase("db1")
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
+func TestSessionSnapshots(t *testing.T) {
+	catalogStore, err := store.Open("catalog_session_snap", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_session_snap")
+
+	dataStore, err := store.Open("sqldata_session_snap", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_session_snap")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id) VALUES (1)", nil, true)
+	require.NoError(t, err)
+
+	session1, err := engine.SessionWithDatabase("db1")
+	require.NoError(t, err)
+
+	session2, err := engine.SessionWithDatabase("db1")
+	require.NoError(t, err)
+
+	// readers left open by the engine or by another session
+	er, err := engine.QueryStmt("SELECT id FROM table1", nil, true)
+	require.NoError(t, err)
+
+	r1, err := session1.QueryStmt("SELECT id FROM table1", nil, true)
+	require.NoError(t, err)
+
+	_, err = session2.ExecStmt("INSERT INTO table1 (id) VALUES (2)", nil, true)
+	require.NoError(t, err)
+
+	// the session sees its own writes
+	rows, _, err := session2.QueryAll("SELECT id FROM table1", nil)
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 2}, rowIDs(rows))
+
+	// the open reader keeps reading the snapshot it was created on
+	row, err := r1.Read()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+
+	_, err = r1.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	// the snapshot of a session can not be renewed nor released while its readers are open
+	rows, _, err = session1.QueryAll("SELECT id FROM table1", nil)
+	require.NoError(t, err)
+	require.Equal(t, []int64{1}, rowIDs(rows))
+
+	err = session1.Close()
+	require.ErrorIs(t, err, tbtree.ErrReadersNotClosed)
+
+	err = r1.Close()
+	require.NoError(t, err)
+
+	rows, _, err = session1.QueryAll("SELECT id FROM table1", nil)
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 2}, rowIDs(rows))
+
+	err = er.Close()
+	require.NoError(t, err)
+
+	err = session1.Close()
+	require.NoError(t, err)
+
+	err = session2.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
@@ -61,14 +61,23 @@ func setResult(l yyLexer, stmts []SQLStmt) {
     pparam int
     update *colUpdate
     updates []*colUpdate
+    tupleUpdate *tupleUpdate
+    updateStmt *UpdateStmt
+    onConflict *conflictClause
+    pagination pagination
+    grouping grouping
+    param *Param
+    ctes []*commonTableExp
+    cte *commonTableExp
+    merge *MergeStmt
 }
 
-%token CREATE USE DATABASE SNAPSHOT SINCE UP TO TABLE UNIQUE INDEX ON ALTER ADD COLUMN PRIMARY KEY
+%token CREATE USE DATABASE SNAPSHOT SINCE UP TO TABLE UNIQUE INDEX ON ALTER ADD DROP COLUMN PRIMARY KEY
 %token BEGIN TRANSACTION COMMIT
-%token INSERT UPSERT INTO VALUES DELETE UPDATE SET
-%token SELECT DISTINCT FROM BEFORE TX JOIN HAVING WHERE GROUP BY LIMIT ORDER ASC DESC AS
-%token NOT LIKE IF EXISTS IN
-%token AUTO_INCREMENT NULL NPARAM
+%token INSERT UPSERT INTO VALUES DELETE UPDATE SET MERGE USING WHEN MATCHED THEN CONFLICT DO NOTHING RETURNING NULLS
+%token WITH SELECT DISTINCT FROM BEFORE TX FOR SYSTEM_TIME OF CURRENT JOIN HAVING WHERE GROUP BY LIMIT OFFSET FETCH FIRST NEXT ROW ROWS ONLY ORDER ASC DESC AS
+%token NOT LIKE ESCAPE IF EXISTS IN INCLUDING INDEXES COMMENT IS OVER PARTITION UNKNOWN ROLLUP
+%token AUTO_INCREMENT NULL NPARAM DEFAULT GENERATED ALWAYS STORED TYPE_KW ALL
 %token <pparam> PPARAM
 %token <joinType> JOINTYPE
 %token <logicOp> LOP
@@ -85,7 +94,7 @@ func setResult(l yyLexer, stmts []SQLStmt) {
 %left  ','
 %right AS
 %left  LOP
-%right LIKE
+%right LIKE ESCAPE
 %right NOT
 %left  CMPOP
 %left '+' '-'
@@ -101,10 +110,12 @@ func setResult(l yyLexer, stmts []SQLStmt) {
 %type <ids> ids one_or_more_ids opt_ids
 %type <cols> cols
 %type <rows> rows
+%type <ctes> ctes
+%type <cte> cte
 %type <row> row
 %type <values> values opt_values
 %type <value> val
-%type <sel> selector
+%type <sel> selector projection window_fn
 %type <sels> opt_selectors selectors
 %type <col> col
 %type <distinct> opt_distinct
@@ -114,17 +125,29 @@ func setResult(l yyLexer, stmts []SQLStmt) {
 %type <joins> opt_joins joins
 %type <join> join
 %type <joinType> opt_join_type
-%type <exp> exp opt_where opt_having boundexp
+%type <exp> exp opt_where opt_having opt_default boundexp
 %type <binExp> binExp
-%type <cols> opt_groupby
+%type <grouping> opt_groupby
+%type <cols> opt_partition
 %type <number> opt_limit opt_max_len
+%type <pagination> opt_pagination limit_clause offset_clause
+%type <param> param
 %type <id> opt_as
+%type <id> col_id col_label
+%type <id> DEFAULT OFFSET FETCH FIRST NEXT ROW ROWS ONLY INCLUDING INDEXES ESCAPE WITH COMMENT MERGE USING WHEN MATCHED THEN FOR SYSTEM_TIME OVER PARTITION CONFLICT DO NOTHING GENERATED ALWAYS STORED UNKNOWN RETURNING NULLS CURRENT OF ROLLUP TYPE_KW ALL
+%type <str> comment
 %type <ordcols> ordcols opt_orderby
 %type <opt_ord> opt_ord
 %type <ids> opt_indexon
-%type <boolean> opt_if_not_exists opt_auto_increment opt_not_null opt_not
+%type <boolean> opt_if_not_exists opt_if_exists opt_including_indexes opt_auto_increment opt_not_null opt_not opt_nulls_distinct
 %type <update> update
-%type <updates> updates
+%type <updates> updates merge_matched
+%type <tupleUpdate> tuple_update
+%type <updateStmt> assignments
+%type <onConflict> opt_on_conflict
+%type <ids> opt_conflict_target
+%type <ids> opt_returning returned_rows
+%type <merge> merge_actions merge_not_matched
 
 %start sql
 
@@ -194,25 +217,76 @@ ddlstmt:
         $$ = &UseSnapshotStmt{sinceTx: $3, asBefore: $4}
     }
 |
-    CREATE TABLE opt_if_not_exists IDENTIFIER '(' colsSpec ',' PRIMARY KEY one_or_more_ids ')'
+    CREATE TABLE opt_if_not_exists col_label '(' colsSpec ',' PRIMARY KEY one_or_more_ids ')'
     {
         $$ = &CreateTableStmt{ifNotExists: $3, table: $4, colsSpec: $6, pkColNames: $10}
     }
 |
-    CREATE INDEX opt_if_not_exists ON IDENTIFIER '(' ids ')'
+    CREATE TABLE opt_if_not_exists col_label '(' LIKE col_label opt_including_indexes ')'
     {
-        $$ = &CreateIndexStmt{ifNotExists: $3, table: $5, cols: $7}
+        $$ = &CreateTableLikeStmt{ifNotExists: $3, table: $4, sourceTable: $7, includingIndexes: $8}
     }
 |
-    CREATE UNIQUE INDEX opt_if_not_exists ON IDENTIFIER '(' ids ')'
+    DROP TABLE opt_if_exists col_label
     {
-        $$ = &CreateIndexStmt{unique: true, ifNotExists: $4, table: $6, cols: $8}
+        $$ = &DropTableStmt{ifExists: $3, table: $4}
     }
 |
-    ALTER TABLE IDENTIFIER ADD COLUMN colSpec
+    CREATE INDEX opt_if_not_exists ON col_label '(' ids ')' opt_nulls_distinct
+    {
+        $$ = &CreateIndexStmt{ifNotExists: $3, table: $5, cols: $7, nullable: $9}
+    }
+|
+    CREATE UNIQUE INDEX opt_if_not_exists ON col_label '(' ids ')' opt_nulls_distinct
+    {
+        $$ = &CreateIndexStmt{unique: true, ifNotExists: $4, table: $6, cols: $8, nullable: $10}
+    }
+|
+    DROP INDEX opt_if_exists ON col_label '(' ids ')'
+    {
+        $$ = &DropIndexStmt{ifExists: $3, table: $5, cols: $7}
+    }
+|
+    ALTER TABLE col_label ADD COLUMN colSpec
     {
         $$ = &AddColumnStmt{table: $3, colSpec: $6}
     }
+|
+    ALTER TABLE col_label AUTO_INCREMENT CMPOP NUMBER
+    {
+        $$ = &AlterAutoIncrementStmt{table: $3, op: $5, nextValue: $6}
+    }
+|
+    ALTER TABLE col_label ALTER COLUMN col_label DROP DEFAULT
+    {
+        $$ = &DropDefaultStmt{table: $3, col: $6}
+    }
+|
+    ALTER TABLE col_label ALTER COLUMN col_label TYPE_KW TYPE opt_max_len
+    {
+        $$ = &AlterColumnTypeStmt{table: $3, col: $6, colType: $8, maxLen: int($9)}
+    }
+|
+    COMMENT ON TABLE col_label IS comment
+    {
+        $$ = &CommentStmt{table: $4, comment: $6}
+    }
+|
+    COMMENT ON COLUMN col_label '.' col_label IS comment
+    {
+        $$ = &CommentStmt{table: $4, col: $6, comment: $8}
+    }
+
+comment:
+    VARCHAR
+    {
+        $$ = $1
+    }
+|
+    NULL
+    {
+        $$ = ""
+    }
 
 opt_since:
     {
@@ -234,8 +308,38 @@ opt_if_not_exists:
         $$ = true
     }
 
+opt_if_exists:
+    {
+        $$ = false
+    }
+|
+    IF EXISTS
+    {
+        $$ = true
+    }
+
+opt_nulls_distinct:
+    {
+        $$ = false
+    }
+|
+    NULLS DISTINCT
+    {
+        $$ = true
+    }
+
+opt_including_indexes:
+    {
+        $$ = false
+    }
+|
+    INCLUDING INDEXES
+    {
+        $$ = true
+    }
+
 one_or_more_ids:
-    IDENTIFIER
+    col_label
     {
         $$ = []string{$1}
     }
@@ -246,14 +350,14 @@ one_or_more_ids:
     }
 
 dmlstmt:
-    INSERT INTO tableRef '(' opt_ids ')' VALUES rows
+    INSERT INTO tableRef '(' opt_ids ')' VALUES rows opt_on_conflict opt_returning
     {
-        $$ = &UpsertIntoStmt{isInsert: true, tableRef: $3, cols: $5, rows: $8}
+        $$ = &UpsertIntoStmt{isInsert: true, tableRef: $3, cols: $5, rows: $8, onConflict: $9, returning: $10}
     }
 |
-    UPSERT INTO tableRef '(' ids ')' VALUES rows
+    UPSERT INTO tableRef '(' ids ')' VALUES rows opt_returning
     {
-        $$ = &UpsertIntoStmt{tableRef: $3, cols: $5, rows: $8}
+        $$ = &UpsertIntoStmt{tableRef: $3, cols: $5, rows: $8, returning: $9}
     }
 |
     DELETE FROM tableRef opt_where opt_indexon opt_limit
@@ -261,9 +365,63 @@ dmlstmt:
         $$ = &DeleteFromStmt{tableRef: $3, where: $4, indexOn: $5, limit: int($6)}
     }
 |
-    UPDATE tableRef SET updates opt_where opt_indexon opt_limit
+    UPDATE tableRef SET assignments opt_where opt_indexon opt_limit
+    {
+        $4.tableRef = $2
+        $4.where = $5
+        $4.indexOn = $6
+        $4.limit = int($7)
+        $$ = $4
+    }
+|
+    DELETE FROM tableRef WHERE CURRENT OF val
     {
-        $$ = &UpdateStmt{tableRef: $2, updates: $4, where: $5, indexOn: $6, limit: int($7)}
+        $$ = &DeleteFromStmt{tableRef: $3, currentOf: $7}
+    }
+|
+    UPDATE tableRef SET assignments WHERE CURRENT OF val
+    {
+        $4.tableRef = $2
+        $4.currentOf = $8
+        $$ = $4
+    }
+|
+    MERGE INTO tableRef opt_as USING ds ON exp merge_actions
+    {
+        $3.as = $4
+        $9.target = $3
+        $9.source = $6
+        $9.on = $8
+        $$ = $9
+    }
+
+merge_actions:
+    merge_matched
+    {
+        $$ = &MergeStmt{updates: $1}
+    }
+|
+    merge_not_matched
+    {
+        $$ = $1
+    }
+|
+    merge_matched merge_not_matched
+    {
+        $2.updates = $1
+        $$ = $2
+    }
+
+merge_matched:
+    WHEN MATCHED THEN UPDATE SET updates
+    {
+        $$ = $6
+    }
+
+merge_not_matched:
+    WHEN NOT MATCHED THEN INSERT '(' ids ')' VALUES row
+    {
+        $$ = &MergeStmt{insertCols: $7, insertValues: $10.Values}
     }
 
 updates:
@@ -278,11 +436,45 @@ updates:
     }
 
 update:
-    IDENTIFIER CMPOP exp
+    col_label CMPOP exp
     {
         $$ = &colUpdate{col: $1, op: $2, val: $3}
     }
 
+assignments:
+    update
+    {
+        $$ = &UpdateStmt{updates: []*colUpdate{$1}}
+    }
+|
+    tuple_update
+    {
+        $$ = &UpdateStmt{tupleUpdates: []*tupleUpdate{$1}}
+    }
+|
+    assignments ',' update
+    {
+        $1.updates = append($1.updates, $3)
+        $$ = $1
+    }
+|
+    assignments ',' tuple_update
+    {
+        $1.tupleUpdates = append($1.tupleUpdates, $3)
+        $$ = $1
+    }
+
+tuple_update:
+    '(' ids ')' CMPOP '(' values ')'
+    {
+        $$ = &tupleUpdate{cols: $2, op: $4, vals: $6}
+    }
+|
+    '(' ids ')' CMPOP '(' dqlstmt ')'
+    {
+        $$ = &tupleUpdate{cols: $2, op: $4, q: $6.(*SelectStmt)}
+    }
+
 opt_ids:
     {
         $$ = nil
@@ -311,12 +503,12 @@ row:
     }
 
 ids:
-    IDENTIFIER
+    col_label
     {
         $$ = []string{$1}
     }
 |
-    ids ',' IDENTIFIER
+    ids ',' col_label
     {
         $$ = append($1, $3)
     }
@@ -374,24 +566,24 @@ val:
         $$ = &Blob{val: $1}
     }
 |
-    IDENTIFIER '(' ')'
+    IDENTIFIER '(' opt_values ')'
     {
-        $$ = &SysFn{fn: $1}
+        $$ = &SysFn{fn: $1, params: $3}
     }
 |
-    NPARAM IDENTIFIER
+    param
     {
-        $$ = &Param{id: $2}
+        $$ = $1
     }
 |
-    PPARAM
+    NULL
     {
-        $$ = &Param{id: fmt.Sprintf("param%d", $1), pos: $1}
+        $$ = &NullValue{t: AnyType}
     }
 |
-    NULL
+    DEFAULT
     {
-        $$ = &NullValue{t: AnyType}
+        $$ = &DefaultValue{}
     }
 
 colsSpec:
@@ -406,9 +598,22 @@ colsSpec:
     }
 
 colSpec:
-    IDENTIFIER TYPE opt_max_len opt_auto_increment opt_not_null
+    col_label TYPE opt_max_len opt_auto_increment opt_not_null opt_default
+    {
+        $$ = &ColSpec{colName: $1, colType: $2, maxLen: int($3), autoIncrement: $4, notNull: $5, defaultValue: $6}
+    }
+|
+    col_label TYPE opt_max_len opt_generated_always AS '(' exp ')' STORED opt_not_null
+    {
+        $$ = &ColSpec{colName: $1, colType: $2, maxLen: int($3), notNull: $10, generatedAs: $7}
+    }
+
+opt_generated_always:
+    {
+    }
+|
+    GENERATED ALWAYS
     {
-        $$ = &ColSpec{colName: $1, colType: $2, maxLen: int($3), autoIncrement: $4, notNull: $5}
     }
 
 opt_max_len:
@@ -431,6 +636,16 @@ opt_auto_increment:
         $$ = true
     }
 
+opt_default:
+    {
+        $$ = nil
+    }
+|
+    DEFAULT exp
+    {
+        $$ = $2
+    }
+
 opt_not_null:
     {
         $$ = false
@@ -447,7 +662,7 @@ opt_not_null:
     }
 
 dqlstmt:
-    SELECT opt_distinct opt_selectors FROM ds opt_indexon opt_joins opt_where opt_groupby opt_having opt_orderby opt_limit
+    SELECT opt_distinct opt_selectors FROM ds opt_indexon opt_joins opt_where opt_groupby opt_having opt_orderby opt_pagination
     {
         $$ = &SelectStmt{
                 distinct: $2,
@@ -456,12 +671,51 @@ dqlstmt:
                 indexOn: $6,
                 joins: $7,
                 where: $8,
-                groupBy: $9,
+                groupBy: $9.cols,
+                rollup: $9.rollup,
                 having: $10,
                 orderBy: $11,
-                limit: int($12),
+                limit: $12.limit,
+                limitParam: $12.limitParam,
+                hasLimit: $12.hasLimit,
+                offset: $12.offset,
+                offsetParam: $12.offsetParam,
             }
     }
+|
+    VALUES rows
+    {
+        $$ = &SelectStmt{ds: &valuesDataSource{rows: $2}}
+    }
+|
+    TABLE tableRef
+    {
+        $$ = &SelectStmt{ds: $2}
+    }
+|
+    WITH ctes dqlstmt
+    {
+        stmt := $3.(*SelectStmt)
+        stmt.ctes = append($2, stmt.ctes...)
+        $$ = stmt
+    }
+
+ctes:
+    cte
+    {
+        $$ = []*commonTableExp{$1}
+    }
+|
+    ctes ',' cte
+    {
+        $$ = append($1, $3)
+    }
+
+cte:
+    col_label AS '(' dqlstmt ')'
+    {
+        $$ = &commonTableExp{name: $1, query: $4.(*SelectStmt)}
+    }
 
 opt_distinct:
     {
@@ -485,18 +739,46 @@ opt_selectors:
     }
 
 selectors:
-    selector opt_as
+    projection
     {
-        $1.setAlias($2)
         $$ = []Selector{$1}
     }
 |
-    selectors ',' selector opt_as
+    selectors ',' projection
     {
-        $3.setAlias($4)
         $$ = append($1, $3)
     }
 
+projection:
+    exp opt_as
+    {
+        sel := asSelector($1)
+        sel.setAlias($2)
+        $$ = sel
+    }
+|
+    window_fn opt_as
+    {
+        $1.setAlias($2)
+        $$ = $1
+    }
+
+window_fn:
+    IDENTIFIER '(' opt_values ')' OVER '(' opt_partition ORDER BY ordcols ')'
+    {
+        $$ = &WindowFnSelector{fn: $1, params: $3, partitionBy: $7, orderBy: $10}
+    }
+
+opt_partition:
+    {
+        $$ = nil
+    }
+|
+    PARTITION BY cols
+    {
+        $$ = $3
+    }
+
 selector:
     col
     {
@@ -512,19 +794,24 @@ selector:
     {
         $$ = &AggColSelector{aggFn: $1, db: $3.db, table: $3.table, col: $3.col}
     }
+|
+    AGGREGATE_FUNC '(' DISTINCT col ')'
+    {
+        $$ = &AggColSelector{aggFn: $1, distinct: true, db: $4.db, table: $4.table, col: $4.col}
+    }
 
 col:
-    IDENTIFIER
+    col_id
     {
         $$ = &ColSelector{col: $1}
     }
 |
-    IDENTIFIER '.' IDENTIFIER
+    col_id '.' col_label
     {
         $$ = &ColSelector{table: $1, col: $3}
     }
 |
-    IDENTIFIER '.' IDENTIFIER '.' IDENTIFIER
+    col_id '.' col_label '.' col_label
     {
         $$ = &ColSelector{db: $1, table: $3, col: $5}
     }
@@ -544,12 +831,12 @@ ds:
     }
 
 tableRef:
-    IDENTIFIER
+    col_label
     {
         $$ = &tableRef{table: $1}
     }
 |
-    IDENTIFIER '.' IDENTIFIER
+    col_label '.' col_label
     {
         $$ = &tableRef{db: $1, table: $3}
     }
@@ -563,6 +850,11 @@ opt_as_before:
     {
         $$ = $3
     }
+|
+    FOR SYSTEM_TIME AS OF TX NUMBER
+    {
+        $$ = $6 + 1
+    }
 
 opt_joins:
     {
@@ -613,12 +905,17 @@ opt_where:
 
 opt_groupby:
     {
-        $$ = nil
+        $$ = grouping{}
     }
 |
     GROUP BY cols
     {
-        $$ = $3
+        $$ = grouping{cols: $3}
+    }
+|
+    GROUP BY ROLLUP '(' cols ')'
+    {
+        $$ = grouping{cols: $5, rollup: true}
     }
 
 opt_having:
@@ -641,6 +938,85 @@ opt_limit:
         $$ = $2
     }
 
+opt_pagination:
+    {
+        $$ = pagination{}
+    }
+|
+    limit_clause
+    {
+        $$ = $1
+    }
+|
+    offset_clause
+    {
+        $$ = $1
+    }
+|
+    limit_clause offset_clause
+    {
+        $$ = pagination{limit: $1.limit, limitParam: $1.limitParam, hasLimit: $1.hasLimit, offset: $2.offset, offsetParam: $2.offsetParam}
+    }
+|
+    offset_clause limit_clause
+    {
+        $$ = pagination{limit: $2.limit, limitParam: $2.limitParam, hasLimit: $2.hasLimit, offset: $1.offset, offsetParam: $1.offsetParam}
+    }
+
+limit_clause:
+    LIMIT NUMBER
+    {
+        $$ = pagination{limit: int($2), hasLimit: true}
+    }
+|
+    LIMIT param
+    {
+        $$ = pagination{limitParam: $2, hasLimit: true}
+    }
+|
+    LIMIT ALL
+    {
+        $$ = pagination{}
+    }
+|
+    FETCH first_or_next NUMBER row_or_rows ONLY
+    {
+        $$ = pagination{limit: int($3), hasLimit: true}
+    }
+|
+    FETCH first_or_next param row_or_rows ONLY
+    {
+        $$ = pagination{limitParam: $3, hasLimit: true}
+    }
+
+offset_clause:
+    OFFSET NUMBER opt_row_or_rows
+    {
+        $$ = pagination{offset: int($2)}
+    }
+|
+    OFFSET param opt_row_or_rows
+    {
+        $$ = pagination{offsetParam: $2}
+    }
+
+param:
+    NPARAM IDENTIFIER
+    {
+        $$ = &Param{id: $2}
+    }
+|
+    PPARAM
+    {
+        $$ = &Param{id: fmt.Sprintf("param%d", $1), pos: $1}
+    }
+
+first_or_next: FIRST | NEXT
+
+row_or_rows: ROW | ROWS
+
+opt_row_or_rows: | row_or_rows
+
 opt_orderby:
     {
         $$ = nil
@@ -651,6 +1027,52 @@ opt_orderby:
         $$ = $3
     }
 
+opt_on_conflict:
+    {
+        $$ = nil
+    }
+|
+    ON CONFLICT opt_conflict_target DO NOTHING
+    {
+        $$ = &conflictClause{target: $3}
+    }
+|
+    ON CONFLICT opt_conflict_target DO UPDATE SET updates
+    {
+        $$ = &conflictClause{target: $3, updates: $7}
+    }
+
+opt_returning:
+    {
+        $$ = nil
+    }
+|
+    RETURNING returned_rows
+    {
+        $$ = $2
+    }
+
+returned_rows:
+    IDENTIFIER '.' '*'
+    {
+        $$ = []string{$1}
+    }
+|
+    returned_rows ',' IDENTIFIER '.' '*'
+    {
+        $$ = append($1, $3)
+    }
+
+opt_conflict_target:
+    {
+        $$ = nil
+    }
+|
+    '(' ids ')'
+    {
+        $$ = $2
+    }
+
 opt_indexon:
     {
         $$ = nil
@@ -697,7 +1119,7 @@ opt_as:
         $$ = $1
     }
 |
-    AS IDENTIFIER
+    AS col_label
     {
         $$ = $2
     }
@@ -727,6 +1149,11 @@ exp:
     {
         $$ = &LikeBoolExp{val: $1, notLike: $2, pattern: $4}
     }
+|
+    boundexp opt_not LIKE exp ESCAPE VARCHAR
+    {
+        $$ = &LikeBoolExp{val: $1, notLike: $2, pattern: $4, withEscape: true, escape: $6}
+    }
 |
     EXISTS '(' dqlstmt ')'
     {
@@ -742,6 +1169,16 @@ exp:
     {
         $$ = &InListExp{val: $1, notIn: $2, values: $5}
     }
+|
+    boundexp IS opt_not BOOLEAN
+    {
+        $$ = &IsBoolExp{exp: $1, not: $3, val: $4}
+    }
+|
+    boundexp IS opt_not UNKNOWN
+    {
+        $$ = &IsBoolExp{exp: $1, not: $3, unknown: true}
+    }
 
 boundexp:
     selector
@@ -798,4 +1235,48 @@ binExp:
     exp CMPOP exp
     {
         $$ = &CmpBoolExp{left: $1, op: $2, right: $3}
-    }
\ No newline at end of file
+    }
+
+col_id:
+    IDENTIFIER
+|
+    OFFSET | FETCH | FIRST | NEXT | ROW | ROWS | ONLY
+|
+    INCLUDING | INDEXES
+|
+    ESCAPE
+|
+    WITH
+|
+    COMMENT
+|
+    MERGE | USING | WHEN | MATCHED | THEN
+|
+    FOR | SYSTEM_TIME
+|
+    OVER
+|
+    PARTITION
+|
+    CONFLICT | DO | NOTHING
+|
+    GENERATED | ALWAYS | STORED
+|
+    UNKNOWN
+|
+    RETURNING
+|
+    NULLS
+|
+    CURRENT | OF
+|
+    ROLLUP
+|
+    TYPE_KW
+|
+    ALL
+
+col_label:
+    col_id
+|
+    DEFAULT
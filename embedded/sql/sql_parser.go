@@ -10,43 +10,52 @@ func setResult(l yyLexer, stmts []SQLStmt) {
 }
 
 type yySymType struct {
-	yys      int
-	stmts    []SQLStmt
-	stmt     SQLStmt
-	colsSpec []*ColSpec
-	colSpec  *ColSpec
-	cols     []*ColSelector
-	rows     []*RowSpec
-	row      *RowSpec
-	values   []ValueExp
-	value    ValueExp
-	id       string
-	number   uint64
-	str      string
-	boolean  bool
-	blob     []byte
-	sqlType  SQLValueType
-	aggFn    AggregateFn
-	ids      []string
-	col      *ColSelector
-	sel      Selector
-	sels     []Selector
-	distinct bool
-	ds       DataSource
-	tableRef *tableRef
-	joins    []*JoinSpec
-	join     *JoinSpec
-	joinType JoinType
-	exp      ValueExp
-	binExp   ValueExp
-	err      error
-	ordcols  []*OrdCol
-	opt_ord  bool
-	logicOp  LogicOperator
-	cmpOp    CmpOperator
-	pparam   int
-	update   *colUpdate
-	updates  []*colUpdate
+	yys         int
+	stmts       []SQLStmt
+	stmt        SQLStmt
+	colsSpec    []*ColSpec
+	colSpec     *ColSpec
+	cols        []*ColSelector
+	rows        []*RowSpec
+	row         *RowSpec
+	values      []ValueExp
+	value       ValueExp
+	id          string
+	number      uint64
+	str         string
+	boolean     bool
+	blob        []byte
+	sqlType     SQLValueType
+	aggFn       AggregateFn
+	ids         []string
+	col         *ColSelector
+	sel         Selector
+	sels        []Selector
+	distinct    bool
+	ds          DataSource
+	tableRef    *tableRef
+	joins       []*JoinSpec
+	join        *JoinSpec
+	joinType    JoinType
+	exp         ValueExp
+	binExp      ValueExp
+	err         error
+	ordcols     []*OrdCol
+	opt_ord     bool
+	logicOp     LogicOperator
+	cmpOp       CmpOperator
+	pparam      int
+	update      *colUpdate
+	updates     []*colUpdate
+	tupleUpdate *tupleUpdate
+	updateStmt  *UpdateStmt
+	onConflict  *conflictClause
+	pagination  pagination
+	grouping    grouping
+	param       *Param
+	ctes        []*commonTableExp
+	cte         *commonTableExp
+	merge       *MergeStmt
 }
 
 const CREATE = 57346
@@ -62,55 +71,93 @@ const INDEX = 57355
 const ON = 57356
 const ALTER = 57357
 const ADD = 57358
-const COLUMN = 57359
-const PRIMARY = 57360
-const KEY = 57361
-const BEGIN = 57362
-const TRANSACTION = 57363
-const COMMIT = 57364
-const INSERT = 57365
-const UPSERT = 57366
-const INTO = 57367
-const VALUES = 57368
-const DELETE = 57369
-const UPDATE = 57370
-const SET = 57371
-const SELECT = 57372
-const DISTINCT = 57373
-const FROM = 57374
-const BEFORE = 57375
-const TX = 57376
-const JOIN = 57377
-const HAVING = 57378
-const WHERE = 57379
-const GROUP = 57380
-const BY = 57381
-const LIMIT = 57382
-const ORDER = 57383
-const ASC = 57384
-const DESC = 57385
-const AS = 57386
-const NOT = 57387
-const LIKE = 57388
-const IF = 57389
-const EXISTS = 57390
-const IN = 57391
-const AUTO_INCREMENT = 57392
-const NULL = 57393
-const NPARAM = 57394
-const PPARAM = 57395
-const JOINTYPE = 57396
-const LOP = 57397
-const CMPOP = 57398
-const IDENTIFIER = 57399
-const TYPE = 57400
-const NUMBER = 57401
-const VARCHAR = 57402
-const BOOLEAN = 57403
-const BLOB = 57404
-const AGGREGATE_FUNC = 57405
-const ERROR = 57406
-const STMT_SEPARATOR = 57407
+const DROP = 57359
+const COLUMN = 57360
+const PRIMARY = 57361
+const KEY = 57362
+const BEGIN = 57363
+const TRANSACTION = 57364
+const COMMIT = 57365
+const INSERT = 57366
+const UPSERT = 57367
+const INTO = 57368
+const VALUES = 57369
+const DELETE = 57370
+const UPDATE = 57371
+const SET = 57372
+const MERGE = 57373
+const USING = 57374
+const WHEN = 57375
+const MATCHED = 57376
+const THEN = 57377
+const CONFLICT = 57378
+const DO = 57379
+const NOTHING = 57380
+const RETURNING = 57381
+const NULLS = 57382
+const WITH = 57383
+const SELECT = 57384
+const DISTINCT = 57385
+const FROM = 57386
+const BEFORE = 57387
+const TX = 57388
+const FOR = 57389
+const SYSTEM_TIME = 57390
+const OF = 57391
+const CURRENT = 57392
+const JOIN = 57393
+const HAVING = 57394
+const WHERE = 57395
+const GROUP = 57396
+const BY = 57397
+const LIMIT = 57398
+const OFFSET = 57399
+const FETCH = 57400
+const FIRST = 57401
+const NEXT = 57402
+const ROW = 57403
+const ROWS = 57404
+const ONLY = 57405
+const ORDER = 57406
+const ASC = 57407
+const DESC = 57408
+const AS = 57409
+const NOT = 57410
+const LIKE = 57411
+const ESCAPE = 57412
+const IF = 57413
+const EXISTS = 57414
+const IN = 57415
+const INCLUDING = 57416
+const INDEXES = 57417
+const COMMENT = 57418
+const IS = 57419
+const OVER = 57420
+const PARTITION = 57421
+const UNKNOWN = 57422
+const ROLLUP = 57423
+const AUTO_INCREMENT = 57424
+const NULL = 57425
+const NPARAM = 57426
+const DEFAULT = 57427
+const GENERATED = 57428
+const ALWAYS = 57429
+const STORED = 57430
+const TYPE_KW = 57431
+const ALL = 57432
+const PPARAM = 57433
+const JOINTYPE = 57434
+const LOP = 57435
+const CMPOP = 57436
+const IDENTIFIER = 57437
+const TYPE = 57438
+const NUMBER = 57439
+const VARCHAR = 57440
+const BOOLEAN = 57441
+const BLOB = 57442
+const AGGREGATE_FUNC = 57443
+const ERROR = 57444
+const STMT_SEPARATOR = 57445
 
 var yyToknames = [...]string{
 	"$end",
@@ -129,6 +176,7 @@ var yyToknames = [...]string{
 	"ON",
 	"ALTER",
 	"ADD",
+	"DROP",
 	"COLUMN",
 	"PRIMARY",
 	"KEY",
@@ -142,29 +190,66 @@ var yyToknames = [...]string{
 	"DELETE",
 	"UPDATE",
 	"SET",
+	"MERGE",
+	"USING",
+	"WHEN",
+	"MATCHED",
+	"THEN",
+	"CONFLICT",
+	"DO",
+	"NOTHING",
+	"RETURNING",
+	"NULLS",
+	"WITH",
 	"SELECT",
 	"DISTINCT",
 	"FROM",
 	"BEFORE",
 	"TX",
+	"FOR",
+	"SYSTEM_TIME",
+	"OF",
+	"CURRENT",
 	"JOIN",
 	"HAVING",
 	"WHERE",
 	"GROUP",
 	"BY",
 	"LIMIT",
+	"OFFSET",
+	"FETCH",
+	"FIRST",
+	"NEXT",
+	"ROW",
+	"ROWS",
+	"ONLY",
 	"ORDER",
 	"ASC",
 	"DESC",
 	"AS",
 	"NOT",
 	"LIKE",
+	"ESCAPE",
 	"IF",
 	"EXISTS",
 	"IN",
+	"INCLUDING",
+	"INDEXES",
+	"COMMENT",
+	"IS",
+	"OVER",
+	"PARTITION",
+	"UNKNOWN",
+	"ROLLUP",
 	"AUTO_INCREMENT",
 	"NULL",
 	"NPARAM",
+	"DEFAULT",
+	"GENERATED",
+	"ALWAYS",
+	"STORED",
+	"TYPE_KW",
+	"ALL",
 	"PPARAM",
 	"JOINTYPE",
 	"LOP",
@@ -200,186 +285,422 @@ var yyExca = [...]int{
 	-1, 1,
 	1, -1,
 	-2, 0,
-	-1, 109,
-	46, 121,
-	49, 121,
-	-2, 110,
-	-1, 128,
-	35, 88,
-	-2, 83,
-	-1, 166,
-	35, 88,
-	-2, 85,
+	-1, 100,
+	69, 202,
+	73, 202,
+	-2, 188,
+	-1, 260,
+	51, 136,
+	-2, 131,
+	-1, 306,
+	51, 136,
+	-2, 133,
+	-1, 352,
+	67, 88,
+	-2, 92,
 }
 
 const yyPrivate = 57344
 
-const yyLast = 318
+const yyLast = 1627
 
 var yyAct = [...]int{
-	260, 43, 144, 224, 204, 4, 106, 207, 103, 223,
-	87, 203, 135, 165, 80, 65, 83, 7, 74, 236,
-	239, 142, 142, 142, 200, 142, 45, 249, 242, 240,
-	220, 201, 111, 143, 241, 113, 238, 208, 124, 122,
-	123, 35, 92, 212, 121, 191, 117, 118, 119, 120,
-	44, 170, 209, 69, 112, 159, 89, 111, 141, 116,
-	113, 132, 205, 124, 122, 123, 211, 175, 157, 121,
-	93, 117, 118, 119, 120, 44, 137, 95, 79, 112,
-	147, 148, 150, 149, 116, 108, 133, 78, 105, 68,
-	151, 152, 62, 20, 128, 130, 18, 125, 151, 152,
-	69, 147, 148, 150, 149, 259, 131, 129, 190, 147,
-	148, 150, 149, 152, 140, 155, 156, 150, 149, 81,
-	158, 59, 45, 147, 148, 150, 149, 114, 44, 254,
-	37, 163, 161, 40, 239, 221, 172, 142, 64, 5,
-	219, 173, 45, 162, 197, 169, 174, 126, 44, 179,
-	42, 181, 182, 183, 184, 185, 186, 139, 100, 67,
-	45, 38, 104, 189, 192, 177, 171, 84, 160, 136,
-	138, 97, 66, 94, 91, 85, 70, 35, 54, 193,
-	194, 51, 46, 136, 202, 198, 196, 127, 168, 235,
-	206, 210, 90, 86, 250, 234, 47, 218, 187, 96,
-	48, 188, 38, 154, 71, 244, 215, 261, 262, 145,
-	17, 253, 230, 214, 81, 19, 229, 226, 225, 195,
-	99, 227, 231, 49, 232, 76, 237, 75, 63, 33,
-	23, 7, 247, 245, 10, 11, 58, 178, 176, 32,
-	31, 60, 88, 251, 21, 12, 252, 73, 216, 61,
-	6, 2, 255, 13, 14, 257, 258, 15, 16, 34,
-	7, 263, 10, 11, 264, 101, 77, 248, 180, 98,
-	36, 72, 24, 12, 55, 56, 57, 25, 27, 26,
-	146, 13, 14, 50, 30, 15, 16, 53, 28, 29,
-	107, 82, 153, 233, 217, 243, 256, 199, 213, 110,
-	109, 228, 167, 166, 164, 52, 22, 41, 39, 115,
-	222, 246, 102, 134, 9, 8, 3, 1,
+	34, 31, 502, 247, 405, 501, 492, 491, 479, 115,
+	35, 75, 427, 454, 446, 389, 396, 363, 250, 109,
+	445, 382, 352, 123, 107, 209, 4, 30, 276, 286,
+	305, 154, 293, 200, 186, 122, 164, 204, 5, 118,
+	97, 131, 410, 118, 74, 92, 159, 160, 291, 353,
+	162, 433, 419, 375, 345, 451, 514, 155, 156, 158,
+	157, 159, 160, 496, 482, 316, 93, 310, 290, 273,
+	272, 269, 155, 156, 158, 157, 159, 160, 161, 225,
+	397, 159, 160, 291, 268, 141, 267, 155, 156, 158,
+	157, 485, 155, 156, 158, 157, 478, 177, 291, 291,
+	127, 291, 291, 177, 477, 418, 417, 384, 291, 357,
+	354, 346, 176, 118, 118, 151, 302, 291, 32, 118,
+	133, 311, 503, 175, 160, 292, 452, 179, 439, 75,
+	437, 163, 182, 367, 155, 156, 158, 157, 178, 190,
+	159, 160, 347, 196, 197, 160, 124, 322, 205, 282,
+	203, 155, 156, 158, 157, 155, 156, 158, 157, 278,
+	264, 486, 233, 219, 118, 199, 118, 118, 118, 118,
+	118, 118, 198, 180, 229, 98, 185, 181, 172, 170,
+	207, 118, 169, 118, 26, 235, 24, 441, 118, 118,
+	93, 271, 227, 240, 212, 245, 223, 208, 173, 248,
+	248, 126, 222, 249, 158, 157, 224, 248, 232, 500,
+	258, 461, 118, 509, 231, 155, 156, 158, 157, 388,
+	390, 255, 478, 451, 440, 318, 291, 177, 260, 153,
+	121, 118, 266, 344, 277, 385, 254, 279, 262, 118,
+	381, 288, 277, 331, 285, 261, 289, 9, 270, 167,
+	168, 265, 284, 280, 119, 171, 287, 205, 239, 299,
+	468, 120, 359, 8, 308, 320, 118, 466, 118, 162,
+	283, 256, 317, 297, 119, 118, 319, 10, 7, 248,
+	460, 120, 321, 248, 121, 303, 324, 489, 313, 416,
+	174, 312, 329, 309, 300, 135, 130, 161, 337, 498,
+	98, 257, 213, 214, 215, 216, 217, 218, 121, 119,
+	210, 242, 325, 23, 409, 75, 120, 435, 25, 277,
+	33, 333, 472, 248, 230, 379, 355, 358, 407, 380,
+	335, 138, 89, 364, 436, 373, 315, 341, 339, 128,
+	327, 166, 343, 406, 244, 376, 351, 349, 253, 118,
+	165, 118, 234, 191, 132, 139, 361, 360, 362, 195,
+	193, 366, 422, 425, 423, 166, 248, 263, 371, 391,
+	220, 116, 119, 117, 221, 364, 183, 408, 118, 120,
+	480, 481, 386, 334, 326, 111, 112, 113, 114, 281,
+	401, 392, 404, 129, 393, 402, 423, 144, 145, 146,
+	512, 148, 253, 413, 301, 511, 412, 150, 13, 14,
+	118, 118, 420, 294, 118, 140, 432, 493, 494, 16,
+	429, 15, 450, 429, 470, 471, 194, 449, 18, 19,
+	431, 403, 20, 21, 400, 22, 447, 449, 448, 370,
+	248, 118, 118, 459, 340, 447, 118, 448, 118, 201,
+	399, 455, 342, 152, 336, 323, 296, 467, 238, 473,
+	464, 118, 118, 118, 474, 476, 187, 465, 188, 356,
+	455, 475, 429, 237, 189, 88, 411, 29, 9, 490,
+	17, 495, 383, 484, 390, 368, 458, 253, 205, 118,
+	443, 414, 483, 463, 8, 442, 504, 505, 497, 421,
+	205, 259, 499, 507, 248, 508, 506, 510, 10, 7,
+	205, 487, 513, 13, 14, 147, 462, 516, 515, 330,
+	9, 328, 90, 87, 16, 86, 15, 2, 488, 149,
+	6, 374, 27, 18, 19, 243, 8, 20, 21, 241,
+	22, 142, 9, 430, 137, 295, 426, 338, 143, 76,
+	10, 7, 91, 332, 77, 79, 78, 134, 8, 236,
+	192, 184, 50, 51, 52, 53, 54, 59, 60, 61,
+	66, 67, 314, 7, 85, 84, 251, 453, 55, 56,
+	69, 68, 456, 469, 457, 17, 80, 81, 38, 39,
+	40, 41, 42, 43, 44, 82, 378, 83, 394, 102,
+	415, 47, 438, 104, 387, 45, 46, 49, 202, 57,
+	58, 65, 70, 395, 116, 119, 117, 62, 63, 64,
+	71, 72, 120, 377, 350, 424, 125, 444, 111, 112,
+	113, 114, 110, 372, 369, 101, 103, 100, 434, 398,
+	307, 108, 50, 51, 52, 53, 54, 59, 60, 61,
+	66, 67, 48, 306, 304, 136, 28, 96, 55, 56,
+	69, 68, 94, 99, 106, 73, 246, 274, 38, 39,
+	40, 41, 42, 43, 44, 12, 11, 3, 1, 102,
+	0, 47, 0, 104, 0, 45, 46, 49, 0, 57,
+	58, 65, 70, 0, 116, 119, 117, 62, 63, 64,
+	71, 72, 120, 0, 0, 0, 105, 0, 111, 112,
+	113, 114, 110, 0, 0, 0, 103, 95, 0, 0,
+	0, 108, 50, 51, 52, 53, 54, 59, 60, 61,
+	66, 67, 48, 0, 0, 0, 0, 0, 55, 56,
+	69, 68, 0, 0, 0, 0, 0, 0, 38, 39,
+	40, 41, 42, 43, 44, 0, 0, 0, 0, 102,
+	0, 47, 0, 104, 0, 45, 46, 49, 0, 57,
+	58, 65, 70, 0, 116, 119, 117, 62, 63, 64,
+	71, 72, 120, 0, 0, 0, 125, 0, 111, 112,
+	113, 114, 110, 0, 0, 0, 103, 0, 0, 0,
+	0, 108, 50, 51, 52, 53, 54, 59, 60, 61,
+	66, 67, 48, 0, 0, 0, 0, 0, 55, 56,
+	69, 298, 0, 0, 0, 0, 0, 0, 38, 39,
+	40, 41, 42, 43, 44, 0, 0, 0, 0, 102,
+	0, 47, 0, 104, 0, 45, 46, 49, 0, 57,
+	58, 65, 70, 0, 116, 119, 117, 62, 63, 64,
+	71, 72, 120, 0, 0, 0, 125, 0, 111, 112,
+	113, 114, 110, 0, 0, 0, 103, 0, 0, 0,
+	0, 108, 50, 51, 52, 53, 54, 59, 60, 61,
+	66, 67, 48, 0, 0, 0, 0, 0, 55, 56,
+	69, 252, 0, 0, 0, 0, 0, 0, 38, 39,
+	40, 41, 42, 43, 44, 0, 0, 0, 0, 102,
+	0, 47, 0, 104, 0, 45, 46, 49, 0, 57,
+	58, 65, 70, 0, 116, 119, 117, 62, 63, 64,
+	71, 72, 120, 0, 0, 0, 125, 0, 111, 112,
+	113, 114, 110, 0, 0, 0, 103, 0, 0, 0,
+	0, 108, 50, 51, 52, 53, 54, 59, 60, 61,
+	66, 67, 48, 0, 0, 0, 0, 0, 55, 56,
+	69, 68, 0, 0, 0, 0, 0, 0, 38, 39,
+	40, 41, 42, 43, 44, 0, 0, 0, 0, 102,
+	0, 47, 0, 104, 0, 45, 46, 49, 0, 57,
+	58, 65, 70, 0, 116, 119, 117, 62, 63, 64,
+	71, 72, 120, 0, 0, 0, 105, 0, 111, 112,
+	113, 114, 110, 0, 0, 0, 103, 0, 0, 0,
+	0, 108, 50, 51, 52, 53, 54, 59, 60, 61,
+	66, 67, 48, 0, 228, 0, 0, 0, 55, 56,
+	69, 68, 0, 0, 0, 0, 0, 0, 38, 39,
+	40, 41, 42, 43, 44, 0, 0, 0, 0, 0,
+	0, 47, 0, 0, 0, 45, 46, 49, 0, 57,
+	58, 65, 70, 0, 0, 0, 0, 62, 63, 64,
+	71, 72, 0, 0, 0, 0, 37, 50, 51, 52,
+	53, 54, 59, 60, 61, 66, 67, 48, 0, 0,
+	0, 0, 226, 55, 56, 69, 68, 0, 0, 0,
+	0, 0, 0, 38, 39, 40, 41, 42, 43, 44,
+	0, 0, 0, 0, 0, 0, 47, 0, 0, 0,
+	45, 46, 49, 0, 57, 58, 65, 70, 0, 0,
+	0, 36, 62, 63, 64, 71, 72, 0, 0, 0,
+	0, 37, 50, 51, 52, 53, 54, 59, 60, 61,
+	66, 67, 48, 0, 0, 0, 365, 0, 55, 56,
+	69, 68, 0, 0, 0, 0, 0, 0, 38, 39,
+	40, 41, 42, 43, 44, 0, 0, 0, 0, 0,
+	0, 47, 0, 0, 0, 45, 46, 49, 0, 57,
+	58, 65, 70, 0, 0, 0, 36, 62, 63, 64,
+	71, 72, 0, 0, 0, 0, 37, 50, 51, 52,
+	53, 54, 59, 60, 61, 66, 67, 48, 0, 0,
+	0, 211, 0, 55, 56, 69, 68, 0, 0, 0,
+	0, 0, 0, 38, 39, 40, 41, 42, 43, 44,
+	0, 0, 0, 0, 0, 0, 47, 0, 0, 0,
+	45, 46, 49, 0, 57, 58, 65, 70, 0, 0,
+	348, 36, 62, 63, 64, 71, 72, 0, 0, 0,
+	0, 37, 50, 51, 52, 53, 54, 59, 60, 61,
+	66, 67, 48, 0, 0, 0, 206, 0, 55, 56,
+	69, 68, 0, 0, 0, 0, 0, 0, 38, 39,
+	40, 41, 42, 43, 44, 0, 0, 0, 0, 0,
+	0, 47, 0, 0, 0, 45, 46, 49, 0, 57,
+	58, 65, 70, 0, 0, 0, 36, 62, 63, 64,
+	71, 72, 0, 0, 0, 0, 37, 50, 51, 52,
+	53, 54, 59, 60, 61, 66, 67, 48, 0, 0,
+	0, 0, 0, 55, 56, 69, 68, 0, 0, 0,
+	0, 0, 0, 38, 39, 40, 41, 42, 43, 44,
+	0, 0, 0, 0, 0, 275, 47, 0, 0, 0,
+	45, 46, 49, 0, 57, 58, 65, 70, 0, 0,
+	0, 36, 62, 63, 64, 71, 72, 0, 0, 0,
+	0, 37, 50, 51, 52, 53, 54, 59, 60, 61,
+	66, 67, 48, 0, 0, 0, 0, 0, 55, 56,
+	69, 68, 0, 0, 0, 0, 0, 0, 38, 39,
+	40, 41, 42, 43, 44, 0, 0, 0, 0, 0,
+	0, 47, 0, 0, 0, 45, 46, 49, 0, 57,
+	58, 65, 70, 0, 0, 0, 36, 62, 63, 64,
+	71, 72, 0, 0, 0, 0, 37, 50, 51, 52,
+	53, 54, 59, 60, 61, 66, 67, 48, 0, 0,
+	0, 0, 0, 55, 56, 69, 68, 0, 0, 0,
+	0, 0, 0, 38, 39, 40, 41, 42, 43, 44,
+	0, 0, 0, 0, 0, 0, 47, 0, 0, 0,
+	45, 46, 49, 0, 57, 58, 65, 70, 0, 0,
+	0, 0, 62, 63, 64, 71, 72, 0, 0, 0,
+	0, 37, 50, 51, 52, 53, 54, 59, 60, 61,
+	66, 67, 48, 0, 0, 0, 0, 0, 55, 56,
+	69, 68, 0, 0, 0, 0, 0, 0, 38, 39,
+	40, 41, 42, 43, 44, 0, 0, 0, 0, 0,
+	0, 47, 0, 0, 0, 45, 46, 49, 0, 57,
+	58, 65, 428, 0, 0, 0, 0, 62, 63, 64,
+	71, 72, 0, 0, 0, 0, 37,
 }
 
 var yyPact = [...]int{
-	230, -1000, -1000, 25, 22, -1000, 223, 199, -1000, -1000,
-	266, 282, 273, 215, 214, 197, 120, -1000, 230, -1000,
-	-1000, 258, 65, -1000, 125, 153, 153, 270, 124, 279,
-	121, 120, 120, 120, 207, 51, -1000, 219, 21, 196,
-	-1000, 73, 115, -1000, 17, 30, -1000, 119, 159, 257,
-	153, -1000, 194, 191, 250, 15, 6, 177, 110, 118,
-	-1000, -1000, 258, -16, 85, -1000, -1000, 117, -31, 116,
-	5, 151, 114, 255, -1000, 186, 99, 248, 105, 105,
-	285, 12, 82, -1000, 131, -1000, -1000, 285, 194, 201,
-	115, -1000, -1000, -12, 16, 112, -1000, 4, 113, 98,
-	-1000, 112, -15, 72, -1000, -40, 169, 267, 43, 158,
-	-1000, 12, 12, -4, -1000, -1000, 12, -1000, -1000, -1000,
-	-1000, -17, 111, -1000, -1000, 285, 110, 12, 134, 115,
-	-22, -1000, -1000, 109, 71, -1000, 83, 105, -5, -1000,
-	-1000, 212, 108, 211, -1000, 90, 254, 12, 12, 12,
-	12, 12, 12, 152, -1000, 57, 49, 201, 35, -28,
-	-1000, 169, -1000, 43, 177, -1000, 134, 184, -1000, -1000,
-	115, -1000, 126, -50, -42, 105, -10, -1000, -10, -1000,
-	-20, 49, 49, -1000, -1000, 57, 14, 12, -6, -30,
-	-1000, -1000, -1000, 175, -1000, -16, -1000, 229, -1000, 147,
-	81, -1000, -43, 70, -1000, 12, 70, -1000, -1000, 105,
-	57, -13, -1000, 180, 173, 285, -20, 144, -1000, -56,
-	-1000, -10, -37, 69, 43, -44, -39, -45, 164, 12,
-	103, 253, -46, -1000, -1000, 143, -1000, -1000, -1000, 12,
-	-1000, -1000, -1000, 169, 172, 43, 64, -1000, 12, -1000,
-	-1000, 43, -1000, 103, 103, 43, 40, 165, -1000, 103,
-	-1000, -1000, -1000, 165, -1000,
+	509, -1000, -1000, 77, 75, -1000, 510, 434, 8, 1401,
+	1401, -1000, -1000, 543, 580, 584, 564, 560, 499, 497,
+	431, 1401, 496, -1000, 509, -1000, -1000, 404, 611, -1000,
+	127, -1000, 691, -1000, 93, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, 236, -1000, 326, 201, 283, 283, 544,
+	200, 536, 284, 284, 1401, 530, 1401, 1401, 1401, 485,
+	1401, -1000, 506, 6, 409, -1000, 126, -1000, -17, 202,
+	273, -1000, 691, 691, 72, 69, -1000, -1000, 691, -1000,
+	68, -1000, -1000, -1000, -1000, -1000, -1000, -1000, 90, 195,
+	-1000, 8, 1, 124, -12, 28, 1401, -1000, 1401, 67,
+	-1000, 1401, 308, 547, 283, -1000, 421, 428, 1401, 281,
+	546, 344, 1401, 1401, 62, 55, 396, 1206, 202, -1000,
+	-1000, 404, 1141, 931, -1000, 691, 691, 691, 691, 691,
+	691, -1000, 1401, -1000, 301, 297, -1000, 30, 98, 467,
+	691, -32, 1011, 1401, -1000, -1000, -1000, 691, 691, -1000,
+	-1000, 467, 52, 280, 1401, 545, -1000, 427, 410, 161,
+	-1000, -1000, 1401, 521, 217, 517, 267, 87, 1401, 1401,
+	571, 851, 168, -1000, -1000, 207, 1401, 469, -1000, 571,
+	421, 467, -1000, 98, 98, -1000, -1000, 30, 111, -1000,
+	691, 50, 152, -25, -27, -1000, -1000, -40, 1466, 83,
+	-12, -41, -42, 1336, -1000, 49, 1401, 156, 322, -1000,
+	39, 1401, 155, 1401, 158, 1401, -43, 123, -1000, 14,
+	357, 532, 407, -12, 571, 771, 1206, 691, 5, 1141,
+	172, 202, -44, 51, 531, -1000, -1000, -1000, 258, -1000,
+	-46, 1401, -1000, -1000, 122, 1401, -1000, 169, 1401, 37,
+	-1000, 406, 1401, -1000, -1000, 295, -1000, -1000, -1000, 263,
+	494, 1401, 492, -1000, 146, 539, 288, 357, 405, -1000,
+	-1000, -12, 204, 533, 391, -1000, 172, 401, -1000, -1000,
+	202, 135, -57, 0, 1401, 32, -1000, -1000, 1271, 272,
+	-63, -1, 1401, 423, -2, 242, 166, 158, 8, -1000,
+	8, -1000, 1076, -1000, 28, -1000, 288, 23, 691, 385,
+	691, -1000, 1141, -1000, -1000, -1000, -1000, 256, 511, -1000,
+	-58, 270, 243, 143, 442, -4, 138, -1000, -1000, -63,
+	-1000, 205, 181, -1000, -1000, 1401, -1000, 531, 47, 398,
+	379, 571, 331, 376, 1076, -1000, -1000, 260, 310, -1000,
+	227, -71, -1000, 433, 442, -1000, -1000, 445, 455, -1000,
+	194, -5, -6, -59, -1000, 466, -1000, 328, 299, 691,
+	1531, 529, 375, 1466, -60, 232, -1000, 251, 20, -1000,
+	-1000, -1000, -1000, -1000, 18, 121, 79, -1000, -1000, -1000,
+	-1000, 296, 460, 456, 380, 367, -12, 120, 16, -1000,
+	691, 1466, 120, -1000, -1000, 691, -1000, 691, 449, 1401,
+	185, 105, 487, 458, -1000, 370, 389, 170, 365, 225,
+	1466, 1466, 1466, -12, -7, 315, -12, -47, 454, -20,
+	53, -1000, 481, 504, -1000, -1000, -1000, -1000, -1000, 190,
+	-1000, -1000, 356, 356, 119, -1000, -48, -1000, 1466, -1000,
+	-1000, -1000, 211, -1000, 472, -1000, 103, 1401, 12, 356,
+	356, -1000, -1000, -1000, -1000, -1000, -1000, 315, 260, 1401,
+	-1000, 110, -1000, 1401, 342, 337, -1000, -1000, 110, 1401,
+	-55, -1000, -1000, -1000, 491, 8, -1000,
 }
 
 var yyPgo = [...]int{
-	0, 317, 251, 130, 316, 139, 315, 314, 5, 313,
-	12, 8, 7, 312, 311, 11, 4, 9, 310, 309,
-	127, 308, 307, 1, 306, 10, 242, 305, 18, 304,
-	13, 303, 302, 3, 14, 301, 300, 299, 298, 2,
-	297, 15, 296, 295, 0, 6, 196, 294, 293, 292,
-	16, 291, 210,
+	0, 678, 527, 45, 677, 38, 676, 675, 26, 667,
+	28, 3, 17, 666, 12, 27, 665, 44, 1, 23,
+	35, 24, 664, 40, 663, 662, 657, 19, 656, 25,
+	310, 655, 34, 654, 30, 653, 640, 146, 33, 639,
+	638, 637, 635, 634, 633, 32, 22, 627, 20, 14,
+	9, 31, 10, 0, 29, 13, 625, 8, 18, 41,
+	331, 624, 623, 4, 36, 21, 2, 5, 613, 37,
+	608, 604, 602, 15, 600, 598, 16, 313, 596, 583,
+	6, 7,
 }
 
 var yyR1 = [...]int{
-	0, 1, 2, 2, 2, 52, 52, 4, 4, 5,
+	0, 1, 2, 2, 2, 77, 77, 4, 4, 5,
 	5, 3, 3, 6, 6, 6, 6, 6, 6, 6,
-	27, 27, 46, 46, 12, 12, 7, 7, 7, 7,
-	51, 51, 50, 13, 13, 15, 15, 16, 11, 11,
-	14, 14, 18, 18, 17, 17, 19, 19, 19, 19,
-	19, 19, 19, 19, 9, 9, 10, 40, 40, 47,
-	47, 48, 48, 48, 8, 24, 24, 21, 21, 22,
-	22, 20, 20, 20, 23, 23, 23, 25, 25, 26,
-	26, 28, 28, 29, 29, 30, 30, 31, 32, 32,
-	34, 34, 38, 38, 35, 35, 39, 39, 43, 43,
-	45, 45, 42, 42, 44, 44, 44, 41, 41, 41,
-	33, 33, 33, 33, 33, 33, 33, 33, 36, 36,
-	36, 49, 49, 37, 37, 37, 37, 37, 37,
+	6, 6, 6, 6, 6, 6, 6, 6, 54, 54,
+	31, 31, 59, 59, 60, 60, 65, 65, 61, 61,
+	12, 12, 7, 7, 7, 7, 7, 7, 7, 75,
+	75, 75, 68, 76, 67, 67, 66, 70, 70, 70,
+	70, 69, 69, 13, 13, 15, 15, 18, 11, 11,
+	14, 14, 20, 20, 19, 19, 21, 21, 21, 21,
+	21, 21, 21, 21, 9, 9, 10, 10, 78, 78,
+	46, 46, 62, 62, 40, 40, 63, 63, 63, 8,
+	8, 8, 8, 16, 16, 17, 28, 28, 25, 25,
+	26, 26, 23, 23, 24, 44, 44, 22, 22, 22,
+	22, 27, 27, 27, 29, 29, 30, 30, 32, 32,
+	32, 33, 33, 34, 34, 35, 36, 36, 38, 38,
+	43, 43, 43, 39, 39, 45, 45, 47, 47, 47,
+	47, 47, 48, 48, 48, 48, 48, 49, 49, 50,
+	50, 79, 79, 80, 80, 81, 81, 56, 56, 71,
+	71, 71, 73, 73, 74, 74, 72, 72, 58, 58,
+	55, 55, 57, 57, 57, 51, 51, 51, 37, 37,
+	37, 37, 37, 37, 37, 37, 37, 37, 37, 41,
+	41, 41, 64, 64, 42, 42, 42, 42, 42, 42,
+	52, 52, 52, 52, 52, 52, 52, 52, 52, 52,
+	52, 52, 52, 52, 52, 52, 52, 52, 52, 52,
+	52, 52, 52, 52, 52, 52, 52, 52, 52, 52,
+	52, 52, 52, 52, 52, 52, 53, 53,
 }
 
 var yyR2 = [...]int{
 	0, 1, 2, 2, 3, 0, 1, 1, 4, 1,
-	1, 2, 3, 3, 3, 4, 11, 8, 9, 6,
-	0, 3, 0, 3, 1, 3, 8, 8, 6, 7,
-	1, 3, 3, 0, 1, 1, 3, 3, 1, 3,
+	1, 2, 3, 3, 3, 4, 11, 9, 4, 9,
+	10, 8, 6, 6, 8, 9, 6, 8, 1, 1,
+	0, 3, 0, 3, 0, 2, 0, 2, 0, 2,
+	1, 3, 10, 9, 6, 7, 7, 8, 9, 1,
+	1, 2, 6, 10, 1, 3, 3, 1, 1, 3,
+	3, 7, 7, 0, 1, 1, 3, 3, 1, 3,
 	1, 3, 0, 1, 1, 3, 1, 1, 1, 1,
-	3, 2, 1, 1, 1, 3, 5, 0, 3, 0,
-	1, 0, 1, 2, 12, 0, 1, 1, 1, 2,
-	4, 1, 3, 4, 1, 3, 5, 3, 4, 1,
-	3, 0, 3, 0, 1, 1, 2, 6, 0, 1,
-	0, 2, 0, 3, 0, 2, 0, 2, 0, 3,
-	0, 4, 2, 4, 0, 1, 1, 0, 1, 2,
-	1, 1, 2, 2, 4, 4, 6, 6, 1, 1,
-	3, 0, 1, 3, 3, 3, 3, 3, 3,
+	4, 1, 1, 1, 1, 3, 6, 10, 0, 2,
+	0, 3, 0, 1, 0, 2, 0, 1, 2, 12,
+	2, 2, 3, 1, 3, 5, 0, 1, 1, 1,
+	1, 3, 2, 2, 11, 0, 3, 1, 3, 4,
+	5, 1, 3, 5, 3, 4, 1, 3, 0, 3,
+	6, 0, 1, 1, 2, 6, 0, 1, 0, 2,
+	0, 3, 6, 0, 2, 0, 2, 0, 1, 1,
+	2, 2, 2, 2, 2, 5, 5, 3, 3, 2,
+	1, 1, 1, 1, 1, 0, 1, 0, 3, 0,
+	5, 7, 0, 2, 3, 5, 0, 3, 0, 4,
+	2, 4, 0, 1, 1, 0, 1, 2, 1, 1,
+	2, 2, 4, 6, 4, 6, 6, 4, 4, 1,
+	1, 3, 0, 1, 3, 3, 3, 3, 3, 3,
+	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+	1, 1, 1, 1, 1, 1, 1, 1,
 }
 
 var yyChk = [...]int{
-	-1000, -1, -2, -4, -8, -5, 20, 30, -6, -7,
-	4, 5, 15, 23, 24, 27, 28, -52, 71, -52,
-	71, 21, -24, 31, 6, 11, 13, 12, 6, 7,
-	11, 25, 25, 32, -26, 57, -2, -3, -5, -21,
-	68, -22, -20, -23, 63, 57, 57, -46, 47, -46,
-	13, 57, -27, 8, 57, -26, -26, -26, 29, 70,
-	22, -52, 71, 32, 65, -41, 57, 44, 72, 70,
-	57, 45, 14, -46, -28, 33, 34, 16, 72, 72,
-	-34, 37, -51, -50, 57, 57, -3, -25, -26, 72,
-	-20, 57, 73, -23, 57, 72, 48, 57, 14, 34,
-	59, 17, -13, -11, 57, -11, -45, 5, -33, -36,
-	-37, 45, 67, 48, -20, -19, 72, 59, 60, 61,
-	62, 57, 52, 53, 51, -34, 65, 56, -45, -28,
-	-8, -41, 73, 70, -9, -10, 57, 72, 57, 59,
-	-10, 73, 65, 73, -39, 40, 13, 66, 67, 69,
-	68, 55, 56, -49, 45, -33, -33, 72, -33, 72,
-	57, -45, -50, -33, -29, -30, -31, -32, 54, -41,
-	73, 57, 65, 58, -11, 72, 26, 57, 26, 59,
-	14, -33, -33, -33, -33, -33, -33, 46, 49, -8,
-	73, 73, -39, -34, -30, 35, -41, 18, -10, -40,
-	74, 73, -11, -15, -16, 72, -15, -12, 57, 72,
-	-33, 72, 73, -38, 38, -25, 19, -47, 50, 59,
-	73, 65, -18, -17, -33, -11, -8, -17, -35, 36,
-	39, -45, -12, -48, 51, 45, 75, -16, 73, 65,
-	73, 73, 73, -43, 41, -33, -14, -23, 14, 73,
-	51, -33, -39, 39, 65, -33, -42, -23, -23, 65,
-	-44, 42, 43, -23, -44,
+	-1000, -1, -2, -4, -8, -5, 21, 42, 27, 11,
+	41, -6, -7, 4, 5, 17, 15, 76, 24, 25,
+	28, 29, 31, -77, 109, -77, 109, 22, -28, 43,
+	-15, -18, 110, -30, -53, -52, 85, 95, 57, 58,
+	59, 60, 61, 62, 63, 74, 75, 70, 41, 76,
+	31, 32, 33, 34, 35, 47, 48, 78, 79, 36,
+	37, 38, 86, 87, 88, 80, 39, 40, 50, 49,
+	81, 89, 90, -16, -17, -53, 6, 11, 13, 12,
+	6, 7, 11, 13, 11, 14, 26, 26, 44, -30,
+	26, -2, -3, -5, -25, 106, -26, -23, -37, -24,
+	-41, -42, 68, 105, 72, 95, -22, -21, 110, -27,
+	101, 97, 98, 99, 100, -50, 83, 85, -52, 84,
+	91, 103, -20, -19, -37, 95, 108, -8, 103, 67,
+	95, -59, 71, -59, 13, 95, -31, 8, -60, 71,
+	-60, -53, 11, 18, -30, -30, -30, 30, -30, 23,
+	-77, 109, 44, 103, -51, 104, 105, 107, 106, 93,
+	94, 95, 67, -51, -64, 77, 68, -37, -37, 110,
+	110, -37, 110, 108, 95, -18, 111, 103, 110, -53,
+	-17, 110, -53, 68, 14, -59, -32, 45, 47, 46,
+	-53, 72, 14, 16, 82, 15, -53, -53, 110, 110,
+	-38, 53, -70, -66, -69, -53, 110, -51, -3, -29,
+	-30, 110, -23, -37, -37, -37, -37, -37, -37, -53,
+	69, 73, -64, -8, -20, 111, 111, -27, 43, -53,
+	-37, -20, -8, 110, 72, -53, 14, 46, 48, 97,
+	-53, 18, 94, 18, 77, 108, -13, -11, -53, -11,
+	-58, 5, 50, -37, -38, 53, 103, 94, -11, 32,
+	-58, -32, -8, -37, 110, 99, 80, 111, 111, 111,
+	-27, 108, 111, 111, -9, 69, -10, -53, 110, -53,
+	97, 67, 110, -10, 97, -53, -54, 98, 83, -53,
+	111, 103, 111, -45, 56, 13, 49, -58, 50, -66,
+	-69, -37, 111, -29, -33, -34, -35, -36, 92, -51,
+	111, 70, -8, -19, 41, 78, 111, -53, 103, -53,
+	96, -11, 110, 49, -11, 17, 89, 77, 27, -53,
+	27, 97, 14, -21, 95, -45, 49, 94, 14, -38,
+	53, -34, 51, -51, 98, 111, 111, 110, 19, -10,
+	-61, 74, -46, 112, 111, -11, 46, 111, 85, 96,
+	-54, -15, -15, -12, -53, 110, -21, 110, -37, -43,
+	54, -29, -44, 79, 20, 111, 75, -62, -78, 82,
+	86, 97, -65, 40, 111, 97, -46, -71, 14, -73,
+	39, -11, -19, -8, -75, -68, -76, 33, -39, 52,
+	55, -58, 64, 55, -12, -63, 83, 68, 67, 87,
+	113, 43, -65, -73, 36, -74, 95, 111, 111, 111,
+	-76, 33, 34, 68, -56, 64, -37, -14, 81, -27,
+	14, 55, -14, 111, -40, 85, 83, 110, -72, 110,
+	103, 108, 35, 34, -47, -48, -49, 56, 58, 57,
+	55, 103, 110, -37, -55, -27, -37, -37, 37, -11,
+	95, 106, 29, 35, -49, -48, 97, -50, 90, -79,
+	59, 60, 97, -50, -55, -27, -14, 111, 103, -57,
+	65, 66, 111, 38, 29, 111, 108, 30, 24, 97,
+	-50, -81, -80, 61, 62, -81, 111, -27, 88, 30,
+	106, -67, -66, 110, -80, -80, -57, -63, -67, 103,
+	-11, 63, 63, -66, 111, 27, -18,
 }
 
 var yyDef = [...]int{
-	0, -2, 1, 5, 5, 7, 0, 65, 9, 10,
-	0, 0, 0, 0, 0, 0, 0, 2, 6, 3,
-	6, 0, 0, 66, 0, 22, 22, 0, 0, 20,
-	0, 0, 0, 0, 0, 79, 4, 0, 5, 0,
-	67, 68, 107, 71, 0, 74, 13, 0, 0, 0,
-	22, 14, 81, 0, 0, 0, 0, 90, 0, 0,
-	8, 11, 6, 0, 0, 69, 108, 0, 0, 0,
-	0, 0, 0, 0, 15, 0, 0, 0, 33, 0,
-	100, 0, 90, 30, 0, 80, 12, 100, 81, 0,
-	107, 109, 72, 0, 75, 0, 23, 0, 0, 0,
-	21, 0, 0, 34, 38, 0, 96, 0, 91, -2,
-	111, 0, 0, 0, 118, 119, 0, 46, 47, 48,
-	49, 74, 0, 52, 53, 100, 0, 0, -2, 107,
-	0, 70, 73, 0, 0, 54, 0, 0, 0, 82,
-	19, 0, 0, 0, 28, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 122, 112, 113, 0, 0, 0,
-	51, 96, 31, 32, 90, 84, -2, 0, 89, 77,
-	107, 76, 0, 57, 0, 0, 0, 39, 0, 97,
-	0, 123, 124, 125, 126, 127, 128, 0, 0, 0,
-	120, 50, 29, 92, 86, 0, 78, 0, 55, 59,
-	0, 17, 0, 26, 35, 42, 27, 101, 24, 0,
-	114, 0, 115, 94, 0, 100, 0, 61, 60, 0,
-	18, 0, 0, 43, 44, 0, 0, 0, 98, 0,
-	0, 0, 0, 56, 62, 0, 58, 36, 37, 0,
-	25, 116, 117, 96, 0, 95, 93, 40, 0, 16,
-	63, 45, 64, 0, 0, 87, 99, 104, 41, 0,
-	102, 105, 106, 104, 103,
+	0, -2, 1, 5, 5, 7, 0, 106, 0, 0,
+	0, 9, 10, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 2, 6, 3, 6, 0, 0, 107,
+	100, 65, 72, 101, 126, 246, 247, 210, 211, 212,
+	213, 214, 215, 216, 217, 218, 219, 220, 221, 222,
+	223, 224, 225, 226, 227, 228, 229, 230, 231, 232,
+	233, 234, 235, 236, 237, 238, 239, 240, 241, 242,
+	243, 244, 245, 0, 103, 0, 0, 32, 32, 0,
+	0, 30, 34, 34, 0, 0, 0, 0, 0, 0,
+	0, 4, 0, 5, 0, 108, 109, 110, 185, 185,
+	-2, 189, 0, 0, 0, 210, 199, 200, 0, 117,
+	0, 76, 77, 78, 79, 81, 82, 83, 121, 0,
+	160, 0, 0, 73, 74, 210, 0, 102, 0, 0,
+	13, 0, 0, 0, 32, 14, 128, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 138, 0, 185, 8,
+	11, 6, 0, 0, 112, 0, 0, 0, 0, 0,
+	0, 186, 0, 113, 0, 202, 203, 190, 191, 0,
+	72, 0, 0, 0, 159, 66, 67, 0, 72, 127,
+	104, 0, 0, 0, 0, 0, 15, 0, 0, 0,
+	18, 35, 0, 0, 0, 0, 0, 0, 63, 0,
+	178, 0, 138, 57, 58, 0, 0, 0, 12, 178,
+	128, 0, 111, 204, 205, 206, 207, 208, 209, 187,
+	0, 0, 0, 0, 0, 201, 118, 0, 0, 122,
+	75, 0, 0, 0, 33, 0, 0, 0, 0, 31,
+	0, 0, 0, 0, 0, 0, 0, 64, 68, 0,
+	145, 0, 241, 139, 178, 0, 0, 0, 0, 0,
+	-2, 185, 0, 192, 0, 197, 198, 194, 80, 119,
+	0, 0, 80, 105, 0, 0, 84, 0, 0, 0,
+	129, 0, 0, 22, 23, 0, 26, 28, 29, 0,
+	0, 0, 0, 44, 0, 0, 0, 145, 241, 59,
+	60, 56, 0, 0, 138, 132, -2, 0, 137, 124,
+	185, 0, 0, 0, 221, 0, 120, 123, 0, 38,
+	90, 0, 0, 0, 0, 0, 0, 0, 0, 69,
+	0, 146, 0, 46, 0, 45, 0, 0, 0, 140,
+	0, 134, 0, 125, 193, 195, 196, 115, 0, 85,
+	0, 0, -2, 0, 36, 0, 0, 21, 24, 90,
+	27, 169, 172, 179, 40, 0, 47, 0, 0, 143,
+	0, 178, 0, 0, 0, 17, 39, 96, 0, 93,
+	0, 0, 19, 0, 36, 130, 25, 172, 0, 43,
+	0, 0, 0, 0, 48, 49, 50, 0, 167, 0,
+	0, 0, 0, 0, 0, 94, 97, 0, 0, 89,
+	91, 37, 20, 42, 176, 173, 0, 41, 61, 62,
+	51, 0, 0, 0, 147, 0, 144, 141, 243, 70,
+	0, 0, 116, 16, 86, 0, 98, 0, 0, 0,
+	0, 0, 0, 0, 99, 148, 149, 0, 0, 0,
+	0, 0, 0, 135, 0, 182, 95, 0, 0, 0,
+	0, 174, 0, 0, 150, 151, 152, 153, 154, 0,
+	161, 162, 165, 165, 168, 71, 0, 114, 0, 180,
+	183, 184, 0, 170, 0, 177, 0, 0, 0, 0,
+	0, 157, 166, 163, 164, 158, 142, 182, 96, 0,
+	175, 52, 54, 0, 0, 0, 181, 87, 171, 0,
+	0, 155, 156, 55, 0, 0, 53,
 }
 
 var yyTok1 = [...]int{
@@ -387,12 +708,12 @@ var yyTok1 = [...]int{
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	72, 73, 68, 66, 65, 67, 70, 69, 3, 3,
+	110, 111, 106, 104, 103, 105, 108, 107, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 74, 3, 75,
+	3, 112, 3, 113,
 }
 
 var yyTok2 = [...]int{
@@ -402,7 +723,11 @@ var yyTok2 = [...]int{
 	32, 33, 34, 35, 36, 37, 38, 39, 40, 41,
 	42, 43, 44, 45, 46, 47, 48, 49, 50, 51,
 	52, 53, 54, 55, 56, 57, 58, 59, 60, 61,
-	62, 63, 64, 71,
+	62, 63, 64, 65, 66, 67, 68, 69, 70, 71,
+	72, 73, 74, 75, 76, 77, 78, 79, 80, 81,
+	82, 83, 84, 85, 86, 87, 88, 89, 90, 91,
+	92, 93, 94, 95, 96, 97, 98, 99, 100, 101,
+	102, 109,
 }
 
 var yyTok3 = [...]int{
@@ -810,577 +1135,971 @@ yydefault:
 			yyVAL.stmt = &CreateTableStmt{ifNotExists: yyDollar[3].boolean, table: yyDollar[4].id, colsSpec: yyDollar[6].colsSpec, pkColNames: yyDollar[10].ids}
 		}
 	case 17:
-		yyDollar = yyS[yypt-8 : yypt+1]
+		yyDollar = yyS[yypt-9 : yypt+1]
 		{
-			yyVAL.stmt = &CreateIndexStmt{ifNotExists: yyDollar[3].boolean, table: yyDollar[5].id, cols: yyDollar[7].ids}
+			yyVAL.stmt = &CreateTableLikeStmt{ifNotExists: yyDollar[3].boolean, table: yyDollar[4].id, sourceTable: yyDollar[7].id, includingIndexes: yyDollar[8].boolean}
 		}
 	case 18:
-		yyDollar = yyS[yypt-9 : yypt+1]
+		yyDollar = yyS[yypt-4 : yypt+1]
 		{
-			yyVAL.stmt = &CreateIndexStmt{unique: true, ifNotExists: yyDollar[4].boolean, table: yyDollar[6].id, cols: yyDollar[8].ids}
+			yyVAL.stmt = &DropTableStmt{ifExists: yyDollar[3].boolean, table: yyDollar[4].id}
 		}
 	case 19:
+		yyDollar = yyS[yypt-9 : yypt+1]
+		{
+			yyVAL.stmt = &CreateIndexStmt{ifNotExists: yyDollar[3].boolean, table: yyDollar[5].id, cols: yyDollar[7].ids, nullable: yyDollar[9].boolean}
+		}
+	case 20:
+		yyDollar = yyS[yypt-10 : yypt+1]
+		{
+			yyVAL.stmt = &CreateIndexStmt{unique: true, ifNotExists: yyDollar[4].boolean, table: yyDollar[6].id, cols: yyDollar[8].ids, nullable: yyDollar[10].boolean}
+		}
+	case 21:
+		yyDollar = yyS[yypt-8 : yypt+1]
+		{
+			yyVAL.stmt = &DropIndexStmt{ifExists: yyDollar[3].boolean, table: yyDollar[5].id, cols: yyDollar[7].ids}
+		}
+	case 22:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.stmt = &AddColumnStmt{table: yyDollar[3].id, colSpec: yyDollar[6].colSpec}
 		}
-	case 20:
+	case 23:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.stmt = &AlterAutoIncrementStmt{table: yyDollar[3].id, op: yyDollar[5].cmpOp, nextValue: yyDollar[6].number}
+		}
+	case 24:
+		yyDollar = yyS[yypt-8 : yypt+1]
+		{
+			yyVAL.stmt = &DropDefaultStmt{table: yyDollar[3].id, col: yyDollar[6].id}
+		}
+	case 25:
+		yyDollar = yyS[yypt-9 : yypt+1]
+		{
+			yyVAL.stmt = &AlterColumnTypeStmt{table: yyDollar[3].id, col: yyDollar[6].id, colType: yyDollar[8].sqlType, maxLen: int(yyDollar[9].number)}
+		}
+	case 26:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.stmt = &CommentStmt{table: yyDollar[4].id, comment: yyDollar[6].str}
+		}
+	case 27:
+		yyDollar = yyS[yypt-8 : yypt+1]
+		{
+			yyVAL.stmt = &CommentStmt{table: yyDollar[4].id, col: yyDollar[6].id, comment: yyDollar[8].str}
+		}
+	case 28:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 29:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.str = ""
+		}
+	case 30:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.number = 0
 		}
-	case 21:
+	case 31:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.number = yyDollar[3].number
 		}
-	case 22:
+	case 32:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.boolean = false
 		}
-	case 23:
+	case 33:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.boolean = true
 		}
-	case 24:
+	case 34:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.boolean = false
+		}
+	case 35:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.boolean = true
+		}
+	case 36:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.boolean = false
+		}
+	case 37:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.boolean = true
+		}
+	case 38:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.boolean = false
+		}
+	case 39:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.boolean = true
+		}
+	case 40:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.ids = []string{yyDollar[1].id}
 		}
-	case 25:
+	case 41:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.ids = yyDollar[2].ids
 		}
-	case 26:
-		yyDollar = yyS[yypt-8 : yypt+1]
+	case 42:
+		yyDollar = yyS[yypt-10 : yypt+1]
 		{
-			yyVAL.stmt = &UpsertIntoStmt{isInsert: true, tableRef: yyDollar[3].tableRef, cols: yyDollar[5].ids, rows: yyDollar[8].rows}
+			yyVAL.stmt = &UpsertIntoStmt{isInsert: true, tableRef: yyDollar[3].tableRef, cols: yyDollar[5].ids, rows: yyDollar[8].rows, onConflict: yyDollar[9].onConflict, returning: yyDollar[10].ids}
 		}
-	case 27:
-		yyDollar = yyS[yypt-8 : yypt+1]
+	case 43:
+		yyDollar = yyS[yypt-9 : yypt+1]
 		{
-			yyVAL.stmt = &UpsertIntoStmt{tableRef: yyDollar[3].tableRef, cols: yyDollar[5].ids, rows: yyDollar[8].rows}
+			yyVAL.stmt = &UpsertIntoStmt{tableRef: yyDollar[3].tableRef, cols: yyDollar[5].ids, rows: yyDollar[8].rows, returning: yyDollar[9].ids}
 		}
-	case 28:
+	case 44:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.stmt = &DeleteFromStmt{tableRef: yyDollar[3].tableRef, where: yyDollar[4].exp, indexOn: yyDollar[5].ids, limit: int(yyDollar[6].number)}
 		}
-	case 29:
+	case 45:
 		yyDollar = yyS[yypt-7 : yypt+1]
 		{
-			yyVAL.stmt = &UpdateStmt{tableRef: yyDollar[2].tableRef, updates: yyDollar[4].updates, where: yyDollar[5].exp, indexOn: yyDollar[6].ids, limit: int(yyDollar[7].number)}
+			yyDollar[4].updateStmt.tableRef = yyDollar[2].tableRef
+			yyDollar[4].updateStmt.where = yyDollar[5].exp
+			yyDollar[4].updateStmt.indexOn = yyDollar[6].ids
+			yyDollar[4].updateStmt.limit = int(yyDollar[7].number)
+			yyVAL.stmt = yyDollar[4].updateStmt
 		}
-	case 30:
+	case 46:
+		yyDollar = yyS[yypt-7 : yypt+1]
+		{
+			yyVAL.stmt = &DeleteFromStmt{tableRef: yyDollar[3].tableRef, currentOf: yyDollar[7].value}
+		}
+	case 47:
+		yyDollar = yyS[yypt-8 : yypt+1]
+		{
+			yyDollar[4].updateStmt.tableRef = yyDollar[2].tableRef
+			yyDollar[4].updateStmt.currentOf = yyDollar[8].value
+			yyVAL.stmt = yyDollar[4].updateStmt
+		}
+	case 48:
+		yyDollar = yyS[yypt-9 : yypt+1]
+		{
+			yyDollar[3].tableRef.as = yyDollar[4].id
+			yyDollar[9].merge.target = yyDollar[3].tableRef
+			yyDollar[9].merge.source = yyDollar[6].ds
+			yyDollar[9].merge.on = yyDollar[8].exp
+			yyVAL.stmt = yyDollar[9].merge
+		}
+	case 49:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.merge = &MergeStmt{updates: yyDollar[1].updates}
+		}
+	case 50:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.merge = yyDollar[1].merge
+		}
+	case 51:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyDollar[2].merge.updates = yyDollar[1].updates
+			yyVAL.merge = yyDollar[2].merge
+		}
+	case 52:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.updates = yyDollar[6].updates
+		}
+	case 53:
+		yyDollar = yyS[yypt-10 : yypt+1]
+		{
+			yyVAL.merge = &MergeStmt{insertCols: yyDollar[7].ids, insertValues: yyDollar[10].row.Values}
+		}
+	case 54:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.updates = []*colUpdate{yyDollar[1].update}
 		}
-	case 31:
+	case 55:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.updates = append(yyDollar[1].updates, yyDollar[3].update)
 		}
-	case 32:
+	case 56:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.update = &colUpdate{col: yyDollar[1].id, op: yyDollar[2].cmpOp, val: yyDollar[3].exp}
 		}
-	case 33:
+	case 57:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.updateStmt = &UpdateStmt{updates: []*colUpdate{yyDollar[1].update}}
+		}
+	case 58:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.updateStmt = &UpdateStmt{tupleUpdates: []*tupleUpdate{yyDollar[1].tupleUpdate}}
+		}
+	case 59:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyDollar[1].updateStmt.updates = append(yyDollar[1].updateStmt.updates, yyDollar[3].update)
+			yyVAL.updateStmt = yyDollar[1].updateStmt
+		}
+	case 60:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyDollar[1].updateStmt.tupleUpdates = append(yyDollar[1].updateStmt.tupleUpdates, yyDollar[3].tupleUpdate)
+			yyVAL.updateStmt = yyDollar[1].updateStmt
+		}
+	case 61:
+		yyDollar = yyS[yypt-7 : yypt+1]
+		{
+			yyVAL.tupleUpdate = &tupleUpdate{cols: yyDollar[2].ids, op: yyDollar[4].cmpOp, vals: yyDollar[6].values}
+		}
+	case 62:
+		yyDollar = yyS[yypt-7 : yypt+1]
+		{
+			yyVAL.tupleUpdate = &tupleUpdate{cols: yyDollar[2].ids, op: yyDollar[4].cmpOp, q: yyDollar[6].stmt.(*SelectStmt)}
+		}
+	case 63:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.ids = nil
 		}
-	case 34:
+	case 64:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.ids = yyDollar[1].ids
 		}
-	case 35:
+	case 65:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.rows = []*RowSpec{yyDollar[1].row}
 		}
-	case 36:
+	case 66:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.rows = append(yyDollar[1].rows, yyDollar[3].row)
 		}
-	case 37:
+	case 67:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.row = &RowSpec{Values: yyDollar[2].values}
 		}
-	case 38:
+	case 68:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.ids = []string{yyDollar[1].id}
 		}
-	case 39:
+	case 69:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.ids = append(yyDollar[1].ids, yyDollar[3].id)
 		}
-	case 40:
+	case 70:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.cols = []*ColSelector{yyDollar[1].col}
 		}
-	case 41:
+	case 71:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.cols = append(yyDollar[1].cols, yyDollar[3].col)
 		}
-	case 42:
+	case 72:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.values = nil
 		}
-	case 43:
+	case 73:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.values = yyDollar[1].values
 		}
-	case 44:
+	case 74:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.values = []ValueExp{yyDollar[1].exp}
 		}
-	case 45:
+	case 75:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.values = append(yyDollar[1].values, yyDollar[3].exp)
 		}
-	case 46:
+	case 76:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.value = &Number{val: int64(yyDollar[1].number)}
 		}
-	case 47:
+	case 77:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.value = &Varchar{val: yyDollar[1].str}
 		}
-	case 48:
+	case 78:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.value = &Bool{val: yyDollar[1].boolean}
 		}
-	case 49:
+	case 79:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.value = &Blob{val: yyDollar[1].blob}
 		}
-	case 50:
-		yyDollar = yyS[yypt-3 : yypt+1]
+	case 80:
+		yyDollar = yyS[yypt-4 : yypt+1]
 		{
-			yyVAL.value = &SysFn{fn: yyDollar[1].id}
+			yyVAL.value = &SysFn{fn: yyDollar[1].id, params: yyDollar[3].values}
 		}
-	case 51:
-		yyDollar = yyS[yypt-2 : yypt+1]
+	case 81:
+		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.value = &Param{id: yyDollar[2].id}
+			yyVAL.value = yyDollar[1].param
 		}
-	case 52:
+	case 82:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.value = &Param{id: fmt.Sprintf("param%d", yyDollar[1].pparam), pos: yyDollar[1].pparam}
+			yyVAL.value = &NullValue{t: AnyType}
 		}
-	case 53:
+	case 83:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.value = &NullValue{t: AnyType}
+			yyVAL.value = &DefaultValue{}
 		}
-	case 54:
+	case 84:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.colsSpec = []*ColSpec{yyDollar[1].colSpec}
 		}
-	case 55:
+	case 85:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.colsSpec = append(yyDollar[1].colsSpec, yyDollar[3].colSpec)
 		}
-	case 56:
-		yyDollar = yyS[yypt-5 : yypt+1]
+	case 86:
+		yyDollar = yyS[yypt-6 : yypt+1]
 		{
-			yyVAL.colSpec = &ColSpec{colName: yyDollar[1].id, colType: yyDollar[2].sqlType, maxLen: int(yyDollar[3].number), autoIncrement: yyDollar[4].boolean, notNull: yyDollar[5].boolean}
+			yyVAL.colSpec = &ColSpec{colName: yyDollar[1].id, colType: yyDollar[2].sqlType, maxLen: int(yyDollar[3].number), autoIncrement: yyDollar[4].boolean, notNull: yyDollar[5].boolean, defaultValue: yyDollar[6].exp}
 		}
-	case 57:
+	case 87:
+		yyDollar = yyS[yypt-10 : yypt+1]
+		{
+			yyVAL.colSpec = &ColSpec{colName: yyDollar[1].id, colType: yyDollar[2].sqlType, maxLen: int(yyDollar[3].number), notNull: yyDollar[10].boolean, generatedAs: yyDollar[7].exp}
+		}
+	case 88:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+		}
+	case 89:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+		}
+	case 90:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.number = 0
 		}
-	case 58:
+	case 91:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.number = yyDollar[2].number
 		}
-	case 59:
+	case 92:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.boolean = false
 		}
-	case 60:
+	case 93:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.boolean = true
 		}
-	case 61:
+	case 94:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.exp = nil
+		}
+	case 95:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.exp = yyDollar[2].exp
+		}
+	case 96:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.boolean = false
 		}
-	case 62:
+	case 97:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.boolean = false
 		}
-	case 63:
+	case 98:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.boolean = true
 		}
-	case 64:
+	case 99:
 		yyDollar = yyS[yypt-12 : yypt+1]
 		{
 			yyVAL.stmt = &SelectStmt{
-				distinct:  yyDollar[2].distinct,
-				selectors: yyDollar[3].sels,
-				ds:        yyDollar[5].ds,
-				indexOn:   yyDollar[6].ids,
-				joins:     yyDollar[7].joins,
-				where:     yyDollar[8].exp,
-				groupBy:   yyDollar[9].cols,
-				having:    yyDollar[10].exp,
-				orderBy:   yyDollar[11].ordcols,
-				limit:     int(yyDollar[12].number),
+				distinct:    yyDollar[2].distinct,
+				selectors:   yyDollar[3].sels,
+				ds:          yyDollar[5].ds,
+				indexOn:     yyDollar[6].ids,
+				joins:       yyDollar[7].joins,
+				where:       yyDollar[8].exp,
+				groupBy:     yyDollar[9].grouping.cols,
+				rollup:      yyDollar[9].grouping.rollup,
+				having:      yyDollar[10].exp,
+				orderBy:     yyDollar[11].ordcols,
+				limit:       yyDollar[12].pagination.limit,
+				limitParam:  yyDollar[12].pagination.limitParam,
+				hasLimit:    yyDollar[12].pagination.hasLimit,
+				offset:      yyDollar[12].pagination.offset,
+				offsetParam: yyDollar[12].pagination.offsetParam,
 			}
 		}
-	case 65:
+	case 100:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.stmt = &SelectStmt{ds: &valuesDataSource{rows: yyDollar[2].rows}}
+		}
+	case 101:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.stmt = &SelectStmt{ds: yyDollar[2].tableRef}
+		}
+	case 102:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			stmt := yyDollar[3].stmt.(*SelectStmt)
+			stmt.ctes = append(yyDollar[2].ctes, stmt.ctes...)
+			yyVAL.stmt = stmt
+		}
+	case 103:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.ctes = []*commonTableExp{yyDollar[1].cte}
+		}
+	case 104:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.ctes = append(yyDollar[1].ctes, yyDollar[3].cte)
+		}
+	case 105:
+		yyDollar = yyS[yypt-5 : yypt+1]
+		{
+			yyVAL.cte = &commonTableExp{name: yyDollar[1].id, query: yyDollar[4].stmt.(*SelectStmt)}
+		}
+	case 106:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.distinct = false
 		}
-	case 66:
+	case 107:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.distinct = true
 		}
-	case 67:
+	case 108:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.sels = nil
 		}
-	case 68:
+	case 109:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.sels = yyDollar[1].sels
 		}
-	case 69:
-		yyDollar = yyS[yypt-2 : yypt+1]
+	case 110:
+		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyDollar[1].sel.setAlias(yyDollar[2].id)
 			yyVAL.sels = []Selector{yyDollar[1].sel}
 		}
-	case 70:
-		yyDollar = yyS[yypt-4 : yypt+1]
+	case 111:
+		yyDollar = yyS[yypt-3 : yypt+1]
 		{
-			yyDollar[3].sel.setAlias(yyDollar[4].id)
 			yyVAL.sels = append(yyDollar[1].sels, yyDollar[3].sel)
 		}
-	case 71:
+	case 112:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			sel := asSelector(yyDollar[1].exp)
+			sel.setAlias(yyDollar[2].id)
+			yyVAL.sel = sel
+		}
+	case 113:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyDollar[1].sel.setAlias(yyDollar[2].id)
+			yyVAL.sel = yyDollar[1].sel
+		}
+	case 114:
+		yyDollar = yyS[yypt-11 : yypt+1]
+		{
+			yyVAL.sel = &WindowFnSelector{fn: yyDollar[1].id, params: yyDollar[3].values, partitionBy: yyDollar[7].cols, orderBy: yyDollar[10].ordcols}
+		}
+	case 115:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.cols = nil
+		}
+	case 116:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.cols = yyDollar[3].cols
+		}
+	case 117:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.sel = yyDollar[1].col
 		}
-	case 72:
+	case 118:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.sel = &AggColSelector{aggFn: yyDollar[1].aggFn, col: "*"}
 		}
-	case 73:
+	case 119:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.sel = &AggColSelector{aggFn: yyDollar[1].aggFn, db: yyDollar[3].col.db, table: yyDollar[3].col.table, col: yyDollar[3].col.col}
 		}
-	case 74:
+	case 120:
+		yyDollar = yyS[yypt-5 : yypt+1]
+		{
+			yyVAL.sel = &AggColSelector{aggFn: yyDollar[1].aggFn, distinct: true, db: yyDollar[4].col.db, table: yyDollar[4].col.table, col: yyDollar[4].col.col}
+		}
+	case 121:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.col = &ColSelector{col: yyDollar[1].id}
 		}
-	case 75:
+	case 122:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.col = &ColSelector{table: yyDollar[1].id, col: yyDollar[3].id}
 		}
-	case 76:
+	case 123:
 		yyDollar = yyS[yypt-5 : yypt+1]
 		{
 			yyVAL.col = &ColSelector{db: yyDollar[1].id, table: yyDollar[3].id, col: yyDollar[5].id}
 		}
-	case 77:
+	case 124:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyDollar[1].tableRef.asBefore = yyDollar[2].number
 			yyDollar[1].tableRef.as = yyDollar[3].id
 			yyVAL.ds = yyDollar[1].tableRef
 		}
-	case 78:
+	case 125:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyDollar[2].stmt.(*SelectStmt).as = yyDollar[4].id
 			yyVAL.ds = yyDollar[2].stmt.(DataSource)
 		}
-	case 79:
+	case 126:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.tableRef = &tableRef{table: yyDollar[1].id}
 		}
-	case 80:
+	case 127:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.tableRef = &tableRef{db: yyDollar[1].id, table: yyDollar[3].id}
 		}
-	case 81:
+	case 128:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.number = 0
 		}
-	case 82:
+	case 129:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.number = yyDollar[3].number
 		}
-	case 83:
+	case 130:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.number = yyDollar[6].number + 1
+		}
+	case 131:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.joins = nil
 		}
-	case 84:
+	case 132:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.joins = yyDollar[1].joins
 		}
-	case 85:
+	case 133:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.joins = []*JoinSpec{yyDollar[1].join}
 		}
-	case 86:
+	case 134:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.joins = append([]*JoinSpec{yyDollar[1].join}, yyDollar[2].joins...)
 		}
-	case 87:
+	case 135:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.join = &JoinSpec{joinType: yyDollar[1].joinType, ds: yyDollar[3].ds, indexOn: yyDollar[4].ids, cond: yyDollar[6].exp}
 		}
-	case 88:
+	case 136:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.joinType = InnerJoin
 		}
-	case 89:
+	case 137:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.joinType = yyDollar[1].joinType
 		}
-	case 90:
+	case 138:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.exp = nil
 		}
-	case 91:
+	case 139:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[2].exp
 		}
-	case 92:
+	case 140:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
-			yyVAL.cols = nil
+			yyVAL.grouping = grouping{}
 		}
-	case 93:
+	case 141:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
-			yyVAL.cols = yyDollar[3].cols
+			yyVAL.grouping = grouping{cols: yyDollar[3].cols}
 		}
-	case 94:
+	case 142:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.grouping = grouping{cols: yyDollar[5].cols, rollup: true}
+		}
+	case 143:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.exp = nil
 		}
-	case 95:
+	case 144:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[2].exp
 		}
-	case 96:
+	case 145:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.number = 0
 		}
-	case 97:
+	case 146:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.number = yyDollar[2].number
 		}
-	case 98:
+	case 147:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.pagination = pagination{}
+		}
+	case 148:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.pagination = yyDollar[1].pagination
+		}
+	case 149:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.pagination = yyDollar[1].pagination
+		}
+	case 150:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.pagination = pagination{limit: yyDollar[1].pagination.limit, limitParam: yyDollar[1].pagination.limitParam, hasLimit: yyDollar[1].pagination.hasLimit, offset: yyDollar[2].pagination.offset, offsetParam: yyDollar[2].pagination.offsetParam}
+		}
+	case 151:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.pagination = pagination{limit: yyDollar[2].pagination.limit, limitParam: yyDollar[2].pagination.limitParam, hasLimit: yyDollar[2].pagination.hasLimit, offset: yyDollar[1].pagination.offset, offsetParam: yyDollar[1].pagination.offsetParam}
+		}
+	case 152:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.pagination = pagination{limit: int(yyDollar[2].number), hasLimit: true}
+		}
+	case 153:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.pagination = pagination{limitParam: yyDollar[2].param, hasLimit: true}
+		}
+	case 154:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.pagination = pagination{}
+		}
+	case 155:
+		yyDollar = yyS[yypt-5 : yypt+1]
+		{
+			yyVAL.pagination = pagination{limit: int(yyDollar[3].number), hasLimit: true}
+		}
+	case 156:
+		yyDollar = yyS[yypt-5 : yypt+1]
+		{
+			yyVAL.pagination = pagination{limitParam: yyDollar[3].param, hasLimit: true}
+		}
+	case 157:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.pagination = pagination{offset: int(yyDollar[2].number)}
+		}
+	case 158:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.pagination = pagination{offsetParam: yyDollar[2].param}
+		}
+	case 159:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.param = &Param{id: yyDollar[2].id}
+		}
+	case 160:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.param = &Param{id: fmt.Sprintf("param%d", yyDollar[1].pparam), pos: yyDollar[1].pparam}
+		}
+	case 167:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.ordcols = nil
 		}
-	case 99:
+	case 168:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.ordcols = yyDollar[3].ordcols
 		}
-	case 100:
+	case 169:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.onConflict = nil
+		}
+	case 170:
+		yyDollar = yyS[yypt-5 : yypt+1]
+		{
+			yyVAL.onConflict = &conflictClause{target: yyDollar[3].ids}
+		}
+	case 171:
+		yyDollar = yyS[yypt-7 : yypt+1]
+		{
+			yyVAL.onConflict = &conflictClause{target: yyDollar[3].ids, updates: yyDollar[7].updates}
+		}
+	case 172:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.ids = nil
 		}
-	case 101:
+	case 173:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.ids = yyDollar[2].ids
+		}
+	case 174:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.ids = []string{yyDollar[1].id}
+		}
+	case 175:
+		yyDollar = yyS[yypt-5 : yypt+1]
+		{
+			yyVAL.ids = append(yyDollar[1].ids, yyDollar[3].id)
+		}
+	case 176:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.ids = nil
+		}
+	case 177:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.ids = yyDollar[2].ids
+		}
+	case 178:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.ids = nil
+		}
+	case 179:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.ids = yyDollar[4].ids
 		}
-	case 102:
+	case 180:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.ordcols = []*OrdCol{{sel: yyDollar[1].col, descOrder: yyDollar[2].opt_ord}}
 		}
-	case 103:
+	case 181:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.ordcols = append(yyDollar[1].ordcols, &OrdCol{sel: yyDollar[3].col, descOrder: yyDollar[4].opt_ord})
 		}
-	case 104:
+	case 182:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.opt_ord = false
 		}
-	case 105:
+	case 183:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.opt_ord = false
 		}
-	case 106:
+	case 184:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.opt_ord = true
 		}
-	case 107:
+	case 185:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.id = ""
 		}
-	case 108:
+	case 186:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.id = yyDollar[1].id
 		}
-	case 109:
+	case 187:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.id = yyDollar[2].id
 		}
-	case 110:
+	case 188:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[1].exp
 		}
-	case 111:
+	case 189:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[1].binExp
 		}
-	case 112:
+	case 190:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.exp = &NotBoolExp{exp: yyDollar[2].exp}
 		}
-	case 113:
+	case 191:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.exp = &NumExp{left: &Number{val: 0}, op: SUBSOP, right: yyDollar[2].exp}
 		}
-	case 114:
+	case 192:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.exp = &LikeBoolExp{val: yyDollar[1].exp, notLike: yyDollar[2].boolean, pattern: yyDollar[4].exp}
 		}
-	case 115:
+	case 193:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.exp = &LikeBoolExp{val: yyDollar[1].exp, notLike: yyDollar[2].boolean, pattern: yyDollar[4].exp, withEscape: true, escape: yyDollar[6].str}
+		}
+	case 194:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.exp = &ExistsBoolExp{q: (yyDollar[3].stmt).(*SelectStmt)}
 		}
-	case 116:
+	case 195:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.exp = &InSubQueryExp{val: yyDollar[1].exp, notIn: yyDollar[2].boolean, q: yyDollar[5].stmt.(*SelectStmt)}
 		}
-	case 117:
+	case 196:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.exp = &InListExp{val: yyDollar[1].exp, notIn: yyDollar[2].boolean, values: yyDollar[5].values}
 		}
-	case 118:
+	case 197:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.exp = &IsBoolExp{exp: yyDollar[1].exp, not: yyDollar[3].boolean, val: yyDollar[4].boolean}
+		}
+	case 198:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.exp = &IsBoolExp{exp: yyDollar[1].exp, not: yyDollar[3].boolean, unknown: true}
+		}
+	case 199:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[1].sel
 		}
-	case 119:
+	case 200:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[1].value
 		}
-	case 120:
+	case 201:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[2].exp
 		}
-	case 121:
+	case 202:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.boolean = false
 		}
-	case 122:
+	case 203:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.boolean = true
 		}
-	case 123:
+	case 204:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: ADDOP, right: yyDollar[3].exp}
 		}
-	case 124:
+	case 205:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: SUBSOP, right: yyDollar[3].exp}
 		}
-	case 125:
+	case 206:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: DIVOP, right: yyDollar[3].exp}
 		}
-	case 126:
+	case 207:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: MULTOP, right: yyDollar[3].exp}
 		}
-	case 127:
+	case 208:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &BinBoolExp{left: yyDollar[1].exp, op: yyDollar[2].logicOp, right: yyDollar[3].exp}
 		}
-	case 128:
+	case 209:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &CmpBoolExp{left: yyDollar[1].exp, op: yyDollar[2].cmpOp, right: yyDollar[3].exp}
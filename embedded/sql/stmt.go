@@ -21,9 +21,15 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"math"
 	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/codenotary/immudb/embedded/store"
 )
@@ -32,19 +38,39 @@ const (
 	catalogDatabasePrefix = "CTL.DATABASE." // (key=CTL.DATABASE.{dbID}, value={dbNAME})
 	catalogTablePrefix    = "CTL.TABLE."    // (key=CTL.TABLE.{dbID}{tableID}, value={tableNAME})
 	catalogColumnPrefix   = "CTL.COLUMN."   // (key=CTL.COLUMN.{dbID}{tableID}{colID}{colTYPE}, value={(auto_incremental | nullable){maxLen}{colNAME}})
-	catalogIndexPrefix    = "CTL.INDEX."    // (key=CTL.INDEX.{dbID}{tableID}{indexID}, value={unique {colID1}(ASC|DESC)...{colIDN}(ASC|DESC)})
+	catalogIndexPrefix    = "CTL.INDEX."    // (key=CTL.INDEX.{dbID}{tableID}{indexID}, value={flags {colID1}(ASC|DESC)...{colIDN}(ASC|DESC)})
+	catalogDefaultPrefix  = "CTL.DEFAULT."  // (key=CTL.DEFAULT.{dbID}{tableID}{colID}, value={(value | function | generated){encVAL | fnNAME | expSQL}})
+	catalogSequencePrefix = "CTL.SEQUENCE." // (key=CTL.SEQUENCE.{dbID}{tableID}, value={nextAutoIncrementValue})
+	catalogCommentPrefix  = "CTL.COMMENT."  // (key=CTL.COMMENT.{dbID}{tableID}{colID}, value={comment}) colID is 0 for the comment of the table
+	catalogMissingPrefix  = "CTL.MISSING."  // (key=CTL.MISSING.{dbID}{tableID}{colID}, value={missingTx}{encVAL}) value of the rows written up to missingTx, before the column was added
+	catalogPrevTypePrefix = "CTL.PREVTYPE." // (key=CTL.PREVTYPE.{dbID}{tableID}{colID}{untilTx}, value={colTYPE}) type of the rows written up to untilTx, before the column was altered
+	catalogVersionKey     = "CTL.VERSION"   // (key=CTL.VERSION, value={}) written by every DDL transaction, thus its transaction is the catalog version
 	PIndexPrefix          = "P."            // (key=P.{dbID}{tableID}{0}({pkVal}{padding}{pkValLen})+, value={count (colID valLen val)+})
 	SIndexPrefix          = "S."            // (key=S.{dbID}{tableID}{indexID}({val}{padding}{valLen})+({pkVal}{padding}{pkValLen})+, value={})
 	UIndexPrefix          = "U."            // (key=U.{dbID}{tableID}{indexID}({val}{padding}{valLen})+, value={({pkVal}{padding}{pkValLen})+})
+	migrationPrefix       = "MIGRATION."    // (key=MIGRATION.{dbID}{migrationID}, value={}) written along with the statements of the migration
 )
 
 const PKIndexID = uint32(0)
 
+// flags of the catalog entries of indexes
+const (
+	uniqueIndexFlag   = byte(1)
+	nullableIndexFlag = byte(2)
+)
+
 const (
 	nullableFlag      byte = 1 << iota
 	autoIncrementFlag byte = 1 << iota
 )
 
+// kinds of column default values
+const (
+	defaultValueFlag byte = iota
+	defaultFnFlag
+	generatedExpFlag
+)
+
 type SQLValueType = string
 
 const (
@@ -64,6 +90,16 @@ const (
 	MAX   AggregateFn = "MAX"
 	MIN   AggregateFn = "MIN"
 	AVG   AggregateFn = "AVG"
+
+	// COUNT_DISTINCT, SUM_DISTINCT and AVG_DISTINCT are how COUNT, SUM and AVG(DISTINCT col) are resolved,
+	// they aggregate the distinct non-null values of the column
+	COUNT_DISTINCT AggregateFn = "COUNT_DISTINCT"
+	SUM_DISTINCT   AggregateFn = "SUM_DISTINCT"
+	AVG_DISTINCT   AggregateFn = "AVG_DISTINCT"
+
+	// BOOL_AND and BOOL_OR ignore NULL values, a group holding only NULL values yields TRUE and FALSE respectively
+	BOOL_AND AggregateFn = "BOOL_AND"
+	BOOL_OR  AggregateFn = "BOOL_OR"
 )
 
 type CmpOperator = int
@@ -109,6 +145,15 @@ type TxSummary struct {
 
 	updatedRows     int
 	lastInsertedPKs map[string]int64
+	returnedRows    []*ReturnedRow
+
+	// rewritesRows is set when the data entries rewrite the rows of a table as described by the
+	// catalog entries, both are then committed at once if kept in the same store
+	rewritesRows bool
+
+	// dropsRows is set when the data entries delete the rows of a dropped table or the entries of a dropped index,
+	// as they can no longer be read once the catalog entries are committed, they are deleted afterwards
+	dropsRows bool
 }
 
 func newTxSummary(db *Database) *TxSummary {
@@ -134,6 +179,8 @@ func (s *TxSummary) add(summary *TxSummary) error {
 		s.lastInsertedPKs[t] = pk
 	}
 
+	s.returnedRows = append(s.returnedRows, summary.returnedRows...)
+
 	return nil
 }
 
@@ -172,9 +219,60 @@ func (stmt *TxStmt) compileUsing(e *Engine, implicitDB *Database, params map[str
 		}
 	}
 
+	e.deferUniqueChecks(summary)
+
 	return summary, nil
 }
 
+// deferUniqueChecks makes unique constraints to be checked against the final state of the transaction,
+// so rows may exchange unique values e.g. an index entry deleted by one row and assigned to another one
+// is replaced by the new entry, which is then not required to be absent from the store
+func (e *Engine) deferUniqueChecks(summary *TxSummary) {
+	uniquePrefix := e.mapKey(UIndexPrefix)
+
+	deletedEntries := make(map[string]int)
+
+	for i, de := range summary.des {
+		if bytes.HasPrefix(de.Key, uniquePrefix) && de.Metadata != nil && de.Metadata.Deleted() {
+			deletedEntries[string(de.Key)] = i
+		}
+	}
+
+	if len(deletedEntries) == 0 {
+		return
+	}
+
+	supersededEntries := make(map[int]struct{}, len(deletedEntries))
+
+	for _, de := range summary.des {
+		if de.Metadata != nil && de.Metadata.Deleted() {
+			continue
+		}
+
+		i, deleted := deletedEntries[string(de.Key)]
+		if !deleted {
+			continue
+		}
+
+		// a second entry for the same key is kept as a duplicate
+		delete(deletedEntries, string(de.Key))
+
+		supersededEntries[i] = struct{}{}
+		de.Constraint = nil
+	}
+
+	des := make([]*store.EntrySpec, 0, len(summary.des)-len(supersededEntries))
+
+	for i, de := range summary.des {
+		_, superseded := supersededEntries[i]
+		if !superseded {
+			des = append(des, de)
+		}
+	}
+
+	summary.des = des
+}
+
 type CreateDatabaseStmt struct {
 	DB string
 }
@@ -272,30 +370,30 @@ func (stmt *CreateTableStmt) compileUsing(e *Engine, implicitDB *Database, param
 	}
 
 	for _, col := range table.Cols() {
-		//{auto_incremental | nullable}{maxLen}{colNAME})
-		v := make([]byte, 1+4+len(col.colName))
-
 		if col.autoIncrement {
 			if len(table.primaryIndex.cols) > 1 || col.id != table.primaryIndex.cols[0].id {
 				return nil, ErrLimitedAutoIncrement
 			}
-
-			v[0] = v[0] | autoIncrementFlag
-		}
-
-		if col.notNull {
-			v[0] = v[0] | nullableFlag
 		}
 
-		binary.BigEndian.PutUint32(v[1:], uint32(col.MaxLen()))
-
-		copy(v[5:], []byte(col.Name()))
-
 		ce := &store.EntrySpec{
 			Key:   e.mapKey(catalogColumnPrefix, EncodeID(implicitDB.id), EncodeID(table.id), EncodeID(col.id), []byte(col.colType)),
-			Value: v,
+			Value: encodeColSpec(col),
 		}
 		summary.ces = append(summary.ces, ce)
+
+		if col.defaultValue != nil || col.generatedAs != nil {
+			encDefault, err := encodeDefaultValue(col)
+			if err != nil {
+				return nil, err
+			}
+
+			de := &store.EntrySpec{
+				Key:   e.mapKey(catalogDefaultPrefix, EncodeID(implicitDB.id), EncodeID(table.id), EncodeID(col.id)),
+				Value: encDefault,
+			}
+			summary.ces = append(summary.ces, de)
+		}
 	}
 
 	te := &store.EntrySpec{
@@ -307,16 +405,247 @@ func (stmt *CreateTableStmt) compileUsing(e *Engine, implicitDB *Database, param
 	return summary, nil
 }
 
+type CreateTableLikeStmt struct {
+	table            string
+	ifNotExists      bool
+	sourceTable      string
+	includingIndexes bool
+}
+
+func (stmt *CreateTableLikeStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	return nil
+}
+
+func (stmt *CreateTableLikeStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	if implicitDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
+
+	sourceTable, err := implicitDB.GetTableByName(stmt.sourceTable)
+	if err != nil {
+		return nil, err
+	}
+
+	if stmt.ifNotExists && implicitDB.ExistTable(stmt.table) {
+		return newTxSummary(implicitDB), nil
+	}
+
+	colsSpec := make([]*ColSpec, len(sourceTable.cols))
+
+	for i, col := range sourceTable.cols {
+		colsSpec[i] = &ColSpec{
+			colName:       col.colName,
+			colType:       col.colType,
+			maxLen:        col.maxLen,
+			autoIncrement: col.autoIncrement,
+			notNull:       col.notNull,
+			defaultValue:  col.defaultValue,
+			generatedAs:   col.generatedAs,
+		}
+	}
+
+	createTableStmt := &CreateTableStmt{
+		table:      stmt.table,
+		colsSpec:   colsSpec,
+		pkColNames: colNames(sourceTable.primaryIndex.cols),
+	}
+
+	summary, err = createTableStmt.compileUsing(e, implicitDB, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if !stmt.includingIndexes {
+		return summary, nil
+	}
+
+	// secondary indexes are created in the same order as in the source table
+	indexes := make([]*Index, 0, len(sourceTable.indexes))
+
+	for _, index := range sourceTable.indexes {
+		if !index.IsPrimary() {
+			indexes = append(indexes, index)
+		}
+	}
+
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i].id < indexes[j].id })
+
+	for _, index := range indexes {
+		createIndexStmt := &CreateIndexStmt{unique: index.unique, nullable: index.nullable, table: stmt.table, cols: colNames(index.cols)}
+
+		indexSummary, err := createIndexStmt.compileUsing(e, implicitDB, params)
+		if err != nil {
+			return nil, err
+		}
+
+		err = summary.add(indexSummary)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return summary, nil
+}
+
+// DropTableStmt removes a table from the catalog along with its rows and index entries. The id of the table
+// is not assigned again, thus entries of the dropped table can not be read by tables created afterwards
+type DropTableStmt struct {
+	table    string
+	ifExists bool
+}
+
+func (stmt *DropTableStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	return nil
+}
+
+func (stmt *DropTableStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	if implicitDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
+
+	summary = newTxSummary(implicitDB)
+
+	if stmt.ifExists && !implicitDB.ExistTable(stmt.table) {
+		return summary, nil
+	}
+
+	table, err := implicitDB.GetTableByName(stmt.table)
+	if err != nil {
+		return nil, err
+	}
+
+	err = e.renewSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, prefix := range []string{PIndexPrefix, SIndexPrefix, UIndexPrefix} {
+		err = e.deleteEntriesWithPrefix(e.mapKey(prefix, EncodeID(implicitDB.id), EncodeID(table.id)), summary)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	deleteCatalogEntry := func(prefix string, encIDs ...[]byte) {
+		summary.ces = append(summary.ces, &store.EntrySpec{
+			Key:      e.mapKey(prefix, encIDs...),
+			Metadata: store.NewKVMetadata().AsDeleted(true),
+		})
+	}
+
+	deleteCatalogEntry(catalogTablePrefix, EncodeID(implicitDB.id), EncodeID(table.id))
+
+	for _, col := range table.cols {
+		deleteCatalogEntry(catalogColumnPrefix, EncodeID(implicitDB.id), EncodeID(table.id), EncodeID(col.id), []byte(col.colType))
+
+		if col.defaultValue != nil || col.generatedAs != nil {
+			deleteCatalogEntry(catalogDefaultPrefix, EncodeID(implicitDB.id), EncodeID(table.id), EncodeID(col.id))
+		}
+
+		if col.missingValue != nil {
+			deleteCatalogEntry(catalogMissingPrefix, EncodeID(implicitDB.id), EncodeID(table.id), EncodeID(col.id))
+		}
+
+		if col.comment != "" {
+			deleteCatalogEntry(catalogCommentPrefix, EncodeID(implicitDB.id), EncodeID(table.id), EncodeID(col.id))
+		}
+
+		for _, prevType := range col.prevTypes {
+			var encUntilTx [8]byte
+			binary.BigEndian.PutUint64(encUntilTx[:], prevType.untilTx)
+
+			deleteCatalogEntry(catalogPrevTypePrefix, EncodeID(implicitDB.id), EncodeID(table.id), EncodeID(col.id), encUntilTx[:])
+		}
+	}
+
+	for _, index := range table.indexes {
+		deleteCatalogEntry(catalogIndexPrefix, EncodeID(implicitDB.id), EncodeID(table.id), EncodeID(index.id))
+	}
+
+	if table.comment != "" {
+		deleteCatalogEntry(catalogCommentPrefix, EncodeID(implicitDB.id), EncodeID(table.id), EncodeID(0))
+	}
+
+	if table.autoIncrementPK {
+		deleteCatalogEntry(catalogSequencePrefix, EncodeID(implicitDB.id), EncodeID(table.id))
+	}
+
+	implicitDB.dropTable(table)
+
+	summary.dropsRows = true
+
+	return summary, nil
+}
+
+// deleteEntriesWithPrefix adds the deletion of every data entry with the given prefix to the summary
+func (e *Engine) deleteEntriesWithPrefix(prefix []byte, summary *TxSummary) error {
+	reader, err := e.snapshot.NewKeyReader(&store.KeyReaderSpec{
+		Prefix: prefix,
+		Filter: store.IgnoreDeleted,
+	})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	for {
+		mkey, _, err := reader.Read()
+		if err == store.ErrNoMoreEntries {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		summary.des = append(summary.des, &store.EntrySpec{
+			Key:      mkey,
+			Metadata: store.NewKVMetadata().AsDeleted(true),
+		})
+	}
+}
+
+func colNames(cols []*Column) []string {
+	names := make([]string, len(cols))
+
+	for i, col := range cols {
+		names[i] = col.colName
+	}
+
+	return names
+}
+
+// encodeColSpec encodes the catalog entry of a column as {(auto_incremental | nullable){maxLen}{colNAME}}
+func encodeColSpec(col *Column) []byte {
+	v := make([]byte, 1+4+len(col.colName))
+
+	if col.autoIncrement {
+		v[0] = v[0] | autoIncrementFlag
+	}
+
+	if col.notNull {
+		v[0] = v[0] | nullableFlag
+	}
+
+	binary.BigEndian.PutUint32(v[1:], uint32(col.MaxLen()))
+
+	copy(v[5:], []byte(col.Name()))
+
+	return v
+}
+
 type ColSpec struct {
 	colName       string
 	colType       SQLValueType
 	maxLen        int
 	autoIncrement bool
 	notNull       bool
+	defaultValue  ValueExp
+	generatedAs   ValueExp // the expression the value is computed from, nil when the column is not generated
 }
 
 type CreateIndexStmt struct {
 	unique      bool
+	nullable    bool // NULLS DISTINCT
 	ifNotExists bool
 	table       string
 	cols        []string
@@ -361,7 +690,7 @@ func (stmt *CreateIndexStmt) compileUsing(e *Engine, implicitDB *Database, param
 		colIDs[i] = col.id
 	}
 
-	index, err := table.newIndex(stmt.unique, colIDs)
+	index, err := table.newIndex(stmt.unique, stmt.nullable, colIDs)
 	if err == ErrIndexAlreadyExists && stmt.ifNotExists {
 		return summary, nil
 	}
@@ -369,32 +698,31 @@ func (stmt *CreateIndexStmt) compileUsing(e *Engine, implicitDB *Database, param
 		return nil, err
 	}
 
-	// check table is empty
-	{
-		lastTxID, _ := e.dataStore.Alh()
-		err = e.dataStore.WaitForIndexingUpto(lastTxID, nil)
-		if err != nil {
-			return nil, err
-		}
+	// the primary index is not counted
+	if e.maxIndexesPerTable > 0 && len(table.indexes)-1 > e.maxIndexesPerTable {
+		return nil, fmt.Errorf("%w (max number of indexes per table is %d)", ErrLimitedIndexCreation, e.maxIndexesPerTable)
+	}
 
-		pkPrefix := e.mapKey(PIndexPrefix, EncodeID(table.db.id), EncodeID(table.id), EncodeID(PKIndexID))
-		existKey, err := e.dataStore.ExistKeyWith(pkPrefix, pkPrefix, false)
-		if err != nil {
-			return nil, err
-		}
-		if existKey {
-			return nil, ErrLimitedIndexCreation
-		}
+	empty, err := e.isEmptyTable(table)
+	if err != nil {
+		return nil, err
+	}
+	if !empty {
+		return nil, ErrLimitedIndexCreation
 	}
 
-	// v={unique {colID1}(ASC|DESC)...{colIDN}(ASC|DESC)}
+	// v={flags {colID1}(ASC|DESC)...{colIDN}(ASC|DESC)}
 	// TODO: currently only ASC order is supported
 	colSpecLen := EncIDLen + 1
 
 	encodedValues := make([]byte, 1+len(index.cols)*colSpecLen)
 
 	if index.IsUnique() {
-		encodedValues[0] = 1
+		encodedValues[0] |= uniqueIndexFlag
+	}
+
+	if index.IsNullable() {
+		encodedValues[0] |= nullableIndexFlag
 	}
 
 	for i, col := range index.cols {
@@ -410,1444 +738,3514 @@ func (stmt *CreateIndexStmt) compileUsing(e *Engine, implicitDB *Database, param
 	return summary, nil
 }
 
-type AddColumnStmt struct {
-	table   string
-	colSpec *ColSpec
-}
-
-func (stmt *AddColumnStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
-	return nil
-}
-
-func (stmt *AddColumnStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
-	return nil, ErrNoSupported
-}
-
-type UpsertIntoStmt struct {
-	isInsert bool
-	tableRef *tableRef
+type DropIndexStmt struct {
+	ifExists bool
+	table    string
 	cols     []string
-	rows     []*RowSpec
 }
 
-type RowSpec struct {
-	Values []ValueExp
+func (stmt *DropIndexStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	return nil
 }
 
-func (stmt *UpsertIntoStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
-	for _, row := range stmt.rows {
-		if len(stmt.cols) != len(row.Values) {
-			return ErrIllegalArguments
-		}
+// compileUsing removes the index identified by its columns, in the order they were indexed, along with its entries
+func (stmt *DropIndexStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	if len(stmt.cols) < 1 {
+		return nil, ErrIllegalArguments
+	}
 
-		for i, val := range row.Values {
-			table, err := stmt.tableRef.referencedTable(e, implicitDB)
-			if err != nil {
-				return err
-			}
+	if implicitDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
 
-			col, err := table.GetColumnByName(stmt.cols[i])
-			if err != nil {
-				return err
-			}
+	summary = newTxSummary(implicitDB)
 
-			err = val.requiresType(col.colType, make(map[string]ColDescriptor), params, implicitDB.name, table.name)
-			if err != nil {
-				return err
-			}
-		}
+	table, err := implicitDB.GetTableByName(stmt.table)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
-}
-
-func (stmt *UpsertIntoStmt) validate(table *Table) (map[uint32]int, error) {
-	selPosByColID := make(map[uint32]int, len(stmt.cols))
+	cols := make([]*Column, len(stmt.cols))
 
-	for i, c := range stmt.cols {
-		col, err := table.GetColumnByName(c)
+	for i, colName := range stmt.cols {
+		cols[i], err = table.GetColumnByName(colName)
 		if err != nil {
 			return nil, err
 		}
+	}
 
-		_, duplicated := selPosByColID[col.id]
-		if duplicated {
-			return nil, ErrDuplicatedColumn
-		}
-
-		selPosByColID[col.id] = i
+	index, exists := table.indexes[indexKeyFrom(cols)]
+	if !exists && stmt.ifExists {
+		return summary, nil
 	}
-
-	return selPosByColID, nil
-}
-
-func (stmt *UpsertIntoStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
-	if implicitDB == nil {
-		return nil, ErrNoDatabaseSelected
+	if !exists {
+		return nil, ErrIndexDoesNotExist
 	}
 
-	summary = newTxSummary(implicitDB)
+	if index.IsPrimary() {
+		return nil, ErrPKCanNotBeDropped
+	}
 
-	table, err := stmt.tableRef.referencedTable(e, implicitDB)
+	err = e.renewSnapshot()
 	if err != nil {
 		return nil, err
 	}
 
-	if len(stmt.rows)*len(table.indexes) > e.dataStore.MaxTxEntries() {
-		return nil, ErrTooManyRows
-	}
-
-	selPosByColID, err := stmt.validate(table)
+	err = e.deleteEntriesWithPrefix(e.mapKey(index.prefix(), EncodeID(implicitDB.id), EncodeID(table.id), EncodeID(index.id)), summary)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, row := range stmt.rows {
-		if len(row.Values) != len(stmt.cols) {
-			return nil, ErrInvalidNumberOfValues
-		}
-
-		valuesByColID := make(map[uint32]TypedValue)
-
-		for colID, col := range table.colsByID {
-			colPos, specified := selPosByColID[colID]
-			if !specified {
-				if col.notNull {
-					return nil, ErrNotNullableColumnCannotBeNull
-				}
-				continue
-			}
+	summary.ces = append(summary.ces, &store.EntrySpec{
+		Key:      e.mapKey(catalogIndexPrefix, EncodeID(implicitDB.id), EncodeID(table.id), EncodeID(index.id)),
+		Metadata: store.NewKVMetadata().AsDeleted(true),
+	})
 
-			if stmt.isInsert && col.autoIncrement {
-				return nil, ErrNoValueForAutoIncrementalColumn
-			}
+	table.dropIndex(index)
 
-			cVal := row.Values[colPos]
+	summary.dropsRows = true
 
-			val, err := cVal.substitute(params)
-			if err != nil {
-				return nil, err
-			}
+	return summary, nil
+}
 
-			rval, err := val.reduce(e.catalog, nil, implicitDB.name, table.name)
-			if err != nil {
-				return nil, err
-			}
+type AddColumnStmt struct {
+	table   string
+	colSpec *ColSpec
+}
 
-			_, isNull := rval.(*NullValue)
-			if isNull {
-				if col.notNull {
-					return nil, ErrNotNullableColumnCannotBeNull
-				}
+func (stmt *AddColumnStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	return nil
+}
 
-				continue
-			}
+// compileUsing appends the column to the table. Rows already stored are not rewritten, thus a NOT NULL column
+// can only be added to a non-empty table along with a default value. The default value is kept as the value
+// of the rows written before the column was added, while rows written afterwards hold their own value
+func (stmt *AddColumnStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	if implicitDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
 
-			valuesByColID[colID] = rval
-		}
+	table, err := implicitDB.GetTableByName(stmt.table)
+	if err != nil {
+		return nil, err
+	}
 
-		// inject auto-incremental pk value
-		if stmt.isInsert && table.autoIncrementPK {
-			table.maxPK++
-			e.catalog.mutated = true // TODO: implement transactional in-memory catalog
+	_, err = table.GetColumnByName(stmt.colSpec.colName)
+	if err == nil {
+		return nil, fmt.Errorf("%w (%s)", ErrColumnAlreadyExists, stmt.colSpec.colName)
+	}
 
-			pkCol := table.primaryIndex.cols[0]
+	// only the primary key may be auto-incremental
+	if stmt.colSpec.autoIncrement {
+		return nil, ErrLimitedAutoIncrement
+	}
 
-			valuesByColID[pkCol.id] = &Number{val: table.maxPK}
+	empty, err := e.isEmptyTable(table)
+	if err != nil {
+		return nil, err
+	}
 
-			summary.lastInsertedPKs[table.name] = table.maxPK
+	if !empty {
+		if stmt.colSpec.generatedAs != nil {
+			return nil, fmt.Errorf("%w (generated columns can not be added to table %s as it is not empty)", ErrIllegalGeneratedColumn, table.name)
 		}
 
-		pkEncVals, err := encodedPK(table, valuesByColID)
-		if err != nil {
-			return nil, err
+		if stmt.colSpec.notNull && stmt.colSpec.defaultValue == nil {
+			return nil, fmt.Errorf("%w (column %s has no default value and table %s is not empty)", ErrNotNullableColumnCannotBeNull, stmt.colSpec.colName, table.name)
 		}
+	}
 
-		err = e.doUpsert(pkEncVals, valuesByColID, table, stmt.isInsert, summary)
+	col, err := table.newColumn(stmt.colSpec)
+	if err != nil {
+		return nil, err
+	}
+	e.catalog.mutated = true // TODO: implement transactional in-memory catalog
+
+	if col.generatedAs != nil {
+		err = validateGeneratedExp(col)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	return summary, nil
-}
+	summary = newTxSummary(implicitDB)
 
-func (e *Engine) doUpsert(pkEncVals []byte, valuesByColID map[uint32]TypedValue, table *Table, isInsert bool, summary *TxSummary) error {
-	var reusableIndexEntries map[uint32]struct{}
+	ce := &store.EntrySpec{
+		Key:   e.mapKey(catalogColumnPrefix, EncodeID(implicitDB.id), EncodeID(table.id), EncodeID(col.id), []byte(col.colType)),
+		Value: encodeColSpec(col),
+	}
+	summary.ces = append(summary.ces, ce)
 
-	if !isInsert && len(table.indexes) > 1 {
-		currPKRow, err := e.fetchPKRow(table, valuesByColID)
-		if err != nil && err != ErrNoMoreRows {
-			return err
+	if col.defaultValue != nil || col.generatedAs != nil {
+		encDefault, err := encodeDefaultValue(col)
+		if err != nil {
+			return nil, err
 		}
 
-		if err == nil {
-			currValuesByColID := make(map[uint32]TypedValue, len(currPKRow.Values))
-
-			for _, col := range table.cols {
-				encSel := EncodeSelector("", table.db.name, table.name, col.colName)
-				currValuesByColID[col.id] = currPKRow.Values[encSel]
-			}
-
-			reusableIndexEntries, err = e.deprecateIndexEntries(pkEncVals, currValuesByColID, valuesByColID, table, summary)
-			if err != nil {
-				return err
-			}
+		de := &store.EntrySpec{
+			Key:   e.mapKey(catalogDefaultPrefix, EncodeID(implicitDB.id), EncodeID(table.id), EncodeID(col.id)),
+			Value: encDefault,
 		}
+		summary.ces = append(summary.ces, de)
 	}
 
-	// create primary index entry
-	mkey := e.mapKey(PIndexPrefix, EncodeID(table.db.id), EncodeID(table.id), EncodeID(table.primaryIndex.id), pkEncVals)
-
-	var constraint store.KVConstraint
+	if empty || col.defaultValue == nil {
+		return summary, nil
+	}
 
-	if isInsert && !table.autoIncrementPK {
-		constraint = store.MustNotExistOrDeleted
+	// the default is evaluated once, so functions like NOW() give the same value to all the rows already stored
+	missingValue, err := col.defaultValue.reduce(e.catalog, nil, implicitDB.name, table.name)
+	if err != nil {
+		return nil, err
 	}
 
-	if !isInsert && table.autoIncrementPK {
-		constraint = store.MustExist
+	if _, isNull := missingValue.(*NullValue); isNull {
+		return summary, nil
 	}
 
-	valbuf := bytes.Buffer{}
+	col.missingValue = missingValue
 
-	b := make([]byte, EncLenLen)
-	binary.BigEndian.PutUint32(b, uint32(len(valuesByColID)))
+	// NULL values are not stored, thus rows written afterwards can only be told apart by their transaction
+	col.missingTx, _ = e.dataStore.Alh()
 
-	_, err := valbuf.Write(b)
+	encMissing, err := encodeMissingValue(col)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	for _, col := range table.cols {
-		rval, notNull := valuesByColID[col.id]
-		if !notNull {
-			continue
-		}
+	me := &store.EntrySpec{
+		Key:   e.mapKey(catalogMissingPrefix, EncodeID(implicitDB.id), EncodeID(table.id), EncodeID(col.id)),
+		Value: encMissing,
+	}
+	summary.ces = append(summary.ces, me)
 
-		b := make([]byte, EncIDLen)
-		binary.BigEndian.PutUint32(b, uint32(col.id))
+	return summary, nil
+}
 
-		_, err = valbuf.Write(b)
-		if err != nil {
-			return err
-		}
+// AlterAutoIncrementStmt sets the next value to be assigned to the auto-incremental primary key of a table
+type AlterAutoIncrementStmt struct {
+	table     string
+	op        CmpOperator
+	nextValue uint64
+}
 
-		encVal, err := EncodeValue(rval.Value(), col.colType, col.MaxLen())
-		if err != nil {
-			return err
-		}
+func (stmt *AlterAutoIncrementStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	return nil
+}
 
-		_, err = valbuf.Write(encVal)
-		if err != nil {
-			return err
-		}
+func (stmt *AlterAutoIncrementStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	if implicitDB == nil {
+		return nil, ErrNoDatabaseSelected
 	}
 
-	pke := &store.EntrySpec{
-		Key:        mkey,
-		Value:      valbuf.Bytes(),
-		Constraint: constraint,
+	table, err := implicitDB.GetTableByName(stmt.table)
+	if err != nil {
+		return nil, err
 	}
-	summary.des = append(summary.des, pke)
 
-	// create entries for secondary indexes
-	for _, index := range table.indexes {
-		if index.IsPrimary() {
-			continue
-		}
+	if stmt.op != EQ || !table.autoIncrementPK {
+		return nil, ErrIllegalArguments
+	}
 
-		if reusableIndexEntries != nil {
-			_, reusable := reusableIndexEntries[index.id]
-			if reusable {
-				continue
-			}
-		}
+	// values already assigned can not be reused
+	if stmt.nextValue > math.MaxInt64 || int64(stmt.nextValue) <= table.maxPK {
+		return nil, ErrIllegalArguments
+	}
 
-		var prefix string
-		var encodedValues [][]byte
-		var val []byte
+	table.maxPK = int64(stmt.nextValue) - 1
+	e.catalog.mutated = true // TODO: implement transactional in-memory catalog
 
-		if index.IsUnique() {
-			prefix = UIndexPrefix
-			encodedValues = make([][]byte, 3+len(index.cols))
-			val = pkEncVals
-		} else {
-			prefix = SIndexPrefix
-			encodedValues = make([][]byte, 4+len(index.cols))
-			encodedValues[len(encodedValues)-1] = pkEncVals
-		}
+	var v [8]byte
+	binary.BigEndian.PutUint64(v[:], stmt.nextValue)
 
-		encodedValues[0] = EncodeID(table.db.id)
-		encodedValues[1] = EncodeID(table.id)
-		encodedValues[2] = EncodeID(index.id)
+	summary = newTxSummary(implicitDB)
 
-		for i, col := range index.cols {
-			if col.MaxLen() > maxKeyLen {
-				return ErrMaxKeyLengthExceeded
-			}
+	se := &store.EntrySpec{
+		Key:   e.mapKey(catalogSequencePrefix, EncodeID(implicitDB.id), EncodeID(table.id)),
+		Value: v[:],
+	}
+	summary.ces = append(summary.ces, se)
 
-			rval, notNull := valuesByColID[col.id]
-			if !notNull {
-				return ErrIndexedColumnCanNotBeNull
-			}
+	return summary, nil
+}
 
-			encVal, err := EncodeAsKey(rval.Value(), col.colType, col.MaxLen())
-			if err != nil {
-				return err
-			}
+// DropDefaultStmt removes the default value of a column, so values omitted from then on are NULL
+type DropDefaultStmt struct {
+	table string
+	col   string
+}
 
-			encodedValues[i+3] = encVal
-		}
+func (stmt *DropDefaultStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	return nil
+}
 
-		var constraint store.KVConstraint
+func (stmt *DropDefaultStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	if implicitDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
 
-		if index.IsUnique() {
-			constraint = store.MustNotExistOrDeleted
-		}
+	table, err := implicitDB.GetTableByName(stmt.table)
+	if err != nil {
+		return nil, err
+	}
 
-		ie := &store.EntrySpec{
-			Key:        e.mapKey(prefix, encodedValues...),
-			Value:      val,
-			Constraint: constraint,
-		}
+	col, err := table.GetColumnByName(stmt.col)
+	if err != nil {
+		return nil, err
+	}
 
-		summary.des = append(summary.des, ie)
+	if col.generatedAs != nil {
+		// the expression of generated columns is stored in place of the default value
+		return nil, fmt.Errorf("%w (column %s is generated)", ErrIllegalArguments, col.colName)
 	}
 
-	summary.updatedRows++
+	col.defaultValue = nil
+	e.catalog.mutated = true // TODO: implement transactional in-memory catalog
 
-	return nil
-}
+	summary = newTxSummary(implicitDB)
 
-func encodedPK(table *Table, valuesByColID map[uint32]TypedValue) ([]byte, error) {
-	valbuf := bytes.Buffer{}
+	ce := &store.EntrySpec{
+		Key:      e.mapKey(catalogDefaultPrefix, EncodeID(implicitDB.id), EncodeID(table.id), EncodeID(col.id)),
+		Metadata: store.NewKVMetadata().AsDeleted(true),
+	}
+	summary.ces = append(summary.ces, ce)
 
-	for _, col := range table.primaryIndex.cols {
-		rval, notNull := valuesByColID[col.id]
-		if !notNull {
-			return nil, ErrPKCanNotBeNull
-		}
+	return summary, nil
+}
 
-		encVal, err := EncodeAsKey(rval.Value(), col.colType, col.MaxLen())
-		if err != nil {
-			return nil, err
-		}
+// AlterColumnTypeStmt changes the type of a column, the values already stored are converted
+// as done by convertValue and the change is rejected if any of them can not be converted
+type AlterColumnTypeStmt struct {
+	table   string
+	col     string
+	colType SQLValueType
+	maxLen  int
+}
 
-		if len(encVal) > maxKeyLen {
-			return nil, ErrMaxKeyLengthExceeded
-		}
+func (stmt *AlterColumnTypeStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	return nil
+}
 
-		_, err = valbuf.Write(encVal)
-		if err != nil {
-			return nil, err
-		}
+func (stmt *AlterColumnTypeStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	if implicitDB == nil {
+		return nil, ErrNoDatabaseSelected
 	}
 
-	return valbuf.Bytes(), nil
-}
+	table, err := implicitDB.GetTableByName(stmt.table)
+	if err != nil {
+		return nil, err
+	}
 
-func (e *Engine) fetchPKRow(table *Table, valuesByColID map[uint32]TypedValue) (*Row, error) {
-	pkRanges := make(map[uint32]*typedValueRange, len(table.primaryIndex.cols))
+	col, err := table.GetColumnByName(stmt.col)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, pkCol := range table.primaryIndex.cols {
-		pkVal := valuesByColID[pkCol.id]
+	if !validMaxLenForType(stmt.maxLen, stmt.colType) {
+		return nil, ErrLimitedMaxLen
+	}
 
-		pkRanges[pkCol.id] = &typedValueRange{
-			lRange: &typedValueSemiRange{val: pkVal, inclusive: true},
-			hRange: &typedValueSemiRange{val: pkVal, inclusive: true},
-		}
+	if col.autoIncrement {
+		return nil, fmt.Errorf("%w (column %s is auto-incremental)", ErrIllegalArguments, col.colName)
 	}
 
-	scanSpecs := &ScanSpecs{
-		index:         table.primaryIndex,
-		rangesByColID: pkRanges,
+	if col.generatedAs != nil {
+		return nil, fmt.Errorf("%w (column %s is generated)", ErrIllegalArguments, col.colName)
 	}
 
-	lastTxID, _ := e.dataStore.Alh()
-	err := e.dataStore.WaitForIndexingUpto(lastTxID, nil)
+	// index entries are encoded using the type of the column
+	if len(table.indexesByColID[col.id]) > 0 {
+		return nil, fmt.Errorf("%w (column %s is indexed)", ErrIllegalArguments, col.colName)
+	}
+
+	defaultValue := col.defaultValue
+
+	if val, ok := defaultValue.(TypedValue); ok {
+		defaultValue, err = convertValue(val, stmt.colType)
+		if err != nil {
+			return nil, fmt.Errorf("%w (default value of column %s)", err, col.colName)
+		}
+	}
+
+	defaultValue, err = validDefaultValue(&ColSpec{
+		colName:      col.colName,
+		colType:      stmt.colType,
+		maxLen:       stmt.maxLen,
+		notNull:      col.notNull,
+		defaultValue: defaultValue,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	snapshot := e.dataStore.CurrentSnapshot()
-	defer func() {
-		snapshot.Close()
-	}()
-
-	r, err := e.newRawRowReader(snapshot, table, 0, table.name, scanSpecs)
+	err = e.renewSnapshot()
 	if err != nil {
 		return nil, err
 	}
 
-	defer func() {
-		r.Close()
-	}()
+	rowReader, err := e.snapshot.NewKeyReader(&store.KeyReaderSpec{
+		Prefix: e.mapKey(PIndexPrefix, EncodeID(implicitDB.id), EncodeID(table.id), EncodeID(PKIndexID)),
+		Filter: store.IgnoreDeleted,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rowReader.Close()
 
-	return r.Read()
-}
+	type convertedRow struct {
+		key           []byte
+		valuesByColID map[uint32]TypedValue
+	}
 
-// deprecateIndexEntries mark previous index entries as deleted
-func (e *Engine) deprecateIndexEntries(
-	pkEncVals []byte,
-	currValuesByColID, newValuesByColID map[uint32]TypedValue,
-	table *Table,
-	summary *TxSummary) (reusableIndexEntries map[uint32]struct{}, err error) {
+	var rows []*convertedRow
 
-	reusableIndexEntries = make(map[uint32]struct{})
+	// rows are decoded before the catalog is changed
+	for {
+		mkey, vref, err := rowReader.Read()
+		if err == store.ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
 
-	for _, index := range table.indexes {
-		if index.IsPrimary() {
-			continue
+		if len(rows) >= e.dataStore.MaxTxEntries() {
+			return nil, ErrTooManyRows
 		}
 
-		var prefix string
-		var encodedValues [][]byte
+		v, err := vref.Resolve()
+		if err != nil {
+			return nil, err
+		}
 
-		if index.IsUnique() {
-			prefix = UIndexPrefix
-			encodedValues = make([][]byte, 3+len(index.cols))
-		} else {
-			prefix = SIndexPrefix
-			encodedValues = make([][]byte, 4+len(index.cols))
-			encodedValues[len(encodedValues)-1] = pkEncVals
+		row, err := decodeRow(table, table.name, v, vref.Tx())
+		if err != nil {
+			return nil, err
 		}
 
-		encodedValues[0] = EncodeID(table.db.id)
-		encodedValues[1] = EncodeID(table.id)
-		encodedValues[2] = EncodeID(index.id)
+		valuesByColID := make(map[uint32]TypedValue, len(table.cols))
 
-		// existent index entry is deleted only if it differs from existent one
-		sameIndexKey := true
+		for _, c := range table.cols {
+			val := row.Values[EncodeSelector("", implicitDB.name, table.name, c.colName)]
 
-		for i, col := range index.cols {
-			currVal, notNull := currValuesByColID[col.id]
-			if !notNull {
-				return nil, ErrCorruptedData
+			if _, isNull := val.(*NullValue); isNull {
+				continue
 			}
 
-			newVal, notNull := newValuesByColID[col.id]
-			if notNull {
-				r, err := currVal.Compare(newVal)
+			if c.id == col.id {
+				val, err = convertValue(val, stmt.colType)
 				if err != nil {
-					return nil, err
+					return nil, fmt.Errorf("%w (column %s)", err, col.colName)
 				}
 
-				sameIndexKey = sameIndexKey && r == 0
+				_, err = EncodeValue(val.Value(), stmt.colType, stmt.maxLen)
+				if err != nil {
+					return nil, fmt.Errorf("%w (column %s)", err, col.colName)
+				}
 			}
 
-			encVal, _ := EncodeAsKey(currVal.Value(), col.colType, col.MaxLen())
-
-			encodedValues[i+3] = encVal
+			valuesByColID[c.id] = val
 		}
 
-		// mark existent index entry as deleted
-		if sameIndexKey {
-			reusableIndexEntries[index.id] = struct{}{}
-		} else {
-			ie := &store.EntrySpec{
-				Key:      e.mapKey(prefix, encodedValues...),
-				Metadata: store.NewKVMetadata().AsDeleted(true),
-			}
-
-			summary.des = append(summary.des, ie)
-		}
+		rows = append(rows, &convertedRow{key: mkey, valuesByColID: valuesByColID})
 	}
 
-	return reusableIndexEntries, nil
-}
-
-type UpdateStmt struct {
-	tableRef *tableRef
-	where    ValueExp
-	updates  []*colUpdate
-	indexOn  []string
-	limit    int
-}
-
-type colUpdate struct {
-	col string
-	op  CmpOperator
-	val ValueExp
-}
+	missingValue := col.missingValue
 
-func (stmt *UpdateStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
-	selectStmt := &SelectStmt{
-		ds:    stmt.tableRef,
-		where: stmt.where,
+	if missingValue != nil {
+		missingValue, err = convertValue(missingValue, stmt.colType)
+		if err != nil {
+			return nil, fmt.Errorf("%w (column %s)", err, col.colName)
+		}
 	}
 
-	err := selectStmt.inferParameters(e, implicitDB, params)
-	if err != nil {
-		return err
-	}
+	prevType, prevMaxLen := col.colType, col.maxLen
 
-	table, err := stmt.tableRef.referencedTable(e, implicitDB)
-	if err != nil {
-		return err
-	}
+	col.colType = stmt.colType
+	col.maxLen = stmt.maxLen
+	col.defaultValue = defaultValue
+	col.missingValue = missingValue
+	e.catalog.mutated = true // TODO: implement transactional in-memory catalog
 
-	for _, update := range stmt.updates {
-		col, err := table.GetColumnByName(update.col)
-		if err != nil {
-			return err
+	// generated columns may reference the column
+	for _, c := range table.cols {
+		if c.generatedAs == nil {
+			continue
 		}
 
-		err = update.val.requiresType(col.colType, make(map[string]ColDescriptor), params, implicitDB.name, table.name)
+		err = validateGeneratedExp(c)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	return nil
-}
-
-func (stmt *UpdateStmt) validate(table *Table) error {
-	colIDs := make(map[uint32]struct{}, len(stmt.updates))
+	summary = newTxSummary(implicitDB)
 
-	for _, update := range stmt.updates {
-		if update.op != EQ {
-			return ErrIllegalArguments
+	if prevType != col.colType {
+		ce := &store.EntrySpec{
+			Key:      e.mapKey(catalogColumnPrefix, EncodeID(implicitDB.id), EncodeID(table.id), EncodeID(col.id), []byte(prevType)),
+			Metadata: store.NewKVMetadata().AsDeleted(true),
 		}
+		summary.ces = append(summary.ces, ce)
 
-		col, err := table.GetColumnByName(update.col)
-		if err != nil {
-			return err
-		}
+		// rows already written, including the ones only reachable from past snapshots,
+		// keep being decoded with the type they were encoded with
+		untilTx, _ := e.dataStore.Alh()
 
-		if table.PrimaryIndex().IncludesCol(col.id) {
-			return ErrPKCanNotBeUpdated
-		}
+		var encUntilTx [8]byte
+		binary.BigEndian.PutUint64(encUntilTx[:], untilTx)
 
-		_, duplicated := colIDs[col.id]
-		if duplicated {
-			return ErrDuplicatedColumn
+		pe := &store.EntrySpec{
+			Key:   e.mapKey(catalogPrevTypePrefix, EncodeID(implicitDB.id), EncodeID(table.id), EncodeID(col.id), encUntilTx[:]),
+			Value: []byte(prevType),
 		}
+		summary.ces = append(summary.ces, pe)
 
-		colIDs[col.id] = struct{}{}
-	}
-
-	return nil
-}
-
-func (stmt *UpdateStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
-	if implicitDB == nil {
-		return nil, ErrNoDatabaseSelected
+		col.prevTypes = append(col.prevTypes, &prevColType{colType: prevType, untilTx: untilTx})
 	}
 
-	err = e.renewSnapshot()
-	if err != nil {
-		return nil, err
+	if prevType != col.colType || prevMaxLen != col.maxLen {
+		ce := &store.EntrySpec{
+			Key:   e.mapKey(catalogColumnPrefix, EncodeID(implicitDB.id), EncodeID(table.id), EncodeID(col.id), []byte(col.colType)),
+			Value: encodeColSpec(col),
+		}
+		summary.ces = append(summary.ces, ce)
 	}
 
-	selectStmt := &SelectStmt{
-		ds:      stmt.tableRef,
-		where:   stmt.where,
-		indexOn: stmt.indexOn,
-		limit:   stmt.limit,
-	}
+	if col.defaultValue != nil {
+		encDefault, err := encodeDefaultValue(col)
+		if err != nil {
+			return nil, err
+		}
 
-	rowReader, err := selectStmt.Resolve(e, e.snapshot, implicitDB, params, nil)
-	if err != nil {
-		return nil, err
+		de := &store.EntrySpec{
+			Key:   e.mapKey(catalogDefaultPrefix, EncodeID(implicitDB.id), EncodeID(table.id), EncodeID(col.id)),
+			Value: encDefault,
+		}
+		summary.ces = append(summary.ces, de)
 	}
-	defer rowReader.Close()
 
-	table := rowReader.ScanSpecs().index.table
+	if col.missingValue != nil && prevType != col.colType {
+		encMissing, err := encodeMissingValue(col)
+		if err != nil {
+			return nil, err
+		}
 
-	err = stmt.validate(table)
-	if err != nil {
-		return nil, err
+		me := &store.EntrySpec{
+			Key:   e.mapKey(catalogMissingPrefix, EncodeID(implicitDB.id), EncodeID(table.id), EncodeID(col.id)),
+			Value: encMissing,
+		}
+		summary.ces = append(summary.ces, me)
 	}
 
-	cols, err := rowReader.colsBySelector()
-	if err != nil {
-		return nil, err
+	if prevType == col.colType {
+		// stored values are kept as they are
+		return summary, nil
 	}
 
-	summary = newTxSummary(implicitDB)
-
-	for {
-		if summary.updatedRows*len(table.indexes) > e.dataStore.MaxTxEntries() {
-			return nil, ErrTooManyRows
+	for _, row := range rows {
+		encRow, err := e.encodeRow(table, row.valuesByColID)
+		if err != nil {
+			return nil, err
 		}
 
-		row, err := rowReader.Read()
-		if err == ErrNoMoreRows {
-			break
-		}
+		summary.des = append(summary.des, &store.EntrySpec{Key: row.key, Value: encRow})
+	}
 
-		valuesByColID := make(map[uint32]TypedValue, len(row.Values))
+	summary.rewritesRows = len(summary.des) > 0
 
-		for _, col := range table.cols {
-			encSel := EncodeSelector("", table.db.name, table.name, col.colName)
-			valuesByColID[col.id] = row.Values[encSel]
-		}
+	return summary, nil
+}
 
-		for _, update := range stmt.updates {
-			col, err := table.GetColumnByName(update.col)
-			if err != nil {
-				return nil, err
-			}
+// convertValue converts a value into a value of the given type. Conversions never lose information:
+// INTEGER and BOOLEAN values are rendered as VARCHAR, VARCHAR values are parsed as INTEGER or BOOLEAN,
+// BOOLEAN and INTEGER values are exchanged as 1 (true) and 0 (false), VARCHAR values are taken as BLOB
+// and BLOB values holding valid UTF-8 as VARCHAR
+func convertValue(val TypedValue, t SQLValueType) (TypedValue, error) {
+	if val.Type() == t {
+		return val, nil
+	}
 
-			sval, err := update.val.substitute(params)
-			if err != nil {
-				return nil, err
-			}
+	if _, isNull := val.(*NullValue); isNull {
+		return &NullValue{t: t}, nil
+	}
 
-			rval, err := sval.reduce(e.catalog, row, table.db.name, table.name)
-			if err != nil {
-				return nil, err
+	switch v := val.(type) {
+	case *Number:
+		switch t {
+		case VarcharType:
+			return &Varchar{val: strconv.FormatInt(v.val, 10)}, nil
+		case BooleanType:
+			if v.val == 0 || v.val == 1 {
+				return &Bool{val: v.val == 1}, nil
 			}
-
-			err = rval.requiresType(col.colType, cols, nil, table.db.name, table.name)
+		case TimestampType:
+			// integers are taken as nanoseconds since the unix epoch
+			return &Timestamp{val: time.Unix(0, v.val).UTC()}, nil
+		}
+	case *Timestamp:
+		switch t {
+		case IntegerType:
+			nanos, err := timestampNanos(v.val)
 			if err != nil {
 				return nil, err
 			}
 
-			valuesByColID[col.id] = rval
+			return &Number{val: nanos}, nil
+		case VarcharType:
+			return &Varchar{val: v.val.Format(time.RFC3339Nano)}, nil
 		}
-
-		pkEncVals, err := encodedPK(table, valuesByColID)
-		if err != nil {
-			return nil, err
+	case *Bool:
+		switch t {
+		case VarcharType:
+			return &Varchar{val: strconv.FormatBool(v.val)}, nil
+		case IntegerType:
+			if v.val {
+				return &Number{val: 1}, nil
+			}
+			return &Number{val: 0}, nil
 		}
-
-		err = e.doUpsert(pkEncVals, valuesByColID, table, false, summary)
-		if err != nil {
-			return nil, err
+	case *Varchar:
+		switch t {
+		case IntegerType:
+			n, err := strconv.ParseInt(v.val, 10, 64)
+			if err == nil {
+				return &Number{val: n}, nil
+			}
+		case BooleanType:
+			switch strings.ToLower(v.val) {
+			case "true":
+				return &Bool{val: true}, nil
+			case "false":
+				return &Bool{val: false}, nil
+			}
+		case BLOBType:
+			return &Blob{val: []byte(v.val)}, nil
+		}
+	case *Blob:
+		if t == VarcharType && utf8.Valid(v.val) {
+			return &Varchar{val: string(v.val)}, nil
 		}
 	}
 
-	return summary, nil
+	return nil, fmt.Errorf("%w (%s value %v as %s)", ErrInvalidConversion, val.Type(), val.Value(), t)
 }
 
-type DeleteFromStmt struct {
-	tableRef *tableRef
-	where    ValueExp
-	indexOn  []string
-	limit    int
+// CommentStmt sets the comment of a table or, when col is set, of one of its columns. An empty comment removes it
+type CommentStmt struct {
+	table   string
+	col     string
+	comment string
 }
 
-func (stmt *DeleteFromStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
-	selectStmt := &SelectStmt{
-		ds:    stmt.tableRef,
-		where: stmt.where,
-	}
-	return selectStmt.inferParameters(e, implicitDB, params)
+func (stmt *CommentStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	return nil
 }
 
-func (stmt *DeleteFromStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+func (stmt *CommentStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
 	if implicitDB == nil {
 		return nil, ErrNoDatabaseSelected
 	}
 
-	err = e.renewSnapshot()
+	table, err := implicitDB.GetTableByName(stmt.table)
 	if err != nil {
 		return nil, err
 	}
 
-	selectStmt := &SelectStmt{
-		ds:      stmt.tableRef,
-		where:   stmt.where,
-		indexOn: stmt.indexOn,
-		limit:   stmt.limit,
-	}
+	var colID uint32
 
-	rowReader, err := selectStmt.Resolve(e, e.snapshot, implicitDB, params, nil)
-	if err != nil {
-		return nil, err
+	if stmt.col == "" {
+		table.comment = stmt.comment
+	} else {
+		col, err := table.GetColumnByName(stmt.col)
+		if err != nil {
+			return nil, err
+		}
+
+		col.comment = stmt.comment
+		colID = col.id
 	}
-	defer rowReader.Close()
 
-	table := rowReader.ScanSpecs().index.table
+	e.catalog.mutated = true // TODO: implement transactional in-memory catalog
 
 	summary = newTxSummary(implicitDB)
 
-	for {
-		if summary.updatedRows*len(table.indexes) > e.dataStore.MaxTxEntries() {
-			return nil, ErrTooManyRows
-		}
+	ce := &store.EntrySpec{
+		Key:   e.mapKey(catalogCommentPrefix, EncodeID(implicitDB.id), EncodeID(table.id), EncodeID(colID)),
+		Value: []byte(stmt.comment),
+	}
 
-		row, err := rowReader.Read()
-		if err == ErrNoMoreRows {
-			break
+	if stmt.comment == "" {
+		ce.Value = nil
+		ce.Metadata = store.NewKVMetadata().AsDeleted(true)
+	}
+
+	summary.ces = append(summary.ces, ce)
+
+	return summary, nil
+}
+
+type UpsertIntoStmt struct {
+	isInsert   bool
+	tableRef   *tableRef
+	cols       []string
+	rows       []*RowSpec
+	onConflict *conflictClause
+	returning  []string // OLD and/or NEW, as in RETURNING OLD.*, NEW.*
+}
+
+// ReturnedRow holds the values of a row written by a statement with a RETURNING clause e.g. RETURNING OLD.*, NEW.*
+// Old holds the values of the row before the statement, it's nil when the row did not exist or OLD was not requested.
+// New holds the values as they were written, it's nil when NEW was not requested
+type ReturnedRow struct {
+	Old *Row
+	New *Row
+}
+
+// returningClause tells which values of the written rows are returned
+type returningClause struct {
+	old bool
+	new bool
+}
+
+func newReturningClause(returning []string) (*returningClause, error) {
+	if len(returning) == 0 {
+		return nil, nil
+	}
+
+	r := &returningClause{}
+
+	for _, name := range returning {
+		switch {
+		case name == "old" && !r.old:
+			r.old = true
+		case name == "new" && !r.new:
+			r.new = true
+		default:
+			return nil, fmt.Errorf("%w (RETURNING expects OLD.* and/or NEW.*)", ErrIllegalArguments)
 		}
+	}
 
-		valuesByColID := make(map[uint32]TypedValue, len(row.Values))
+	return r, nil
+}
+
+// add records the values of a written row, old is nil when the row did not exist
+func (r *returningClause) add(table *Table, old *Row, newValuesByColID map[uint32]TypedValue, summary *TxSummary) {
+	returned := &ReturnedRow{}
+
+	if r.old {
+		returned.Old = old
+	}
+
+	if r.new {
+		returned.New = &Row{Values: make(map[string]TypedValue, len(table.cols))}
 
 		for _, col := range table.cols {
-			encSel := EncodeSelector("", table.db.name, table.name, col.colName)
-			valuesByColID[col.id] = row.Values[encSel]
+			val, notNull := newValuesByColID[col.id]
+			if !notNull {
+				val = &NullValue{t: col.colType}
+			}
+
+			returned.New.Values[EncodeSelector("", table.db.name, table.name, col.colName)] = val
 		}
+	}
 
-		pkEncVals, err := encodedPK(table, valuesByColID)
+	summary.returnedRows = append(summary.returnedRows, returned)
+}
+
+// conflictClause tells what to do with rows conflicting with existing ones on the unique index of the target columns
+// e.g. ON CONFLICT (email) DO UPDATE SET name = excluded.name, the primary key is the target when none is set.
+// Conflicting rows are skipped when there are no updates. Updates may reference the row proposed for insertion
+// through the excluded table
+type conflictClause struct {
+	target  []string
+	updates []*colUpdate
+}
+
+const excludedTable = "excluded"
+
+// index returns the unique index the conflicts are checked on
+func (c *conflictClause) index(table *Table) (*Index, error) {
+	if c.target == nil {
+		return table.primaryIndex, nil
+	}
+
+	cols := make([]*Column, len(c.target))
+
+	for i, colName := range c.target {
+		col, err := table.GetColumnByName(colName)
 		if err != nil {
 			return nil, err
 		}
 
-		err = e.deleteIndexEntries(pkEncVals, valuesByColID, table, summary)
-		if err != nil {
-			return nil, err
+		cols[i] = col
+	}
+
+	index, ok := table.indexes[indexKeyFrom(cols)]
+	if !ok || !index.IsUnique() {
+		return nil, ErrNoUniqueIndex
+	}
+
+	return index, nil
+}
+
+// resolve updates the existing row conflicting with the proposed values, if any.
+// Conflicts with rows proposed by the same statement are not resolved but reported when committed
+func (c *conflictClause) resolve(e *Engine, index *Index, valuesByColID map[uint32]TypedValue, params map[string]interface{}, returning *returningClause, summary *TxSummary) (conflicting bool, err error) {
+	table := index.table
+
+	for _, col := range index.cols {
+		_, specified := valuesByColID[col.id]
+		if !specified {
+			// e.g. auto-incremental primary keys, which are always assigned a new value
+			return false, nil
 		}
+	}
 
-		summary.updatedRows++
+	row, err := e.fetchIndexedRow(index, valuesByColID)
+	if err == ErrNoMoreRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
 	}
 
-	return summary, nil
+	if c.updates == nil {
+		return true, nil
+	}
+
+	excludedRow := &Row{Values: make(map[string]TypedValue, len(table.cols))}
+
+	for _, col := range table.cols {
+		val, notNull := valuesByColID[col.id]
+		if !notNull {
+			val = &NullValue{t: col.colType}
+		}
+
+		excludedRow.Values[EncodeSelector("", table.db.name, excludedTable, col.colName)] = val
+	}
+
+	updates := make([]*colUpdate, len(c.updates))
+
+	for i, update := range c.updates {
+		updates[i] = &colUpdate{
+			col: update.col,
+			op:  update.op,
+			val: update.val.reduceSelectors(excludedRow, table.db.name, table.name),
+		}
+	}
+
+	newValuesByColID, err := e.updateRow(table, table.name, row, updates, params, c.colsBySelector(table), summary)
+	if err != nil {
+		return true, err
+	}
+
+	if returning != nil {
+		returning.add(table, row, newValuesByColID, summary)
+	}
+
+	return true, nil
 }
 
-func (e *Engine) deleteIndexEntries(
-	pkEncVals []byte,
-	valuesByColID map[uint32]TypedValue,
-	table *Table,
-	summary *TxSummary) error {
+// colsBySelector returns the columns which can be referenced by the updates, i.e. the ones of the table
+// and the ones of the row proposed for insertion
+func (c *conflictClause) colsBySelector(table *Table) map[string]ColDescriptor {
+	cols := make(map[string]ColDescriptor, 2*len(table.cols))
 
-	for _, index := range table.indexes {
-		var prefix string
-		var encodedValues [][]byte
+	for _, asTable := range []string{table.name, excludedTable} {
+		for _, col := range table.cols {
+			des := ColDescriptor{Database: table.db.name, Table: asTable, Column: col.colName, Type: col.colType}
+			cols[des.Selector()] = des
+		}
+	}
 
-		if index.IsUnique() {
-			if index.IsPrimary() {
-				prefix = PIndexPrefix
-			} else {
-				prefix = UIndexPrefix
+	return cols
+}
+
+type RowSpec struct {
+	Values []ValueExp
+}
+
+func (stmt *UpsertIntoStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	if stmt.onConflict != nil {
+		table, err := stmt.tableRef.referencedTable(e, implicitDB)
+		if err != nil {
+			return err
+		}
+
+		cols := stmt.onConflict.colsBySelector(table)
+
+		for _, update := range stmt.onConflict.updates {
+			col, err := table.GetColumnByName(update.col)
+			if err != nil {
+				return err
 			}
 
-			encodedValues = make([][]byte, 3+len(index.cols))
-		} else {
-			prefix = SIndexPrefix
-			encodedValues = make([][]byte, 4+len(index.cols))
-			encodedValues[len(encodedValues)-1] = pkEncVals
+			err = update.val.requiresType(col.colType, cols, params, implicitDB.name, table.name)
+			if err != nil {
+				return err
+			}
 		}
+	}
 
-		encodedValues[0] = EncodeID(table.db.id)
-		encodedValues[1] = EncodeID(table.id)
-		encodedValues[2] = EncodeID(index.id)
+	for _, row := range stmt.rows {
+		if len(stmt.cols) != len(row.Values) {
+			return ErrIllegalArguments
+		}
 
-		// some rows might not indexed by every index
-		indexed := true
+		for i, val := range row.Values {
+			table, err := stmt.tableRef.referencedTable(e, implicitDB)
+			if err != nil {
+				return err
+			}
 
-		for i, col := range index.cols {
-			val, notNull := valuesByColID[col.id]
-			if !notNull {
-				indexed = false
-				break
+			col, err := table.GetColumnByName(stmt.cols[i])
+			if err != nil {
+				return err
+			}
+
+			err = val.requiresType(col.colType, make(map[string]ColDescriptor), params, implicitDB.name, table.name)
+			if err != nil {
+				return err
 			}
+		}
+	}
+
+	return nil
+}
 
-			encVal, _ := EncodeAsKey(val.Value(), col.colType, col.MaxLen())
+func (stmt *UpsertIntoStmt) validate(table *Table) (map[uint32]int, error) {
+	selPosByColID := make(map[uint32]int, len(stmt.cols))
 
-			encodedValues[i+3] = encVal
+	for i, c := range stmt.cols {
+		col, err := table.GetColumnByName(c)
+		if err != nil {
+			return nil, err
 		}
 
-		if !indexed {
-			continue
+		_, duplicated := selPosByColID[col.id]
+		if duplicated {
+			return nil, ErrDuplicatedColumn
 		}
 
-		ie := &store.EntrySpec{
-			Key:      e.mapKey(prefix, encodedValues...),
-			Metadata: store.NewKVMetadata().AsDeleted(true),
+		if col.generatedAs != nil {
+			return nil, fmt.Errorf("%w (%s)", ErrGeneratedColumnCanNotBeAssigned, col.colName)
 		}
 
-		summary.des = append(summary.des, ie)
+		selPosByColID[col.id] = i
 	}
 
-	return nil
+	return selPosByColID, nil
 }
 
-type ValueExp interface {
-	inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error)
-	requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error
-	substitute(params map[string]interface{}) (ValueExp, error)
-	reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error)
-	reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp
-	isConstant() bool
-	selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error
-}
+func (stmt *UpsertIntoStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	if implicitDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
 
-type typedValueRange struct {
-	lRange *typedValueSemiRange
-	hRange *typedValueSemiRange
+	summary = newTxSummary(implicitDB)
+
+	table, err := stmt.tableRef.referencedTable(e, implicitDB)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stmt.rows)*len(table.indexes) > e.dataStore.MaxTxEntries() {
+		return nil, ErrTooManyRows
+	}
+
+	selPosByColID, err := stmt.validate(table)
+	if err != nil {
+		return nil, err
+	}
+
+	returning, err := newReturningClause(stmt.returning)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflictIndex *Index
+
+	if stmt.onConflict != nil {
+		conflictIndex, err = stmt.onConflict.index(table)
+		if err != nil {
+			return nil, err
+		}
+
+		err = validateUpdates(table, stmt.onConflict.updates)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, row := range stmt.rows {
+		if len(row.Values) != len(stmt.cols) {
+			return nil, ErrInvalidNumberOfValues
+		}
+
+		valuesByColID := make(map[uint32]TypedValue)
+
+		for colID, col := range table.colsByID {
+			if col.generatedAs != nil {
+				// computed once all the other values are known
+				continue
+			}
+
+			colPos, specified := selPosByColID[colID]
+			if specified {
+				// the column list is shared by all rows, DEFAULT lets a row skip a listed column
+				_, isDefault := row.Values[colPos].(*DefaultValue)
+				specified = !isDefault
+			}
+
+			if !specified && col.defaultValue != nil {
+				rval, err := col.defaultValue.reduce(e.catalog, nil, implicitDB.name, table.name)
+				if err != nil {
+					return nil, err
+				}
+
+				valuesByColID[colID] = rval
+				continue
+			}
+
+			if !specified {
+				if col.notNull && !(stmt.isInsert && col.autoIncrement) {
+					return nil, ErrNotNullableColumnCannotBeNull
+				}
+				continue
+			}
+
+			if stmt.isInsert && col.autoIncrement {
+				return nil, ErrNoValueForAutoIncrementalColumn
+			}
+
+			cVal := row.Values[colPos]
+
+			val, err := cVal.substitute(params)
+			if err != nil {
+				return nil, err
+			}
+
+			rval, err := val.reduce(e.catalog, nil, implicitDB.name, table.name)
+			if err != nil {
+				return nil, err
+			}
+
+			_, isNull := rval.(*NullValue)
+			if isNull {
+				if col.notNull {
+					return nil, ErrNotNullableColumnCannotBeNull
+				}
+
+				continue
+			}
+
+			valuesByColID[colID] = e.truncatedValue(col, rval)
+		}
+
+		err = e.setGeneratedValues(table, valuesByColID)
+		if err != nil {
+			return nil, err
+		}
+
+		if stmt.onConflict != nil {
+			conflicting, err := stmt.onConflict.resolve(e, conflictIndex, valuesByColID, params, returning, summary)
+			if err != nil {
+				return nil, err
+			}
+
+			if conflicting {
+				continue
+			}
+		}
+
+		// inject auto-incremental pk value
+		if stmt.isInsert && table.autoIncrementPK {
+			table.maxPK++
+			e.catalog.mutated = true // TODO: implement transactional in-memory catalog
+
+			pkCol := table.primaryIndex.cols[0]
+
+			valuesByColID[pkCol.id] = &Number{val: table.maxPK}
+
+			summary.lastInsertedPKs[table.name] = table.maxPK
+		}
+
+		pkEncVals, err := encodedPK(table, valuesByColID)
+		if err != nil {
+			return nil, err
+		}
+
+		var oldRow *Row
+
+		// inserted rows can not exist already
+		if returning != nil && returning.old && !stmt.isInsert {
+			oldRow, err = e.fetchPKRow(table, valuesByColID)
+			if err != nil && err != ErrNoMoreRows {
+				return nil, err
+			}
+		}
+
+		err = e.doUpsert(pkEncVals, valuesByColID, table, stmt.isInsert, summary)
+		if err != nil {
+			return nil, err
+		}
+
+		if returning != nil {
+			returning.add(table, oldRow, valuesByColID, summary)
+		}
+	}
+
+	return summary, nil
+}
+
+// encodeRow encodes the values of a row as stored in its primary index entry {count (colID valLen val)+},
+// NULL values are omitted
+func (e *Engine) encodeRow(table *Table, valuesByColID map[uint32]TypedValue) ([]byte, error) {
+	valbuf := bytes.Buffer{}
+
+	b := make([]byte, EncLenLen)
+	binary.BigEndian.PutUint32(b, uint32(len(valuesByColID)))
+
+	_, err := valbuf.Write(b)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, col := range table.cols {
+		rval, notNull := valuesByColID[col.id]
+		if !notNull {
+			continue
+		}
+
+		b := make([]byte, EncIDLen)
+		binary.BigEndian.PutUint32(b, uint32(col.id))
+
+		_, err = valbuf.Write(b)
+		if err != nil {
+			return nil, err
+		}
+
+		encVal, err := EncodeValue(rval.Value(), col.colType, e.valueMaxLen(col))
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = valbuf.Write(encVal)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return valbuf.Bytes(), nil
+}
+
+func (e *Engine) doUpsert(pkEncVals []byte, valuesByColID map[uint32]TypedValue, table *Table, isInsert bool, summary *TxSummary) error {
+	var reusableIndexEntries map[uint32]struct{}
+
+	if !isInsert && len(table.indexes) > 1 {
+		currPKRow, err := e.fetchPKRow(table, valuesByColID)
+		if err != nil && err != ErrNoMoreRows {
+			return err
+		}
+
+		if err == nil {
+			currValuesByColID := make(map[uint32]TypedValue, len(currPKRow.Values))
+
+			for _, col := range table.cols {
+				encSel := EncodeSelector("", table.db.name, table.name, col.colName)
+				currValuesByColID[col.id] = currPKRow.Values[encSel]
+			}
+
+			reusableIndexEntries, err = e.deprecateIndexEntries(pkEncVals, currValuesByColID, valuesByColID, table, summary)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// create primary index entry
+	mkey := e.mapKey(PIndexPrefix, EncodeID(table.db.id), EncodeID(table.id), EncodeID(table.primaryIndex.id), pkEncVals)
+
+	var constraint store.KVConstraint
+
+	if isInsert && !table.autoIncrementPK {
+		constraint = store.MustNotExistOrDeleted
+	}
+
+	if !isInsert && table.autoIncrementPK {
+		constraint = store.MustExist
+	}
+
+	encRow, err := e.encodeRow(table, valuesByColID)
+	if err != nil {
+		return err
+	}
+
+	pke := &store.EntrySpec{
+		Key:        mkey,
+		Value:      encRow,
+		Constraint: constraint,
+	}
+	summary.des = append(summary.des, pke)
+
+	// create entries for secondary indexes
+	for _, index := range table.indexes {
+		if index.IsPrimary() {
+			continue
+		}
+
+		if reusableIndexEntries != nil {
+			_, reusable := reusableIndexEntries[index.id]
+			if reusable {
+				continue
+			}
+		}
+
+		for _, col := range index.cols {
+			if col.MaxLen() > maxKeyLen {
+				return ErrMaxKeyLengthExceeded
+			}
+		}
+
+		ikey, err := e.indexEntryKey(index, pkEncVals, valuesByColID)
+		if err != nil {
+			return err
+		}
+
+		var val []byte
+		var constraint store.KVConstraint
+
+		if index.IsUnique() {
+			val = pkEncVals
+			constraint = store.MustNotExistOrDeleted
+		}
+
+		ie := &store.EntrySpec{
+			Key:        ikey,
+			Value:      val,
+			Constraint: constraint,
+		}
+
+		summary.des = append(summary.des, ie)
+	}
+
+	summary.updatedRows++
+
+	return nil
+}
+
+func encodedPK(table *Table, valuesByColID map[uint32]TypedValue) ([]byte, error) {
+	valbuf := bytes.Buffer{}
+
+	for _, col := range table.primaryIndex.cols {
+		rval, notNull := valuesByColID[col.id]
+		if !notNull {
+			return nil, ErrPKCanNotBeNull
+		}
+
+		encVal, err := EncodeAsKey(rval.Value(), col.colType, col.MaxLen())
+		if err != nil {
+			return nil, err
+		}
+
+		if len(encVal) > maxKeyLen {
+			return nil, ErrMaxKeyLengthExceeded
+		}
+
+		_, err = valbuf.Write(encVal)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return valbuf.Bytes(), nil
+}
+
+// indexEntryKey returns the key of the entry of an index for a row. Keys of non-unique indexes include the
+// encoded primary key of the row, as do the ones holding NULLs, which are distinct from each other
+func (e *Engine) indexEntryKey(index *Index, pkEncVals []byte, valuesByColID map[uint32]TypedValue) ([]byte, error) {
+	encVals, hasNulls, err := index.encodeKeyValues(valuesByColID)
+	if err != nil {
+		return nil, err
+	}
+
+	encodedValues := make([][]byte, 0, 4+len(encVals))
+	encodedValues = append(encodedValues, EncodeID(index.table.db.id), EncodeID(index.table.id), EncodeID(index.id))
+	encodedValues = append(encodedValues, encVals...)
+
+	if !index.IsUnique() || hasNulls {
+		encodedValues = append(encodedValues, pkEncVals)
+	}
+
+	return e.mapKey(index.prefix(), encodedValues...), nil
+}
+
+func (e *Engine) fetchPKRow(table *Table, valuesByColID map[uint32]TypedValue) (*Row, error) {
+	return e.fetchIndexedRow(table.primaryIndex, valuesByColID)
+}
+
+// fetchIndexedRow returns the latest row holding the values of the columns of the unique index
+func (e *Engine) fetchIndexedRow(index *Index, valuesByColID map[uint32]TypedValue) (*Row, error) {
+	table := index.table
+
+	ranges := make(map[uint32]*typedValueRange, len(index.cols))
+
+	for _, col := range index.cols {
+		val := valuesByColID[col.id]
+
+		ranges[col.id] = &typedValueRange{
+			lRange: &typedValueSemiRange{val: val, inclusive: true},
+			hRange: &typedValueSemiRange{val: val, inclusive: true},
+		}
+	}
+
+	scanSpecs := &ScanSpecs{
+		index:         index,
+		rangesByColID: ranges,
+	}
+
+	lastTxID, _ := e.dataStore.Alh()
+	err := e.dataStore.WaitForIndexingUpto(lastTxID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := e.dataStore.CurrentSnapshot()
+	defer func() {
+		snapshot.Close()
+	}()
+
+	r, err := e.newRawRowReader(snapshot, table, 0, table.name, scanSpecs)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		r.Close()
+	}()
+
+	return r.Read()
+}
+
+// deprecateIndexEntries mark previous index entries as deleted
+func (e *Engine) deprecateIndexEntries(
+	pkEncVals []byte,
+	currValuesByColID, newValuesByColID map[uint32]TypedValue,
+	table *Table,
+	summary *TxSummary) (reusableIndexEntries map[uint32]struct{}, err error) {
+
+	reusableIndexEntries = make(map[uint32]struct{})
+
+	for _, index := range table.indexes {
+		if index.IsPrimary() {
+			continue
+		}
+
+		// existent index entry is deleted only if it differs from existent one
+		sameIndexKey := true
+
+		for _, col := range index.cols {
+			currVal, notNull := currValuesByColID[col.id]
+			if !notNull {
+				return nil, ErrCorruptedData
+			}
+
+			newVal, notNull := newValuesByColID[col.id]
+			if !notNull && index.IsNullable() {
+				newVal = &NullValue{t: col.colType}
+				notNull = true
+			}
+
+			if notNull {
+				r, err := currVal.Compare(newVal)
+				if err != nil {
+					return nil, err
+				}
+
+				sameIndexKey = sameIndexKey && r == 0
+			}
+		}
+
+		// mark existent index entry as deleted
+		if sameIndexKey {
+			reusableIndexEntries[index.id] = struct{}{}
+		} else {
+			ikey, err := e.indexEntryKey(index, pkEncVals, currValuesByColID)
+			if err != nil {
+				return nil, ErrCorruptedData
+			}
+
+			ie := &store.EntrySpec{
+				Key:      ikey,
+				Metadata: store.NewKVMetadata().AsDeleted(true),
+			}
+
+			summary.des = append(summary.des, ie)
+		}
+	}
+
+	return reusableIndexEntries, nil
+}
+
+type UpdateStmt struct {
+	tableRef     *tableRef
+	where        ValueExp
+	currentOf    ValueExp // the cursor returned by RowReader.Cursor, positioned at the row to be updated
+	updates      []*colUpdate
+	tupleUpdates []*tupleUpdate
+	indexOn      []string
+	limit        int
+}
+
+type colUpdate struct {
+	col string
+	op  CmpOperator
+	val ValueExp
+}
+
+// tupleUpdate assigns several columns at once e.g. SET (a, b) = (1, 2),
+// values are taken from the single row returned by q when it's set e.g. SET (a, b) = (SELECT x, y FROM t)
+type tupleUpdate struct {
+	cols []string
+	op   CmpOperator
+	vals []ValueExp
+	q    *SelectStmt
+}
+
+// colUpdates returns the assignment of each column of the tuple, the query is evaluated only once
+// thus its values are the same for every updated row. Columns are set to NULL when the query returns no rows
+func (tu *tupleUpdate) colUpdates(e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}) ([]*colUpdate, error) {
+	vals := tu.vals
+
+	if tu.q != nil {
+		qvals, err := tu.queryValues(e, snap, implicitDB, params)
+		if err != nil {
+			return nil, err
+		}
+
+		vals = qvals
+	}
+
+	if len(tu.cols) != len(vals) {
+		return nil, ErrInvalidNumberOfValues
+	}
+
+	updates := make([]*colUpdate, len(tu.cols))
+
+	for i, col := range tu.cols {
+		updates[i] = &colUpdate{col: col, op: tu.op, val: vals[i]}
+	}
+
+	return updates, nil
+}
+
+func (tu *tupleUpdate) queryValues(e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}) ([]ValueExp, error) {
+	rowReader, err := tu.q.Resolve(e, snap, implicitDB, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rowReader.Close()
+
+	cols, err := rowReader.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	vals := make([]ValueExp, len(cols))
+
+	row, err := rowReader.Read()
+	if err == ErrNoMoreRows {
+		for i := range cols {
+			vals[i] = &NullValue{t: AnyType}
+		}
+
+		return vals, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = rowReader.Read()
+	if err == nil {
+		return nil, ErrMoreThanOneRow
+	}
+	if err != ErrNoMoreRows {
+		return nil, err
+	}
+
+	for i, col := range cols {
+		vals[i] = row.Values[col.Selector()]
+	}
+
+	return vals, nil
+}
+
+func (stmt *UpdateStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	if stmt.currentOf != nil {
+		err := stmt.currentOf.requiresType(BLOBType, make(map[string]ColDescriptor), params, implicitDB.name, stmt.tableRef.table)
+		if err != nil {
+			return err
+		}
+	}
+
+	selectStmt := &SelectStmt{
+		ds:    stmt.tableRef,
+		where: stmt.where,
+	}
+
+	err := selectStmt.inferParameters(e, implicitDB, params)
+	if err != nil {
+		return err
+	}
+
+	table, err := stmt.tableRef.referencedTable(e, implicitDB)
+	if err != nil {
+		return err
+	}
+
+	updates := append([]*colUpdate{}, stmt.updates...)
+
+	for _, tu := range stmt.tupleUpdates {
+		if tu.q != nil {
+			// values returned by the query are type checked once assigned
+			err = tu.q.inferParameters(e, implicitDB, params)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		tupleUpdates, err := tu.colUpdates(e, nil, implicitDB, nil)
+		if err != nil {
+			return err
+		}
+
+		updates = append(updates, tupleUpdates...)
+	}
+
+	for _, update := range updates {
+		col, err := table.GetColumnByName(update.col)
+		if err != nil {
+			return err
+		}
+
+		err = update.val.requiresType(col.colType, make(map[string]ColDescriptor), params, implicitDB.name, table.name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateUpdates(table *Table, updates []*colUpdate) error {
+	colIDs := make(map[uint32]struct{}, len(updates))
+
+	for _, update := range updates {
+		if update.op != EQ {
+			return ErrIllegalArguments
+		}
+
+		col, err := table.GetColumnByName(update.col)
+		if err != nil {
+			return err
+		}
+
+		if table.PrimaryIndex().IncludesCol(col.id) {
+			return ErrPKCanNotBeUpdated
+		}
+
+		if col.generatedAs != nil {
+			return fmt.Errorf("%w (%s)", ErrGeneratedColumnCanNotBeAssigned, col.colName)
+		}
+
+		_, duplicated := colIDs[col.id]
+		if duplicated {
+			return ErrDuplicatedColumn
+		}
+
+		colIDs[col.id] = struct{}{}
+	}
+
+	return nil
+}
+
+func (stmt *UpdateStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	if implicitDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
+
+	err = e.renewSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	updates := append([]*colUpdate{}, stmt.updates...)
+
+	for _, tu := range stmt.tupleUpdates {
+		tupleUpdates, err := tu.colUpdates(e, e.snapshot, implicitDB, params)
+		if err != nil {
+			return nil, err
+		}
+
+		updates = append(updates, tupleUpdates...)
+	}
+
+	where := stmt.where
+
+	if stmt.currentOf != nil {
+		where, err = e.currentOfCond(e.snapshot, implicitDB, stmt.tableRef, stmt.currentOf, params)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	selectStmt := &SelectStmt{
+		ds:       stmt.tableRef,
+		where:    where,
+		indexOn:  stmt.indexOn,
+		limit:    stmt.limit,
+		hasLimit: stmt.limit > 0,
+	}
+
+	rowReader, err := selectStmt.Resolve(e, e.snapshot, implicitDB, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rowReader.Close()
+
+	table := rowReader.ScanSpecs().index.table
+
+	err = validateUpdates(table, updates)
+	if err != nil {
+		return nil, err
+	}
+
+	cols, err := rowReader.colsBySelector()
+	if err != nil {
+		return nil, err
+	}
+
+	summary = newTxSummary(implicitDB)
+
+	for {
+		if summary.updatedRows*len(table.indexes) > e.dataStore.MaxTxEntries() {
+			return nil, ErrTooManyRows
+		}
+
+		row, err := rowReader.Read()
+		if err == ErrNoMoreRows {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = e.updateRow(table, table.name, row, updates, params, cols, summary)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return summary, nil
+}
+
+// updateRow writes the row read from the table, referenced as asTable, with the assignments applied
+// updateRow writes the row with the updates applied, the values of the updated row are returned
+func (e *Engine) updateRow(table *Table, asTable string, row *Row, updates []*colUpdate, params map[string]interface{}, cols map[string]ColDescriptor, summary *TxSummary) (map[uint32]TypedValue, error) {
+	valuesByColID := make(map[uint32]TypedValue, len(row.Values))
+
+	for _, col := range table.cols {
+		encSel := EncodeSelector("", table.db.name, asTable, col.colName)
+
+		// null values are not stored
+		val := row.Values[encSel]
+		if _, isNull := val.(*NullValue); isNull {
+			continue
+		}
+
+		valuesByColID[col.id] = val
+	}
+
+	// assignments are reduced against the row as it was read, thus they are simultaneous
+	// e.g. SET a = b, b = a swaps both values
+	for _, update := range updates {
+		col, err := table.GetColumnByName(update.col)
+		if err != nil {
+			return nil, err
+		}
+
+		sval, err := update.val.substitute(params)
+		if err != nil {
+			return nil, err
+		}
+
+		rval, err := sval.reduce(e.catalog, row, table.db.name, asTable)
+		if err != nil {
+			return nil, err
+		}
+
+		err = rval.requiresType(col.colType, cols, nil, table.db.name, asTable)
+		if err != nil {
+			return nil, err
+		}
+
+		_, isNull := rval.(*NullValue)
+		if isNull {
+			if col.notNull {
+				return nil, ErrNotNullableColumnCannotBeNull
+			}
+
+			delete(valuesByColID, col.id)
+			continue
+		}
+
+		valuesByColID[col.id] = e.truncatedValue(col, rval)
+	}
+
+	err := e.setGeneratedValues(table, valuesByColID)
+	if err != nil {
+		return nil, err
+	}
+
+	pkEncVals, err := encodedPK(table, valuesByColID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = e.doUpsert(pkEncVals, valuesByColID, table, false, summary)
+	if err != nil {
+		return nil, err
+	}
+
+	return valuesByColID, nil
+}
+
+// MergeStmt updates the rows of the target table matching a row of the source when WHEN MATCHED is set, and inserts
+// a row for every source row without matches when WHEN NOT MATCHED is set. Columns of the source must be qualified
+// with its name or alias, unqualified columns refer to the target table.
+// Every source row is matched against the target table as it was before the statement
+type MergeStmt struct {
+	target *tableRef
+	source DataSource
+	on     ValueExp
+
+	updates []*colUpdate // nil when WHEN MATCHED is not set
+
+	insertCols   []string // nil when WHEN NOT MATCHED is not set
+	insertValues []ValueExp
+}
+
+// jointStmt joins the target table with the source, so the columns of both can be referenced
+func (stmt *MergeStmt) jointStmt() *SelectStmt {
+	return &SelectStmt{
+		ds:    stmt.target,
+		joins: []*JoinSpec{{joinType: InnerJoin, ds: stmt.source, cond: stmt.on}},
+	}
+}
+
+func (stmt *MergeStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	if implicitDB == nil {
+		return ErrNoDatabaseSelected
+	}
+
+	table, err := stmt.target.referencedTable(e, implicitDB)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := e.getSnapshot()
+	if err != nil {
+		return err
+	}
+
+	rowReader, err := stmt.jointStmt().Resolve(e, snapshot, implicitDB, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer rowReader.Close()
+
+	err = rowReader.InferParameters(params)
+	if err != nil {
+		return err
+	}
+
+	cols, err := rowReader.colsBySelector()
+	if err != nil {
+		return err
+	}
+
+	for _, update := range stmt.updates {
+		col, err := table.GetColumnByName(update.col)
+		if err != nil {
+			return err
+		}
+
+		err = update.val.requiresType(col.colType, cols, params, implicitDB.name, stmt.target.Alias())
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(stmt.insertCols) != len(stmt.insertValues) {
+		return ErrInvalidNumberOfValues
+	}
+
+	for i, val := range stmt.insertValues {
+		col, err := table.GetColumnByName(stmt.insertCols[i])
+		if err != nil {
+			return err
+		}
+
+		err = val.requiresType(col.colType, cols, params, implicitDB.name, stmt.target.Alias())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (stmt *MergeStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	if implicitDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
+
+	table, err := stmt.target.referencedTable(e, implicitDB)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateUpdates(table, stmt.updates)
+	if err != nil {
+		return nil, err
+	}
+
+	insertStmt := &UpsertIntoStmt{
+		isInsert: true,
+		tableRef: &tableRef{db: table.db.name, table: table.name},
+		cols:     stmt.insertCols,
+	}
+
+	_, err = insertStmt.validate(table)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stmt.insertCols) != len(stmt.insertValues) {
+		return nil, ErrInvalidNumberOfValues
+	}
+
+	err = e.renewSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	sourceReader, err := (&SelectStmt{ds: stmt.source}).Resolve(e, e.snapshot, implicitDB, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer sourceReader.Close()
+
+	summary = newTxSummary(implicitDB)
+
+	for {
+		if (summary.updatedRows+len(insertStmt.rows))*len(table.indexes) > e.dataStore.MaxTxEntries() {
+			return nil, ErrTooManyRows
+		}
+
+		sourceRow, err := sourceReader.Read()
+		if err == ErrNoMoreRows {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		matched, err := stmt.updateMatchingRows(e, implicitDB, table, sourceRow, params, summary)
+		if err != nil {
+			return nil, err
+		}
+
+		if matched || stmt.insertCols == nil {
+			continue
+		}
+
+		values := make([]ValueExp, len(stmt.insertValues))
+
+		for i, val := range stmt.insertValues {
+			values[i] = val.reduceSelectors(sourceRow, implicitDB.name, stmt.target.Alias())
+		}
+
+		insertStmt.rows = append(insertStmt.rows, &RowSpec{Values: values})
+	}
+
+	if len(insertStmt.rows) > 0 {
+		insertSummary, err := insertStmt.compileUsing(e, implicitDB, params)
+		if err != nil {
+			return nil, err
+		}
+
+		err = summary.add(insertSummary)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	e.deferUniqueChecks(summary)
+
+	return summary, nil
+}
+
+// updateMatchingRows applies the updates, if any, to the rows of the target table matching the source row
+func (stmt *MergeStmt) updateMatchingRows(e *Engine, implicitDB *Database, table *Table, sourceRow *Row, params map[string]interface{}, summary *TxSummary) (matched bool, err error) {
+	targetAlias := stmt.target.Alias()
+
+	selectStmt := &SelectStmt{
+		ds:    stmt.target,
+		where: stmt.on.reduceSelectors(sourceRow, implicitDB.name, targetAlias),
+	}
+
+	rowReader, err := selectStmt.Resolve(e, e.snapshot, implicitDB, params, nil)
+	if err != nil {
+		return false, err
+	}
+	defer rowReader.Close()
+
+	cols, err := rowReader.colsBySelector()
+	if err != nil {
+		return false, err
+	}
+
+	updates := make([]*colUpdate, len(stmt.updates))
+
+	for i, update := range stmt.updates {
+		updates[i] = &colUpdate{
+			col: update.col,
+			op:  update.op,
+			val: update.val.reduceSelectors(sourceRow, implicitDB.name, targetAlias),
+		}
+	}
+
+	for {
+		row, err := rowReader.Read()
+		if err == ErrNoMoreRows {
+			return matched, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		matched = true
+
+		if stmt.updates == nil {
+			return matched, nil
+		}
+
+		_, err = e.updateRow(table, targetAlias, row, updates, params, cols, summary)
+		if err != nil {
+			return false, err
+		}
+	}
+}
+
+type DeleteFromStmt struct {
+	tableRef  *tableRef
+	where     ValueExp
+	currentOf ValueExp // the cursor returned by RowReader.Cursor, positioned at the row to be deleted
+	indexOn   []string
+	limit     int
+}
+
+func (stmt *DeleteFromStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	if stmt.currentOf != nil {
+		err := stmt.currentOf.requiresType(BLOBType, make(map[string]ColDescriptor), params, implicitDB.name, stmt.tableRef.table)
+		if err != nil {
+			return err
+		}
+	}
+
+	selectStmt := &SelectStmt{
+		ds:    stmt.tableRef,
+		where: stmt.where,
+	}
+	return selectStmt.inferParameters(e, implicitDB, params)
+}
+
+func (stmt *DeleteFromStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	if implicitDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
+
+	err = e.renewSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	where := stmt.where
+
+	if stmt.currentOf != nil {
+		where, err = e.currentOfCond(e.snapshot, implicitDB, stmt.tableRef, stmt.currentOf, params)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	selectStmt := &SelectStmt{
+		ds:       stmt.tableRef,
+		where:    where,
+		indexOn:  stmt.indexOn,
+		limit:    stmt.limit,
+		hasLimit: stmt.limit > 0,
+	}
+
+	rowReader, err := selectStmt.Resolve(e, e.snapshot, implicitDB, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rowReader.Close()
+
+	table := rowReader.ScanSpecs().index.table
+
+	summary = newTxSummary(implicitDB)
+
+	for {
+		if summary.updatedRows*len(table.indexes) > e.dataStore.MaxTxEntries() {
+			return nil, ErrTooManyRows
+		}
+
+		row, err := rowReader.Read()
+		if err == ErrNoMoreRows {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		valuesByColID := make(map[uint32]TypedValue, len(row.Values))
+
+		for _, col := range table.cols {
+			encSel := EncodeSelector("", table.db.name, table.name, col.colName)
+			valuesByColID[col.id] = row.Values[encSel]
+		}
+
+		pkEncVals, err := encodedPK(table, valuesByColID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = e.deleteIndexEntries(pkEncVals, valuesByColID, table, summary)
+		if err != nil {
+			return nil, err
+		}
+
+		summary.updatedRows++
+	}
+
+	return summary, nil
+}
+
+// currentOfCond returns the condition selecting the row a cursor is positioned at, the row is identified
+// by its primary key thus it's still selected after the values indexed by the scanned index are updated
+func (e *Engine) currentOfCond(snap *store.Snapshot, implicitDB *Database, tableRef *tableRef, currentOf ValueExp, params map[string]interface{}) (ValueExp, error) {
+	table, err := tableRef.referencedTable(e, implicitDB)
+	if err != nil {
+		return nil, err
+	}
+
+	exp, err := currentOf.substitute(params)
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := exp.reduce(e.catalog, nil, implicitDB.name, table.name)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, ok := val.Value().([]byte)
+	if !ok || len(cursor) == 0 {
+		return nil, fmt.Errorf("%w (cursor must be a non-empty BLOB)", ErrIllegalArguments)
+	}
+
+	var index *Index
+
+	for _, idx := range table.indexes {
+		prefix := e.mapKey(idx.prefix(), EncodeID(table.db.id), EncodeID(table.id), EncodeID(idx.id))
+
+		if bytes.HasPrefix(cursor, prefix) {
+			index = idx
+			break
+		}
+	}
+
+	if index == nil {
+		return nil, fmt.Errorf("%w (cursor does not belong to table %s)", ErrIllegalArguments, table.name)
+	}
+
+	pkKey := cursor
+
+	if !index.IsPrimary() {
+		var encPKVals []byte
+
+		if index.IsUnique() {
+			vref, err := snap.Get(cursor, store.IgnoreDeleted)
+			if errors.Is(err, store.ErrKeyNotFound) {
+				// the row is not there anymore
+				return &Bool{val: false}, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			encPKVals, err = vref.Resolve()
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			encPKVals, err = e.unmapIndexEntry(index, cursor)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		pkKey = e.mapKey(PIndexPrefix, EncodeID(table.db.id), EncodeID(table.id), EncodeID(PKIndexID), encPKVals)
+	}
+
+	row, err := e.decodeIndexKey(table, table.name, table.primaryIndex, pkKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var cond ValueExp
+
+	for _, col := range table.primaryIndex.cols {
+		colCond := &CmpBoolExp{
+			op:    EQ,
+			left:  &ColSelector{col: col.colName},
+			right: row.Values[EncodeSelector("", table.db.name, table.name, col.colName)],
+		}
+
+		if cond == nil {
+			cond = colCond
+			continue
+		}
+
+		cond = &BinBoolExp{op: AND, left: cond, right: colCond}
+	}
+
+	return cond, nil
+}
+
+func (e *Engine) deleteIndexEntries(
+	pkEncVals []byte,
+	valuesByColID map[uint32]TypedValue,
+	table *Table,
+	summary *TxSummary) error {
+
+	for _, index := range table.indexes {
+		ikey, err := e.indexEntryKey(index, pkEncVals, valuesByColID)
+		// some rows might not indexed by every index
+		if err == ErrIndexedColumnCanNotBeNull {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		ie := &store.EntrySpec{
+			Key:      ikey,
+			Metadata: store.NewKVMetadata().AsDeleted(true),
+		}
+
+		summary.des = append(summary.des, ie)
+	}
+
+	return nil
+}
+
+type ValueExp interface {
+	inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error)
+	requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error
+	substitute(params map[string]interface{}) (ValueExp, error)
+	reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error)
+	reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp
+	isConstant() bool
+	selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error
+}
+
+type typedValueRange struct {
+	lRange *typedValueSemiRange
+	hRange *typedValueSemiRange
+}
+
+type typedValueSemiRange struct {
+	val       TypedValue
+	inclusive bool
+}
+
+func (r *typedValueRange) unitary() bool {
+	// TODO: this simplified implementation doesn't cover all unitary cases e.g. 3<=v<4
+	if r.lRange == nil || r.hRange == nil {
+		return false
+	}
+
+	res, _ := r.lRange.val.Compare(r.hRange.val)
+	return res == 0 && r.lRange.inclusive && r.hRange.inclusive
+}
+
+func (r *typedValueRange) refineWith(refiningRange *typedValueRange) error {
+	if r.lRange == nil {
+		r.lRange = refiningRange.lRange
+	} else if r.lRange != nil && refiningRange.lRange != nil {
+		maxRange, err := maxSemiRange(r.lRange, refiningRange.lRange)
+		if err != nil {
+			return err
+		}
+		r.lRange = maxRange
+	}
+
+	if r.hRange == nil {
+		r.hRange = refiningRange.hRange
+	} else if r.hRange != nil && refiningRange.hRange != nil {
+		minRange, err := minSemiRange(r.hRange, refiningRange.hRange)
+		if err != nil {
+			return err
+		}
+		r.hRange = minRange
+	}
+
+	return nil
+}
+
+func (r *typedValueRange) extendWith(extendingRange *typedValueRange) error {
+	if r.lRange == nil || extendingRange.lRange == nil {
+		r.lRange = nil
+	} else {
+		minRange, err := minSemiRange(r.lRange, extendingRange.lRange)
+		if err != nil {
+			return err
+		}
+		r.lRange = minRange
+	}
+
+	if r.hRange == nil || extendingRange.hRange == nil {
+		r.hRange = nil
+	} else {
+		maxRange, err := maxSemiRange(r.hRange, extendingRange.hRange)
+		if err != nil {
+			return err
+		}
+		r.hRange = maxRange
+	}
+
+	return nil
+}
+
+func maxSemiRange(or1, or2 *typedValueSemiRange) (*typedValueSemiRange, error) {
+	r, err := or1.val.Compare(or2.val)
+	if err != nil {
+		return nil, err
+	}
+
+	maxVal := or1.val
+	if r < 0 {
+		maxVal = or2.val
+	}
+
+	return &typedValueSemiRange{
+		val:       maxVal,
+		inclusive: or1.inclusive && or2.inclusive,
+	}, nil
+}
+
+func minSemiRange(or1, or2 *typedValueSemiRange) (*typedValueSemiRange, error) {
+	r, err := or1.val.Compare(or2.val)
+	if err != nil {
+		return nil, err
+	}
+
+	minVal := or1.val
+	if r > 0 {
+		minVal = or2.val
+	}
+
+	return &typedValueSemiRange{
+		val:       minVal,
+		inclusive: or1.inclusive || or2.inclusive,
+	}, nil
+}
+
+type TypedValue interface {
+	ValueExp
+	Type() SQLValueType
+	Value() interface{}
+	// Compare returns -1, 0 or 1 when the value is lower, equal or greater than val.
+	// A NULL value is equal to any other NULL value and lower than any other value of the same type.
+	Compare(val TypedValue) (int, error)
+}
+
+// compareNulls compares v and val when any of them is NULL, handled is false when both are non-null values
+// so the comparison is left to the caller. Values of different types are not comparable, NULL or not,
+// unless their type is not yet known.
+func compareNulls(v, val TypedValue) (cmp int, handled bool, err error) {
+	if v.Type() != AnyType && val.Type() != AnyType && v.Type() != val.Type() {
+		return 0, true, ErrNotComparableValues
+	}
+
+	vIsNull := v.Value() == nil
+	valIsNull := val.Value() == nil
+
+	switch {
+	case vIsNull && valIsNull:
+		return 0, true, nil
+	case vIsNull:
+		return -1, true, nil
+	case valIsNull:
+		return 1, true, nil
+	}
+
+	return 0, false, nil
+}
+
+type NullValue struct {
+	t SQLValueType
+}
+
+func (n *NullValue) Type() SQLValueType {
+	return n.t
+}
+
+func (n *NullValue) Value() interface{} {
+	return nil
+}
+
+func (n *NullValue) Compare(val TypedValue) (int, error) {
+	cmp, _, err := compareNulls(n, val)
+	return cmp, err
+}
+
+func (v *NullValue) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return v.t, nil
+}
+
+func (v *NullValue) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if v.t == t {
+		return nil
+	}
+
+	if v.t != AnyType {
+		return ErrInvalidTypes
+	}
+
+	v.t = t
+
+	return nil
+}
+
+func (v *NullValue) substitute(params map[string]interface{}) (ValueExp, error) {
+	return v, nil
+}
+
+func (v *NullValue) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return v, nil
+}
+
+func (v *NullValue) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return v
+}
+
+func (v *NullValue) isConstant() bool {
+	return true
+}
+
+func (v *NullValue) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+// DefaultValue stands for the DEFAULT keyword used as a row value,
+// the column is then filled as if it were omitted from the column list i.e. with its default value, if any
+type DefaultValue struct{}
+
+func (v *DefaultValue) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return AnyType, nil
+}
+
+func (v *DefaultValue) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	return nil
+}
+
+func (v *DefaultValue) substitute(params map[string]interface{}) (ValueExp, error) {
+	return v, nil
+}
+
+func (v *DefaultValue) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return nil, fmt.Errorf("%w (DEFAULT can only be used as a value in INSERT or UPSERT statements)", ErrIllegalArguments)
+}
+
+func (v *DefaultValue) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return v
+}
+
+func (v *DefaultValue) isConstant() bool {
+	return true
+}
+
+func (v *DefaultValue) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+type Number struct {
+	val int64
+}
+
+func (v *Number) Type() SQLValueType {
+	return IntegerType
+}
+
+func (v *Number) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return IntegerType, nil
+}
+
+func (v *Number) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != IntegerType {
+		return ErrInvalidTypes
+	}
+
+	return nil
+}
+
+func (v *Number) substitute(params map[string]interface{}) (ValueExp, error) {
+	return v, nil
+}
+
+func (v *Number) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return v, nil
+}
+
+func (v *Number) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return v
+}
+
+func (v *Number) isConstant() bool {
+	return true
+}
+
+func (v *Number) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+func (v *Number) Value() interface{} {
+	return v.val
+}
+
+func (v *Number) Compare(val TypedValue) (int, error) {
+	cmp, handled, err := compareNulls(v, val)
+	if handled {
+		return cmp, err
+	}
+
+	rval := val.Value().(int64)
+
+	if v.val == rval {
+		return 0, nil
+	}
+
+	if v.val > rval {
+		return 1, nil
+	}
+
+	return -1, nil
+}
+
+type Timestamp struct {
+	val time.Time
+}
+
+func (v *Timestamp) Type() SQLValueType {
+	return TimestampType
+}
+
+func (v *Timestamp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return TimestampType, nil
+}
+
+func (v *Timestamp) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != TimestampType {
+		return ErrInvalidTypes
+	}
+
+	return nil
+}
+
+func (v *Timestamp) substitute(params map[string]interface{}) (ValueExp, error) {
+	return v, nil
+}
+
+func (v *Timestamp) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return v, nil
+}
+
+func (v *Timestamp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return v
+}
+
+func (v *Timestamp) isConstant() bool {
+	return true
+}
+
+func (v *Timestamp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+func (v *Timestamp) Value() interface{} {
+	return v.val
+}
+
+func (v *Timestamp) Compare(val TypedValue) (int, error) {
+	cmp, handled, err := compareNulls(v, val)
+	if handled {
+		return cmp, err
+	}
+
+	rval := val.Value().(time.Time)
+
+	if v.val.Equal(rval) {
+		return 0, nil
+	}
+
+	if v.val.After(rval) {
+		return 1, nil
+	}
+
+	return -1, nil
+}
+
+type Varchar struct {
+	val string
+}
+
+func (v *Varchar) Type() SQLValueType {
+	return VarcharType
+}
+
+func (v *Varchar) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return VarcharType, nil
+}
+
+func (v *Varchar) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != VarcharType {
+		return ErrInvalidTypes
+	}
+
+	return nil
+}
+
+func (v *Varchar) substitute(params map[string]interface{}) (ValueExp, error) {
+	return v, nil
+}
+
+func (v *Varchar) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return v, nil
+}
+
+func (v *Varchar) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return v
+}
+
+func (v *Varchar) isConstant() bool {
+	return true
+}
+
+func (v *Varchar) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+func (v *Varchar) Value() interface{} {
+	return v.val
+}
+
+func (v *Varchar) Compare(val TypedValue) (int, error) {
+	cmp, handled, err := compareNulls(v, val)
+	if handled {
+		return cmp, err
+	}
+
+	rval := val.Value().(string)
+
+	return bytes.Compare([]byte(v.val), []byte(rval)), nil
+}
+
+type Bool struct {
+	val bool
+}
+
+func (v *Bool) Type() SQLValueType {
+	return BooleanType
+}
+
+func (v *Bool) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return BooleanType, nil
+}
+
+func (v *Bool) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != BooleanType {
+		return ErrInvalidTypes
+	}
+
+	return nil
+}
+
+func (v *Bool) substitute(params map[string]interface{}) (ValueExp, error) {
+	return v, nil
+}
+
+func (v *Bool) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return v, nil
+}
+
+func (v *Bool) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return v
+}
+
+func (v *Bool) isConstant() bool {
+	return true
+}
+
+func (v *Bool) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+func (v *Bool) Value() interface{} {
+	return v.val
+}
+
+func (v *Bool) Compare(val TypedValue) (int, error) {
+	cmp, handled, err := compareNulls(v, val)
+	if handled {
+		return cmp, err
+	}
+
+	rval := val.Value().(bool)
+
+	if v.val == rval {
+		return 0, nil
+	}
+
+	if v.val {
+		return 1, nil
+	}
+
+	return -1, nil
+}
+
+type Blob struct {
+	val []byte
+}
+
+func (v *Blob) Type() SQLValueType {
+	return BLOBType
+}
+
+func (v *Blob) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return BLOBType, nil
+}
+
+func (v *Blob) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != BLOBType {
+		return ErrInvalidTypes
+	}
+
+	return nil
+}
+
+func (v *Blob) substitute(params map[string]interface{}) (ValueExp, error) {
+	return v, nil
+}
+
+func (v *Blob) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return v, nil
+}
+
+func (v *Blob) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return v
+}
+
+func (v *Blob) isConstant() bool {
+	return true
+}
+
+func (v *Blob) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+func (v *Blob) Value() interface{} {
+	return v.val
+}
+
+func (v *Blob) Compare(val TypedValue) (int, error) {
+	cmp, handled, err := compareNulls(v, val)
+	if handled {
+		return cmp, err
+	}
+
+	rval := val.Value().([]byte)
+
+	return bytes.Compare(v.val, rval), nil
 }
 
-type typedValueSemiRange struct {
-	val       TypedValue
-	inclusive bool
+type SysFn struct {
+	fn     string
+	params []ValueExp
 }
 
-func (r *typedValueRange) unitary() bool {
-	// TODO: this simplified implementation doesn't cover all unitary cases e.g. 3<=v<4
-	if r.lRange == nil || r.hRange == nil {
-		return false
+// arity returns the number of arguments required by the function, ok is false for unknown functions
+func (v *SysFn) arity() (n int, ok bool) {
+	switch strings.ToUpper(v.fn) {
+	case "NOW", "CURRENT_DATABASE":
+		return 0, true
+	case "ABS", "TRIM", "LTRIM", "RTRIM":
+		return 1, true
+	case "REPLACE":
+		return 3, true
 	}
 
-	res, _ := r.lRange.val.Compare(r.hRange.val)
-	return res == 0 && r.lRange.inclusive && r.hRange.inclusive
+	return 0, false
 }
 
-func (r *typedValueRange) refineWith(refiningRange *typedValueRange) error {
-	if r.lRange == nil {
-		r.lRange = refiningRange.lRange
-	} else if r.lRange != nil && refiningRange.lRange != nil {
-		maxRange, err := maxSemiRange(r.lRange, refiningRange.lRange)
-		if err != nil {
-			return err
-		}
-		r.lRange = maxRange
+func (v *SysFn) validateArity() error {
+	n, ok := v.arity()
+	if !ok {
+		return ErrIllegalArguments
 	}
 
-	if r.hRange == nil {
-		r.hRange = refiningRange.hRange
-	} else if r.hRange != nil && refiningRange.hRange != nil {
-		minRange, err := minSemiRange(r.hRange, refiningRange.hRange)
-		if err != nil {
-			return err
-		}
-		r.hRange = minRange
+	if len(v.params) != n {
+		return fmt.Errorf("%w (%s expects %d arguments)", ErrIllegalArguments, strings.ToUpper(v.fn), n)
 	}
 
 	return nil
 }
 
-func (r *typedValueRange) extendWith(extendingRange *typedValueRange) error {
-	if r.lRange == nil || extendingRange.lRange == nil {
-		r.lRange = nil
-	} else {
-		minRange, err := minSemiRange(r.lRange, extendingRange.lRange)
+func (v *SysFn) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	err := v.validateArity()
+	if err != nil {
+		return AnyType, err
+	}
+
+	switch strings.ToUpper(v.fn) {
+	case "NOW":
+		return TimestampType, nil
+	case "CURRENT_DATABASE":
+		return VarcharType, nil
+	case "ABS":
+		err = v.params[0].requiresType(IntegerType, cols, params, implicitDB, implicitTable)
 		if err != nil {
-			return err
+			return AnyType, err
 		}
-		r.lRange = minRange
+
+		return IntegerType, nil
 	}
 
-	if r.hRange == nil || extendingRange.hRange == nil {
-		r.hRange = nil
-	} else {
-		maxRange, err := maxSemiRange(r.hRange, extendingRange.hRange)
+	// string functions
+	for _, p := range v.params {
+		err = p.requiresType(VarcharType, cols, params, implicitDB, implicitTable)
 		if err != nil {
-			return err
+			return AnyType, err
 		}
-		r.hRange = maxRange
 	}
 
-	return nil
+	return VarcharType, nil
 }
 
-func maxSemiRange(or1, or2 *typedValueSemiRange) (*typedValueSemiRange, error) {
-	r, err := or1.val.Compare(or2.val)
+func (v *SysFn) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	it, err := v.inferType(cols, params, implicitDB, implicitTable)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	maxVal := or1.val
-	if r < 0 {
-		maxVal = or2.val
+	if t != it {
+		return ErrInvalidTypes
 	}
 
-	return &typedValueSemiRange{
-		val:       maxVal,
-		inclusive: or1.inclusive && or2.inclusive,
-	}, nil
+	return nil
 }
 
-func minSemiRange(or1, or2 *typedValueSemiRange) (*typedValueSemiRange, error) {
-	r, err := or1.val.Compare(or2.val)
-	if err != nil {
-		return nil, err
-	}
-
-	minVal := or1.val
-	if r > 0 {
-		minVal = or2.val
+func (v *SysFn) substitute(params map[string]interface{}) (ValueExp, error) {
+	if len(v.params) == 0 {
+		return v, nil
 	}
 
-	return &typedValueSemiRange{
-		val:       minVal,
-		inclusive: or1.inclusive || or2.inclusive,
-	}, nil
-}
-
-type TypedValue interface {
-	ValueExp
-	Type() SQLValueType
-	Value() interface{}
-	Compare(val TypedValue) (int, error)
-}
+	fnParams := make([]ValueExp, len(v.params))
 
-type NullValue struct {
-	t SQLValueType
-}
+	for i, p := range v.params {
+		sp, err := p.substitute(params)
+		if err != nil {
+			return nil, err
+		}
 
-func (n *NullValue) Type() SQLValueType {
-	return n.t
-}
+		fnParams[i] = sp
+	}
 
-func (n *NullValue) Value() interface{} {
-	return nil
+	return &SysFn{fn: v.fn, params: fnParams}, nil
 }
 
-func (n *NullValue) Compare(val TypedValue) (int, error) {
-	if n.t != AnyType && val.Type() != AnyType && n.t != val.Type() {
-		return 0, ErrNotComparableValues
+func (v *SysFn) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	_, known := v.arity()
+	if !known {
+		return nil, ErrNoSupported
 	}
 
-	if val.Value() == nil {
-		return 0, nil
+	err := v.validateArity()
+	if err != nil {
+		return nil, err
 	}
 
-	return -1, nil
-}
+	fn := strings.ToUpper(v.fn)
 
-func (v *NullValue) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	return v.t, nil
-}
+	switch fn {
+	case "NOW":
+		return &Timestamp{val: time.Now().UTC()}, nil
+	case "CURRENT_DATABASE":
+		// the database the statement is run against, i.e. the database of the table being read or written
+		if implicitDB == "" {
+			return nil, ErrNoDatabaseSelected
+		}
 
-func (v *NullValue) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
-	if v.t == t {
-		return nil
-	}
+		return &Varchar{val: implicitDB}, nil
+	case "ABS":
+		rval, err := v.params[0].reduce(catalog, row, implicitDB, implicitTable)
+		if err != nil {
+			return nil, err
+		}
 
-	if v.t != AnyType {
-		return ErrInvalidTypes
-	}
+		_, isNull := rval.(*NullValue)
+		if isNull {
+			return &NullValue{t: IntegerType}, nil
+		}
 
-	v.t = t
+		n, isNumber := rval.Value().(int64)
+		if !isNumber {
+			return nil, fmt.Errorf("%w (expecting numeric value)", ErrInvalidValue)
+		}
 
-	return nil
-}
+		if n == math.MinInt64 {
+			// the absolute value of the lowest integer can not be represented
+			return nil, ErrArithmeticOverflow
+		}
 
-func (v *NullValue) substitute(params map[string]interface{}) (ValueExp, error) {
-	return v, nil
-}
+		if n < 0 {
+			n = -n
+		}
 
-func (v *NullValue) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
-	return v, nil
-}
+		return &Number{val: n}, nil
+	}
 
-func (v *NullValue) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
-	return v
-}
+	// string functions, a null argument makes the result null
+	args := make([]string, len(v.params))
 
-func (v *NullValue) isConstant() bool {
-	return true
-}
+	for i, p := range v.params {
+		rval, err := p.reduce(catalog, row, implicitDB, implicitTable)
+		if err != nil {
+			return nil, err
+		}
 
-func (v *NullValue) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
-	return nil
-}
+		_, isNull := rval.(*NullValue)
+		if isNull {
+			return &NullValue{t: VarcharType}, nil
+		}
 
-type Number struct {
-	val int64
-}
+		s, isString := rval.Value().(string)
+		if !isString {
+			return nil, fmt.Errorf("%w (expecting string value)", ErrInvalidValue)
+		}
 
-func (v *Number) Type() SQLValueType {
-	return IntegerType
-}
+		args[i] = s
+	}
 
-func (v *Number) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	return IntegerType, nil
+	switch fn {
+	case "TRIM":
+		return &Varchar{val: strings.TrimSpace(args[0])}, nil
+	case "LTRIM":
+		return &Varchar{val: strings.TrimLeftFunc(args[0], unicode.IsSpace)}, nil
+	case "RTRIM":
+		return &Varchar{val: strings.TrimRightFunc(args[0], unicode.IsSpace)}, nil
+	case "REPLACE":
+		return &Varchar{val: strings.ReplaceAll(args[0], args[1], args[2])}, nil
+	}
+
+	return nil, ErrUnexpected
 }
 
-func (v *Number) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
-	if t != IntegerType {
-		return ErrInvalidTypes
+func (v *SysFn) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	if len(v.params) == 0 {
+		return v
 	}
 
-	return nil
-}
+	fnParams := make([]ValueExp, len(v.params))
 
-func (v *Number) substitute(params map[string]interface{}) (ValueExp, error) {
-	return v, nil
-}
+	for i, p := range v.params {
+		fnParams[i] = p.reduceSelectors(row, implicitDB, implicitTable)
+	}
 
-func (v *Number) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
-	return v, nil
+	return &SysFn{fn: v.fn, params: fnParams}
 }
 
-func (v *Number) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
-	return v
-}
+func (v *SysFn) isConstant() bool {
+	n, ok := v.arity()
+	if !ok || n == 0 {
+		// functions without arguments depend on the time or context of the evaluation
+		return false
+	}
+
+	for _, p := range v.params {
+		if !p.isConstant() {
+			return false
+		}
+	}
 
-func (v *Number) isConstant() bool {
 	return true
 }
 
-func (v *Number) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+func (v *SysFn) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
 	return nil
 }
 
-func (v *Number) Value() interface{} {
-	return v.val
+type Param struct {
+	id  string
+	pos int
 }
 
-func (v *Number) Compare(val TypedValue) (int, error) {
-	_, isNull := val.(*NullValue)
-	if isNull {
-		return 1, nil
-	}
-
-	if val.Type() != IntegerType {
-		return 0, ErrNotComparableValues
+func (v *Param) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	t, ok := params[v.id]
+	if !ok {
+		params[v.id] = AnyType
+		return AnyType, nil
 	}
 
-	rval := val.Value().(int64)
-
-	if v.val == rval {
-		return 0, nil
-	}
+	return t, nil
+}
 
-	if v.val > rval {
-		return 1, nil
+func (v *Param) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	currT, ok := params[v.id]
+	if ok && currT != t && currT != AnyType {
+		return ErrInferredMultipleTypes
 	}
 
-	return -1, nil
-}
+	params[v.id] = t
 
-type Varchar struct {
-	val string
+	return nil
 }
 
-func (v *Varchar) Type() SQLValueType {
-	return VarcharType
-}
+func (p *Param) substitute(params map[string]interface{}) (ValueExp, error) {
+	val, ok := params[p.id]
+	if !ok {
+		return nil, ErrMissingParameter
+	}
 
-func (v *Varchar) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	return VarcharType, nil
-}
+	if val == nil {
+		return &NullValue{t: AnyType}, nil
+	}
 
-func (v *Varchar) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
-	if t != VarcharType {
-		return ErrInvalidTypes
+	switch v := val.(type) {
+	case bool:
+		{
+			return &Bool{val: v}, nil
+		}
+	case string:
+		{
+			return &Varchar{val: v}, nil
+		}
+	case int:
+		{
+			return &Number{val: int64(v)}, nil
+		}
+	case uint:
+		{
+			return &Number{val: int64(v)}, nil
+		}
+	case uint64:
+		{
+			return &Number{val: int64(v)}, nil
+		}
+	case int64:
+		{
+			return &Number{val: v}, nil
+		}
+	case []byte:
+		{
+			return &Blob{val: v}, nil
+		}
+	case time.Time:
+		{
+			return &Timestamp{val: v.UTC()}, nil
+		}
 	}
 
-	return nil
-}
-
-func (v *Varchar) substitute(params map[string]interface{}) (ValueExp, error) {
-	return v, nil
+	return nil, ErrUnsupportedParameter
 }
 
-func (v *Varchar) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
-	return v, nil
+func (p *Param) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return nil, ErrUnexpected
 }
 
-func (v *Varchar) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
-	return v
+func (p *Param) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return p
 }
 
-func (v *Varchar) isConstant() bool {
+func (p *Param) isConstant() bool {
 	return true
 }
 
-func (v *Varchar) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+func (v *Param) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
 	return nil
 }
 
-func (v *Varchar) Value() interface{} {
-	return v.val
-}
-
-func (v *Varchar) Compare(val TypedValue) (int, error) {
-	_, isNull := val.(*NullValue)
-	if isNull {
-		return 1, nil
-	}
+type Comparison int
 
-	if val.Type() != VarcharType {
-		return 0, ErrNotComparableValues
-	}
+const (
+	EqualTo Comparison = iota
+	LowerThan
+	LowerOrEqualTo
+	GreaterThan
+	GreaterOrEqualTo
+)
 
-	rval := val.Value().(string)
+type DataSource interface {
+	inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error
+	Resolve(e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}, ScanSpecs *ScanSpecs) (RowReader, error)
+	Alias() string
+}
 
-	return bytes.Compare([]byte(v.val), []byte(rval)), nil
+type SelectStmt struct {
+	distinct    bool
+	selectors   []Selector
+	ds          DataSource
+	indexOn     []string
+	joins       []*JoinSpec
+	where       ValueExp
+	groupBy     []*ColSelector
+	rollup      bool // GROUP BY ROLLUP, subtotals are returned for every prefix of the grouping columns
+	having      ValueExp
+	limit       int
+	limitParam  *Param // the limit is bound at execution when given as a parameter
+	hasLimit    bool   // a zero limit means no rows when explicitly specified
+	offset      int
+	offsetParam *Param // the offset is bound at execution when given as a parameter
+	orderBy     []*OrdCol
+	as          string
+	ctes        []*commonTableExp // defined in the WITH clause
+	cursor      []byte            // rows are read after the position of the cursor, see Engine.QueryStmtFromCursor
+}
+
+// commonTableExp is a query named in a WITH clause, it's referenced by name as a derived table
+type commonTableExp struct {
+	name  string
+	query *SelectStmt
+}
+
+// grouping holds the columns given in the GROUP BY clause, either directly or as ROLLUP(...)
+type grouping struct {
+	cols   []*ColSelector
+	rollup bool
+}
+
+// pagination holds the row limit and offset of a query, either specified as LIMIT/OFFSET
+// or using the standard OFFSET ... FETCH syntax
+type pagination struct {
+	limit       int
+	limitParam  *Param
+	hasLimit    bool
+	offset      int
+	offsetParam *Param
 }
 
-type Bool struct {
-	val bool
+type ScanSpecs struct {
+	index         *Index
+	rangesByColID map[uint32]*typedValueRange
+	descOrder     bool
+	noRows        bool     // the query condition can not be satisfied, thus no scan is needed
+	cond          ValueExp // the query condition ranges are narrowed with, once parameters are known
+	keysOnly      bool     // rows are decoded from index keys, thus only indexed columns hold values
+	after         []byte   // rows are read after the position of a cursor
 }
 
-func (v *Bool) Type() SQLValueType {
-	return BooleanType
+func (stmt *SelectStmt) Limit() int {
+	return stmt.limit
 }
 
-func (v *Bool) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	return BooleanType, nil
+func (stmt *SelectStmt) Offset() int {
+	return stmt.offset
 }
 
-func (v *Bool) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
-	if t != BooleanType {
-		return ErrInvalidTypes
+func (stmt *SelectStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	if len(stmt.ctes) > 0 {
+		expanded, err := stmt.withCTEs(nil)
+		if err != nil {
+			return err
+		}
+
+		return expanded.inferParameters(e, implicitDB, params)
 	}
 
-	return nil
-}
+	_, err := stmt.compileUsing(e, implicitDB, nil)
+	if err != nil {
+		return err
+	}
 
-func (v *Bool) substitute(params map[string]interface{}) (ValueExp, error) {
-	return v, nil
-}
+	snapshot, err := e.getSnapshot()
+	if err != nil {
+		return err
+	}
 
-func (v *Bool) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
-	return v, nil
-}
+	// TODO (jeroiraz) may be optimized so to resolve the query statement just once
+	rowReader, err := stmt.Resolve(e, snapshot, implicitDB, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer rowReader.Close()
 
-func (v *Bool) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
-	return v
-}
+	err = rowReader.InferParameters(params)
+	if err != nil {
+		return err
+	}
 
-func (v *Bool) isConstant() bool {
-	return true
-}
+	for _, p := range []*Param{stmt.limitParam, stmt.offsetParam} {
+		if p == nil {
+			continue
+		}
 
-func (v *Bool) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
-	return nil
-}
+		err = p.requiresType(IntegerType, nil, params, "", "")
+		if err != nil {
+			return err
+		}
+	}
 
-func (v *Bool) Value() interface{} {
-	return v.val
+	return nil
 }
 
-func (v *Bool) Compare(val TypedValue) (int, error) {
-	_, isNull := val.(*NullValue)
-	if isNull {
-		return 1, nil
-	}
-
-	if val.Type() != BooleanType {
-		return 0, ErrNotComparableValues
+// paginationFor returns the limit and offset of the query, binding them when given as parameters.
+// A negative limit means no limit, as it's the case while inferring parameters i.e. when params is nil
+func (stmt *SelectStmt) paginationFor(params map[string]interface{}) (limit, offset int, err error) {
+	limit = -1
+	if stmt.hasLimit && stmt.limitParam == nil {
+		limit = stmt.limit
 	}
 
-	rval := val.Value().(bool)
+	offset = stmt.offset
 
-	if v.val == rval {
-		return 0, nil
+	if params == nil {
+		return limit, offset, nil
 	}
 
-	if v.val {
-		return 1, nil
+	if stmt.limitParam != nil {
+		limit, err = boundPaginationParam(stmt.limitParam, params)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		if limit <= 0 {
+			return 0, 0, fmt.Errorf("%w (LIMIT must be a positive integer)", ErrIllegalArguments)
+		}
 	}
 
-	return -1, nil
-}
+	if stmt.offsetParam != nil {
+		offset, err = boundPaginationParam(stmt.offsetParam, params)
+		if err != nil {
+			return 0, 0, err
+		}
 
-type Blob struct {
-	val []byte
-}
+		if offset < 0 {
+			return 0, 0, fmt.Errorf("%w (OFFSET can not be negative)", ErrIllegalArguments)
+		}
+	}
 
-func (v *Blob) Type() SQLValueType {
-	return BLOBType
+	return limit, offset, nil
 }
 
-func (v *Blob) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	return BLOBType, nil
-}
+func boundPaginationParam(p *Param, params map[string]interface{}) (int, error) {
+	val, err := p.substitute(params)
+	if err != nil {
+		return 0, err
+	}
 
-func (v *Blob) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
-	if t != BLOBType {
-		return ErrInvalidTypes
+	n, isNumber := val.(*Number)
+	if !isNumber {
+		return 0, fmt.Errorf("%w (expecting an integer for %s)", ErrIllegalArguments, p.id)
 	}
 
-	return nil
+	return int(n.val), nil
 }
 
-func (v *Blob) substitute(params map[string]interface{}) (ValueExp, error) {
-	return v, nil
-}
+func (stmt *SelectStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	if len(stmt.ctes) > 0 {
+		expanded, err := stmt.withCTEs(nil)
+		if err != nil {
+			return nil, err
+		}
 
-func (v *Blob) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
-	return v, nil
-}
+		return expanded.compileUsing(e, implicitDB, params)
+	}
 
-func (v *Blob) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
-	return v
-}
+	if implicitDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
 
-func (v *Blob) isConstant() bool {
-	return true
-}
+	if stmt.groupBy == nil && stmt.having != nil {
+		return nil, ErrHavingClauseRequiresGroupClause
+	}
 
-func (v *Blob) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
-	return nil
-}
+	for _, sel := range stmt.selectors {
+		expSel, isExp := sel.(*ExpSelector)
+		if isExp && containsAggregation(expSel.exp) {
+			return nil, ErrLimitedAggregation
+		}
 
-func (v *Blob) Value() interface{} {
-	return v.val
-}
+		aggSel, isAgg := sel.(*AggColSelector)
+		if isAgg && aggSel.distinct && aggSel.aggFn != COUNT && aggSel.aggFn != SUM && aggSel.aggFn != AVG {
+			return nil, fmt.Errorf("%w (DISTINCT is only supported within COUNT, SUM and AVG)", ErrLimitedAggregation)
+		}
+	}
 
-func (v *Blob) Compare(val TypedValue) (int, error) {
-	_, isNull := val.(*NullValue)
-	if isNull {
-		return 1, nil
+	// rows are sorted by several columns as long as they are all sorted in the same direction
+	for _, ordCol := range stmt.orderBy {
+		if ordCol.descOrder != stmt.orderBy[0].descOrder {
+			return nil, ErrLimitedOrderBy
+		}
 	}
 
-	if val.Type() != BLOBType {
-		return 0, ErrNotComparableValues
+	orderBy, err := stmt.ordering()
+	if err != nil {
+		return nil, err
 	}
 
-	rval := val.Value().([]byte)
+	if len(orderBy) > 0 {
+		scanStmt, err := stmt.drivenByJoinedTable()
+		if err != nil {
+			return nil, err
+		}
 
-	return bytes.Compare(v.val, rval), nil
-}
+		if scanStmt == nil {
+			scanStmt = stmt
+		}
 
-type SysFn struct {
-	fn string
-}
+		tableRef, ok := scanStmt.ds.(*tableRef)
+		if !ok {
+			return nil, ErrLimitedOrderBy
+		}
 
-func (v *SysFn) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	if strings.ToUpper(v.fn) == "NOW" {
-		return IntegerType, nil
-	}
+		table, err := tableRef.referencedTable(e, implicitDB)
+		if err != nil {
+			return nil, err
+		}
 
-	return AnyType, ErrIllegalArguments
-}
+		colIDs, err := orderingColIDs(table, orderBy)
+		if err != nil {
+			return nil, err
+		}
 
-func (v *SysFn) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
-	if strings.ToUpper(v.fn) == "NOW" {
-		if t != IntegerType {
-			return ErrInvalidTypes
+		indexed := false
+
+		for _, idx := range table.indexesByColID[colIDs[0]] {
+			if idx.colsPos(colIDs) >= 0 {
+				indexed = true
+				break
+			}
 		}
 
-		return nil
+		if !indexed && !stmt.sortableInMemory() {
+			return nil, ErrLimitedOrderBy
+		}
 	}
 
-	return ErrIllegalArguments
-}
-
-func (v *SysFn) substitute(params map[string]interface{}) (ValueExp, error) {
-	return v, nil
+	return newTxSummary(implicitDB), nil
 }
 
-func (v *SysFn) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
-	if strings.ToUpper(v.fn) == "NOW" {
-		return &Number{val: time.Now().UnixNano()}, nil
+// drivenByJoinedTable returns the query rewritten so that the first joined table drives the join, which is the case
+// when rows are sorted by its columns, thus they can be scanned in order using one of its indexes instead of being sorted.
+// A nil query is returned when the rows are not sorted by the columns of the first joined table or it can not drive the join.
+// The condition of the query is not used to narrow the scan of the driving table, as unqualified columns refer to
+// the table the query is defined over, it's evaluated over the joint rows instead.
+func (stmt *SelectStmt) drivenByJoinedTable() (*SelectStmt, error) {
+	if len(stmt.joins) == 0 {
+		return nil, nil
 	}
 
-	return nil, errors.New("not yet supported")
-}
+	orderBy, err := stmt.ordering()
+	if err != nil {
+		return nil, err
+	}
 
-func (v *SysFn) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
-	return v
-}
+	join := stmt.joins[0]
 
-func (v *SysFn) isConstant() bool {
-	return false
-}
+	_, isTableRef := stmt.ds.(*tableRef)
+	_, isJoinedTableRef := join.ds.(*tableRef)
 
-func (v *SysFn) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
-	return nil
-}
+	// rows of an inner join are the same whichever table drives it
+	if len(orderBy) == 0 || !isTableRef || !isJoinedTableRef || join.joinType != InnerJoin {
+		return nil, nil
+	}
 
-type Param struct {
-	id  string
-	pos int
-}
+	if stmt.ds.Alias() == join.ds.Alias() {
+		return nil, nil
+	}
 
-func (v *Param) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	t, ok := params[v.id]
-	if !ok {
-		params[v.id] = AnyType
-		return AnyType, nil
+	for _, ordCol := range orderBy {
+		_, _, table, _ := ordCol.sel.resolve("", stmt.ds.Alias())
+		if table != join.ds.Alias() {
+			return nil, nil
+		}
 	}
 
-	return t, nil
+	rewritten := *stmt
+	rewritten.ds = join.ds
+	rewritten.indexOn = join.indexOn
+	rewritten.where = nil
+	rewritten.joins = append(
+		[]*JoinSpec{{joinType: InnerJoin, ds: stmt.ds, cond: join.cond, indexOn: stmt.indexOn}},
+		stmt.joins[1:]...,
+	)
+
+	return &rewritten, nil
 }
 
-func (v *Param) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
-	currT, ok := params[v.id]
-	if ok && currT != t && currT != AnyType {
-		return ErrInferredMultipleTypes
+// ordering returns the columns rows are sorted by, which are the ones of the window when window functions are used
+func (stmt *SelectStmt) ordering() ([]*OrdCol, error) {
+	windows := windowFnSelectors(stmt.selectors)
+	if len(windows) == 0 {
+		return stmt.orderBy, nil
 	}
 
-	params[v.id] = t
+	for _, w := range windows {
+		err := w.validate()
+		if err != nil {
+			return nil, err
+		}
 
-	return nil
-}
+		if !sameOrdering(w.orderBy, windows[0].orderBy) || !samePartitioning(w.partitionBy, windows[0].partitionBy) {
+			return nil, ErrLimitedWindowFunctions
+		}
+	}
 
-func (p *Param) substitute(params map[string]interface{}) (ValueExp, error) {
-	val, ok := params[p.id]
-	if !ok {
-		return nil, ErrMissingParameter
+	// partitioned rows are not sorted as requested by the query
+	if len(stmt.orderBy) > 0 && (len(windows[0].partitionBy) > 0 || !sameOrdering(stmt.orderBy, windows[0].orderBy)) {
+		return nil, ErrLimitedWindowFunctions
 	}
 
-	if val == nil {
-		return &NullValue{t: AnyType}, nil
+	if stmt.groupBy != nil {
+		return nil, ErrLimitedWindowFunctions
 	}
 
-	switch v := val.(type) {
-	case bool:
-		{
-			return &Bool{val: v}, nil
-		}
-	case string:
-		{
-			return &Varchar{val: v}, nil
-		}
-	case int:
-		{
-			return &Number{val: int64(v)}, nil
-		}
-	case uint:
-		{
-			return &Number{val: int64(v)}, nil
-		}
-	case uint64:
-		{
-			return &Number{val: int64(v)}, nil
-		}
-	case int64:
-		{
-			return &Number{val: v}, nil
-		}
-	case []byte:
-		{
-			return &Blob{val: v}, nil
+	for _, sel := range stmt.selectors {
+		_, isAgg := sel.(*AggColSelector)
+		if isAgg {
+			return nil, ErrLimitedWindowFunctions
 		}
 	}
 
-	return nil, ErrUnsupportedParameter
+	return windows[0].ordering(), nil
 }
 
-func (p *Param) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
-	return nil, ErrUnexpected
-}
+// orderingColIDs returns the ids of the columns of the table rows are sorted by
+func orderingColIDs(table *Table, orderBy []*OrdCol) ([]uint32, error) {
+	colIDs := make([]uint32, len(orderBy))
 
-func (p *Param) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
-	return p
+	for i, ordCol := range orderBy {
+		col, err := table.GetColumnByName(ordCol.sel.col)
+		if err != nil {
+			return nil, err
+		}
+
+		colIDs[i] = col.id
+	}
+
+	return colIDs, nil
 }
 
-func (p *Param) isConstant() bool {
+func samePartitioning(p1, p2 []*ColSelector) bool {
+	if len(p1) != len(p2) {
+		return false
+	}
+
+	for i := range p1 {
+		if *p1[i] != *p2[i] {
+			return false
+		}
+	}
+
 	return true
 }
 
-func (v *Param) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
-	return nil
+func sameOrdering(o1, o2 []*OrdCol) bool {
+	if len(o1) != len(o2) {
+		return false
+	}
+
+	for i := range o1 {
+		if *o1[i].sel != *o2[i].sel || o1[i].descOrder != o2[i].descOrder {
+			return false
+		}
+	}
+
+	return true
 }
 
-type Comparison int
+// sortableInMemory tells if the rows of the query can be sorted without an index,
+// it's only the case when few enough of them are returned to be kept in memory
+func (stmt *SelectStmt) sortableInMemory() bool {
+	if !stmt.hasLimit || stmt.distinct || stmt.groupBy != nil {
+		return false
+	}
 
-const (
-	EqualTo Comparison = iota
-	LowerThan
-	LowerOrEqualTo
-	GreaterThan
-	GreaterOrEqualTo
-)
+	for _, sel := range stmt.selectors {
+		_, isAgg := sel.(*AggColSelector)
+		if isAgg {
+			return false
+		}
+	}
 
-type DataSource interface {
-	inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error
-	Resolve(e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}, ScanSpecs *ScanSpecs) (RowReader, error)
-	Alias() string
+	return true
 }
 
-type SelectStmt struct {
-	distinct  bool
-	selectors []Selector
-	ds        DataSource
-	indexOn   []string
-	joins     []*JoinSpec
-	where     ValueExp
-	groupBy   []*ColSelector
-	having    ValueExp
-	limit     int
-	orderBy   []*OrdCol
-	as        string
-}
+func (stmt *SelectStmt) Resolve(e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}, _ *ScanSpecs) (ret RowReader, err error) {
+	if len(stmt.ctes) > 0 {
+		expanded, err := stmt.withCTEs(nil)
+		if err != nil {
+			return nil, err
+		}
 
-type ScanSpecs struct {
-	index         *Index
-	rangesByColID map[uint32]*typedValueRange
-	descOrder     bool
-}
+		expanded.cursor = stmt.cursor
 
-func (stmt *SelectStmt) Limit() int {
-	return stmt.limit
-}
+		return expanded.Resolve(e, snap, implicitDB, params, nil)
+	}
 
-func (stmt *SelectStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
-	_, err := stmt.compileUsing(e, implicitDB, nil)
+	if e.maxJoinDepth > 0 && stmt.joinedRelations() > e.maxJoinDepth {
+		return nil, fmt.Errorf("%w (max number of joined relations is %d)", ErrJoinDepthExceeded, e.maxJoinDepth)
+	}
+
+	orderBy, err := stmt.ordering()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	snapshot, err := e.getSnapshot()
+	limit, offset, err := stmt.paginationFor(params)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// TODO (jeroiraz) may be optimized so to resolve the query statement just once
-	rowReader, err := stmt.Resolve(e, snapshot, implicitDB, nil, nil)
+	scanStmt, err := stmt.drivenByJoinedTable()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer rowReader.Close()
 
-	return rowReader.InferParameters(params)
-}
+	drivenByJoin := scanStmt != nil
 
-func (stmt *SelectStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
-	if implicitDB == nil {
-		return nil, ErrNoDatabaseSelected
+	if !drivenByJoin {
+		scanStmt = stmt
 	}
 
-	if stmt.groupBy == nil && stmt.having != nil {
-		return nil, ErrHavingClauseRequiresGroupClause
+	scanSpecs, err := scanStmt.genScanSpecs(e, snap, implicitDB, params)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(stmt.groupBy) > 1 {
-		return nil, ErrLimitedGroupBy
-	}
+	if stmt.cursor != nil {
+		// e.g. derived tables
+		if scanSpecs == nil {
+			return nil, ErrNoSupported
+		}
 
-	if len(stmt.orderBy) > 1 {
-		return nil, ErrLimitedOrderBy
+		scanSpecs.after = stmt.cursor
 	}
 
-	if len(stmt.orderBy) > 0 {
-		tableRef, ok := stmt.ds.(*tableRef)
-		if !ok {
-			return nil, ErrLimitedOrderBy
+	where := stmt.where
+
+	// conditions not depending on rows are evaluated only once,
+	// rows are not filtered when they hold and not even scanned when they don't
+	if where != nil {
+		satisfied, isConstant := constantCondition(where)
+
+		if isConstant && satisfied {
+			where = nil
 		}
 
-		table, err := tableRef.referencedTable(e, implicitDB)
+		if isConstant && !satisfied && scanSpecs != nil {
+			scanSpecs.noRows = true
+			where = nil
+		}
+	}
+
+	if scanSpecs != nil && stmt.countsIndexKeys(scanSpecs, where) {
+		scanSpecs.keysOnly = true
+	}
+
+	rowReader, err := scanStmt.ds.Resolve(e, snap, implicitDB, params, scanSpecs)
+	if err != nil {
+		return nil, err
+	}
+
+	// readers created so far must be closed if the query can not be fully resolved
+	defer func() {
 		if err != nil {
-			return nil, err
+			rowReader.Close()
 		}
+	}()
 
-		col, err := table.GetColumnByName(stmt.orderBy[0].sel.col)
+	if scanStmt.joins != nil {
+		jointRowReader, err := e.newJointRowReader(implicitDB, snap, params, rowReader, scanStmt.joins)
 		if err != nil {
 			return nil, err
 		}
 
-		_, indexed := table.indexesByColID[col.id]
-		if !indexed {
-			return nil, ErrLimitedOrderBy
-		}
+		jointRowReader.drivenByJoin = drivenByJoin
+
+		rowReader = jointRowReader
 	}
 
-	return newTxSummary(implicitDB), nil
-}
+	if where != nil {
+		condRowReader, err := e.newConditionalRowReader(rowReader, where, params)
+		if err != nil {
+			return nil, err
+		}
 
-func (stmt *SelectStmt) Resolve(e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}, _ *ScanSpecs) (rowReader RowReader, err error) {
-	scanSpecs, err := stmt.genScanSpecs(e, snap, implicitDB, params)
-	if err != nil {
-		return nil, err
+		rowReader = condRowReader
 	}
 
-	rowReader, err = stmt.ds.Resolve(e, snap, implicitDB, params, scanSpecs)
-	if err != nil {
-		return nil, err
-	}
+	if len(orderBy) > 0 {
+		if scanSpecs == nil {
+			return nil, ErrLimitedOrderBy
+		}
 
-	if stmt.joins != nil {
-		rowReader, err = e.newJointRowReader(implicitDB, snap, params, rowReader, stmt.joins)
+		sorted, err := scanSpecs.sortedBy(orderBy)
 		if err != nil {
 			return nil, err
 		}
+
+		if !sorted {
+			// only the rows that may be returned are kept
+			topN := limit + offset
+			if limit < 0 {
+				// the limit is not known while inferring parameters
+				topN = 0
+			}
+
+			topNRowReader, err := e.newTopNRowReader(rowReader, orderBy, topN)
+			if err != nil {
+				return nil, err
+			}
+
+			rowReader = topNRowReader
+		}
 	}
 
-	if stmt.where != nil {
-		rowReader, err = e.newConditionalRowReader(rowReader, stmt.where, params)
+	if windows := windowFnSelectors(stmt.selectors); len(windows) > 0 {
+		windowRowReader, err := e.newWindowRowReader(rowReader, windows)
 		if err != nil {
 			return nil, err
 		}
+
+		rowReader = windowRowReader
 	}
 
 	containsAggregations := false
@@ -1858,50 +4256,235 @@ func (stmt *SelectStmt) Resolve(e *Engine, snap *store.Snapshot, implicitDB *Dat
 		}
 	}
 
-	if containsAggregations {
+	if containsAggregations || stmt.rollup {
 		var groupBy []*ColSelector
 		if stmt.groupBy != nil {
 			groupBy = stmt.groupBy
 		}
 
-		rowReader, err = e.newGroupedRowReader(rowReader, stmt.selectors, groupBy)
+		groupedRowReader, err := e.newGroupedRowReader(rowReader, stmt.selectors, groupBy, stmt.rollup)
+		if err != nil {
+			return nil, err
+		}
+
+		rowReader = groupedRowReader
+
+		if stmt.having != nil {
+			condRowReader, err := e.newConditionalRowReader(rowReader, stmt.having, params)
+			if err != nil {
+				return nil, err
+			}
+
+			rowReader = condRowReader
+		}
+	}
+
+	projectedRowReader, err := e.newProjectedRowReader(rowReader, stmt.as, stmt.selectors, params)
+	if err != nil {
+		return nil, err
+	}
+
+	projectedRowReader.derived = scanSpecs == nil
+
+	rowReader = projectedRowReader
+
+	if stmt.distinct {
+		distinctRowReader, err := e.newDistinctRowReader(rowReader)
+		if err != nil {
+			return nil, err
+		}
+
+		rowReader = distinctRowReader
+	}
+
+	if limit >= 0 || offset > 0 {
+		limitRowReader, err := e.newLimitRowReader(rowReader, limit, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		rowReader = limitRowReader
+	}
+
+	// the query can only be resumed when rows are returned in the order they are scanned
+	if stmt.cursor != nil {
+		_, err = rowReader.Cursor()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rowReader, nil
+}
+
+// constantCondition evaluates a condition depending neither on rows nor on parameters,
+// isConstant is false when the condition can only be evaluated while reading rows
+func constantCondition(cond ValueExp) (satisfied bool, isConstant bool) {
+	if !cond.isConstant() {
+		return false, false
+	}
+
+	// parameters may still be set after the query is resolved
+	exp, err := cond.substitute(nil)
+	if err != nil {
+		return false, false
+	}
+
+	v, err := exp.reduce(nil, nil, "", "")
+	if err != nil {
+		return false, false
+	}
+
+	b, isBool := v.(*Bool)
+	if !isBool {
+		return false, false
+	}
+
+	return b.val, true
+}
+
+// joinedRelations returns the number of relations read by the query, relations joined within derived tables included
+func (stmt *SelectStmt) joinedRelations() int {
+	relationsOf := func(ds DataSource) int {
+		derived, ok := ds.(*SelectStmt)
+		if ok {
+			return derived.joinedRelations()
+		}
+
+		return 1
+	}
+
+	n := relationsOf(stmt.ds)
+
+	for _, join := range stmt.joins {
+		n += relationsOf(join.ds)
+	}
+
+	return n
+}
+
+func (stmt *SelectStmt) Alias() string {
+	if stmt.as == "" {
+		return stmt.ds.Alias()
+	}
+
+	return stmt.as
+}
+
+// withCTEs returns a copy of the statement where tables named after a common table expression,
+// either defined by the statement or given in ctes, are replaced by its query.
+// Expressions may only reference the ones defined before them, thus recursion is not possible
+func (stmt *SelectStmt) withCTEs(ctes map[string]*SelectStmt) (*SelectStmt, error) {
+	scope := make(map[string]*SelectStmt, len(ctes)+len(stmt.ctes))
+	for name, query := range ctes {
+		scope[name] = query
+	}
+
+	defined := make(map[string]struct{}, len(stmt.ctes))
+
+	for _, cte := range stmt.ctes {
+		_, duplicated := defined[cte.name]
+		if duplicated {
+			return nil, ErrDuplicatedTableExpression
+		}
+
+		defined[cte.name] = struct{}{}
+
+		query, err := cte.query.withCTEs(scope)
 		if err != nil {
 			return nil, err
 		}
 
-		if stmt.having != nil {
-			rowReader, err = e.newConditionalRowReader(rowReader, stmt.having, params)
-			if err != nil {
-				return nil, err
-			}
-		}
+		scope[cte.name] = query
 	}
 
-	rowReader, err = e.newProjectedRowReader(rowReader, stmt.as, stmt.selectors)
+	expanded := *stmt
+	expanded.ctes = nil
+
+	ds, err := dataSourceWithCTEs(stmt.ds, scope)
 	if err != nil {
 		return nil, err
 	}
 
-	if stmt.distinct {
-		rowReader, err = e.newDistinctRowReader(rowReader)
-		if err != nil {
-			return nil, err
+	expanded.ds = ds
+
+	if len(stmt.joins) > 0 {
+		expanded.joins = make([]*JoinSpec, len(stmt.joins))
+
+		for i, jspec := range stmt.joins {
+			ds, err := dataSourceWithCTEs(jspec.ds, scope)
+			if err != nil {
+				return nil, err
+			}
+
+			expandedJoin := *jspec
+			expandedJoin.ds = ds
+
+			expanded.joins[i] = &expandedJoin
 		}
 	}
 
-	if stmt.limit > 0 {
-		return e.newLimitRowReader(rowReader, stmt.limit)
+	return &expanded, nil
+}
+
+func dataSourceWithCTEs(ds DataSource, ctes map[string]*SelectStmt) (DataSource, error) {
+	switch ds := ds.(type) {
+	case *tableRef:
+		{
+			query, ok := ctes[ds.table]
+			if !ok || ds.db != "" {
+				return ds, nil
+			}
+
+			if ds.asBefore > 0 {
+				return nil, fmt.Errorf("%w: common table expressions can not be read as before a transaction", ErrIllegalArguments)
+			}
+
+			// every reference gets its own copy so to be aliased independently
+			derived := *query
+			derived.as = ds.Alias()
+
+			return &derived, nil
+		}
+	case *SelectStmt:
+		{
+			return ds.withCTEs(ctes)
+		}
 	}
 
-	return rowReader, nil
+	return ds, nil
 }
 
-func (stmt *SelectStmt) Alias() string {
-	if stmt.as == "" {
-		return stmt.ds.Alias()
+// countsIndexKeys returns true when the query just counts the rows satisfying a condition over the columns of
+// the index to be scanned, in such case rows don't need to be fetched as index keys hold all the required values
+func (stmt *SelectStmt) countsIndexKeys(scanSpecs *ScanSpecs, where ValueExp) bool {
+	if len(stmt.selectors) != 1 || stmt.joins != nil || stmt.groupBy != nil || stmt.having != nil {
+		return false
 	}
 
-	return stmt.as
+	aggSel, isAgg := stmt.selectors[0].(*AggColSelector)
+	if !isAgg || aggSel.aggFn != COUNT || aggSel.distinct {
+		return false
+	}
+
+	if where == nil {
+		return true
+	}
+
+	tableRef := stmt.ds.(*tableRef)
+
+	table := scanSpecs.index.table
+	cols := make(map[string]ColDescriptor, len(scanSpecs.index.cols))
+
+	for _, col := range scanSpecs.index.cols {
+		des := ColDescriptor{Database: table.db.name, Table: tableRef.Alias(), Column: col.colName, Type: col.colType}
+		cols[des.Selector()] = des
+	}
+
+	// references to other columns or subqueries can not be resolved from the indexed values
+	_, err := where.inferType(cols, make(map[string]SQLValueType), table.db.name, tableRef.Alias())
+
+	return err == nil
 }
 
 func (stmt *SelectStmt) genScanSpecs(e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}) (*ScanSpecs, error) {
@@ -1945,25 +4528,34 @@ func (stmt *SelectStmt) genScanSpecs(e *Engine, snap *store.Snapshot, implicitDB
 		preferredIndex = index
 	}
 
+	orderBy, err := stmt.ordering()
+	if err != nil {
+		return nil, err
+	}
+
 	var sortingIndex *Index
 	var descOrder bool
 
-	if stmt.orderBy == nil {
-		if preferredIndex == nil {
-			sortingIndex = table.primaryIndex
-		} else {
+	if orderBy == nil {
+		switch {
+		case preferredIndex != nil:
 			sortingIndex = preferredIndex
+		case stmt.groupBy == nil:
+			// rows need not be ordered, thus the index narrowing the scan the most can be used
+			sortingIndex = table.mostSelectiveIndex(rangesByColID)
+		default:
+			sortingIndex = table.primaryIndex
 		}
 	}
 
-	if len(stmt.orderBy) > 0 {
-		col, err := table.GetColumnByName(stmt.orderBy[0].sel.col)
+	if len(orderBy) > 0 {
+		colIDs, err := orderingColIDs(table, orderBy)
 		if err != nil {
 			return nil, err
 		}
 
-		for _, idx := range table.indexesByColID[col.id] {
-			if idx.sortableUsing(col.id, rangesByColID) {
+		for _, idx := range table.indexesByColID[colIDs[0]] {
+			if idx.sortableUsing(colIDs, rangesByColID) {
 				if preferredIndex == nil || idx.id == preferredIndex.id {
 					sortingIndex = idx
 					break
@@ -1971,7 +4563,18 @@ func (stmt *SelectStmt) genScanSpecs(e *Engine, snap *store.Snapshot, implicitDB
 			}
 		}
 
-		descOrder = stmt.orderBy[0].descOrder
+		descOrder = orderBy[0].descOrder
+
+		// rows will be sorted once read, thus any index may be used
+		if sortingIndex == nil && stmt.sortableInMemory() {
+			sortingIndex = preferredIndex
+
+			if sortingIndex == nil {
+				sortingIndex = table.mostSelectiveIndex(rangesByColID)
+			}
+
+			descOrder = false
+		}
 	}
 
 	if sortingIndex == nil {
@@ -1982,9 +4585,20 @@ func (stmt *SelectStmt) genScanSpecs(e *Engine, snap *store.Snapshot, implicitDB
 		index:         sortingIndex,
 		rangesByColID: rangesByColID,
 		descOrder:     descOrder,
+		cond:          stmt.where,
 	}, nil
 }
 
+// sortedBy tells if the rows are scanned in the order of the column
+func (s *ScanSpecs) sortedBy(orderBy []*OrdCol) (bool, error) {
+	colIDs, err := orderingColIDs(s.index.table, orderBy)
+	if err != nil {
+		return false, err
+	}
+
+	return s.index.sortableUsing(colIDs, s.rangesByColID), nil
+}
+
 type tableRef struct {
 	db       string
 	table    string
@@ -2049,6 +4663,101 @@ func (stmt *tableRef) Alias() string {
 	return stmt.as
 }
 
+// valuesDataSource is a list of rows given as VALUES (...), (...), its columns are named after their position
+type valuesDataSource struct {
+	rows []*RowSpec
+}
+
+func (stmt *valuesDataSource) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	if implicitDB == nil {
+		return ErrNoDatabaseSelected
+	}
+
+	cols, err := stmt.colsSpec(implicitDB.name)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range stmt.rows {
+		for i, val := range row.Values {
+			if cols[i].Type == AnyType {
+				continue
+			}
+
+			err = val.requiresType(cols[i].Type, make(map[string]ColDescriptor), params, implicitDB.name, stmt.Alias())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// colsSpec returns the descriptors of the generated columns, typed after the first value of each column
+// whose type can be known before parameters are provided
+func (stmt *valuesDataSource) colsSpec(dbName string) ([]ColDescriptor, error) {
+	if len(stmt.rows) == 0 || len(stmt.rows[0].Values) == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	cols := make([]ColDescriptor, len(stmt.rows[0].Values))
+
+	for i := range cols {
+		cols[i] = ColDescriptor{
+			Database: dbName,
+			Table:    stmt.Alias(),
+			Column:   fmt.Sprintf("column%d", i+1),
+			Type:     AnyType,
+		}
+	}
+
+	for _, row := range stmt.rows {
+		if len(row.Values) != len(cols) {
+			return nil, ErrInvalidNumberOfValues
+		}
+
+		for i, val := range row.Values {
+			t, err := val.inferType(make(map[string]ColDescriptor), make(map[string]SQLValueType), dbName, stmt.Alias())
+			if err != nil {
+				return nil, err
+			}
+
+			if t == AnyType {
+				continue
+			}
+
+			if cols[i].Type == AnyType {
+				cols[i].Type = t
+				continue
+			}
+
+			if cols[i].Type != t {
+				return nil, ErrInvalidTypes
+			}
+		}
+	}
+
+	return cols, nil
+}
+
+func (stmt *valuesDataSource) Resolve(e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}, _ *ScanSpecs) (RowReader, error) {
+	if implicitDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
+
+	cols, err := stmt.colsSpec(implicitDB.name)
+	if err != nil {
+		return nil, err
+	}
+
+	return newValuesRowReader(implicitDB.name, stmt.Alias(), cols, stmt.rows, params)
+}
+
+func (stmt *valuesDataSource) Alias() string {
+	return "values"
+}
+
 type JoinSpec struct {
 	joinType JoinType
 	ds       DataSource
@@ -2168,11 +4877,12 @@ func (sel *ColSelector) selectorRanges(table *Table, asTable string, params map[
 }
 
 type AggColSelector struct {
-	aggFn AggregateFn
-	db    string
-	table string
-	col   string
-	as    string
+	aggFn    AggregateFn
+	distinct bool
+	db       string
+	table    string
+	col      string
+	as       string
 }
 
 func EncodeSelector(aggFn, db, table, col string) string {
@@ -2190,6 +4900,17 @@ func (sel *AggColSelector) resolve(implicitDB, implicitTable string) (aggFn, db,
 		table = sel.table
 	}
 
+	if sel.distinct {
+		switch sel.aggFn {
+		case COUNT:
+			return COUNT_DISTINCT, db, table, sel.col
+		case SUM:
+			return SUM_DISTINCT, db, table, sel.col
+		case AVG:
+			return AVG_DISTINCT, db, table, sel.col
+		}
+	}
+
 	return sel.aggFn, db, table, sel.col
 }
 
@@ -2217,6 +4938,15 @@ func (sel *AggColSelector) inferType(cols map[string]ColDescriptor, params map[s
 		return IntegerType, nil
 	}
 
+	if sel.aggFn == BOOL_AND || sel.aggFn == BOOL_OR {
+		err := colSelector.requiresType(BooleanType, cols, params, implicitDB, implicitTable)
+		if err != nil {
+			return AnyType, ErrInvalidTypes
+		}
+
+		return BooleanType, nil
+	}
+
 	return colSelector.inferType(cols, params, implicitDB, implicitTable)
 }
 
@@ -2234,31 +4964,294 @@ func (sel *AggColSelector) requiresType(t SQLValueType, cols map[string]ColDescr
 		return colSelector.requiresType(IntegerType, cols, params, implicitDB, implicitTable)
 	}
 
-	return colSelector.requiresType(t, cols, params, implicitDB, implicitTable)
+	if sel.aggFn == BOOL_AND || sel.aggFn == BOOL_OR {
+		if t != BooleanType {
+			return ErrInvalidTypes
+		}
+
+		return colSelector.requiresType(BooleanType, cols, params, implicitDB, implicitTable)
+	}
+
+	return colSelector.requiresType(t, cols, params, implicitDB, implicitTable)
+}
+
+func (sel *AggColSelector) substitute(params map[string]interface{}) (ValueExp, error) {
+	return sel, nil
+}
+
+func (sel *AggColSelector) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	v, ok := row.Values[EncodeSelector(sel.resolve(implicitDB, implicitTable))]
+	if !ok {
+		return nil, ErrColumnDoesNotExist
+	}
+	return v, nil
+}
+
+func (sel *AggColSelector) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return sel
+}
+
+func (sel *AggColSelector) isConstant() bool {
+	return false
+}
+
+func (sel *AggColSelector) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+// WindowFnSelector projects the value of a window function e.g. ROW_NUMBER() OVER (ORDER BY id),
+// it's computed while reading the rows in the order of the window. Values are computed from scratch
+// for each partition, when partitionBy is set
+type WindowFnSelector struct {
+	fn          string
+	params      []ValueExp
+	partitionBy []*ColSelector
+	orderBy     []*OrdCol
+	as          string
+}
+
+func windowFnSelectors(selectors []Selector) []*WindowFnSelector {
+	var windows []*WindowFnSelector
+
+	for _, sel := range selectors {
+		w, isWindow := sel.(*WindowFnSelector)
+		if isWindow {
+			windows = append(windows, w)
+		}
+	}
+
+	return windows
+}
+
+func (sel *WindowFnSelector) validate() error {
+	switch strings.ToUpper(sel.fn) {
+	case "ROW_NUMBER", "RANK", "DENSE_RANK":
+	default:
+		return ErrIllegalArguments
+	}
+
+	if len(sel.params) > 0 {
+		return fmt.Errorf("%w (%s expects %d arguments)", ErrIllegalArguments, strings.ToUpper(sel.fn), 0)
+	}
+
+	if len(sel.orderBy) != 1 {
+		return ErrLimitedWindowFunctions
+	}
+
+	return nil
+}
+
+// ordering returns the columns rows must be sorted by, so the ones of the same partition are read consecutively
+func (sel *WindowFnSelector) ordering() []*OrdCol {
+	ordering := make([]*OrdCol, 0, len(sel.partitionBy)+len(sel.orderBy))
+
+	// partitions are read in the same direction rows are sorted within them, as indexes are scanned
+	for _, col := range sel.partitionBy {
+		ordering = append(ordering, &OrdCol{sel: col, descOrder: sel.orderBy[0].descOrder})
+	}
+
+	return append(ordering, sel.orderBy...)
+}
+
+func (sel *WindowFnSelector) resolve(implicitDB, implicitTable string) (aggFn, db, table, col string) {
+	return strings.ToUpper(sel.fn), implicitDB, implicitTable, "*"
+}
+
+func (sel *WindowFnSelector) alias() string {
+	return sel.as
+}
+
+func (sel *WindowFnSelector) setAlias(alias string) {
+	sel.as = alias
+}
+
+func (sel *WindowFnSelector) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return IntegerType, nil
+}
+
+func (sel *WindowFnSelector) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != IntegerType {
+		return ErrInvalidTypes
+	}
+
+	return nil
+}
+
+func (sel *WindowFnSelector) substitute(params map[string]interface{}) (ValueExp, error) {
+	return sel, nil
+}
+
+func (sel *WindowFnSelector) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	v, ok := row.Values[EncodeSelector(sel.resolve(implicitDB, implicitTable))]
+	if !ok {
+		return nil, ErrColumnDoesNotExist
+	}
+	return v, nil
+}
+
+func (sel *WindowFnSelector) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return sel
+}
+
+func (sel *WindowFnSelector) isConstant() bool {
+	return false
+}
+
+func (sel *WindowFnSelector) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+// ExpSelector projects the value of an arbitrary expression e.g. SELECT active OR false FROM t
+type ExpSelector struct {
+	exp ValueExp
+	as  string
+}
+
+func asSelector(exp ValueExp) Selector {
+	sel, isSelector := exp.(Selector)
+	if isSelector {
+		return sel
+	}
+
+	return &ExpSelector{exp: exp}
+}
+
+func (sel *ExpSelector) resolve(implicitDB, implicitTable string) (aggFn, db, table, col string) {
+	return "", implicitDB, implicitTable, sel.as
+}
+
+func (sel *ExpSelector) alias() string {
+	return sel.as
+}
+
+func (sel *ExpSelector) setAlias(alias string) {
+	sel.as = alias
+}
+
+func (sel *ExpSelector) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return sel.exp.inferType(cols, params, implicitDB, implicitTable)
+}
+
+func (sel *ExpSelector) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	return sel.exp.requiresType(t, cols, params, implicitDB, implicitTable)
+}
+
+func (sel *ExpSelector) substitute(params map[string]interface{}) (ValueExp, error) {
+	exp, err := sel.exp.substitute(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExpSelector{exp: exp, as: sel.as}, nil
 }
 
-func (sel *AggColSelector) substitute(params map[string]interface{}) (ValueExp, error) {
-	return sel, nil
+func (sel *ExpSelector) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return sel.exp.reduce(catalog, row, implicitDB, implicitTable)
 }
 
-func (sel *AggColSelector) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
-	v, ok := row.Values[EncodeSelector(sel.resolve(implicitDB, implicitTable))]
-	if !ok {
-		return nil, ErrColumnDoesNotExist
-	}
-	return v, nil
+func (sel *ExpSelector) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return &ExpSelector{exp: sel.exp.reduceSelectors(row, implicitDB, implicitTable), as: sel.as}
 }
 
-func (sel *AggColSelector) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
-	return sel
+func (sel *ExpSelector) isConstant() bool {
+	return sel.exp.isConstant()
 }
 
-func (sel *AggColSelector) isConstant() bool {
+func (sel *ExpSelector) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+// containsAggregation returns true if an aggregation is used within the expression
+func containsAggregation(exp ValueExp) bool {
+	switch e := exp.(type) {
+	case *AggColSelector:
+		return true
+	case *ExpSelector:
+		return containsAggregation(e.exp)
+	case *NumExp:
+		return containsAggregation(e.left) || containsAggregation(e.right)
+	case *CmpBoolExp:
+		return containsAggregation(e.left) || containsAggregation(e.right)
+	case *BinBoolExp:
+		return containsAggregation(e.left) || containsAggregation(e.right)
+	case *NotBoolExp:
+		return containsAggregation(e.exp)
+	case *IsBoolExp:
+		return containsAggregation(e.exp)
+	case *LikeBoolExp:
+		return containsAggregation(e.val) || containsAggregation(e.pattern)
+	case *InSubQueryExp:
+		return containsAggregation(e.val)
+	case *InListExp:
+		if containsAggregation(e.val) {
+			return true
+		}
+
+		for _, v := range e.values {
+			if containsAggregation(v) {
+				return true
+			}
+		}
+	case *SysFn:
+		for _, p := range e.params {
+			if containsAggregation(p) {
+				return true
+			}
+		}
+	}
+
 	return false
 }
 
-func (sel *AggColSelector) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
-	return nil
+// foldConstants returns an equivalent expression where fully-constant subexpressions are replaced by
+// their values, so they are evaluated once instead of per row. Params must be already substituted.
+// Constant subexpressions failing to be evaluated are kept as they are, so the error is returned when reading rows
+func foldConstants(exp ValueExp) ValueExp {
+	if _, isValue := exp.(TypedValue); isValue {
+		return exp
+	}
+
+	if exp.isConstant() {
+		v, err := exp.reduce(nil, nil, "", "")
+		if err == nil {
+			return v
+		}
+
+		return exp
+	}
+
+	switch e := exp.(type) {
+	case *ExpSelector:
+		return &ExpSelector{exp: foldConstants(e.exp), as: e.as}
+	case *NumExp:
+		return &NumExp{op: e.op, left: foldConstants(e.left), right: foldConstants(e.right)}
+	case *CmpBoolExp:
+		return &CmpBoolExp{op: e.op, left: foldConstants(e.left), right: foldConstants(e.right)}
+	case *BinBoolExp:
+		return &BinBoolExp{op: e.op, left: foldConstants(e.left), right: foldConstants(e.right)}
+	case *NotBoolExp:
+		return &NotBoolExp{exp: foldConstants(e.exp)}
+	case *IsBoolExp:
+		return &IsBoolExp{exp: foldConstants(e.exp), not: e.not, unknown: e.unknown, val: e.val}
+	case *InListExp:
+		values := make([]ValueExp, len(e.values))
+
+		for i, v := range e.values {
+			values[i] = foldConstants(v)
+		}
+
+		return &InListExp{val: foldConstants(e.val), notIn: e.notIn, values: values}
+	case *SysFn:
+		params := make([]ValueExp, len(e.params))
+
+		for i, p := range e.params {
+			params[i] = foldConstants(p)
+		}
+
+		return &SysFn{fn: e.fn, params: params}
+	}
+
+	return exp
 }
 
 type NumExp struct {
@@ -2336,14 +5329,26 @@ func (bexp *NumExp) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable
 		return nil, fmt.Errorf("%w (expecting numeric value)", ErrInvalidValue)
 	}
 
+	// results out of the range of integers are reported instead of wrapped around,
+	// note unary minus is parsed as a subtraction from zero thus -x overflows when x is the lowest integer
 	switch bexp.op {
 	case ADDOP:
 		{
-			return &Number{val: nl + nr}, nil
+			n := nl + nr
+			if (nr > 0 && n < nl) || (nr < 0 && n > nl) {
+				return nil, ErrArithmeticOverflow
+			}
+
+			return &Number{val: n}, nil
 		}
 	case SUBSOP:
 		{
-			return &Number{val: nl - nr}, nil
+			n := nl - nr
+			if (nr > 0 && n > nl) || (nr < 0 && n < nl) {
+				return nil, ErrArithmeticOverflow
+			}
+
+			return &Number{val: n}, nil
 		}
 	case DIVOP:
 		{
@@ -2351,11 +5356,20 @@ func (bexp *NumExp) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable
 				return nil, ErrDivisionByZero
 			}
 
+			if nl == math.MinInt64 && nr == -1 {
+				return nil, ErrArithmeticOverflow
+			}
+
 			return &Number{val: nl / nr}, nil
 		}
 	case MULTOP:
 		{
-			return &Number{val: nl * nr}, nil
+			n := nl * nr
+			if (nl == -1 && nr == math.MinInt64) || (nr == -1 && nl == math.MinInt64) || (nl != 0 && n/nl != nr) {
+				return nil, ErrArithmeticOverflow
+			}
+
+			return &Number{val: n}, nil
 		}
 	}
 
@@ -2416,6 +5430,10 @@ func (bexp *NotBoolExp) reduce(catalog *Catalog, row *Row, implicitDB, implicitT
 		return nil, err
 	}
 
+	if isNullBool(v) {
+		return &NullValue{t: BooleanType}, nil
+	}
+
 	r, isBool := v.Value().(bool)
 	if !isBool {
 		return nil, ErrInvalidCondition
@@ -2438,10 +5456,89 @@ func (bexp *NotBoolExp) selectorRanges(table *Table, asTable string, params map[
 	return nil
 }
 
+// IsBoolExp tests a boolean against TRUE, FALSE or UNKNOWN i.e. NULL e.g. active IS NOT TRUE.
+// Unlike comparisons and NOT, it yields either TRUE or FALSE even when the tested value is NULL
+type IsBoolExp struct {
+	exp     ValueExp
+	not     bool
+	unknown bool // tests for NULL, val is ignored
+	val     bool
+}
+
+func (bexp *IsBoolExp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	err := bexp.exp.requiresType(BooleanType, cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return AnyType, err
+	}
+
+	return BooleanType, nil
+}
+
+func (bexp *IsBoolExp) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != BooleanType {
+		return ErrInvalidTypes
+	}
+
+	return bexp.exp.requiresType(BooleanType, cols, params, implicitDB, implicitTable)
+}
+
+func (bexp *IsBoolExp) substitute(params map[string]interface{}) (ValueExp, error) {
+	rexp, err := bexp.exp.substitute(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IsBoolExp{exp: rexp, not: bexp.not, unknown: bexp.unknown, val: bexp.val}, nil
+}
+
+func (bexp *IsBoolExp) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	v, err := bexp.exp.reduce(catalog, row, implicitDB, implicitTable)
+	if err != nil {
+		return nil, err
+	}
+
+	var satisfied bool
+
+	if isNullBool(v) {
+		satisfied = bexp.unknown
+	} else {
+		r, isBool := v.Value().(bool)
+		if !isBool {
+			return nil, ErrInvalidCondition
+		}
+
+		satisfied = !bexp.unknown && r == bexp.val
+	}
+
+	return &Bool{val: satisfied != bexp.not}, nil
+}
+
+func (bexp *IsBoolExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return &IsBoolExp{
+		exp:     bexp.exp.reduceSelectors(row, implicitDB, implicitTable),
+		not:     bexp.not,
+		unknown: bexp.unknown,
+		val:     bexp.val,
+	}
+}
+
+func (bexp *IsBoolExp) isConstant() bool {
+	return bexp.exp.isConstant()
+}
+
+func (bexp *IsBoolExp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+// LikeBoolExp matches val against a regular expression. When an ESCAPE character is specified,
+// the pattern is instead interpreted as a standard SQL pattern where '%' matches any sequence of characters,
+// '_' matches a single character and the escape character makes the following one to be matched literally
 type LikeBoolExp struct {
-	val     ValueExp
-	notLike bool
-	pattern ValueExp
+	val        ValueExp
+	notLike    bool
+	pattern    ValueExp
+	withEscape bool
+	escape     string
 }
 
 func (bexp *LikeBoolExp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
@@ -2490,9 +5587,11 @@ func (bexp *LikeBoolExp) substitute(params map[string]interface{}) (ValueExp, er
 	}
 
 	return &LikeBoolExp{
-		val:     val,
-		notLike: bexp.notLike,
-		pattern: pattern,
+		val:        val,
+		notLike:    bexp.notLike,
+		pattern:    pattern,
+		withEscape: bexp.withEscape,
+		escape:     bexp.escape,
 	}, nil
 }
 
@@ -2506,7 +5605,7 @@ func (bexp *LikeBoolExp) reduce(catalog *Catalog, row *Row, implicitDB, implicit
 		return nil, fmt.Errorf("error in 'LIKE' clause: %w", err)
 	}
 
-	if rval.Type() != VarcharType {
+	if rval.Value() != nil && rval.Type() != VarcharType {
 		return nil, fmt.Errorf("error in 'LIKE' clause: %w (expecting %s)", ErrInvalidTypes, VarcharType)
 	}
 
@@ -2515,11 +5614,21 @@ func (bexp *LikeBoolExp) reduce(catalog *Catalog, row *Row, implicitDB, implicit
 		return nil, fmt.Errorf("error in 'LIKE' clause: %w", err)
 	}
 
-	if rpattern.Type() != VarcharType {
+	if rpattern.Value() != nil && rpattern.Type() != VarcharType {
 		return nil, fmt.Errorf("error evaluating 'LIKE' clause: %w", ErrInvalidTypes)
 	}
 
-	matched, err := regexp.MatchString(rpattern.Value().(string), rval.Value().(string))
+	// matching NULL values or NULL patterns is unknown
+	if rval.Value() == nil || rpattern.Value() == nil {
+		return &NullValue{t: BooleanType}, nil
+	}
+
+	pattern, err := bexp.regexpPattern(rpattern.Value().(string))
+	if err != nil {
+		return nil, fmt.Errorf("error in 'LIKE' clause: %w", err)
+	}
+
+	matched, err := regexp.MatchString(pattern, rval.Value().(string))
 	if err != nil {
 		return nil, fmt.Errorf("error in 'LIKE' clause: %w", err)
 	}
@@ -2527,6 +5636,49 @@ func (bexp *LikeBoolExp) reduce(catalog *Catalog, row *Row, implicitDB, implicit
 	return &Bool{val: matched != bexp.notLike}, nil
 }
 
+// regexpPattern returns the regular expression to be matched,
+// SQL patterns are translated quoting any character with a special meaning in regular expressions
+func (bexp *LikeBoolExp) regexpPattern(pattern string) (string, error) {
+	if !bexp.withEscape {
+		return pattern, nil
+	}
+
+	escape := []rune(bexp.escape)
+	if len(escape) != 1 {
+		return "", fmt.Errorf("%w (escape must be a single character)", ErrInvalidCondition)
+	}
+
+	var b strings.Builder
+
+	b.WriteString("(?s)^")
+
+	escaped := false
+
+	for _, ch := range pattern {
+		switch {
+		case escaped:
+			b.WriteString(regexp.QuoteMeta(string(ch)))
+			escaped = false
+		case ch == escape[0]:
+			escaped = true
+		case ch == '%':
+			b.WriteString(".*")
+		case ch == '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+
+	if escaped {
+		return "", fmt.Errorf("%w (pattern ends with the escape character)", ErrInvalidCondition)
+	}
+
+	b.WriteString("$")
+
+	return b.String(), nil
+}
+
 func (bexp *LikeBoolExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
 	return bexp
 }
@@ -2535,8 +5687,105 @@ func (bexp *LikeBoolExp) isConstant() bool {
 	return false
 }
 
+// selectorRanges narrows the scan when the pattern is anchored at the beginning of the text
+// e.g. title LIKE '^abc' is restricted to the range [abc, abd)
 func (bexp *LikeBoolExp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
-	return nil
+	sel, isSel := bexp.val.(*ColSelector)
+	if bexp.notLike || !isSel || bexp.pattern == nil || !bexp.pattern.isConstant() {
+		return nil
+	}
+
+	aggFn, db, t, col := sel.resolve(table.db.name, table.name)
+	if aggFn != "" || db != table.db.name || t != asTable {
+		return nil
+	}
+
+	column, err := table.GetColumnByName(col)
+	if err != nil {
+		return err
+	}
+
+	if column.colType != VarcharType {
+		return nil
+	}
+
+	pattern, err := bexp.pattern.substitute(params)
+	if err == ErrMissingParameter {
+		// ranges are narrowed once parameters are provided, see rawRowReader.SetParameters
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	rpattern, err := pattern.reduce(nil, nil, table.db.name, table.name)
+	if err != nil {
+		return err
+	}
+
+	if rpattern.Type() != VarcharType {
+		// invalid patterns are reported when evaluating the condition
+		return nil
+	}
+
+	regexpPattern, err := bexp.regexpPattern(rpattern.Value().(string))
+	if err != nil {
+		// invalid patterns are reported when evaluating the condition
+		return nil
+	}
+
+	prefix, ok := literalPrefix(regexpPattern)
+	if !ok || len(prefix) > column.MaxLen() {
+		return nil
+	}
+
+	err = updateRangeFor(column.id, &Varchar{val: prefix}, GE, rangesByColID)
+	if err != nil {
+		return err
+	}
+
+	upperBound, bounded := prefixUpperBound(prefix)
+	if !bounded {
+		return nil
+	}
+
+	return updateRangeFor(column.id, &Varchar{val: upperBound}, LT, rangesByColID)
+}
+
+// literalPrefix returns the literal text all the strings matching the pattern start with,
+// only patterns anchored at the beginning of the text are considered
+func literalPrefix(pattern string) (string, bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+
+	re = re.Simplify()
+
+	if re.Op != syntax.OpConcat || len(re.Sub) < 2 || re.Sub[0].Op != syntax.OpBeginText {
+		return "", false
+	}
+
+	lit := re.Sub[1]
+	if lit.Op != syntax.OpLiteral || lit.Flags&syntax.FoldCase != 0 {
+		return "", false
+	}
+
+	return string(lit.Rune), true
+}
+
+// prefixUpperBound returns the smallest string greater than any string with the given prefix
+func prefixUpperBound(prefix string) (string, bool) {
+	b := []byte(prefix)
+
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < 0xFF {
+			b[i]++
+			return string(b[:i+1]), true
+		}
+	}
+
+	return "", false
 }
 
 type CmpBoolExp struct {
@@ -2670,7 +5919,7 @@ func (bexp *CmpBoolExp) selectorRanges(table *Table, asTable string, params map[
 
 	val, err := c.substitute(params)
 	if err == ErrMissingParameter {
-		// TODO: not supported when parameters are not provided during query resolution
+		// ranges are narrowed once parameters are provided, see rawRowReader.SetParameters
 		return nil
 	}
 	if err != nil {
@@ -2682,6 +5931,11 @@ func (bexp *CmpBoolExp) selectorRanges(table *Table, asTable string, params map[
 		return err
 	}
 
+	// timestamps are only comparable with timestamps, even though both are encoded as integers
+	if rval.Type() != AnyType && (rval.Type() == TimestampType) != (column.colType == TimestampType) {
+		return ErrNotComparableValues
+	}
+
 	return updateRangeFor(column.id, rval, bexp.op, rangesByColID)
 }
 
@@ -2835,29 +6089,52 @@ func (bexp *BinBoolExp) reduce(catalog *Catalog, row *Row, implicitDB, implicitT
 	}
 
 	bl, isBool := vl.(*Bool)
-	if !isBool {
+	if !isBool && !isNullBool(vl) {
 		return nil, fmt.Errorf("%w (expecting boolean value)", ErrInvalidValue)
 	}
 
 	br, isBool := vr.(*Bool)
-	if !isBool {
+	if !isBool && !isNullBool(vr) {
 		return nil, fmt.Errorf("%w (expecting boolean value)", ErrInvalidValue)
 	}
 
+	// three-valued logic: a NULL operand only decides the result when the other one doesn't
 	switch bexp.op {
 	case AND:
 		{
-			return &Bool{val: bl.val && br.val}, nil
+			if (bl != nil && !bl.val) || (br != nil && !br.val) {
+				return &Bool{val: false}, nil
+			}
+
+			if bl == nil || br == nil {
+				return &NullValue{t: BooleanType}, nil
+			}
+
+			return &Bool{val: true}, nil
 		}
 	case OR:
 		{
-			return &Bool{val: bl.val || br.val}, nil
+			if (bl != nil && bl.val) || (br != nil && br.val) {
+				return &Bool{val: true}, nil
+			}
+
+			if bl == nil || br == nil {
+				return &NullValue{t: BooleanType}, nil
+			}
+
+			return &Bool{val: false}, nil
 		}
 	}
 
 	return nil, ErrUnexpected
 }
 
+// isNullBool returns true when v is a NULL that may stand for an unknown boolean value
+func isNullBool(v TypedValue) bool {
+	n, isNull := v.(*NullValue)
+	return isNull && (n.t == BooleanType || n.t == AnyType)
+}
+
 func (bexp *BinBoolExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
 	return &BinBoolExp{
 		op:    bexp.op,
@@ -3040,7 +6317,7 @@ func (bexp *InListExp) reduce(catalog *Catalog, row *Row, implicitDB, implicitTa
 		return nil, fmt.Errorf("error evaluating 'IN' clause: %w", err)
 	}
 
-	var found bool
+	var found, nullFound bool
 
 	for _, v := range bexp.values {
 		rv, err := v.reduce(catalog, row, implicitDB, implicitTable)
@@ -3053,12 +6330,23 @@ func (bexp *InListExp) reduce(catalog *Catalog, row *Row, implicitDB, implicitTa
 			return nil, fmt.Errorf("error evaluating 'IN' clause: %w", err)
 		}
 
+		if rv.Value() == nil {
+			nullFound = true
+			continue
+		}
+
 		if r == 0 {
 			// TODO: short-circuit evaluation may be preferred when upfront static type inference is in place
 			found = found || true
 		}
 	}
 
+	// as in standard SQL, a value not found in a list containing NULL may still be equal to it,
+	// thus 'x NOT IN (..., NULL)' is NULL instead of true, and so is 'NULL NOT IN (...)'
+	if !found && (nullFound || rval.Value() == nil) {
+		return &NullValue{t: BooleanType}, nil
+	}
+
 	return &Bool{val: found != bexp.notIn}, nil
 }
 
@@ -3071,6 +6359,7 @@ func (bexp *InListExp) reduceSelectors(row *Row, implicitDB, implicitTable strin
 
 	return &InListExp{
 		val:    bexp.val.reduceSelectors(row, implicitDB, implicitTable),
+		notIn:  bexp.notIn,
 		values: values,
 	}
 }
@@ -3079,7 +6368,84 @@ func (bexp *InListExp) isConstant() bool {
 	return false
 }
 
+// selectorRanges narrows the scan to the values from the smallest to the biggest one in the list,
+// as it's done for the equivalent disjunction of equalities, NULL values are skipped as they never match
 func (bexp *InListExp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
-	// TODO: may be determiined by smallest and bigggest value in the list
-	return nil
+	sel, isSel := bexp.val.(*ColSelector)
+	if !isSel || bexp.notIn {
+		return nil
+	}
+
+	aggFn, db, t, col := sel.resolve(table.db.name, table.name)
+	if aggFn != "" || db != table.db.name || t != asTable {
+		return nil
+	}
+
+	// errors are not reported but left to be reported when rows are filtered, thus the scan is just not narrowed
+	column, err := table.GetColumnByName(col)
+	if err != nil {
+		return nil
+	}
+
+	var minVal, maxVal TypedValue
+
+	for _, v := range bexp.values {
+		if !v.isConstant() {
+			return nil
+		}
+
+		// ranges are narrowed once parameters are provided, see rawRowReader.SetParameters
+		val, err := v.substitute(params)
+		if err != nil {
+			return nil
+		}
+
+		rval, err := val.reduce(nil, nil, table.db.name, table.name)
+		if err != nil {
+			return nil
+		}
+
+		if rval.Value() == nil {
+			continue
+		}
+
+		if rval.Type() != column.colType {
+			return nil
+		}
+
+		if minVal == nil {
+			minVal = rval
+			maxVal = rval
+			continue
+		}
+
+		cmp, err := rval.Compare(minVal)
+		if err != nil {
+			return err
+		}
+
+		if cmp < 0 {
+			minVal = rval
+		}
+
+		cmp, err = rval.Compare(maxVal)
+		if err != nil {
+			return err
+		}
+
+		if cmp > 0 {
+			maxVal = rval
+		}
+	}
+
+	if minVal == nil {
+		return nil
+	}
+
+	err = updateRangeFor(column.id, minVal, GE, rangesByColID)
+	if err != nil {
+		return err
+	}
+
+	return updateRangeFor(column.id, maxVal, LE, rangesByColID)
 }
@@ -416,9 +416,18 @@ func TestRequiresTypeSysFnValueExp(t *testing.T) {
 			params:        params,
 			implicitDB:    "db1",
 			implicitTable: "mytable",
-			requiredType:  IntegerType,
+			requiredType:  TimestampType,
 			expectedError: nil,
 		},
+		{
+			exp:           &SysFn{fn: "NOW"},
+			cols:          cols,
+			params:        params,
+			implicitDB:    "db1",
+			implicitTable: "mytable",
+			requiredType:  IntegerType,
+			expectedError: ErrInvalidTypes,
+		},
 		{
 			exp:           &SysFn{fn: "NOW"},
 			cols:          cols,
@@ -437,6 +446,60 @@ func TestRequiresTypeSysFnValueExp(t *testing.T) {
 			requiredType:  VarcharType,
 			expectedError: ErrIllegalArguments,
 		},
+		{
+			exp:           &SysFn{fn: "TRIM", params: []ValueExp{&ColSelector{col: "title"}}},
+			cols:          cols,
+			params:        params,
+			implicitDB:    "db1",
+			implicitTable: "mytable",
+			requiredType:  VarcharType,
+			expectedError: nil,
+		},
+		{
+			exp:           &SysFn{fn: "ltrim", params: []ValueExp{&ColSelector{col: "title"}}},
+			cols:          cols,
+			params:        params,
+			implicitDB:    "db1",
+			implicitTable: "mytable",
+			requiredType:  IntegerType,
+			expectedError: ErrInvalidTypes,
+		},
+		{
+			exp:           &SysFn{fn: "RTRIM", params: []ValueExp{&ColSelector{col: "id"}}},
+			cols:          cols,
+			params:        params,
+			implicitDB:    "db1",
+			implicitTable: "mytable",
+			requiredType:  VarcharType,
+			expectedError: ErrInvalidTypes,
+		},
+		{
+			exp:           &SysFn{fn: "ABS", params: []ValueExp{&ColSelector{col: "id"}}},
+			cols:          cols,
+			params:        params,
+			implicitDB:    "db1",
+			implicitTable: "mytable",
+			requiredType:  IntegerType,
+			expectedError: nil,
+		},
+		{
+			exp:           &SysFn{fn: "abs", params: []ValueExp{&ColSelector{col: "title"}}},
+			cols:          cols,
+			params:        params,
+			implicitDB:    "db1",
+			implicitTable: "mytable",
+			requiredType:  IntegerType,
+			expectedError: ErrInvalidTypes,
+		},
+		{
+			exp:           &SysFn{fn: "REPLACE", params: []ValueExp{&ColSelector{col: "title"}, &Varchar{val: "a"}, &Param{id: "to"}}},
+			cols:          cols,
+			params:        params,
+			implicitDB:    "db1",
+			implicitTable: "mytable",
+			requiredType:  VarcharType,
+			expectedError: nil,
+		},
 	}
 
 	for i, tc := range testCases {
@@ -634,6 +697,198 @@ func TestLikeBoolExpEdgeCases(t *testing.T) {
 		require.ErrorIs(t, err, ErrInvalidTypes)
 	})
 
+	t.Run("like expression with invalid escape", func(t *testing.T) {
+		row := &Row{Values: map[string]TypedValue{"(db1.table1.col1)": &Varchar{val: "abc"}}}
+
+		exp := &LikeBoolExp{val: &ColSelector{col: "col1"}, pattern: &Varchar{val: "a%"}, withEscape: true, escape: "ab"}
+		_, err = exp.reduce(nil, row, "db1", "table1")
+		require.ErrorIs(t, err, ErrInvalidCondition)
+
+		exp = &LikeBoolExp{val: &ColSelector{col: "col1"}, pattern: &Varchar{val: "a%!"}, withEscape: true, escape: "!"}
+		_, err = exp.reduce(nil, row, "db1", "table1")
+		require.ErrorIs(t, err, ErrInvalidCondition)
+	})
+
+	t.Run("like expression with null values", func(t *testing.T) {
+		row := &Row{Values: map[string]TypedValue{"(db1.table1.col1)": &NullValue{t: VarcharType}}}
+
+		exp := &LikeBoolExp{val: &ColSelector{col: "col1"}, pattern: &Varchar{val: "a%"}}
+		v, err := exp.reduce(nil, row, "db1", "table1")
+		require.NoError(t, err)
+		require.Equal(t, &NullValue{t: BooleanType}, v)
+
+		exp = &LikeBoolExp{val: &ColSelector{col: "col1"}, pattern: &Varchar{val: "a%"}, notLike: true, withEscape: true, escape: "!"}
+		v, err = exp.reduce(nil, row, "db1", "table1")
+		require.NoError(t, err)
+		require.Equal(t, &NullValue{t: BooleanType}, v)
+
+		exp = &LikeBoolExp{val: &Varchar{val: "abc"}, pattern: &NullValue{t: VarcharType}}
+		v, err = exp.reduce(nil, nil, "db1", "table1")
+		require.NoError(t, err)
+		require.Equal(t, &NullValue{t: BooleanType}, v)
+	})
+
+}
+
+func TestThreeValuedLogic(t *testing.T) {
+	null := &NullValue{t: BooleanType}
+
+	testCases := []struct {
+		exp      ValueExp
+		expected TypedValue
+	}{
+		{exp: &NotBoolExp{exp: null}, expected: null},
+		{exp: &BinBoolExp{op: AND, left: null, right: &Bool{val: true}}, expected: null},
+		{exp: &BinBoolExp{op: AND, left: &Bool{val: false}, right: null}, expected: &Bool{val: false}},
+		{exp: &BinBoolExp{op: OR, left: null, right: &Bool{val: false}}, expected: null},
+		{exp: &BinBoolExp{op: OR, left: &Bool{val: true}, right: null}, expected: &Bool{val: true}},
+		{exp: &BinBoolExp{op: OR, left: null, right: &NullValue{t: AnyType}}, expected: null},
+		{exp: &InListExp{val: &Number{val: 1}, values: []ValueExp{&Number{val: 1}, &NullValue{t: AnyType}}}, expected: &Bool{val: true}},
+		{exp: &InListExp{val: &Number{val: 1}, values: []ValueExp{&Number{val: 2}, &NullValue{t: AnyType}}}, expected: null},
+		{exp: &InListExp{val: &Number{val: 1}, notIn: true, values: []ValueExp{&Number{val: 1}, &NullValue{t: AnyType}}}, expected: &Bool{val: false}},
+		{exp: &InListExp{val: &Number{val: 1}, notIn: true, values: []ValueExp{&Number{val: 2}, &NullValue{t: AnyType}}}, expected: null},
+		{exp: &InListExp{val: &NullValue{t: IntegerType}, notIn: true, values: []ValueExp{&Number{val: 2}}}, expected: null},
+	}
+
+	for i, tc := range testCases {
+		v, err := tc.exp.reduce(nil, nil, "", "")
+		require.NoError(t, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Equal(t, tc.expected, v, fmt.Sprintf("failed on iteration %d", i))
+	}
+
+	_, err := (&BinBoolExp{op: AND, left: &NullValue{t: IntegerType}, right: &Bool{val: true}}).reduce(nil, nil, "", "")
+	require.ErrorIs(t, err, ErrInvalidValue)
+}
+
+func TestCompareNullValues(t *testing.T) {
+	values := []TypedValue{
+		&Number{val: 1},
+		&Varchar{val: "title"},
+		&Bool{val: false},
+		&Blob{val: []byte{}},
+	}
+
+	for _, v := range values {
+		t.Run(string(v.Type()), func(t *testing.T) {
+			null := &NullValue{t: v.Type()}
+
+			for _, n := range []TypedValue{null, &NullValue{t: AnyType}, &MinValue{val: null}} {
+				cmp, err := null.Compare(n)
+				require.NoError(t, err)
+				require.Zero(t, cmp)
+
+				cmp, err = n.Compare(null)
+				require.NoError(t, err)
+				require.Zero(t, cmp)
+
+				cmp, err = n.Compare(v)
+				require.NoError(t, err)
+				require.Equal(t, -1, cmp)
+
+				cmp, err = v.Compare(n)
+				require.NoError(t, err)
+				require.Equal(t, 1, cmp)
+			}
+
+			for _, other := range values {
+				if other.Type() == v.Type() {
+					continue
+				}
+
+				otherNull := &NullValue{t: other.Type()}
+
+				_, err := null.Compare(otherNull)
+				require.ErrorIs(t, err, ErrNotComparableValues)
+
+				_, err = null.Compare(other)
+				require.ErrorIs(t, err, ErrNotComparableValues)
+
+				_, err = v.Compare(otherNull)
+				require.ErrorIs(t, err, ErrNotComparableValues)
+
+				_, err = v.Compare(other)
+				require.ErrorIs(t, err, ErrNotComparableValues)
+			}
+		})
+	}
+}
+
+func TestFoldConstants(t *testing.T) {
+	col := &ColSelector{col: "col1"}
+
+	testCases := []struct {
+		exp      ValueExp
+		expected ValueExp
+	}{
+		{
+			exp:      &NumExp{op: ADDOP, left: &Number{val: 1}, right: &Number{val: 2}},
+			expected: &Number{val: 3},
+		},
+		{
+			exp:      &NumExp{op: MULTOP, left: col, right: &NumExp{op: SUBSOP, left: &Number{val: 5}, right: &Number{val: 2}}},
+			expected: &NumExp{op: MULTOP, left: col, right: &Number{val: 3}},
+		},
+		{
+			exp:      &BinBoolExp{op: AND, left: &CmpBoolExp{op: GT, left: col, right: &Number{val: 1}}, right: &NotBoolExp{exp: &Bool{val: false}}},
+			expected: &BinBoolExp{op: AND, left: &CmpBoolExp{op: GT, left: col, right: &Number{val: 1}}, right: &Bool{val: true}},
+		},
+		{
+			exp:      &InListExp{val: col, values: []ValueExp{&NumExp{op: ADDOP, left: &Number{val: 1}, right: &Number{val: 1}}, col}},
+			expected: &InListExp{val: col, values: []ValueExp{&Number{val: 2}, col}},
+		},
+		{
+			exp:      &SysFn{fn: "NOW", params: []ValueExp{}},
+			expected: &SysFn{fn: "NOW", params: []ValueExp{}},
+		},
+		{
+			exp:      &NumExp{op: DIVOP, left: &Number{val: 1}, right: &Number{val: 0}},
+			expected: &NumExp{op: DIVOP, left: &Number{val: 1}, right: &Number{val: 0}},
+		},
+	}
+
+	for i, tc := range testCases {
+		require.Equal(t, tc.expected, foldConstants(tc.exp), fmt.Sprintf("failed on iteration %d", i))
+	}
+}
+
+func TestLiteralPrefix(t *testing.T) {
+	testCases := []struct {
+		pattern        string
+		expectedPrefix string
+		expectedFound  bool
+	}{
+		{pattern: "^abc", expectedPrefix: "abc", expectedFound: true},
+		{pattern: "^abc.*", expectedPrefix: "abc", expectedFound: true},
+		{pattern: "^abc[0-9]+$", expectedPrefix: "abc", expectedFound: true},
+		{pattern: "^abc?", expectedPrefix: "ab", expectedFound: true},
+		{pattern: "^ab(c|d)", expectedPrefix: "ab", expectedFound: true},
+		{pattern: "abc", expectedFound: false},
+		{pattern: ".*abc", expectedFound: false},
+		{pattern: "^.abc", expectedFound: false},
+		{pattern: "^", expectedFound: false},
+		{pattern: "(?i)^abc", expectedFound: false},
+		{pattern: "(?m)^abc", expectedFound: false},
+		{pattern: "^abc(", expectedFound: false},
+	}
+
+	for _, tc := range testCases {
+		prefix, found := literalPrefix(tc.pattern)
+		require.Equal(t, tc.expectedFound, found, tc.pattern)
+		require.Equal(t, tc.expectedPrefix, prefix, tc.pattern)
+	}
+}
+
+func TestPrefixUpperBound(t *testing.T) {
+	upperBound, bounded := prefixUpperBound("abc")
+	require.True(t, bounded)
+	require.Equal(t, "abd", upperBound)
+
+	upperBound, bounded = prefixUpperBound("ab\xff\xff")
+	require.True(t, bounded)
+	require.Equal(t, "ac", upperBound)
+
+	_, bounded = prefixUpperBound("\xff")
+	require.False(t, bounded)
 }
 
 func TestAliasing(t *testing.T) {
@@ -695,3 +950,31 @@ func TestIsConstant(t *testing.T) {
 
 	require.False(t, (&ExistsBoolExp{}).isConstant())
 }
+
+func TestGeneratedExpSQL(t *testing.T) {
+	for _, sql := range []string{
+		"(price * qty)",
+		"((price - 1) / 2)",
+		"((qty > 10) AND (NOT active))",
+		"ABS((0 - 5))",
+		"(title = 'title1')",
+		"(data = x'0a0b')",
+		"(active OR FALSE)",
+		"(active IS NOT UNKNOWN)",
+		"((qty > 1) IS FALSE)",
+		"NULL",
+	} {
+		exp, err := parseGeneratedExp(sql)
+		require.NoError(t, err)
+
+		encoded, err := generatedExpSQL(exp)
+		require.NoError(t, err)
+		require.Equal(t, sql, encoded)
+	}
+
+	_, err := generatedExpSQL(&Varchar{val: "it's"})
+	require.ErrorIs(t, err, ErrIllegalGeneratedColumn)
+
+	_, err = generatedExpSQL(&Param{id: "param1"})
+	require.ErrorIs(t, err, ErrIllegalGeneratedColumn)
+}
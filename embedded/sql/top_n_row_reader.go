@@ -0,0 +1,233 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"container/heap"
+	"sort"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+// topNRowReader returns the first n rows according to columns which rows are not sorted by.
+// Rows are kept in a bounded heap while reading them, thus memory usage doesn't depend on the number of rows scanned
+type topNRowReader struct {
+	rowReader RowReader
+
+	n          int
+	ordCols    []ColDescriptor
+	descOrders []bool
+
+	rows []*Row // sorted rows once the underlying reader is fully read
+	read int
+}
+
+func (e *Engine) newTopNRowReader(rowReader RowReader, orderBy []*OrdCol, n int) (*topNRowReader, error) {
+	if rowReader == nil || len(orderBy) == 0 || n < 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	cols, err := rowReader.colsBySelector()
+	if err != nil {
+		return nil, err
+	}
+
+	ordCols := make([]ColDescriptor, len(orderBy))
+	descOrders := make([]bool, len(orderBy))
+
+	for i, ordCol := range orderBy {
+		col, ok := cols[EncodeSelector(ordCol.sel.resolve(rowReader.ImplicitDB(), rowReader.ImplicitTable()))]
+		if !ok {
+			return nil, ErrColumnDoesNotExist
+		}
+
+		ordCols[i] = col
+		descOrders[i] = ordCol.descOrder
+	}
+
+	return &topNRowReader{
+		rowReader:  rowReader,
+		n:          n,
+		ordCols:    ordCols,
+		descOrders: descOrders,
+	}, nil
+}
+
+func (tr *topNRowReader) ImplicitDB() string {
+	return tr.rowReader.ImplicitDB()
+}
+
+func (tr *topNRowReader) ImplicitTable() string {
+	return tr.rowReader.ImplicitTable()
+}
+
+func (tr *topNRowReader) SetParameters(params map[string]interface{}) error {
+	return tr.rowReader.SetParameters(params)
+}
+
+func (tr *topNRowReader) OrderBy() []ColDescriptor {
+	return tr.ordCols
+}
+
+func (tr *topNRowReader) ScanSpecs() *ScanSpecs {
+	return tr.rowReader.ScanSpecs()
+}
+
+func (tr *topNRowReader) TxHeader() (*store.TxHeader, error) {
+	return ReaderTxHeader(tr.rowReader)
+}
+
+// Cursor is not supported, rows are not returned in the order they are scanned
+func (tr *topNRowReader) Cursor() ([]byte, error) {
+	return nil, ErrNoSupported
+}
+
+func (tr *topNRowReader) Columns() ([]ColDescriptor, error) {
+	return tr.rowReader.Columns()
+}
+
+func (tr *topNRowReader) colsBySelector() (map[string]ColDescriptor, error) {
+	return tr.rowReader.colsBySelector()
+}
+
+func (tr *topNRowReader) InferParameters(params map[string]SQLValueType) error {
+	return tr.rowReader.InferParameters(params)
+}
+
+func (tr *topNRowReader) Read() (*Row, error) {
+	if tr.rows == nil {
+		rows, err := tr.topRows()
+		if err != nil {
+			return nil, err
+		}
+
+		tr.rows = rows
+	}
+
+	if tr.read == len(tr.rows) {
+		return nil, ErrNoMoreRows
+	}
+
+	row := tr.rows[tr.read]
+	tr.read++
+
+	return row, nil
+}
+
+// topRows reads all the rows, keeping the first n ones sorted
+func (tr *topNRowReader) topRows() ([]*Row, error) {
+	if tr.n == 0 {
+		return []*Row{}, nil
+	}
+
+	h := &rowHeap{
+		sels:       make([]string, len(tr.ordCols)),
+		descOrders: tr.descOrders,
+	}
+
+	for i := range tr.ordCols {
+		h.sels[i] = tr.ordCols[i].Selector()
+	}
+
+	for seq := 0; ; seq++ {
+		row, err := tr.rowReader.Read()
+		if err == ErrNoMoreRows {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		heap.Push(h, &heapRow{row: row, seq: seq})
+
+		if len(h.rows) > tr.n {
+			heap.Pop(h)
+		}
+
+		if h.err != nil {
+			return nil, h.err
+		}
+	}
+
+	// rows going last are considered lower by the heap
+	sort.Sort(sort.Reverse(h))
+
+	if h.err != nil {
+		return nil, h.err
+	}
+
+	rows := make([]*Row, len(h.rows))
+	for i, r := range h.rows {
+		rows[i] = r.row
+	}
+
+	return rows, nil
+}
+
+func (tr *topNRowReader) Close() error {
+	return tr.rowReader.Close()
+}
+
+type heapRow struct {
+	row *Row
+	seq int // rows read first go first when sorting values are equal
+}
+
+// rowHeap is a max-heap of rows, the row going last according to the sorting columns is on top
+type rowHeap struct {
+	rows       []*heapRow
+	sels       []string
+	descOrders []bool
+	err        error // values that can not be compared, it's kept since the heap interface can't return errors
+}
+
+func (h *rowHeap) Len() int {
+	return len(h.rows)
+}
+
+func (h *rowHeap) Less(i, j int) bool {
+	for k, sel := range h.sels {
+		cmp, err := h.rows[i].row.Values[sel].Compare(h.rows[j].row.Values[sel])
+		if err != nil {
+			h.err = err
+			return false
+		}
+
+		if h.descOrders[k] {
+			cmp = -cmp
+		}
+
+		if cmp != 0 {
+			return cmp > 0
+		}
+	}
+
+	return h.rows[i].seq > h.rows[j].seq
+}
+
+func (h *rowHeap) Swap(i, j int) {
+	h.rows[i], h.rows[j] = h.rows[j], h.rows[i]
+}
+
+func (h *rowHeap) Push(x interface{}) {
+	h.rows = append(h.rows, x.(*heapRow))
+}
+
+func (h *rowHeap) Pop() interface{} {
+	last := h.rows[len(h.rows)-1]
+	h.rows = h.rows[:len(h.rows)-1]
+	return last
+}
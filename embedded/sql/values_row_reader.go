@@ -0,0 +1,145 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+type valuesRowReader struct {
+	dbName     string
+	tableAlias string
+	colsByPos  []ColDescriptor
+	colsBySel  map[string]ColDescriptor
+	rows       []*RowSpec
+	params     map[string]interface{}
+	read       int
+}
+
+func newValuesRowReader(dbName, tableAlias string, cols []ColDescriptor, rows []*RowSpec, params map[string]interface{}) (*valuesRowReader, error) {
+	if len(cols) == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	colsBySel := make(map[string]ColDescriptor, len(cols))
+
+	for _, col := range cols {
+		colsBySel[col.Selector()] = col
+	}
+
+	return &valuesRowReader{
+		dbName:     dbName,
+		tableAlias: tableAlias,
+		colsByPos:  cols,
+		colsBySel:  colsBySel,
+		rows:       rows,
+		params:     params,
+	}, nil
+}
+
+func (r *valuesRowReader) ImplicitDB() string {
+	return r.dbName
+}
+
+func (r *valuesRowReader) ImplicitTable() string {
+	return r.tableAlias
+}
+
+// OrderBy returns no columns as rows are read in the given order
+func (r *valuesRowReader) OrderBy() []ColDescriptor {
+	return nil
+}
+
+func (r *valuesRowReader) ScanSpecs() *ScanSpecs {
+	return nil
+}
+
+// Cursor is not supported, values are not scanned from any table
+func (r *valuesRowReader) Cursor() ([]byte, error) {
+	return nil, ErrNoSupported
+}
+
+func (r *valuesRowReader) Columns() ([]ColDescriptor, error) {
+	ret := make([]ColDescriptor, len(r.colsByPos))
+	copy(ret, r.colsByPos)
+	return ret, nil
+}
+
+func (r *valuesRowReader) colsBySelector() (map[string]ColDescriptor, error) {
+	ret := make(map[string]ColDescriptor, len(r.colsBySel))
+	for sel := range r.colsBySel {
+		ret[sel] = r.colsBySel[sel]
+	}
+	return ret, nil
+}
+
+func (r *valuesRowReader) InferParameters(params map[string]SQLValueType) error {
+	for _, row := range r.rows {
+		for i, val := range row.Values {
+			if r.colsByPos[i].Type == AnyType {
+				continue
+			}
+
+			err := val.requiresType(r.colsByPos[i].Type, make(map[string]ColDescriptor), params, r.dbName, r.tableAlias)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *valuesRowReader) SetParameters(params map[string]interface{}) (err error) {
+	r.params, err = normalizeParams(params)
+	return err
+}
+
+func (r *valuesRowReader) Read() (*Row, error) {
+	if r.read == len(r.rows) {
+		return nil, ErrNoMoreRows
+	}
+
+	values := make(map[string]TypedValue, len(r.colsByPos))
+
+	for i, exp := range r.rows[r.read].Values {
+		col := r.colsByPos[i]
+
+		sexp, err := exp.substitute(r.params)
+		if err != nil {
+			return nil, err
+		}
+
+		val, err := sexp.reduce(nil, nil, r.dbName, r.tableAlias)
+		if err != nil {
+			return nil, err
+		}
+
+		if val.Type() != AnyType && col.Type != AnyType && val.Type() != col.Type {
+			return nil, ErrInvalidTypes
+		}
+
+		if _, isNull := val.(*NullValue); isNull {
+			val = &NullValue{t: col.Type}
+		}
+
+		values[col.Selector()] = val
+	}
+
+	r.read++
+
+	return &Row{Values: values}, nil
+}
+
+func (r *valuesRowReader) Close() error {
+	return nil
+}
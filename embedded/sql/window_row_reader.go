@@ -0,0 +1,245 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"strings"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+// windowRowReader computes the value of window functions while reading the rows, which are already sorted
+// as required by the window, so a new partition starts whenever the value of any partitioning column changes
+type windowRowReader struct {
+	rowReader RowReader
+
+	windows []*WindowFnSelector
+
+	partitionCols []string // selectors of the columns the window is partitioned by
+	ordCol        string   // selector of the column the window is ordered by
+
+	partitionVals []TypedValue
+
+	rowNumber int64
+	rank      int64 // number of the first row holding the current value of the ordering column
+	denseRank int64 // number of distinct values of the ordering column read so far
+	ordVal    TypedValue
+
+	cols colsCache
+}
+
+func (e *Engine) newWindowRowReader(rowReader RowReader, windows []*WindowFnSelector) (*windowRowReader, error) {
+	if rowReader == nil || len(windows) == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	partitionCols := make([]string, len(windows[0].partitionBy))
+
+	for i, col := range windows[0].partitionBy {
+		partitionCols[i] = EncodeSelector(col.resolve(rowReader.ImplicitDB(), rowReader.ImplicitTable()))
+	}
+
+	return &windowRowReader{
+		rowReader:     rowReader,
+		windows:       windows,
+		partitionCols: partitionCols,
+		ordCol:        EncodeSelector(windows[0].orderBy[0].sel.resolve(rowReader.ImplicitDB(), rowReader.ImplicitTable())),
+	}, nil
+}
+
+func (wr *windowRowReader) ImplicitDB() string {
+	return wr.rowReader.ImplicitDB()
+}
+
+func (wr *windowRowReader) ImplicitTable() string {
+	return wr.rowReader.ImplicitTable()
+}
+
+func (wr *windowRowReader) SetParameters(params map[string]interface{}) error {
+	return wr.rowReader.SetParameters(params)
+}
+
+func (wr *windowRowReader) OrderBy() []ColDescriptor {
+	return wr.rowReader.OrderBy()
+}
+
+func (wr *windowRowReader) ScanSpecs() *ScanSpecs {
+	return wr.rowReader.ScanSpecs()
+}
+
+func (wr *windowRowReader) TxHeader() (*store.TxHeader, error) {
+	return ReaderTxHeader(wr.rowReader)
+}
+
+// Cursor is not supported, window values depend on the rows of previous pages
+func (wr *windowRowReader) Cursor() ([]byte, error) {
+	return nil, ErrNoSupported
+}
+
+func (wr *windowRowReader) InferParameters(params map[string]SQLValueType) error {
+	return wr.rowReader.InferParameters(params)
+}
+
+// windowCol returns the descriptor of the column holding the value of the window function
+func (wr *windowRowReader) windowCol(w *WindowFnSelector) ColDescriptor {
+	aggFn, db, table, col := w.resolve(wr.rowReader.ImplicitDB(), wr.rowReader.ImplicitTable())
+
+	return ColDescriptor{
+		AggFn:    aggFn,
+		Database: db,
+		Table:    table,
+		Column:   col,
+		Type:     IntegerType,
+	}
+}
+
+func (wr *windowRowReader) Columns() ([]ColDescriptor, error) {
+	return wr.cols.columns(wr.resolveColumns)
+}
+
+func (wr *windowRowReader) resolveColumns() ([]ColDescriptor, error) {
+	dsCols, err := wr.rowReader.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	cols := make([]ColDescriptor, len(dsCols), len(dsCols)+len(wr.windows))
+	copy(cols, dsCols)
+
+	for _, w := range wr.windows {
+		cols = append(cols, wr.windowCol(w))
+	}
+
+	return cols, nil
+}
+
+func (wr *windowRowReader) colsBySelector() (map[string]ColDescriptor, error) {
+	return wr.cols.columnsBySelector(wr.resolveColsBySelector)
+}
+
+func (wr *windowRowReader) resolveColsBySelector() (map[string]ColDescriptor, error) {
+	dsCols, err := wr.rowReader.colsBySelector()
+	if err != nil {
+		return nil, err
+	}
+
+	cols := make(map[string]ColDescriptor, len(dsCols)+len(wr.windows))
+	for sel, des := range dsCols {
+		cols[sel] = des
+	}
+
+	for _, w := range wr.windows {
+		des := wr.windowCol(w)
+		cols[des.Selector()] = des
+	}
+
+	return cols, nil
+}
+
+func (wr *windowRowReader) Read() (*Row, error) {
+	row, err := wr.rowReader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	newPartition, err := wr.readPartition(row)
+	if err != nil {
+		return nil, err
+	}
+
+	if newPartition {
+		wr.rowNumber = 0
+		wr.denseRank = 0
+		wr.ordVal = nil
+	}
+
+	wr.rowNumber++
+
+	// rows holding the same value of the ordering column are tied, thus they get the same rank
+	ordVal, ok := row.Values[wr.ordCol]
+	if !ok {
+		return nil, ErrColumnDoesNotExist
+	}
+
+	tied := false
+
+	if wr.ordVal != nil {
+		cmp, err := wr.ordVal.Compare(ordVal)
+		if err != nil {
+			return nil, err
+		}
+
+		tied = cmp == 0
+	}
+
+	if !tied {
+		wr.rank = wr.rowNumber
+		wr.denseRank++
+		wr.ordVal = ordVal
+	}
+
+	for _, w := range wr.windows {
+		var val int64
+
+		switch strings.ToUpper(w.fn) {
+		case "ROW_NUMBER":
+			val = wr.rowNumber
+		case "RANK":
+			val = wr.rank
+		case "DENSE_RANK":
+			val = wr.denseRank
+		}
+
+		des := wr.windowCol(w)
+		row.Values[des.Selector()] = &Number{val: val}
+	}
+
+	return row, nil
+}
+
+// readPartition tells if the row is the first one of a partition
+func (wr *windowRowReader) readPartition(row *Row) (bool, error) {
+	vals := make([]TypedValue, len(wr.partitionCols))
+	newPartition := wr.partitionVals == nil
+
+	for i, col := range wr.partitionCols {
+		val, ok := row.Values[col]
+		if !ok {
+			return false, ErrColumnDoesNotExist
+		}
+
+		vals[i] = val
+
+		if newPartition {
+			continue
+		}
+
+		cmp, err := wr.partitionVals[i].Compare(val)
+		if err != nil {
+			return false, err
+		}
+
+		newPartition = cmp != 0
+	}
+
+	wr.partitionVals = vals
+
+	return newPartition, nil
+}
+
+func (wr *windowRowReader) Close() error {
+	return wr.rowReader.Close()
+}
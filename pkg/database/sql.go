@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/codenotary/immudb/embedded/sql"
 	"github.com/codenotary/immudb/embedded/store"
@@ -140,7 +141,7 @@ func (d *db) VerifiableSQLGet(req *schema.VerifiableSQLGetRequest) (*schema.Veri
 	for _, col := range table.Cols() {
 		colNamesByID[col.ID()] = col.Name()
 		colIdsByName[sql.EncodeSelector("", d.options.dbName, table.Name(), col.Name())] = col.ID()
-		colTypesByID[col.ID()] = col.Type()
+		colTypesByID[col.ID()] = col.TypeAt(e.Tx) // the type the row was encoded with
 		colLenByID[col.ID()] = int32(col.MaxLen())
 	}
 
@@ -258,6 +259,7 @@ func (d *db) DescribeTable(tableName string) (*schema.SQLQueryResult, error) {
 		{Name: "INDEX", Type: sql.VarcharType},
 		{Name: "AUTO_INCREMENT", Type: sql.BooleanType},
 		{Name: "UNIQUE", Type: sql.BooleanType},
+		{Name: "COMMENT", Type: sql.VarcharType},
 	}}
 
 	for _, c := range table.Cols() {
@@ -297,6 +299,7 @@ func (d *db) DescribeTable(tableName string) (*schema.SQLQueryResult, error) {
 				{Value: &schema.SQLValue_S{S: index}},
 				{Value: &schema.SQLValue_B{B: c.IsAutoIncremental()}},
 				{Value: &schema.SQLValue_B{B: unique}},
+				{Value: &schema.SQLValue_S{S: c.Comment()}},
 			},
 		})
 	}
@@ -553,6 +556,10 @@ func typedValueToRowValue(tv sql.TypedValue) *schema.SQLValue {
 		{
 			return &schema.SQLValue{Value: &schema.SQLValue_Bs{Bs: tv.Value().([]byte)}}
 		}
+	case sql.TimestampType:
+		{
+			return &schema.SQLValue{Value: &schema.SQLValue_N{N: tv.Value().(time.Time).UnixNano()}}
+		}
 	}
 	return nil
 }
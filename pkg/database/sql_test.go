@@ -177,4 +177,12 @@ func TestSQLExecAndQuery(t *testing.T) {
 	})
 	require.Equal(t, store.ErrKeyNotFound, err)
 
+	_, err = db.SQLExec(&schema.SQLExecRequest{Sql: "COMMENT ON COLUMN table1.title IS 'main title'"})
+	require.NoError(t, err)
+
+	res, err = db.DescribeTable("table1")
+	require.NoError(t, err)
+	require.Equal(t, "COMMENT", res.Columns[len(res.Columns)-1].Name)
+	require.Equal(t, "", res.Rows[0].Values[len(res.Columns)-1].GetS())
+	require.Equal(t, "main title", res.Rows[1].Values[len(res.Columns)-1].GetS())
 }
@@ -41,6 +41,10 @@ var ErrParametersValueSizeTooLarge = errors.New("provided parameters exceeded th
 var ErrNegativeParameterValueLen = errors.New("negative parameter length detected")
 var ErrMalformedMessage = errors.New("malformed message detected")
 var ErrMessageTooLarge = errors.New("payload message hit  allowed memory boundaries")
+var ErrUnknownSetting = errors.New("unrecognized configuration parameter")
+var ErrParamTypeHintsExceeded = errors.New("more parameter types specified than parameters found")
+var ErrUnsupportedParamTypeHint = errors.New("unsupported parameter type")
+var ErrIncompatibleParamTypeHint = errors.New("parameter type is not compatible with the inferred one")
 
 func MapPgError(err error) (er bm.ErrorResp) {
 	switch {
@@ -100,6 +104,26 @@ func MapPgError(err error) (er bm.ErrorResp) {
 			bm.Code(pgmeta.PgServerErrProtocolViolation),
 			bm.Message(err.Error()),
 		)
+	case errors.Is(err, ErrUnknownSetting):
+		er = bm.ErrorResponse(bm.Severity(pgmeta.PgSeverityError),
+			bm.Code(pgmeta.UndefinedObject),
+			bm.Message(err.Error()),
+		)
+	case errors.Is(err, ErrParamTypeHintsExceeded):
+		er = bm.ErrorResponse(bm.Severity(pgmeta.PgSeverityError),
+			bm.Code(pgmeta.PgServerErrProtocolViolation),
+			bm.Message(err.Error()),
+		)
+	case errors.Is(err, ErrUnsupportedParamTypeHint):
+		er = bm.ErrorResponse(bm.Severity(pgmeta.PgSeverityError),
+			bm.Code(pgmeta.UndefinedObject),
+			bm.Message(err.Error()),
+		)
+	case errors.Is(err, ErrIncompatibleParamTypeHint):
+		er = bm.ErrorResponse(bm.Severity(pgmeta.PgSeverityError),
+			bm.Code(pgmeta.DatatypeMismatch),
+			bm.Message(err.Error()),
+		)
 	default:
 		er = bm.ErrorResponse(bm.Severity(pgmeta.PgSeverityError),
 			bm.Message(err.Error()),
@@ -1,6 +1,7 @@
 package errors
 
 import (
+	"fmt"
 	"github.com/stretchr/testify/require"
 	"testing"
 )
@@ -24,4 +25,16 @@ func TestMapPgError(t *testing.T) {
 	err = ErrMalformedMessage
 	be = MapPgError(err)
 	require.NotNil(t, be)
+	err = fmt.Errorf("%w \"%s\"", ErrUnknownSetting, "unknown")
+	be = MapPgError(err)
+	require.NotNil(t, be)
+	err = ErrParamTypeHintsExceeded
+	be = MapPgError(err)
+	require.NotNil(t, be)
+	err = fmt.Errorf("%w (oid %d)", ErrUnsupportedParamTypeHint, 700)
+	be = MapPgError(err)
+	require.NotNil(t, be)
+	err = fmt.Errorf("%w (%s)", ErrIncompatibleParamTypeHint, "param1")
+	be = MapPgError(err)
+	require.NotNil(t, be)
 }
@@ -19,93 +19,138 @@ package bmessages
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
 
 	"github.com/codenotary/immudb/pkg/api/schema"
 )
 
+// ErrNilValue is returned when a row holds a nil value
+var ErrNilValue = errors.New("nil value found in row")
+
 // DataRow if ResultColumnFormatCodes is nil default text format is used
 func DataRow(rows []*schema.Row, colNumb int, ResultColumnFormatCodes []int16) []byte {
-	rowsB := make([]byte, 0)
+	var buf bytes.Buffer
 	for _, row := range rows {
-		rowB := make([]byte, 0)
-		// Identifies the message as a data row.
-		// Byte1('D')
-		messageType := []byte(`D`)
-
-		// The number of column values that follow (possibly zero).
-		// Int16
-		columnNumb := make([]byte, 2)
-		binary.BigEndian.PutUint16(columnNumb, uint16(colNumb))
-
-		for i, val := range row.Values {
+		for _, val := range row.Values {
 			if val == nil {
 				return nil
 			}
+		}
+		// writes on a bytes.Buffer never fail
+		WriteDataRow(&buf, row, colNumb, ResultColumnFormatCodes)
+	}
+	return buf.Bytes()
+}
 
-			valueLength := make([]byte, 4)
-			value := make([]byte, 0)
+// WriteDataRow writes the DataRow message of a single row to w. BLOB values are not
+// buffered: in binary format they are written as they are, in text format they are
+// hex encoded in small chunks while being written. If ResultColumnFormatCodes is nil
+// default text format is used
+func WriteDataRow(w io.Writer, row *schema.Row, colNumb int, ResultColumnFormatCodes []int16) error {
+	values := make([][]byte, len(row.Values))
+	lengths := make([]int32, len(row.Values))
+	hexEncoded := make([]bool, len(row.Values))
 
-			BINformat := false
-			if ResultColumnFormatCodes != nil && len(ResultColumnFormatCodes) == 1 {
-				BINformat = ResultColumnFormatCodes[0] == 1
-			}
-			if ResultColumnFormatCodes != nil && len(ResultColumnFormatCodes) > i && ResultColumnFormatCodes[i] == 1 {
-				BINformat = true
+	// Length of message contents in bytes, including self.
+	messageLength := 4 + 2
+
+	for i, val := range row.Values {
+		if val == nil {
+			return ErrNilValue
+		}
+
+		BINformat := false
+		if ResultColumnFormatCodes != nil && len(ResultColumnFormatCodes) == 1 {
+			BINformat = ResultColumnFormatCodes[0] == 1
+		}
+		if ResultColumnFormatCodes != nil && len(ResultColumnFormatCodes) > i && ResultColumnFormatCodes[i] == 1 {
+			BINformat = true
+		}
+
+		switch tv := val.Value.(type) {
+		case *schema.SQLValue_Null:
+			//  As a special case, -1 indicates a NULL column value. No value bytes follow in the NULL case.
+			lengths[i] = -1
+		case *schema.SQLValue_Bs:
+			// value bytes are written later on, straight from the row
+			values[i] = tv.Bs
+			lengths[i] = int32(len(tv.Bs))
+			if !BINformat {
+				lengths[i] = int32(hex.EncodedLen(len(tv.Bs)))
+				hexEncoded[i] = true
 			}
+		default:
 			if BINformat {
-				switch tv := val.Value.(type) {
-				case *schema.SQLValue_Null:
-					{
-						n := -1
-						binary.BigEndian.PutUint32(valueLength, uint32(n))
-					}
-				case *schema.SQLValue_N:
-					{
-						binary.BigEndian.PutUint32(valueLength, uint32(8))
-						value = make([]byte, 8)
-						binary.BigEndian.PutUint64(value, uint64(tv.N))
-					}
-				case *schema.SQLValue_S:
-					{
-						binary.BigEndian.PutUint32(valueLength, uint32(len(tv.S)))
-						value = make([]byte, len(tv.S))
-						value = []byte(tv.S)
-					}
-				case *schema.SQLValue_B:
-					{
-						binary.BigEndian.PutUint32(valueLength, uint32(1))
-						value = make([]byte, 1)
-						value = []byte{0}
-						if tv.B {
-							value = []byte{1}
-						}
-					}
-				case *schema.SQLValue_Bs:
-					{
-						binary.BigEndian.PutUint32(valueLength, uint32(len(tv.Bs)))
-						value = make([]byte, len(tv.Bs))
-						value = tv.Bs
-					}
-				}
+				values[i] = binaryValue(val)
 			} else {
 				// only text format is allowed in simple query
-				value = schema.RenderValueAsByte(val.Value)
-			}
-			binary.BigEndian.PutUint32(valueLength, uint32(len(value)))
-			//  As a special case, -1 indicates a NULL column value. No value bytes follow in the NULL case.
-			if value == nil {
-				tm := int32(-1)
-				value = nil
-				binary.BigEndian.PutUint32(valueLength, uint32(tm))
+				values[i] = schema.RenderValueAsByte(val.Value)
 			}
-			rowB = append(rowB, bytes.Join([][]byte{valueLength, value}, nil)...)
+			lengths[i] = int32(len(values[i]))
+		}
+
+		messageLength += 4
+		if lengths[i] > 0 {
+			messageLength += int(lengths[i])
 		}
+	}
+
+	// Identifies the message as a data row.
+	// Byte1('D')
+	// Length of message contents in bytes, including self.
+	// Int32
+	// The number of column values that follow (possibly zero).
+	// Int16
+	header := make([]byte, 1+4+2)
+	header[0] = 'D'
+	binary.BigEndian.PutUint32(header[1:], uint32(messageLength))
+	binary.BigEndian.PutUint16(header[5:], uint16(colNumb))
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
 
-		// Length of message contents in bytes, including self.
-		// Int32
-		selfMessageLength := make([]byte, 4)
-		binary.BigEndian.PutUint32(selfMessageLength, uint32(4+2+len(rowB)))
-		rowsB = append(rowsB, bytes.Join([][]byte{messageType, selfMessageLength, columnNumb, rowB}, nil)...)
+	valueLength := make([]byte, 4)
+
+	for i := range row.Values {
+		binary.BigEndian.PutUint32(valueLength, uint32(lengths[i]))
+		if _, err := w.Write(valueLength); err != nil {
+			return err
+		}
+
+		if lengths[i] <= 0 {
+			continue
+		}
+
+		var err error
+		if hexEncoded[i] {
+			_, err = hex.NewEncoder(w).Write(values[i])
+		} else {
+			_, err = w.Write(values[i])
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func binaryValue(val *schema.SQLValue) []byte {
+	switch tv := val.Value.(type) {
+	case *schema.SQLValue_N:
+		value := make([]byte, 8)
+		binary.BigEndian.PutUint64(value, uint64(tv.N))
+		return value
+	case *schema.SQLValue_S:
+		return []byte(tv.S)
+	case *schema.SQLValue_B:
+		if tv.B {
+			return []byte{1}
+		}
+		return []byte{0}
 	}
-	return rowsB
+	return []byte{}
 }
@@ -0,0 +1,153 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bmessages
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"runtime"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataRow(t *testing.T) {
+	row := &schema.Row{
+		Columns: []string{"id", "title", "active", "content", "missing"},
+		Values: []*schema.SQLValue{
+			{Value: &schema.SQLValue_N{N: 1}},
+			{Value: &schema.SQLValue_S{S: "title"}},
+			{Value: &schema.SQLValue_B{B: true}},
+			{Value: &schema.SQLValue_Bs{Bs: []byte{0xca, 0xfe}}},
+			{Value: &schema.SQLValue_Null{}},
+		},
+	}
+
+	t.Run("text format", func(t *testing.T) {
+		msg := DataRow([]*schema.Row{row, row}, 5, nil)
+
+		expected := []byte{'D', 0, 0, 0, 40, 0, 5}
+		expected = append(expected, 0, 0, 0, 1, '1')
+		expected = append(expected, 0, 0, 0, 5, 't', 'i', 't', 'l', 'e')
+		expected = append(expected, 0, 0, 0, 4, 't', 'r', 'u', 'e')
+		expected = append(expected, 0, 0, 0, 4, 'c', 'a', 'f', 'e')
+		expected = append(expected, 0xff, 0xff, 0xff, 0xff)
+
+		require.Equal(t, append(expected, expected...), msg)
+	})
+
+	t.Run("binary format", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := WriteDataRow(&buf, row, 5, []int16{1})
+		require.NoError(t, err)
+
+		expected := []byte{'D', 0, 0, 0, 42, 0, 5}
+		expected = append(expected, 0, 0, 0, 8, 0, 0, 0, 0, 0, 0, 0, 1)
+		expected = append(expected, 0, 0, 0, 5, 't', 'i', 't', 'l', 'e')
+		expected = append(expected, 0, 0, 0, 1, 1)
+		expected = append(expected, 0, 0, 0, 2, 0xca, 0xfe)
+		expected = append(expected, 0xff, 0xff, 0xff, 0xff)
+
+		require.Equal(t, expected, buf.Bytes())
+	})
+
+	t.Run("nil value", func(t *testing.T) {
+		nilRow := &schema.Row{Columns: []string{"id"}, Values: []*schema.SQLValue{nil}}
+
+		require.Nil(t, DataRow([]*schema.Row{nilRow}, 1, nil))
+
+		err := WriteDataRow(&bytes.Buffer{}, nilRow, 1, nil)
+		require.ErrorIs(t, err, ErrNilValue)
+	})
+}
+
+// digestWriter checks the DataRow message of a single BLOB column without keeping it in memory
+type digestWriter struct {
+	header []byte
+	h      hashWriter
+}
+
+type hashWriter interface {
+	Write([]byte) (int, error)
+	Sum([]byte) []byte
+}
+
+func (w *digestWriter) Write(b []byte) (int, error) {
+	n := len(b)
+
+	// message header, column count and value length
+	if len(w.header) < 11 {
+		c := 11 - len(w.header)
+		if c > len(b) {
+			c = len(b)
+		}
+		w.header = append(w.header, b[:c]...)
+		b = b[c:]
+	}
+
+	w.h.Write(b)
+
+	return n, nil
+}
+
+func TestWriteDataRowLargeBlob(t *testing.T) {
+	blob := make([]byte, 16<<20)
+	for i := range blob {
+		blob[i] = byte(i % 251)
+	}
+
+	row := &schema.Row{
+		Columns: []string{"content"},
+		Values:  []*schema.SQLValue{{Value: &schema.SQLValue_Bs{Bs: blob}}},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		formats  []int16
+		expected []byte
+	}{
+		{name: "binary format", formats: []int16{1}, expected: blob},
+		{name: "text format", formats: nil, expected: []byte(hex.EncodeToString(blob))},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := &digestWriter{h: sha256.New()}
+
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+
+			err := WriteDataRow(w, row, 1, tc.formats)
+			require.NoError(t, err)
+
+			runtime.ReadMemStats(&after)
+
+			// the value must not be copied into a message buffer
+			require.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(1<<20))
+
+			require.Len(t, w.header, 11)
+			require.Equal(t, byte('D'), w.header[0])
+			require.Equal(t, uint32(4+2+4+len(tc.expected)), binary.BigEndian.Uint32(w.header[1:]))
+			require.Equal(t, uint16(1), binary.BigEndian.Uint16(w.header[5:]))
+			require.Equal(t, uint32(len(tc.expected)), binary.BigEndian.Uint32(w.header[7:]))
+
+			digest := sha256.Sum256(tc.expected)
+			require.Equal(t, digest[:], w.h.Sum(nil))
+		})
+	}
+}
@@ -174,14 +174,14 @@ func (s *session) HandleStartup(dbList database.DatabaseList) (err error) {
 			return err
 		}
 	}
-	if _, err := s.writeMessage(bm.ParameterStatus([]byte("standard_conforming_strings"), []byte("on"))); err != nil {
+	if _, err := s.writeMessage(bm.ParameterStatus([]byte("standard_conforming_strings"), []byte(pgmeta.PgSettings["standard_conforming_strings"]))); err != nil {
 		return err
 	}
-	if _, err := s.writeMessage(bm.ParameterStatus([]byte("client_encoding"), []byte("UTF8"))); err != nil {
+	if _, err := s.writeMessage(bm.ParameterStatus([]byte("client_encoding"), []byte(pgmeta.PgSettings["client_encoding"]))); err != nil {
 		return err
 	}
 	// todo this is needed by jdbc driver. Here is added the minor supported version at the moment
-	if _, err := s.writeMessage(bm.ParameterStatus([]byte("server_version"), []byte(pgmeta.PgsqlProtocolVersion))); err != nil {
+	if _, err := s.writeMessage(bm.ParameterStatus([]byte("server_version"), []byte(pgmeta.PgSettings["server_version"]))); err != nil {
 		return err
 	}
 
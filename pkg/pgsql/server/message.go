@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"github.com/codenotary/immudb/pkg/pgsql/errors"
 	"github.com/codenotary/immudb/pkg/pgsql/server/pgmeta"
+	"io"
 	"math"
 	"net"
 )
@@ -57,7 +58,7 @@ func (r *messageReader) ReadRawMessage() (*rawMessage, error) {
 	}
 
 	lb := make([]byte, 4)
-	if _, err := r.conn.Read(lb); err != nil {
+	if _, err := io.ReadFull(r.conn, lb); err != nil {
 		return nil, err
 	}
 	pLen := binary.BigEndian.Uint32(lb) - 4
@@ -69,7 +70,8 @@ func (r *messageReader) ReadRawMessage() (*rawMessage, error) {
 		return nil, errors.ErrMessageTooLarge
 	}
 	payload := make([]byte, pLen)
-	if _, err := r.conn.Read(payload); err != nil {
+	// large payloads may span several reads
+	if _, err := io.ReadFull(r.conn, payload); err != nil {
 		return nil, err
 	}
 
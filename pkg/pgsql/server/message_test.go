@@ -47,7 +47,8 @@ func TestSession_MessageReader(t *testing.T) {
 	}
 	go func() {
 		c2.Write([]byte{'E'})
-		c2.Write([]byte{0, 0, 0, 4})
+		c2.Write([]byte{0, 0, 0, 8})
+		c2.Write([]byte{0, 0})
 		c2.Close()
 	}()
 
@@ -55,6 +56,21 @@ func TestSession_MessageReader(t *testing.T) {
 
 	require.Error(t, err)
 
+	c1, c2 = net.Pipe()
+	mr = &messageReader{
+		conn: c1,
+	}
+	go func() {
+		c2.Write([]byte{'E'})
+		c2.Write([]byte{0, 0, 0, 4})
+		c2.Close()
+	}()
+
+	msg, err := mr.ReadRawMessage()
+
+	require.NoError(t, err)
+	require.Empty(t, msg.payload)
+
 	mr = &messageReader{}
 	err = mr.CloseConnection()
 
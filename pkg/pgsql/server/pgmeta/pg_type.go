@@ -27,6 +27,22 @@ const PgsqlProtocolVersion = "9.6"
 
 var PgsqlProtocolVersionMessage = fmt.Sprintf("pgsql wire protocol %s or greater version implemented by immudb", PgsqlProtocolVersion)
 
+// PgSettings holds the run-time parameters reported to clients, either when the session starts or through SHOW.
+// Names are lower case
+var PgSettings = map[string]string{
+	"server_version":              PgsqlProtocolVersion,
+	"server_encoding":             "UTF8",
+	"client_encoding":             "UTF8",
+	"standard_conforming_strings": "on",
+	"integer_datetimes":           "on",
+	"datestyle":                   "ISO, MDY",
+	"timezone":                    "UTC",
+	"search_path":                 "\"$user\", public",
+	"transaction_isolation":       "read committed",
+	"extra_float_digits":          "1",
+	"application_name":            "",
+}
+
 // PgTypeMap maps the immudb type descriptor with pgsql pgtype map.
 // First int is the oid value (retrieved with select * from pg_type;)
 // Second int is the length of the value. -1 for dynamic.
@@ -38,6 +54,18 @@ var PgTypeMap = map[string][]int{
 	"VARCHAR":   {25, -1}, //text
 }
 
+// PgOidTypeMap maps the oid of the pgsql types clients may use as parameter type hints with the immudb type descriptor.
+// Hints are only honored when compatible with the inferred type, thus oids sharing the same representation are accepted.
+var PgOidTypeMap = map[int32]string{
+	16:   "BOOLEAN", //bool
+	17:   "BLOB",    //bytea
+	20:   "INTEGER", //int8
+	21:   "INTEGER", //int2
+	23:   "INTEGER", //int4
+	25:   "VARCHAR", //text
+	1043: "VARCHAR", //varchar
+}
+
 const PgSeverityError = "ERROR"
 const PgSeverityFaral = "FATAL"
 const PgSeverityPanic = "PANIC"
@@ -53,6 +81,8 @@ const PgServerErrProtocolViolation = "08P01"
 const PgServerErrConnectionFailure = "08006"
 const ProgramLimitExceeded = "54000"
 const DataException = "22000"
+const UndefinedObject = "42704"
+const DatatypeMismatch = "42804"
 
 var MTypes = map[byte]string{
 	'Q': "query",
@@ -342,6 +342,58 @@ func TestPgsqlServer_SimpleQueryQueryEmptyQueryMessage(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestPgsqlServer_QueryWithoutMatchingRows(t *testing.T) {
+	td, _ := ioutil.TempDir("", "_pgsql")
+	options := server.DefaultOptions().WithDir(td).WithPgsqlServer(true).WithPgsqlServerPort(0)
+	bs := servertest.NewBufconnServer(options)
+
+	bs.Start()
+	defer bs.Stop()
+
+	defer os.RemoveAll(td)
+	defer os.Remove(".state-")
+
+	bs.WaitForPgsqlListener()
+
+	db, err := pgx.Connect(context.Background(), fmt.Sprintf("host=localhost port=%d sslmode=disable user=immudb dbname=defaultdb password=immudb", bs.Server.Srv.PgsqlSrv.GetPort()))
+	require.NoError(t, err)
+	defer db.Close(context.Background())
+
+	table := getRandomTableName()
+	_, err = db.Exec(context.Background(), fmt.Sprintf("CREATE TABLE %s (id INTEGER, title VARCHAR, PRIMARY KEY id)", table))
+	require.NoError(t, err)
+
+	_, err = db.Exec(context.Background(), fmt.Sprintf("INSERT INTO %s (id, title) VALUES (1, 'title 1')", table))
+	require.NoError(t, err)
+
+	t.Run("simple query", func(t *testing.T) {
+		tag, err := db.Exec(context.Background(), fmt.Sprintf("SELECT id, title FROM %s WHERE id = 2", table))
+		require.NoError(t, err)
+		require.Equal(t, "SELECT 0", tag.String())
+
+		tag, err = db.Exec(context.Background(), fmt.Sprintf("SELECT id, title FROM %s", table))
+		require.NoError(t, err)
+		require.Equal(t, "SELECT 1", tag.String())
+	})
+
+	t.Run("extended query", func(t *testing.T) {
+		rows, err := db.Query(context.Background(), fmt.Sprintf("SELECT id, title FROM %s WHERE id = ?", table), 2)
+		require.NoError(t, err)
+		require.False(t, rows.Next())
+		require.NoError(t, rows.Err())
+		require.Equal(t, "SELECT 0", rows.CommandTag().String())
+
+		require.Len(t, rows.FieldDescriptions(), 2)
+
+		rows, err = db.Query(context.Background(), fmt.Sprintf("SELECT id, title FROM %s WHERE id = ?", table), 1)
+		require.NoError(t, err)
+		require.True(t, rows.Next())
+		require.False(t, rows.Next())
+		require.NoError(t, rows.Err())
+		require.Equal(t, "SELECT 1", rows.CommandTag().String())
+	})
+}
+
 func TestPgsqlServer_SimpleQueryQueryCreateOrUseDatabaseNotSupported(t *testing.T) {
 	td, _ := ioutil.TempDir("", "_pgsql")
 	options := server.DefaultOptions().WithDir(td).WithPgsqlServer(true).WithPgsqlServerPort(0)
@@ -518,6 +570,37 @@ func TestPgsqlServer_VersionStatement(t *testing.T) {
 	require.Equal(t, pgmeta.PgsqlProtocolVersionMessage, version)
 }
 
+func TestPgsqlServer_ShowStatement(t *testing.T) {
+	td, _ := ioutil.TempDir("", "_pgsql")
+	options := server.DefaultOptions().WithDir(td).WithPgsqlServer(true).WithPgsqlServerPort(0)
+	bs := servertest.NewBufconnServer(options)
+
+	bs.Start()
+	defer bs.Stop()
+
+	defer os.RemoveAll(td)
+	defer os.Remove(".state-")
+
+	bs.WaitForPgsqlListener()
+
+	db, err := sql.Open("postgres", fmt.Sprintf("host=localhost port=%d sslmode=disable user=immudb dbname=defaultdb password=immudb", bs.Server.Srv.PgsqlSrv.GetPort()))
+	require.NoError(t, err)
+
+	var serverVersion string
+	err = db.QueryRow("SHOW server_version").Scan(&serverVersion)
+	require.NoError(t, err)
+	require.Equal(t, pgmeta.PgsqlProtocolVersion, serverVersion)
+
+	var searchPath string
+	err = db.QueryRow("show SEARCH_PATH;").Scan(&searchPath)
+	require.NoError(t, err)
+	require.Equal(t, pgmeta.PgSettings["search_path"], searchPath)
+
+	err = db.QueryRow("SHOW unknown_setting").Scan(&searchPath)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unrecognized configuration parameter")
+}
+
 func TestPgsqlServerSetStatement(t *testing.T) {
 	td, _ := ioutil.TempDir("", "_pgsql")
 	options := server.DefaultOptions().WithDir(td).WithPgsqlServer(true).WithPgsqlServerPort(0)
@@ -536,6 +619,40 @@ func TestPgsqlServerSetStatement(t *testing.T) {
 
 	_, err = db.Query(fmt.Sprintf("SET test=val"))
 	require.NoError(t, err)
+
+	// settings are kept by each session, so the same connection is used to read them back
+	conn, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.ExecContext(context.Background(), "SET extra_float_digits = 3")
+	require.NoError(t, err)
+
+	_, err = conn.ExecContext(context.Background(), "set SESSION application_name TO 'immudb-test';")
+	require.NoError(t, err)
+
+	var extraFloatDigits string
+	err = conn.QueryRowContext(context.Background(), "SHOW extra_float_digits").Scan(&extraFloatDigits)
+	require.NoError(t, err)
+	require.Equal(t, "3", extraFloatDigits)
+
+	var applicationName string
+	err = conn.QueryRowContext(context.Background(), "SELECT current_setting('APPLICATION_NAME')").Scan(&applicationName)
+	require.NoError(t, err)
+	require.Equal(t, "immudb-test", applicationName)
+
+	err = conn.QueryRowContext(context.Background(), "SELECT current_setting('test')").Scan(&applicationName)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unrecognized configuration parameter")
+
+	// other sessions are not affected
+	otherConn, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	defer otherConn.Close()
+
+	err = otherConn.QueryRowContext(context.Background(), "SHOW extra_float_digits").Scan(&extraFloatDigits)
+	require.NoError(t, err)
+	require.Equal(t, pgmeta.PgSettings["extra_float_digits"], extraFloatDigits)
 }
 
 func TestPgsqlServer_SimpleQueryNilValues(t *testing.T) {
@@ -642,6 +759,63 @@ func TestPgsqlServer_ExtendedQueryPGxNamedStatements(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestPgsqlServer_ExtendedQueryPGxParamTypeHints(t *testing.T) {
+	td, _ := ioutil.TempDir("", "_pgsql")
+	options := server.DefaultOptions().WithDir(td).WithPgsqlServer(true).WithPgsqlServerPort(0)
+	bs := servertest.NewBufconnServer(options)
+
+	bs.Start()
+	defer bs.Stop()
+
+	defer os.RemoveAll(td)
+	defer os.Remove(".state-")
+
+	bs.WaitForPgsqlListener()
+
+	connect := func(t *testing.T) *pgx.Conn {
+		db, err := pgx.Connect(context.Background(), fmt.Sprintf("host=localhost port=%d sslmode=disable user=immudb dbname=defaultdb password=immudb", bs.Server.Srv.PgsqlSrv.GetPort()))
+		require.NoError(t, err)
+		return db
+	}
+
+	db := connect(t)
+	defer db.Close(context.Background())
+
+	table := getRandomTableName()
+	_, err := db.Exec(context.Background(), fmt.Sprintf("CREATE TABLE %s (id INTEGER, title VARCHAR, active BOOLEAN, PRIMARY KEY id)", table))
+	require.NoError(t, err)
+
+	query := fmt.Sprintf("SELECT id FROM %s WHERE id = ? AND title = ? AND active = ?", table)
+
+	t.Run("compatible hints should be honored", func(t *testing.T) {
+		// int4, varchar and unspecified
+		sd, err := db.PgConn().Prepare(context.Background(), "hinted", query, []uint32{23, 1043, 0})
+		require.NoError(t, err)
+		require.Equal(t, []uint32{20, 25, 16}, sd.ParamOIDs)
+	})
+
+	t.Run("invalid hints should be rejected", func(t *testing.T) {
+		for _, c := range []struct {
+			oids []uint32
+			err  string
+		}{
+			// bool hint for an INTEGER parameter
+			{oids: []uint32{16}, err: "parameter type is not compatible with the inferred one"},
+			// float8 is not supported
+			{oids: []uint32{701}, err: "unsupported parameter type"},
+			{oids: []uint32{0, 0, 0, 0}, err: "more parameter types specified than parameters found"},
+		} {
+			db := connect(t)
+
+			_, err := db.PgConn().Prepare(context.Background(), "", query, c.oids)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), c.err)
+
+			db.Close(context.Background())
+		}
+	})
+}
+
 func TestPgsqlServer_ExtendedQueryPGxMultiFieldsPreparedStatements(t *testing.T) {
 	td, _ := ioutil.TempDir("", "_pgsql")
 	options := server.DefaultOptions().WithDir(td).WithPgsqlServer(true).WithPgsqlServerPort(0)
@@ -686,6 +860,41 @@ func TestPgsqlServer_ExtendedQueryPGxMultiFieldsPreparedStatements(t *testing.T)
 	require.Equal(t, true, isPresent)
 }
 
+func TestPgsqlServer_ExtendedQueryPGxLargeBlob(t *testing.T) {
+	td, _ := ioutil.TempDir("", "_pgsql")
+	options := server.DefaultOptions().WithDir(td).WithPgsqlServer(true).WithPgsqlServerPort(0)
+	bs := servertest.NewBufconnServer(options)
+
+	bs.Start()
+	defer bs.Stop()
+
+	defer os.RemoveAll(td)
+	defer os.Remove(".state-")
+
+	bs.WaitForPgsqlListener()
+
+	db, err := pgx.Connect(context.Background(), fmt.Sprintf("host=localhost port=%d sslmode=disable user=immudb dbname=defaultdb password=immudb", bs.Server.Srv.PgsqlSrv.GetPort()))
+	require.NoError(t, err)
+	defer db.Close(context.Background())
+
+	table := getRandomTableName()
+	_, err = db.Exec(context.Background(), fmt.Sprintf("CREATE TABLE %s (id INTEGER, content BLOB, PRIMARY KEY id)", table))
+	require.NoError(t, err)
+
+	binaryContent := make([]byte, 4<<20)
+	for i := range binaryContent {
+		binaryContent[i] = byte(i % 251)
+	}
+
+	_, err = db.Exec(context.Background(), fmt.Sprintf("INSERT INTO %s (id, content) VALUES (1, ?)", table), binaryContent)
+	require.NoError(t, err)
+
+	var content []byte
+	err = db.QueryRow(context.Background(), fmt.Sprintf("SELECT content FROM %s WHERE id = ?", table), 1).Scan(&content)
+	require.NoError(t, err)
+	require.Equal(t, binaryContent, content)
+}
+
 func TestPgsqlServer_ExtendedQueryPGMultiFieldsPreparedStatements(t *testing.T) {
 	td, _ := ioutil.TempDir("", "_pgsql")
 	options := server.DefaultOptions().WithDir(td).WithPgsqlServer(true).WithPgsqlServerPort(0)
@@ -66,11 +66,12 @@ func (s *session) QueriesMachine() (err error) {
 		case fm.TerminateMsg:
 			return s.mr.CloseConnection()
 		case fm.QueryMsg:
-			if err = s.fetchAndWriteResults(v.GetStatements(), nil, nil, false); err != nil {
+			commandTag, err := s.fetchAndWriteResults(v.GetStatements(), nil, nil, false)
+			if err != nil {
 				s.ErrorHandle(err)
 				continue
 			}
-			if _, err = s.writeMessage(bm.CommandComplete([]byte(`ok`))); err != nil {
+			if _, err = s.writeMessage(bm.CommandComplete(commandTag)); err != nil {
 				s.ErrorHandle(err)
 				continue
 			}
@@ -88,6 +89,11 @@ func (s *session) QueriesMachine() (err error) {
 					waitForSync = true
 					continue
 				}
+				if err = applyParamTypeHints(paramCols, v.ObjectIDs); err != nil {
+					s.ErrorHandle(err)
+					waitForSync = true
+					continue
+				}
 			}
 			_, ok := s.statements[v.DestPreparedStatementName]
 			// unnamed prepared statement overrides previous
@@ -134,7 +140,7 @@ func (s *session) QueriesMachine() (err error) {
 					waitForSync = true
 					continue
 				}
-				if _, err := s.writeMessage(bm.RowDescription(st.Results, nil)); err != nil {
+				if _, err := s.writeMessage(rowDescriptionOrNoData(st, nil)); err != nil {
 					s.ErrorHandle(err)
 					waitForSync = true
 					continue
@@ -151,7 +157,7 @@ func (s *session) QueriesMachine() (err error) {
 					waitForSync = true
 					continue
 				}
-				if _, err = s.writeMessage(bm.RowDescription(st.Statement.Results, st.ResultColumnFormatCodes)); err != nil {
+				if _, err = s.writeMessage(rowDescriptionOrNoData(st.Statement, st.ResultColumnFormatCodes)); err != nil {
 					s.ErrorHandle(err)
 					waitForSync = true
 					continue
@@ -199,15 +205,16 @@ func (s *session) QueriesMachine() (err error) {
 			}
 		case fm.Execute:
 			//query execution
-			if err = s.fetchAndWriteResults(s.portals[v.PortalName].Statement.SQLStatement,
+			commandTag, err := s.fetchAndWriteResults(s.portals[v.PortalName].Statement.SQLStatement,
 				s.portals[v.PortalName].Parameters,
 				s.portals[v.PortalName].ResultColumnFormatCodes,
-				true); err != nil {
+				true)
+			if err != nil {
 				s.ErrorHandle(err)
 				waitForSync = true
 				continue
 			}
-			if _, err := s.writeMessage(bm.CommandComplete([]byte(`ok`))); err != nil {
+			if _, err := s.writeMessage(bm.CommandComplete(commandTag)); err != nil {
 				s.ErrorHandle(err)
 				waitForSync = true
 			}
@@ -220,64 +227,70 @@ func (s *session) QueriesMachine() (err error) {
 	}
 }
 
-func (s *session) fetchAndWriteResults(statements string, parameters []*schema.NamedParam, resultColumnFormatCodes []int16, skipRowDesc bool) error {
-	if s.isInBlackList(statements) {
-		return nil
-	}
+// fetchAndWriteResults writes the rows returned by the statements, the returned command tag
+// is the one of the CommandComplete message closing the results of the last statement
+func (s *session) fetchAndWriteResults(statements string, parameters []*schema.NamedParam, resultColumnFormatCodes []int16, skipRowDesc bool) (commandTag []byte, err error) {
+	commandTag = []byte(`ok`)
+
 	if i := s.isEmulableInternally(statements); i != nil {
 		if err := s.tryToHandleInternally(i); err != nil && err != pserr.ErrMessageCannotBeHandledInternally {
-			return err
+			return nil, err
 		}
-		return nil
+		return commandTag, nil
+	}
+	if s.isInBlackList(statements) {
+		return commandTag, nil
 	}
 
 	stmts, err := sql.Parse(strings.NewReader(statements))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	for _, stmt := range stmts {
 		switch st := stmt.(type) {
 		case *sql.UseDatabaseStmt:
 			{
-				return pserr.ErrUseDBStatementNotSupported
+				return nil, pserr.ErrUseDBStatementNotSupported
 			}
 		case *sql.CreateDatabaseStmt:
 			{
-				return pserr.ErrCreateDBStatementNotSupported
+				return nil, pserr.ErrCreateDBStatementNotSupported
 			}
 		case *sql.SelectStmt:
-			if err = s.query(st, parameters, resultColumnFormatCodes, skipRowDesc); err != nil {
-				return err
+			rowCount, err := s.query(st, parameters, resultColumnFormatCodes, skipRowDesc)
+			if err != nil {
+				return nil, err
 			}
+			commandTag = []byte(fmt.Sprintf("SELECT %d", rowCount))
 		case sql.SQLStmt:
 			if err = s.exec(st, parameters, resultColumnFormatCodes, skipRowDesc); err != nil {
-				return err
+				return nil, err
 			}
+			commandTag = []byte(`ok`)
 		}
 	}
-	return nil
+	return commandTag, nil
 }
 
-func (s *session) query(st *sql.SelectStmt, parameters []*schema.NamedParam, resultColumnFormatCodes []int16, skipRowDesc bool) error {
+// query writes the rows returned by the statement and returns how many of them were written.
+// A query not matching any row only writes its RowDescription, if required,
+// EmptyQueryResponse is meant for empty query strings instead.
+func (s *session) query(st *sql.SelectStmt, parameters []*schema.NamedParam, resultColumnFormatCodes []int16, skipRowDesc bool) (int, error) {
 	res, err := s.database.SQLQueryPrepared(st, parameters, true)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	if res != nil && len(res.Rows) > 0 {
-		if !skipRowDesc {
-			if _, err = s.writeMessage(bm.RowDescription(res.Columns, nil)); err != nil {
-				return err
-			}
-		}
-		if _, err = s.writeMessage(bm.DataRow(res.Rows, len(res.Columns), resultColumnFormatCodes)); err != nil {
-			return err
+	if !skipRowDesc {
+		if _, err = s.writeMessage(bm.RowDescription(res.Columns, nil)); err != nil {
+			return 0, err
 		}
-		return nil
 	}
-	if _, err = s.writeMessage(bm.EmptyQueryResponse()); err != nil {
-		return err
+	if len(res.Rows) > 0 {
+		if err = s.writeDataRows(res.Rows, len(res.Columns), resultColumnFormatCodes); err != nil {
+			return 0, err
+		}
 	}
-	return nil
+	return len(res.Rows), nil
 }
 
 func (s *session) exec(st sql.SQLStmt, parameters []*schema.NamedParam, resultColumnFormatCodes []int16, skipRowDesc bool) error {
@@ -302,6 +315,16 @@ type statement struct {
 	Results      []*schema.Column
 }
 
+// rowDescriptionOrNoData describes the rows returned by the statement,
+// NoData is used for statements not returning rows, e.g. INSERT or CREATE TABLE
+func rowDescriptionOrNoData(st *statement, resultColumnFormatCodes []int16) []byte {
+	if len(st.Results) == 0 {
+		return bm.NoData()
+	}
+
+	return bm.RowDescription(st.Results, resultColumnFormatCodes)
+}
+
 func (s *session) inferParamAndResultCols(statement string) ([]*schema.Column, []*schema.Column, error) {
 	// todo @Michele The query string contained in a Parse message cannot include more than one SQL statement;
 	// else a syntax error is reported. This restriction does not exist in the simple-query protocol,
@@ -24,6 +24,7 @@ import (
 	"github.com/codenotary/immudb/pkg/pgsql/server/bmessages"
 	h "github.com/codenotary/immudb/pkg/pgsql/server/fmessages/fmessages_test"
 	"github.com/stretchr/testify/require"
+	"io"
 	"net"
 	"os"
 	"sync"
@@ -421,3 +422,64 @@ func TestSession_QueriesMachine(t *testing.T) {
 		})
 	}
 }
+
+func TestSession_DescribeStatementsWithoutResults(t *testing.T) {
+	c1, c2 := net.Pipe()
+
+	insertSt := &statement{
+		Name:         "insert_st",
+		SQLStatement: "INSERT INTO table1 (id) VALUES (1)",
+		Results:      []*schema.Column{},
+	}
+
+	selectSt := &statement{
+		Name:         "select_st",
+		SQLStatement: "SELECT id FROM table1",
+		Results:      []*schema.Column{{Name: "(defaultdb.table1.id)", Type: "INTEGER"}},
+	}
+
+	s := session{
+		log:   logger.NewSimpleLogger("test", os.Stdout),
+		mr:    &messageReader{conn: c1},
+		Mutex: sync.Mutex{},
+		statements: map[string]*statement{
+			insertSt.Name: insertSt,
+			selectSt.Name: selectSt,
+		},
+		portals: map[string]*portal{
+			"insert_port": {Name: "insert_port", Statement: insertSt},
+			"select_port": {Name: "select_port", Statement: selectSt},
+		},
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- s.QueriesMachine()
+	}()
+
+	read := func(n int) []byte {
+		b := make([]byte, n)
+		_, err := io.ReadFull(c2, b)
+		require.NoError(t, err)
+		return b
+	}
+
+	read(len(bmessages.ReadyForQuery()))
+
+	c2.Write(h.Msg('D', h.Join([][]byte{{'P'}, h.S("insert_port")})))
+	require.Equal(t, bmessages.NoData(), read(len(bmessages.NoData())))
+
+	c2.Write(h.Msg('D', h.Join([][]byte{{'S'}, h.S("insert_st")})))
+	require.Equal(t, bmessages.ParameterDescription(nil), read(len(bmessages.ParameterDescription(nil))))
+	require.Equal(t, bmessages.NoData(), read(len(bmessages.NoData())))
+
+	rowDescription := bmessages.RowDescription(selectSt.Results, nil)
+
+	c2.Write(h.Msg('D', h.Join([][]byte{{'P'}, h.S("select_port")})))
+	require.Equal(t, rowDescription, read(len(rowDescription)))
+
+	// Terminate message
+	c2.Write(h.Msg('X', []byte{0}))
+
+	require.NoError(t, <-done)
+}
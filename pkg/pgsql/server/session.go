@@ -17,6 +17,7 @@ limitations under the License.
 package server
 
 import (
+	"bufio"
 	"crypto/tls"
 	"encoding/json"
 	"github.com/codenotary/immudb/pkg/api/schema"
@@ -42,6 +43,7 @@ type session struct {
 	protocolVersion string
 	portals         map[string]*portal
 	statements      map[string]*statement
+	settings        map[string]string // run-time parameters changed with SET
 	sync.Mutex
 }
 
@@ -126,6 +128,22 @@ func (s *session) writeMessage(msg []byte) (int, error) {
 	return s.mr.Write(msg)
 }
 
+// writeDataRows writes one DataRow message per row to the connection. Small values are
+// coalesced in a bounded buffer while large values are written through, so rows are never
+// copied into a message buffer as a whole
+func (s *session) writeDataRows(rows []*schema.Row, colNumb int, resultColumnFormatCodes []int16) error {
+	w := bufio.NewWriter(s.mr)
+
+	for _, row := range rows {
+		s.debugMessage([]byte{'D'})
+		if err := bm.WriteDataRow(w, row, colNumb, resultColumnFormatCodes); err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
 func (s *session) debugMessage(msg []byte) {
 	if s.log != nil && len(msg) > 0 {
 		s.log.Debugf("write %s - %s message", string(msg[0]), pgmeta.MTypes[msg[0]])
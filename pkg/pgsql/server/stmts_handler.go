@@ -2,11 +2,16 @@ package server
 
 import (
 	pserr "github.com/codenotary/immudb/pkg/pgsql/errors"
+	"github.com/codenotary/immudb/pkg/pgsql/server/pgmeta"
 	"regexp"
+	"strings"
 )
 
-var set = regexp.MustCompile(`(?i)set\s+.+`)
+var set = regexp.MustCompile(`(?i)^\s*set\s+.+`)
+var setParameter = regexp.MustCompile(`(?i)^\s*set\s+(?:session\s+)?(\w+)(?:\s*=\s*|\s+to\s+)(.+?)\s*;?\s*$`)
 var selectVersion = regexp.MustCompile(`(?i)select\s+version\(\s*\)`)
+var show = regexp.MustCompile(`(?i)^\s*show\s+(\w+)\s*;?\s*$`)
+var selectCurrentSetting = regexp.MustCompile(`(?i)^\s*select\s+current_setting\(\s*'(\w+)'\s*\)\s*;?\s*$`)
 
 func (s *session) isInBlackList(statement string) bool {
 	if set.MatchString(statement) {
@@ -22,14 +27,37 @@ func (s *session) isEmulableInternally(statement string) interface{} {
 	if selectVersion.MatchString(statement) {
 		return &version{}
 	}
+	if m := show.FindStringSubmatch(statement); m != nil {
+		name := strings.ToLower(m[1])
+		return &showSetting{name: name, colName: name}
+	}
+	if m := selectCurrentSetting.FindStringSubmatch(statement); m != nil {
+		return &showSetting{name: strings.ToLower(m[1]), colName: "current_setting"}
+	}
+	// unrecognized parameters are still accepted, they are ignored as part of the black list
+	if m := setParameter.FindStringSubmatch(statement); m != nil {
+		name := strings.ToLower(m[1])
+		if _, ok := pgmeta.PgSettings[name]; ok {
+			return &setSetting{name: name, value: strings.Trim(m[2], "'")}
+		}
+	}
 	return nil
 }
 func (s *session) tryToHandleInternally(command interface{}) error {
-	switch command.(type) {
+	switch cmd := command.(type) {
 	case *version:
 		if err := s.writeVersionInfo(); err != nil {
 			return err
 		}
+	case *showSetting:
+		if err := s.writeSetting(cmd.name, cmd.colName); err != nil {
+			return err
+		}
+	case *setSetting:
+		if s.settings == nil {
+			s.settings = make(map[string]string)
+		}
+		s.settings[cmd.name] = cmd.value
 	default:
 		return pserr.ErrMessageCannotBeHandledInternally
 	}
@@ -37,3 +65,13 @@ func (s *session) tryToHandleInternally(command interface{}) error {
 }
 
 type version struct{}
+
+type showSetting struct {
+	name    string
+	colName string
+}
+
+type setSetting struct {
+	name  string
+	value string
+}
@@ -21,6 +21,8 @@ import (
 	"encoding/hex"
 	"fmt"
 	"github.com/codenotary/immudb/pkg/api/schema"
+	pserr "github.com/codenotary/immudb/pkg/pgsql/errors"
+	"github.com/codenotary/immudb/pkg/pgsql/server/pgmeta"
 	"strconv"
 )
 
@@ -75,6 +77,33 @@ func buildNamedParams(paramsType []*schema.Column, paramsVal []interface{}) ([]*
 	return schema.EncodeParams(pMap)
 }
 
+// applyParamTypeHints honors the parameter types specified by the client in the Parse message. Object IDs are positional,
+// a zero leaves the type unspecified. Hints may only define a type the server could not infer or agree with the inferred one.
+func applyParamTypeHints(paramCols []*schema.Column, objectIDs []int32) error {
+	if len(objectIDs) > len(paramCols) {
+		return pserr.ErrParamTypeHintsExceeded
+	}
+
+	for i, oid := range objectIDs {
+		if oid == 0 {
+			continue
+		}
+
+		hintedType, ok := pgmeta.PgOidTypeMap[oid]
+		if !ok {
+			return fmt.Errorf("%w (oid %d for parameter %s)", pserr.ErrUnsupportedParamTypeHint, oid, paramCols[i].Name)
+		}
+
+		if paramCols[i].Type != "ANY" && paramCols[i].Type != hintedType {
+			return fmt.Errorf("%w (parameter %s is %s, specified oid %d)", pserr.ErrIncompatibleParamTypeHint, paramCols[i].Name, paramCols[i].Type, oid)
+		}
+
+		paramCols[i].Type = hintedType
+	}
+
+	return nil
+}
+
 func getInt64(p []byte) (int64, error) {
 	switch len(p) {
 	case 8:
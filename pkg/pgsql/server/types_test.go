@@ -19,6 +19,7 @@ package server
 import (
 	"encoding/binary"
 	"github.com/codenotary/immudb/pkg/api/schema"
+	pserr "github.com/codenotary/immudb/pkg/pgsql/errors"
 	"github.com/stretchr/testify/require"
 	"testing"
 )
@@ -90,3 +91,29 @@ func Test_buildNamedParams(t *testing.T) {
 	_, err = buildNamedParams(cols, pt)
 	require.Error(t, err)
 }
+
+func Test_applyParamTypeHints(t *testing.T) {
+	cols := []*schema.Column{
+		{Name: "param1", Type: "INTEGER"},
+		{Name: "param2", Type: "VARCHAR"},
+		{Name: "param3", Type: "ANY"},
+	}
+
+	err := applyParamTypeHints(cols, []int32{21, 0, 17})
+	require.NoError(t, err)
+	require.Equal(t, "INTEGER", cols[0].Type)
+	require.Equal(t, "VARCHAR", cols[1].Type)
+	require.Equal(t, "BLOB", cols[2].Type)
+
+	err = applyParamTypeHints(cols, nil)
+	require.NoError(t, err)
+
+	err = applyParamTypeHints(cols, []int32{25})
+	require.ErrorIs(t, err, pserr.ErrIncompatibleParamTypeHint)
+
+	err = applyParamTypeHints(cols, []int32{0, 701})
+	require.ErrorIs(t, err, pserr.ErrUnsupportedParamTypeHint)
+
+	err = applyParamTypeHints(cols, []int32{0, 0, 0, 0})
+	require.ErrorIs(t, err, pserr.ErrParamTypeHintsExceeded)
+}
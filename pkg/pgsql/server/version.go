@@ -1,7 +1,10 @@
 package server
 
 import (
+	"fmt"
+
 	"github.com/codenotary/immudb/pkg/api/schema"
+	pserr "github.com/codenotary/immudb/pkg/pgsql/errors"
 	bm "github.com/codenotary/immudb/pkg/pgsql/server/bmessages"
 	"github.com/codenotary/immudb/pkg/pgsql/server/pgmeta"
 )
@@ -21,3 +24,28 @@ func (s *session) writeVersionInfo() error {
 
 	return nil
 }
+
+// writeSetting writes the value of a run-time parameter, as changed with SET in the session or its default one
+func (s *session) writeSetting(name, colName string) error {
+	value, ok := s.settings[name]
+	if !ok {
+		value, ok = pgmeta.PgSettings[name]
+	}
+	if !ok {
+		return fmt.Errorf("%w \"%s\"", pserr.ErrUnknownSetting, name)
+	}
+
+	cols := []*schema.Column{{Name: colName, Type: "VARCHAR"}}
+	if _, err := s.writeMessage(bm.RowDescription(cols, nil)); err != nil {
+		return err
+	}
+	rows := []*schema.Row{{
+		Columns: []string{colName},
+		Values:  []*schema.SQLValue{{Value: &schema.SQLValue_S{S: value}}},
+	}}
+	if _, err := s.writeMessage(bm.DataRow(rows, len(cols), nil)); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stdlib
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strconv"
+
+	embeddedsql "github.com/codenotary/immudb/embedded/sql"
+)
+
+// OpenEngineDB returns a *sql.DB running statements directly against the embedded engine, over the given database.
+// Every connection of the pool uses its own engine session, thus the database selected by the engine is not changed
+func OpenEngineDB(engine *embeddedsql.Engine, dbName string) *sql.DB {
+	return sql.OpenDB(&engineConnector{engine: engine, dbName: dbName})
+}
+
+type engineConnector struct {
+	engine *embeddedsql.Engine
+	dbName string
+}
+
+// Connect implement driver.Connector interface
+func (c *engineConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	session, err := c.engine.SessionWithDatabase(c.dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EngineConn{session: session}, nil
+}
+
+func (c *engineConnector) Driver() driver.Driver {
+	return engineDriver{}
+}
+
+// engineDriver is only returned by the connector, connections to the engine can not be opened by name
+type engineDriver struct{}
+
+func (d engineDriver) Open(name string) (driver.Conn, error) {
+	return nil, ErrNotImplemented
+}
+
+// EngineConn is a driver.Conn running statements within a session of the embedded engine
+type EngineConn struct {
+	session *embeddedsql.Session
+}
+
+func (c *EngineConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, ErrNotImplemented
+}
+
+func (c *EngineConn) Close() error {
+	return c.session.Close()
+}
+
+func (c *EngineConn) Begin() (driver.Tx, error) {
+	return nil, ErrNotImplemented
+}
+
+func (c *EngineConn) ExecContext(ctx context.Context, query string, argsV []driver.NamedValue) (driver.Result, error) {
+	params, err := engineParams(argsV)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := c.session.ExecStmt(query, params, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return engineResult{summary}, nil
+}
+
+func (c *EngineConn) QueryContext(ctx context.Context, query string, argsV []driver.NamedValue) (driver.Rows, error) {
+	params, err := engineParams(argsV)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := c.session.QueryStmt(query, params, true)
+	if err != nil {
+		return nil, err
+	}
+
+	cols, err := reader.Columns()
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+
+	return &EngineRows{reader: reader, cols: cols}, nil
+}
+
+// engineParams maps positional arguments to the params referenced as $1, $2... and named ones to @name
+func engineParams(argsV []driver.NamedValue) (map[string]interface{}, error) {
+	params := make(map[string]interface{}, len(argsV))
+
+	for _, nv := range argsV {
+		if _, isFloat := nv.Value.(float64); isFloat {
+			return nil, ErrFloatValuesNotSupported
+		}
+
+		name := nv.Name
+		if name == "" {
+			name = "param" + strconv.Itoa(nv.Ordinal)
+		}
+
+		params[name] = nv.Value
+	}
+
+	return params, nil
+}
+
+// EngineRows is a driver.Rows reading the rows of an embedded engine row reader
+type EngineRows struct {
+	reader embeddedsql.RowReader
+	cols   []embeddedsql.ColDescriptor
+}
+
+func (r *EngineRows) Columns() []string {
+	names := make([]string, len(r.cols))
+
+	for i, col := range r.cols {
+		names[i] = col.Column
+	}
+
+	return names
+}
+
+// ColumnTypeDatabaseTypeName returns the SQL type of the column e.g. INTEGER or VARCHAR
+func (r *EngineRows) ColumnTypeDatabaseTypeName(index int) string {
+	if index < 0 || index >= len(r.cols) {
+		return ""
+	}
+
+	return string(r.cols[index].Type)
+}
+
+func (r *EngineRows) Close() error {
+	return r.reader.Close()
+}
+
+func (r *EngineRows) Next(dest []driver.Value) error {
+	row, err := r.reader.Read()
+	if err == embeddedsql.ErrNoMoreRows {
+		return io.EOF
+	}
+	if err != nil {
+		return err
+	}
+
+	for i, col := range r.cols {
+		dest[i] = row.Values[col.Selector()].Value()
+	}
+
+	return nil
+}
+
+// engineResult implements Result for statements run against the embedded engine
+type engineResult struct {
+	summary *embeddedsql.ExecSummary
+}
+
+func (r engineResult) LastInsertId() (int64, error) {
+	if len(r.summary.LastInsertedPKs) == 1 {
+		for _, v := range r.summary.LastInsertedPKs {
+			return v, nil
+		}
+	}
+
+	return 0, ErrNoLastInsertId
+}
+
+func (r engineResult) RowsAffected() (int64, error) {
+	return int64(r.summary.UpdatedRows), nil
+}
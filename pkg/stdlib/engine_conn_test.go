@@ -0,0 +1,134 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stdlib
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	embeddedsql "github.com/codenotary/immudb/embedded/sql"
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenEngineDB(t *testing.T) {
+	catalogStore, err := store.Open("engine_conn_catalog", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("engine_conn_catalog")
+
+	dataStore, err := store.Open("engine_conn_data", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("engine_conn_data")
+
+	engine, err := embeddedsql.NewEngine(catalogStore, dataStore, embeddedsql.DefaultOptions().WithPrefix([]byte("SQL")))
+	require.NoError(t, err)
+	defer engine.Close()
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	db := OpenEngineDB(engine, "db1")
+	defer db.Close()
+
+	ctx := context.Background()
+
+	_, err = db.ExecContext(ctx, "CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR, active BOOLEAN, payload BLOB, ts TIMESTAMP, PRIMARY KEY id)")
+	require.NoError(t, err)
+
+	ts := time.Date(2021, 11, 3, 10, 30, 0, 0, time.UTC)
+
+	res, err := db.ExecContext(ctx, "INSERT INTO table1 (title, active, payload, ts) VALUES ($1, $2, $3, $4)", "title1", true, []byte{1, 2}, ts)
+	require.NoError(t, err)
+
+	id, err := res.LastInsertId()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), id)
+
+	_, err = db.ExecContext(ctx, "INSERT INTO table1 (title, active) VALUES (@title, @active)", sql.Named("title", "title2"), sql.Named("active", false))
+	require.NoError(t, err)
+
+	_, err = db.ExecContext(ctx, "INSERT INTO table1 (title) VALUES ($1)", 1.5)
+	require.ErrorIs(t, err, ErrFloatValuesNotSupported)
+
+	res, err = db.ExecContext(ctx, "UPDATE table1 SET active = NOT active")
+	require.NoError(t, err)
+
+	updated, err := res.RowsAffected()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), updated)
+
+	_, err = res.LastInsertId()
+	require.ErrorIs(t, err, ErrNoLastInsertId)
+
+	rows, err := db.QueryContext(ctx, "SELECT id, title, active, payload, ts FROM table1 WHERE id >= $1", 1)
+	require.NoError(t, err)
+
+	cols, err := rows.Columns()
+	require.NoError(t, err)
+	require.Equal(t, []string{"id", "title", "active", "payload", "ts"}, cols)
+
+	colTypes, err := rows.ColumnTypes()
+	require.NoError(t, err)
+	require.Equal(t, "INTEGER", colTypes[0].DatabaseTypeName())
+	require.Equal(t, "VARCHAR", colTypes[1].DatabaseTypeName())
+
+	var title string
+	var active bool
+	var payload []byte
+	var ts1 sql.NullTime
+
+	require.True(t, rows.Next())
+	require.NoError(t, rows.Scan(&id, &title, &active, &payload, &ts1))
+	require.Equal(t, int64(1), id)
+	require.Equal(t, "title1", title)
+	require.False(t, active)
+	require.Equal(t, []byte{1, 2}, payload)
+	require.Equal(t, ts, ts1.Time)
+
+	require.True(t, rows.Next())
+	require.NoError(t, rows.Scan(&id, &title, &active, &payload, &ts1))
+	require.Equal(t, int64(2), id)
+	require.Equal(t, "title2", title)
+	require.True(t, active)
+	require.Nil(t, payload)
+	require.False(t, ts1.Valid)
+
+	require.False(t, rows.Next())
+	require.NoError(t, rows.Err())
+	require.NoError(t, rows.Close())
+
+	var count int64
+	err = db.QueryRowContext(ctx, "SELECT COUNT() AS c FROM table1").Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	_, err = db.QueryContext(ctx, "SELECT id FROM table2")
+	require.ErrorIs(t, err, embeddedsql.ErrTableDoesNotExist)
+
+	// the database selected by the engine is not changed by the connections
+	_, err = engine.DatabaseInUse()
+	require.ErrorIs(t, err, embeddedsql.ErrNoDatabaseSelected)
+
+	_, err = OpenEngineDB(engine, "db2").Conn(ctx)
+	require.ErrorIs(t, err, embeddedsql.ErrDatabaseDoesNotExist)
+
+	_, err = db.Driver().Open("db1")
+	require.ErrorIs(t, err, ErrNotImplemented)
+}
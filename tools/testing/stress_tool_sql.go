@@ -214,6 +214,27 @@ func compactor(ctx context.Context, client immuclient.ImmuClient, c cfg, wg *syn
 	wg.Done()
 }
 
+// tables created by previous versions of the tool hold unix nanos in an INTEGER ts column,
+// those holding more rows than a single transaction can rewrite must be recreated instead
+func migrateTimestamps(ctx context.Context, client immuclient.ImmuClient) error {
+	res, err := client.DescribeTable(ctx, "entries")
+	if err != nil {
+		return err
+	}
+
+	for _, row := range res.Rows {
+		if row.Values[0].GetS() != "ts" || row.Values[1].GetS() != "INTEGER" {
+			continue
+		}
+
+		log.Printf("Converting unix nanos of existing entries into timestamps\r\n")
+		_, err = client.SQLExec(ctx, "ALTER TABLE entries ALTER COLUMN ts TYPE TIMESTAMP;", nil)
+		return err
+	}
+
+	return nil
+}
+
 func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 	c := parseConfig()
@@ -222,7 +243,12 @@ func main() {
 	client, ctx := connect(c)
 
 	log.Printf("Creating tables\r\n")
-	_, err := client.SQLExec(ctx, "CREATE TABLE IF NOT EXISTS entries (id INTEGER, value BLOB, ts INTEGER, PRIMARY KEY id);", nil)
+	_, err := client.SQLExec(ctx, "CREATE TABLE IF NOT EXISTS entries (id INTEGER, value BLOB, ts TIMESTAMP, PRIMARY KEY id);", nil)
+	if err != nil {
+		panic(err)
+	}
+
+	err = migrateTimestamps(ctx, client)
 	if err != nil {
 		panic(err)
 	}